@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
+)
+
+// runBackup creates a backup and prints where it was written
+func runBackup(args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	fs.StringVar(&cfg.Backup.Directory, "dir", cfg.Backup.Directory, "directory to write the backup to")
+	fs.StringVar(&cfg.Backup.Method, "method", cfg.Backup.Method, "backup method: auto, pg_dump or snapshot")
+	fs.Parse(args)
+
+	if err := openDatabase(); err != nil {
+		return err
+	}
+	defer database.CloseDatabase()
+
+	backup, err := services.NewBackupService(cfg.Backup).RunBackup("cli")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Backup #%d (%s) written to %s (%d bytes)\n", backup.ID, backup.Method, backup.FilePath, backup.SizeBytes)
+	for table, count := range backup.RowCounts {
+		fmt.Printf("  %-16s %d rows\n", table, count)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"covid19-kms/internal/config"
+)
+
+var configCommands = []command{
+	{name: "check", description: "Validate the configuration and list invalid settings", run: runConfigCheck},
+}
+
+// runConfigCheck validates the configuration
+func runConfigCheck(args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	err = cfg.Validate()
+	var invalid config.ValidationErrors
+	if errors.As(err, &invalid) {
+		fmt.Printf("Found %d invalid or missing settings:\n", len(invalid))
+		for _, setting := range invalid {
+			fmt.Printf("  %-32s %s\n", setting.EnvVar, setting.Message)
+		}
+		return fmt.Errorf("configuration is invalid")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Configuration is valid")
+	return nil
+}
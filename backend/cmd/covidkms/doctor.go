@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"covid19-kms/internal/etl"
+)
+
+// runDoctor checks that the configuration, database and source APIs are
+// ready for the first run and prints a pass/fail report
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	skipAPIs := fs.Bool("skip-apis", false, "skip the source API probes, which spend one request of each source's quota")
+	timeout := fs.Duration("timeout", 15*time.Second, "timeout of each API probe")
+	output := fs.String("o", "", "also write the report to this file")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	verbose := fs.Bool("v", false, "show database and client logs")
+	fs.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	report := etl.RunSelfTest(context.Background(), etl.SelfTestOptions{
+		SkipExternalAPIs: *skipAPIs,
+		Timeout:          *timeout,
+	})
+
+	writers := []io.Writer{os.Stdout}
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %v", err)
+		}
+		defer file.Close()
+		writers = append(writers, file)
+	}
+	w := io.MultiWriter(writers...)
+
+	if *asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+	} else {
+		report.WriteText(w)
+	}
+
+	if report.Status == etl.SelfTestFail {
+		return fmt.Errorf("self-test found failing checks")
+	}
+	return nil
+}
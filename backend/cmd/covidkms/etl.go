@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/etl"
+)
+
+var etlCommands = []command{
+	{name: "run", description: "Extract, transform and load the configured sources once", run: runETL},
+	{name: "backfill", description: "Backfill archived articles by crawling the outlet sitemaps", run: runBackfill},
+}
+
+// runETL runs the pipeline for a project, optionally with its own source
+// selection. Interrupting the command cancels the run like
+// /api/etl/runs/{batch_id}/cancel.
+func runETL(args []string) error {
+	fs := flag.NewFlagSet("etl run", flag.ExitOnError)
+	project := fs.String("project", database.DefaultProject, "project whose settings the run uses and whose records it loads")
+	sources := fs.String("sources", "", "comma-separated sources to extract instead of the project's")
+	skip := fs.String("skip", "", "comma-separated sources to leave out of this run")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	opts := etl.PipelineOptions{Sources: splitList(*sources), Skip: splitList(*skip)}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+	if err := migrateDatabase(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result := etl.NewETLOrchestrator().RunETLPipelineWithOptions(ctx, *project, opts)
+	return printETLResult(result, *asJSON)
+}
+
+// runBackfill crawls the outlet sitemaps in CRAWLER_SITEMAPS for archived
+// articles and loads them into a project; omitted flags fall back to the
+// CRAWLER_* settings
+func runBackfill(args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	opts := etl.NewCrawlOptions(cfg.ETL.Crawler)
+
+	fs := flag.NewFlagSet("etl backfill", flag.ExitOnError)
+	project := fs.String("project", database.DefaultProject, "project to load the articles into")
+	outlets := fs.String("outlet", "", "comma-separated outlets of CRAWLER_SITEMAPS to crawl (default all)")
+	from := fs.String("from", "", "earliest publication day (YYYY-MM-DD, default CRAWLER_FROM)")
+	to := fs.String("to", "", "latest publication day (YYYY-MM-DD, default CRAWLER_TO)")
+	fs.IntVar(&opts.MaxArticles, "max", opts.MaxArticles, "maximum articles to fetch")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	for _, outlet := range splitList(*outlets) {
+		if _, ok := cfg.ETL.Crawler.Sitemaps[outlet]; !ok {
+			return fmt.Errorf("outlet %q has no sitemap in CRAWLER_SITEMAPS", outlet)
+		}
+		opts.Outlets = append(opts.Outlets, outlet)
+	}
+	if *from != "" {
+		if opts.From, err = time.Parse("2006-01-02", *from); err != nil {
+			return fmt.Errorf("invalid -from %q: %v", *from, err)
+		}
+	}
+	if *to != "" {
+		if opts.To, err = time.Parse("2006-01-02", *to); err != nil {
+			return fmt.Errorf("invalid -to %q: %v", *to, err)
+		}
+	}
+	if opts.To.Before(opts.From) {
+		return fmt.Errorf("-to must not be before -from")
+	}
+	if opts.MaxArticles <= 0 {
+		return fmt.Errorf("-max must be greater than 0")
+	}
+
+	if err := migrateDatabase(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result := etl.NewETLOrchestrator().RunCrawlBackfill(ctx, *project, opts)
+	return printETLResult(result, *asJSON)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// migrateDatabase migrates the database to the current schema. The pipeline
+// opens its own connection, so this one is closed again.
+func migrateDatabase() error {
+	defer database.CloseDatabase()
+	return openDatabase()
+}
+
+// printETLResult prints the outcome of a run and fails unless it succeeded
+func printETLResult(result *etl.ETLResult, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("failed to write result: %v", err)
+		}
+	} else {
+		fmt.Printf("%s (batch %s, %s)\n", result.Message, result.BatchID, result.PipelineDuration)
+		for _, source := range result.Extraction {
+			fmt.Printf("  %-16s %-8s %d records in %s\n", source.Source, source.Status, source.RecordCount, source.Duration)
+		}
+		if result.Loading != nil {
+			fmt.Printf("  loaded %d records (%d updated)\n", result.Loading.RecordsCount, result.Loading.UpdatedCount)
+		}
+	}
+
+	if result.Status == "error" || result.Status == "cancelled" {
+		return fmt.Errorf("run %s: %s", result.Status, result.Error)
+	}
+	return nil
+}
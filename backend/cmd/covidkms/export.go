@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/services"
+)
+
+// runExport writes the matching processed records of a project to an export
+// file in EXPORT_DIR, like POST /api/export without the background job
+func runExport(args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", services.ExportFormatCSV, "file format: csv, json or parquet")
+	fs.StringVar(&cfg.Export.Directory, "dir", cfg.Export.Directory, "directory to write the export to")
+	fs.IntVar(&cfg.Export.MaxRecords, "max", cfg.Export.MaxRecords, "maximum records to export")
+	filters := database.ProcessedDataFilter{}
+	fs.StringVar(&filters.Project, "project", database.DefaultProject, "project whose records are exported")
+	fs.StringVar(&filters.Query, "q", "", "full-text query the records must match")
+	fs.StringVar(&filters.Source, "source", "", "export only the records of this source")
+	fs.StringVar(&filters.Sentiment, "sentiment", "", "export only positive, negative or neutral records")
+	fs.StringVar(&filters.Campaign, "campaign", "", "export only the records of this campaign")
+	fs.StringVar(&filters.Hashtag, "hashtag", "", "export only the Instagram records of this hashtag")
+	from := fs.String("from", "", "earliest publication day (YYYY-MM-DD)")
+	to := fs.String("to", "", "latest publication day (YYYY-MM-DD), inclusive")
+	excludeRestricted := fs.Bool("exclude-restricted", false, "drop records whose license prohibits redistribution")
+	verbose := fs.Bool("v", false, "show database and export logs")
+	fs.Parse(args)
+
+	if *from != "" {
+		day, err := time.Parse("2006-01-02", *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from %q: %v", *from, err)
+		}
+		filters.From = &day
+	}
+	if *to != "" {
+		day, err := time.Parse("2006-01-02", *to)
+		if err != nil {
+			return fmt.Errorf("invalid -to %q: %v", *to, err)
+		}
+		end := day.Add(24*time.Hour - time.Nanosecond)
+		filters.To = &end
+	}
+	if *excludeRestricted {
+		filters.ExcludeLicenses = cfg.Terms.NoRedistribution
+	}
+
+	if err := openDatabase(); err != nil {
+		return err
+	}
+	defer database.CloseDatabase()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	job, err := services.NewExportService(cfg.Export).RunExport(strings.ToLower(*format), filters)
+	if err != nil {
+		return err
+	}
+	if job.Status != "completed" {
+		return fmt.Errorf("export %s failed: %s", job.ID, job.ErrorMessage)
+	}
+
+	fmt.Printf("Exported %d records to %s\n", job.RecordCount, job.FilePath)
+	return nil
+}
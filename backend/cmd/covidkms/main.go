@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/api"
+	"covid19-kms/internal/config"
+)
+
+// command is a covidkms subcommand. A command with subcommands runs the one
+// named by its first argument, e.g. "covidkms db migrate".
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+	subcommands []command
+	hidden      bool // former name kept for scripts, not listed in the usage
+}
+
+var dbCommands = []command{
+	{name: "migrate", description: "Create the schema and apply pending migrations", run: runMigrate},
+	{name: "backup", description: "Back up the raw and processed records (content only, no users, projects or keys)", run: runBackup},
+	{name: "restore", description: "Load a backup into an empty database", run: runRestore},
+	{name: "seed", description: "Load the bundled demo dataset into a project", run: runSeed},
+	{name: "prune", description: "Archive or delete raw data older than the retention age", run: runPrune},
+}
+
+var commands = []command{
+	{name: "serve", description: "Run the API server and the scheduler", run: runServe},
+	{name: "etl", description: "Run the pipeline or backfill archived articles", subcommands: etlCommands},
+	{name: "db", description: "Migrate, back up, restore, seed or prune the database", subcommands: dbCommands},
+	{name: "sentiment", description: "Re-score stored sentiment or analyze texts", subcommands: sentimentCommands},
+	{name: "export", description: "Export processed records to a CSV, JSON or Parquet file", run: runExport},
+	{name: "config", description: "Check the configuration", subcommands: configCommands},
+	{name: "doctor", description: "Check configuration, schema and API keys before the first run", run: runDoctor},
+
+	// The db commands were top-level commands before
+	{name: "migrate", run: runMigrate, hidden: true},
+	{name: "backup", run: runBackup, hidden: true},
+	{name: "restore", run: runRestore, hidden: true},
+	{name: "seed", run: runSeed, hidden: true},
+}
+
+func main() {
+	if len(os.Args) < 2 || isHelp(os.Args[1]) {
+		printUsage("covidkms", commands)
+		return
+	}
+
+	// Load environment variables from .env file
+	if err := config.LoadDefaultEnv(); err != nil {
+		log.Printf("⚠️ Warning: Failed to load .env file: %v", err)
+	}
+
+	os.Exit(dispatch("covidkms", commands, os.Args[1:]))
+}
+
+// dispatch runs the command named by args[0] with the remaining arguments
+// and returns the exit code
+func dispatch(path string, cmds []command, args []string) int {
+	if len(args) == 0 || isHelp(args[0]) {
+		printUsage(path, cmds)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	for _, cmd := range cmds {
+		if cmd.name != args[0] {
+			continue
+		}
+		if len(cmd.subcommands) > 0 {
+			return dispatch(path+" "+cmd.name, cmd.subcommands, args[1:])
+		}
+		if err := cmd.run(args[1:]); err != nil {
+			log.Printf("❌ %s %s failed: %v", path, cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", path+" "+args[0])
+	printUsage(path, cmds)
+	return 2
+}
+
+// isHelp reports whether arg asks for the usage
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "--help"
+}
+
+// printUsage lists the listed commands of path
+func printUsage(path string, cmds []command) {
+	fmt.Printf("Usage: %s <command> [flags]\n", path)
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, cmd := range cmds {
+		if !cmd.hidden {
+			fmt.Printf("  %-12s %s\n", cmd.name, cmd.description)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Run '%s <command> -h' for command flags.\n", path)
+}
+
+// loadConfig loads the configuration and refuses settings that would
+// otherwise silently fall back, like the server does at startup
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var invalid config.ValidationErrors
+		if errors.As(err, &invalid) {
+			for _, setting := range invalid {
+				log.Printf("❌ %s", setting)
+			}
+		}
+		return nil, fmt.Errorf("invalid configuration, fix the settings above or run 'covidkms config check'")
+	}
+	return cfg, nil
+}
+
+// openDatabase connects to the database and migrates it to the current schema
+func openDatabase() error {
+	if err := database.InitDatabase(); err != nil {
+		return err
+	}
+	return database.Migrate()
+}
+
+// runServe runs the API server until it stops
+func runServe(args []string) error {
+	return api.RunServer()
+}
+
+// runMigrate migrates the database and reports the schema version
+func runMigrate(args []string) error {
+	if err := openDatabase(); err != nil {
+		return err
+	}
+	defer database.CloseDatabase()
+
+	version, err := database.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database schema is at version %d\n", version)
+	return nil
+}
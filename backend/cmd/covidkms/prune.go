@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/services"
+)
+
+// runPrune archives or deletes the raw data older than
+// RETENTION_RAW_DATA_MAX_AGE, like POST /api/admin/retention
+func runPrune(args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("db prune", flag.ExitOnError)
+	fs.DurationVar(&cfg.Retention.MaxAge, "max-age", cfg.Retention.MaxAge, "prune raw data extracted longer ago than this, e.g. 2160h")
+	fs.StringVar(&cfg.Retention.Mode, "mode", cfg.Retention.Mode, "archive or delete")
+	fs.StringVar(&cfg.Retention.ArchiveDir, "dir", cfg.Retention.ArchiveDir, "directory to write the archive to")
+	dryRun := fs.Bool("dry-run", false, "only report what would be pruned")
+	fs.Parse(args)
+
+	if err := openDatabase(); err != nil {
+		return err
+	}
+	defer database.CloseDatabase()
+
+	run, err := services.NewRetentionService(cfg.Retention, database.NewPostgresStore()).RunRetention("cli", *dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "Pruned"
+	if run.DryRun {
+		verb = "Would prune"
+	}
+	fmt.Printf("%s %d raw data rows extracted before %s (%d bytes)\n", verb, run.RowsPruned, run.Cutoff.Format("2006-01-02 15:04"), run.BytesReclaimed)
+	if run.ArchivePath != "" {
+		fmt.Printf("  archived to %s\n", run.ArchivePath)
+	}
+	fmt.Printf("  raw_data size %d bytes before, %d bytes after\n", run.TableBytesBefore, run.TableBytesAfter)
+
+	return nil
+}
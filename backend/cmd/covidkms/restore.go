@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
+)
+
+// runRestore loads a backup file into an empty database
+func runRestore(args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	file := fs.String("file", "", "backup file to restore (.dump or .jsonl.gz)")
+	fs.Parse(args)
+
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+
+	if err := database.InitDatabase(); err != nil {
+		return err
+	}
+	defer database.CloseDatabase()
+
+	counts, err := services.NewBackupService(cfg.Backup).RestoreBackup(*file)
+	if err != nil {
+		return err
+	}
+
+	version, err := database.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %s (schema version %d)\n", *file, version)
+	for table, count := range counts {
+		fmt.Printf("  %-16s %d rows\n", table, count)
+	}
+
+	return nil
+}
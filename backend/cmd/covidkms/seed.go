@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/etl"
+)
+
+// runSeed loads the bundled demo dataset into a project, in the SQLite file
+// with DB_TYPE=sqlite
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	project := fs.String("project", database.DefaultProject, "project to load the demo records into")
+	records := fs.Int("records", 3000, "number of demo records to generate")
+	end := fs.String("end", "", "day of the newest records (YYYY-MM-DD, default today)")
+	seed := fs.Int64("seed", 1, "random seed; the same seed and end day reproduce the same data")
+	verbose := fs.Bool("v", false, "show transformer and loader logs")
+	fs.Parse(args)
+
+	opts := etl.SeedOptions{ProjectID: *project, Records: *records, Seed: *seed}
+	if *end != "" {
+		parsed, err := time.Parse("2006-01-02", *end)
+		if err != nil {
+			return fmt.Errorf("invalid -end %q: %v", *end, err)
+		}
+		opts.End = parsed
+	}
+
+	// Demo data needs no API keys, so the configuration is not validated
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Database.Type == "sqlite" {
+		store, err := database.OpenSQLiteStore(cfg.GetDatabaseDSN())
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		opts.Store = store
+	} else {
+		if err := openDatabase(); err != nil {
+			return err
+		}
+		defer database.CloseDatabase()
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	result, err := etl.SeedDemoData(opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Seeded project %s with demo data from %s to %s (batch %s)\n", *project, result.From, result.To, result.BatchID)
+	if result.Replaced > 0 {
+		fmt.Printf("  replaced %d earlier demo records\n", result.Replaced)
+	}
+	for source, count := range result.Records {
+		fmt.Printf("  %-16s %d records\n", source, count)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/services"
+)
+
+var sentimentCommands = []command{
+	{name: "clean", description: "Re-score the sentiment of stored records", run: runSentimentClean},
+	{name: "analyze", description: "Print the sentiment of the given texts", run: runSentimentAnalyze},
+}
+
+// runSentimentClean re-scores the sentiment of a project's records, like
+// POST /api/etl/cleanup/sentiment. An interrupted cleanup of the same scope
+// resumes from its checkpoint unless -restart is given.
+func runSentimentClean(args []string) error {
+	fs := flag.NewFlagSet("sentiment clean", flag.ExitOnError)
+	project := fs.String("project", database.DefaultProject, "project whose records are re-scored")
+	source := fs.String("source", "", "re-score only the records of this source")
+	from := fs.String("from", "", "re-score only records processed from this day (YYYY-MM-DD, with -to)")
+	to := fs.String("to", "", "re-score only records processed until this day (YYYY-MM-DD, with -from)")
+	stale := fs.Bool("stale", false, "re-score only records scored by an outdated analyzer version")
+	restart := fs.Bool("restart", false, "discard the checkpoint of an interrupted cleanup instead of resuming it")
+	fs.Parse(args)
+
+	if (*from == "") != (*to == "") {
+		return fmt.Errorf("-from and -to must be given together")
+	}
+
+	if err := openDatabase(); err != nil {
+		return err
+	}
+	defer database.CloseDatabase()
+
+	cleanup := services.NewSentimentCleanupService(database.DB).ForProject(*project)
+	if *restart {
+		cleanup.Restart()
+	}
+
+	var result *services.CleanupResult
+	switch {
+	case *stale:
+		result = cleanup.CleanStaleSentiments()
+	case *source != "":
+		result = cleanup.CleanSentimentBySource(*source)
+	case *from != "":
+		startDate, err := time.Parse("2006-01-02", *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from %q: %v", *from, err)
+		}
+		endDate, err := time.Parse("2006-01-02", *to)
+		if err != nil {
+			return fmt.Errorf("invalid -to %q: %v", *to, err)
+		}
+		result = cleanup.CleanSentimentByDateRange(startDate, endDate)
+	default:
+		result = cleanup.CleanAllSentiments()
+	}
+
+	fmt.Printf("Sentiment cleanup %s: %d of %d records processed, %d updated, %d failed in %s\n", result.Status,
+		result.ProcessedRecords, result.TotalRecords, result.UpdatedRecords, result.ErrorRecords, result.ProcessingTime.Round(time.Millisecond))
+	if result.ResumedFromID > 0 {
+		fmt.Printf("  resumed after record %d\n", result.ResumedFromID)
+	}
+	for _, message := range result.Errors {
+		fmt.Printf("  %s\n", message)
+	}
+
+	if result.Status != "completed" {
+		return fmt.Errorf("cleanup %s", result.Status)
+	}
+	return nil
+}
+
+// runSentimentAnalyze prints the sentiment the analyzer gives each argument
+func runSentimentAnalyze(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(`usage: covidkms sentiment analyze "text" ["text" ...]`)
+	}
+
+	analyzer := services.NewSentimentAnalyzer()
+	for _, text := range args {
+		result := analyzer.AnalyzeSentiment(text)
+		fmt.Printf("%s\n  %s (score %.2f, confidence %.2f)", text, result.Category, result.Score, result.Confidence)
+		if len(result.Keywords) > 0 {
+			fmt.Printf(", keywords: %s", strings.Join(result.Keywords, ", "))
+		}
+		fmt.Println()
+	}
+	return nil
+}
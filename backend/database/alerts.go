@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecordAlert stores an alert that was emailed, or failed to be
+func RecordAlert(alert *Alert) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO alerts (project_id, kind, alert_key, subject, body, error)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING id, sent_at
+	`
+
+	err := DB.QueryRow(sqlQuery, projectIDOrDefault(alert.ProjectID), alert.Kind, alert.Key, alert.Subject, alert.Body, alert.Error).Scan(&alert.ID, &alert.SentAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert alert: %v", err)
+	}
+
+	return nil
+}
+
+// AlertSentWithin reports whether an alert with the given key was emailed
+// successfully within the last window
+func AlertSentWithin(key string, window time.Duration) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var sent bool
+	err := DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM alerts
+			WHERE alert_key = $1 AND error IS NULL AND sent_at > NOW() - $2 * INTERVAL '1 second'
+		)
+	`, key, window.Seconds()).Scan(&sent)
+	if err != nil {
+		return false, fmt.Errorf("failed to check recent alerts: %v", err)
+	}
+
+	return sent, nil
+}
+
+// CountZeroRecordRuns counts how many of the last finished pipeline runs of a
+// project extracted no records from a source in any of their campaigns. Runs
+// that skipped the source for its health or budget do not count.
+func CountZeroRecordRuns(projectID, source string, runs int) (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT COUNT(*)
+		FROM (
+			SELECT metrics FROM pipeline_runs
+			WHERE project_id = $1 AND kind = 'pipeline' AND status <> 'running'
+			ORDER BY id DESC
+			LIMIT $3
+		) r
+		WHERE EXISTS (
+			SELECT 1 FROM jsonb_array_elements(COALESCE(r.metrics->'extraction', '[]'::jsonb)) e
+			WHERE e->>'source' = $2 AND e->>'status' <> 'skipped'
+		) AND NOT EXISTS (
+			SELECT 1 FROM jsonb_array_elements(COALESCE(r.metrics->'extraction', '[]'::jsonb)) e
+			WHERE e->>'source' = $2 AND (e->>'record_count')::int > 0
+		)
+	`
+
+	var count int
+	if err := DB.QueryRow(sqlQuery, projectIDOrDefault(projectID), source, runs).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count zero record runs: %v", err)
+	}
+
+	return count, nil
+}
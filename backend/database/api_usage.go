@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// IncrementAPIUsage counts one API request of a source on the UTC day of at
+func IncrementAPIUsage(source string, at time.Time) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO api_usage (source, day, calls, updated_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (source, day) DO UPDATE SET calls = api_usage.calls + 1, updated_at = NOW()
+	`
+
+	if _, err := DB.Exec(sqlQuery, source, at.UTC().Format("2006-01-02")); err != nil {
+		return fmt.Errorf("failed to count API request: %v", err)
+	}
+
+	return nil
+}
+
+// GetAPIUsage returns how many API requests of a source were sent on the UTC
+// day of at and in its calendar month up to that day
+func GetAPIUsage(source string, at time.Time) (day, month int, err error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	at = at.UTC()
+	monthStart := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	sqlQuery := `
+		SELECT COALESCE(SUM(calls) FILTER (WHERE day = $2), 0), COALESCE(SUM(calls), 0)
+		FROM api_usage
+		WHERE source = $1 AND day >= $3 AND day <= $2
+	`
+
+	err = DB.QueryRow(sqlQuery, source, at.Format("2006-01-02"), monthStart.Format("2006-01-02")).Scan(&day, &month)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query API usage: %v", err)
+	}
+
+	return day, month, nil
+}
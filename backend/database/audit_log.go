@@ -0,0 +1,93 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InsertAuditEntry appends an entry to the audit log
+func InsertAuditEntry(entry *AuditEntry) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO audit_log (actor, project_id, action, method, path, query, status_code, remote_addr)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := DB.QueryRow(sqlQuery, entry.Actor, projectIDOrDefault(entry.ProjectID), entry.Action, entry.Method, entry.Path, entry.Query,
+		entry.StatusCode, entry.RemoteAddr).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %v", err)
+	}
+
+	return nil
+}
+
+// GetAuditEntries returns audit entries newest first, optionally filtered by
+// project, action prefix (e.g. "cleanup") and exact actor
+func GetAuditEntries(projectID, action, actor string, limit int) ([]AuditEntry, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var conditions []string
+	var args []interface{}
+	if projectID != "" {
+		args = append(args, projectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if action != "" {
+		args = append(args, likeEscaper.Replace(action)+"%")
+		conditions = append(conditions, fmt.Sprintf(`action LIKE $%d ESCAPE '\'`, len(args)))
+	}
+	if actor != "" {
+		args = append(args, actor)
+		conditions = append(conditions, fmt.Sprintf("actor = $%d", len(args)))
+	}
+
+	sqlQuery := `
+		SELECT id, actor, project_id, action, method, path, COALESCE(query, ''), COALESCE(status_code, 0),
+		       COALESCE(remote_addr, ''), created_at
+		FROM audit_log
+	`
+	if len(conditions) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	sqlQuery += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.ProjectID,
+			&entry.Action,
+			&entry.Method,
+			&entry.Path,
+			&entry.Query,
+			&entry.StatusCode,
+			&entry.RemoteAddr,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate audit log: %v", err)
+	}
+
+	return entries, nil
+}
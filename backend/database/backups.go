@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// BackupTables lists the tables included in logical backups. Backups hold
+// the content only: projects, API keys, users, project settings, saved
+// searches, collections, notes and tags are not included, and a restore
+// starts from the ones the migrations create.
+var BackupTables = []string{"raw_data", "processed_data"}
+
+// CreateBackupRecord stores a new backup history entry
+func CreateBackupRecord(record *BackupRecord) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO backups (method, trigger, status, file_path)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, started_at
+	`
+
+	if err := DB.QueryRow(sqlQuery, record.Method, record.Trigger, record.Status, record.FilePath).Scan(&record.ID, &record.StartedAt); err != nil {
+		return fmt.Errorf("failed to insert backup record: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateBackupRecord stores the final status of a backup
+func UpdateBackupRecord(record *BackupRecord) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rowCountsJSON, err := json.Marshal(record.RowCounts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal row counts: %v", err)
+	}
+
+	sqlQuery := `
+		UPDATE backups
+		SET status = $1, file_path = $2, size_bytes = $3, row_counts = $4,
+		    error_message = NULLIF($5, ''), completed_at = $6
+		WHERE id = $7
+	`
+
+	_, err = DB.Exec(sqlQuery, record.Status, record.FilePath, record.SizeBytes, string(rowCountsJSON),
+		record.ErrorMessage, record.CompletedAt, record.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update backup record: %v", err)
+	}
+
+	return nil
+}
+
+// GetBackupRecords returns the most recent backups first
+func GetBackupRecords(limit int) ([]BackupRecord, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, method, trigger, status, COALESCE(file_path, ''), size_bytes,
+		       COALESCE(row_counts::text, 'null'), COALESCE(error_message, ''), started_at, completed_at
+		FROM backups
+		ORDER BY started_at DESC
+		LIMIT $1
+	`
+
+	rows, err := DB.Query(sqlQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backups: %v", err)
+	}
+	defer rows.Close()
+
+	var records []BackupRecord
+	for rows.Next() {
+		var record BackupRecord
+		var rowCountsJSON string
+		if err := rows.Scan(
+			&record.ID,
+			&record.Method,
+			&record.Trigger,
+			&record.Status,
+			&record.FilePath,
+			&record.SizeBytes,
+			&rowCountsJSON,
+			&record.ErrorMessage,
+			&record.StartedAt,
+			&record.CompletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan backup row: %v", err)
+		}
+		if err := json.Unmarshal([]byte(rowCountsJSON), &record.RowCounts); err != nil {
+			return nil, fmt.Errorf("failed to parse row counts: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate backups: %v", err)
+	}
+
+	return records, nil
+}
+
+// SnapshotTables reads every row of the given tables inside a single
+// repeatable-read transaction so all tables reflect the same point in time.
+// Column values are passed to fn keyed by column name.
+func SnapshotTables(tables []string, fn func(table string, row map[string]interface{}) error) (map[string]int, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin snapshot transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	counts := make(map[string]int)
+	for _, table := range tables {
+		count, err := snapshotTable(tx, table, fn)
+		if err != nil {
+			return nil, err
+		}
+		counts[table] = count
+	}
+
+	return counts, nil
+}
+
+// snapshotTable streams one table's rows in primary key order
+func snapshotTable(tx *sql.Tx, table string, fn func(table string, row map[string]interface{}) error) (int, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT * FROM %s ORDER BY id", table))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s columns: %v", table, err)
+	}
+
+	count := 0
+	for rows.Next() {
+		row, err := scanRowMap(rows, columns)
+		if err != nil {
+			return count, fmt.Errorf("failed to scan %s row: %v", table, err)
+		}
+
+		if err := fn(table, row); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to iterate %s: %v", table, err)
+	}
+
+	return count, nil
+}
+
+// scanRowMap scans the current row into its values keyed by column name, with
+// text and JSON columns as strings and timestamps in RFC 3339
+func scanRowMap(rows *sql.Rows, columns []string) (map[string]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		switch v := values[i].(type) {
+		case []byte:
+			row[column] = string(v)
+		case time.Time:
+			row[column] = v.Format(time.RFC3339Nano)
+		default:
+			row[column] = v
+		}
+	}
+	return row, nil
+}
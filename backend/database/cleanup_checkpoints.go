@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetCleanupCheckpoint returns the last processed record ID saved under key,
+// or 0 when there is no checkpoint
+func GetCleanupCheckpoint(key string) (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var lastID int
+	err := DB.QueryRow(`SELECT last_id FROM cleanup_checkpoints WHERE checkpoint_key = $1`, key).Scan(&lastID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query cleanup checkpoint: %v", err)
+	}
+
+	return lastID, nil
+}
+
+// SaveCleanupCheckpoint records the last processed record ID under key
+func SaveCleanupCheckpoint(key, projectID string, lastID int) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO cleanup_checkpoints (checkpoint_key, project_id, last_id, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (checkpoint_key) DO UPDATE SET last_id = EXCLUDED.last_id, updated_at = NOW()
+	`
+
+	if _, err := DB.Exec(sqlQuery, key, projectIDOrDefault(projectID), lastID); err != nil {
+		return fmt.Errorf("failed to save cleanup checkpoint: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteCleanupCheckpoint removes the checkpoint saved under key
+func DeleteCleanupCheckpoint(key string) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	if _, err := DB.Exec(`DELETE FROM cleanup_checkpoints WHERE checkpoint_key = $1`, key); err != nil {
+		return fmt.Errorf("failed to delete cleanup checkpoint: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+const cleanupJobColumns = `id, job_type, scope, status, total_records, processed_records,
+	updated_records, error_records, COALESCE(errors::text, 'null'), started_at, completed_at`
+
+// CreateCleanupJob stores a new cleanup job
+func CreateCleanupJob(job *CleanupJob) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	scopeJSON, err := json.Marshal(job.Scope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cleanup scope: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO cleanup_jobs (project_id, job_type, scope, status, total_records)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, started_at
+	`
+
+	err = DB.QueryRow(sqlQuery, projectIDOrDefault(job.Scope.Project), job.JobType, string(scopeJSON), job.Status, job.TotalRecords).Scan(&job.ID, &job.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert cleanup job: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateCleanupJob stores the progress and status of a cleanup job
+func UpdateCleanupJob(job *CleanupJob) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	errorsJSON, err := json.Marshal(job.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cleanup errors: %v", err)
+	}
+
+	sqlQuery := `
+		UPDATE cleanup_jobs
+		SET status = $1, processed_records = $2, updated_records = $3, error_records = $4,
+		    errors = $5, completed_at = $6
+		WHERE id = $7
+	`
+
+	_, err = DB.Exec(sqlQuery, job.Status, job.ProcessedRecords, job.UpdatedRecords, job.ErrorRecords,
+		string(errorsJSON), job.CompletedAt, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update cleanup job: %v", err)
+	}
+
+	return nil
+}
+
+// GetCleanupJob retrieves a project's cleanup job by ID, returning nil when it does not exist
+func GetCleanupJob(projectID string, id int) (*CleanupJob, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	row := DB.QueryRow(`SELECT `+cleanupJobColumns+` FROM cleanup_jobs WHERE id = $1 AND project_id = $2`, id, projectID)
+	job, err := scanCleanupJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cleanup job: %v", err)
+	}
+
+	return job, nil
+}
+
+// GetCleanupJobs returns a project's most recent cleanup jobs first
+func GetCleanupJobs(projectID string, limit int) ([]CleanupJob, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rows, err := DB.Query(`SELECT `+cleanupJobColumns+` FROM cleanup_jobs WHERE project_id = $1 ORDER BY id DESC LIMIT $2`, projectID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cleanup jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var jobs []CleanupJob
+	for rows.Next() {
+		job, err := scanCleanupJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cleanup job: %v", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate cleanup jobs: %v", err)
+	}
+
+	return jobs, nil
+}
+
+// scanCleanupJob scans a row selected with cleanupJobColumns
+func scanCleanupJob(row interface{ Scan(...interface{}) error }) (*CleanupJob, error) {
+	var job CleanupJob
+	var scopeJSON, errorsJSON string
+	err := row.Scan(
+		&job.ID,
+		&job.JobType,
+		&scopeJSON,
+		&job.Status,
+		&job.TotalRecords,
+		&job.ProcessedRecords,
+		&job.UpdatedRecords,
+		&job.ErrorRecords,
+		&errorsJSON,
+		&job.StartedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopeJSON), &job.Scope); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(errorsJSON), &job.Errors); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// collectionColumns are the columns scanned by scanCollection
+const collectionColumns = `c.id, c.project_id, c.user_id, c.name, COALESCE(c.description, ''),
+	(SELECT COUNT(*) FROM collection_items ci WHERE ci.collection_id = c.id), c.created_at, c.updated_at`
+
+// scanCollection scans a single row selected with collectionColumns
+func scanCollection(row interface{ Scan(...interface{}) error }) (*Collection, error) {
+	var collection Collection
+	err := row.Scan(
+		&collection.ID,
+		&collection.ProjectID,
+		&collection.UserID,
+		&collection.Name,
+		&collection.Description,
+		&collection.ItemCount,
+		&collection.CreatedAt,
+		&collection.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// CreateCollection stores a new collection. It reports false when the user
+// already has a collection with the same name in the project.
+func CreateCollection(collection *Collection) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	collection.ProjectID = projectIDOrDefault(collection.ProjectID)
+
+	sqlQuery := `
+		INSERT INTO collections (project_id, user_id, name, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id, user_id, name) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`
+
+	err := DB.QueryRow(sqlQuery,
+		collection.ProjectID,
+		collection.UserID,
+		collection.Name,
+		collection.Description,
+	).Scan(&collection.ID, &collection.CreatedAt, &collection.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert collection: %v", err)
+	}
+
+	return true, nil
+}
+
+// GetCollections returns the collections of a user within a project
+func GetCollections(projectID, userID string) ([]Collection, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + collectionColumns + `
+		FROM collections c
+		WHERE c.project_id = $1 AND c.user_id = $2
+		ORDER BY c.name
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %v", err)
+	}
+	defer rows.Close()
+
+	collections := []Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %v", err)
+		}
+		collections = append(collections, *collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate collections: %v", err)
+	}
+
+	return collections, nil
+}
+
+// GetCollection returns a collection owned by the given user in a project,
+// or nil when there is no such collection
+func GetCollection(projectID, userID string, id int) (*Collection, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + collectionColumns + `
+		FROM collections c
+		WHERE c.id = $1 AND c.project_id = $2 AND c.user_id = $3
+	`
+
+	collection, err := scanCollection(DB.QueryRow(sqlQuery, id, projectIDOrDefault(projectID), userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection: %v", err)
+	}
+
+	return collection, nil
+}
+
+// DeleteCollection removes a collection owned by the given user in a project,
+// together with its items
+func DeleteCollection(projectID, userID string, id int) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	result, err := DB.Exec("DELETE FROM collections WHERE id = $1 AND project_id = $2 AND user_id = $3", id, projectIDOrDefault(projectID), userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete collection: %v", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// AddCollectionItem adds a record to a collection. Adding a record that is
+// already in the collection replaces its annotation.
+func AddCollectionItem(collectionID, recordID int, annotation string) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO collection_items (collection_id, record_id, annotation)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (collection_id, record_id) DO UPDATE SET annotation = EXCLUDED.annotation
+	`
+
+	if _, err := DB.Exec(sqlQuery, collectionID, recordID, annotation); err != nil {
+		return fmt.Errorf("failed to add collection item: %v", err)
+	}
+	if _, err := DB.Exec(`UPDATE collections SET updated_at = NOW() WHERE id = $1`, collectionID); err != nil {
+		return fmt.Errorf("failed to update collection: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveCollectionItem removes a record from a collection
+func RemoveCollectionItem(collectionID, recordID int) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	result, err := DB.Exec("DELETE FROM collection_items WHERE collection_id = $1 AND record_id = $2", collectionID, recordID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove collection item: %v", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return false, nil
+	}
+	if _, err := DB.Exec(`UPDATE collections SET updated_at = NOW() WHERE id = $1`, collectionID); err != nil {
+		return false, fmt.Errorf("failed to update collection: %v", err)
+	}
+
+	return true, nil
+}
+
+// GetCollectionItems returns the records of a collection in the order they
+// were added. Soft-deleted records are left out.
+func GetCollectionItems(collectionID int) ([]CollectionItem, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `, COALESCE(ci.annotation, ''), ci.added_at
+		FROM collection_items ci
+		JOIN processed_data ON processed_data.id = ci.record_id
+		WHERE ci.collection_id = $1 AND deleted_at IS NULL
+		ORDER BY ci.added_at, ci.record_id
+	`
+
+	rows, err := DB.Query(sqlQuery, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection items: %v", err)
+	}
+	defer rows.Close()
+
+	items := []CollectionItem{}
+	for rows.Next() {
+		var item CollectionItem
+		data, err := scanProcessedDataRow(extraColumns{rows, []interface{}{&item.Annotation, &item.AddedAt}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection item: %v", err)
+		}
+		item.ProcessedData = *data
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate collection items: %v", err)
+	}
+
+	if len(items) == 0 {
+		return items, nil
+	}
+	recordIDs := make([]int, len(items))
+	for i, item := range items {
+		recordIDs[i] = item.ID
+	}
+	notes, err := GetRecordNotes(items[0].ProjectID, recordIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Notes = notes[items[i].ID]
+	}
+
+	return items, nil
+}
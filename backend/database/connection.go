@@ -1,10 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strings"
 
 	_ "github.com/lib/pq"
 )
@@ -19,25 +22,20 @@ func InitDatabase() error {
 		return nil
 	}
 
-	connStr := os.Getenv("DATABASE_URL")
-	if connStr == "" {
-		// Fallback to individual environment variables
-		connStr = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			os.Getenv("DATABASE_HOST"),
-			os.Getenv("DATABASE_PORT"),
-			os.Getenv("DATABASE_USER"),
-			os.Getenv("DATABASE_PASSWORD"),
-			os.Getenv("DATABASE_NAME"),
-			os.Getenv("DATABASE_SSL_MODE"),
-		)
-	}
-
 	var err error
-	DB, err = sql.Open("postgres", connStr)
+	previous := DB
+	DB, err = sql.Open("postgres", utcSession(ConnectionString()))
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 
+	// A pool replaced on reconnect is closed with its prepared statements;
+	// closing waits for the calls still running on it
+	if previous != nil {
+		forgetStatements(previous)
+		previous.Close()
+	}
+
 	// Configure connection pooling
 	DB.SetMaxOpenConns(25)   // Maximum number of open connections
 	DB.SetMaxIdleConns(5)    // Maximum number of idle connections
@@ -52,14 +50,114 @@ func InitDatabase() error {
 	return nil
 }
 
+// ConnectionString returns the PostgreSQL connection string from the environment
+func ConnectionString() string {
+	if connStr := os.Getenv("DATABASE_URL"); connStr != "" {
+		return connStr
+	}
+
+	// Fallback to individual environment variables
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		os.Getenv("DATABASE_HOST"),
+		os.Getenv("DATABASE_PORT"),
+		os.Getenv("DATABASE_USER"),
+		os.Getenv("DATABASE_PASSWORD"),
+		os.Getenv("DATABASE_NAME"),
+		os.Getenv("DATABASE_SSL_MODE"),
+	)
+}
+
+// ConnectionParams are the parts of the connection string the PostgreSQL
+// client tools are given. Empty parts are left to the tools' defaults.
+type ConnectionParams struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	DBName   string
+	SSLMode  string
+}
+
+// ConnectionParameters splits ConnectionString into its parts, so client
+// tools can be given the password through the environment rather than on a
+// command line every local user can read
+func ConnectionParameters() (ConnectionParams, error) {
+	connStr := ConnectionString()
+
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			return ConnectionParams{}, fmt.Errorf("failed to parse DATABASE_URL: %v", err)
+		}
+		params := ConnectionParams{
+			Host:    u.Hostname(),
+			Port:    u.Port(),
+			User:    u.User.Username(),
+			DBName:  strings.TrimPrefix(u.Path, "/"),
+			SSLMode: u.Query().Get("sslmode"),
+		}
+		params.Password, _ = u.User.Password()
+		return params, nil
+	}
+
+	var params ConnectionParams
+	for _, field := range strings.Fields(connStr) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return ConnectionParams{}, fmt.Errorf("failed to parse connection string: %q is not key=value", field)
+		}
+		value = strings.Trim(value, "'")
+		switch key {
+		case "host":
+			params.Host = value
+		case "port":
+			params.Port = value
+		case "user":
+			params.User = value
+		case "password":
+			params.Password = value
+		case "dbname":
+			params.DBName = value
+		case "sslmode":
+			params.SSLMode = value
+		}
+	}
+	return params, nil
+}
+
+// utcSession sets the session time zone of a connection string to UTC, so
+// TIMESTAMP columns defaulting to NOW() hold UTC like every timestamp the
+// pipeline writes, whatever the server's time zone
+func utcSession(connStr string) string {
+	if strings.Contains(strings.ToLower(connStr), "timezone=") {
+		return connStr
+	}
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		if strings.Contains(connStr, "?") {
+			return connStr + "&timezone=UTC"
+		}
+		return connStr + "?timezone=UTC"
+	}
+	return connStr + " timezone=UTC"
+}
+
 // EnsureConnection ensures the database connection is alive
 func EnsureConnection() error {
+	return EnsureConnectionContext(context.Background())
+}
+
+// EnsureConnectionContext is EnsureConnection with a context that bounds the
+// ping, so a caller whose request is cancelled does not wait on a dead server
+func EnsureConnectionContext(ctx context.Context) error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
 	// Ping the database to check if connection is alive
-	if err := DB.Ping(); err != nil {
+	if err := DB.PingContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		// Try to reconnect
 		log.Println("⚠️ Database connection lost, attempting to reconnect...")
 		if err := InitDatabase(); err != nil {
@@ -74,7 +172,9 @@ func EnsureConnection() error {
 // CloseDatabase closes the database connection
 func CloseDatabase() error {
 	if DB != nil {
-		return DB.Close()
+		err := DB.Close()
+		forgetStatements(DB)
+		return err
 	}
 	return nil
 }
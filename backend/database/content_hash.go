@@ -0,0 +1,41 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ContentHash returns the SHA-256 of a record's normalized title and locator:
+// its URL, or another stable identifier for records without one. Records of
+// a project with the same hash are the same item, so reloading it updates
+// the existing row instead of adding a duplicate.
+func ContentHash(title, locator string) string {
+	sum := sha256.Sum256([]byte(normalizeHashPart(title) + "\n" + normalizeHashPart(locator)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeHashPart lowercases text and collapses its whitespace, so
+// formatting differences between runs do not change the hash
+func normalizeHashPart(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// contentHashSQL computes ContentHash in SQL for rows loaded before content
+// hashing: the locator of YouTube comments is "youtube:" and their ID, and
+// that of other records their URL, falling back to their content
+const contentHashSQL = `encode(sha256(convert_to(
+	lower(btrim(regexp_replace(COALESCE(title, ''), '\s+', ' ', 'g'))) || E'\n' ||
+	lower(btrim(regexp_replace(
+		CASE WHEN source = 'youtube' THEN 'youtube:' || COALESCE(processed_data->>'id', '')
+		ELSE COALESCE(NULLIF(processed_data->>'url', ''), content, '') END,
+		'\s+', ' ', 'g'))),
+	'UTF8')), 'hex')`
+
+// liveDuplicateCondition excludes processed_data rows whose content is
+// already held by another live row of the project, so restoring them does
+// not break the content hash uniqueness
+const liveDuplicateCondition = `(content_hash IS NULL OR NOT EXISTS (
+	SELECT 1 FROM processed_data live
+	WHERE live.project_id = processed_data.project_id AND live.content_hash = processed_data.content_hash
+		AND live.deleted_at IS NULL AND live.id <> processed_data.id))`
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// UpsertCovidStatistics stores official figures, replacing the figures of
+// days already stored. Figures that are nil keep their stored value, so cases
+// and vaccinations can be loaded from separate feeds.
+func UpsertCovidStatistics(stats []CovidStatistic) (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin statistics upsert: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO covid_statistics (country, day, new_cases, new_deaths, new_recovered, new_vaccinations,
+			cumulative_cases, cumulative_deaths, source, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (country, day) DO UPDATE SET
+			new_cases = COALESCE(EXCLUDED.new_cases, covid_statistics.new_cases),
+			new_deaths = COALESCE(EXCLUDED.new_deaths, covid_statistics.new_deaths),
+			new_recovered = COALESCE(EXCLUDED.new_recovered, covid_statistics.new_recovered),
+			new_vaccinations = COALESCE(EXCLUDED.new_vaccinations, covid_statistics.new_vaccinations),
+			cumulative_cases = COALESCE(EXCLUDED.cumulative_cases, covid_statistics.cumulative_cases),
+			cumulative_deaths = COALESCE(EXCLUDED.cumulative_deaths, covid_statistics.cumulative_deaths),
+			source = EXCLUDED.source,
+			updated_at = NOW()
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statistics upsert: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, stat := range stats {
+		_, err := stmt.Exec(stat.Country, stat.Day, stat.NewCases, stat.NewDeaths, stat.NewRecovered,
+			stat.NewVaccinations, stat.CumulativeCases, stat.CumulativeDeaths, stat.Source)
+		if err != nil {
+			return 0, fmt.Errorf("failed to upsert statistics of %s: %v", stat.Day, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit statistics upsert: %v", err)
+	}
+
+	return len(stats), nil
+}
+
+// GetCovidStatistics returns the official figures of a country for the days
+// in [from, to], oldest first
+func GetCovidStatistics(country string, from, to time.Time) ([]CovidStatistic, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT country, TO_CHAR(day, 'YYYY-MM-DD'), new_cases, new_deaths, new_recovered, new_vaccinations,
+			cumulative_cases, cumulative_deaths, source, updated_at
+		FROM covid_statistics
+		WHERE country = $1 AND day >= $2::date AND day <= $3::date
+		ORDER BY day
+	`
+
+	rows, err := DB.Query(sqlQuery, country, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query covid statistics: %v", err)
+	}
+	defer rows.Close()
+
+	stats := []CovidStatistic{}
+	for rows.Next() {
+		var stat CovidStatistic
+		err := rows.Scan(&stat.Country, &stat.Day, &stat.NewCases, &stat.NewDeaths, &stat.NewRecovered,
+			&stat.NewVaccinations, &stat.CumulativeCases, &stat.CumulativeDeaths, &stat.Source, &stat.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan covid statistics: %v", err)
+		}
+		stats = append(stats, stat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read covid statistics: %v", err)
+	}
+
+	return stats, nil
+}
+
+// GetSentimentCaseSeries lines up a project's daily sentiment from the
+// rollups with a country's official figures over the last days days. Days
+// with only one of the two are included with the other side empty.
+// Duplicates are counted only with includeDuplicates.
+func GetSentimentCaseSeries(projectID, country string, days int, includeDuplicates bool) ([]SentimentCasePoint, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		WITH sentiment AS (
+			SELECT day, SUM(record_count) AS records, SUM(sentiment_score_sum) AS score_sum,
+				COALESCE(SUM(record_count) FILTER (WHERE sentiment = 'negative'), 0) AS negative
+			FROM daily_rollups
+			WHERE project_id = $1 AND day > CURRENT_DATE - $3::integer` + rollupCondition(includeDuplicates) + `
+			GROUP BY day
+		), official AS (
+			SELECT day, new_cases, new_deaths, new_vaccinations
+			FROM covid_statistics
+			WHERE country = $2 AND day > CURRENT_DATE - $3::integer
+		)
+		SELECT TO_CHAR(COALESCE(s.day, o.day), 'YYYY-MM-DD'), COALESCE(s.records, 0),
+			COALESCE(s.score_sum, 0), COALESCE(s.negative, 0), o.new_cases, o.new_deaths, o.new_vaccinations
+		FROM sentiment s
+		FULL OUTER JOIN official o ON o.day = s.day
+		ORDER BY 1
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), country, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sentiment case series: %v", err)
+	}
+	defer rows.Close()
+
+	points := []SentimentCasePoint{}
+	for rows.Next() {
+		var point SentimentCasePoint
+		var scoreSum float64
+		var negative int
+		err := rows.Scan(&point.Day, &point.RecordCount, &scoreSum, &negative,
+			&point.NewCases, &point.NewDeaths, &point.NewVaccinations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sentiment case series: %v", err)
+		}
+		if point.RecordCount > 0 {
+			point.AvgSentimentScore = scoreSum / float64(point.RecordCount)
+			point.NegativeShare = float64(negative) / float64(point.RecordCount)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sentiment case series: %v", err)
+	}
+
+	return points, nil
+}
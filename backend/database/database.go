@@ -11,10 +11,10 @@ package database
 // Database Operations
 // - InitDatabase: Initialize PostgreSQL connection
 // - CreateTables: Create database schema
+// - Migrate: Create the schema and apply pending migrations
 // - InsertRawData: Store raw extracted data
-// - InsertProcessedData: Store processed data
-// - GetLatestProcessedData: Retrieve latest data
-// - GetDataBySource: Filter data by source
+// - UpsertProcessedData: Store processed data, updating records loaded before
+// - QueryProcessedData: Filter data by project, source, sentiment and dates
 // - GetDataCount: Get record counts
 
 // Usage Example:
@@ -39,10 +39,10 @@ package database
 //     Sentiment: "neutral",
 //     ProcessedData: "{}",
 // }
-// database.InsertProcessedData(data)
+// database.UpsertProcessedData(data)
 //
 // // Retrieve data
-// results, err := database.GetLatestProcessedData(10)
+// results, err := database.QueryProcessedData(database.ProcessedDataFilter{Project: database.DefaultProject}, 10)
 // if err != nil {
 //     log.Printf("Error: %v", err)
 // }
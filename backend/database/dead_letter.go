@@ -0,0 +1,113 @@
+package database
+
+import (
+	"fmt"
+)
+
+// InsertDeadLetter stores a processed record whose load failed
+func InsertDeadLetter(letter DeadLetter) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO dead_letter (project_id, batch_id, source, record, topics, entities, error)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7)
+	`
+
+	_, err := DB.Exec(sqlQuery, projectIDOrDefault(letter.ProjectID), letter.BatchID, letter.Source,
+		letter.Record, letter.Topics, letter.Entities, letter.Error)
+	if err != nil {
+		return fmt.Errorf("failed to insert dead letter: %v", err)
+	}
+
+	return nil
+}
+
+// GetDeadLetters returns up to limit dead-lettered records of a project,
+// oldest first
+func GetDeadLetters(projectID string, limit int) ([]DeadLetter, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, project_id, COALESCE(batch_id, ''), source, record, COALESCE(topics, ''),
+			COALESCE(entities, ''), error, attempts, created_at, last_attempt_at
+		FROM dead_letter
+		WHERE project_id = $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %v", err)
+	}
+	defer rows.Close()
+
+	letters := []DeadLetter{}
+	for rows.Next() {
+		var letter DeadLetter
+		err := rows.Scan(&letter.ID, &letter.ProjectID, &letter.BatchID, &letter.Source, &letter.Record, &letter.Topics,
+			&letter.Entities, &letter.Error, &letter.Attempts, &letter.CreatedAt, &letter.LastAttemptAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %v", err)
+		}
+		letters = append(letters, letter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dead letters: %v", err)
+	}
+
+	return letters, nil
+}
+
+// CountDeadLetters returns how many dead-lettered records a project has
+func CountDeadLetters(projectID string) (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM dead_letter WHERE project_id = $1`, projectIDOrDefault(projectID)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count dead letters: %v", err)
+	}
+
+	return count, nil
+}
+
+// RecordDeadLetterAttempt notes another failed attempt to load a
+// dead-lettered record with its error
+func RecordDeadLetterAttempt(id int, loadErr string) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		UPDATE dead_letter
+		SET attempts = attempts + 1, error = $2, last_attempt_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := DB.Exec(sqlQuery, id, loadErr); err != nil {
+		return fmt.Errorf("failed to update dead letter %d: %v", id, err)
+	}
+
+	return nil
+}
+
+// DeleteDeadLetter removes a dead-lettered record once it has been loaded
+func DeleteDeadLetter(id int) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	if _, err := DB.Exec(`DELETE FROM dead_letter WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete dead letter %d: %v", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// SourceDayCount is the number of records and negative records of one source
+// on one day, read from the daily rollups
+type SourceDayCount struct {
+	Day           string `json:"day"` // YYYY-MM-DD
+	Source        string `json:"source"`
+	RecordCount   int    `json:"record_count"`
+	NegativeCount int    `json:"negative_count"`
+}
+
+// GetTopItemsPerSource returns up to limit records per source of one day of
+// EventTimeColumn with the given sentiment, the strongest scores first. The
+// day starts at day, in its time zone. Duplicates are left out unless
+// includeDuplicates.
+func GetTopItemsPerSource(projectID string, day time.Time, sentiment string, limit int, includeDuplicates bool) ([]ProcessedData, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	order := "sentiment_score DESC"
+	if sentiment == "negative" {
+		order = "sentiment_score ASC"
+	}
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY source ORDER BY ` + order + ` NULLS LAST, relevance_score DESC, id DESC) AS item_rank
+			FROM processed_data
+			WHERE project_id = $1 AND deleted_at IS NULL AND sentiment = $2
+				AND ` + EventTimeColumn + ` >= $3 AND ` + EventTimeColumn + ` < $4` + duplicatesCondition(includeDuplicates) + `
+		) ranked
+		WHERE item_rank <= $5
+		ORDER BY source, item_rank
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), sentiment, day.UTC(), day.AddDate(0, 0, 1).UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top items: %v", err)
+	}
+	defer rows.Close()
+
+	return scanProcessedData(rows)
+}
+
+// GetTermCounts counts, for the records of a project in [from, to) of
+// EventTimeColumn, how many records have each term among their keyphrases.
+// Duplicates are counted only with includeDuplicates.
+func GetTermCounts(projectID string, from, to time.Time, includeDuplicates bool) (map[string]int, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT title, content
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL
+			AND ` + EventTimeColumn + ` >= $2 AND ` + EventTimeColumn + ` < $3` + duplicatesCondition(includeDuplicates) + `
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query term counts: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var title, content string
+		if err := rows.Scan(&title, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan term counts: %v", err)
+		}
+		for _, term := range ExtractKeyphrases(title+" "+content, relatedKeyphraseCount) {
+			counts[term]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read term counts: %v", err)
+	}
+
+	return counts, nil
+}
+
+// GetSourceDayCounts returns the per-source record counts of each day in
+// [from, to], read from the daily rollups for UTC days and counted from the
+// records for days in the time zone of from otherwise. Duplicates are
+// counted only with includeDuplicates.
+func GetSourceDayCounts(projectID string, from, to time.Time, includeDuplicates bool) ([]SourceDayCount, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT TO_CHAR(day, 'YYYY-MM-DD'), source, SUM(record_count),
+			COALESCE(SUM(record_count) FILTER (WHERE sentiment = 'negative'), 0)
+		FROM daily_rollups
+		WHERE project_id = $1 AND day >= $2::date AND day <= $3::date` + rollupCondition(includeDuplicates) + `
+		GROUP BY day, source
+		ORDER BY day, source
+	`
+	args := []interface{}{projectIDOrDefault(projectID), from.Format("2006-01-02"), to.Format("2006-01-02")}
+	if zone := from.Location(); !isUTC(zone) {
+		start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, zone)
+		end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, zone)
+		sqlQuery = `
+			SELECT TO_CHAR(` + localDayColumn("$4") + `, 'YYYY-MM-DD'), source, COUNT(*),
+				COUNT(*) FILTER (WHERE sentiment = 'negative')
+			FROM processed_data
+			WHERE project_id = $1 AND deleted_at IS NULL
+				AND ` + EventTimeColumn + ` >= $2 AND ` + EventTimeColumn + ` < $3` + duplicatesCondition(includeDuplicates) + `
+			GROUP BY 1, 2
+			ORDER BY 1, 2
+		`
+		args = []interface{}{projectIDOrDefault(projectID), start.UTC(), end.UTC(), zone.String()}
+	}
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query source day counts: %v", err)
+	}
+	defer rows.Close()
+
+	var counts []SourceDayCount
+	for rows.Next() {
+		var count SourceDayCount
+		if err := rows.Scan(&count.Day, &count.Source, &count.RecordCount, &count.NegativeCount); err != nil {
+			return nil, fmt.Errorf("failed to scan source day counts: %v", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read source day counts: %v", err)
+	}
+
+	return counts, nil
+}
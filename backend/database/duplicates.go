@@ -0,0 +1,162 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DuplicateGroup is a canonical record with the near-duplicates of the same
+// story, e.g. one report carried by Kompas, Detik and Google News
+type DuplicateGroup struct {
+	Canonical  ProcessedData   `json:"canonical"`
+	Duplicates []ProcessedData `json:"duplicates"`
+}
+
+// canonicalCondition keeps one record per story: unique records and the
+// canonical records of duplicate groups. Analytics count only these unless
+// asked to include duplicates, so an article syndicated to five portals is
+// one data point.
+const canonicalCondition = "duplicate_of IS NULL"
+
+// dedupSourceCondition limits duplicate detection to articles; social
+// comments and posts are not stories carried by several outlets
+const dedupSourceCondition = "source NOT IN ('youtube', 'instagram')"
+
+// GetDedupCandidates returns the live articles of a project with an
+// EventTimeColumn of since or later. Articles already grouped under a
+// canonical record before since keep that group and are left out.
+func GetDedupCandidates(projectID string, since time.Time) ([]ProcessedData, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data p
+		WHERE project_id = $1 AND deleted_at IS NULL AND ` + dedupSourceCondition + ` AND title <> ''
+			AND ` + EventTimeColumn + ` >= $2
+			AND (duplicate_of IS NULL OR EXISTS (
+				SELECT 1 FROM processed_data c
+				WHERE c.id = p.duplicate_of AND c.deleted_at IS NULL AND COALESCE(c.published_at, c.processed_at) >= $2
+			))
+		ORDER BY ` + EventTimeColumn + `, id
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dedup candidates: %v", err)
+	}
+	defer rows.Close()
+
+	return scanProcessedData(rows)
+}
+
+// SetDuplicates regroups the records ids: each id in canonical is marked as
+// a duplicate of the record it maps to, and the other ids as not duplicates
+func SetDuplicates(projectID string, ids []int, canonical map[int]int) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	duplicateIDs := make([]int64, 0, len(canonical))
+	canonicalIDs := make([]int64, 0, len(canonical))
+	for id, canonicalID := range canonical {
+		duplicateIDs = append(duplicateIDs, int64(id))
+		canonicalIDs = append(canonicalIDs, int64(canonicalID))
+	}
+	allIDs := make([]int64, len(ids))
+	for i, id := range ids {
+		allIDs[i] = int64(id)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin duplicate update: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE processed_data SET duplicate_of = NULL WHERE project_id = $1 AND id = ANY($2) AND duplicate_of IS NOT NULL`,
+		projectIDOrDefault(projectID), pq.Array(allIDs)); err != nil {
+		return fmt.Errorf("failed to clear duplicates: %v", err)
+	}
+	if len(duplicateIDs) > 0 {
+		sqlQuery := `
+			UPDATE processed_data p
+			SET duplicate_of = m.canonical
+			FROM unnest($2::integer[], $3::integer[]) AS m(id, canonical)
+			WHERE p.project_id = $1 AND p.id = m.id
+		`
+		if _, err := tx.Exec(sqlQuery, projectIDOrDefault(projectID), pq.Array(duplicateIDs), pq.Array(canonicalIDs)); err != nil {
+			return fmt.Errorf("failed to mark duplicates: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit duplicates: %v", err)
+	}
+	return nil
+}
+
+// GetDuplicateGroups returns up to limit duplicate groups of a project, the
+// most recently published canonical records first
+func GetDuplicateGroups(projectID string, limit int) ([]DuplicateGroup, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	projectID = projectIDOrDefault(projectID)
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data p
+		WHERE project_id = $1 AND deleted_at IS NULL AND duplicate_of IS NULL
+			AND EXISTS (SELECT 1 FROM processed_data d WHERE d.duplicate_of = p.id AND d.deleted_at IS NULL)
+		ORDER BY ` + EventTimeColumn + ` DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := DB.Query(sqlQuery, projectID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate groups: %v", err)
+	}
+	canonicals, err := scanProcessedData(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []DuplicateGroup{}
+	if len(canonicals) == 0 {
+		return groups, nil
+	}
+	index := make(map[int]int, len(canonicals))
+	ids := make([]int64, len(canonicals))
+	for i, record := range canonicals {
+		index[record.ID] = i
+		ids[i] = int64(record.ID)
+		groups = append(groups, DuplicateGroup{Canonical: record, Duplicates: []ProcessedData{}})
+	}
+
+	sqlQuery = `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL AND duplicate_of = ANY($2)
+		ORDER BY ` + EventTimeColumn + `, id
+	`
+	rows, err = DB.Query(sqlQuery, projectID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicates: %v", err)
+	}
+	duplicates, err := scanProcessedData(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range duplicates {
+		group := &groups[index[*record.DuplicateOf]]
+		group.Duplicates = append(group.Duplicates, record)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EnsureEmbeddingSchema enables pgvector and creates the embeddings table for
+// vectors of the given dimensions. It runs only when an embedding provider is
+// configured, so databases without pgvector keep working without embeddings.
+// The embeddings of deleted records are deleted by the processed_data trigger.
+func EnsureEmbeddingSchema(dimensions int) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS processed_data_embeddings (
+			processed_data_id INTEGER PRIMARY KEY,
+			model VARCHAR(100) NOT NULL,
+			embedding vector(%d) NOT NULL,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`, dimensions),
+		`CREATE INDEX IF NOT EXISTS idx_processed_data_embeddings_hnsw
+			ON processed_data_embeddings USING hnsw (embedding vector_cosine_ops)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := DB.Exec(statement); err != nil {
+			return fmt.Errorf("failed to prepare embedding schema (is pgvector installed?): %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetRecordsWithoutEmbedding returns up to limit active records of a project
+// that have no embedding for model yet, oldest first
+func GetRecordsWithoutEmbedding(projectID, model string, limit int) ([]ProcessedData, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM processed_data_embeddings e
+				WHERE e.processed_data_id = processed_data.id AND e.model = $2
+			)
+		ORDER BY id
+		LIMIT $3
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records without embedding: %v", err)
+	}
+	defer rows.Close()
+
+	return scanProcessedData(rows)
+}
+
+// SaveEmbedding stores the embedding of a record, replacing an existing one
+func SaveEmbedding(recordID int, model string, embedding []float32) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO processed_data_embeddings (processed_data_id, model, embedding, created_at)
+		VALUES ($1, $2, $3::vector, NOW())
+		ON CONFLICT (processed_data_id) DO UPDATE
+		SET model = EXCLUDED.model, embedding = EXCLUDED.embedding, created_at = NOW()
+	`
+
+	if _, err := DB.Exec(sqlQuery, recordID, model, vectorLiteral(embedding)); err != nil {
+		return fmt.Errorf("failed to save embedding: %v", err)
+	}
+
+	return nil
+}
+
+// SemanticSearch returns the active records of a project whose embeddings are
+// closest to query by cosine similarity, most similar first
+func SemanticSearch(projectID, model string, query []float32, source string, limit int) ([]RelatedRecord, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `, 1 - (e.embedding <=> $1::vector)
+		FROM processed_data
+		JOIN processed_data_embeddings e ON e.processed_data_id = processed_data.id
+		WHERE project_id = $2 AND deleted_at IS NULL AND e.model = $3
+	`
+	args := []interface{}{vectorLiteral(query), projectIDOrDefault(projectID), model}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+	args = append(args, limit)
+	sqlQuery += fmt.Sprintf(" ORDER BY e.embedding <=> $1::vector LIMIT $%d", len(args))
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run semantic search: %v", err)
+	}
+	defer rows.Close()
+
+	results := []RelatedRecord{}
+	for rows.Next() {
+		var result RelatedRecord
+		data := &result.ProcessedData
+		err := rows.Scan(
+			&data.ID,
+			&data.Source,
+			&data.ProcessedAt,
+			&data.PublishedAt,
+			&data.Title,
+			&data.Content,
+			&data.RelevanceScore,
+			&data.Sentiment,
+			&data.SentimentScore,
+			&data.SentimentConfidence,
+			&data.AnalyzerVersion,
+			&data.BatchID,
+			&data.ProjectID,
+			&data.License,
+			&data.ToxicityScore,
+			&data.ProcessedData,
+			&result.Score,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan semantic search result: %v", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read semantic search results: %v", err)
+	}
+
+	return results, nil
+}
+
+// vectorLiteral formats an embedding in pgvector's text format, e.g. "[0.1,0.2]"
+func vectorLiteral(embedding []float32) string {
+	parts := make([]string, len(embedding))
+	for i, value := range embedding {
+		parts[i] = strconv.FormatFloat(float64(value), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
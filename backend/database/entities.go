@@ -0,0 +1,118 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SetRecordEntities replaces the named entities a record of a project
+// mentions, adding entities not seen before
+func SetRecordEntities(projectID string, recordID int, entities []RecordEntity) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	names := make([]string, len(entities))
+	types := make([]string, len(entities))
+	mentions := make([]int64, len(entities))
+	for i, entity := range entities {
+		names[i] = entity.Name
+		types[i] = entity.Type
+		mentions[i] = int64(entity.Mentions)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin record entities update: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM record_entities WHERE record_id = $1`, recordID); err != nil {
+		return fmt.Errorf("failed to clear record entities: %v", err)
+	}
+
+	if len(entities) > 0 {
+		_, err = tx.Exec(`
+			INSERT INTO entities (name, type)
+			SELECT * FROM UNNEST($1::text[], $2::text[])
+			ON CONFLICT (name, type) DO NOTHING
+		`, pq.Array(names), pq.Array(types))
+		if err != nil {
+			return fmt.Errorf("failed to add entities: %v", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO record_entities (record_id, entity_id, project_id, mentions)
+			SELECT $1, e.id, $2, m.mentions
+			FROM UNNEST($3::text[], $4::text[], $5::int[]) AS m(name, type, mentions)
+			JOIN entities e ON e.name = m.name AND e.type = m.type
+		`, recordID, projectIDOrDefault(projectID), pq.Array(names), pq.Array(types), pq.Array(mentions))
+		if err != nil {
+			return fmt.Errorf("failed to link record entities: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit record entities: %v", err)
+	}
+	return nil
+}
+
+// GetEntityCounts returns the entities mentioned by the most records of a
+// project whose EventTimeColumn falls in [from, to). An empty entityType or
+// source counts across all of them, and nil bounds leave the range open.
+// Duplicates are counted only with includeDuplicates.
+func GetEntityCounts(projectID, entityType, source string, from, to *time.Time, limit int, includeDuplicates bool) ([]EntityCount, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT e.name, e.type, COUNT(*), SUM(re.mentions)
+		FROM record_entities re
+		JOIN entities e ON e.id = re.entity_id
+		JOIN processed_data p ON p.id = re.record_id
+		WHERE re.project_id = $1 AND p.deleted_at IS NULL` + duplicatesCondition(includeDuplicates) + `
+	`
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if entityType != "" {
+		args = append(args, entityType)
+		sqlQuery += fmt.Sprintf(" AND e.type = $%d", len(args))
+	}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND p.source = $%d", len(args))
+	}
+	if from != nil {
+		args = append(args, *from)
+		sqlQuery += fmt.Sprintf(" AND %s >= $%d", EventTimeColumn, len(args))
+	}
+	if to != nil {
+		args = append(args, *to)
+		sqlQuery += fmt.Sprintf(" AND %s < $%d", EventTimeColumn, len(args))
+	}
+	args = append(args, limit)
+	sqlQuery += fmt.Sprintf(" GROUP BY e.name, e.type ORDER BY COUNT(*) DESC, SUM(re.mentions) DESC, e.name LIMIT $%d", len(args))
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entity counts: %v", err)
+	}
+	defer rows.Close()
+
+	counts := []EntityCount{}
+	for rows.Next() {
+		var count EntityCount
+		if err := rows.Scan(&count.Name, &count.Type, &count.RecordCount, &count.Mentions); err != nil {
+			return nil, fmt.Errorf("failed to scan entity counts: %v", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read entity counts: %v", err)
+	}
+
+	return counts, nil
+}
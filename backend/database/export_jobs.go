@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// CreateExportJob stores a new export job
+func CreateExportJob(job *ExportJob) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	filtersJSON, err := json.Marshal(job.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal export filters: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO export_jobs (id, project_id, format, filters, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+
+	if err := DB.QueryRow(sqlQuery, job.ID, projectIDOrDefault(job.Filters.Project), job.Format, string(filtersJSON), job.Status).Scan(&job.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert export job: %v", err)
+	}
+
+	return nil
+}
+
+// GetExportJob retrieves an export job by ID, returning nil when it does not exist
+func GetExportJob(id string) (*ExportJob, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, project_id, format, filters, status, COALESCE(file_path, ''), record_count,
+		       COALESCE(error_message, ''), created_at, completed_at
+		FROM export_jobs
+		WHERE id = $1
+	`
+
+	var job ExportJob
+	var projectID, filtersJSON string
+	err := DB.QueryRow(sqlQuery, id).Scan(
+		&job.ID,
+		&projectID,
+		&job.Format,
+		&filtersJSON,
+		&job.Status,
+		&job.FilePath,
+		&job.RecordCount,
+		&job.ErrorMessage,
+		&job.CreatedAt,
+		&job.CompletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query export job: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(filtersJSON), &job.Filters); err != nil {
+		return nil, fmt.Errorf("failed to parse export filters: %v", err)
+	}
+	// The column is authoritative for jobs created before projects existed
+	job.Filters.Project = projectID
+
+	return &job, nil
+}
+
+// UpdateExportJobStatus records the progress or outcome of an export job
+func UpdateExportJobStatus(job *ExportJob) error {
+	sqlQuery := `
+		UPDATE export_jobs
+		SET status = $1, file_path = $2, record_count = $3, error_message = $4, completed_at = $5
+		WHERE id = $6
+	`
+
+	_, err := DB.Exec(sqlQuery, job.Status, job.FilePath, job.RecordCount, job.ErrorMessage, job.CompletedAt, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update export job: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,466 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps the records in memory, for tests of the
+// handlers and services. It has no tags, so filters on tags match nothing,
+// and no pipeline checkpoints keeping raw data from being pruned.
+type MemoryStore struct {
+	mu        sync.Mutex
+	processed []ProcessedData
+	raw       []memoryRawData
+	nextID    int
+	nextRawID int
+}
+
+// memoryRawData is a raw payload with the columns RawData leaves out
+type memoryRawData struct {
+	RawData
+	projectID string
+	batchID   string
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1, nextRawID: 1}
+}
+
+// UpsertProcessedData stores a record like the package function. A record
+// loaded with a zero ProcessedAt is stamped with the current time.
+func (s *MemoryStore) UpsertProcessedData(_ context.Context, data *ProcessedData) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data.ProjectID = projectIDOrDefault(data.ProjectID)
+	if data.ContentHash != "" {
+		for i := range s.processed {
+			existing := &s.processed[i]
+			if existing.ProjectID != data.ProjectID || existing.ContentHash != data.ContentHash {
+				continue
+			}
+			data.ID = existing.ID
+			data.ProcessedAt = existing.ProcessedAt
+			if data.PublishedAt == nil {
+				data.PublishedAt = existing.PublishedAt
+			}
+			if data.Campaign == "" {
+				data.Campaign = existing.Campaign
+			}
+			if data.Hashtag == "" {
+				data.Hashtag = existing.Hashtag
+			}
+			data.DuplicateOf = existing.DuplicateOf
+			data.ChangedAt = existing.ChangedAt
+			*existing = *data
+			return false, nil
+		}
+	}
+
+	data.ID = s.nextID
+	s.nextID++
+	if data.ProcessedAt.IsZero() {
+		data.ProcessedAt = time.Now()
+	}
+	s.processed = append(s.processed, *data)
+	return true, nil
+}
+
+// GetProcessedDataByID returns a record of a project, or nil if it does not
+// exist
+func (s *MemoryStore) GetProcessedDataByID(_ context.Context, projectID string, id int) (*ProcessedData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, data := range s.processed {
+		if data.ID == id && data.ProjectID == projectIDOrDefault(projectID) {
+			return &data, nil
+		}
+	}
+	return nil, nil
+}
+
+// QueryProcessedDataPage returns a page of the records matching a filter,
+// newest first, skipping the first offset records
+func (s *MemoryStore) QueryProcessedDataPage(_ context.Context, filter ProcessedDataFilter, limit, offset int) ([]ProcessedData, error) {
+	records := s.matching(filter)
+	sort.Slice(records, func(i, j int) bool { return records[i].ID > records[j].ID })
+
+	if offset >= len(records) {
+		return []ProcessedData{}, nil
+	}
+	records = records[offset:]
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// CountProcessedData counts the records matching a filter
+func (s *MemoryStore) CountProcessedData(_ context.Context, filter ProcessedDataFilter) (int, error) {
+	return len(s.matching(filter)), nil
+}
+
+// matching returns the records matching a filter, in load order
+func (s *MemoryStore) matching(filter ProcessedDataFilter) []ProcessedData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []ProcessedData{}
+	for _, data := range s.processed {
+		if matchesFilter(data, filter) {
+			records = append(records, data)
+		}
+	}
+	return records
+}
+
+// matchesFilter reports whether a record matches a filter like the WHERE
+// clause of buildProcessedDataWhere
+func matchesFilter(data ProcessedData, filter ProcessedDataFilter) bool {
+	if filter.Project != "" && data.ProjectID != filter.Project {
+		return false
+	}
+	text := strings.ToLower(data.Title + "\n" + data.Content)
+	for _, term := range strings.Fields(filter.Query) {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	if filter.Source != "" && data.Source != filter.Source ||
+		filter.Sentiment != "" && data.Sentiment != filter.Sentiment ||
+		filter.Campaign != "" && data.Campaign != filter.Campaign ||
+		filter.Hashtag != "" && data.Hashtag != filter.Hashtag {
+		return false
+	}
+	eventTime := recordEventTime(data)
+	if filter.From != nil && eventTime.Before(*filter.From) || filter.To != nil && !eventTime.Before(*filter.To) {
+		return false
+	}
+	if filter.AfterID > 0 && data.ID <= filter.AfterID || filter.BeforeID > 0 && data.ID >= filter.BeforeID {
+		return false
+	}
+	license := data.License
+	if license == "" {
+		license = "unspecified"
+	}
+	for _, excluded := range filter.ExcludeLicenses {
+		if license == excluded {
+			return false
+		}
+	}
+	if filter.MinRelevance != nil && data.RelevanceScore < *filter.MinRelevance {
+		return false
+	}
+	if filter.Language != "" && recordField(data, "language") != filter.Language {
+		return false
+	}
+	if filter.MinToxicity != nil && (data.ToxicityScore == nil || *data.ToxicityScore < *filter.MinToxicity) ||
+		filter.MaxToxicity != nil && (data.ToxicityScore == nil || *data.ToxicityScore > *filter.MaxToxicity) {
+		return false
+	}
+	return len(filter.Tags) == 0
+}
+
+// recordEventTime is EventTimeColumn of a record
+func recordEventTime(data ProcessedData) time.Time {
+	if data.PublishedAt != nil {
+		return *data.PublishedAt
+	}
+	return data.ProcessedAt
+}
+
+// recordField returns a top-level field of the processed_data JSON of a
+// record, or nil
+func recordField(data ProcessedData, name string) interface{} {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(data.ProcessedData), &fields); err != nil {
+		return nil
+	}
+	return fields[name]
+}
+
+// counted returns the live records of a project that aggregates count,
+// leaving out duplicates unless includeDuplicates is set
+func (s *MemoryStore) counted(projectID string, includeDuplicates bool) []ProcessedData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []ProcessedData
+	for _, data := range s.processed {
+		if data.ProjectID == projectIDOrDefault(projectID) && (includeDuplicates || data.DuplicateOf == nil) {
+			records = append(records, data)
+		}
+	}
+	return records
+}
+
+// InsertRawData stores a raw payload and returns its ID
+func (s *MemoryStore) InsertRawData(projectID, source, query, batchID string, rawData interface{}) (int, error) {
+	jsonData, err := json.Marshal(rawData)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal raw data: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := memoryRawData{
+		RawData: RawData{
+			ID:          s.nextRawID,
+			Source:      source,
+			ExtractedAt: time.Now(),
+			RawData:     string(jsonData),
+			Query:       query,
+		},
+		projectID: projectIDOrDefault(projectID),
+		batchID:   batchID,
+	}
+	s.nextRawID++
+	s.raw = append(s.raw, data)
+	return data.ID, nil
+}
+
+// GetRawDataByBatch returns the raw payloads stored by one pipeline run,
+// optionally limited to a single source
+func (s *MemoryStore) GetRawDataByBatch(projectID, batchID, source string) ([]RawData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []RawData
+	for _, data := range s.raw {
+		if data.batchID == batchID && data.projectID == projectIDOrDefault(projectID) && (source == "" || data.Source == source) {
+			results = append(results, data.RawData)
+		}
+	}
+	return results, nil
+}
+
+// RawDataTableSize returns the size of the stored raw payloads
+func (s *MemoryStore) RawDataTableSize() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var size int64
+	for _, data := range s.raw {
+		size += int64(len(data.RawData.RawData))
+	}
+	return size, nil
+}
+
+// CountExpiredRawData counts the raw payloads extracted before cutoff and
+// their size
+func (s *MemoryStore) CountExpiredRawData(cutoff time.Time) (int, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int
+	var size int64
+	for _, data := range s.raw {
+		if data.ExtractedAt.Before(cutoff) {
+			count++
+			size += int64(len(data.RawData.RawData))
+		}
+	}
+	return count, size, nil
+}
+
+// PruneRawData deletes up to limit raw payloads extracted before cutoff,
+// oldest first, passing them to archive first like the package function
+func (s *MemoryStore) PruneRawData(cutoff time.Time, limit int, archive func(rows []map[string]interface{}) error) (int, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []int
+	for i, data := range s.raw {
+		if data.ExtractedAt.Before(cutoff) {
+			expired = append(expired, i)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		a, b := s.raw[expired[i]], s.raw[expired[j]]
+		if !a.ExtractedAt.Equal(b.ExtractedAt) {
+			return a.ExtractedAt.Before(b.ExtractedAt)
+		}
+		return a.ID < b.ID
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	if len(expired) == 0 {
+		return 0, 0, nil
+	}
+
+	rows := make([]map[string]interface{}, 0, len(expired))
+	pruned := make(map[int]bool, len(expired))
+	var size int64
+	for _, i := range expired {
+		data := s.raw[i]
+		rows = append(rows, map[string]interface{}{
+			"id":           int64(data.ID),
+			"project_id":   data.projectID,
+			"source":       data.Source,
+			"query":        data.Query,
+			"raw_data":     json.RawMessage(data.RawData.RawData),
+			"extracted_at": data.ExtractedAt,
+			"batch_id":     data.batchID,
+		})
+		pruned[i] = true
+		size += int64(len(data.RawData.RawData))
+	}
+	if archive != nil {
+		if err := archive(rows); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	kept := s.raw[:0]
+	for i, data := range s.raw {
+		if !pruned[i] {
+			kept = append(kept, data)
+		}
+	}
+	s.raw = kept
+
+	return len(rows), size, nil
+}
+
+// GetDataCount returns the number of raw payloads and records of a project
+func (s *MemoryStore) GetDataCount(_ context.Context, projectID string, includeDuplicates bool) (map[string]int, error) {
+	records := s.counted(projectID, includeDuplicates)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rawCount := 0
+	for _, data := range s.raw {
+		if data.projectID == projectIDOrDefault(projectID) {
+			rawCount++
+		}
+	}
+	return map[string]int{"raw_data": rawCount, "processed_data": len(records)}, nil
+}
+
+// GetSentimentCounts counts the records of a project per sentiment like the
+// package function
+func (s *MemoryStore) GetSentimentCounts(_ context.Context, projectID, source string, from, to *time.Time, excludeSarcastic, includeDuplicates bool) (SentimentCounts, []SentimentCounts, error) {
+	perSource := make(map[string]*SentimentCounts)
+	for _, data := range s.counted(projectID, includeDuplicates) {
+		eventTime := recordEventTime(data)
+		if source != "" && data.Source != source ||
+			from != nil && eventTime.Before(*from) || to != nil && !eventTime.Before(*to) ||
+			excludeSarcastic && recordField(data, "sarcastic") == true {
+			continue
+		}
+
+		counts := perSource[data.Source]
+		if counts == nil {
+			counts = &SentimentCounts{Source: data.Source}
+		}
+		switch data.Sentiment {
+		case "positive":
+			counts.Positive++
+		case "negative":
+			counts.Negative++
+		case "neutral":
+			counts.Neutral++
+		default:
+			continue
+		}
+		perSource[data.Source] = counts
+	}
+
+	var overall SentimentCounts
+	bySource := []SentimentCounts{}
+	for _, counts := range perSource {
+		overall.Positive += counts.Positive
+		overall.Negative += counts.Negative
+		overall.Neutral += counts.Neutral
+		bySource = append(bySource, counts.withShares())
+	}
+	sort.Slice(bySource, func(i, j int) bool { return bySource[i].Source < bySource[j].Source })
+
+	return overall.withShares(), bySource, nil
+}
+
+// GetWordCounts returns the limit most frequent words of a project's records
+// with the tokenization and stop words of the package function
+func (s *MemoryStore) GetWordCounts(_ context.Context, projectID, source, sentiment string, limit int, includeDuplicates bool) ([]WordCount, error) {
+	var records []ProcessedData
+	for _, data := range s.counted(projectID, includeDuplicates) {
+		if (source == "" || data.Source == source) && (sentiment == "" || data.Sentiment == sentiment) {
+			records = append(records, data)
+		}
+	}
+	return countWords(records, limit), nil
+}
+
+// GetSources returns the default source taxonomy
+func (s *MemoryStore) GetSources(_ context.Context) ([]Source, error) {
+	return defaultSourceList(), nil
+}
+
+// countWords returns the limit most frequent words of the titles and content
+// of records, counted like GetWordCounts counts them in SQL, for the stores
+// that count them in Go
+func countWords(records []ProcessedData, limit int) []WordCount {
+	type wordTotals struct {
+		WordCount
+		scoreSum float64
+		scored   int
+	}
+
+	stopWords := getStopWords()
+	totals := make(map[string]*wordTotals)
+	for _, data := range records {
+		for _, word := range tokenizeText(strings.ToLower(data.Title + " " + data.Content)) {
+			if !isFrequencyWord(word, stopWords) {
+				continue
+			}
+			total := totals[word]
+			if total == nil {
+				total = &wordTotals{WordCount: WordCount{Word: word}}
+				totals[word] = total
+			}
+			total.Count++
+			switch data.Sentiment {
+			case "positive":
+				total.PositiveCount++
+			case "negative":
+				total.NegativeCount++
+			case "neutral":
+				total.NeutralCount++
+			}
+			if data.SentimentScore != nil {
+				total.scoreSum += *data.SentimentScore
+				total.scored++
+			}
+		}
+	}
+
+	counts := make([]WordCount, 0, len(totals))
+	for _, total := range totals {
+		if total.scored > 0 {
+			total.AvgSentiment = total.scoreSum / float64(total.scored)
+		}
+		counts = append(counts, total.WordCount)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Word < counts[j].Word
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+
+	return counts
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnalyzerVersionCount is the number of records scored by a sentiment analyzer version
+type AnalyzerVersionCount struct {
+	AnalyzerVersion *int `json:"analyzer_version"` // nil for records scored before versioning
+	Records         int  `json:"records"`
+}
+
+// GetAnalyzerVersionCounts counts a project's records per sentiment analyzer
+// version, limited to records whose event time falls in [from, to) when set
+func GetAnalyzerVersionCounts(projectID string, from, to *time.Time) ([]AnalyzerVersionCount, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT analyzer_version, COUNT(*)
+		FROM processed_data
+		WHERE deleted_at IS NULL AND project_id = $1
+			AND ($2::timestamp IS NULL OR ` + EventTimeColumn + ` >= $2)
+			AND ($3::timestamp IS NULL OR ` + EventTimeColumn + ` < $3)
+		GROUP BY analyzer_version
+		ORDER BY analyzer_version NULLS FIRST
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analyzer versions: %v", err)
+	}
+	defer rows.Close()
+
+	counts := []AnalyzerVersionCount{}
+	for rows.Next() {
+		var count AnalyzerVersionCount
+		if err := rows.Scan(&count.AnalyzerVersion, &count.Records); err != nil {
+			return nil, fmt.Errorf("failed to scan analyzer version: %v", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read analyzer versions: %v", err)
+	}
+
+	return counts, nil
+}
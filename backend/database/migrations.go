@@ -0,0 +1,714 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Migration is a versioned schema change applied on top of CreateTables
+type Migration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// migrations lists schema changes in order. Version 1 is the baseline schema
+// created by CreateTables; append new migrations with increasing versions.
+var migrations = []Migration{
+	{Version: 1, Description: "baseline schema"},
+	{
+		Version:     2,
+		Description: "cleanup job tracking",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS cleanup_jobs (
+				id SERIAL PRIMARY KEY,
+				job_type VARCHAR(20) NOT NULL,
+				scope JSONB NOT NULL,
+				status VARCHAR(30) NOT NULL,
+				total_records INTEGER DEFAULT 0,
+				processed_records INTEGER DEFAULT 0,
+				updated_records INTEGER DEFAULT 0,
+				error_records INTEGER DEFAULT 0,
+				errors JSONB,
+				started_at TIMESTAMP DEFAULT NOW(),
+				completed_at TIMESTAMP
+			)`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "sentiment analyzer version per record",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS analyzer_version INTEGER`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_analyzer_version ON processed_data(analyzer_version)`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "extraction batch IDs and soft delete",
+		Statements: []string{
+			`ALTER TABLE raw_data ADD COLUMN IF NOT EXISTS batch_id VARCHAR(64)`,
+			`ALTER TABLE raw_data ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS batch_id VARCHAR(64)`,
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+			`CREATE INDEX IF NOT EXISTS idx_raw_data_batch ON raw_data(batch_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_batch ON processed_data(batch_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_active ON processed_data(id) WHERE deleted_at IS NULL`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "audit log",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS audit_log (
+				id SERIAL PRIMARY KEY,
+				actor VARCHAR(255) NOT NULL,
+				action VARCHAR(100) NOT NULL,
+				method VARCHAR(10) NOT NULL,
+				path TEXT NOT NULL,
+				query TEXT,
+				status_code INTEGER,
+				remote_addr VARCHAR(100),
+				created_at TIMESTAMP DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at)`,
+			`CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action)`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "projects and project-scoped API keys",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS projects (
+				id VARCHAR(50) PRIMARY KEY,
+				name VARCHAR(255) NOT NULL,
+				created_at TIMESTAMP DEFAULT NOW()
+			)`,
+			`INSERT INTO projects (id, name) VALUES ('default', 'Default project') ON CONFLICT (id) DO NOTHING`,
+			`CREATE TABLE IF NOT EXISTS project_api_keys (
+				id SERIAL PRIMARY KEY,
+				project_id VARCHAR(50) NOT NULL REFERENCES projects(id),
+				key_hash VARCHAR(64) NOT NULL UNIQUE,
+				prefix VARCHAR(16) NOT NULL,
+				label VARCHAR(255),
+				created_at TIMESTAMP DEFAULT NOW()
+			)`,
+			`ALTER TABLE raw_data ADD COLUMN IF NOT EXISTS project_id VARCHAR(50) NOT NULL DEFAULT 'default'`,
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS project_id VARCHAR(50) NOT NULL DEFAULT 'default'`,
+			`ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS project_id VARCHAR(50) NOT NULL DEFAULT 'default'`,
+			`ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS project_id VARCHAR(50) NOT NULL DEFAULT 'default'`,
+			`ALTER TABLE cleanup_jobs ADD COLUMN IF NOT EXISTS project_id VARCHAR(50) NOT NULL DEFAULT 'default'`,
+			`ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS project_id VARCHAR(50) NOT NULL DEFAULT 'default'`,
+			`CREATE INDEX IF NOT EXISTS idx_raw_data_project ON raw_data(project_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_project ON processed_data(project_id, source)`,
+			`CREATE INDEX IF NOT EXISTS idx_saved_searches_project ON saved_searches(project_id)`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "per-project pipeline settings",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS project_settings (
+				project_id VARCHAR(50) PRIMARY KEY REFERENCES projects(id),
+				overrides JSONB NOT NULL DEFAULT '{}',
+				updated_at TIMESTAMP DEFAULT NOW()
+			)`,
+		},
+	},
+	{
+		Version:     8,
+		Description: "sentiment cleanup checkpoints",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS cleanup_checkpoints (
+				checkpoint_key VARCHAR(255) PRIMARY KEY,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				last_id INTEGER NOT NULL,
+				updated_at TIMESTAMP DEFAULT NOW()
+			)`,
+		},
+	},
+	{
+		Version:     9,
+		Description: "daily aggregate rollups",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS daily_rollups (
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				day DATE NOT NULL,
+				source VARCHAR(50) NOT NULL,
+				sentiment VARCHAR(20) NOT NULL,
+				topic VARCHAR(100) NOT NULL,
+				province VARCHAR(100) NOT NULL,
+				record_count INTEGER NOT NULL,
+				sentiment_score_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+				relevance_score_sum DOUBLE PRECISION NOT NULL DEFAULT 0,
+				updated_at TIMESTAMP DEFAULT NOW(),
+				PRIMARY KEY (project_id, day, source, sentiment, topic, province)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_daily_rollups_day ON daily_rollups(project_id, day)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_processed_at ON processed_data(processed_at)`,
+		},
+	},
+	{
+		Version:     10,
+		Description: "published_at time axis",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS published_at TIMESTAMP`,
+			`UPDATE processed_data
+				SET published_at = (processed_data->>'published_at')::timestamptz
+				WHERE published_at IS NULL
+					AND processed_data->>'published_at' ~ '^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})$'`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_published_at ON processed_data(published_at)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_event_time ON processed_data((COALESCE(published_at, processed_at)))`,
+		},
+	},
+	{
+		Version:     11,
+		Description: "per-record license tags",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS license VARCHAR(100)`,
+			// Existing records get the default license of their source
+			`UPDATE processed_data SET license = CASE source
+				WHEN 'youtube' THEN 'youtube-tos'
+				WHEN 'instagram' THEN 'instagram-tos'
+				WHEN 'google_news' THEN 'publisher-copyright'
+				WHEN 'indonesia_news' THEN 'publisher-copyright'
+				ELSE 'unspecified'
+			END WHERE license IS NULL`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_license ON processed_data(license)`,
+		},
+	},
+	{
+		Version:     12,
+		Description: "toxicity score of social comments",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS toxicity_score REAL`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_toxicity_score ON processed_data(toxicity_score) WHERE toxicity_score IS NOT NULL`,
+		},
+	},
+	{
+		Version:     13,
+		Description: "record collections",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS collections (
+				id SERIAL PRIMARY KEY,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				user_id VARCHAR(100) NOT NULL,
+				name VARCHAR(255) NOT NULL,
+				description TEXT,
+				created_at TIMESTAMP DEFAULT NOW(),
+				updated_at TIMESTAMP DEFAULT NOW(),
+				UNIQUE (project_id, user_id, name)
+			)`,
+			`CREATE TABLE IF NOT EXISTS collection_items (
+				collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+				record_id INTEGER NOT NULL REFERENCES processed_data(id) ON DELETE CASCADE,
+				annotation TEXT,
+				added_at TIMESTAMP DEFAULT NOW(),
+				PRIMARY KEY (collection_id, record_id)
+			)`,
+		},
+	},
+	{
+		Version:     14,
+		Description: "analyst notes on records",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS record_notes (
+				id SERIAL PRIMARY KEY,
+				record_id INTEGER NOT NULL REFERENCES processed_data(id) ON DELETE CASCADE,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				author VARCHAR(100) NOT NULL,
+				body TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_record_notes_record ON record_notes(record_id)`,
+		},
+	},
+	{
+		Version:     15,
+		Description: "manual and automatic record tags",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS tags (
+				record_id INTEGER NOT NULL REFERENCES processed_data(id) ON DELETE CASCADE,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				name VARCHAR(100) NOT NULL,
+				origin VARCHAR(10) NOT NULL,
+				created_by VARCHAR(100),
+				created_at TIMESTAMP DEFAULT NOW(),
+				PRIMARY KEY (record_id, name)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_tags_project_name ON tags(project_id, name)`,
+		},
+	},
+	{
+		Version:     16,
+		Description: "official COVID-19 statistics",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS covid_statistics (
+				country VARCHAR(2) NOT NULL,
+				day DATE NOT NULL,
+				new_cases INTEGER,
+				new_deaths INTEGER,
+				new_recovered INTEGER,
+				new_vaccinations INTEGER,
+				cumulative_cases INTEGER,
+				cumulative_deaths INTEGER,
+				source VARCHAR(50) NOT NULL,
+				updated_at TIMESTAMP DEFAULT NOW(),
+				PRIMARY KEY (country, day)
+			)`,
+		},
+	},
+	{
+		Version:     17,
+		Description: "canonical source taxonomy",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS sources (
+				name VARCHAR(50) PRIMARY KEY,
+				label VARCHAR(100) NOT NULL,
+				kind VARCHAR(20) NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS source_aliases (
+				alias VARCHAR(100) PRIMARY KEY,
+				source VARCHAR(50) NOT NULL REFERENCES sources(name),
+				outlet VARCHAR(100)
+			)`,
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS outlet VARCHAR(100)`,
+			seedSourcesStatement(),
+			seedSourceAliasesStatement(),
+			// Instagram records were labelled "Instagram (@user)"; the account becomes the outlet
+			`UPDATE processed_data
+				SET source = 'instagram', outlet = COALESCE(outlet, substring(processed_data->>'source' from '^Instagram \((@[^)]+)\)$'))
+				WHERE processed_data->>'source' LIKE 'Instagram (@%'`,
+			// Other records are mapped by their source column, then by the label they were transformed with
+			`UPDATE processed_data p
+				SET source = a.source, outlet = COALESCE(p.outlet, a.outlet)
+				FROM source_aliases a
+				WHERE p.source NOT IN (SELECT name FROM sources) AND LOWER(p.source) = a.alias`,
+			`UPDATE processed_data p
+				SET source = a.source, outlet = COALESCE(p.outlet, a.outlet)
+				FROM source_aliases a
+				WHERE p.source NOT IN (SELECT name FROM sources) AND LOWER(p.processed_data->>'source') = a.alias`,
+			`UPDATE processed_data
+				SET source = 'other', outlet = COALESCE(outlet, NULLIF(processed_data->>'source', ''))
+				WHERE source NOT IN (SELECT name FROM sources)`,
+			// Rollups of the old spellings are dropped; rebuild them with POST /api/admin/rollups
+			`DELETE FROM daily_rollups WHERE source NOT IN (SELECT name FROM sources)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_outlet ON processed_data(project_id, outlet) WHERE outlet IS NOT NULL`,
+		},
+	},
+	{
+		Version:     18,
+		Description: "campaign of processed records",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS campaign VARCHAR(100)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_campaign ON processed_data(project_id, campaign) WHERE campaign IS NOT NULL`,
+		},
+	},
+	{
+		Version:     19,
+		Description: "content hash of processed records",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64)`,
+			`UPDATE processed_data SET content_hash = ` + contentHashSQL + ` WHERE content_hash IS NULL`,
+			// Duplicates loaded by earlier runs are soft-deleted, keeping the newest copy
+			`UPDATE processed_data p
+				SET deleted_at = NOW()
+				WHERE p.deleted_at IS NULL AND EXISTS (
+					SELECT 1 FROM processed_data newer
+					WHERE newer.project_id = p.project_id AND newer.content_hash = p.content_hash
+						AND newer.deleted_at IS NULL AND newer.id > p.id
+				)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_processed_data_content_hash ON processed_data(project_id, content_hash) WHERE deleted_at IS NULL AND content_hash IS NOT NULL`,
+		},
+	},
+	{
+		Version:     20,
+		Description: "near-duplicate groups",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS duplicate_of INTEGER REFERENCES processed_data(id) ON DELETE SET NULL`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_duplicate_of ON processed_data(duplicate_of) WHERE duplicate_of IS NOT NULL`,
+		},
+	},
+	{
+		Version:     21,
+		Description: "Google Trends search interest",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS search_interest (
+				geo VARCHAR(10) NOT NULL,
+				term VARCHAR(100) NOT NULL,
+				day DATE NOT NULL,
+				interest INTEGER NOT NULL,
+				updated_at TIMESTAMP DEFAULT NOW(),
+				PRIMARY KEY (geo, term, day)
+			)`,
+		},
+	},
+	{
+		Version:     22,
+		Description: "canonical records in daily rollups",
+		Statements: []string{
+			`ALTER TABLE daily_rollups ADD COLUMN IF NOT EXISTS canonical BOOLEAN NOT NULL DEFAULT TRUE`,
+			`ALTER TABLE daily_rollups DROP CONSTRAINT IF EXISTS daily_rollups_pkey`,
+			`ALTER TABLE daily_rollups ADD PRIMARY KEY (project_id, day, source, sentiment, topic, province, canonical)`,
+			// Days with records already grouped as duplicates are rebuilt
+			`CREATE TEMPORARY TABLE duplicate_days ON COMMIT DROP AS
+				SELECT DISTINCT project_id, (` + EventTimeColumn + `)::date AS day
+				FROM processed_data
+				WHERE duplicate_of IS NOT NULL AND deleted_at IS NULL`,
+			`DELETE FROM daily_rollups r USING duplicate_days d WHERE r.project_id = d.project_id AND r.day = d.day`,
+			`INSERT INTO daily_rollups (project_id, day, source, sentiment, topic, province, canonical,
+				record_count, sentiment_score_sum, relevance_score_sum, updated_at)
+				SELECT p.project_id, d.day, ` + rollupKeyValues + `,
+					COUNT(*), COALESCE(SUM(sentiment_score), 0), COALESCE(SUM(relevance_score), 0), NOW()
+				FROM processed_data p
+				JOIN duplicate_days d ON d.project_id = p.project_id AND d.day = (` + EventTimeColumn + `)::date
+				WHERE p.deleted_at IS NULL
+				GROUP BY 1, 2, 3, 4, 5, 6, 7`,
+		},
+	},
+	{
+		Version:     23,
+		Description: "versions of re-fetched articles",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS checked_at TIMESTAMP`,
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS changed_at TIMESTAMP`,
+			`CREATE TABLE IF NOT EXISTS record_versions (
+				record_id INTEGER NOT NULL REFERENCES processed_data(id) ON DELETE CASCADE,
+				version INTEGER NOT NULL,
+				title TEXT NOT NULL,
+				content TEXT NOT NULL,
+				content_digest VARCHAR(64) NOT NULL,
+				title_changed BOOLEAN NOT NULL DEFAULT FALSE,
+				content_changed BOOLEAN NOT NULL DEFAULT FALSE,
+				fetched_at TIMESTAMP DEFAULT NOW(),
+				PRIMARY KEY (record_id, version)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_changed_at ON processed_data(project_id, changed_at) WHERE changed_at IS NOT NULL`,
+		},
+	},
+	{
+		Version:     24,
+		Description: "news archive source of crawled articles",
+		Statements: []string{
+			seedSourcesStatement(),
+			seedSourceAliasesStatement(),
+		},
+	},
+	{
+		Version:     25,
+		Description: "raw data lookup by extraction time for reprocessing",
+		Statements: []string{
+			`CREATE INDEX IF NOT EXISTS idx_raw_data_extracted_at ON raw_data(project_id, extracted_at)`,
+		},
+	},
+	{
+		Version:     26,
+		Description: "star-schema warehouse of processed records",
+		Statements:  warehouseMigrationStatements(),
+	},
+	{
+		Version:     27,
+		Description: "record list filters by language and relevance",
+		Statements: []string{
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_language ON processed_data(project_id, (processed_data->>'language'))`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_relevance ON processed_data(project_id, relevance_score)`,
+		},
+	},
+	{
+		Version:     28,
+		Description: "named entities linked to records",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS entities (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(200) NOT NULL,
+				type VARCHAR(20) NOT NULL,
+				UNIQUE (name, type)
+			)`,
+			`CREATE TABLE IF NOT EXISTS record_entities (
+				record_id INTEGER NOT NULL REFERENCES processed_data(id) ON DELETE CASCADE,
+				entity_id INTEGER NOT NULL REFERENCES entities(id) ON DELETE CASCADE,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				mentions INTEGER NOT NULL DEFAULT 1,
+				PRIMARY KEY (record_id, entity_id)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_record_entities_project ON record_entities(project_id, entity_id)`,
+		},
+	},
+	{
+		Version:     29,
+		Description: "province codes of records",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS region_code VARCHAR(10)`,
+			provinceCodeBackfill(),
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_region_code ON processed_data(project_id, region_code)`,
+		},
+	},
+	{
+		Version:     30,
+		Description: "pipeline run history",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS pipeline_runs (
+				id SERIAL PRIMARY KEY,
+				batch_id VARCHAR(64) NOT NULL UNIQUE,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				kind VARCHAR(20) NOT NULL,
+				status VARCHAR(20) NOT NULL,
+				message TEXT,
+				error TEXT,
+				metrics JSONB,
+				started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+				completed_at TIMESTAMP
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_pipeline_runs_project ON pipeline_runs(project_id, id)`,
+		},
+	},
+	{
+		Version:     31,
+		Description: "operator alerts",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS alerts (
+				id SERIAL PRIMARY KEY,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				kind VARCHAR(30) NOT NULL,
+				alert_key VARCHAR(200) NOT NULL,
+				subject TEXT NOT NULL,
+				body TEXT,
+				error TEXT,
+				sent_at TIMESTAMP NOT NULL DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_alerts_key ON alerts(alert_key, sent_at)`,
+		},
+	},
+	{
+		Version:     32,
+		Description: "dashboard users",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS users (
+				id SERIAL PRIMARY KEY,
+				username VARCHAR(100) NOT NULL UNIQUE,
+				password_hash TEXT NOT NULL,
+				role VARCHAR(20) NOT NULL,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				created_at TIMESTAMP DEFAULT NOW(),
+				last_login_at TIMESTAMP
+			)`,
+		},
+	},
+	{
+		Version:     33,
+		Description: "record quarantine",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS quarantined_records (
+				id SERIAL PRIMARY KEY,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				batch_id VARCHAR(64),
+				campaign VARCHAR(100),
+				source VARCHAR(50) NOT NULL,
+				kind VARCHAR(20) NOT NULL,
+				record_id VARCHAR(100),
+				reasons JSONB NOT NULL,
+				record JSONB NOT NULL,
+				quarantined_at TIMESTAMP DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_quarantined_records_batch ON quarantined_records(project_id, batch_id)`,
+		},
+	},
+	{
+		Version:     34,
+		Description: "dead letter queue of failed loads",
+		Statements: []string{
+			// TEXT rather than JSONB so records whose content PostgreSQL
+			// rejects can still be kept
+			`CREATE TABLE IF NOT EXISTS dead_letter (
+				id SERIAL PRIMARY KEY,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				batch_id VARCHAR(64),
+				source VARCHAR(50) NOT NULL,
+				record TEXT NOT NULL,
+				topics TEXT,
+				entities TEXT,
+				error TEXT NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 1,
+				created_at TIMESTAMP DEFAULT NOW(),
+				last_attempt_at TIMESTAMP DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_dead_letter_project ON dead_letter(project_id, id)`,
+		},
+	},
+	{
+		Version:     35,
+		Description: "hashtag of processed Instagram records",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS hashtag VARCHAR(100)`,
+			`CREATE INDEX IF NOT EXISTS idx_processed_data_hashtag ON processed_data(project_id, hashtag) WHERE hashtag IS NOT NULL`,
+		},
+	},
+	{
+		Version:     36,
+		Description: "pipeline stage checkpoints",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS pipeline_checkpoints (
+				id SERIAL PRIMARY KEY,
+				batch_id VARCHAR(64) NOT NULL,
+				project_id VARCHAR(50) NOT NULL DEFAULT 'default',
+				campaign_index INTEGER NOT NULL,
+				campaign VARCHAR(100),
+				query TEXT,
+				stage VARCHAR(20) NOT NULL,
+				raw_data_ids JSONB NOT NULL DEFAULT '[]',
+				state JSONB,
+				created_at TIMESTAMP DEFAULT NOW(),
+				updated_at TIMESTAMP DEFAULT NOW(),
+				UNIQUE (batch_id, campaign_index)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_pipeline_checkpoints_project ON pipeline_checkpoints(project_id, updated_at)`,
+		},
+	},
+	{
+		Version:     37,
+		Description: "API requests per source and day",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS api_usage (
+				source VARCHAR(50) NOT NULL,
+				day DATE NOT NULL,
+				calls INTEGER NOT NULL DEFAULT 0,
+				updated_at TIMESTAMP DEFAULT NOW(),
+				PRIMARY KEY (source, day)
+			)`,
+		},
+	},
+	{
+		Version:     38,
+		Description: "raw data retention runs",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS retention_runs (
+				id SERIAL PRIMARY KEY,
+				mode VARCHAR(20) NOT NULL,
+				trigger VARCHAR(20) NOT NULL,
+				dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+				status VARCHAR(20) NOT NULL,
+				cutoff TIMESTAMP NOT NULL,
+				rows_pruned INTEGER NOT NULL DEFAULT 0,
+				bytes_reclaimed BIGINT NOT NULL DEFAULT 0,
+				table_bytes_before BIGINT NOT NULL DEFAULT 0,
+				table_bytes_after BIGINT NOT NULL DEFAULT 0,
+				archive_path TEXT,
+				error_message TEXT,
+				started_at TIMESTAMP DEFAULT NOW(),
+				completed_at TIMESTAMP
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_retention_runs_started ON retention_runs(started_at)`,
+		},
+	},
+	{
+		Version:     39,
+		Description: "processed_data partitioned by month",
+		Statements:  partitionMigrationStatements(),
+	},
+	{
+		Version:     40,
+		Description: "saved search cursors per notification channel",
+		Statements: []string{
+			`ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS webhook_matched_id INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS email_matched_id INTEGER NOT NULL DEFAULT 0`,
+			`UPDATE saved_searches SET webhook_matched_id = COALESCE(last_matched_id, 0), email_matched_id = COALESCE(last_matched_id, 0)`,
+		},
+	},
+	{
+		Version:     41,
+		Description: "sentiment rescore timestamp",
+		Statements: []string{
+			`ALTER TABLE processed_data ADD COLUMN IF NOT EXISTS rescored_at TIMESTAMP`,
+		},
+	},
+}
+
+// LatestSchemaVersion returns the schema version this build expects
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the highest migration applied to the database
+func SchemaVersion() (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var version int
+	err := DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	return version, nil
+}
+
+// Migrate creates the baseline schema, applies pending migrations in order and
+// creates the processed_data partitions of the coming months
+func Migrate() error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	if err := CreateTables(); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT,
+		applied_at TIMESTAMP DEFAULT NOW()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+
+	current, err := SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		if err := applyMigration(migration); err != nil {
+			return err
+		}
+		log.Printf("✅ Applied migration %d: %s", migration.Version, migration.Description)
+	}
+
+	created, err := EnsureProcessedDataPartitions(time.Now())
+	if err != nil {
+		return err
+	}
+	if len(created) > 0 {
+		log.Printf("🗂️ Created processed_data partitions for %s", strings.Join(created, ", "))
+	}
+
+	return nil
+}
+
+// applyMigration runs a migration and records it in a single transaction
+func applyMigration(migration Migration) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %v", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range migration.Statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("migration %d failed: %v", migration.Version, err)
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, description) VALUES ($1, $2)`, migration.Version, migration.Description)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %v", migration.Version, err)
+	}
+
+	return tx.Commit()
+}
@@ -17,16 +17,483 @@ type RawData struct {
 
 // ProcessedData represents processed data
 type ProcessedData struct {
-	ID                  int       `json:"id"`
-	Source              string    `json:"source"`
-	ProcessedAt         time.Time `json:"processed_at"`
-	Title               string    `json:"title"`
-	Content             string    `json:"content"`
-	RelevanceScore      float64   `json:"relevance_score"`
-	Sentiment           string    `json:"sentiment"`
-	SentimentScore      *float64  `json:"sentiment_score,omitempty"`
-	SentimentConfidence *float64  `json:"sentiment_confidence,omitempty"`
-	ProcessedData       string    `json:"processed_data"` // JSON string
+	ID                  int        `json:"id"`
+	Source              string     `json:"source"`           // canonical source of the taxonomy
+	Outlet              string     `json:"outlet,omitempty"` // publisher, channel or account within the source
+	ProcessedAt         time.Time  `json:"processed_at"`
+	PublishedAt         *time.Time `json:"published_at,omitempty"` // nil when the source has no date
+	Title               string     `json:"title"`
+	Content             string     `json:"content"`
+	RelevanceScore      float64    `json:"relevance_score"`
+	Sentiment           string     `json:"sentiment"`
+	SentimentScore      *float64   `json:"sentiment_score,omitempty"`
+	SentimentConfidence *float64   `json:"sentiment_confidence,omitempty"`
+	AnalyzerVersion     *int       `json:"analyzer_version,omitempty"` // nil for records scored before versioning
+	BatchID             string     `json:"batch_id,omitempty"`         // ETL run that loaded the record
+	ProjectID           string     `json:"project_id"`
+	License             string     `json:"license,omitempty"`        // redistribution terms of the source
+	ToxicityScore       *float64   `json:"toxicity_score,omitempty"` // nil for records that are not scored
+	Campaign            string     `json:"campaign,omitempty"`       // campaign whose query extracted the record
+	RegionCode          string     `json:"region_code,omitempty"`    // ISO 3166-2 code of the province, see ProvinceCodes
+	Hashtag             string     `json:"hashtag,omitempty"`        // Instagram hashtag whose feed the record was found in
+	ContentHash         string     `json:"content_hash,omitempty"`   // see ContentHash; unique among a project's live records
+	DuplicateOf         *int       `json:"duplicate_of,omitempty"`   // canonical record of the same story, nil for canonical and unique records
+	ChangedAt           *time.Time `json:"changed_at,omitempty"`     // when an edit of the article was last detected
+	ProcessedData       string     `json:"processed_data"`           // JSON string
+}
+
+// SavedSearch represents a stored query that is re-checked after every load
+type SavedSearch struct {
+	ID             int        `json:"id"`
+	ProjectID      string     `json:"project_id"`
+	UserID         string     `json:"user_id"`
+	Name           string     `json:"name"`
+	Query          string     `json:"query"`
+	Source         string     `json:"source,omitempty"`
+	Sentiment      string     `json:"sentiment,omitempty"`
+	NotifyEmail    string     `json:"notify_email,omitempty"`
+	WebhookURL     string     `json:"webhook_url,omitempty"`
+	LastMatchedID  int        `json:"last_matched_id"`
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// WebhookMatchedID and EmailMatchedID are the last records delivered per
+	// notification channel; a failed delivery holds back only its channel
+	WebhookMatchedID int `json:"webhook_matched_id"`
+	EmailMatchedID   int `json:"email_matched_id"`
+}
+
+// Collection is a user's named set of records curated as evidence
+type Collection struct {
+	ID          int       `json:"id"`
+	ProjectID   string    `json:"project_id"`
+	UserID      string    `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	ItemCount   int       `json:"item_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CollectionItem is a record in a collection with the curator's annotation
+type CollectionItem struct {
+	ProcessedData
+	Annotation string       `json:"annotation,omitempty"`
+	AddedAt    time.Time    `json:"added_at"`
+	Notes      []RecordNote `json:"notes,omitempty"` // analyst notes on the record, oldest first
+}
+
+// Tag origins
+const (
+	TagOriginManual = "manual" // added by an analyst
+	TagOriginAuto   = "auto"   // added at load time from topic classification
+)
+
+// RecordTag is a tag on a record
+type RecordTag struct {
+	Name      string    `json:"name"`
+	Origin    string    `json:"origin"` // TagOriginManual or TagOriginAuto
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagCount is the number of records carrying a tag, split by origin
+type TagCount struct {
+	Name        string `json:"name"`
+	Count       int    `json:"count"`
+	ManualCount int    `json:"manual_count"`
+	AutoCount   int    `json:"auto_count"`
+}
+
+// RecordEntity is a named entity a record mentions
+type RecordEntity struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // person, place or organization
+	Mentions int    `json:"mentions"`
+}
+
+// EntityCount is the number of records mentioning an entity and the mentions
+// across them
+type EntityCount struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	RecordCount int    `json:"record_count"`
+	Mentions    int    `json:"mentions"`
+}
+
+// ProvinceCount is the number of records about a province and their
+// sentiment
+type ProvinceCount struct {
+	Code         string  `json:"code"` // ISO 3166-2 code
+	Province     string  `json:"province"`
+	Count        int     `json:"count"`
+	Positive     int     `json:"positive"`
+	Negative     int     `json:"negative"`
+	Neutral      int     `json:"neutral"`
+	AverageScore float64 `json:"average_sentiment_score"`
+}
+
+// RecordNote is a piece of free-text analyst commentary on a record
+type RecordNote struct {
+	ID        int       `json:"id"`
+	RecordID  int       `json:"record_id"`
+	ProjectID string    `json:"project_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportJob represents an asynchronous export of processed data to a file
+type ExportJob struct {
+	ID           string              `json:"id"`
+	Format       string              `json:"format"`
+	Filters      ProcessedDataFilter `json:"filters"`
+	Status       string              `json:"status"` // "pending", "running", "completed", "failed"
+	FilePath     string              `json:"-"`
+	RecordCount  int                 `json:"record_count"`
+	ErrorMessage string              `json:"error,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	CompletedAt  *time.Time          `json:"completed_at,omitempty"`
+}
+
+// BackupRecord tracks a logical backup of the raw and processed tables
+type BackupRecord struct {
+	ID           int            `json:"id"`
+	Method       string         `json:"method"`  // "pg_dump" or "snapshot"
+	Trigger      string         `json:"trigger"` // "api" or "cli"
+	Status       string         `json:"status"`  // "running", "completed", "failed"
+	FilePath     string         `json:"file_path,omitempty"`
+	SizeBytes    int64          `json:"size_bytes"`
+	RowCounts    map[string]int `json:"row_counts,omitempty"`
+	ErrorMessage string         `json:"error,omitempty"`
+	StartedAt    time.Time      `json:"started_at"`
+	CompletedAt  *time.Time     `json:"completed_at,omitempty"`
+}
+
+// RetentionRun records one pruning of raw_data rows older than the
+// retention cutoff. Dry runs report what would have been pruned.
+type RetentionRun struct {
+	ID               int        `json:"id"`
+	Mode             string     `json:"mode"`    // "archive" or "delete"
+	Trigger          string     `json:"trigger"` // "scheduler", "api" or "cli"
+	DryRun           bool       `json:"dry_run"`
+	Status           string     `json:"status"` // "running", "completed", "failed"
+	Cutoff           time.Time  `json:"cutoff"`
+	RowsPruned       int        `json:"rows_pruned"`
+	BytesReclaimed   int64      `json:"bytes_reclaimed"` // stored size of the pruned rows
+	TableBytesBefore int64      `json:"table_bytes_before"`
+	TableBytesAfter  int64      `json:"table_bytes_after"`
+	ArchivePath      string     `json:"archive_path,omitempty"`
+	ErrorMessage     string     `json:"error,omitempty"`
+	StartedAt        time.Time  `json:"started_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+}
+
+// CleanupJob tracks a batch reprocessing job over processed_data
+type CleanupJob struct {
+	ID               int          `json:"id"`
+	JobType          string       `json:"job_type"` // "relevance" or "language"
+	Scope            CleanupScope `json:"scope"`
+	Status           string       `json:"status"` // "running", "completed", "completed_with_errors", "failed"
+	TotalRecords     int          `json:"total_records"`
+	ProcessedRecords int          `json:"processed_records"`
+	UpdatedRecords   int          `json:"updated_records"`
+	ErrorRecords     int          `json:"error_records"`
+	Errors           []string     `json:"errors,omitempty"`
+	StartedAt        time.Time    `json:"started_at"`
+	CompletedAt      *time.Time   `json:"completed_at,omitempty"`
+}
+
+// CleanupScope limits a cleanup job to a source and/or processing date range
+type CleanupScope struct {
+	Project   string     `json:"project,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
+
+// PipelineRun is the history entry of a pipeline, crawl or reprocess run
+type PipelineRun struct {
+	ID          int                    `json:"id"`
+	BatchID     string                 `json:"batch_id"`
+	ProjectID   string                 `json:"project_id"`
+	Kind        string                 `json:"kind"`   // "pipeline", "crawl" or "reprocess"
+	Status      string                 `json:"status"` // "running", "success", "error", "cancelled"
+	Message     string                 `json:"message,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Metrics     map[string]interface{} `json:"metrics,omitempty"` // per-stage metrics
+	StartedAt   time.Time              `json:"started_at"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+}
+
+// PipelineCheckpoint is the last completed stage of one campaign of a
+// pipeline run, kept while the run is in progress so that a run interrupted
+// by a shutdown can be resumed from it
+type PipelineCheckpoint struct {
+	ID            int       `json:"id"`
+	BatchID       string    `json:"batch_id"`
+	ProjectID     string    `json:"project_id"`
+	CampaignIndex int       `json:"campaign_index"`
+	Campaign      string    `json:"campaign,omitempty"`
+	Query         string    `json:"query"`
+	Stage         string    `json:"stage"`           // "extracted", "transformed" or "loaded"
+	RawDataIDs    []int     `json:"raw_data_ids"`    // raw_data rows holding the campaign's extracted payloads
+	State         string    `json:"state,omitempty"` // JSON of the stage outputs a resumed run reuses
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Alert is an operator alert emailed after a pipeline run
+type Alert struct {
+	ID        int       `json:"id"`
+	ProjectID string    `json:"project_id"`
+	Kind      string    `json:"kind"` // "run_failed", "zero_records" or "sentiment_shift"
+	Key       string    `json:"key"`  // alerts with the same key share a suppression window
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	Error     string    `json:"error,omitempty"` // why emailing failed
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// AuditEntry records who performed an administrative or data-mutating action
+type AuditEntry struct {
+	ID         int       `json:"id"`
+	Actor      string    `json:"actor"` // "api_key:<fingerprint>", "user:<id>" or "anonymous"
+	ProjectID  string    `json:"project_id"`
+	Action     string    `json:"action"` // e.g. "etl.run", "cleanup.sentiment", "record.delete"
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	StatusCode int       `json:"status_code"`
+	RemoteAddr string    `json:"remote_addr"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Project is a research group's separate corpus within a shared deployment
+type Project struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// User is a dashboard user who logs in for API tokens. Viewers may only
+// read the data of their project; admins may change anything.
+type User struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	PasswordHash string     `json:"-"`
+	Role         string     `json:"role"` // "viewer" or "admin"
+	ProjectID    string     `json:"project_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+}
+
+// ProjectAPIKey is an API key scoped to a single project. Only a hash of
+// the key is stored.
+type ProjectAPIKey struct {
+	ID        int       `json:"id"`
+	ProjectID string    `json:"project_id"`
+	Label     string    `json:"label"`
+	Prefix    string    `json:"prefix"` // first characters of the key, for identification
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectOverrides holds the pipeline settings a project overrides. Unset
+// fields fall back to the global ETL configuration.
+type ProjectOverrides struct {
+	Keywords         []string        `json:"keywords,omitempty"`
+	Sources          map[string]bool `json:"sources,omitempty"` // source name -> enabled
+	MinRelevance     *float64        `json:"min_relevance,omitempty"`
+	ScheduleInterval string          `json:"schedule_interval,omitempty"` // Go duration, "0" disables
+}
+
+// ProjectSettings is the stored override set of a project
+type ProjectSettings struct {
+	ProjectID string           `json:"project_id"`
+	Overrides ProjectOverrides `json:"overrides"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// TrendPoint is one day of a trend read from the daily rollups, grouped by a
+// single dimension value (a source, sentiment, topic or province)
+type TrendPoint struct {
+	Day               string  `json:"day"` // YYYY-MM-DD
+	Value             string  `json:"value"`
+	RecordCount       int     `json:"record_count"`
+	AvgSentimentScore float64 `json:"avg_sentiment_score"`
+	AvgRelevanceScore float64 `json:"avg_relevance_score"`
+}
+
+// TimeSeriesPoint is one period of a source's records, counted from the
+// records themselves
+type TimeSeriesPoint struct {
+	Period            string  `json:"period"` // YYYY-MM-DD of the period's first day
+	Source            string  `json:"source"`
+	RecordCount       int     `json:"record_count"`
+	AvgSentimentScore float64 `json:"avg_sentiment_score"`
+	AvgRelevanceScore float64 `json:"avg_relevance_score"`
+}
+
+// SentimentCounts counts the records of a source, or of all sources, per
+// sentiment. The percentages are shares of Total, rounded to one decimal.
+type SentimentCounts struct {
+	Source      string  `json:"source,omitempty"` // empty for the overall counts
+	Positive    int     `json:"positive"`
+	Negative    int     `json:"negative"`
+	Neutral     int     `json:"neutral"`
+	Total       int     `json:"total"`
+	PositivePct float64 `json:"positive_pct"`
+	NegativePct float64 `json:"negative_pct"`
+	NeutralPct  float64 `json:"neutral_pct"`
+}
+
+// WordCount is how often a word occurs in a project's records, split by the
+// sentiment of the records
+type WordCount struct {
+	Word          string  `json:"word"`
+	Count         int     `json:"count"`
+	PositiveCount int     `json:"positive_count"`
+	NegativeCount int     `json:"negative_count"`
+	NeutralCount  int     `json:"neutral_count"`
+	AvgSentiment  float64 `json:"avg_sentiment"`
+}
+
+// RecordWords is the date of a record and the distinct words it contains
+type RecordWords struct {
+	At    time.Time
+	Words []string
+}
+
+// CovidStatistic holds the official COVID-19 figures of one country and day.
+// Figures a source does not report are nil.
+type CovidStatistic struct {
+	Country          string    `json:"country"` // ISO 3166-1 alpha-2 code
+	Day              string    `json:"day"`     // YYYY-MM-DD
+	NewCases         *int      `json:"new_cases"`
+	NewDeaths        *int      `json:"new_deaths"`
+	NewRecovered     *int      `json:"new_recovered"`
+	NewVaccinations  *int      `json:"new_vaccinations"` // doses given
+	CumulativeCases  *int      `json:"cumulative_cases"`
+	CumulativeDeaths *int      `json:"cumulative_deaths"`
+	Source           string    `json:"source"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// RecordVersion is the content of an article as fetched from its URL.
+// Version 1 is the first fetch; each later version is stored only when the
+// title or content differs from the previous one.
+type RecordVersion struct {
+	RecordID       int       `json:"record_id"`
+	Version        int       `json:"version"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	ContentDigest  string    `json:"content_digest"`
+	TitleChanged   bool      `json:"title_changed"`
+	ContentChanged bool      `json:"content_changed"`
+	FetchedAt      time.Time `json:"fetched_at"`
+}
+
+// UpdateCheckTarget is an ingested article due to be fetched again
+type UpdateCheckTarget struct {
+	RecordID  int
+	ProjectID string
+	URL       string
+}
+
+// QuarantinedRecord is a transformed record that failed validation, kept
+// with the rules it broke so it can be inspected instead of loaded
+type QuarantinedRecord struct {
+	ID            int       `json:"id"`
+	ProjectID     string    `json:"project_id"`
+	BatchID       string    `json:"batch_id,omitempty"`
+	Campaign      string    `json:"campaign,omitempty"`
+	Source        string    `json:"source"`
+	Kind          string    `json:"kind"` // "video" or "article"
+	RecordID      string    `json:"record_id,omitempty"`
+	Reasons       []string  `json:"reasons"`
+	Record        string    `json:"record"` // JSON string of the transformed record
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// DeadLetter is a processed record whose load failed, kept with the error
+// until a retry loads it
+type DeadLetter struct {
+	ID            int       `json:"id"`
+	ProjectID     string    `json:"project_id"`
+	BatchID       string    `json:"batch_id,omitempty"`
+	Source        string    `json:"source"`
+	Record        string    `json:"record"`             // JSON of the ProcessedData
+	Topics        string    `json:"topics,omitempty"`   // JSON of the classified topics
+	Entities      string    `json:"entities,omitempty"` // JSON of the extracted entities
+	Error         string    `json:"error"`              // error of the latest attempt
+	Attempts      int       `json:"attempts"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastAttemptAt time.Time `json:"last_attempt_at"`
+}
+
+// SearchInterest is the Google Trends interest in a term on one day, from 0
+// to 100 relative to the busiest day of the terms compared with it
+type SearchInterest struct {
+	Geo       string    `json:"geo"`
+	Term      string    `json:"term"`
+	Day       string    `json:"day"` // YYYY-MM-DD
+	Interest  int       `json:"interest"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SentimentCasePoint is one day of social sentiment from the daily rollups
+// next to the official figures and search interest of that day
+type SentimentCasePoint struct {
+	Day               string         `json:"day"` // YYYY-MM-DD
+	RecordCount       int            `json:"record_count"`
+	AvgSentimentScore float64        `json:"avg_sentiment_score"`
+	NegativeShare     float64        `json:"negative_share"`
+	NewCases          *int           `json:"new_cases"`
+	NewDeaths         *int           `json:"new_deaths"`
+	NewVaccinations   *int           `json:"new_vaccinations"`
+	SearchInterest    map[string]int `json:"search_interest,omitempty"` // by term
+}
+
+// AspectSentimentSummary is the sentiment about one aspect (government,
+// vaccine, economy) across the records that mention it
+type AspectSentimentSummary struct {
+	Aspect      string  `json:"aspect"`
+	RecordCount int     `json:"record_count"`
+	Mentions    int     `json:"mentions"` // sentences mentioning the aspect
+	AvgScore    float64 `json:"avg_score"`
+	Positive    int     `json:"positive"`
+	Negative    int     `json:"negative"`
+	Neutral     int     `json:"neutral"`
+}
+
+// ProcessedDataFilter narrows down processed data queries
+type ProcessedDataFilter struct {
+	Project   string     `json:"project,omitempty"` // empty matches every project
+	Query     string     `json:"query,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	Sentiment string     `json:"sentiment,omitempty"`
+	Campaign  string     `json:"campaign,omitempty"`
+	Hashtag   string     `json:"hashtag,omitempty"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	AfterID   int        `json:"after_id,omitempty"`
+	BeforeID  int        `json:"before_id,omitempty"`
+
+	// ExcludeLicenses drops records under any of these license tags
+	ExcludeLicenses []string `json:"exclude_licenses,omitempty"`
+
+	// MinRelevance drops records scored below it
+	MinRelevance *float64 `json:"min_relevance,omitempty"`
+
+	// Language keeps records detected in this language, e.g. "id"
+	Language string `json:"language,omitempty"`
+
+	// MinToxicity and MaxToxicity bound the toxicity score; either one drops
+	// records that were never scored
+	MinToxicity *float64 `json:"min_toxicity,omitempty"`
+	MaxToxicity *float64 `json:"max_toxicity,omitempty"`
+
+	// Tags keeps records that carry every one of these tags
+	Tags []string `json:"tags,omitempty"`
 }
 
 // CreateTables creates all necessary tables
@@ -51,9 +518,46 @@ func CreateTables() error {
 			sentiment_confidence DECIMAL(3,2),
 			processed_data JSONB NOT NULL
 		)`,
+		`CREATE TABLE IF NOT EXISTS saved_searches (
+			id SERIAL PRIMARY KEY,
+			user_id VARCHAR(100) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			query TEXT NOT NULL,
+			source VARCHAR(50),
+			sentiment VARCHAR(20),
+			notify_email VARCHAR(255),
+			webhook_url TEXT,
+			last_matched_id INTEGER DEFAULT 0,
+			last_notified_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT NOW()
+		)`,
+		`CREATE TABLE IF NOT EXISTS export_jobs (
+			id VARCHAR(64) PRIMARY KEY,
+			format VARCHAR(10) NOT NULL,
+			filters JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			file_path TEXT,
+			record_count INTEGER DEFAULT 0,
+			error_message TEXT,
+			created_at TIMESTAMP DEFAULT NOW(),
+			completed_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS backups (
+			id SERIAL PRIMARY KEY,
+			method VARCHAR(20) NOT NULL,
+			trigger VARCHAR(20) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			file_path TEXT,
+			size_bytes BIGINT DEFAULT 0,
+			row_counts JSONB,
+			error_message TEXT,
+			started_at TIMESTAMP DEFAULT NOW(),
+			completed_at TIMESTAMP
+		)`,
 		`CREATE INDEX IF NOT EXISTS idx_raw_data_source ON raw_data(source)`,
 		`CREATE INDEX IF NOT EXISTS idx_processed_data_source ON processed_data(source)`,
 		`CREATE INDEX IF NOT EXISTS idx_processed_data_timestamp ON processed_data(processed_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_saved_searches_user ON saved_searches(user_id)`,
 	}
 
 	for _, query := range queries {
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// CreateRecordNote stores a note on a record
+func CreateRecordNote(note *RecordNote) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	note.ProjectID = projectIDOrDefault(note.ProjectID)
+
+	sqlQuery := `
+		INSERT INTO record_notes (record_id, project_id, author, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := DB.QueryRow(sqlQuery, note.RecordID, note.ProjectID, note.Author, note.Body).Scan(&note.ID, &note.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert record note: %v", err)
+	}
+
+	return nil
+}
+
+// GetRecordNotes returns the notes on the given records of a project, keyed
+// by record ID, oldest first
+func GetRecordNotes(projectID string, recordIDs []int) (map[int][]RecordNote, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, record_id, project_id, author, body, created_at
+		FROM record_notes
+		WHERE project_id = $1 AND record_id = ANY($2)
+		ORDER BY created_at, id
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), pq.Array(recordIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record notes: %v", err)
+	}
+	defer rows.Close()
+
+	notes := make(map[int][]RecordNote)
+	for rows.Next() {
+		var note RecordNote
+		if err := rows.Scan(&note.ID, &note.RecordID, &note.ProjectID, &note.Author, &note.Body, &note.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan record note: %v", err)
+		}
+		notes[note.RecordID] = append(notes[note.RecordID], note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate record notes: %v", err)
+	}
+
+	return notes, nil
+}
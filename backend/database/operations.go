@@ -1,167 +1,402 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/lib/pq"
 )
 
-// InsertRawData inserts raw data into the database
-func InsertRawData(source, query string, rawData interface{}) error {
+// insertRawDataQuery inserts a raw payload. It is prepared once, see prepared.
+const insertRawDataQuery = `
+	INSERT INTO raw_data (project_id, source, query, raw_data, batch_id)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	RETURNING id
+`
+
+// InsertRawData inserts raw data into the database and returns its ID
+func InsertRawData(projectID, source, query, batchID string, rawData interface{}) (int, error) {
+	return InsertRawDataContext(context.Background(), projectID, source, query, batchID, rawData)
+}
+
+// InsertRawDataContext is InsertRawData with a context that cancels the insert
+func InsertRawDataContext(ctx context.Context, projectID, source, query, batchID string, rawData interface{}) (int, error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
 	jsonData, err := json.Marshal(rawData)
 	if err != nil {
-		return fmt.Errorf("failed to marshal raw data: %v", err)
+		return 0, fmt.Errorf("failed to marshal raw data: %v", err)
+	}
+
+	stmt, err := prepared(ctx, DB, insertRawDataQuery)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = stmt.QueryRowContext(ctx, projectIDOrDefault(projectID), source, query, string(jsonData), batchID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert raw data: %v", err)
+	}
+
+	return id, nil
+}
+
+// GetRawDataByBatch returns the raw payloads stored by one pipeline run,
+// optionally limited to a single source
+func GetRawDataByBatch(projectID, batchID, source string) ([]RawData, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
 	}
 
 	sqlQuery := `
-		INSERT INTO raw_data (source, query, raw_data)
-		VALUES ($1, $2, $3)
+		SELECT id, source, extracted_at, raw_data, COALESCE(query, '')
+		FROM raw_data
+		WHERE batch_id = $1 AND project_id = $2 AND deleted_at IS NULL
 	`
+	args := []interface{}{batchID, projectIDOrDefault(projectID)}
+	if source != "" {
+		sqlQuery += " AND source = $3"
+		args = append(args, source)
+	}
+	sqlQuery += " ORDER BY id"
 
-	_, err = DB.Exec(sqlQuery, source, query, string(jsonData))
+	rows, err := DB.Query(sqlQuery, args...)
 	if err != nil {
-		return fmt.Errorf("failed to insert raw data: %v", err)
+		return nil, fmt.Errorf("failed to query raw data: %v", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var results []RawData
+	for rows.Next() {
+		var data RawData
+		if err := rows.Scan(&data.ID, &data.Source, &data.ExtractedAt, &data.RawData, &data.Query); err != nil {
+			return nil, fmt.Errorf("failed to scan raw data: %v", err)
+		}
+		results = append(results, data)
+	}
+
+	return results, rows.Err()
 }
 
-// InsertProcessedData inserts processed data into the database
-func InsertProcessedData(data *ProcessedData) error {
+// GetRawDataByIDs returns the live raw payloads of a project with the given
+// IDs, in ID order
+func GetRawDataByIDs(projectID string, ids []int) ([]RawData, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
 	sqlQuery := `
-		INSERT INTO processed_data (source, title, content, relevance_score, sentiment, sentiment_score, sentiment_confidence, processed_data)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		SELECT id, source, extracted_at, raw_data, COALESCE(query, '')
+		FROM raw_data
+		WHERE project_id = $1 AND id = ANY($2) AND deleted_at IS NULL
+		ORDER BY id
 	`
 
-	_, err := DB.Exec(sqlQuery,
-		data.Source,
-		data.Title,
-		data.Content,
-		data.RelevanceScore,
-		data.Sentiment,
-		data.SentimentScore,
-		data.SentimentConfidence,
-		data.ProcessedData,
-	)
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), pq.Array(ids))
 	if err != nil {
-		return fmt.Errorf("failed to insert processed data: %v", err)
+		return nil, fmt.Errorf("failed to query raw data: %v", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var results []RawData
+	for rows.Next() {
+		var data RawData
+		if err := rows.Scan(&data.ID, &data.Source, &data.ExtractedAt, &data.RawData, &data.Query); err != nil {
+			return nil, fmt.Errorf("failed to scan raw data: %v", err)
+		}
+		results = append(results, data)
+	}
+
+	return results, rows.Err()
 }
 
-// GetLatestProcessedData retrieves the latest processed data
-func GetLatestProcessedData(limit int) ([]ProcessedData, error) {
-	// Check if database is connected and ensure connection is alive
+// StreamRawData calls fn for every live raw payload of a project extracted
+// since from and before to, optionally limited to a single source, in ID
+// order without holding the payloads in memory. A nil to means up to now.
+func StreamRawData(projectID, source string, from time.Time, to *time.Time, fn func(RawData) error) error {
 	if err := EnsureConnection(); err != nil {
-		return []ProcessedData{}, fmt.Errorf("database connection issue: %v", err)
+		return fmt.Errorf("database connection issue: %v", err)
 	}
 
 	sqlQuery := `
-		SELECT id, source, processed_at, title, content, relevance_score, sentiment, sentiment_score, sentiment_confidence, processed_data
-		FROM processed_data 
-		ORDER BY processed_at DESC 
-		LIMIT $1
+		SELECT id, source, extracted_at, raw_data, COALESCE(query, '')
+		FROM raw_data
+		WHERE project_id = $1 AND extracted_at >= $2 AND deleted_at IS NULL
 	`
+	args := []interface{}{projectIDOrDefault(projectID), from}
+	if to != nil {
+		args = append(args, *to)
+		sqlQuery += fmt.Sprintf(" AND extracted_at < $%d", len(args))
+	}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+	sqlQuery += " ORDER BY id"
 
-	rows, err := DB.Query(sqlQuery, limit)
+	rows, err := DB.Query(sqlQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query processed data: %v", err)
+		return fmt.Errorf("failed to query raw data: %v", err)
 	}
 	defer rows.Close()
 
-	var results []ProcessedData
 	for rows.Next() {
-		var data ProcessedData
-		err := rows.Scan(
-			&data.ID,
-			&data.Source,
-			&data.ProcessedAt,
-			&data.Title,
-			&data.Content,
-			&data.RelevanceScore,
-			&data.Sentiment,
-			&data.SentimentScore,
-			&data.SentimentConfidence,
-			&data.ProcessedData,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %v", err)
+		var data RawData
+		if err := rows.Scan(&data.ID, &data.Source, &data.ExtractedAt, &data.RawData, &data.Query); err != nil {
+			return fmt.Errorf("failed to scan raw data: %v", err)
+		}
+		if err := fn(data); err != nil {
+			return err
 		}
-		results = append(results, data)
 	}
 
-	return results, nil
+	return rows.Err()
 }
 
-// GetDataBySource retrieves data by source
-func GetDataBySource(source string, limit int) ([]ProcessedData, error) {
-	// Check if database is connected and ensure connection is alive
+// GetBatchSample returns a random sample of up to n live processed records
+// loaded by one pipeline run, optionally limited to a single source. Sources
+// take turns, so every source of the run is represented in small samples.
+func GetBatchSample(projectID, batchID, source string, n int) ([]ProcessedData, error) {
 	if err := EnsureConnection(); err != nil {
-		return []ProcessedData{}, fmt.Errorf("database connection issue: %v", err)
+		return nil, fmt.Errorf("database connection issue: %v", err)
 	}
 
-	var sqlQuery string
-	var args []interface{}
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY source ORDER BY random()) AS sample_rank
+			FROM processed_data
+			WHERE batch_id = $1 AND project_id = $2 AND deleted_at IS NULL AND ($3 = '' OR source = $3)
+		) sampled
+		ORDER BY sample_rank, random()
+		LIMIT $4
+	`
 
-	if limit > 0 {
-		// If limit specified, use it
-		sqlQuery = `
-			SELECT id, source, processed_at, title, content, relevance_score, sentiment, sentiment_score, sentiment_confidence, processed_data
-			FROM processed_data 
-			WHERE source = $1
-			ORDER BY processed_at DESC 
-			LIMIT $2
-		`
-		args = []interface{}{source, limit}
-	} else {
-		// If no limit (or limit = 0), get ALL data
-		sqlQuery = `
-			SELECT id, source, processed_at, title, content, relevance_score, sentiment, sentiment_score, sentiment_confidence, processed_data
-			FROM processed_data 
-			WHERE source = $1
-			ORDER BY processed_at DESC
-		`
-		args = []interface{}{source}
+	rows, err := DB.Query(sqlQuery, batchID, projectIDOrDefault(projectID), source, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query run sample: %v", err)
 	}
+	defer rows.Close()
 
-	rows, err := DB.Query(sqlQuery, args...)
+	return scanProcessedData(rows)
+}
+
+// GetBatchSourceCounts returns the number of live processed records loaded by
+// one pipeline run per source
+func GetBatchSourceCounts(projectID, batchID string) (map[string]int, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rows, err := DB.Query(`
+		SELECT source, COUNT(*)
+		FROM processed_data
+		WHERE batch_id = $1 AND project_id = $2 AND deleted_at IS NULL
+		GROUP BY source
+	`, batchID, projectIDOrDefault(projectID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to query data by source: %v", err)
+		return nil, fmt.Errorf("failed to query run source counts: %v", err)
 	}
 	defer rows.Close()
 
-	var results []ProcessedData
+	counts := make(map[string]int)
 	for rows.Next() {
-		var data ProcessedData
-		err := rows.Scan(
-			&data.ID,
-			&data.Source,
-			&data.ProcessedAt,
-			&data.Title,
-			&data.Content,
-			&data.RelevanceScore,
-			&data.Sentiment,
-			&data.SentimentScore,
-			&data.SentimentConfidence,
-			&data.ProcessedData,
-		)
+		var source string
+		var count int
+		if err := rows.Scan(&source, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan run source counts: %v", err)
+		}
+		counts[source] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// UpsertProcessedData inserts processed data and sets its ID. A record whose
+// content hash matches a live record of the project updates that record
+// instead, keeping its ID and processed_at; inserted reports which happened.
+func UpsertProcessedData(data *ProcessedData) (inserted bool, err error) {
+	return UpsertProcessedDataContext(context.Background(), data)
+}
+
+// UpsertProcessedDataContext is UpsertProcessedData with a context that
+// cancels the load and rolls it back
+func UpsertProcessedDataContext(ctx context.Context, data *ProcessedData) (inserted bool, err error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	db := DB
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin record load: %v", err)
+	}
+	defer tx.Rollback()
+
+	inserted, err = upsertProcessedData(ctx, db, tx, data)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit record load: %v", err)
+	}
+	return inserted, nil
+}
+
+// The statements of upsertProcessedData. They are prepared once, see
+// prepared, as every loaded record runs them.
+const (
+	lockContentHashQuery = `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`
+
+	updateProcessedDataQuery = `
+		UPDATE processed_data SET
+			source = $1,
+			title = $2,
+			content = $3,
+			relevance_score = $4,
+			sentiment = $5,
+			sentiment_score = $6,
+			sentiment_confidence = $7,
+			analyzer_version = $8,
+			processed_data = $9,
+			batch_id = NULLIF($10, ''),
+			published_at = COALESCE($12, published_at),
+			license = NULLIF($13, ''),
+			toxicity_score = $14,
+			outlet = NULLIF($15, ''),
+			campaign = COALESCE(NULLIF($16, ''), campaign),
+			region_code = NULLIF($18, ''),
+			hashtag = COALESCE(NULLIF($19, ''), hashtag)
+		WHERE project_id = $11 AND content_hash = NULLIF($17, '') AND deleted_at IS NULL
+		RETURNING id
+	`
+
+	insertProcessedDataQuery = `
+		INSERT INTO processed_data (source, title, content, relevance_score, sentiment, sentiment_score, sentiment_confidence, analyzer_version, processed_data, batch_id, project_id, published_at, license, toxicity_score, outlet, campaign, content_hash, region_code, hashtag)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, NULLIF($13, ''), $14, NULLIF($15, ''), NULLIF($16, ''), NULLIF($17, ''), NULLIF($18, ''), NULLIF($19, ''))
+		RETURNING id
+	`
+)
+
+// upsertProcessedData runs the processed record upsert in a transaction. A
+// unique index on processed_data must contain processed_at, so it cannot keep
+// content hashes unique across the monthly partitions. Instead the upsert
+// holds a transaction lock on the project and content hash while it updates
+// the live record with that hash or, without one, inserts the record. tx
+// must have been begun on db.
+func upsertProcessedData(ctx context.Context, db *sql.DB, tx *sql.Tx, data *ProcessedData) (inserted bool, err error) {
+	projectID := projectIDOrDefault(data.ProjectID)
+	if data.ContentHash != "" {
+		lock, err := preparedTx(ctx, db, tx, lockContentHashQuery)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %v", err)
+			return false, err
+		}
+		if _, err := lock.ExecContext(ctx, projectID, data.ContentHash); err != nil {
+			return false, fmt.Errorf("failed to lock content hash: %v", err)
 		}
-		results = append(results, data)
 	}
 
-	return results, nil
+	args := []interface{}{
+		data.Source,
+		data.Title,
+		data.Content,
+		data.RelevanceScore,
+		data.Sentiment,
+		data.SentimentScore,
+		data.SentimentConfidence,
+		data.AnalyzerVersion,
+		data.ProcessedData,
+		data.BatchID,
+		projectID,
+		data.PublishedAt,
+		data.License,
+		data.ToxicityScore,
+		data.Outlet,
+		data.Campaign,
+		data.ContentHash,
+		data.RegionCode,
+		data.Hashtag,
+	}
+
+	update, err := preparedTx(ctx, db, tx, updateProcessedDataQuery)
+	if err != nil {
+		return false, err
+	}
+	err = update.QueryRowContext(ctx, args...).Scan(&data.ID)
+	if err == nil {
+		return false, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to upsert processed data: %v", err)
+	}
+
+	insert, err := preparedTx(ctx, db, tx, insertProcessedDataQuery)
+	if err != nil {
+		return false, err
+	}
+	if err := insert.QueryRowContext(ctx, args...).Scan(&data.ID); err != nil {
+		return false, fmt.Errorf("failed to upsert processed data: %v", err)
+	}
+
+	return true, nil
+}
+
+// processedDataByIDQuery selects a live record by ID. It is prepared once,
+// see prepared.
+var processedDataByIDQuery = `
+	SELECT ` + processedDataColumns + `
+	FROM processed_data
+	WHERE id = $1 AND deleted_at IS NULL AND project_id = $2
+`
+
+// GetProcessedDataByID returns a single active record of a project, or nil if it does not exist
+func GetProcessedDataByID(projectID string, id int) (*ProcessedData, error) {
+	return GetProcessedDataByIDContext(context.Background(), projectID, id)
+}
+
+// GetProcessedDataByIDContext is GetProcessedDataByID with a context that cancels the lookup
+func GetProcessedDataByIDContext(ctx context.Context, projectID string, id int) (*ProcessedData, error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	stmt, err := prepared(ctx, DB, processedDataByIDQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := scanProcessedDataRow(stmt.QueryRowContext(ctx, id, projectIDOrDefault(projectID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed data: %v", err)
+	}
+
+	return data, nil
 }
 
-// GetDataCount returns the total count of records
-func GetDataCount() (map[string]int, error) {
+// GetDataCount returns the total count of a project's records. Processed
+// duplicates are only counted with includeDuplicates.
+func GetDataCount(projectID string, includeDuplicates bool) (map[string]int, error) {
+	return GetDataCountContext(context.Background(), projectID, includeDuplicates)
+}
+
+// GetDataCountContext is GetDataCount with a context that cancels the counts
+func GetDataCountContext(ctx context.Context, projectID string, includeDuplicates bool) (map[string]int, error) {
 	// Check if database is connected and ensure connection is alive
-	if err := EnsureConnection(); err != nil {
+	if err := EnsureConnectionContext(ctx); err != nil {
 		return map[string]int{"raw_data": 0, "processed_data": 0}, fmt.Errorf("database connection issue: %v", err)
 	}
 
@@ -169,7 +404,7 @@ func GetDataCount() (map[string]int, error) {
 
 	// Count raw data
 	var rawCount int
-	err := DB.QueryRow("SELECT COUNT(*) FROM raw_data").Scan(&rawCount)
+	err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM raw_data WHERE deleted_at IS NULL AND project_id = $1", projectID).Scan(&rawCount)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count raw data: %v", err)
 	}
@@ -177,7 +412,7 @@ func GetDataCount() (map[string]int, error) {
 
 	// Count processed data
 	var processedCount int
-	err = DB.QueryRow("SELECT COUNT(*) FROM processed_data").Scan(&processedCount)
+	err = DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM processed_data WHERE deleted_at IS NULL AND project_id = $1"+duplicatesCondition(includeDuplicates), projectID).Scan(&processedCount)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count processed data: %v", err)
 	}
@@ -186,8 +421,9 @@ func GetDataCount() (map[string]int, error) {
 	return counts, nil
 }
 
-// GetDataSummary returns a comprehensive summary of all data
-func GetDataSummary() (map[string]interface{}, error) {
+// GetDataSummary returns a comprehensive summary of a project's data,
+// counting duplicates only with includeDuplicates
+func GetDataSummary(projectID string, includeDuplicates bool) (map[string]interface{}, error) {
 	// Check if database is connected and ensure connection is alive
 	if err := EnsureConnection(); err != nil {
 		return map[string]interface{}{
@@ -200,6 +436,7 @@ func GetDataSummary() (map[string]interface{}, error) {
 	}
 
 	summary := make(map[string]interface{})
+	duplicates := duplicatesCondition(includeDuplicates)
 
 	// Get counts by source
 	sources := []string{"youtube", "google_news", "instagram", "indonesia_news"}
@@ -207,7 +444,7 @@ func GetDataSummary() (map[string]interface{}, error) {
 
 	for _, source := range sources {
 		var count int
-		err := DB.QueryRow("SELECT COUNT(*) FROM processed_data WHERE source = $1", source).Scan(&count)
+		err := DB.QueryRow("SELECT COUNT(*) FROM processed_data WHERE source = $1 AND deleted_at IS NULL AND project_id = $2"+duplicates, source, projectID).Scan(&count)
 		if err != nil {
 			// Log error but continue with other sources
 			fmt.Printf("Warning: failed to count %s data: %v\n", source, err)
@@ -219,21 +456,21 @@ func GetDataSummary() (map[string]interface{}, error) {
 
 	// Get average relevance score
 	var avgRelevance float64
-	err := DB.QueryRow("SELECT AVG(relevance_score) FROM processed_data WHERE relevance_score IS NOT NULL").Scan(&avgRelevance)
+	err := DB.QueryRow("SELECT AVG(relevance_score) FROM processed_data WHERE relevance_score IS NOT NULL AND deleted_at IS NULL AND project_id = $1"+duplicates, projectID).Scan(&avgRelevance)
 	if err != nil {
 		avgRelevance = 0.0
 	}
 
 	// Get total records
 	var totalRecords int
-	err = DB.QueryRow("SELECT COUNT(*) FROM processed_data").Scan(&totalRecords)
+	err = DB.QueryRow("SELECT COUNT(*) FROM processed_data WHERE deleted_at IS NULL AND project_id = $1"+duplicates, projectID).Scan(&totalRecords)
 	if err != nil {
 		totalRecords = 0
 	}
 
 	// Get latest update timestamp
 	var latestUpdate string
-	err = DB.QueryRow("SELECT MAX(processed_at) FROM processed_data").Scan(&latestUpdate)
+	err = DB.QueryRow("SELECT MAX(processed_at) FROM processed_data WHERE deleted_at IS NULL AND project_id = $1", projectID).Scan(&latestUpdate)
 	if err != nil {
 		latestUpdate = "Never"
 	}
@@ -247,7 +484,11 @@ func GetDataSummary() (map[string]interface{}, error) {
 }
 
 // GetSentimentDistribution returns sentiment distribution across all sources
-func GetSentimentDistribution() (map[string]interface{}, error) {
+// Records scored by an analyzer older than minAnalyzerVersion are excluded;
+// unversioned records count as version 0. Likely sarcastic comments are
+// left out when excludeSarcastic is set, and duplicates unless
+// includeDuplicates is.
+func GetSentimentDistribution(projectID string, minAnalyzerVersion int, excludeSarcastic, includeDuplicates bool) (map[string]interface{}, error) {
 	// Check if database is connected and ensure connection is alive
 	if err := EnsureConnection(); err != nil {
 		return map[string]interface{}{
@@ -278,8 +519,12 @@ func GetSentimentDistribution() (map[string]interface{}, error) {
 	for _, source := range sources {
 		for _, sentiment := range sentiments {
 			var count int
-			query := "SELECT COUNT(*) FROM processed_data WHERE source = $1 AND sentiment = $2"
-			err := DB.QueryRow(query, source, sentiment).Scan(&count)
+			query := "SELECT COUNT(*) FROM processed_data WHERE source = $1 AND sentiment = $2 AND COALESCE(analyzer_version, 0) >= $3 AND deleted_at IS NULL AND project_id = $4"
+			if excludeSarcastic {
+				query += " AND " + notSarcasticCondition
+			}
+			query += duplicatesCondition(includeDuplicates)
+			err := DB.QueryRow(query, source, sentiment, minAnalyzerVersion, projectID).Scan(&count)
 			if err != nil {
 				// Log error but continue
 				fmt.Printf("Warning: failed to count %s %s data: %v\n", source, sentiment, err)
@@ -311,11 +556,133 @@ func GetSentimentDistribution() (map[string]interface{}, error) {
 		"total":    totalPositive + totalNegative + totalNeutral,
 	}
 
+	byVersion, err := getSentimentByAnalyzerVersion(projectID, includeDuplicates)
+	if err != nil {
+		fmt.Printf("Warning: failed to segment sentiment by analyzer version: %v\n", err)
+	} else {
+		distribution["by_analyzer_version"] = byVersion
+	}
+
 	return distribution, nil
 }
 
-// GetWordFrequency returns word frequency analysis across all sources
-func GetWordFrequency() (map[string]interface{}, error) {
+// GetSentimentCounts counts the live records of a project per sentiment,
+// overall and per source, with percentage shares. An empty source counts all
+// sources, and nil bounds on EventTimeColumn leave the range open. Likely
+// sarcastic comments are left out when excludeSarcastic is set, and
+// duplicates unless includeDuplicates is.
+func GetSentimentCounts(projectID, source string, from, to *time.Time, excludeSarcastic, includeDuplicates bool) (SentimentCounts, []SentimentCounts, error) {
+	return GetSentimentCountsContext(context.Background(), projectID, source, from, to, excludeSarcastic, includeDuplicates)
+}
+
+// GetSentimentCountsContext is GetSentimentCounts with a context that cancels the query
+func GetSentimentCountsContext(ctx context.Context, projectID, source string, from, to *time.Time, excludeSarcastic, includeDuplicates bool) (SentimentCounts, []SentimentCounts, error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return SentimentCounts{}, nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT source,
+			COUNT(*) FILTER (WHERE sentiment = 'positive'),
+			COUNT(*) FILTER (WHERE sentiment = 'negative'),
+			COUNT(*) FILTER (WHERE sentiment = 'neutral')
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL AND sentiment IN ('positive', 'negative', 'neutral')` + duplicatesCondition(includeDuplicates) + `
+	`
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+	if from != nil {
+		args = append(args, *from)
+		sqlQuery += fmt.Sprintf(" AND %s >= $%d", EventTimeColumn, len(args))
+	}
+	if to != nil {
+		args = append(args, *to)
+		sqlQuery += fmt.Sprintf(" AND %s < $%d", EventTimeColumn, len(args))
+	}
+	if excludeSarcastic {
+		sqlQuery += " AND " + notSarcasticCondition
+	}
+	sqlQuery += " GROUP BY source ORDER BY source"
+
+	rows, err := DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return SentimentCounts{}, nil, fmt.Errorf("failed to query sentiment counts: %v", err)
+	}
+	defer rows.Close()
+
+	var overall SentimentCounts
+	bySource := []SentimentCounts{}
+	for rows.Next() {
+		var counts SentimentCounts
+		if err := rows.Scan(&counts.Source, &counts.Positive, &counts.Negative, &counts.Neutral); err != nil {
+			return SentimentCounts{}, nil, fmt.Errorf("failed to scan sentiment counts: %v", err)
+		}
+		overall.Positive += counts.Positive
+		overall.Negative += counts.Negative
+		overall.Neutral += counts.Neutral
+		bySource = append(bySource, counts.withShares())
+	}
+	if err := rows.Err(); err != nil {
+		return SentimentCounts{}, nil, fmt.Errorf("failed to read sentiment counts: %v", err)
+	}
+
+	return overall.withShares(), bySource, nil
+}
+
+// withShares returns the counts with their total and percentage shares
+func (c SentimentCounts) withShares() SentimentCounts {
+	c.Total = c.Positive + c.Negative + c.Neutral
+	if c.Total > 0 {
+		share := func(count int) float64 {
+			return math.Round(float64(count)*1000/float64(c.Total)) / 10
+		}
+		c.PositivePct = share(c.Positive)
+		c.NegativePct = share(c.Negative)
+		c.NeutralPct = share(c.Neutral)
+	}
+	return c
+}
+
+// getSentimentByAnalyzerVersion counts sentiments per analyzer version
+func getSentimentByAnalyzerVersion(projectID string, includeDuplicates bool) (map[string]map[string]int, error) {
+	rows, err := DB.Query(`
+		SELECT COALESCE(analyzer_version, 0), sentiment, COUNT(*)
+		FROM processed_data
+		WHERE sentiment IS NOT NULL AND deleted_at IS NULL AND project_id = $1`+duplicatesCondition(includeDuplicates)+`
+		GROUP BY 1, 2
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byVersion := make(map[string]map[string]int)
+	for rows.Next() {
+		var version, count int
+		var sentiment string
+		if err := rows.Scan(&version, &sentiment, &count); err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("v%d", version)
+		if version == 0 {
+			key = "unversioned"
+		}
+		if byVersion[key] == nil {
+			byVersion[key] = make(map[string]int)
+		}
+		byVersion[key][sentiment] = count
+	}
+
+	return byVersion, rows.Err()
+}
+
+// GetWordFrequency returns word frequency analysis across all sources of a
+// project, counting duplicates only with includeDuplicates
+func GetWordFrequency(projectID string, includeDuplicates bool) (map[string]interface{}, error) {
 	// Check if database is connected and ensure connection is alive
 	if err := EnsureConnection(); err != nil {
 		return map[string]interface{}{
@@ -333,11 +700,11 @@ func GetWordFrequency() (map[string]interface{}, error) {
 			sentiment,
 			sentiment_score
 		FROM processed_data 
-		WHERE title IS NOT NULL OR content IS NOT NULL
+		WHERE (title IS NOT NULL OR content IS NOT NULL) AND deleted_at IS NULL AND project_id = $1` + duplicatesCondition(includeDuplicates) + `
 		ORDER BY processed_at DESC
 	`
 
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query word frequency data: %v", err)
 	}
@@ -364,7 +731,7 @@ func GetWordFrequency() (map[string]interface{}, error) {
 			wordLower := strings.ToLower(strings.TrimSpace(word))
 
 			// Skip stop words, short words, and non-alphabetic
-			if len(wordLower) < 3 || contains(stopWords, wordLower) || !isAlphabetic(wordLower) {
+			if !isFrequencyWord(wordLower, stopWords) {
 				continue
 			}
 
@@ -427,6 +794,133 @@ func GetWordFrequency() (map[string]interface{}, error) {
 	}, nil
 }
 
+// GetWordCounts returns the limit most frequent words of a project's live
+// records, counted in SQL with the tokenization and stop words of
+// GetWordFrequency. Empty source and sentiment count all records.
+// Duplicates are counted only with includeDuplicates.
+func GetWordCounts(projectID, source, sentiment string, limit int, includeDuplicates bool) ([]WordCount, error) {
+	return GetWordCountsContext(context.Background(), projectID, source, sentiment, limit, includeDuplicates)
+}
+
+// GetWordCountsContext is GetWordCounts with a context that cancels the query
+func GetWordCountsContext(ctx context.Context, projectID, source, sentiment string, limit int, includeDuplicates bool) ([]WordCount, error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	conditions := "project_id = $1 AND deleted_at IS NULL" + duplicatesCondition(includeDuplicates)
+	args := []interface{}{projectIDOrDefault(projectID), pq.Array(StopWords()), limit}
+	if source != "" {
+		args = append(args, source)
+		conditions += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+	if sentiment != "" {
+		args = append(args, sentiment)
+		conditions += fmt.Sprintf(" AND sentiment = $%d", len(args))
+	}
+
+	sqlQuery := `
+		SELECT w.word, COUNT(*),
+			COUNT(*) FILTER (WHERE p.sentiment = 'positive'),
+			COUNT(*) FILTER (WHERE p.sentiment = 'negative'),
+			COUNT(*) FILTER (WHERE p.sentiment = 'neutral'),
+			COALESCE(AVG(p.sentiment_score), 0)
+		FROM processed_data p,
+			regexp_split_to_table(lower(COALESCE(p.title, '') || ' ' || COALESCE(p.content, '')), '[[:space:][:punct:]]+') AS w(word)
+		WHERE ` + conditions + `
+			AND length(w.word) >= 3 AND w.word ~ '^[[:alpha:]]+$' AND NOT w.word = ANY($2)
+		GROUP BY w.word
+		ORDER BY COUNT(*) DESC, w.word
+		LIMIT $3
+	`
+
+	rows, err := DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query word counts: %v", err)
+	}
+	defer rows.Close()
+
+	counts := []WordCount{}
+	for rows.Next() {
+		var count WordCount
+		if err := rows.Scan(&count.Word, &count.Count, &count.PositiveCount, &count.NegativeCount, &count.NeutralCount, &count.AvgSentiment); err != nil {
+			return nil, fmt.Errorf("failed to scan word counts: %v", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word counts: %v", err)
+	}
+
+	return counts, nil
+}
+
+// GetRecordWords returns, for the records of a project in [from, to) of
+// EventTimeColumn, their date and the distinct words of their title and
+// content that word frequencies count. An empty source reads all sources,
+// and duplicates are read only with includeDuplicates.
+func GetRecordWords(projectID, source string, from, to time.Time, includeDuplicates bool) ([]RecordWords, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + EventTimeColumn + `, COALESCE(title, ''), COALESCE(content, '')
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL
+			AND ` + EventTimeColumn + ` >= $2 AND ` + EventTimeColumn + ` < $3` + duplicatesCondition(includeDuplicates) + `
+	`
+	args := []interface{}{projectIDOrDefault(projectID), from.UTC(), to.UTC()}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record words: %v", err)
+	}
+	defer rows.Close()
+
+	stopWords := getStopWords()
+	var records []RecordWords
+	for rows.Next() {
+		var record RecordWords
+		var title, content string
+		if err := rows.Scan(&record.At, &title, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan record words: %v", err)
+		}
+		seen := make(map[string]bool)
+		for _, word := range tokenizeText(title + " " + content) {
+			word = strings.ToLower(word)
+			if isFrequencyWord(word, stopWords) && !seen[word] {
+				seen[word] = true
+				record.Words = append(record.Words, word)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read record words: %v", err)
+	}
+
+	return records, nil
+}
+
+// StopWordsVersion identifies the word frequency stop words. Bump it whenever
+// getStopWords changes.
+const StopWordsVersion = 1
+
+// StopWords returns the stop words excluded from word frequencies, sorted
+func StopWords() []string {
+	words := make([]string, 0, len(getStopWords()))
+	for word := range getStopWords() {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
 // Helper functions for word frequency analysis
 func getStopWords() map[string]bool {
 	stopWords := map[string]bool{
@@ -484,6 +978,12 @@ func contains(slice map[string]bool, item string) bool {
 	return exists
 }
 
+// isFrequencyWord reports whether a lowercased word counts toward word
+// frequencies: alphabetic, at least three letters long and not a stop word
+func isFrequencyWord(word string, stopWords map[string]bool) bool {
+	return len(word) >= 3 && !contains(stopWords, word) && isAlphabetic(word)
+}
+
 func isAlphabetic(word string) bool {
 	for _, char := range word {
 		if !unicode.IsLetter(char) {
@@ -492,3 +992,326 @@ func isAlphabetic(word string) bool {
 	}
 	return true
 }
+
+// SearchProcessedData performs a keyword search over titles and content.
+// Every whitespace-separated term in query must appear in the title or content.
+// Only records with an ID greater than afterID are returned, oldest first, so
+// a cursor moved to the last record returned skips none.
+func SearchProcessedData(projectID, query, source, sentiment string, afterID, limit int) ([]ProcessedData, error) {
+	if err := EnsureConnection(); err != nil {
+		return []ProcessedData{}, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	whereClause, args := buildProcessedDataWhere(ProcessedDataFilter{
+		Project:   projectID,
+		Query:     query,
+		Source:    source,
+		Sentiment: sentiment,
+		AfterID:   afterID,
+	})
+	args = append(args, limit)
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data
+	` + whereClause + fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search processed data: %v", err)
+	}
+	defer rows.Close()
+
+	return scanProcessedData(rows)
+}
+
+// QueryProcessedData retrieves processed data matching a filter, newest first.
+// A limit of 0 returns all matching rows.
+func QueryProcessedData(filter ProcessedDataFilter, limit int) ([]ProcessedData, error) {
+	return QueryProcessedDataPage(filter, limit, 0)
+}
+
+// QueryProcessedDataPage retrieves a page of processed data matching a
+// filter, newest first, skipping the first offset rows
+func QueryProcessedDataPage(filter ProcessedDataFilter, limit, offset int) ([]ProcessedData, error) {
+	return QueryProcessedDataPageContext(context.Background(), filter, limit, offset)
+}
+
+// QueryProcessedDataPageContext is QueryProcessedDataPage with a context that cancels the query
+func QueryProcessedDataPageContext(ctx context.Context, filter ProcessedDataFilter, limit, offset int) ([]ProcessedData, error) {
+	// Check if database is connected and ensure connection is alive
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return []ProcessedData{}, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	whereClause, args := buildProcessedDataWhere(filter)
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data
+	` + whereClause + " ORDER BY id DESC"
+	if limit > 0 {
+		args = append(args, limit)
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		sqlQuery += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query processed data: %v", err)
+	}
+	defer rows.Close()
+
+	return scanProcessedData(rows)
+}
+
+// CountProcessedData counts the records matching a filter
+func CountProcessedData(filter ProcessedDataFilter) (int, error) {
+	return CountProcessedDataContext(context.Background(), filter)
+}
+
+// CountProcessedDataContext is CountProcessedData with a context that cancels the count
+func CountProcessedDataContext(ctx context.Context, filter ProcessedDataFilter) (int, error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	whereClause, args := buildProcessedDataWhere(filter)
+
+	var count int
+	if err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM processed_data"+whereClause, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count processed data: %v", err)
+	}
+	return count, nil
+}
+
+// StreamProcessedData calls fn for every record matching the filter in ID order
+// without holding the full result set in memory
+func StreamProcessedData(filter ProcessedDataFilter, limit int, fn func(ProcessedData) error) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	whereClause, args := buildProcessedDataWhere(filter)
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data
+	` + whereClause + " ORDER BY id"
+	if limit > 0 {
+		args = append(args, limit)
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query processed data: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data, err := scanProcessedDataRow(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %v", err)
+		}
+		if err := fn(*data); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeContains returns a LIKE pattern matching text literally anywhere in a
+// value; the LIKE must use ESCAPE '\'
+func likeContains(text string) string {
+	return "%" + likeEscaper.Replace(text) + "%"
+}
+
+// buildProcessedDataWhere translates a filter into a WHERE clause and its arguments
+func buildProcessedDataWhere(filter ProcessedDataFilter) (string, []interface{}) {
+	// Soft-deleted records are never returned
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.Project != "" {
+		args = append(args, filter.Project)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	for _, term := range strings.Fields(filter.Query) {
+		args = append(args, likeContains(term))
+		conditions = append(conditions, fmt.Sprintf(`(title ILIKE $%d ESCAPE '\' OR content ILIKE $%d ESCAPE '\')`, len(args), len(args)))
+	}
+	if filter.Source != "" {
+		args = append(args, filter.Source)
+		conditions = append(conditions, fmt.Sprintf("source = $%d", len(args)))
+	}
+	if filter.Sentiment != "" {
+		args = append(args, filter.Sentiment)
+		conditions = append(conditions, fmt.Sprintf("sentiment = $%d", len(args)))
+	}
+	if filter.Campaign != "" {
+		args = append(args, filter.Campaign)
+		conditions = append(conditions, fmt.Sprintf("campaign = $%d", len(args)))
+	}
+	if filter.Hashtag != "" {
+		args = append(args, filter.Hashtag)
+		conditions = append(conditions, fmt.Sprintf("hashtag = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("%s >= $%d", EventTimeColumn, len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("%s < $%d", EventTimeColumn, len(args)))
+	}
+	if filter.AfterID > 0 {
+		args = append(args, filter.AfterID)
+		conditions = append(conditions, fmt.Sprintf("id > $%d", len(args)))
+	}
+	if filter.BeforeID > 0 {
+		args = append(args, filter.BeforeID)
+		conditions = append(conditions, fmt.Sprintf("id < $%d", len(args)))
+	}
+	if len(filter.ExcludeLicenses) > 0 {
+		args = append(args, pq.Array(filter.ExcludeLicenses))
+		conditions = append(conditions, fmt.Sprintf("COALESCE(license, 'unspecified') <> ALL($%d)", len(args)))
+	}
+	if filter.MinRelevance != nil {
+		args = append(args, *filter.MinRelevance)
+		conditions = append(conditions, fmt.Sprintf("relevance_score >= $%d", len(args)))
+	}
+	if filter.Language != "" {
+		args = append(args, filter.Language)
+		conditions = append(conditions, fmt.Sprintf("processed_data->>'language' = $%d", len(args)))
+	}
+	if filter.MinToxicity != nil {
+		args = append(args, *filter.MinToxicity)
+		conditions = append(conditions, fmt.Sprintf("toxicity_score >= $%d", len(args)))
+	}
+	if filter.MaxToxicity != nil {
+		args = append(args, *filter.MaxToxicity)
+		conditions = append(conditions, fmt.Sprintf("toxicity_score <= $%d", len(args)))
+	}
+	for _, tag := range filter.Tags {
+		args = append(args, tag)
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM tags t WHERE t.record_id = processed_data.id AND t.name = $%d)", len(args)))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetMaxProcessedDataID returns the highest processed_data ID, or 0 for an empty table
+func GetMaxProcessedDataID() (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var maxID int
+	if err := DB.QueryRow("SELECT COALESCE(MAX(id), 0) FROM processed_data").Scan(&maxID); err != nil {
+		return 0, fmt.Errorf("failed to get max processed data id: %v", err)
+	}
+	return maxID, nil
+}
+
+// EventTimeColumn is the time axis of analytics: when the item was published,
+// falling back to when it was processed for sources without a date
+const EventTimeColumn = "COALESCE(published_at, processed_at)"
+
+// localDayColumn is the calendar day of EventTimeColumn in the time zone
+// named by the query parameter placeholder; timestamps are stored in UTC
+func localDayColumn(placeholder string) string {
+	return "(" + EventTimeColumn + " AT TIME ZONE 'UTC' AT TIME ZONE " + placeholder + ")::date"
+}
+
+// isUTC reports whether days in zone are the UTC days of the daily rollups
+func isUTC(zone *time.Location) bool {
+	return zone == nil || zone == time.UTC
+}
+
+// duplicatesCondition returns the condition appended to a WHERE clause to
+// count canonical records only, or nothing with includeDuplicates
+func duplicatesCondition(includeDuplicates bool) string {
+	if includeDuplicates {
+		return ""
+	}
+	return " AND " + canonicalCondition
+}
+
+// notSarcasticCondition excludes social comments flagged as likely sarcastic
+// by the transformer, whose lexicon sentiment is usually inverted
+const notSarcasticCondition = "COALESCE((processed_data->>'sarcastic')::boolean, false) = false"
+
+// processedDataColumns is the standard column list for processed_data queries
+const processedDataColumns = `id, source, COALESCE(outlet, ''), processed_at, published_at, title, content, relevance_score, sentiment,
+	sentiment_score, sentiment_confidence, analyzer_version, COALESCE(batch_id, ''), project_id, COALESCE(license, ''), toxicity_score, COALESCE(campaign, ''), COALESCE(region_code, ''), COALESCE(hashtag, ''), COALESCE(content_hash, ''), duplicate_of, changed_at, processed_data`
+
+// scanProcessedDataRow scans a single row selected with processedDataColumns
+func scanProcessedDataRow(row interface{ Scan(...interface{}) error }) (*ProcessedData, error) {
+	var data ProcessedData
+	err := row.Scan(
+		&data.ID,
+		&data.Source,
+		&data.Outlet,
+		&data.ProcessedAt,
+		&data.PublishedAt,
+		&data.Title,
+		&data.Content,
+		&data.RelevanceScore,
+		&data.Sentiment,
+		&data.SentimentScore,
+		&data.SentimentConfidence,
+		&data.AnalyzerVersion,
+		&data.BatchID,
+		&data.ProjectID,
+		&data.License,
+		&data.ToxicityScore,
+		&data.Campaign,
+		&data.RegionCode,
+		&data.Hashtag,
+		&data.ContentHash,
+		&data.DuplicateOf,
+		&data.ChangedAt,
+		&data.ProcessedData,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// extraColumns scans the columns selected after processedDataColumns into
+// dest, so scanProcessedDataRow can read rows that carry additional columns
+type extraColumns struct {
+	row  interface{ Scan(...interface{}) error }
+	dest []interface{}
+}
+
+// Scan scans the processed data columns followed by the extra columns
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.dest...)...)
+}
+
+// scanProcessedData scans processed_data rows selected with processedDataColumns
+func scanProcessedData(rows *sql.Rows) ([]ProcessedData, error) {
+	var results []ProcessedData
+	for rows.Next() {
+		data, err := scanProcessedDataRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		results = append(results, *data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %v", err)
+	}
+
+	return results, nil
+}
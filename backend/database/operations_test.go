@@ -0,0 +1,19 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessedDataSearchEscapesWildcards(t *testing.T) {
+	where, args := buildProcessedDataWhere(ProcessedDataFilter{Query: `100% kasus_harian C:\`})
+
+	if !strings.Contains(where, `ILIKE $1 ESCAPE '\'`) {
+		t.Errorf("Expected the search to declare its escape character, got %s", where)
+	}
+	for i, expected := range []string{`%100\%%`, `%kasus\_harian%`, `%C:\\%`} {
+		if args[i] != expected {
+			t.Errorf("Expected pattern %q, got %q", expected, args[i])
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// PartitionMonthsAhead is how many months after the current one get their
+// processed_data partition in advance
+const PartitionMonthsAhead = 3
+
+// ProcessedDataPartitionPatterns match the partitions of processed_data, for
+// tools like pg_dump that select tables by name
+var ProcessedDataPartitionPatterns = []string{"processed_data_[0-9]*", "processed_data_default"}
+
+// processedDataIndexes are the indexes of processed_data, created on the
+// partitioned table so every partition gets them. Unique indexes of a
+// partitioned table must contain processed_at, so the content hash index is
+// not unique; upsertProcessedData keeps content hashes unique instead.
+var processedDataIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_source ON processed_data(source)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_timestamp ON processed_data(processed_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_analyzer_version ON processed_data(analyzer_version)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_batch ON processed_data(batch_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_active ON processed_data(id) WHERE deleted_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_project ON processed_data(project_id, source)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_published_at ON processed_data(published_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_event_time ON processed_data((COALESCE(published_at, processed_at)))`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_license ON processed_data(license)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_toxicity_score ON processed_data(toxicity_score) WHERE toxicity_score IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_outlet ON processed_data(project_id, outlet) WHERE outlet IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_campaign ON processed_data(project_id, campaign) WHERE campaign IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_content_hash ON processed_data(project_id, content_hash) WHERE deleted_at IS NULL AND content_hash IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_duplicate_of ON processed_data(duplicate_of) WHERE duplicate_of IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_changed_at ON processed_data(project_id, changed_at) WHERE changed_at IS NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_language ON processed_data(project_id, (processed_data->>'language'))`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_relevance ON processed_data(project_id, relevance_score)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_region_code ON processed_data(project_id, region_code)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_hashtag ON processed_data(project_id, hashtag) WHERE hashtag IS NOT NULL`,
+}
+
+// ensurePartitionFunction creates the partition of the month of its argument
+// unless it exists, moving the month's rows out of the default partition
+// first, and reports whether it created one. Partitions are named
+// processed_data_YYYY_MM.
+const ensurePartitionFunction = `CREATE OR REPLACE FUNCTION ensure_processed_data_partition(for_month DATE) RETURNS BOOLEAN AS $$
+	DECLARE
+		start_at TIMESTAMP := date_trunc('month', for_month::timestamp);
+		end_at TIMESTAMP := date_trunc('month', for_month::timestamp) + INTERVAL '1 month';
+		partition_name TEXT := 'processed_data_' || to_char(for_month, 'YYYY_MM');
+	BEGIN
+		IF to_regclass(partition_name) IS NOT NULL THEN
+			RETURN FALSE;
+		END IF;
+		EXECUTE format('CREATE TABLE %I (LIKE processed_data INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', partition_name);
+		EXECUTE format('WITH moved AS (DELETE FROM processed_data_default WHERE processed_at >= %L AND processed_at < %L RETURNING *)
+			INSERT INTO %I SELECT * FROM moved', start_at, end_at, partition_name);
+		EXECUTE format('ALTER TABLE processed_data ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', partition_name, start_at, end_at);
+		RETURN TRUE;
+	END
+	$$ LANGUAGE plpgsql`
+
+// deleteDependentsFunction deletes what referenced a deleted record before
+// processed_data was partitioned, when foreign keys did. A record moved to
+// another partition by an update of processed_at is deleted and inserted
+// again under its ID, so its dependents are kept.
+const deleteDependentsFunction = `CREATE OR REPLACE FUNCTION processed_data_delete_dependents() RETURNS TRIGGER AS $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM processed_data WHERE id = OLD.id) THEN
+			RETURN OLD;
+		END IF;
+		DELETE FROM collection_items WHERE record_id = OLD.id;
+		DELETE FROM record_notes WHERE record_id = OLD.id;
+		DELETE FROM tags WHERE record_id = OLD.id;
+		DELETE FROM record_versions WHERE record_id = OLD.id;
+		DELETE FROM record_entities WHERE record_id = OLD.id;
+		DELETE FROM fact_content WHERE record_id = OLD.id;
+		IF to_regclass('processed_data_embeddings') IS NOT NULL THEN
+			EXECUTE 'DELETE FROM processed_data_embeddings WHERE processed_data_id = $1' USING OLD.id;
+		END IF;
+		UPDATE processed_data SET duplicate_of = NULL WHERE duplicate_of = OLD.id;
+		RETURN OLD;
+	END
+	$$ LANGUAGE plpgsql`
+
+// partitionMigrationStatements turn processed_data into a table partitioned
+// by the month of processed_at. The rows are copied into a partition per
+// month they fall in, and rows outside every partition land in
+// processed_data_default. Foreign keys cannot reference a partitioned table
+// without its partition key, so the ones to processed_data(id) are dropped
+// and a trigger deletes the dependents of deleted records instead.
+func partitionMigrationStatements() []string {
+	statements := []string{
+		`DROP VIEW IF EXISTS fact_content_live`,
+		`DO $$
+		DECLARE
+			fk RECORD;
+		BEGIN
+			FOR fk IN SELECT conrelid::regclass AS referencing, conname FROM pg_constraint
+				WHERE contype = 'f' AND confrelid = 'processed_data'::regclass
+			LOOP
+				EXECUTE format('ALTER TABLE %s DROP CONSTRAINT %I', fk.referencing, fk.conname);
+			END LOOP;
+		END
+		$$`,
+		`ALTER TABLE processed_data RENAME TO processed_data_unpartitioned`,
+		`ALTER SEQUENCE processed_data_id_seq OWNED BY NONE`,
+		`UPDATE processed_data_unpartitioned SET processed_at = NOW() WHERE processed_at IS NULL`,
+		`CREATE TABLE processed_data (LIKE processed_data_unpartitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+			PARTITION BY RANGE (processed_at)`,
+		`ALTER TABLE processed_data ALTER COLUMN processed_at SET NOT NULL`,
+		`CREATE TABLE processed_data_default PARTITION OF processed_data DEFAULT`,
+		ensurePartitionFunction,
+		`SELECT ensure_processed_data_partition(month::date)
+			FROM (SELECT DISTINCT date_trunc('month', processed_at) AS month FROM processed_data_unpartitioned) months`,
+		`INSERT INTO processed_data SELECT * FROM processed_data_unpartitioned`,
+		`DROP TABLE processed_data_unpartitioned`,
+		`ALTER SEQUENCE processed_data_id_seq OWNED BY processed_data.id`,
+		`ALTER TABLE processed_data ADD PRIMARY KEY (id, processed_at)`,
+	}
+	statements = append(statements, processedDataIndexes...)
+	return append(statements,
+		deleteDependentsFunction,
+		`CREATE TRIGGER processed_data_delete_dependents AFTER DELETE ON processed_data
+			FOR EACH ROW EXECUTE FUNCTION processed_data_delete_dependents()`,
+		factContentLiveView,
+	)
+}
+
+// EnsureProcessedDataPartitions creates the processed_data partitions of the
+// current month and the PartitionMonthsAhead months after it, and of every
+// month whose rows landed in the default partition, and returns the months
+// it created partitions for
+func EnsureProcessedDataPartitions(now time.Time) ([]string, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rows, err := DB.Query(`SELECT DISTINCT date_trunc('month', processed_at) FROM processed_data_default`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query default partition months: %v", err)
+	}
+	var months []time.Time
+	for rows.Next() {
+		var month time.Time
+		if err := rows.Scan(&month); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan default partition month: %v", err)
+		}
+		months = append(months, month)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate default partition months: %v", err)
+	}
+
+	current := time.Date(now.UTC().Year(), now.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= PartitionMonthsAhead; i++ {
+		months = append(months, current.AddDate(0, i, 0))
+	}
+
+	// The default partition is scanned while a partition is attached, so each
+	// one is created in its own statement
+	var created []string
+	for _, month := range months {
+		var isNew bool
+		if err := DB.QueryRow(`SELECT ensure_processed_data_partition($1::date)`, month.Format("2006-01-02")).Scan(&isNew); err != nil {
+			return created, fmt.Errorf("failed to create processed data partition for %s: %v", month.Format("2006-01"), err)
+		}
+		if isNew {
+			created = append(created, month.Format("2006-01"))
+		}
+	}
+
+	return created, nil
+}
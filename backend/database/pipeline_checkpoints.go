@@ -0,0 +1,111 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SavePipelineCheckpoint stores the checkpoint of a campaign of a run,
+// replacing the campaign's earlier checkpoint
+func SavePipelineCheckpoint(checkpoint *PipelineCheckpoint) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	idsJSON, err := json.Marshal(checkpoint.RawDataIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal raw data IDs: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO pipeline_checkpoints (batch_id, project_id, campaign_index, campaign, query, stage, raw_data_ids, state, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, '')::jsonb, NOW())
+		ON CONFLICT (batch_id, campaign_index) DO UPDATE
+		SET stage = EXCLUDED.stage, raw_data_ids = EXCLUDED.raw_data_ids, state = EXCLUDED.state, updated_at = NOW()
+		RETURNING id, updated_at
+	`
+
+	err = DB.QueryRow(sqlQuery, checkpoint.BatchID, projectIDOrDefault(checkpoint.ProjectID), checkpoint.CampaignIndex,
+		checkpoint.Campaign, checkpoint.Query, checkpoint.Stage, string(idsJSON), checkpoint.State).Scan(&checkpoint.ID, &checkpoint.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save pipeline checkpoint: %v", err)
+	}
+
+	return nil
+}
+
+// GetInterruptedPipelineRun returns the checkpoints of the most recent run of
+// a project that was interrupted since the given time, by campaign index.
+// It returns none when no such run left checkpoints.
+func GetInterruptedPipelineRun(projectID string, since time.Time) ([]PipelineCheckpoint, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, batch_id, project_id, campaign_index, COALESCE(campaign, ''), COALESCE(query, ''),
+			stage, raw_data_ids, COALESCE(state::text, ''), updated_at
+		FROM pipeline_checkpoints
+		WHERE batch_id = (
+			SELECT batch_id FROM pipeline_checkpoints
+			WHERE project_id = $1 AND updated_at >= $2
+			ORDER BY updated_at DESC
+			LIMIT 1
+		)
+		ORDER BY campaign_index
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pipeline checkpoints: %v", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []PipelineCheckpoint
+	for rows.Next() {
+		var checkpoint PipelineCheckpoint
+		var idsJSON []byte
+		if err := rows.Scan(&checkpoint.ID, &checkpoint.BatchID, &checkpoint.ProjectID, &checkpoint.CampaignIndex, &checkpoint.Campaign,
+			&checkpoint.Query, &checkpoint.Stage, &idsJSON, &checkpoint.State, &checkpoint.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pipeline checkpoint: %v", err)
+		}
+		if err := json.Unmarshal(idsJSON, &checkpoint.RawDataIDs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal raw data IDs: %v", err)
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+// DeletePipelineCheckpoints removes the checkpoints of a run
+func DeletePipelineCheckpoints(batchID string) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	if _, err := DB.Exec(`DELETE FROM pipeline_checkpoints WHERE batch_id = $1`, batchID); err != nil {
+		return fmt.Errorf("failed to delete pipeline checkpoints: %v", err)
+	}
+
+	return nil
+}
+
+// DeletePipelineCheckpointsExcept removes the checkpoints of a project's
+// runs other than the given ones and returns how many were removed
+func DeletePipelineCheckpointsExcept(projectID string, batchIDs []string) (int64, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `DELETE FROM pipeline_checkpoints WHERE project_id = $1 AND batch_id <> ALL($2)`
+	result, err := DB.Exec(sqlQuery, projectIDOrDefault(projectID), pq.Array(batchIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete pipeline checkpoints: %v", err)
+	}
+
+	return result.RowsAffected()
+}
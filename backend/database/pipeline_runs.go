@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+const pipelineRunColumns = `id, batch_id, project_id, kind, status, COALESCE(message, ''), COALESCE(error, ''),
+	COALESCE(metrics::text, 'null'), started_at, completed_at`
+
+// CreatePipelineRun stores a run that has just started. A run resuming an
+// interrupted run under its batch ID takes over the entry of that run.
+func CreatePipelineRun(run *PipelineRun) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO pipeline_runs (batch_id, project_id, kind, status)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (batch_id) DO UPDATE
+		SET status = EXCLUDED.status, message = NULL, error = NULL, completed_at = NULL
+		RETURNING id, started_at
+	`
+
+	err := DB.QueryRow(sqlQuery, run.BatchID, projectIDOrDefault(run.ProjectID), run.Kind, run.Status).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert pipeline run: %v", err)
+	}
+
+	return nil
+}
+
+// FinishPipelineRun stores the outcome and stage metrics of a run
+func FinishPipelineRun(run *PipelineRun) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	metricsJSON, err := json.Marshal(run.Metrics)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pipeline run metrics: %v", err)
+	}
+
+	sqlQuery := `
+		UPDATE pipeline_runs
+		SET status = $1, message = $2, error = $3, metrics = $4, completed_at = $5
+		WHERE id = $6
+	`
+
+	_, err = DB.Exec(sqlQuery, run.Status, run.Message, run.Error, string(metricsJSON), run.CompletedAt, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update pipeline run: %v", err)
+	}
+
+	return nil
+}
+
+// GetPipelineRuns returns a page of a project's runs, most recent first,
+// and the number of runs stored for the project. A non-empty kind or status
+// limits the runs to those of that kind or status.
+func GetPipelineRuns(projectID, kind, status string, limit, offset int) ([]PipelineRun, int, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	where := " WHERE project_id = $1"
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if kind != "" {
+		args = append(args, kind)
+		where += fmt.Sprintf(" AND kind = $%d", len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+
+	var total int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM pipeline_runs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count pipeline runs: %v", err)
+	}
+
+	args = append(args, limit, offset)
+	sqlQuery := `SELECT ` + pipelineRunColumns + ` FROM pipeline_runs` + where +
+		fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query pipeline runs: %v", err)
+	}
+	defer rows.Close()
+
+	runs := []PipelineRun{}
+	for rows.Next() {
+		run, err := scanPipelineRun(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan pipeline run: %v", err)
+		}
+		runs = append(runs, *run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate pipeline runs: %v", err)
+	}
+
+	return runs, total, nil
+}
+
+// GetPipelineRun returns a project's run by ID or batch ID, or nil when it
+// does not exist
+func GetPipelineRun(projectID, idOrBatchID string) (*PipelineRun, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	row := DB.QueryRow(`SELECT `+pipelineRunColumns+` FROM pipeline_runs WHERE project_id = $1 AND (batch_id = $2 OR id::text = $2)`, projectIDOrDefault(projectID), idOrBatchID)
+	run, err := scanPipelineRun(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline run: %v", err)
+	}
+
+	return run, nil
+}
+
+// GetLatestPipelineRun returns a project's most recent run, or nil when it
+// has none
+func GetLatestPipelineRun(projectID string) (*PipelineRun, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	row := DB.QueryRow(`SELECT `+pipelineRunColumns+` FROM pipeline_runs WHERE project_id = $1 ORDER BY id DESC LIMIT 1`, projectIDOrDefault(projectID))
+	run, err := scanPipelineRun(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest pipeline run: %v", err)
+	}
+
+	return run, nil
+}
+
+// scanPipelineRun scans a row selected with pipelineRunColumns
+func scanPipelineRun(row interface{ Scan(...interface{}) error }) (*PipelineRun, error) {
+	var run PipelineRun
+	var metricsJSON string
+	err := row.Scan(
+		&run.ID,
+		&run.BatchID,
+		&run.ProjectID,
+		&run.Kind,
+		&run.Status,
+		&run.Message,
+		&run.Error,
+		&metricsJSON,
+		&run.StartedAt,
+		&run.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(metricsJSON), &run.Metrics); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
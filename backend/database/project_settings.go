@@ -0,0 +1,56 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// GetProjectSettings returns a project's overrides, or nil when it has none
+func GetProjectSettings(projectID string) (*ProjectSettings, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	settings := ProjectSettings{ProjectID: projectID}
+	var overridesJSON string
+	err := DB.QueryRow(`SELECT overrides, updated_at FROM project_settings WHERE project_id = $1`, projectID).
+		Scan(&overridesJSON, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project settings: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(overridesJSON), &settings.Overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse project settings: %v", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveProjectSettings replaces a project's overrides
+func SaveProjectSettings(settings *ProjectSettings) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	overridesJSON, err := json.Marshal(settings.Overrides)
+	if err != nil {
+		return fmt.Errorf("failed to encode project settings: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO project_settings (project_id, overrides, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (project_id) DO UPDATE SET overrides = EXCLUDED.overrides, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	if err := DB.QueryRow(sqlQuery, settings.ProjectID, string(overridesJSON)).Scan(&settings.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save project settings: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// DefaultProject owns all data that predates projects and every request
+// made without a project-scoped API key
+const DefaultProject = "default"
+
+// projectIDOrDefault maps an empty project ID to DefaultProject
+func projectIDOrDefault(projectID string) string {
+	if projectID == "" {
+		return DefaultProject
+	}
+	return projectID
+}
+
+// CreateProject stores a new project
+func CreateProject(project *Project) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO projects (id, name)
+		VALUES ($1, $2)
+		RETURNING created_at
+	`
+
+	if err := DB.QueryRow(sqlQuery, project.ID, project.Name).Scan(&project.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert project: %v", err)
+	}
+
+	return nil
+}
+
+// GetProjects returns all projects
+func GetProjects() ([]Project, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rows, err := DB.Query(`SELECT id, name, created_at FROM projects ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %v", err)
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var project Project
+		if err := rows.Scan(&project.ID, &project.Name, &project.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %v", err)
+		}
+		projects = append(projects, project)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate projects: %v", err)
+	}
+
+	return projects, nil
+}
+
+// ProjectExists reports whether a project with the given ID exists
+func ProjectExists(projectID string) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var exists bool
+	if err := DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1)`, projectID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check project: %v", err)
+	}
+
+	return exists, nil
+}
+
+// CreateProjectAPIKey generates a new API key for a project. The plaintext
+// key is only returned here; the database keeps its SHA-256 hash.
+func CreateProjectAPIKey(projectID, label string) (string, *ProjectAPIKey, error) {
+	if err := EnsureConnection(); err != nil {
+		return "", nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %v", err)
+	}
+	key := "ck_" + hex.EncodeToString(raw)
+
+	apiKey := &ProjectAPIKey{
+		ProjectID: projectID,
+		Label:     label,
+		Prefix:    key[:10],
+	}
+
+	sqlQuery := `
+		INSERT INTO project_api_keys (project_id, key_hash, prefix, label)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := DB.QueryRow(sqlQuery, projectID, hashAPIKey(key), apiKey.Prefix, label).Scan(&apiKey.ID, &apiKey.CreatedAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to insert project API key: %v", err)
+	}
+
+	return key, apiKey, nil
+}
+
+// GetProjectAPIKeys lists the keys of a project without their secrets
+func GetProjectAPIKeys(projectID string) ([]ProjectAPIKey, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rows, err := DB.Query(`
+		SELECT id, project_id, COALESCE(label, ''), prefix, created_at
+		FROM project_api_keys
+		WHERE project_id = $1
+		ORDER BY id
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project API keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []ProjectAPIKey
+	for rows.Next() {
+		var key ProjectAPIKey
+		if err := rows.Scan(&key.ID, &key.ProjectID, &key.Label, &key.Prefix, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan project API key: %v", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate project API keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+// GetProjectForAPIKey resolves an API key to its project, returning an empty
+// string when the key is unknown
+func GetProjectForAPIKey(key string) (string, error) {
+	if err := EnsureConnection(); err != nil {
+		return "", fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var projectID string
+	err := DB.QueryRow(`SELECT project_id FROM project_api_keys WHERE key_hash = $1`, hashAPIKey(key)).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up API key: %v", err)
+	}
+
+	return projectID, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of an API key
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProvinceCodes maps the provinces records are tagged with, as named in the
+// region of their processed data, to their ISO 3166-2:ID codes, which map
+// libraries key Indonesian province shapes by
+var ProvinceCodes = map[string]string{
+	"Aceh":                "ID-AC",
+	"Sumatera Utara":      "ID-SU",
+	"Sumatera Barat":      "ID-SB",
+	"Riau":                "ID-RI",
+	"Kepulauan Riau":      "ID-KR",
+	"Jambi":               "ID-JA",
+	"Sumatera Selatan":    "ID-SS",
+	"Bangka Belitung":     "ID-BB",
+	"Bengkulu":            "ID-BE",
+	"Lampung":             "ID-LA",
+	"DKI Jakarta":         "ID-JK",
+	"Banten":              "ID-BT",
+	"Jawa Barat":          "ID-JB",
+	"Jawa Tengah":         "ID-JT",
+	"DI Yogyakarta":       "ID-YO",
+	"Jawa Timur":          "ID-JI",
+	"Bali":                "ID-BA",
+	"Nusa Tenggara Barat": "ID-NB",
+	"Nusa Tenggara Timur": "ID-NT",
+	"Kalimantan Barat":    "ID-KB",
+	"Kalimantan Tengah":   "ID-KT",
+	"Kalimantan Selatan":  "ID-KS",
+	"Kalimantan Timur":    "ID-KI",
+	"Kalimantan Utara":    "ID-KU",
+	"Sulawesi Utara":      "ID-SA",
+	"Gorontalo":           "ID-GO",
+	"Sulawesi Tengah":     "ID-ST",
+	"Sulawesi Barat":      "ID-SR",
+	"Sulawesi Selatan":    "ID-SN",
+	"Sulawesi Tenggara":   "ID-SG",
+	"Maluku":              "ID-MA",
+	"Maluku Utara":        "ID-MU",
+	"Papua":               "ID-PA",
+	"Papua Barat":         "ID-PB",
+}
+
+// provinceCodeBackfill sets the region code of records tagged with a
+// province before region codes were stored
+func provinceCodeBackfill() string {
+	names := make([]string, 0, len(ProvinceCodes))
+	for name := range ProvinceCodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = fmt.Sprintf("('%s', '%s')", name, ProvinceCodes[name])
+	}
+	return `UPDATE processed_data p SET region_code = c.code
+		FROM (VALUES ` + strings.Join(values, ", ") + `) AS c(name, code)
+		WHERE p.processed_data->>'region' = c.name AND p.region_code IS NULL`
+}
+
+// GetProvinceCounts counts the live records of a project per province with
+// their sentiment, for a map. Every province is listed, the most covered
+// first, and records without a province are counted separately. An empty
+// source counts across all sources, and nil bounds on EventTimeColumn leave
+// the range open. Duplicates are counted only with includeDuplicates.
+func GetProvinceCounts(projectID, source string, from, to *time.Time, includeDuplicates bool) ([]ProvinceCount, int, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT COALESCE(region_code, ''), COUNT(*),
+			COUNT(*) FILTER (WHERE sentiment = 'positive'),
+			COUNT(*) FILTER (WHERE sentiment = 'negative'),
+			COUNT(*) FILTER (WHERE sentiment = 'neutral'),
+			COALESCE(AVG(sentiment_score), 0)
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL` + duplicatesCondition(includeDuplicates) + `
+	`
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+	if from != nil {
+		args = append(args, *from)
+		sqlQuery += fmt.Sprintf(" AND %s >= $%d", EventTimeColumn, len(args))
+	}
+	if to != nil {
+		args = append(args, *to)
+		sqlQuery += fmt.Sprintf(" AND %s < $%d", EventTimeColumn, len(args))
+	}
+	sqlQuery += " GROUP BY COALESCE(region_code, '')"
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query province counts: %v", err)
+	}
+	defer rows.Close()
+
+	byCode := make(map[string]ProvinceCount)
+	unlocated := 0
+	for rows.Next() {
+		var count ProvinceCount
+		if err := rows.Scan(&count.Code, &count.Count, &count.Positive, &count.Negative, &count.Neutral, &count.AverageScore); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan province counts: %v", err)
+		}
+		if count.Code == "" {
+			unlocated = count.Count
+			continue
+		}
+		byCode[count.Code] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read province counts: %v", err)
+	}
+
+	counts := make([]ProvinceCount, 0, len(ProvinceCodes))
+	for name, code := range ProvinceCodes {
+		count := byCode[code]
+		count.Code = code
+		count.Province = name
+		counts = append(counts, count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Province < counts[j].Province
+	})
+
+	return counts, unlocated, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// InsertQuarantinedRecords stores records that failed validation in one
+// transaction and returns how many were stored
+func InsertQuarantinedRecords(records []QuarantinedRecord) (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin quarantine insert: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO quarantined_records (project_id, batch_id, campaign, source, kind, record_id, reasons, record)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, NULLIF($6, ''), $7, $8)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare quarantine insert: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, record := range records {
+		reasons, err := json.Marshal(record.Reasons)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal quarantine reasons: %v", err)
+		}
+		_, err = stmt.Exec(projectIDOrDefault(record.ProjectID), record.BatchID, record.Campaign, record.Source,
+			record.Kind, record.RecordID, string(reasons), record.Record)
+		if err != nil {
+			return 0, fmt.Errorf("failed to quarantine record %s: %v", record.RecordID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit quarantine insert: %v", err)
+	}
+
+	return len(records), nil
+}
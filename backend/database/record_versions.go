@@ -0,0 +1,174 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetUpdateCheckTargets returns up to limit live articles with a URL that
+// were published since publishedSince and not checked since checkedBefore,
+// least recently checked first. Instagram posts are left out; their pages
+// require a login.
+func GetUpdateCheckTargets(publishedSince, checkedBefore time.Time, limit int) ([]UpdateCheckTarget, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, project_id, processed_data->>'url'
+		FROM processed_data
+		WHERE deleted_at IS NULL
+			AND processed_data->>'url' LIKE 'http%'
+			AND COALESCE(processed_data->>'source_key', '') <> 'instagram'
+			AND ` + EventTimeColumn + ` >= $1
+			AND (checked_at IS NULL OR checked_at < $2)
+		ORDER BY checked_at NULLS FIRST, id
+		LIMIT $3
+	`
+
+	rows, err := DB.Query(sqlQuery, publishedSince, checkedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query update check targets: %v", err)
+	}
+	defer rows.Close()
+
+	targets := []UpdateCheckTarget{}
+	for rows.Next() {
+		var target UpdateCheckTarget
+		if err := rows.Scan(&target.RecordID, &target.ProjectID, &target.URL); err != nil {
+			return nil, fmt.Errorf("failed to scan update check target: %v", err)
+		}
+		targets = append(targets, target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read update check targets: %v", err)
+	}
+
+	return targets, nil
+}
+
+// GetLatestRecordVersion returns the newest stored version of a record, or
+// nil when the record was never fetched again
+func GetLatestRecordVersion(recordID int) (*RecordVersion, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT record_id, version, title, content, content_digest, title_changed, content_changed, fetched_at
+		FROM record_versions
+		WHERE record_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	version, err := scanRecordVersion(DB.QueryRow(sqlQuery, recordID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest version of record %d: %v", recordID, err)
+	}
+	return version, nil
+}
+
+// AddRecordVersion stores the next version of a record and marks the record
+// checked. A version after the first also sets the record's changed_at.
+func AddRecordVersion(version *RecordVersion) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin record version insert: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO record_versions (record_id, version, title, content, content_digest, title_changed, content_changed, fetched_at)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6, NOW()
+		FROM record_versions
+		WHERE record_id = $1
+		RETURNING version, fetched_at
+	`, version.RecordID, version.Title, version.Content, version.ContentDigest, version.TitleChanged, version.ContentChanged,
+	).Scan(&version.Version, &version.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert version of record %d: %v", version.RecordID, err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE processed_data
+		SET checked_at = $2, changed_at = CASE WHEN $3 > 1 THEN $2 ELSE changed_at END
+		WHERE id = $1
+	`, version.RecordID, version.FetchedAt, version.Version)
+	if err != nil {
+		return fmt.Errorf("failed to mark record %d checked: %v", version.RecordID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit record version: %v", err)
+	}
+	return nil
+}
+
+// MarkRecordChecked records that a record was fetched again without finding
+// an edit, or could not be fetched, so it waits for the next interval
+func MarkRecordChecked(recordID int) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	if _, err := DB.Exec(`UPDATE processed_data SET checked_at = NOW() WHERE id = $1`, recordID); err != nil {
+		return fmt.Errorf("failed to mark record %d checked: %v", recordID, err)
+	}
+	return nil
+}
+
+// GetRecordVersions returns the stored versions of a project's record,
+// oldest first
+func GetRecordVersions(projectID string, recordID int) ([]RecordVersion, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT v.record_id, v.version, v.title, v.content, v.content_digest, v.title_changed, v.content_changed, v.fetched_at
+		FROM record_versions v
+		JOIN processed_data p ON p.id = v.record_id
+		WHERE p.project_id = $1 AND v.record_id = $2
+		ORDER BY v.version
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record versions: %v", err)
+	}
+	defer rows.Close()
+
+	versions := []RecordVersion{}
+	for rows.Next() {
+		version, err := scanRecordVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record version: %v", err)
+		}
+		versions = append(versions, *version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read record versions: %v", err)
+	}
+
+	return versions, nil
+}
+
+// scanRecordVersion scans a record_versions row
+func scanRecordVersion(row interface{ Scan(...interface{}) error }) (*RecordVersion, error) {
+	var version RecordVersion
+	err := row.Scan(&version.RecordID, &version.Version, &version.Title, &version.Content, &version.ContentDigest,
+		&version.TitleChanged, &version.ContentChanged, &version.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// relatedKeyphraseCount is how many keyphrases represent a record
+const relatedKeyphraseCount = 15
+
+// relatedCandidateLimit caps the records scored for a single lookup
+const relatedCandidateLimit = 500
+
+// RelatedRecord is a record similar to another one
+type RelatedRecord struct {
+	ProcessedData
+	Score       float64  `json:"score"`                  // keyphrase Jaccard or embedding cosine similarity
+	SharedTerms []string `json:"shared_terms,omitempty"` // keyphrases both records have
+}
+
+// ExtractKeyphrases returns the n most frequent non-stop words of text
+func ExtractKeyphrases(text string, n int) []string {
+	stopWords := getStopWords()
+	counts := make(map[string]int)
+	for _, word := range tokenizeText(strings.ToLower(text)) {
+		if len(word) < 3 || !isAlphabetic(word) || contains(stopWords, word) {
+			continue
+		}
+		counts[word]++
+	}
+
+	terms := make([]string, 0, len(counts))
+	for term := range counts {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if counts[terms[i]] != counts[terms[j]] {
+			return counts[terms[i]] > counts[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// GetRelatedProcessedData returns the records of a project, across all sources,
+// that share the most keyphrases with the record id, most similar first.
+// It returns nil when the record does not exist.
+func GetRelatedProcessedData(projectID string, id, limit int) ([]RelatedRecord, error) {
+	record, err := GetProcessedDataByID(projectID, id)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	keyphrases := ExtractKeyphrases(record.Title+" "+record.Content, relatedKeyphraseCount)
+	related := []RelatedRecord{}
+	if len(keyphrases) == 0 {
+		return related, nil
+	}
+
+	// ILIKE ANY takes no ESCAPE clause; the patterns rely on the default
+	// backslash escape likeContains writes
+	patterns := make([]string, len(keyphrases))
+	for i, term := range keyphrases {
+		patterns[i] = likeContains(term)
+	}
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL AND id <> $2
+			AND (title ILIKE ANY($3) OR content ILIKE ANY($3))
+		ORDER BY id DESC
+		LIMIT $4
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), id, pq.Array(patterns), relatedCandidateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query related data: %v", err)
+	}
+	defer rows.Close()
+
+	candidates, err := scanProcessedData(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	own := make(map[string]bool, len(keyphrases))
+	for _, term := range keyphrases {
+		own[term] = true
+	}
+
+	for _, candidate := range candidates {
+		theirs := ExtractKeyphrases(candidate.Title+" "+candidate.Content, relatedKeyphraseCount)
+
+		var shared []string
+		for _, term := range theirs {
+			if own[term] {
+				shared = append(shared, term)
+			}
+		}
+		if len(shared) == 0 {
+			continue
+		}
+
+		union := len(own) + len(theirs) - len(shared)
+		related = append(related, RelatedRecord{
+			ProcessedData: candidate,
+			Score:         float64(len(shared)) / float64(union),
+			SharedTerms:   shared,
+		})
+	}
+
+	sort.SliceStable(related, func(i, j int) bool {
+		return related[i].Score > related[j].Score
+	})
+	if len(related) > limit {
+		related = related[:limit]
+	}
+
+	return related, nil
+}
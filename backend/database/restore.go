@@ -0,0 +1,190 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EnsureTablesEmpty returns an error if any of the given tables has rows
+func EnsureTablesEmpty(tables []string) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	for _, table := range tables {
+		var exists bool
+		if err := DB.QueryRow(fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", table)).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check %s: %v", table, err)
+		}
+		if exists {
+			return fmt.Errorf("table %s is not empty; restore requires an empty database", table)
+		}
+	}
+
+	return nil
+}
+
+// RestoreSnapshot inserts snapshot rows in a single transaction. next returns
+// one row at a time and io.EOF when done. Columns that no longer exist in the
+// current schema are dropped; columns added since the snapshot get defaults.
+func RestoreSnapshot(next func() (string, map[string]interface{}, error)) (map[string]int, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin restore transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	tableColumns := make(map[string]map[string]bool)
+	counts := make(map[string]int)
+
+	for {
+		table, row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return counts, err
+		}
+
+		columns, ok := tableColumns[table]
+		if !ok {
+			if !isBackupTable(table) {
+				return counts, fmt.Errorf("snapshot contains unexpected table %q", table)
+			}
+			if columns, err = currentColumns(table); err != nil {
+				return counts, err
+			}
+			tableColumns[table] = columns
+		}
+
+		var names []string
+		for name := range row {
+			if columns[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		placeholders := make([]string, len(names))
+		values := make([]interface{}, len(names))
+		for i, name := range names {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			values[i] = row[name]
+		}
+
+		sqlQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+		if _, err := tx.Exec(sqlQuery, values...); err != nil {
+			return counts, fmt.Errorf("failed to restore %s row: %v", table, err)
+		}
+		counts[table]++
+	}
+
+	// Move ID sequences past the restored rows so new inserts don't collide
+	for table := range tableColumns {
+		sqlQuery := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %s", table, table)
+		if _, err := tx.Exec(sqlQuery); err != nil {
+			return counts, fmt.Errorf("failed to reset %s id sequence: %v", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return counts, fmt.Errorf("failed to commit restore: %v", err)
+	}
+
+	return counts, nil
+}
+
+// RebuildDerivedData loads the warehouse facts of every record and rebuilds
+// the daily rollups of every day with live records. Backups hold the records
+// only, so a restore derives both again, whichever way it loaded them.
+func RebuildDerivedData() error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin warehouse load: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range contentFactStatements("TRUE") {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("failed to load warehouse facts: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit warehouse load: %v", err)
+	}
+
+	rows, err := DB.Query(`
+		SELECT DISTINCT project_id, (` + EventTimeColumn + `)::date
+		FROM processed_data
+		WHERE deleted_at IS NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query record days: %v", err)
+	}
+	defer rows.Close()
+
+	type projectDay struct {
+		projectID string
+		day       time.Time
+	}
+	var days []projectDay
+	for rows.Next() {
+		var pd projectDay
+		if err := rows.Scan(&pd.projectID, &pd.day); err != nil {
+			return fmt.Errorf("failed to scan record day: %v", err)
+		}
+		days = append(days, pd)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read record days: %v", err)
+	}
+
+	for _, pd := range days {
+		if _, err := RefreshDailyRollups(pd.projectID, pd.day); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// currentColumns returns the set of columns a table has in the live schema
+func currentColumns(table string) (map[string]bool, error) {
+	rows, err := DB.Query(`SELECT column_name FROM information_schema.columns WHERE table_name = $1`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %v", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %v", err)
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// isBackupTable reports whether table is one of BackupTables
+func isBackupTable(table string) bool {
+	for _, t := range BackupTables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
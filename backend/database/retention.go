@@ -0,0 +1,221 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// expiredRawData matches the raw_data rows r extracted before $1, except the
+// rows a pipeline checkpoint still needs to resume its run
+const expiredRawData = `
+	r.extracted_at < $1
+	AND NOT EXISTS (
+		SELECT 1 FROM pipeline_checkpoints c WHERE c.raw_data_ids @> jsonb_build_array(r.id)
+	)
+`
+
+// CreateRetentionRun stores a new retention run history entry
+func CreateRetentionRun(run *RetentionRun) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO retention_runs (mode, trigger, dry_run, status, cutoff, archive_path)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING id, started_at
+	`
+
+	if err := DB.QueryRow(sqlQuery, run.Mode, run.Trigger, run.DryRun, run.Status, run.Cutoff, run.ArchivePath).Scan(&run.ID, &run.StartedAt); err != nil {
+		return fmt.Errorf("failed to insert retention run: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateRetentionRun stores the outcome and metrics of a retention run
+func UpdateRetentionRun(run *RetentionRun) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		UPDATE retention_runs
+		SET status = $1, rows_pruned = $2, bytes_reclaimed = $3, table_bytes_before = $4,
+		    table_bytes_after = $5, archive_path = NULLIF($6, ''), error_message = NULLIF($7, ''),
+		    completed_at = $8
+		WHERE id = $9
+	`
+
+	_, err := DB.Exec(sqlQuery, run.Status, run.RowsPruned, run.BytesReclaimed, run.TableBytesBefore,
+		run.TableBytesAfter, run.ArchivePath, run.ErrorMessage, run.CompletedAt, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update retention run: %v", err)
+	}
+
+	return nil
+}
+
+// GetRetentionRuns returns the most recent retention runs first
+func GetRetentionRuns(limit int) ([]RetentionRun, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, mode, trigger, dry_run, status, cutoff, rows_pruned, bytes_reclaimed,
+		       table_bytes_before, table_bytes_after, COALESCE(archive_path, ''),
+		       COALESCE(error_message, ''), started_at, completed_at
+		FROM retention_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`
+
+	rows, err := DB.Query(sqlQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention runs: %v", err)
+	}
+	defer rows.Close()
+
+	var runs []RetentionRun
+	for rows.Next() {
+		var run RetentionRun
+		if err := rows.Scan(
+			&run.ID,
+			&run.Mode,
+			&run.Trigger,
+			&run.DryRun,
+			&run.Status,
+			&run.Cutoff,
+			&run.RowsPruned,
+			&run.BytesReclaimed,
+			&run.TableBytesBefore,
+			&run.TableBytesAfter,
+			&run.ArchivePath,
+			&run.ErrorMessage,
+			&run.StartedAt,
+			&run.CompletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan retention run row: %v", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate retention runs: %v", err)
+	}
+
+	return runs, nil
+}
+
+// RawDataTableSize returns the disk space of raw_data with its indexes and
+// TOAST data. Deleted rows only free it once they are vacuumed.
+func RawDataTableSize() (int64, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	var size int64
+	if err := DB.QueryRow(`SELECT pg_total_relation_size('raw_data')`).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to read raw_data size: %v", err)
+	}
+
+	return size, nil
+}
+
+// CountExpiredRawData counts the raw_data rows extracted before cutoff and
+// their stored size in bytes, without pruning them
+func CountExpiredRawData(cutoff time.Time) (int, int64, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT COUNT(*), COALESCE(SUM(pg_column_size(r.*)), 0)
+		FROM raw_data r
+		WHERE ` + expiredRawData
+
+	var count int
+	var size int64
+	if err := DB.QueryRow(sqlQuery, cutoff).Scan(&count, &size); err != nil {
+		return 0, 0, fmt.Errorf("failed to count expired raw data: %v", err)
+	}
+
+	return count, size, nil
+}
+
+// PruneRawData deletes up to limit raw_data rows extracted before cutoff,
+// oldest first, and returns how many it deleted and their stored size. When
+// archive is set the rows are passed to it, keyed by column name, before they
+// are deleted; an archive error deletes nothing.
+func PruneRawData(cutoff time.Time, limit int, archive func(rows []map[string]interface{}) error) (int, int64, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin prune transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	sqlQuery := `
+		SELECT r.*, pg_column_size(r.*) AS stored_bytes
+		FROM raw_data r
+		WHERE ` + expiredRawData + `
+		ORDER BY r.extracted_at, r.id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(sqlQuery, cutoff, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query expired raw data: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read raw_data columns: %v", err)
+	}
+
+	var expired []map[string]interface{}
+	var ids []int64
+	var size int64
+	for rows.Next() {
+		row, err := scanRowMap(rows, columns)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to scan raw data row: %v", err)
+		}
+
+		storedBytes, _ := row["stored_bytes"].(int64)
+		delete(row, "stored_bytes")
+		id, _ := row["id"].(int64)
+		expired = append(expired, row)
+		ids = append(ids, id)
+		size += storedBytes
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to iterate expired raw data: %v", err)
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return 0, 0, nil
+	}
+	if archive != nil {
+		if err := archive(expired); err != nil {
+			return 0, 0, err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM raw_data WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return 0, 0, fmt.Errorf("failed to delete expired raw data: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit prune transaction: %v", err)
+	}
+
+	return len(ids), size, nil
+}
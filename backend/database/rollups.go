@@ -0,0 +1,325 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RollupDimensions maps the trend dimensions to their daily_rollups column
+var RollupDimensions = map[string]string{
+	"source":    "source",
+	"sentiment": "sentiment",
+	"topic":     "topic",
+	"province":  "province",
+}
+
+// rollupDimensionValues are the processed_data expressions the rollup
+// dimensions are computed from
+var rollupDimensionValues = map[string]string{
+	"source":    "source",
+	"sentiment": "COALESCE(NULLIF(sentiment, ''), 'unknown')",
+	"topic":     "COALESCE(NULLIF(processed_data->>'category', ''), 'unknown')",
+	"province":  "COALESCE(NULLIF(processed_data->>'region', ''), 'unknown')",
+}
+
+// rollupKeyValues selects the source, sentiment, topic, province and
+// canonical columns of a rollup row from processed_data
+var rollupKeyValues = rollupDimensionValues["source"] + ", " + rollupDimensionValues["sentiment"] + ", " +
+	rollupDimensionValues["topic"] + ", " + rollupDimensionValues["province"] + ", " + canonicalCondition
+
+// rollupCondition returns the condition appended to a daily_rollups WHERE
+// clause to count canonical records only, or nothing with includeDuplicates
+func rollupCondition(includeDuplicates bool) string {
+	if includeDuplicates {
+		return ""
+	}
+	return " AND canonical"
+}
+
+// GetBatchDays returns the UTC days the live records loaded by one pipeline
+// run fall on, sorted, so the rollups of those days can be rebuilt
+func GetBatchDays(projectID, batchID string) ([]time.Time, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT DISTINCT (` + EventTimeColumn + `)::date AS day
+		FROM processed_data
+		WHERE project_id = $1 AND batch_id = $2 AND deleted_at IS NULL
+		ORDER BY day
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query batch days: %v", err)
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, fmt.Errorf("failed to scan batch day: %v", err)
+		}
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+// GetRecordDays returns the UTC days the records of a project with the given
+// IDs fall on, deleted or not, sorted, so the rollups of those days can be
+// rebuilt after the records change
+func GetRecordDays(projectID string, ids []int) ([]time.Time, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT DISTINCT (` + EventTimeColumn + `)::date AS day
+		FROM processed_data
+		WHERE project_id = $1 AND id = ANY($2)
+		ORDER BY day
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record days: %v", err)
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, fmt.Errorf("failed to scan record day: %v", err)
+		}
+		days = append(days, day)
+	}
+
+	return days, rows.Err()
+}
+
+// RefreshDailyRollups recomputes a project's rollup rows for the UTC
+// calendar day of day. The day is replaced as a whole, so records that were
+// soft-deleted, rescored or regrouped since the last refresh are reflected too.
+func RefreshDailyRollups(projectID string, day time.Time) (int64, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	projectID = projectIDOrDefault(projectID)
+	dayStr := day.UTC().Format("2006-01-02")
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin rollup refresh: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM daily_rollups WHERE project_id = $1 AND day = $2`, projectID, dayStr); err != nil {
+		return 0, fmt.Errorf("failed to clear daily rollups: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO daily_rollups (project_id, day, source, sentiment, topic, province, canonical,
+			record_count, sentiment_score_sum, relevance_score_sum, updated_at)
+		SELECT $1, $2::date, ` + rollupKeyValues + `,
+			COUNT(*), COALESCE(SUM(sentiment_score), 0), COALESCE(SUM(relevance_score), 0), NOW()
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL
+			AND ` + EventTimeColumn + ` >= $2::date AND ` + EventTimeColumn + ` < $2::date + INTERVAL '1 day'
+		GROUP BY 3, 4, 5, 6, 7
+	`
+
+	result, err := tx.Exec(sqlQuery, projectID, dayStr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build daily rollups: %v", err)
+	}
+	rows, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit daily rollups: %v", err)
+	}
+
+	return rows, nil
+}
+
+// GetDailyTrend returns per-day totals for the last days days grouped by
+// dimension, counting duplicates only with includeDuplicates. UTC days are
+// read from the rollups only; days in another zone are counted from the
+// records, as the rollups hold UTC days.
+func GetDailyTrend(projectID, dimension string, days int, zone *time.Location, includeDuplicates bool) ([]TrendPoint, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	column, ok := RollupDimensions[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unknown trend dimension %q", dimension)
+	}
+
+	sqlQuery := `
+		SELECT TO_CHAR(day, 'YYYY-MM-DD'), ` + column + `,
+			SUM(record_count), SUM(sentiment_score_sum), SUM(relevance_score_sum)
+		FROM daily_rollups
+		WHERE project_id = $1 AND day > CURRENT_DATE - $2::integer` + rollupCondition(includeDuplicates) + `
+		GROUP BY day, ` + column + `
+		ORDER BY day, ` + column
+	args := []interface{}{projectIDOrDefault(projectID), days}
+	if !isUTC(zone) {
+		now := time.Now().In(zone)
+		start := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, zone)
+		sqlQuery = `
+			SELECT TO_CHAR(` + localDayColumn("$3") + `, 'YYYY-MM-DD') AS day, ` + rollupDimensionValues[dimension] + ` AS value,
+				COUNT(*), COALESCE(SUM(sentiment_score), 0), COALESCE(SUM(relevance_score), 0)
+			FROM processed_data
+			WHERE project_id = $1 AND deleted_at IS NULL AND ` + EventTimeColumn + ` >= $2` + duplicatesCondition(includeDuplicates) + `
+			GROUP BY 1, 2
+			ORDER BY 1, 2`
+		args = []interface{}{projectIDOrDefault(projectID), start.UTC(), zone.String()}
+	}
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily trend: %v", err)
+	}
+	defer rows.Close()
+
+	var points []TrendPoint
+	for rows.Next() {
+		var point TrendPoint
+		var sentimentSum, relevanceSum float64
+		if err := rows.Scan(&point.Day, &point.Value, &point.RecordCount, &sentimentSum, &relevanceSum); err != nil {
+			return nil, fmt.Errorf("failed to scan daily trend: %v", err)
+		}
+		if point.RecordCount > 0 {
+			point.AvgSentimentScore = sentimentSum / float64(point.RecordCount)
+			point.AvgRelevanceScore = relevanceSum / float64(point.RecordCount)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read daily trend: %v", err)
+	}
+
+	return points, nil
+}
+
+// TimeSeriesIntervals are the periods a time series can be bucketed in, as
+// date_trunc fields
+var TimeSeriesIntervals = map[string]bool{"day": true, "week": true, "month": true}
+
+// GetTimeSeries counts a project's records per interval (day, week or month;
+// weeks start on Monday) and source, with their average sentiment and
+// relevance, for the records whose EventTimeColumn falls in [from, to).
+// Periods are bucketed in zone. An empty source counts all sources, and
+// duplicates are counted only with includeDuplicates.
+func GetTimeSeries(projectID, interval, source string, from, to time.Time, zone *time.Location, includeDuplicates bool) ([]TimeSeriesPoint, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	if !TimeSeriesIntervals[interval] {
+		return nil, fmt.Errorf("unknown time series interval %q", interval)
+	}
+	if zone == nil {
+		zone = time.UTC
+	}
+
+	sqlQuery := `
+		SELECT TO_CHAR(date_trunc($2, ` + EventTimeColumn + ` AT TIME ZONE 'UTC' AT TIME ZONE $3), 'YYYY-MM-DD') AS period, source,
+			COUNT(*), COALESCE(AVG(sentiment_score), 0), COALESCE(AVG(relevance_score), 0)
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NULL
+			AND ` + EventTimeColumn + ` >= $4 AND ` + EventTimeColumn + ` < $5` + duplicatesCondition(includeDuplicates) + `
+	`
+	args := []interface{}{projectIDOrDefault(projectID), interval, zone.String(), from.UTC(), to.UTC()}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+	sqlQuery += " GROUP BY 1, 2 ORDER BY 1, 2"
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time series: %v", err)
+	}
+	defer rows.Close()
+
+	points := []TimeSeriesPoint{}
+	for rows.Next() {
+		var point TimeSeriesPoint
+		if err := rows.Scan(&point.Period, &point.Source, &point.RecordCount, &point.AvgSentimentScore, &point.AvgRelevanceScore); err != nil {
+			return nil, fmt.Errorf("failed to scan time series: %v", err)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read time series: %v", err)
+	}
+
+	return points, nil
+}
+
+// GetAspectSentiment aggregates the per-record aspect sentiment stored in
+// processed_data->'aspects', optionally for a single source and without
+// likely sarcastic comments, counting duplicates only with includeDuplicates
+func GetAspectSentiment(projectID, source string, excludeSarcastic, includeDuplicates bool) ([]AspectSentimentSummary, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT a.key, COUNT(*), SUM((a.value->>'mentions')::integer),
+			AVG((a.value->>'score')::float),
+			COUNT(*) FILTER (WHERE a.value->>'category' = 'positive'),
+			COUNT(*) FILTER (WHERE a.value->>'category' = 'negative'),
+			COUNT(*) FILTER (WHERE a.value->>'category' = 'neutral')
+		FROM processed_data, jsonb_each(processed_data->'aspects') a
+		WHERE project_id = $1 AND deleted_at IS NULL
+			AND jsonb_typeof(processed_data->'aspects') = 'object'
+	`
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND source = $%d", len(args))
+	}
+	if excludeSarcastic {
+		sqlQuery += " AND " + notSarcasticCondition
+	}
+	sqlQuery += duplicatesCondition(includeDuplicates)
+	sqlQuery += " GROUP BY a.key ORDER BY a.key"
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aspect sentiment: %v", err)
+	}
+	defer rows.Close()
+
+	summaries := []AspectSentimentSummary{}
+	for rows.Next() {
+		var summary AspectSentimentSummary
+		err := rows.Scan(
+			&summary.Aspect,
+			&summary.RecordCount,
+			&summary.Mentions,
+			&summary.AvgScore,
+			&summary.Positive,
+			&summary.Negative,
+			&summary.Neutral,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan aspect sentiment: %v", err)
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read aspect sentiment: %v", err)
+	}
+
+	return summaries, nil
+}
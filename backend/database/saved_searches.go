@@ -0,0 +1,162 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CreateSavedSearch stores a new saved search. The match cursor starts at the
+// current newest record so subscribers are only notified about future data.
+func CreateSavedSearch(search *SavedSearch) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	maxID, err := GetMaxProcessedDataID()
+	if err != nil {
+		return err
+	}
+	search.LastMatchedID = maxID
+	search.WebhookMatchedID = maxID
+	search.EmailMatchedID = maxID
+	search.ProjectID = projectIDOrDefault(search.ProjectID)
+
+	sqlQuery := `
+		INSERT INTO saved_searches (project_id, user_id, name, query, source, sentiment, notify_email, webhook_url, last_matched_id, webhook_matched_id, email_matched_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	err = DB.QueryRow(sqlQuery,
+		search.ProjectID,
+		search.UserID,
+		search.Name,
+		search.Query,
+		search.Source,
+		search.Sentiment,
+		search.NotifyEmail,
+		search.WebhookURL,
+		search.LastMatchedID,
+		search.WebhookMatchedID,
+		search.EmailMatchedID,
+	).Scan(&search.ID, &search.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert saved search: %v", err)
+	}
+
+	return nil
+}
+
+// GetSavedSearches returns the saved searches of a user within a project.
+// Empty projectID or userID values match every project or user.
+func GetSavedSearches(projectID, userID string) ([]SavedSearch, error) {
+	if err := EnsureConnection(); err != nil {
+		return []SavedSearch{}, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT id, project_id, user_id, name, query, COALESCE(source, ''), COALESCE(sentiment, ''),
+		       COALESCE(notify_email, ''), COALESCE(webhook_url, ''), last_matched_id, last_notified_at, created_at,
+		       webhook_matched_id, email_matched_id
+		FROM saved_searches
+	`
+	var conditions []string
+	var args []interface{}
+	if projectID != "" {
+		args = append(args, projectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if userID != "" {
+		args = append(args, userID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if len(conditions) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sqlQuery += " ORDER BY id"
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %v", err)
+	}
+	defer rows.Close()
+
+	var results []SavedSearch
+	for rows.Next() {
+		var search SavedSearch
+		err := rows.Scan(
+			&search.ID,
+			&search.ProjectID,
+			&search.UserID,
+			&search.Name,
+			&search.Query,
+			&search.Source,
+			&search.Sentiment,
+			&search.NotifyEmail,
+			&search.WebhookURL,
+			&search.LastMatchedID,
+			&search.LastNotifiedAt,
+			&search.CreatedAt,
+			&search.WebhookMatchedID,
+			&search.EmailMatchedID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %v", err)
+		}
+		results = append(results, search)
+	}
+
+	return results, nil
+}
+
+// DeleteSavedSearch removes a saved search owned by the given user in a project
+func DeleteSavedSearch(projectID string, id int, userID string) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	result, err := DB.Exec("DELETE FROM saved_searches WHERE id = $1 AND user_id = $2 AND project_id = $3", id, userID, projectID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete saved search: %v", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// savedSearchCursorColumns are the cursor columns of a saved search: the last
+// record matched, and the last record delivered per notification channel
+var savedSearchCursorColumns = map[string]string{
+	"matched": "last_matched_id",
+	"webhook": "webhook_matched_id",
+	"email":   "email_matched_id",
+}
+
+// UpdateSavedSearchCursors advances the cursors of a saved search, keyed by
+// "matched" or a notification channel ("webhook", "email"), to the last
+// record they covered. notified also stamps the notification time.
+func UpdateSavedSearchCursors(id int, cursors map[string]int, notified bool) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	args := []interface{}{id}
+	var assignments []string
+	for _, key := range []string{"matched", "webhook", "email"} {
+		if lastID, ok := cursors[key]; ok {
+			args = append(args, lastID)
+			assignments = append(assignments, fmt.Sprintf("%s = $%d", savedSearchCursorColumns[key], len(args)))
+		}
+	}
+	if notified {
+		assignments = append(assignments, "last_notified_at = NOW()")
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	if _, err := DB.Exec(`UPDATE saved_searches SET `+strings.Join(assignments, ", ")+` WHERE id = $1`, args...); err != nil {
+		return fmt.Errorf("failed to update saved search cursors: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// UpsertSearchInterest stores search interest, replacing the interest of
+// terms and days already stored. Google Trends rescales a whole series when
+// a new peak is reached, so each refresh overwrites the earlier values.
+func UpsertSearchInterest(points []SearchInterest) (int, error) {
+	if err := EnsureConnection(); err != nil {
+		return 0, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin search interest upsert: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO search_interest (geo, term, day, interest, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (geo, term, day) DO UPDATE SET
+			interest = EXCLUDED.interest,
+			updated_at = NOW()
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare search interest upsert: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, point := range points {
+		if _, err := stmt.Exec(point.Geo, point.Term, point.Day, point.Interest); err != nil {
+			return 0, fmt.Errorf("failed to upsert search interest in %q on %s: %v", point.Term, point.Day, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit search interest upsert: %v", err)
+	}
+
+	return len(points), nil
+}
+
+// GetSearchInterest returns the search interest of a geo for the days in
+// [from, to], oldest first
+func GetSearchInterest(geo string, from, to time.Time) ([]SearchInterest, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT geo, term, TO_CHAR(day, 'YYYY-MM-DD'), interest, updated_at
+		FROM search_interest
+		WHERE geo = $1 AND day >= $2::date AND day <= $3::date
+		ORDER BY day, term
+	`
+
+	rows, err := DB.Query(sqlQuery, geo, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search interest: %v", err)
+	}
+	defer rows.Close()
+
+	points := []SearchInterest{}
+	for rows.Next() {
+		var point SearchInterest
+		if err := rows.Scan(&point.Geo, &point.Term, &point.Day, &point.Interest, &point.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan search interest: %v", err)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search interest: %v", err)
+	}
+
+	return points, nil
+}
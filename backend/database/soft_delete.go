@@ -0,0 +1,152 @@
+package database
+
+import (
+	"fmt"
+)
+
+// SoftDeleteProcessedData marks a processed record of a project as deleted.
+// It returns false when the record does not exist in the project or is
+// already deleted.
+func SoftDeleteProcessedData(projectID string, id int) (bool, error) {
+	return setProcessedDataDeleted(projectID, id, true)
+}
+
+// RestoreProcessedData clears the deleted mark on a processed record of a
+// project. It returns false when the record does not exist in the project, is
+// not deleted, or has been loaded again since as another live record.
+func RestoreProcessedData(projectID string, id int) (bool, error) {
+	return setProcessedDataDeleted(projectID, id, false)
+}
+
+// setProcessedDataDeleted sets or clears deleted_at on a single record
+func setProcessedDataDeleted(projectID string, id int, deleted bool) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `UPDATE processed_data SET deleted_at = NOW() WHERE id = $1 AND project_id = $2 AND deleted_at IS NULL`
+	if !deleted {
+		sqlQuery = `UPDATE processed_data SET deleted_at = NULL WHERE id = $1 AND project_id = $2 AND deleted_at IS NOT NULL AND ` + liveDuplicateCondition
+	}
+
+	result, err := DB.Exec(sqlQuery, id, projectIDOrDefault(projectID))
+	if err != nil {
+		return false, fmt.Errorf("failed to update processed data %d: %v", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read affected rows: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+// SoftDeleteBatch marks all raw and processed rows of an extraction batch in
+// a project as deleted and returns the number of rows changed per table
+func SoftDeleteBatch(projectID, batchID string) (map[string]int64, error) {
+	return setBatchDeleted(projectID, batchID, true)
+}
+
+// RestoreBatch clears the deleted mark on all rows of an extraction batch in
+// a project and returns the number of rows changed per table. Processed
+// records loaded again since by another batch stay deleted.
+func RestoreBatch(projectID, batchID string) (map[string]int64, error) {
+	return setBatchDeleted(projectID, batchID, false)
+}
+
+// setBatchDeleted sets or clears deleted_at on a batch in both tables atomically
+func setBatchDeleted(projectID, batchID string, deleted bool) (map[string]int64, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	counts := make(map[string]int64)
+	for _, table := range []string{"raw_data", "processed_data"} {
+		sqlQuery := fmt.Sprintf(`UPDATE %s SET deleted_at = NOW() WHERE batch_id = $1 AND project_id = $2 AND deleted_at IS NULL`, table)
+		if !deleted {
+			sqlQuery = fmt.Sprintf(`UPDATE %s SET deleted_at = NULL WHERE batch_id = $1 AND project_id = $2 AND deleted_at IS NOT NULL`, table)
+			if table == "processed_data" {
+				sqlQuery += " AND " + liveDuplicateCondition
+			}
+		}
+
+		result, err := tx.Exec(sqlQuery, batchID, projectIDOrDefault(projectID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to update %s for batch %s: %v", table, batchID, err)
+		}
+		if counts[table], err = result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to read affected rows: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit batch update: %v", err)
+	}
+
+	return counts, nil
+}
+
+// PurgeBatch permanently deletes the raw and processed rows of a batch in a
+// project and returns the number of rows removed per table. Tags, notes and
+// collection items of the removed records are deleted with them.
+func PurgeBatch(projectID, batchID string) (map[string]int64, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	counts := make(map[string]int64)
+	for _, table := range []string{"raw_data", "processed_data"} {
+		sqlQuery := fmt.Sprintf(`DELETE FROM %s WHERE project_id = $1 AND batch_id = $2`, table)
+
+		result, err := tx.Exec(sqlQuery, projectIDOrDefault(projectID), batchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s for batch %s: %v", table, batchID, err)
+		}
+		if counts[table], err = result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to read affected rows: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit batch purge: %v", err)
+	}
+
+	return counts, nil
+}
+
+// GetDeletedProcessedData lists the soft-deleted records of a project, most
+// recently deleted first
+func GetDeletedProcessedData(projectID string, limit int) ([]ProcessedData, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT ` + processedDataColumns + `
+		FROM processed_data
+		WHERE project_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT $2
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted data: %v", err)
+	}
+	defer rows.Close()
+
+	return scanProcessedData(rows)
+}
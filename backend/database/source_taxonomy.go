@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// OtherSource is the canonical source of records no alias maps to
+const OtherSource = "other"
+
+// Source is a canonical source of the taxonomy
+type Source struct {
+	Name    string   `json:"name"`
+	Label   string   `json:"label"`
+	Kind    string   `json:"kind"` // "video", "news", "social" or "other"
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// SourceAlias maps a spelling of a source or outlet to its canonical source.
+// Outlet is the canonical outlet name when the alias names an outlet.
+type SourceAlias struct {
+	Alias  string `json:"alias"` // lowercase
+	Source string `json:"source"`
+	Outlet string `json:"outlet,omitempty"`
+}
+
+// defaultSources are the canonical sources seeded by the taxonomy migration
+var defaultSources = []Source{
+	{Name: "youtube", Label: "YouTube", Kind: "video"},
+	{Name: "google_news", Label: "Google News", Kind: "news"},
+	{Name: "instagram", Label: "Instagram", Kind: "social"},
+	{Name: "indonesia_news", Label: "Indonesian News", Kind: "news"},
+	{Name: "news_archive", Label: "News Archive", Kind: "news"},
+	{Name: OtherSource, Label: "Other", Kind: "other"},
+}
+
+// defaultSourceAliases are the spellings found in records loaded before the
+// taxonomy, and the outlets of the Indonesia news scraper
+var defaultSourceAliases = []SourceAlias{
+	{Alias: "youtube", Source: "youtube"},
+	{Alias: "google_news", Source: "google_news"},
+	{Alias: "google news", Source: "google_news"},
+	{Alias: "real-time news", Source: "google_news"},
+	{Alias: "instagram", Source: "instagram"},
+	{Alias: "indonesia_news", Source: "indonesia_news"},
+	{Alias: "indonesia news", Source: "indonesia_news"},
+	{Alias: "news_archive", Source: "news_archive"},
+	{Alias: "detik", Source: "indonesia_news", Outlet: "Detik"},
+	{Alias: "kompas", Source: "indonesia_news", Outlet: "Kompas"},
+	{Alias: "cnn", Source: "indonesia_news", Outlet: "CNN Indonesia"},
+	{Alias: "cnn indonesia", Source: "indonesia_news", Outlet: "CNN Indonesia"},
+	{Alias: "tempo", Source: "indonesia_news", Outlet: "Tempo"},
+	{Alias: OtherSource, Source: OtherSource},
+}
+
+// seedSourcesStatement inserts the default sources, keeping rows edited since
+func seedSourcesStatement() string {
+	values := make([]string, 0, len(defaultSources))
+	for _, source := range defaultSources {
+		values = append(values, fmt.Sprintf("(%s, %s, %s)", quoteLiteral(source.Name), quoteLiteral(source.Label), quoteLiteral(source.Kind)))
+	}
+	return `INSERT INTO sources (name, label, kind) VALUES ` + strings.Join(values, ", ") + ` ON CONFLICT (name) DO NOTHING`
+}
+
+// seedSourceAliasesStatement inserts the default aliases, keeping rows edited since
+func seedSourceAliasesStatement() string {
+	values := make([]string, 0, len(defaultSourceAliases))
+	for _, alias := range defaultSourceAliases {
+		outlet := "NULL"
+		if alias.Outlet != "" {
+			outlet = quoteLiteral(alias.Outlet)
+		}
+		values = append(values, fmt.Sprintf("(%s, %s, %s)", quoteLiteral(alias.Alias), quoteLiteral(alias.Source), outlet))
+	}
+	return `INSERT INTO source_aliases (alias, source, outlet) VALUES ` + strings.Join(values, ", ") + ` ON CONFLICT (alias) DO NOTHING`
+}
+
+// defaultSourceList returns the default sources with their default aliases,
+// ordered like GetSources, for the stores without a taxonomy table
+func defaultSourceList() []Source {
+	sources := make([]Source, len(defaultSources))
+	copy(sources, defaultSources)
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	for i := range sources {
+		for _, alias := range defaultSourceAliases {
+			if alias.Source == sources[i].Name {
+				sources[i].Aliases = append(sources[i].Aliases, alias.Alias)
+			}
+		}
+		sort.Strings(sources[i].Aliases)
+	}
+	return sources
+}
+
+// quoteLiteral quotes a constant for inclusion in a SQL statement
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// SourceTaxonomy resolves source and outlet spellings to canonical names
+type SourceTaxonomy struct {
+	aliases map[string]SourceAlias
+}
+
+// DefaultSourceTaxonomy returns the taxonomy seeded by the migration, for
+// use when the database is unavailable
+func DefaultSourceTaxonomy() *SourceTaxonomy {
+	return newSourceTaxonomy(defaultSourceAliases)
+}
+
+// newSourceTaxonomy indexes aliases by their spelling
+func newSourceTaxonomy(aliases []SourceAlias) *SourceTaxonomy {
+	taxonomy := &SourceTaxonomy{aliases: make(map[string]SourceAlias, len(aliases))}
+	for _, alias := range aliases {
+		taxonomy.aliases[alias.Alias] = alias
+	}
+	return taxonomy
+}
+
+// LoadSourceTaxonomy reads the source aliases from the database
+func LoadSourceTaxonomy() (*SourceTaxonomy, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rows, err := DB.Query(`SELECT alias, source, COALESCE(outlet, '') FROM source_aliases`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query source aliases: %v", err)
+	}
+	defer rows.Close()
+
+	aliases := []SourceAlias{}
+	for rows.Next() {
+		var alias SourceAlias
+		if err := rows.Scan(&alias.Alias, &alias.Source, &alias.Outlet); err != nil {
+			return nil, fmt.Errorf("failed to scan source alias: %v", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read source aliases: %v", err)
+	}
+
+	return newSourceTaxonomy(aliases), nil
+}
+
+// lookup finds the alias of a spelling, ignoring case and surrounding spaces
+func (t *SourceTaxonomy) lookup(name string) (SourceAlias, bool) {
+	alias, ok := t.aliases[strings.ToLower(strings.TrimSpace(name))]
+	return alias, ok
+}
+
+// Resolve returns the canonical source and outlet of a record. The source is
+// looked up by name, then by outlet; records matching neither belong to
+// OtherSource. Outlets with an alias are renamed to their canonical outlet.
+func (t *SourceTaxonomy) Resolve(name, outlet string) (string, string) {
+	outlet = strings.TrimSpace(outlet)
+	outletAlias, outletKnown := t.lookup(outlet)
+	if outletKnown && outletAlias.Outlet != "" {
+		outlet = outletAlias.Outlet
+	}
+
+	if alias, ok := t.lookup(name); ok {
+		if outlet == "" {
+			outlet = alias.Outlet
+		}
+		return alias.Source, outlet
+	}
+	if outletKnown {
+		return outletAlias.Source, outlet
+	}
+	return OtherSource, outlet
+}
+
+// GetSources returns the canonical sources with their aliases
+func GetSources() ([]Source, error) {
+	return GetSourcesContext(context.Background())
+}
+
+// GetSourcesContext is GetSources with a context that cancels the query
+func GetSourcesContext(ctx context.Context) ([]Source, error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT s.name, s.label, s.kind, COALESCE(ARRAY_AGG(a.alias ORDER BY a.alias) FILTER (WHERE a.alias IS NOT NULL), '{}')
+		FROM sources s
+		LEFT JOIN source_aliases a ON a.source = s.name
+		GROUP BY s.name, s.label, s.kind
+		ORDER BY s.name
+	`
+
+	rows, err := DB.QueryContext(ctx, sqlQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sources: %v", err)
+	}
+	defer rows.Close()
+
+	sources := []Source{}
+	for rows.Next() {
+		var source Source
+		if err := rows.Scan(&source.Name, &source.Label, &source.Kind, pq.Array(&source.Aliases)); err != nil {
+			return nil, fmt.Errorf("failed to scan source: %v", err)
+		}
+		sources = append(sources, source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sources: %v", err)
+	}
+
+	return sources, nil
+}
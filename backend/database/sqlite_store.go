@@ -0,0 +1,614 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDriver is the database/sql driver SQLite stores are opened with, the
+// pure Go driver of modernc.org/sqlite, so builds need no cgo
+const sqliteDriver = "sqlite"
+
+// sqliteTimeLayout stores times as fixed-width UTC text, so comparing them as
+// text compares them in time
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqliteSchema creates the tables of a SQLite store. They mirror the columns
+// of raw_data and processed_data that the Store interfaces read and write.
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS raw_data (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		query TEXT NOT NULL DEFAULT '',
+		raw_data TEXT NOT NULL,
+		batch_id TEXT NOT NULL DEFAULT '',
+		extracted_at TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_raw_data_batch ON raw_data(project_id, batch_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_raw_data_extracted_at ON raw_data(extracted_at)`,
+	`CREATE TABLE IF NOT EXISTS processed_data (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		outlet TEXT NOT NULL DEFAULT '',
+		processed_at TEXT NOT NULL,
+		published_at TEXT,
+		title TEXT NOT NULL DEFAULT '',
+		content TEXT NOT NULL DEFAULT '',
+		relevance_score REAL NOT NULL DEFAULT 0,
+		sentiment TEXT NOT NULL DEFAULT '',
+		sentiment_score REAL,
+		sentiment_confidence REAL,
+		analyzer_version INTEGER,
+		batch_id TEXT NOT NULL DEFAULT '',
+		license TEXT NOT NULL DEFAULT '',
+		toxicity_score REAL,
+		campaign TEXT NOT NULL DEFAULT '',
+		region_code TEXT NOT NULL DEFAULT '',
+		hashtag TEXT NOT NULL DEFAULT '',
+		content_hash TEXT NOT NULL DEFAULT '',
+		duplicate_of INTEGER,
+		changed_at TEXT,
+		processed_data TEXT NOT NULL DEFAULT '{}'
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_processed_data_content_hash ON processed_data(project_id, content_hash) WHERE content_hash <> ''`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_project ON processed_data(project_id, source)`,
+	`CREATE INDEX IF NOT EXISTS idx_processed_data_event_time ON processed_data(project_id, COALESCE(published_at, processed_at))`,
+}
+
+// sqliteProcessedDataColumns is the column list of processed_data queries of
+// a SQLite store, in the order of processedDataColumns
+const sqliteProcessedDataColumns = `id, source, outlet, processed_at, published_at, title, content, relevance_score, sentiment,
+	sentiment_score, sentiment_confidence, analyzer_version, batch_id, project_id, license, toxicity_score, campaign, region_code, hashtag, content_hash, duplicate_of, changed_at, processed_data`
+
+// SQLiteStore is a Store in a SQLite file, for demos and classes that run the
+// system without a PostgreSQL server. It holds the records and raw payloads
+// only: like MemoryStore it has no tags, so filters on tags match nothing,
+// and no pipeline checkpoints keeping raw data from being pruned.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens the SQLite store at path, creating the file and its
+// tables if needed
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database %s: %v", path, err)
+	}
+	// SQLite writes one transaction at a time, and an in-memory database
+	// only lives as long as its connection
+	db.SetMaxOpenConns(1)
+
+	for _, statement := range sqliteSchema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create SQLite schema: %v", err)
+		}
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close closes the SQLite database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// sqliteTime formats a time for a SQLite store
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// sqliteNullTime formats an optional time for a SQLite store
+func sqliteNullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// parseSQLiteTime parses an optional time stored by a SQLite store
+func parseSQLiteTime(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	parsed, err := time.Parse(sqliteTimeLayout, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// scanSQLiteProcessedData scans a row selected with sqliteProcessedDataColumns
+func scanSQLiteProcessedData(row interface{ Scan(...interface{}) error }) (*ProcessedData, error) {
+	var data ProcessedData
+	var processedAt string
+	var publishedAt, changedAt *string
+	err := row.Scan(
+		&data.ID,
+		&data.Source,
+		&data.Outlet,
+		&processedAt,
+		&publishedAt,
+		&data.Title,
+		&data.Content,
+		&data.RelevanceScore,
+		&data.Sentiment,
+		&data.SentimentScore,
+		&data.SentimentConfidence,
+		&data.AnalyzerVersion,
+		&data.BatchID,
+		&data.ProjectID,
+		&data.License,
+		&data.ToxicityScore,
+		&data.Campaign,
+		&data.RegionCode,
+		&data.Hashtag,
+		&data.ContentHash,
+		&data.DuplicateOf,
+		&changedAt,
+		&data.ProcessedData,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	at, err := parseSQLiteTime(&processedAt)
+	if err != nil {
+		return nil, err
+	}
+	data.ProcessedAt = *at
+	if data.PublishedAt, err = parseSQLiteTime(publishedAt); err != nil {
+		return nil, err
+	}
+	if data.ChangedAt, err = parseSQLiteTime(changedAt); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// UpsertProcessedData stores a record like the package function, updating the
+// live record of the project with the same content hash. A record loaded with
+// a zero ProcessedAt is stamped with the current time.
+func (s *SQLiteStore) UpsertProcessedData(ctx context.Context, data *ProcessedData) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin record load: %v", err)
+	}
+	defer tx.Rollback()
+
+	data.ProjectID = projectIDOrDefault(data.ProjectID)
+	var existingID int
+	err = sql.ErrNoRows
+	if data.ContentHash != "" {
+		err = tx.QueryRowContext(ctx, `SELECT id FROM processed_data WHERE project_id = ? AND content_hash = ?`, data.ProjectID, data.ContentHash).Scan(&existingID)
+	}
+
+	inserted := false
+	switch err {
+	case nil:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE processed_data SET
+				source = ?, title = ?, content = ?, relevance_score = ?, sentiment = ?,
+				sentiment_score = ?, sentiment_confidence = ?, analyzer_version = ?, processed_data = ?, batch_id = ?,
+				published_at = COALESCE(?, published_at), license = ?, toxicity_score = ?, outlet = ?,
+				campaign = COALESCE(NULLIF(?, ''), campaign), region_code = ?, hashtag = COALESCE(NULLIF(?, ''), hashtag)
+			WHERE id = ?
+		`, data.Source, data.Title, data.Content, data.RelevanceScore, data.Sentiment,
+			data.SentimentScore, data.SentimentConfidence, data.AnalyzerVersion, data.ProcessedData, data.BatchID,
+			sqliteNullTime(data.PublishedAt), data.License, data.ToxicityScore, data.Outlet,
+			data.Campaign, data.RegionCode, data.Hashtag, existingID)
+		data.ID = existingID
+	case sql.ErrNoRows:
+		if data.ProcessedAt.IsZero() {
+			data.ProcessedAt = time.Now()
+		}
+		var result sql.Result
+		result, err = tx.ExecContext(ctx, `
+			INSERT INTO processed_data (source, title, content, relevance_score, sentiment, sentiment_score, sentiment_confidence, analyzer_version, processed_data, batch_id, project_id, published_at, license, toxicity_score, outlet, campaign, content_hash, region_code, hashtag, processed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, data.Source, data.Title, data.Content, data.RelevanceScore, data.Sentiment, data.SentimentScore, data.SentimentConfidence,
+			data.AnalyzerVersion, data.ProcessedData, data.BatchID, data.ProjectID, sqliteNullTime(data.PublishedAt), data.License,
+			data.ToxicityScore, data.Outlet, data.Campaign, data.ContentHash, data.RegionCode, data.Hashtag, sqliteTime(data.ProcessedAt))
+		if err == nil {
+			var id int64
+			id, err = result.LastInsertId()
+			data.ID = int(id)
+			inserted = true
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert processed data: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit record load: %v", err)
+	}
+	return inserted, nil
+}
+
+// GetProcessedDataByID returns a record of a project, or nil if it does not
+// exist
+func (s *SQLiteStore) GetProcessedDataByID(ctx context.Context, projectID string, id int) (*ProcessedData, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqliteProcessedDataColumns+` FROM processed_data WHERE id = ? AND project_id = ?`, id, projectIDOrDefault(projectID))
+	data, err := scanSQLiteProcessedData(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed data: %v", err)
+	}
+	return data, nil
+}
+
+// QueryProcessedDataPage returns a page of the records matching a filter,
+// newest first, skipping the first offset records
+func (s *SQLiteStore) QueryProcessedDataPage(ctx context.Context, filter ProcessedDataFilter, limit, offset int) ([]ProcessedData, error) {
+	whereClause, args := buildSQLiteProcessedDataWhere(filter)
+	sqlQuery := `SELECT ` + sqliteProcessedDataColumns + ` FROM processed_data` + whereClause + ` ORDER BY id DESC`
+	if limit > 0 {
+		sqlQuery += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	} else if offset > 0 {
+		sqlQuery += " LIMIT -1 OFFSET ?"
+		args = append(args, offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query processed data: %v", err)
+	}
+	defer rows.Close()
+
+	results := []ProcessedData{}
+	for rows.Next() {
+		data, err := scanSQLiteProcessedData(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan processed data: %v", err)
+		}
+		results = append(results, *data)
+	}
+	return results, rows.Err()
+}
+
+// CountProcessedData counts the records matching a filter
+func (s *SQLiteStore) CountProcessedData(ctx context.Context, filter ProcessedDataFilter) (int, error) {
+	whereClause, args := buildSQLiteProcessedDataWhere(filter)
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM processed_data"+whereClause, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count processed data: %v", err)
+	}
+	return count, nil
+}
+
+// buildSQLiteProcessedDataWhere builds the WHERE clause of a filter like
+// buildProcessedDataWhere, with SQLite placeholders
+func buildSQLiteProcessedDataWhere(filter ProcessedDataFilter) (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	add := func(condition string, values ...interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+
+	if filter.Project != "" {
+		add("project_id = ?", filter.Project)
+	}
+	// LIKE ignores the case of ASCII letters in SQLite
+	for _, term := range strings.Fields(filter.Query) {
+		add(`(title LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\')`, likeContains(term), likeContains(term))
+	}
+	if filter.Source != "" {
+		add("source = ?", filter.Source)
+	}
+	if filter.Sentiment != "" {
+		add("sentiment = ?", filter.Sentiment)
+	}
+	if filter.Campaign != "" {
+		add("campaign = ?", filter.Campaign)
+	}
+	if filter.Hashtag != "" {
+		add("hashtag = ?", filter.Hashtag)
+	}
+	if filter.From != nil {
+		add("COALESCE(published_at, processed_at) >= ?", sqliteTime(*filter.From))
+	}
+	if filter.To != nil {
+		add("COALESCE(published_at, processed_at) < ?", sqliteTime(*filter.To))
+	}
+	if filter.AfterID > 0 {
+		add("id > ?", filter.AfterID)
+	}
+	if filter.BeforeID > 0 {
+		add("id < ?", filter.BeforeID)
+	}
+	for _, license := range filter.ExcludeLicenses {
+		add("COALESCE(NULLIF(license, ''), 'unspecified') <> ?", license)
+	}
+	if filter.MinRelevance != nil {
+		add("relevance_score >= ?", *filter.MinRelevance)
+	}
+	if filter.Language != "" {
+		add("json_extract(processed_data, '$.language') = ?", filter.Language)
+	}
+	if filter.MinToxicity != nil {
+		add("toxicity_score >= ?", *filter.MinToxicity)
+	}
+	if filter.MaxToxicity != nil {
+		add("toxicity_score <= ?", *filter.MaxToxicity)
+	}
+	if len(filter.Tags) > 0 {
+		add("1 = 0")
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// InsertRawData stores a raw payload and returns its ID
+func (s *SQLiteStore) InsertRawData(projectID, source, query, batchID string, rawData interface{}) (int, error) {
+	jsonData, err := json.Marshal(rawData)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal raw data: %v", err)
+	}
+
+	result, err := s.db.Exec(`INSERT INTO raw_data (project_id, source, query, raw_data, batch_id, extracted_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		projectIDOrDefault(projectID), source, query, string(jsonData), batchID, sqliteTime(time.Now()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert raw data: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert raw data: %v", err)
+	}
+	return int(id), nil
+}
+
+// GetRawDataByBatch returns the raw payloads stored by one pipeline run,
+// optionally limited to a single source
+func (s *SQLiteStore) GetRawDataByBatch(projectID, batchID, source string) ([]RawData, error) {
+	sqlQuery := `SELECT id, source, extracted_at, raw_data, query FROM raw_data WHERE batch_id = ? AND project_id = ?`
+	args := []interface{}{batchID, projectIDOrDefault(projectID)}
+	if source != "" {
+		sqlQuery += " AND source = ?"
+		args = append(args, source)
+	}
+	sqlQuery += " ORDER BY id"
+
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query raw data: %v", err)
+	}
+	defer rows.Close()
+
+	var results []RawData
+	for rows.Next() {
+		var data RawData
+		var extractedAt string
+		if err := rows.Scan(&data.ID, &data.Source, &extractedAt, &data.RawData, &data.Query); err != nil {
+			return nil, fmt.Errorf("failed to scan raw data: %v", err)
+		}
+		at, err := parseSQLiteTime(&extractedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan raw data: %v", err)
+		}
+		data.ExtractedAt = *at
+		results = append(results, data)
+	}
+	return results, rows.Err()
+}
+
+// RawDataTableSize returns the size of the stored raw payloads
+func (s *SQLiteStore) RawDataTableSize() (int64, error) {
+	var size int64
+	if err := s.db.QueryRow(`SELECT COALESCE(SUM(LENGTH(raw_data)), 0) FROM raw_data`).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to read raw_data size: %v", err)
+	}
+	return size, nil
+}
+
+// CountExpiredRawData counts the raw payloads extracted before cutoff and
+// their size
+func (s *SQLiteStore) CountExpiredRawData(cutoff time.Time) (int, int64, error) {
+	var count int
+	var size int64
+	err := s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(LENGTH(raw_data)), 0) FROM raw_data WHERE extracted_at < ?`, sqliteTime(cutoff)).Scan(&count, &size)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count expired raw data: %v", err)
+	}
+	return count, size, nil
+}
+
+// PruneRawData deletes up to limit raw payloads extracted before cutoff,
+// oldest first, passing them to archive first like the package function
+func (s *SQLiteStore) PruneRawData(cutoff time.Time, limit int, archive func(rows []map[string]interface{}) error) (int, int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin prune transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	const expired = `SELECT id FROM raw_data WHERE extracted_at < ? ORDER BY extracted_at, id LIMIT ?`
+	rows, err := tx.Query(`SELECT id, project_id, source, query, raw_data, batch_id, extracted_at FROM raw_data WHERE id IN (`+expired+`) ORDER BY extracted_at, id`,
+		sqliteTime(cutoff), limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query expired raw data: %v", err)
+	}
+
+	var pruned []map[string]interface{}
+	var size int64
+	for rows.Next() {
+		var id int64
+		var projectID, source, query, rawData, batchID, extractedAt string
+		if err := rows.Scan(&id, &projectID, &source, &query, &rawData, &batchID, &extractedAt); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan raw data row: %v", err)
+		}
+		at, err := parseSQLiteTime(&extractedAt)
+		if err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan raw data row: %v", err)
+		}
+		pruned = append(pruned, map[string]interface{}{
+			"id":           id,
+			"project_id":   projectID,
+			"source":       source,
+			"query":        query,
+			"raw_data":     json.RawMessage(rawData),
+			"extracted_at": *at,
+			"batch_id":     batchID,
+		})
+		size += int64(len(rawData))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to iterate expired raw data: %v", err)
+	}
+	if len(pruned) == 0 {
+		return 0, 0, nil
+	}
+
+	if archive != nil {
+		if err := archive(pruned); err != nil {
+			return 0, 0, err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM raw_data WHERE id IN (`+expired+`)`, sqliteTime(cutoff), limit); err != nil {
+		return 0, 0, fmt.Errorf("failed to delete expired raw data: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit prune transaction: %v", err)
+	}
+
+	return len(pruned), size, nil
+}
+
+// sqliteDuplicatesCondition is duplicatesCondition for a SQLite store
+func sqliteDuplicatesCondition(includeDuplicates bool) string {
+	if includeDuplicates {
+		return ""
+	}
+	return " AND duplicate_of IS NULL"
+}
+
+// GetDataCount returns the number of raw payloads and records of a project
+func (s *SQLiteStore) GetDataCount(ctx context.Context, projectID string, includeDuplicates bool) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	var rawCount int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM raw_data WHERE project_id = ?`, projectIDOrDefault(projectID)).Scan(&rawCount); err != nil {
+		return nil, fmt.Errorf("failed to count raw data: %v", err)
+	}
+	counts["raw_data"] = rawCount
+
+	var processedCount int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM processed_data WHERE project_id = ?`+sqliteDuplicatesCondition(includeDuplicates), projectIDOrDefault(projectID)).Scan(&processedCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count processed data: %v", err)
+	}
+	counts["processed_data"] = processedCount
+
+	return counts, nil
+}
+
+// GetSentimentCounts counts the records of a project per sentiment like the
+// package function
+func (s *SQLiteStore) GetSentimentCounts(ctx context.Context, projectID, source string, from, to *time.Time, excludeSarcastic, includeDuplicates bool) (SentimentCounts, []SentimentCounts, error) {
+	sqlQuery := `
+		SELECT source,
+			SUM(sentiment = 'positive'),
+			SUM(sentiment = 'negative'),
+			SUM(sentiment = 'neutral')
+		FROM processed_data
+		WHERE project_id = ? AND sentiment IN ('positive', 'negative', 'neutral')` + sqliteDuplicatesCondition(includeDuplicates)
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if source != "" {
+		sqlQuery += " AND source = ?"
+		args = append(args, source)
+	}
+	if from != nil {
+		sqlQuery += " AND COALESCE(published_at, processed_at) >= ?"
+		args = append(args, sqliteTime(*from))
+	}
+	if to != nil {
+		sqlQuery += " AND COALESCE(published_at, processed_at) < ?"
+		args = append(args, sqliteTime(*to))
+	}
+	if excludeSarcastic {
+		sqlQuery += " AND COALESCE(json_extract(processed_data, '$.sarcastic'), 0) = 0"
+	}
+	sqlQuery += " GROUP BY source ORDER BY source"
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return SentimentCounts{}, nil, fmt.Errorf("failed to query sentiment counts: %v", err)
+	}
+	defer rows.Close()
+
+	var overall SentimentCounts
+	bySource := []SentimentCounts{}
+	for rows.Next() {
+		var counts SentimentCounts
+		if err := rows.Scan(&counts.Source, &counts.Positive, &counts.Negative, &counts.Neutral); err != nil {
+			return SentimentCounts{}, nil, fmt.Errorf("failed to scan sentiment counts: %v", err)
+		}
+		overall.Positive += counts.Positive
+		overall.Negative += counts.Negative
+		overall.Neutral += counts.Neutral
+		bySource = append(bySource, counts.withShares())
+	}
+	if err := rows.Err(); err != nil {
+		return SentimentCounts{}, nil, fmt.Errorf("failed to read sentiment counts: %v", err)
+	}
+
+	return overall.withShares(), bySource, nil
+}
+
+// GetWordCounts returns the limit most frequent words of a project's records.
+// SQLite cannot split text into words, so they are counted in Go.
+func (s *SQLiteStore) GetWordCounts(ctx context.Context, projectID, source, sentiment string, limit int, includeDuplicates bool) ([]WordCount, error) {
+	sqlQuery := `SELECT title, content, sentiment, sentiment_score FROM processed_data WHERE project_id = ?` + sqliteDuplicatesCondition(includeDuplicates)
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if source != "" {
+		sqlQuery += " AND source = ?"
+		args = append(args, source)
+	}
+	if sentiment != "" {
+		sqlQuery += " AND sentiment = ?"
+		args = append(args, sentiment)
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query word counts: %v", err)
+	}
+	defer rows.Close()
+
+	var records []ProcessedData
+	for rows.Next() {
+		var data ProcessedData
+		if err := rows.Scan(&data.Title, &data.Content, &data.Sentiment, &data.SentimentScore); err != nil {
+			return nil, fmt.Errorf("failed to scan word counts: %v", err)
+		}
+		records = append(records, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word counts: %v", err)
+	}
+
+	return countWords(records, limit), nil
+}
+
+// GetSources returns the default source taxonomy, the SQLite store has no
+// taxonomy table to edit it in
+func (s *SQLiteStore) GetSources(_ context.Context) ([]Source, error) {
+	return defaultSourceList(), nil
+}
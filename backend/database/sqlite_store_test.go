@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQLiteSearchMatchesLiterally(t *testing.T) {
+	store, err := OpenSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open the SQLite store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	for _, record := range []ProcessedData{
+		{Source: "youtube", Title: "Vaksin 100% gratis", ContentHash: "a"},
+		{Source: "youtube", Title: "Vaksin 1000 dosis", ContentHash: "b"},
+		{Source: "youtube", Title: "Data kasus_harian", ContentHash: "c"},
+		{Source: "youtube", Title: "Data kasus harian", ContentHash: "d"},
+	} {
+		record := record
+		if _, err := store.UpsertProcessedData(ctx, &record); err != nil {
+			t.Fatalf("Failed to load a record: %v", err)
+		}
+	}
+
+	for search, expected := range map[string]int{"100%": 1, "kasus_harian": 1, `\`: 0} {
+		count, err := store.CountProcessedData(ctx, ProcessedDataFilter{Query: search})
+		if err != nil {
+			t.Fatalf("Failed to count records matching %q: %v", search, err)
+		}
+		if count != expected {
+			t.Errorf("Expected %d records matching %q, got %d", expected, search, count)
+		}
+	}
+}
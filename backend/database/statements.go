@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// statements caches the prepared statements of the hot insert and select
+// paths per connection pool, so PostgreSQL parses them once per pooled
+// connection rather than on every call. InitDatabase closes and forgets the
+// statements of a pool it replaces on reconnect, CloseDatabase those of the
+// closed pool.
+var statements struct {
+	sync.Mutex
+	pools map[*sql.DB]map[string]*sql.Stmt
+}
+
+// cachedStatement returns the cached statement of a query on db, if any
+func cachedStatement(db *sql.DB, query string) (*sql.Stmt, bool) {
+	statements.Lock()
+	defer statements.Unlock()
+	stmt, ok := statements.pools[db][query]
+	return stmt, ok
+}
+
+// prepared returns the prepared statement of a query on db, preparing it on
+// first use. The statement is prepared without holding the cache lock, so a
+// slow prepare on one pool never blocks the queries of another; when two
+// calls race, the first statement stored wins and the other is closed.
+func prepared(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, error) {
+	if stmt, ok := cachedStatement(db, query); ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %v", err)
+	}
+
+	statements.Lock()
+	defer statements.Unlock()
+
+	if statements.pools == nil {
+		statements.pools = make(map[*sql.DB]map[string]*sql.Stmt)
+	}
+	stmts := statements.pools[db]
+	if stmts == nil {
+		stmts = make(map[string]*sql.Stmt)
+		statements.pools[db] = stmts
+	}
+	if cached, ok := stmts[query]; ok {
+		stmt.Close()
+		return cached, nil
+	}
+	stmts[query] = stmt
+	return stmt, nil
+}
+
+// preparedTx returns the prepared statement of a query bound to a
+// transaction begun on db
+func preparedTx(ctx context.Context, db *sql.DB, tx *sql.Tx, query string) (*sql.Stmt, error) {
+	stmt, err := prepared(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.StmtContext(ctx, stmt), nil
+}
+
+// forgetStatements closes and drops the prepared statements of a pool that
+// was closed or replaced. Closing a statement waits for the calls running it.
+func forgetStatements(db *sql.DB) {
+	statements.Lock()
+	stmts := statements.pools[db]
+	delete(statements.pools, db)
+	statements.Unlock()
+
+	for _, stmt := range stmts {
+		stmt.Close()
+	}
+}
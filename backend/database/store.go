@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// ProcessedDataRepo loads and reads the processed records
+type ProcessedDataRepo interface {
+	UpsertProcessedData(ctx context.Context, data *ProcessedData) (inserted bool, err error)
+	GetProcessedDataByID(ctx context.Context, projectID string, id int) (*ProcessedData, error)
+	QueryProcessedDataPage(ctx context.Context, filter ProcessedDataFilter, limit, offset int) ([]ProcessedData, error)
+	CountProcessedData(ctx context.Context, filter ProcessedDataFilter) (int, error)
+}
+
+// RawDataRepo stores the raw payloads of the extractions and prunes them
+// past their retention age
+type RawDataRepo interface {
+	InsertRawData(projectID, source, query, batchID string, rawData interface{}) (int, error)
+	GetRawDataByBatch(projectID, batchID, source string) ([]RawData, error)
+	RawDataTableSize() (int64, error)
+	CountExpiredRawData(cutoff time.Time) (int, int64, error)
+	PruneRawData(cutoff time.Time, limit int, archive func(rows []map[string]interface{}) error) (int, int64, error)
+}
+
+// AnalyticsRepo aggregates the processed records of a project
+type AnalyticsRepo interface {
+	GetDataCount(ctx context.Context, projectID string, includeDuplicates bool) (map[string]int, error)
+	GetSentimentCounts(ctx context.Context, projectID, source string, from, to *time.Time, excludeSarcastic, includeDuplicates bool) (SentimentCounts, []SentimentCounts, error)
+	GetWordCounts(ctx context.Context, projectID, source, sentiment string, limit int, includeDuplicates bool) ([]WordCount, error)
+}
+
+// SourceRepo reads the source taxonomy
+type SourceRepo interface {
+	GetSources(ctx context.Context) ([]Source, error)
+}
+
+// Store is the storage the API handlers and services read and write
+// through, so they can run against MemoryStore in tests instead of a live
+// PostgreSQL. Only the record, stats, sentiment, word cloud, source and
+// GraphQL endpoints, the loader and the raw data retention go through it; the
+// other handlers and services still call the package functions on DB. A
+// server on SQLiteStore therefore serves those endpoints only, and
+// MemoryStore backs tests, not a server.
+type Store interface {
+	ProcessedDataRepo
+	RawDataRepo
+	AnalyticsRepo
+	SourceRepo
+}
+
+// PostgresStore is the Store of the connected PostgreSQL database; its
+// methods are the package functions of the same name
+type PostgresStore struct{}
+
+// NewPostgresStore returns the Store of the connected database
+func NewPostgresStore() *PostgresStore {
+	return &PostgresStore{}
+}
+
+func (PostgresStore) UpsertProcessedData(ctx context.Context, data *ProcessedData) (bool, error) {
+	return UpsertProcessedDataContext(ctx, data)
+}
+
+func (PostgresStore) GetProcessedDataByID(ctx context.Context, projectID string, id int) (*ProcessedData, error) {
+	return GetProcessedDataByIDContext(ctx, projectID, id)
+}
+
+func (PostgresStore) QueryProcessedDataPage(ctx context.Context, filter ProcessedDataFilter, limit, offset int) ([]ProcessedData, error) {
+	return QueryProcessedDataPageContext(ctx, filter, limit, offset)
+}
+
+func (PostgresStore) CountProcessedData(ctx context.Context, filter ProcessedDataFilter) (int, error) {
+	return CountProcessedDataContext(ctx, filter)
+}
+
+func (PostgresStore) InsertRawData(projectID, source, query, batchID string, rawData interface{}) (int, error) {
+	return InsertRawData(projectID, source, query, batchID, rawData)
+}
+
+func (PostgresStore) GetRawDataByBatch(projectID, batchID, source string) ([]RawData, error) {
+	return GetRawDataByBatch(projectID, batchID, source)
+}
+
+func (PostgresStore) RawDataTableSize() (int64, error) {
+	return RawDataTableSize()
+}
+
+func (PostgresStore) CountExpiredRawData(cutoff time.Time) (int, int64, error) {
+	return CountExpiredRawData(cutoff)
+}
+
+func (PostgresStore) PruneRawData(cutoff time.Time, limit int, archive func(rows []map[string]interface{}) error) (int, int64, error) {
+	return PruneRawData(cutoff, limit, archive)
+}
+
+func (PostgresStore) GetDataCount(ctx context.Context, projectID string, includeDuplicates bool) (map[string]int, error) {
+	return GetDataCountContext(ctx, projectID, includeDuplicates)
+}
+
+func (PostgresStore) GetSentimentCounts(ctx context.Context, projectID, source string, from, to *time.Time, excludeSarcastic, includeDuplicates bool) (SentimentCounts, []SentimentCounts, error) {
+	return GetSentimentCountsContext(ctx, projectID, source, from, to, excludeSarcastic, includeDuplicates)
+}
+
+func (PostgresStore) GetWordCounts(ctx context.Context, projectID, source, sentiment string, limit int, includeDuplicates bool) ([]WordCount, error) {
+	return GetWordCountsContext(ctx, projectID, source, sentiment, limit, includeDuplicates)
+}
+
+func (PostgresStore) GetSources(ctx context.Context) ([]Source, error) {
+	return GetSourcesContext(ctx)
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// maxTagLength is the longest tag name the tags table accepts
+const maxTagLength = 100
+
+// NormalizeTag lowercases a tag and joins its words with hyphens, so
+// "Vaccine Hesitancy" and "vaccine-hesitancy" are the same tag. It returns ""
+// for tags that are empty or too long.
+func NormalizeTag(tag string) string {
+	tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+	if len(tag) > maxTagLength {
+		return ""
+	}
+	return tag
+}
+
+// AddRecordTags tags a record. Tags the record already has keep their
+// original origin and author.
+func AddRecordTags(projectID string, recordID int, tags []string, origin, createdBy string) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO tags (record_id, project_id, name, origin, created_by)
+		SELECT $1, $2, name, $4, NULLIF($5, '')
+		FROM UNNEST($3::text[]) AS name
+		ON CONFLICT (record_id, name) DO NOTHING
+	`
+
+	if _, err := DB.Exec(sqlQuery, recordID, projectIDOrDefault(projectID), pq.Array(tags), origin, createdBy); err != nil {
+		return fmt.Errorf("failed to add record tags: %v", err)
+	}
+	return nil
+}
+
+// RemoveRecordTag removes a tag from a record of a project
+func RemoveRecordTag(projectID string, recordID int, tag string) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	result, err := DB.Exec("DELETE FROM tags WHERE record_id = $1 AND name = $2 AND project_id = $3", recordID, tag, projectIDOrDefault(projectID))
+	if err != nil {
+		return false, fmt.Errorf("failed to remove record tag: %v", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// GetRecordTags returns the tags of the given records of a project, keyed by
+// record ID and sorted by name
+func GetRecordTags(projectID string, recordIDs []int) (map[int][]RecordTag, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT record_id, name, origin, COALESCE(created_by, ''), created_at
+		FROM tags
+		WHERE project_id = $1 AND record_id = ANY($2)
+		ORDER BY record_id, name
+	`
+
+	rows, err := DB.Query(sqlQuery, projectIDOrDefault(projectID), pq.Array(recordIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record tags: %v", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]RecordTag)
+	for rows.Next() {
+		var recordID int
+		var tag RecordTag
+		if err := rows.Scan(&recordID, &tag.Name, &tag.Origin, &tag.CreatedBy, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan record tag: %v", err)
+		}
+		tags[recordID] = append(tags[recordID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate record tags: %v", err)
+	}
+
+	return tags, nil
+}
+
+// GetTagCounts counts the records of a project per tag, most used first.
+// An empty source counts across all sources. Duplicates are counted only
+// with includeDuplicates.
+func GetTagCounts(projectID, source string, includeDuplicates bool) ([]TagCount, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		SELECT t.name, COUNT(*),
+			COUNT(*) FILTER (WHERE t.origin = 'manual'),
+			COUNT(*) FILTER (WHERE t.origin = 'auto')
+		FROM tags t
+		JOIN processed_data p ON p.id = t.record_id
+		WHERE t.project_id = $1 AND p.deleted_at IS NULL` + duplicatesCondition(includeDuplicates) + `
+	`
+	args := []interface{}{projectIDOrDefault(projectID)}
+	if source != "" {
+		args = append(args, source)
+		sqlQuery += fmt.Sprintf(" AND p.source = $%d", len(args))
+	}
+	sqlQuery += " GROUP BY t.name ORDER BY COUNT(*) DESC, t.name"
+
+	rows, err := DB.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag counts: %v", err)
+	}
+	defer rows.Close()
+
+	counts := []TagCount{}
+	for rows.Next() {
+		var count TagCount
+		if err := rows.Scan(&count.Name, &count.Count, &count.ManualCount, &count.AutoCount); err != nil {
+			return nil, fmt.Errorf("failed to scan tag counts: %v", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tag counts: %v", err)
+	}
+
+	return counts, nil
+}
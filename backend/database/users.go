@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const userColumns = `id, username, password_hash, role, project_id, created_at, last_login_at`
+
+// CreateUser stores a new user with an already hashed password
+func CreateUser(user *User) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	sqlQuery := `
+		INSERT INTO users (username, password_hash, role, project_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	user.ProjectID = projectIDOrDefault(user.ProjectID)
+	err := DB.QueryRow(sqlQuery, user.Username, user.PasswordHash, user.Role, user.ProjectID).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert user: %v", err)
+	}
+
+	return nil
+}
+
+// GetUserByUsername retrieves a user by username, returning nil when it does not exist
+func GetUserByUsername(username string) (*User, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	user, err := scanUser(DB.QueryRow(`SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	return user, nil
+}
+
+// GetUser retrieves a user by ID, returning nil when it does not exist
+func GetUser(id int) (*User, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	user, err := scanUser(DB.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	return user, nil
+}
+
+// GetUsers returns all users
+func GetUsers() ([]User, error) {
+	if err := EnsureConnection(); err != nil {
+		return nil, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	rows, err := DB.Query(`SELECT ` + userColumns + ` FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %v", err)
+		}
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %v", err)
+	}
+
+	return users, nil
+}
+
+// DeleteUser removes a user, reporting whether it existed. Tokens issued to
+// the user can no longer be refreshed.
+func DeleteUser(id int) (bool, error) {
+	if err := EnsureConnection(); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	result, err := DB.Exec(`DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete user: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete user: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+// RecordUserLogin stores the time of a user's latest login
+func RecordUserLogin(id int) error {
+	if err := EnsureConnection(); err != nil {
+		return fmt.Errorf("database connection issue: %v", err)
+	}
+
+	if _, err := DB.Exec(`UPDATE users SET last_login_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to record user login: %v", err)
+	}
+
+	return nil
+}
+
+// scanUser scans a row selected with userColumns
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var user User
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.ProjectID, &user.CreatedAt, &user.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Warehouse star schema: fact_content holds one row per processed record,
+// keyed by the record ID, with the measures analytics aggregate. Its
+// dimensions are dim_source (the canonical sources), dim_content_type (the
+// kind of record a source yields), dim_date (the UTC day of the record's
+// event time) and dim_language. The fact row is derived from the stored
+// record, so loading and backfilling share the same statements.
+
+// defaultContentTypes map the source kinds of the taxonomy to the content
+// type of their records; YouTube records are comments on videos. Records
+// naming their content_type, like Instagram comments, use it instead.
+var defaultContentTypes = []struct{ name, sourceKind string }{
+	{"comment", "video"},
+	{"article", "news"},
+	{"post", "social"},
+	{"other", "other"},
+}
+
+// warehouseTableStatements create the dimension and fact tables
+var warehouseTableStatements = []string{
+	`CREATE TABLE IF NOT EXISTS dim_source (
+		source_key SERIAL PRIMARY KEY,
+		name VARCHAR(50) NOT NULL UNIQUE,
+		label VARCHAR(100) NOT NULL,
+		kind VARCHAR(20) NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS dim_content_type (
+		content_type_key SERIAL PRIMARY KEY,
+		name VARCHAR(20) NOT NULL UNIQUE,
+		source_kind VARCHAR(20) NOT NULL UNIQUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS dim_date (
+		date_key INTEGER PRIMARY KEY,
+		date DATE NOT NULL UNIQUE,
+		year SMALLINT NOT NULL,
+		quarter SMALLINT NOT NULL,
+		month SMALLINT NOT NULL,
+		day SMALLINT NOT NULL,
+		iso_week SMALLINT NOT NULL,
+		day_of_week SMALLINT NOT NULL,
+		is_weekend BOOLEAN NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS dim_language (
+		language_key SERIAL PRIMARY KEY,
+		code VARCHAR(10) NOT NULL UNIQUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS fact_content (
+		record_id INTEGER PRIMARY KEY REFERENCES processed_data(id) ON DELETE CASCADE,
+		project_id VARCHAR(50) NOT NULL,
+		source_key INTEGER NOT NULL REFERENCES dim_source(source_key),
+		content_type_key INTEGER NOT NULL REFERENCES dim_content_type(content_type_key),
+		date_key INTEGER NOT NULL REFERENCES dim_date(date_key),
+		language_key INTEGER NOT NULL REFERENCES dim_language(language_key),
+		outlet VARCHAR(100),
+		campaign VARCHAR(100),
+		relevance_score DECIMAL(3,2),
+		sentiment VARCHAR(20),
+		sentiment_score DECIMAL(3,2),
+		toxicity_score REAL,
+		word_count INTEGER,
+		loaded_at TIMESTAMP DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_fact_content_project_date ON fact_content(project_id, date_key)`,
+	`CREATE INDEX IF NOT EXISTS idx_fact_content_source ON fact_content(source_key)`,
+	factContentLiveView,
+}
+
+// factContentLiveView leaves out the facts of soft-deleted records, which
+// stay until the record is purged, like every other query
+const factContentLiveView = `CREATE OR REPLACE VIEW fact_content_live AS
+		SELECT f.*
+		FROM fact_content f
+		JOIN processed_data p ON p.id = f.record_id
+		WHERE p.deleted_at IS NULL`
+
+// seedContentTypesStatement inserts the default content types
+func seedContentTypesStatement() string {
+	statement := `INSERT INTO dim_content_type (name, source_kind) VALUES `
+	for i, contentType := range defaultContentTypes {
+		if i > 0 {
+			statement += ", "
+		}
+		statement += fmt.Sprintf("(%s, %s)", quoteLiteral(contentType.name), quoteLiteral(contentType.sourceKind))
+	}
+	return statement + ` ON CONFLICT (name) DO NOTHING`
+}
+
+// warehouseMigrationStatements create the warehouse and load the facts of
+// the records loaded before it
+func warehouseMigrationStatements() []string {
+	statements := append([]string{}, warehouseTableStatements...)
+	statements = append(statements, seedContentTypesStatement())
+	return append(statements, contentFactStatements("TRUE")...)
+}
+
+// recordLanguage is the dim_language code of a processed record
+const recordLanguage = `COALESCE(NULLIF(p.processed_data->>'language', ''), 'unknown')`
+
+// recordContentType matches the dim_content_type ct of a processed record:
+// the content type the record names, e.g. "comment" for Instagram comments,
+// or else the one of its source's kind
+const recordContentType = `CASE WHEN p.processed_data->>'content_type' IS NULL THEN ct.source_kind = s.kind
+				ELSE ct.name = p.processed_data->>'content_type' END`
+
+// contentFactStatements add the dimension rows of the processed records
+// matching condition, on processed_data p, and upsert their fact rows
+func contentFactStatements(condition string) []string {
+	return []string{
+		`INSERT INTO dim_source (name, label, kind)
+			SELECT s.name, s.label, s.kind
+			FROM sources s
+			WHERE s.name IN (SELECT p.source FROM processed_data p WHERE ` + condition + `)
+			ON CONFLICT (name) DO UPDATE SET label = EXCLUDED.label, kind = EXCLUDED.kind`,
+		`INSERT INTO dim_date (date_key, date, year, quarter, month, day, iso_week, day_of_week, is_weekend)
+			SELECT TO_CHAR(d, 'YYYYMMDD')::int, d, EXTRACT(YEAR FROM d), EXTRACT(QUARTER FROM d), EXTRACT(MONTH FROM d),
+				EXTRACT(DAY FROM d), EXTRACT(WEEK FROM d), EXTRACT(ISODOW FROM d), EXTRACT(ISODOW FROM d) >= 6
+			FROM (SELECT DISTINCT (` + EventTimeColumn + `)::date AS d FROM processed_data p WHERE ` + condition + `) days
+			ON CONFLICT (date_key) DO NOTHING`,
+		`INSERT INTO dim_language (code)
+			SELECT DISTINCT ` + recordLanguage + `
+			FROM processed_data p
+			WHERE ` + condition + `
+			ON CONFLICT (code) DO NOTHING`,
+		`INSERT INTO fact_content (record_id, project_id, source_key, content_type_key, date_key, language_key,
+				outlet, campaign, relevance_score, sentiment, sentiment_score, toxicity_score, word_count, loaded_at)
+			SELECT p.id, p.project_id, s.source_key,
+				COALESCE(ct.content_type_key, (SELECT content_type_key FROM dim_content_type WHERE name = 'other')),
+				TO_CHAR((` + EventTimeColumn + `)::date, 'YYYYMMDD')::int, l.language_key,
+				p.outlet, p.campaign, p.relevance_score, p.sentiment, p.sentiment_score, p.toxicity_score,
+				CASE WHEN p.processed_data->>'word_count' ~ '^[0-9]+$' THEN (p.processed_data->>'word_count')::int END,
+				NOW()
+			FROM processed_data p
+			JOIN dim_source s ON s.name = p.source
+			LEFT JOIN dim_content_type ct ON ` + recordContentType + `
+			JOIN dim_language l ON l.code = ` + recordLanguage + `
+			WHERE ` + condition + `
+			ON CONFLICT (record_id) DO UPDATE SET
+				project_id = EXCLUDED.project_id,
+				source_key = EXCLUDED.source_key,
+				content_type_key = EXCLUDED.content_type_key,
+				date_key = EXCLUDED.date_key,
+				language_key = EXCLUDED.language_key,
+				outlet = EXCLUDED.outlet,
+				campaign = EXCLUDED.campaign,
+				relevance_score = EXCLUDED.relevance_score,
+				sentiment = EXCLUDED.sentiment,
+				sentiment_score = EXCLUDED.sentiment_score,
+				toxicity_score = EXCLUDED.toxicity_score,
+				word_count = EXCLUDED.word_count,
+				loaded_at = EXCLUDED.loaded_at`,
+	}
+}
+
+// recordFactStatements load the fact row of the record with ID $1
+var recordFactStatements = contentFactStatements("p.id = $1")
+
+// UpsertProcessedDataWithFact upserts a processed record like
+// UpsertProcessedData and loads its warehouse fact and dimension rows in the
+// same transaction, so the warehouse never holds a fact without its record
+// or misses a loaded record
+func UpsertProcessedDataWithFact(data *ProcessedData) (inserted bool, err error) {
+	return UpsertProcessedDataWithFactContext(context.Background(), data)
+}
+
+// UpsertProcessedDataWithFactContext is UpsertProcessedDataWithFact with a
+// context that cancels the load and rolls it back
+func UpsertProcessedDataWithFactContext(ctx context.Context, data *ProcessedData) (inserted bool, err error) {
+	if err := EnsureConnectionContext(ctx); err != nil {
+		return false, fmt.Errorf("database connection issue: %v", err)
+	}
+
+	db := DB
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin record load: %v", err)
+	}
+	defer tx.Rollback()
+
+	inserted, err = upsertProcessedData(ctx, db, tx, data)
+	if err != nil {
+		return false, err
+	}
+	if err := loadContentFact(ctx, db, tx, data.ID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit record load: %v", err)
+	}
+	return inserted, nil
+}
+
+// loadContentFact loads the fact and dimension rows of a stored record with
+// prepared statements, as every loaded record runs them
+func loadContentFact(ctx context.Context, db *sql.DB, tx *sql.Tx, recordID int) error {
+	for _, statement := range recordFactStatements {
+		stmt, err := preparedTx(ctx, db, tx, statement)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, recordID); err != nil {
+			return fmt.Errorf("failed to load warehouse fact of record %d: %v", recordID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,837 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/etl"
+	"covid19-kms/internal/services"
+)
+
+// AdminHandler handles administrative operations
+type AdminHandler struct {
+	backupService    *services.BackupService
+	retentionService *services.RetentionService
+}
+
+// NewAdminHandler creates a new admin handler pruning raw data in store
+func NewAdminHandler(store database.Store) *AdminHandler {
+	cfg, _ := config.LoadConfig()
+
+	return &AdminHandler{
+		backupService:    services.NewBackupService(cfg.Backup),
+		retentionService: services.NewRetentionService(cfg.Retention, store),
+	}
+}
+
+// Backups lists backup history (GET) or triggers a new backup (POST)
+func (h *AdminHandler) Backups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := 20
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+				limit = parsed
+			}
+		}
+
+		backups, err := database.GetBackupRecords(limit)
+		if err != nil {
+			http.Error(w, "Failed to retrieve backups: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"count":     len(backups),
+			"backups":   backups,
+		})
+	case http.MethodPost:
+		backup, err := h.backupService.StartBackup("api")
+		if err != nil {
+			http.Error(w, "Failed to start backup: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "accepted",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"backup":    backup,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Retention lists retention run history (GET) or prunes the raw data older
+// than RETENTION_RAW_DATA_MAX_AGE (POST). With ?dry_run=true the POST waits
+// for the run and only reports what would be pruned.
+func (h *AdminHandler) Retention(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := 20
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+				limit = parsed
+			}
+		}
+
+		runs, err := database.GetRetentionRuns(limit)
+		if err != nil {
+			http.Error(w, "Failed to retrieve retention runs: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"count":     len(runs),
+			"runs":      runs,
+		})
+	case http.MethodPost:
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+
+		var run *database.RetentionRun
+		var err error
+		if dryRun {
+			run, err = h.retentionService.RunRetention("api", true)
+		} else {
+			run, err = h.retentionService.StartRetention("api", false)
+		}
+		if errors.Is(err, services.ErrRetentionDisabled) || errors.Is(err, services.ErrRetentionRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to run retention: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		status := "accepted"
+		if dryRun {
+			status = "success"
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    status,
+			"timestamp": time.Now().Format(time.RFC3339),
+			"run":       run,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Records handles soft delete and restore of processed records:
+// GET /api/admin/records/deleted, DELETE /api/admin/records/{id},
+// POST /api/admin/records/{id}/restore
+func (h *AdminHandler) Records(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/records/"), "/"), "/")
+
+	if len(parts) == 1 && parts[0] == "deleted" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit := 50
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+				limit = parsed
+			}
+		}
+
+		records, err := database.GetDeletedProcessedData(requestProject(r), limit)
+		if err != nil {
+			http.Error(w, "Failed to retrieve deleted records: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"count":     len(records),
+			"data":      records,
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid record ID", http.StatusBadRequest)
+		return
+	}
+
+	var changed bool
+	var action string
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		action = "deleted"
+		changed, err = database.SoftDeleteProcessedData(requestProject(r), id)
+	case len(parts) == 2 && parts[1] == "restore" && r.Method == http.MethodPost:
+		action = "restored"
+		changed, err = database.RestoreProcessedData(requestProject(r), id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Failed to update record: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !changed {
+		http.Error(w, "Record not found or already "+action, http.StatusNotFound)
+		return
+	}
+
+	days, err := database.GetRecordDays(requestProject(r), []int{id})
+	if err != nil {
+		log.Printf("⚠️ Failed to find the rollup day of record %d: %v", id, err)
+	}
+	services.RefreshAnalytics(requestProject(r), days)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"id":        id,
+		"action":    action,
+	})
+}
+
+// Batches handles soft delete and restore of whole extraction batches:
+// DELETE /api/admin/batches/{batch_id}, POST /api/admin/batches/{batch_id}/restore
+func (h *AdminHandler) Batches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/batches/"), "/"), "/")
+	batchID := parts[0]
+	if batchID == "" {
+		http.Error(w, "Batch ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// The rollup days are those of the batch's live records, read before a
+	// delete and after a restore
+	projectID := requestProject(r)
+	var counts map[string]int64
+	var days []time.Time
+	var err, daysErr error
+	var action string
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		action = "deleted"
+		days, daysErr = database.GetBatchDays(projectID, batchID)
+		counts, err = database.SoftDeleteBatch(projectID, batchID)
+	case len(parts) == 2 && parts[1] == "restore" && r.Method == http.MethodPost:
+		action = "restored"
+		counts, err = database.RestoreBatch(projectID, batchID)
+		if err == nil {
+			days, daysErr = database.GetBatchDays(projectID, batchID)
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, "Failed to update batch: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if daysErr != nil {
+		log.Printf("⚠️ Failed to find the rollup days of batch %s: %v", batchID, daysErr)
+	}
+	services.RefreshAnalytics(projectID, days)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"batch_id":  batchID,
+		"action":    action,
+		"rows":      counts,
+	})
+}
+
+// AuditLog returns audit entries, newest first, filtered by the optional
+// project, action (prefix match) and actor query parameters
+func (h *AdminHandler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	entries, err := database.GetAuditEntries(requestedProject(r), r.URL.Query().Get("action"), r.URL.Query().Get("actor"), limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve audit log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"count":     len(entries),
+		"entries":   entries,
+	})
+}
+
+// Rollups rebuilds the daily rollups of the request's project for the last
+// days days (POST ?days=30, default 30), e.g. to backfill after an upgrade
+func (h *AdminHandler) Rollups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed <= 0 || parsed > 3650 {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	projectID := requestProject(r)
+	now := time.Now()
+	var rows int64
+	for i := 0; i < days; i++ {
+		count, err := database.RefreshDailyRollups(projectID, now.AddDate(0, 0, -i))
+		if err != nil {
+			http.Error(w, "Failed to rebuild rollups: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rows += count
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"project":   projectID,
+		"days":      days,
+		"rows":      rows,
+	})
+}
+
+// Duplicates regroups the near-duplicate articles of the request's project
+// published in the last days days (POST ?days=30, default 30), e.g. to group
+// records loaded before duplicate detection or after changing DEDUP_SIMILARITY.
+// The rollups of those days are rebuilt so trends count the new groups.
+func (h *AdminHandler) Duplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed <= 0 || parsed > 3650 {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	projectID := requestProject(r)
+	now := time.Now()
+	result, err := services.NewDedupService().DeduplicateSince(projectID, now.AddDate(0, 0, -days))
+	if err != nil {
+		http.Error(w, "Failed to group duplicates: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i := 0; i <= days; i++ {
+		if _, err := database.RefreshDailyRollups(projectID, now.AddDate(0, 0, -i)); err != nil {
+			http.Error(w, "Failed to rebuild rollups: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"project":   projectID,
+		"days":      days,
+		"result":    result,
+	})
+}
+
+// Statistics refreshes the official COVID-19 statistics from the configured
+// provider (POST)
+func (h *AdminHandler) Statistics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	days, err := etl.RefreshCovidStatistics(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to refresh statistics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"days":      days,
+	})
+}
+
+// SearchInterest refreshes the Google Trends search interest of the
+// configured terms (POST)
+func (h *AdminHandler) SearchInterest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	values, err := etl.RefreshSearchInterest(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to refresh search interest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"values":    values,
+	})
+}
+
+// RecordUpdates fetches the articles due for an update check now instead of
+// waiting for the scheduler (POST)
+func (h *AdminHandler) RecordUpdates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := etl.CheckRecordUpdates(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to check articles for updates: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"result":    result,
+	})
+}
+
+// Cache reports the hit and miss counts of the dashboard and analytics
+// caches (GET), or drops the caller's project's cached analytics responses
+// (DELETE)
+func (h *AdminHandler) Cache(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"dashboard": services.SharedDashboardCache().Stats(),
+			"analytics": services.SharedAnalyticsCache().Stats(),
+		})
+	case http.MethodDelete:
+		projectID := requestProject(r)
+		services.InvalidateAnalytics(projectID)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"project":   projectID,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// SelfTest checks the configuration, the database schema and the source
+// APIs (GET); ?skip_apis=true leaves out the API probes, which spend one
+// request of each source's quota. A failing self-test answers 503.
+func (h *AdminHandler) SelfTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	report := etl.RunSelfTest(r.Context(), etl.SelfTestOptions{
+		SkipExternalAPIs: r.URL.Query().Get("skip_apis") == "true",
+		Timeout:          15 * time.Second,
+	})
+
+	if report.Status == etl.SelfTestFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"report":    report,
+	})
+}
+
+// projectIDPattern restricts project IDs to short URL-safe slugs
+var projectIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// Projects lists projects (GET) or creates one (POST {id, name})
+func (h *AdminHandler) Projects(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		projects, err := database.GetProjects()
+		if err != nil {
+			http.Error(w, "Failed to retrieve projects: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"count":     len(projects),
+			"projects":  projects,
+		})
+	case http.MethodPost:
+		var project database.Project
+		if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		project.ID = strings.TrimSpace(project.ID)
+		if !projectIDPattern.MatchString(project.ID) {
+			http.Error(w, "id must be 1-50 lowercase letters, digits, '-' or '_'", http.StatusBadRequest)
+			return
+		}
+		if project.Name == "" {
+			project.Name = project.ID
+		}
+
+		exists, err := database.ProjectExists(project.ID)
+		if err != nil {
+			http.Error(w, "Failed to check project: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if exists {
+			http.Error(w, "Project already exists", http.StatusConflict)
+			return
+		}
+
+		if err := database.CreateProject(&project); err != nil {
+			http.Error(w, "Failed to create project: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"project":   project,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ProjectRoutes dispatches /api/admin/projects/{id}/keys and /api/admin/projects/{id}/settings
+func (h *AdminHandler) ProjectRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/projects/"), "/"), "/")
+	if len(parts) != 2 || (parts[1] != "keys" && parts[1] != "settings") {
+		http.NotFound(w, r)
+		return
+	}
+	projectID := parts[0]
+
+	exists, err := database.ProjectExists(projectID)
+	if err != nil {
+		http.Error(w, "Failed to check project: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	if parts[1] == "settings" {
+		h.projectSettings(w, r, projectID)
+		return
+	}
+	h.projectKeys(w, r, projectID)
+}
+
+// projectKeys lists (GET) or creates (POST {label}) the API keys of a project.
+// The plaintext key is only returned on creation.
+func (h *AdminHandler) projectKeys(w http.ResponseWriter, r *http.Request, projectID string) {
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := database.GetProjectAPIKeys(projectID)
+		if err != nil {
+			http.Error(w, "Failed to retrieve project keys: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":     "success",
+			"timestamp":  time.Now().Format(time.RFC3339),
+			"project_id": projectID,
+			"count":      len(keys),
+			"keys":       keys,
+		})
+	case http.MethodPost:
+		var req struct {
+			Label string `json:"label"`
+		}
+		// The body is optional
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		key, apiKey, err := database.CreateProjectAPIKey(projectID, req.Label)
+		if err != nil {
+			http.Error(w, "Failed to create project key: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"api_key":   key,
+			"key":       apiKey,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// projectSettings returns (GET) or replaces (PUT) a project's pipeline overrides.
+// Responses include the effective settings after merging over the global configuration.
+func (h *AdminHandler) projectSettings(w http.ResponseWriter, r *http.Request, projectID string) {
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := database.GetProjectSettings(projectID)
+		if err != nil {
+			http.Error(w, "Failed to retrieve project settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if settings == nil {
+			settings = &database.ProjectSettings{ProjectID: projectID}
+		}
+
+		effective, err := etl.LoadRunSettings(projectID)
+		if err != nil {
+			http.Error(w, "Invalid project settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"settings":  settings,
+			"effective": effective,
+		})
+	case http.MethodPut:
+		settings := &database.ProjectSettings{ProjectID: projectID}
+		if err := json.NewDecoder(r.Body).Decode(&settings.Overrides); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Validate before storing so a bad override can never break the project's runs
+		cfg, _ := config.LoadConfig()
+		effective, err := etl.ResolveRunSettings(cfg.ETL, &settings.Overrides)
+		if err != nil {
+			http.Error(w, "Invalid project settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := database.SaveProjectSettings(settings); err != nil {
+			http.Error(w, "Failed to save project settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"settings":  settings,
+			"effective": effective,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// minPasswordLength is the shortest password accepted for a dashboard user
+const minPasswordLength = 8
+
+// Users lists dashboard users (GET) or creates one
+// (POST {username, password, role, project})
+func (h *AdminHandler) Users(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		users, err := database.GetUsers()
+		if err != nil {
+			http.Error(w, "Failed to retrieve users: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"count":     len(users),
+			"users":     users,
+		})
+	case http.MethodPost:
+		var req struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Role     string `json:"role"`
+			Project  string `json:"project"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Username = strings.TrimSpace(req.Username)
+		if req.Username == "" {
+			http.Error(w, "username is required", http.StatusBadRequest)
+			return
+		}
+		if len(req.Password) < minPasswordLength {
+			http.Error(w, "password must be at least "+strconv.Itoa(minPasswordLength)+" characters", http.StatusBadRequest)
+			return
+		}
+		if req.Role == "" {
+			req.Role = services.RoleViewer
+		}
+		if req.Role != services.RoleViewer && req.Role != services.RoleAdmin {
+			http.Error(w, "role must be viewer or admin", http.StatusBadRequest)
+			return
+		}
+		if req.Project == "" {
+			req.Project = database.DefaultProject
+		}
+
+		exists, err := database.ProjectExists(req.Project)
+		if err != nil {
+			http.Error(w, "Failed to check project: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+
+		existing, err := database.GetUserByUsername(req.Username)
+		if err != nil {
+			http.Error(w, "Failed to check user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if existing != nil {
+			http.Error(w, "User already exists", http.StatusConflict)
+			return
+		}
+
+		hash, err := services.HashPassword(req.Password)
+		if err != nil {
+			http.Error(w, "Failed to create user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user := database.User{
+			Username:     req.Username,
+			PasswordHash: hash,
+			Role:         req.Role,
+			ProjectID:    req.Project,
+		}
+		if err := database.CreateUser(&user); err != nil {
+			http.Error(w, "Failed to create user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"user":      user,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// UserRoutes deletes a dashboard user (DELETE /api/admin/users/{id}). Tokens
+// already issued stay valid until they expire but can no longer be refreshed.
+func (h *AdminHandler) UserRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/users/"), "/"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := database.DeleteUser(id)
+	if err != nil {
+		http.Error(w, "Failed to delete user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"user_id":   id,
+	})
+}
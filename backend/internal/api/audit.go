@@ -0,0 +1,75 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+
+	"covid19-kms/database"
+)
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before writing it
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// auditMiddleware records every non-read request to a handler in the audit log.
+// GET, HEAD and OPTIONS requests are passed through without an entry.
+func (r *Router) auditMiddleware(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+
+		entry := &database.AuditEntry{
+			Actor:      auditActor(req),
+			ProjectID:  requestProject(req),
+			Action:     action,
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Query:      req.URL.RawQuery,
+			StatusCode: recorder.status,
+			RemoteAddr: req.RemoteAddr,
+		}
+
+		// Audit failures are logged but never fail the request
+		go func() {
+			if err := database.InsertAuditEntry(entry); err != nil {
+				log.Printf("⚠️ Failed to write audit entry for %s: %v", action, err)
+			}
+		}()
+	}
+}
+
+// auditActor identifies who made a request. API keys are stored as a short
+// fingerprint so the audit log never contains credentials. A user ID the
+// caller only claims, in X-User-ID or user_id, is recorded as claimed:<id>
+// so it is never mistaken for a user:<username> verified by a token.
+func auditActor(r *http.Request) string {
+	if user := requestUser(r); user != nil {
+		return "user:" + user.Username
+	}
+
+	if key := requestAPIKey(r); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "api_key:" + hex.EncodeToString(sum[:])[:12]
+	}
+
+	if userID := requestUserID(r); userID != "" {
+		return "claimed:" + userID
+	}
+
+	return "anonymous"
+}
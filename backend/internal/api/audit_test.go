@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuditActorMarksClaimedUserIDs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/searches?user_id=alice", nil)
+	if actor := auditActor(req); actor != "claimed:alice" {
+		t.Errorf("Expected a claimed user ID, got %s", actor)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/searches", nil)
+	req.Header.Set("X-User-ID", "alice")
+	if actor := auditActor(req); actor != "claimed:alice" {
+		t.Errorf("Expected a claimed user ID, got %s", actor)
+	}
+}
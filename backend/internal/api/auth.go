@@ -0,0 +1,101 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"covid19-kms/internal/services"
+)
+
+// userContextKey is the request context key holding the claims of the
+// access token a request was made with
+type userContextKey struct{}
+
+// openPaths stay reachable without credentials when user authentication is
+// enabled, besides the /api/auth/ endpoints
+var openPaths = map[string]bool{
+	"/":                 true,
+	"/api":              true,
+	"/health":           true,
+	"/api/health":       true,
+	"/api/openapi.json": true,
+	"/api/docs":         true,
+}
+
+// requestUser returns the user a request was authenticated as by an access
+// token, or nil for requests made with an API key or without credentials
+func requestUser(r *http.Request) *services.TokenClaims {
+	user, _ := r.Context().Value(userContextKey{}).(*services.TokenClaims)
+	return user
+}
+
+// userWritePaths are the endpoints where viewers may also write: their
+// collections, saved searches and export jobs, and GraphQL, which takes its
+// queries by POST. userWritePrefixes add the collection routes, the record
+// notes and tags under /api/etl/data/ and the export job routes.
+var userWritePaths = map[string]bool{
+	"/api/graphql":     true,
+	"/api/searches":    true,
+	"/api/collections": true,
+	"/api/exports":     true,
+}
+
+var userWritePrefixes = []string{
+	"/api/collections/",
+	"/api/etl/data/",
+	"/api/exports/",
+}
+
+// viewerAllowed reports whether a viewer may make a request: reads anywhere,
+// and writes to userWritePaths and userWritePrefixes
+func viewerAllowed(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || userWritePaths[req.URL.Path] {
+		return true
+	}
+	for _, prefix := range userWritePrefixes {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestRole returns the role a request acts with: the role of its user,
+// admin for the admin API key and viewer for project API keys
+func (r *Router) requestRole(req *http.Request) string {
+	if user := requestUser(req); user != nil {
+		return user.Role
+	}
+	if r.adminAPIKey != "" && subtle.ConstantTimeCompare([]byte(requestAPIKey(req)), []byte(r.adminAPIKey)) == 1 {
+		return services.RoleAdmin
+	}
+	return services.RoleViewer
+}
+
+// authMiddleware enforces user authentication when a JWT secret is
+// configured. Requests need an API key, checked by projectMiddleware, or an
+// access token. Admins may make any request; viewers, project API keys
+// included, may read and write only their own collections, saved searches,
+// notes, tags and exports (see viewerAllowed). The open paths, the login
+// endpoints and public mode requests are passed through.
+func (r *Router) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.auth.Enabled() || req.Method == http.MethodOptions || isPublicRequest(req) ||
+			openPaths[req.URL.Path] || strings.HasPrefix(req.URL.Path, "/api/auth/") {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if requestUser(req) == nil && requestAPIKey(req) == "" {
+			http.Error(w, "Authentication required: log in at /api/auth/login or send an API key", http.StatusUnauthorized)
+			return
+		}
+
+		if r.requestRole(req) != services.RoleAdmin && !viewerAllowed(req) {
+			http.Error(w, "Forbidden: this action requires the admin role", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
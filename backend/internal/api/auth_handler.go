@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"covid19-kms/internal/services"
+)
+
+// AuthHandler logs dashboard users in and renews their tokens
+type AuthHandler struct {
+	auth *services.AuthService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(auth *services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		auth: auth,
+	}
+}
+
+// Login exchanges a username and password for an access and refresh token
+// (POST {username, password})
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.auth.Enabled() {
+		http.Error(w, "User authentication is disabled (AUTH_JWT_SECRET not set)", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, tokens, err := h.auth.Login(body.Username, body.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to log in: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"user":      user,
+		"tokens":    tokens,
+	})
+}
+
+// Refresh exchanges a refresh token for a new token pair (POST {refresh_token})
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.auth.Enabled() {
+		http.Error(w, "User authentication is disabled (AUTH_JWT_SECRET not set)", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, tokens, err := h.auth.Refresh(body.RefreshToken)
+	if errors.Is(err, services.ErrInvalidToken) {
+		http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to refresh token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"user":      user,
+		"tokens":    tokens,
+	})
+}
+
+// Me returns the user and token expiry of the access token a request was
+// made with (GET)
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := requestUser(r)
+	if user == nil {
+		http.Error(w, "Authentication required: send an access token", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "success",
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"username":   user.Username,
+		"role":       user.Role,
+		"project_id": requestProject(r),
+		"expires_at": time.Unix(user.ExpiresAt, 0).Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
+)
+
+const testAdminAPIKey = "admin-key-0123456789"
+
+func testAuthRouter() *Router {
+	return &Router{
+		auth: services.NewAuthService(config.APIConfig{
+			JWTSecret:       "test-secret-0123456789-0123456789",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: time.Hour,
+		}),
+		adminAPIKey: testAdminAPIKey,
+	}
+}
+
+func testAccessToken(t *testing.T, r *Router, role string) string {
+	tokens, err := r.auth.IssueTokens(&database.User{ID: 7, Username: "analyst", Role: role, ProjectID: database.DefaultProject})
+	if err != nil {
+		t.Fatalf("Failed to issue tokens: %v", err)
+	}
+	return tokens.AccessToken
+}
+
+func authStatus(r *Router, method, target, credential string) int {
+	handler := r.projectMiddleware(r.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	req := httptest.NewRequest(method, target, nil)
+	if credential != "" {
+		req.Header.Set("Authorization", "Bearer "+credential)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthViewerWritesOwnResources(t *testing.T) {
+	r := testAuthRouter()
+	viewer := testAccessToken(t, r, services.RoleViewer)
+
+	for _, tc := range []struct {
+		method, target string
+		expected       int
+	}{
+		{http.MethodGet, "/api/etl/data", http.StatusOK},
+		{http.MethodPost, "/api/collections", http.StatusOK},
+		{http.MethodPost, "/api/collections/3/records", http.StatusOK},
+		{http.MethodDelete, "/api/searches", http.StatusOK},
+		{http.MethodPost, "/api/etl/data/12/notes", http.StatusOK},
+		{http.MethodDelete, "/api/etl/data/12/tags/hoax", http.StatusOK},
+		{http.MethodPost, "/api/exports", http.StatusOK},
+		{http.MethodPost, "/api/graphql", http.StatusOK},
+		{http.MethodPost, "/api/etl/run", http.StatusForbidden},
+		{http.MethodPost, "/api/etl/cleanup/sentiment", http.StatusForbidden},
+		{http.MethodPost, "/api/admin/users", http.StatusForbidden},
+	} {
+		if status := authStatus(r, tc.method, tc.target, viewer); status != tc.expected {
+			t.Errorf("Expected %d for a viewer's %s %s, got %d", tc.expected, tc.method, tc.target, status)
+		}
+	}
+}
+
+func TestAuthAdminWritesAnywhere(t *testing.T) {
+	r := testAuthRouter()
+	admin := testAccessToken(t, r, services.RoleAdmin)
+
+	for _, credential := range []string{admin, testAdminAPIKey} {
+		if status := authStatus(r, http.MethodPost, "/api/etl/run", credential); status != http.StatusOK {
+			t.Errorf("Expected an admin to run the pipeline, got %d", status)
+		}
+	}
+}
+
+func TestAuthProjectAPIKeyIsViewer(t *testing.T) {
+	r := testAuthRouter()
+	handler := r.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		method, target string
+		expected       int
+	}{
+		{http.MethodGet, "/api/etl/data", http.StatusOK},
+		{http.MethodPost, "/api/etl/data/12/tags", http.StatusOK},
+		{http.MethodPost, "/api/etl/run", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		req.Header.Set("X-API-Key", "project-key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.expected {
+			t.Errorf("Expected %d for %s %s with a project API key, got %d", tc.expected, tc.method, tc.target, rec.Code)
+		}
+	}
+}
+
+func TestAuthRequiresCredentials(t *testing.T) {
+	r := testAuthRouter()
+
+	if status := authStatus(r, http.MethodGet, "/api/etl/data", ""); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", status)
+	}
+	if status := authStatus(r, http.MethodGet, "/api/health", ""); status != http.StatusOK {
+		t.Errorf("Expected the health check to stay open, got %d", status)
+	}
+}
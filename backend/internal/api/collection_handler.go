@@ -0,0 +1,298 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/services"
+)
+
+// CollectionHandler handles the named record collections analysts curate as
+// evidence sets. Collections belong to the calling user (X-User-ID).
+type CollectionHandler struct{}
+
+// NewCollectionHandler creates a new collection handler
+func NewCollectionHandler() *CollectionHandler {
+	return &CollectionHandler{}
+}
+
+// Collections handles listing (GET) and creating (POST) the user's collections
+func (h *CollectionHandler) Collections(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := requestUserID(r)
+	if userID == "" {
+		http.Error(w, "User identification is required (X-User-ID header or user_id parameter)", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listCollections(w, r, userID)
+	case http.MethodPost:
+		h.createCollection(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CollectionRoutes dispatches /api/collections/{id}, /api/collections/{id}/items,
+// /api/collections/{id}/items/{record_id} and /api/collections/{id}/export
+func (h *CollectionHandler) CollectionRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := requestUserID(r)
+	if userID == "" {
+		http.Error(w, "User identification is required (X-User-ID header or user_id parameter)", http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/collections/"), "/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	collection, err := database.GetCollection(requestProject(r), userID, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve collection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if collection == nil {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		h.collection(w, r, collection)
+	case len(parts) == 2 && parts[1] == "items":
+		h.addItem(w, r, collection)
+	case len(parts) == 3 && parts[1] == "items":
+		recordID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid record ID", http.StatusBadRequest)
+			return
+		}
+		h.removeItem(w, r, collection, recordID)
+	case len(parts) == 2 && parts[1] == "export":
+		h.exportCollection(w, r, collection)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// listCollections returns the collections of a user
+func (h *CollectionHandler) listCollections(w http.ResponseWriter, r *http.Request, userID string) {
+	collections, err := database.GetCollections(requestProject(r), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve collections: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"data":        collections,
+		"total_count": len(collections),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// createCollection stores a collection from the JSON body {name, description}
+func (h *CollectionHandler) createCollection(w http.ResponseWriter, r *http.Request, userID string) {
+	var collection database.Collection
+	if err := json.NewDecoder(r.Body).Decode(&collection); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collection.UserID = userID
+	collection.ProjectID = requestProject(r)
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	created, err := database.CreateCollection(&collection)
+	if err != nil {
+		http.Error(w, "Failed to create collection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !created {
+		http.Error(w, fmt.Sprintf("A collection named %q already exists", collection.Name), http.StatusConflict)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"data":      collection,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// collection returns a collection with its records (GET) or deletes it (DELETE)
+func (h *CollectionHandler) collection(w http.ResponseWriter, r *http.Request, collection *database.Collection) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := database.GetCollectionItems(collection.ID)
+		if err != nil {
+			http.Error(w, "Failed to retrieve collection items: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"status":     "success",
+			"timestamp":  time.Now().Format(time.RFC3339),
+			"collection": collection,
+			"items":      items,
+		}
+
+		json.NewEncoder(w).Encode(response)
+	case http.MethodDelete:
+		deleted, err := database.DeleteCollection(collection.ProjectID, collection.UserID, collection.ID)
+		if err != nil {
+			http.Error(w, "Failed to delete collection: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+			return
+		}
+
+		response := map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"deleted":   collection.ID,
+		}
+
+		json.NewEncoder(w).Encode(response)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// addItem adds a record to a collection from the JSON body {record_id, annotation}.
+// Adding a record again updates its annotation.
+func (h *CollectionHandler) addItem(w http.ResponseWriter, r *http.Request, collection *database.Collection) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RecordID   int    `json:"record_id"`
+		Annotation string `json:"annotation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	record, err := database.GetProcessedDataByID(collection.ProjectID, req.RecordID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve record: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if record == nil {
+		http.Error(w, "Record not found", http.StatusNotFound)
+		return
+	}
+
+	annotation := strings.TrimSpace(req.Annotation)
+	if err := database.AddCollectionItem(collection.ID, record.ID, annotation); err != nil {
+		http.Error(w, "Failed to add record to collection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":        "success",
+		"timestamp":     time.Now().Format(time.RFC3339),
+		"collection_id": collection.ID,
+		"record_id":     record.ID,
+		"annotation":    annotation,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// removeItem removes a record from a collection
+func (h *CollectionHandler) removeItem(w http.ResponseWriter, r *http.Request, collection *database.Collection, recordID int) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	removed, err := database.RemoveCollectionItem(collection.ID, recordID)
+	if err != nil {
+		http.Error(w, "Failed to remove record from collection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		http.Error(w, "Record is not in the collection", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":        "success",
+		"timestamp":     time.Now().Format(time.RFC3339),
+		"collection_id": collection.ID,
+		"removed":       recordID,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// exportCollection downloads a collection with its annotations as CSV or PDF
+// (?format=csv|pdf, default csv)
+func (h *CollectionHandler) exportCollection(w http.ResponseWriter, r *http.Request, collection *database.Collection) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = services.ExportFormatCSV
+	}
+	if format != services.ExportFormatCSV && format != services.ExportFormatPDF {
+		http.Error(w, fmt.Sprintf("unsupported export format %q (supported: csv, pdf)", format), http.StatusBadRequest)
+		return
+	}
+
+	items, err := database.GetCollectionItems(collection.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve collection items: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Render fully before writing so a failure can still be reported as an error
+	var buf bytes.Buffer
+	contentType := "text/csv"
+	if format == services.ExportFormatPDF {
+		contentType = "application/pdf"
+		err = services.WriteCollectionPDF(&buf, collection, items)
+	} else {
+		err = services.WriteCollectionCSV(&buf, collection, items)
+	}
+	if err != nil {
+		http.Error(w, "Failed to export collection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("collection_%d.%s", collection.ID, format)))
+	w.Write(buf.Bytes())
+}
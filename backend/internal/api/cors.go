@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"covid19-kms/internal/config"
+)
+
+// corsExposedHeaders are the response headers browsers may read
+const corsExposedHeaders = "Content-Length, Content-Range, Content-Disposition, X-Cache, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After"
+
+// CORS applies the configured cross-origin policy
+type CORS struct {
+	enabled   bool
+	anyOrigin bool
+	origins   map[string]bool
+	methods   string
+	headers   string
+	maxAge    string
+}
+
+// NewCORS creates the cross-origin policy of an API configuration
+func NewCORS(cfg config.APIConfig) *CORS {
+	cors := &CORS{
+		enabled: cfg.EnableCORS,
+		origins: make(map[string]bool),
+		methods: strings.Join(cfg.CORSAllowedMethods, ", "),
+		headers: strings.Join(cfg.CORSAllowedHeaders, ", "),
+		maxAge:  strconv.Itoa(int(cfg.CORSMaxAge.Seconds())),
+	}
+	for _, origin := range cfg.CORSAllowedOrigins {
+		if origin == "*" {
+			cors.anyOrigin = true
+			continue
+		}
+		cors.origins[strings.TrimRight(origin, "/")] = true
+	}
+	return cors
+}
+
+// allowed reports whether a browser origin may call the API
+func (c *CORS) allowed(origin string) bool {
+	return c.anyOrigin || c.origins[origin]
+}
+
+// Apply sets the CORS headers for the request's origin and reports whether
+// it answered the request: OPTIONS requests are answered with 204, or 403
+// for a preflight from an origin that is not allowed. Credentials are only
+// allowed for listed origins, which are echoed back; "*" allows any origin
+// without credentials.
+func (c *CORS) Apply(w http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	allowed := c.enabled && origin != "" && c.allowed(origin)
+
+	if allowed {
+		if c.origins[origin] {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+	}
+	if c.enabled && !c.anyOrigin {
+		w.Header().Add("Vary", "Origin")
+	}
+
+	if req.Method != http.MethodOptions {
+		return false
+	}
+
+	if origin != "" && req.Header.Get("Access-Control-Request-Method") != "" {
+		if !allowed {
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return true
+		}
+		w.Header().Set("Access-Control-Allow-Methods", c.methods)
+		w.Header().Set("Access-Control-Allow-Headers", c.headers)
+		w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"covid19-kms/internal/config"
+)
+
+func testCORSRouter(origins ...string) http.HandlerFunc {
+	r := &Router{cors: NewCORS(config.APIConfig{
+		EnableCORS:         true,
+		CORSAllowedOrigins: origins,
+		CORSAllowedMethods: []string{"GET", "POST"},
+		CORSAllowedHeaders: []string{"Content-Type", "Authorization"},
+		CORSMaxAge:         10 * time.Minute,
+	})}
+	return r.corsMiddleware(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+}
+
+func preflight(handler http.HandlerFunc, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/api/etl/run", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestCORSPreflightAllowedOrigin(t *testing.T) {
+	rec := preflight(testCORSRouter("https://dashboard.example.com"), "https://dashboard.example.com")
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://dashboard.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Content-Type, Authorization",
+		"Access-Control-Max-Age":           "600",
+		"Vary":                             "Origin",
+	}
+	for header, want := range expected {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("Expected %s %q, got %q", header, want, got)
+		}
+	}
+}
+
+func TestCORSPreflightDisallowedOrigin(t *testing.T) {
+	rec := preflight(testCORSRouter("https://dashboard.example.com"), "https://evil.example.com")
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no allowed origin, got %q", got)
+	}
+}
+
+func TestCORSWildcardOrigin(t *testing.T) {
+	rec := preflight(testCORSRouter("*"), "https://anywhere.example.com")
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected the wildcard origin, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials with the wildcard origin, got %q", got)
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	handler := testCORSRouter("https://dashboard.example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/etl/status", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("Expected the request to reach the handler, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example.com" {
+		t.Errorf("Expected the origin to be echoed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "" {
+		t.Errorf("Expected preflight headers only on preflight requests, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/etl/status", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusTeapot || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected other origins to get no CORS headers, got %d %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
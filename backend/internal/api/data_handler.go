@@ -1,23 +1,33 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"covid19-kms/database"
+	"covid19-kms/internal/config"
 	"covid19-kms/internal/etl"
+	"covid19-kms/internal/services"
 )
 
 // DataHandler handles data retrieval from PostgreSQL database
 type DataHandler struct {
-	// No need for dataDir since we're using database
+	store            database.Store
+	maxResponseBytes int
 }
 
-// NewDataHandler creates a new data handler
-func NewDataHandler() *DataHandler {
-	return &DataHandler{}
+// NewDataHandler creates a new data handler reading records through store
+func NewDataHandler(store database.Store) *DataHandler {
+	cfg, _ := config.LoadConfig()
+	return &DataHandler{
+		store:            store,
+		maxResponseBytes: cfg.API.MaxResponseBytes,
+	}
 }
 
 // GetLatestData retrieves the latest data from PostgreSQL database
@@ -30,36 +40,42 @@ func (h *DataHandler) GetLatestData(w http.ResponseWriter, r *http.Request) {
 	// Set content type (CORS is handled by middleware)
 	w.Header().Set("Content-Type", "application/json")
 
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := recordListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Get data from database
-	data, err := h.retrieveLatestData()
+	records, err := queryRecordPage(r.Context(), h.store, filter, page)
 	if err != nil {
 		http.Error(w, "Failed to retrieve data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Return response
-	response := map[string]interface{}{
-		"status":      "success",
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"data":        data,
-		"total_count": len(data),
-	}
+	response := records.envelope(r, map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"data":      recordItems(records.Records),
+	})
 
-	json.NewEncoder(w).Encode(response)
+	writeListResponse(w, r, response, h.maxResponseBytes)
 }
 
-// retrieveLatestData fetches latest data from PostgreSQL database
-func (h *DataHandler) retrieveLatestData() ([]map[string]interface{}, error) {
-	// Get latest processed data from database
-	processedData, err := database.GetLatestProcessedData(100)
-	if err != nil {
-		return nil, err
-	}
-
-	var results []map[string]interface{}
+// recordItems converts records to the response format of the record lists
+func recordItems(processedData []database.ProcessedData) []map[string]interface{} {
+	results := []map[string]interface{}{}
 	for _, data := range processedData {
 		// Convert database model to response format
 		result := map[string]interface{}{
+			"id":                   data.ID,
 			"source":               data.Source,
 			"title":                data.Title,
 			"content":              data.Content,
@@ -73,7 +89,7 @@ func (h *DataHandler) retrieveLatestData() ([]map[string]interface{}, error) {
 		results = append(results, result)
 	}
 
-	return results, nil
+	return results
 }
 
 // GetDataBySource retrieves data filtered by source
@@ -93,133 +109,284 @@ func (h *DataHandler) GetDataBySource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get data by source from database (get ALL data by passing limit = 0)
-	data, err := database.GetDataBySource(source, 0)
+	page, err := parsePageRequest(r)
 	if err != nil {
-		http.Error(w, "Failed to retrieve data: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	var results []map[string]interface{}
-	for _, item := range data {
-		result := map[string]interface{}{
-			"source":               item.Source,
-			"title":                item.Title,
-			"content":              item.Content,
-			"relevance_score":      item.RelevanceScore,
-			"sentiment":            item.Sentiment,
-			"sentiment_score":      item.SentimentScore,
-			"sentiment_confidence": item.SentimentConfidence,
-			"processed_at":         item.ProcessedAt.Format(time.RFC3339),
-			"processed_data":       item.ProcessedData,
-		}
-		results = append(results, result)
+	filter, err := recordListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Source = source
+
+	// Get a page of the source's data from database
+	records, err := queryRecordPage(r.Context(), h.store, filter, page)
+	if err != nil {
+		http.Error(w, "Failed to retrieve data: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	// Return response
-	response := map[string]interface{}{
-		"status":      "success",
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"source":      source,
-		"data":        results,
-		"total_count": len(results),
+	response := records.envelope(r, map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    source,
+		"data":      recordItems(records.Records),
+	})
+
+	writeListResponse(w, r, response, h.maxResponseBytes)
+}
+
+// recordListFilter reads the filter parameters of the record lists: campaign,
+// hashtag, tag, sentiment, min_relevance, language and the from/to dates of
+// the items
+func recordListFilter(r *http.Request) (database.ProcessedDataFilter, error) {
+	query := r.URL.Query()
+	filter := database.ProcessedDataFilter{
+		Project:   requestProject(r),
+		Campaign:  query.Get("campaign"),
+		Hashtag:   query.Get("hashtag"),
+		Sentiment: query.Get("sentiment"),
+		Language:  query.Get("language"),
+		Tags:      requestTags(r),
 	}
 
-	json.NewEncoder(w).Encode(response)
+	var err error
+	if filter.MinRelevance, err = parseScoreParam(query.Get("min_relevance")); err != nil {
+		return filter, fmt.Errorf("Invalid min_relevance: %v", err)
+	}
+	if filter.From, err = parseDateParam(query.Get("from"), false); err != nil {
+		return filter, fmt.Errorf("Invalid from: %v", err)
+	}
+	if filter.To, err = parseDateParam(query.Get("to"), true); err != nil {
+		return filter, fmt.Errorf("Invalid to: %v", err)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, fmt.Errorf("from must not be after to")
+	}
+
+	return filter, nil
 }
 
-// GetDataStats retrieves database statistics
-func (h *DataHandler) GetDataStats(w http.ResponseWriter, r *http.Request) {
+// GetRecord retrieves a single record including its full processed_data,
+// e.g. /api/etl/data/record/42
+func (h *DataHandler) GetRecord(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Set content type (CORS is handled by middleware)
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get data counts from database
-	counts, err := database.GetDataCount()
+	id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/etl/data/record/"), "/"))
 	if err != nil {
-		http.Error(w, "Failed to retrieve stats: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid record ID", http.StatusBadRequest)
 		return
 	}
 
-	// Return response
+	item, err := h.store.GetProcessedDataByID(r.Context(), requestProject(r), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve record: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if item == nil {
+		http.Error(w, "Record not found", http.StatusNotFound)
+		return
+	}
+
+	record := map[string]interface{}{
+		"id":                   item.ID,
+		"source":               item.Source,
+		"title":                item.Title,
+		"content":              item.Content,
+		"relevance_score":      item.RelevanceScore,
+		"sentiment":            item.Sentiment,
+		"sentiment_score":      item.SentimentScore,
+		"sentiment_confidence": item.SentimentConfidence,
+		"analyzer_version":     item.AnalyzerVersion,
+		"batch_id":             item.BatchID,
+		"license":              item.License,
+		"processed_at":         item.ProcessedAt.Format(time.RFC3339),
+	}
+	if item.PublishedAt != nil {
+		record["published_at"] = item.PublishedAt.Format(time.RFC3339)
+	}
+	if json.Valid([]byte(item.ProcessedData)) {
+		record["processed_data"] = json.RawMessage(item.ProcessedData)
+	} else {
+		record["processed_data"] = item.ProcessedData
+	}
+
+	notes, err := database.GetRecordNotes(item.ProjectID, []int{item.ID})
+	if err != nil {
+		http.Error(w, "Failed to retrieve record notes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	record["notes"] = notesOrEmpty(notes[item.ID])
+
+	tags, err := database.GetRecordTags(item.ProjectID, []int{item.ID})
+	if err != nil {
+		http.Error(w, "Failed to retrieve record tags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	record["tags"] = tagsOrEmpty(tags[item.ID])
+
 	response := map[string]interface{}{
 		"status":    "success",
 		"timestamp": time.Now().Format(time.RFC3339),
-		"stats":     counts,
+		"record":    record,
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetYouTubeData retrieves YouTube data from database
-func (h *DataHandler) GetYouTubeData(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// DataRoutes dispatches per-record data endpoints such as /api/etl/data/{id}/related,
+// /api/etl/data/{id}/notes and /api/etl/data/{id}/tags[/{tag}]
+func (h *DataHandler) DataRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/etl/data/"), "/"), "/")
+	if len(parts) < 2 {
+		http.NotFound(w, r)
 		return
 	}
 
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid record ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "related":
+		h.getRelated(w, r, id)
+	case len(parts) == 2 && parts[1] == "notes":
+		h.recordNotes(w, r, id)
+	case len(parts) == 2 && parts[1] == "versions":
+		h.getRecordVersions(w, r, id)
+	case len(parts) == 2 && parts[1] == "tags":
+		h.recordTags(w, r, id, "")
+	case len(parts) == 3 && parts[1] == "tags":
+		h.recordTags(w, r, id, parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// recordTags lists (GET) or adds (POST {tags: [...]}) the tags of a record, or
+// removes one (DELETE /api/etl/data/{id}/tags/{tag}). Tags added here are
+// manual tags authored by the calling user (X-User-ID).
+func (h *DataHandler) recordTags(w http.ResponseWriter, r *http.Request, id int, tag string) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get YouTube data from database (get ALL data by passing limit = 0)
-	data, err := database.GetDataBySource("youtube", 0)
+	allowed := r.Method == http.MethodDelete
+	if tag == "" {
+		allowed = r.Method == http.MethodGet || r.Method == http.MethodPost
+	}
+	if !allowed {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	record, err := h.store.GetProcessedDataByID(r.Context(), requestProject(r), id)
 	if err != nil {
-		http.Error(w, "Failed to retrieve YouTube data: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to retrieve record: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if record == nil {
+		http.Error(w, "Record not found", http.StatusNotFound)
 		return
 	}
 
-	// Process and enrich the data
-	var enrichedData []map[string]interface{}
-	for _, item := range data {
-		// Create the enriched item with the expected structure
-		enrichedItem := map[string]interface{}{
-			"id":                    item.ID,
-			"title":                 item.Title,
-			"description":           item.Content,
-			"covid_relevance_score": item.RelevanceScore,
-			"sentiment":             item.Sentiment,
-			"sentiment_score":       item.SentimentScore,
-			"sentiment_confidence":  item.SentimentConfidence,
+	if r.Method != http.MethodGet {
+		userID := requestUserID(r)
+		if userID == "" {
+			http.Error(w, "User identification is required (X-User-ID header or user_id parameter)", http.StatusUnauthorized)
+			return
 		}
 
-		// Parse processed_data JSON to extract metadata
-		var metadata map[string]interface{}
-		if item.ProcessedData != "" {
-			if err := json.Unmarshal([]byte(item.ProcessedData), &metadata); err == nil {
-				// Create the metadata structure as expected
-				metadataStructure := map[string]interface{}{
-					"video": map[string]interface{}{
-						"id":       metadata["video_id"],
-						"title":    item.Title,
-						"views":    metadata["views"],
-						"duration": metadata["duration"],
-						"likes":    metadata["likes"],
-					},
-					"comment": map[string]interface{}{
-						"id":       item.ID,
-						"content":  item.Content,
-						"language": metadata["language"],
-					},
+		if r.Method == http.MethodDelete {
+			removed, err := database.RemoveRecordTag(record.ProjectID, record.ID, database.NormalizeTag(tag))
+			if err != nil {
+				http.Error(w, "Failed to remove tag: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !removed {
+				http.Error(w, "Tag not found on record", http.StatusNotFound)
+				return
+			}
+		} else {
+			var req struct {
+				Tags []string `json:"tags"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var tags []string
+			for _, name := range req.Tags {
+				normalized := database.NormalizeTag(name)
+				if normalized == "" {
+					http.Error(w, fmt.Sprintf("Invalid tag %q (1-100 characters)", name), http.StatusBadRequest)
+					return
 				}
-				enrichedItem["metadata"] = metadataStructure
+				tags = append(tags, normalized)
+			}
+			if len(tags) == 0 {
+				http.Error(w, "tags is required", http.StatusBadRequest)
+				return
+			}
+
+			if err := database.AddRecordTags(record.ProjectID, record.ID, tags, database.TagOriginManual, userID); err != nil {
+				http.Error(w, "Failed to add tags: "+err.Error(), http.StatusInternalServerError)
+				return
 			}
 		}
+	}
 
-		enrichedData = append(enrichedData, enrichedItem)
+	tags, err := database.GetRecordTags(record.ProjectID, []int{record.ID})
+	if err != nil {
+		http.Error(w, "Failed to retrieve record tags: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	response := map[string]interface{}{
-		"data": enrichedData,
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"record_id": record.ID,
+		"data":      tagsOrEmpty(tags[record.ID]),
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetGoogleNewsData retrieves Google News data from database
-func (h *DataHandler) GetGoogleNewsData(w http.ResponseWriter, r *http.Request) {
+// tagsOrEmpty returns tags, or an empty list so JSON responses never contain null
+func tagsOrEmpty(tags []database.RecordTag) []database.RecordTag {
+	if tags == nil {
+		return []database.RecordTag{}
+	}
+	return tags
+}
+
+// requestTags returns the normalized tags of the tag parameter, which may be
+// repeated or comma-separated (?tag=vaccination,misinformation)
+func requestTags(r *http.Request) []string {
+	var tags []string
+	for _, value := range r.URL.Query()["tag"] {
+		for _, tag := range strings.Split(value, ",") {
+			if normalized := database.NormalizeTag(tag); normalized != "" {
+				tags = append(tags, normalized)
+			}
+		}
+	}
+	return tags
+}
+
+// GetTagCounts returns how many records carry each manual and automatic tag
+// (?source= to limit to one source)
+func (h *DataHandler) GetTagCounts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -227,70 +394,26 @@ func (h *DataHandler) GetGoogleNewsData(w http.ResponseWriter, r *http.Request)
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get Google News data from database (get ALL data by passing limit = 0)
-	data, err := database.GetDataBySource("google_news", 0)
+	source := r.URL.Query().Get("source")
+	counts, err := database.GetTagCounts(requestProject(r), source, includeDuplicates(r))
 	if err != nil {
-		http.Error(w, "Failed to retrieve Google News data: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to retrieve tag counts: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Process and enrich the data
-	var enrichedData []map[string]interface{}
-	for _, item := range data {
-		enrichedItem := map[string]interface{}{
-			"id":                   item.ID,
-			"source":               item.Source,
-			"processed_at":         item.ProcessedAt,
-			"title":                item.Title,
-			"content":              item.Content,
-			"relevance_score":      item.RelevanceScore,
-			"sentiment":            item.Sentiment,
-			"sentiment_score":      item.SentimentScore,
-			"sentiment_confidence": item.SentimentConfidence,
-		}
-
-		// Parse processed_data JSON to extract metadata
-		if item.ProcessedData != "" {
-			var metadata map[string]interface{}
-			if err := json.Unmarshal([]byte(item.ProcessedData), &metadata); err == nil {
-				// Extract news-specific metadata
-				if url, ok := metadata["url"]; ok {
-					enrichedItem["url"] = url
-				}
-				if author, ok := metadata["author"]; ok {
-					enrichedItem["author"] = author
-				}
-				if publishedAt, ok := metadata["published_at"]; ok {
-					enrichedItem["published_at"] = publishedAt
-				}
-				if source, ok := metadata["source"]; ok {
-					enrichedItem["news_source"] = source
-				}
-				if language, ok := metadata["language"]; ok {
-					enrichedItem["language"] = language
-				}
-				if category, ok := metadata["category"]; ok {
-					enrichedItem["category"] = category
-				}
-			}
-		}
-
-		enrichedData = append(enrichedData, enrichedItem)
-	}
-
 	response := map[string]interface{}{
-		"status":      "success",
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"source":      "google_news",
-		"data":        enrichedData,
-		"total_count": len(enrichedData),
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    source,
+		"data":      counts,
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetInstagramData retrieves Instagram data from database
-func (h *DataHandler) GetInstagramData(w http.ResponseWriter, r *http.Request) {
+// GetEntityCounts returns the people, places and organizations mentioned by
+// the most records (?type=person&source=&from=2024-01-01&to=&limit=20)
+func (h *DataHandler) GetEntityCounts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -298,70 +421,58 @@ func (h *DataHandler) GetInstagramData(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get Instagram data from database (get ALL data by passing limit = 0)
-	data, err := database.GetDataBySource("instagram", 0)
-	if err != nil {
-		http.Error(w, "Failed to retrieve Instagram data: "+err.Error(), http.StatusInternalServerError)
+	query := r.URL.Query()
+	entityType := query.Get("type")
+	switch entityType {
+	case "", services.EntityPerson, services.EntityPlace, services.EntityOrganization:
+	default:
+		http.Error(w, "type must be person, place or organization", http.StatusBadRequest)
 		return
 	}
 
-	// Process and enrich the data
-	var enrichedData []map[string]interface{}
-	for _, item := range data {
-		enrichedItem := map[string]interface{}{
-			"id":                   item.ID,
-			"source":               item.Source,
-			"processed_at":         item.ProcessedAt,
-			"title":                item.Title,
-			"content":              item.Content,
-			"relevance_score":      item.RelevanceScore,
-			"sentiment":            item.Sentiment,
-			"sentiment_score":      item.SentimentScore,
-			"sentiment_confidence": item.SentimentConfidence,
+	limit := 20
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 200 {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
 		}
+		limit = parsed
+	}
 
-		// Parse processed_data JSON to extract metadata
-		if item.ProcessedData != "" {
-			var metadata map[string]interface{}
-			if err := json.Unmarshal([]byte(item.ProcessedData), &metadata); err == nil {
-				// Extract Instagram-specific metadata
-				if likes, ok := metadata["likes"]; ok {
-					enrichedItem["likes"] = likes
-				}
-				if comments, ok := metadata["comments"]; ok {
-					enrichedItem["comments"] = comments
-				}
-				if postID, ok := metadata["post_id"]; ok {
-					enrichedItem["post_id"] = postID
-				}
-				if hashtags, ok := metadata["hashtags"]; ok {
-					enrichedItem["hashtags"] = hashtags
-				}
-				if followers, ok := metadata["followers"]; ok {
-					enrichedItem["followers"] = followers
-				}
-				if mediaType, ok := metadata["media_type"]; ok {
-					enrichedItem["media_type"] = mediaType
-				}
-			}
-		}
+	from, err := parseDateParam(query.Get("from"), false)
+	if err != nil {
+		http.Error(w, "Invalid from date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseDateParam(query.Get("to"), true)
+	if err != nil {
+		http.Error(w, "Invalid to date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		enrichedData = append(enrichedData, enrichedItem)
+	source := query.Get("source")
+	counts, err := database.GetEntityCounts(requestProject(r), entityType, source, from, to, limit, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve entity counts: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	response := map[string]interface{}{
-		"status":      "success",
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"source":      "instagram",
-		"data":        enrichedData,
-		"total_count": len(enrichedData),
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"type":      entityType,
+		"source":    source,
+		"data":      counts,
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetIndonesiaNewsData retrieves Indonesia News data from database or fresh from API
-func (h *DataHandler) GetIndonesiaNewsData(w http.ResponseWriter, r *http.Request) {
+// GetProvinceCounts returns the number of records about each province and
+// their sentiment, keyed by ISO 3166-2 code for a map
+// (?source=&from=2024-01-01&to=)
+func (h *DataHandler) GetProvinceCounts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -369,25 +480,336 @@ func (h *DataHandler) GetIndonesiaNewsData(w http.ResponseWriter, r *http.Reques
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Check if user wants fresh data
-	refresh := r.URL.Query().Get("refresh")
+	from, err := parseDateParam(r.URL.Query().Get("from"), false)
+	if err != nil {
+		http.Error(w, "Invalid from date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseDateParam(r.URL.Query().Get("to"), true)
+	if err != nil {
+		http.Error(w, "Invalid to date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if refresh == "true" {
-		// Fetch fresh data from the scraper
-		h.getFreshIndonesiaNewsData(w, r)
+	source := r.URL.Query().Get("source")
+	counts, unlocated, err := database.GetProvinceCounts(requestProject(r), source, from, to, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve province counts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    source,
+		"data":      counts,
+		"unlocated": unlocated,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// recordNotes lists (GET) or adds (POST {body}) analyst notes on a record.
+// The author of a new note is the calling user (X-User-ID).
+func (h *DataHandler) recordNotes(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Get Indonesia News data from database (get ALL data by passing limit = 0)
-	data, err := database.GetDataBySource("indonesia_news", 0)
+	record, err := h.store.GetProcessedDataByID(r.Context(), requestProject(r), id)
 	if err != nil {
-		http.Error(w, "Failed to retrieve Indonesia News data: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to retrieve record: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if record == nil {
+		http.Error(w, "Record not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		notes, err := database.GetRecordNotes(record.ProjectID, []int{record.ID})
+		if err != nil {
+			http.Error(w, "Failed to retrieve record notes: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"status":      "success",
+			"timestamp":   time.Now().Format(time.RFC3339),
+			"record_id":   record.ID,
+			"data":        notesOrEmpty(notes[record.ID]),
+			"total_count": len(notes[record.ID]),
+		}
+
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	author := requestUserID(r)
+	if author == "" {
+		http.Error(w, "User identification is required (X-User-ID header or user_id parameter)", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	note := database.RecordNote{
+		RecordID:  record.ID,
+		ProjectID: record.ProjectID,
+		Author:    author,
+		Body:      strings.TrimSpace(req.Body),
+	}
+	if note.Body == "" {
+		http.Error(w, "body is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateRecordNote(&note); err != nil {
+		http.Error(w, "Failed to create note: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"data":      note,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// notesOrEmpty returns notes, or an empty list so JSON responses never contain null
+func notesOrEmpty(notes []database.RecordNote) []database.RecordNote {
+	if notes == nil {
+		return []database.RecordNote{}
+	}
+	return notes
+}
+
+// getRelated returns the records most similar to a record across all sources,
+// e.g. social media reactions to a news article
+func (h *DataHandler) getRelated(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 50 {
+			http.Error(w, "limit must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	related, err := database.GetRelatedProcessedData(requestProject(r), id, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve related records: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if related == nil {
+		http.Error(w, "Record not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"id":          id,
+		"related":     related,
+		"total_count": len(related),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// getRecordVersions lists the versions of an article fetched again by the
+// update checks, oldest first, so edits after publication can be traced
+func (h *DataHandler) getRecordVersions(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	record, err := h.store.GetProcessedDataByID(r.Context(), requestProject(r), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve record: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if record == nil {
+		http.Error(w, "Record not found", http.StatusNotFound)
+		return
+	}
+
+	versions, err := database.GetRecordVersions(record.ProjectID, record.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve record versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"id":          id,
+		"changed_at":  record.ChangedAt,
+		"versions":    versions,
+		"total_count": len(versions),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDataStats retrieves database statistics
+func (h *DataHandler) GetDataStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Set content type (CORS is handled by middleware)
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get data counts from database
+	counts, err := h.store.GetDataCount(r.Context(), requestProject(r), includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve stats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"stats":     counts,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetYouTubeData retrieves YouTube data from database
+func (h *DataHandler) GetYouTubeData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := recordListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Source = "youtube"
+
+	// Get a page of YouTube data from database
+	records, err := queryRecordPage(r.Context(), h.store, filter, page)
+	if err != nil {
+		http.Error(w, "Failed to retrieve YouTube data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Process and enrich the data
+	enrichedData := []map[string]interface{}{}
+	for _, item := range records.Records {
+		// Create the enriched item with the expected structure
+		enrichedItem := map[string]interface{}{
+			"id":                    item.ID,
+			"title":                 item.Title,
+			"description":           item.Content,
+			"covid_relevance_score": item.RelevanceScore,
+			"sentiment":             item.Sentiment,
+			"sentiment_score":       item.SentimentScore,
+			"sentiment_confidence":  item.SentimentConfidence,
+		}
+
+		// Parse processed_data JSON to extract metadata
+		var metadata map[string]interface{}
+		if item.ProcessedData != "" {
+			if err := json.Unmarshal([]byte(item.ProcessedData), &metadata); err == nil {
+				// Create the metadata structure as expected
+				metadataStructure := map[string]interface{}{
+					"video": map[string]interface{}{
+						"id":       metadata["video_id"],
+						"title":    item.Title,
+						"views":    metadata["views"],
+						"duration": metadata["duration"],
+						"likes":    metadata["likes"],
+					},
+					"comment": map[string]interface{}{
+						"id":       item.ID,
+						"content":  item.Content,
+						"language": metadata["language"],
+					},
+				}
+				enrichedItem["metadata"] = metadataStructure
+			}
+		}
+
+		enrichedData = append(enrichedData, enrichedItem)
+	}
+
+	response := records.envelope(r, map[string]interface{}{
+		"data": enrichedData,
+	})
+
+	writeListResponse(w, r, response, h.maxResponseBytes)
+}
+
+// GetGoogleNewsData retrieves Google News data from database
+func (h *DataHandler) GetGoogleNewsData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := recordListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Source = "google_news"
+
+	// Get a page of Google News data from database
+	records, err := queryRecordPage(r.Context(), h.store, filter, page)
+	if err != nil {
+		http.Error(w, "Failed to retrieve Google News data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Process and enrich the data
-	var enrichedData []map[string]interface{}
-	for _, item := range data {
+	enrichedData := []map[string]interface{}{}
+	for _, item := range records.Records {
 		enrichedItem := map[string]interface{}{
 			"id":                   item.ID,
 			"source":               item.Source,
@@ -404,7 +826,7 @@ func (h *DataHandler) GetIndonesiaNewsData(w http.ResponseWriter, r *http.Reques
 		if item.ProcessedData != "" {
 			var metadata map[string]interface{}
 			if err := json.Unmarshal([]byte(item.ProcessedData), &metadata); err == nil {
-				// Extract Indonesia news-specific metadata
+				// Extract news-specific metadata
 				if url, ok := metadata["url"]; ok {
 					enrichedItem["url"] = url
 				}
@@ -414,8 +836,8 @@ func (h *DataHandler) GetIndonesiaNewsData(w http.ResponseWriter, r *http.Reques
 				if publishedAt, ok := metadata["published_at"]; ok {
 					enrichedItem["published_at"] = publishedAt
 				}
-				if newsSource, ok := metadata["news_source"]; ok {
-					enrichedItem["news_source"] = newsSource
+				if source, ok := metadata["source"]; ok {
+					enrichedItem["news_source"] = source
 				}
 				if language, ok := metadata["language"]; ok {
 					enrichedItem["language"] = language
@@ -423,28 +845,24 @@ func (h *DataHandler) GetIndonesiaNewsData(w http.ResponseWriter, r *http.Reques
 				if category, ok := metadata["category"]; ok {
 					enrichedItem["category"] = category
 				}
-				if region, ok := metadata["region"]; ok {
-					enrichedItem["region"] = region
-				}
 			}
 		}
 
 		enrichedData = append(enrichedData, enrichedItem)
 	}
 
-	response := map[string]interface{}{
-		"status":      "success",
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"source":      "indonesia_news",
-		"data":        enrichedData,
-		"total_count": len(enrichedData),
-	}
+	response := records.envelope(r, map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    "google_news",
+		"data":      enrichedData,
+	})
 
-	json.NewEncoder(w).Encode(response)
+	writeListResponse(w, r, response, h.maxResponseBytes)
 }
 
-// GetSentimentDistribution retrieves sentiment distribution across all sources
-func (h *DataHandler) GetSentimentDistribution(w http.ResponseWriter, r *http.Request) {
+// GetInstagramData retrieves Instagram data from database
+func (h *DataHandler) GetInstagramData(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -452,33 +870,323 @@ func (h *DataHandler) GetSentimentDistribution(w http.ResponseWriter, r *http.Re
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get sentiment distribution from database
-	distribution, err := database.GetSentimentDistribution()
+	page, err := parsePageRequest(r)
 	if err != nil {
-		http.Error(w, "Failed to retrieve sentiment distribution: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response := map[string]interface{}{
-		"status":       "success",
-		"timestamp":    time.Now().Format(time.RFC3339),
-		"distribution": distribution,
+	filter, err := recordListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	filter.Source = "instagram"
 
-	json.NewEncoder(w).Encode(response)
-}
-
-// GetWordFrequency retrieves word frequency analysis across all sources
-func (h *DataHandler) GetWordFrequency(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// Get a page of Instagram data from database
+	records, err := queryRecordPage(r.Context(), h.store, filter, page)
+	if err != nil {
+		http.Error(w, "Failed to retrieve Instagram data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
-	// Get word frequency from database
-	wordFrequency, err := database.GetWordFrequency()
+	// Process and enrich the data
+	enrichedData := []map[string]interface{}{}
+	for _, item := range records.Records {
+		enrichedItem := map[string]interface{}{
+			"id":                   item.ID,
+			"source":               item.Source,
+			"processed_at":         item.ProcessedAt,
+			"title":                item.Title,
+			"content":              item.Content,
+			"relevance_score":      item.RelevanceScore,
+			"sentiment":            item.Sentiment,
+			"sentiment_score":      item.SentimentScore,
+			"sentiment_confidence": item.SentimentConfidence,
+		}
+
+		// Parse processed_data JSON to extract metadata
+		if item.ProcessedData != "" {
+			var metadata map[string]interface{}
+			if err := json.Unmarshal([]byte(item.ProcessedData), &metadata); err == nil {
+				// Extract Instagram-specific metadata
+				if likes, ok := metadata["likes"]; ok {
+					enrichedItem["likes"] = likes
+				}
+				if comments, ok := metadata["comments"]; ok {
+					enrichedItem["comments"] = comments
+				}
+				if postID, ok := metadata["post_id"]; ok {
+					enrichedItem["post_id"] = postID
+				}
+				if hashtags, ok := metadata["hashtags"]; ok {
+					enrichedItem["hashtags"] = hashtags
+				}
+				if followers, ok := metadata["followers"]; ok {
+					enrichedItem["followers"] = followers
+				}
+				if mediaType, ok := metadata["media_type"]; ok {
+					enrichedItem["media_type"] = mediaType
+				}
+			}
+		}
+
+		enrichedData = append(enrichedData, enrichedItem)
+	}
+
+	response := records.envelope(r, map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    "instagram",
+		"data":      enrichedData,
+	})
+
+	writeListResponse(w, r, response, h.maxResponseBytes)
+}
+
+// GetIndonesiaNewsData retrieves Indonesia News data from database or fresh from API
+func (h *DataHandler) GetIndonesiaNewsData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check if user wants fresh data
+	refresh := r.URL.Query().Get("refresh")
+
+	if refresh == "true" {
+		// Fetch fresh data from the scraper
+		h.getFreshIndonesiaNewsData(w, r)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := recordListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Source = "indonesia_news"
+
+	// Get a page of Indonesia News data from database
+	records, err := queryRecordPage(r.Context(), h.store, filter, page)
+	if err != nil {
+		http.Error(w, "Failed to retrieve Indonesia News data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Process and enrich the data
+	enrichedData := []map[string]interface{}{}
+	for _, item := range records.Records {
+		enrichedItem := map[string]interface{}{
+			"id":                   item.ID,
+			"source":               item.Source,
+			"processed_at":         item.ProcessedAt,
+			"title":                item.Title,
+			"content":              item.Content,
+			"relevance_score":      item.RelevanceScore,
+			"sentiment":            item.Sentiment,
+			"sentiment_score":      item.SentimentScore,
+			"sentiment_confidence": item.SentimentConfidence,
+		}
+
+		// Parse processed_data JSON to extract metadata
+		if item.ProcessedData != "" {
+			var metadata map[string]interface{}
+			if err := json.Unmarshal([]byte(item.ProcessedData), &metadata); err == nil {
+				// Extract Indonesia news-specific metadata
+				if url, ok := metadata["url"]; ok {
+					enrichedItem["url"] = url
+				}
+				if author, ok := metadata["author"]; ok {
+					enrichedItem["author"] = author
+				}
+				if publishedAt, ok := metadata["published_at"]; ok {
+					enrichedItem["published_at"] = publishedAt
+				}
+				if newsSource, ok := metadata["news_source"]; ok {
+					enrichedItem["news_source"] = newsSource
+				}
+				if language, ok := metadata["language"]; ok {
+					enrichedItem["language"] = language
+				}
+				if category, ok := metadata["category"]; ok {
+					enrichedItem["category"] = category
+				}
+				if region, ok := metadata["region"]; ok {
+					enrichedItem["region"] = region
+				}
+			}
+		}
+
+		enrichedData = append(enrichedData, enrichedItem)
+	}
+
+	response := records.envelope(r, map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    "indonesia_news",
+		"data":      enrichedData,
+	})
+
+	writeListResponse(w, r, response, h.maxResponseBytes)
+}
+
+// GetSentimentDistribution retrieves sentiment distribution across all sources
+func (h *DataHandler) GetSentimentDistribution(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// Optionally exclude records scored by outdated analyzer versions
+	minAnalyzerVersion := 0
+	if versionStr := r.URL.Query().Get("min_analyzer_version"); versionStr != "" {
+		parsed, err := strconv.Atoi(versionStr)
+		if err != nil {
+			http.Error(w, "Invalid min_analyzer_version", http.StatusBadRequest)
+			return
+		}
+		minAnalyzerVersion = parsed
+	}
+	excludeSarcastic := r.URL.Query().Get("exclude_sarcastic") == "true"
+
+	// Get sentiment distribution, served from the dashboard cache unless filtered
+	var distribution map[string]interface{}
+	var err error
+	if minAnalyzerVersion > 0 || excludeSarcastic {
+		distribution, err = database.GetSentimentDistribution(requestProject(r), minAnalyzerVersion, excludeSarcastic, includeDuplicates(r))
+	} else {
+		distribution, err = services.SharedDashboardCache().SentimentDistribution(requestProject(r), includeDuplicates(r))
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve sentiment distribution: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":           "success",
+		"timestamp":        time.Now().Format(time.RFC3339),
+		"distribution":     distribution,
+		"analyzer_version": services.SentimentAnalyzerVersion,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSentimentCounts returns the records per sentiment with percentage
+// shares, overall and per source (?source=&from=2024-01-01&to=&exclude_sarcastic=true)
+func (h *DataHandler) GetSentimentCounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	from, err := parseDateParam(r.URL.Query().Get("from"), false)
+	if err != nil {
+		http.Error(w, "Invalid from date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseDateParam(r.URL.Query().Get("to"), true)
+	if err != nil {
+		http.Error(w, "Invalid to date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	excludeSarcastic := r.URL.Query().Get("exclude_sarcastic") == "true"
+	overall, bySource, err := h.store.GetSentimentCounts(r.Context(), requestProject(r), source, from, to, excludeSarcastic, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve sentiment counts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":           "success",
+		"timestamp":        time.Now().Format(time.RFC3339),
+		"source":           source,
+		"from":             r.URL.Query().Get("from"),
+		"to":               r.URL.Query().Get("to"),
+		"overall":          overall,
+		"sources":          bySource,
+		"analyzer_version": services.SentimentAnalyzerVersion,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetWordCloud returns the most frequent words of the records for a word
+// cloud (?source=&sentiment=negative&top=100)
+func (h *DataHandler) GetWordCloud(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	sentiment := r.URL.Query().Get("sentiment")
+	switch sentiment {
+	case "", "positive", "negative", "neutral":
+	default:
+		http.Error(w, "sentiment must be positive, negative or neutral", http.StatusBadRequest)
+		return
+	}
+
+	top := 100
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		parsed, err := strconv.Atoi(topStr)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			http.Error(w, "top must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		top = parsed
+	}
+
+	source := r.URL.Query().Get("source")
+	words, err := h.store.GetWordCounts(r.Context(), requestProject(r), source, sentiment, top, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve word counts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    source,
+		"sentiment": sentiment,
+		"words":     words,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetWordFrequency retrieves word frequency analysis across all sources
+func (h *DataHandler) GetWordFrequency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get word frequency (cached)
+	wordFrequency, err := services.SharedDashboardCache().WordFrequency(requestProject(r), includeDuplicates(r))
 	if err != nil {
 		http.Error(w, "Failed to retrieve word frequency: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -493,13 +1201,646 @@ func (h *DataHandler) GetWordFrequency(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetTrendingTerms returns the terms rising and falling most over the last
+// days days against the baseline_days before them
+// (?days=1&baseline_days=7&limit=20&source=)
+func (h *DataHandler) GetTrendingTerms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	params := []struct {
+		name       string
+		value, max int
+	}{{"days", 1, 90}, {"baseline_days", 7, 365}, {"limit", 20, 100}}
+	for i, param := range params {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > param.max {
+			http.Error(w, fmt.Sprintf("%s must be between 1 and %d", param.name, param.max), http.StatusBadRequest)
+			return
+		}
+		params[i].value = parsed
+	}
+	window := time.Duration(params[0].value) * 24 * time.Hour
+	baseline := time.Duration(params[1].value) * 24 * time.Hour
+
+	source := r.URL.Query().Get("source")
+	trends, err := services.NewTrendingService().TrendingTerms(requestProject(r), source, time.Now(), window, baseline, params[2].value, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve trending terms: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"source":    source,
+		"data":      trends,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTrends retrieves daily trends from the rollup tables, grouped by the
+// dimension parameter (source, sentiment, topic or province) over the last days days.
+// With tz (e.g. WIB) days are bucketed in that zone instead of UTC.
+func (h *DataHandler) GetTrends(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	dimension, days, err := parseTrendParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	zone, err := requestTimeZone(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points, err := database.GetDailyTrend(requestProject(r), dimension, days, zone, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve trends: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cfg, _ := config.LoadConfig()
+	to := time.Now().In(zone)
+	interest, err := database.GetSearchInterest(cfg.ExternalAPIs.GoogleTrends.Geo, to.AddDate(0, 0, 1-days), to)
+	if err != nil {
+		http.Error(w, "Failed to retrieve search interest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":          "success",
+		"timestamp":       time.Now().Format(time.RFC3339),
+		"dimension":       dimension,
+		"days":            days,
+		"tz":              zone.String(),
+		"duplicates":      includeDuplicates(r),
+		"trend":           points,
+		"search_interest": interest,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTimeSeries returns record counts with their average sentiment and
+// relevance per source and day, week or month
+// (?interval=week&from=2021-06-01&to=2021-08-31&source=&tz=WIB). The range
+// defaults to the last 30 days.
+func (h *DataHandler) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "day"
+	}
+	if !database.TimeSeriesIntervals[interval] {
+		http.Error(w, "Invalid interval; use day, week or month", http.StatusBadRequest)
+		return
+	}
+
+	zone, err := requestTimeZone(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().In(zone)
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, zone)
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := parseDateParamIn(value, true, zone)
+		if err != nil {
+			http.Error(w, "Invalid to date: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		to = *parsed
+	}
+	from := to.AddDate(0, 0, -30)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := parseDateParamIn(value, false, zone)
+		if err != nil {
+			http.Error(w, "Invalid from date: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		from = *parsed
+	}
+	if !from.Before(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	points, err := database.GetTimeSeries(requestProject(r), interval, source, from, to, zone, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve time series: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":     "success",
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"interval":   interval,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"tz":         zone.String(),
+		"source":     source,
+		"duplicates": includeDuplicates(r),
+		"series":     points,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDuplicates handles GET /api/etl/data/duplicates?limit=20 and returns the
+// most recent groups of near-duplicate articles for review: each canonical
+// record, the earliest report of a story, with the other reports of it
+func (h *DataHandler) GetDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 200 {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	groups, err := database.GetDuplicateGroups(requestProject(r), limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve duplicates: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"groups":      groups,
+		"total_count": len(groups),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseTrendParams reads the dimension (default sentiment) and days (default
+// 30) parameters of the trend endpoints
+// includeDuplicates reports whether an analytics request counts the
+// duplicates of a story (?include_duplicates=true) rather than only its
+// canonical record
+func includeDuplicates(r *http.Request) bool {
+	return r.URL.Query().Get("include_duplicates") == "true"
+}
+
+func parseTrendParams(r *http.Request) (string, int, error) {
+	dimension := r.URL.Query().Get("dimension")
+	if dimension == "" {
+		dimension = "sentiment"
+	}
+	if _, ok := database.RollupDimensions[dimension]; !ok {
+		return "", 0, fmt.Errorf("Invalid dimension; use source, sentiment, topic or province")
+	}
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed <= 0 || parsed > 3650 {
+			return "", 0, fmt.Errorf("Invalid days")
+		}
+		days = parsed
+	}
+
+	return dimension, days, nil
+}
+
+// ExportAnalytics handles GET /api/analytics/export/{trends|word-frequency|source-comparison}
+// and returns the analytics as CSV, computed from the same data as the
+// dashboard endpoints so report charts match the dashboard exactly
+func (h *DataHandler) ExportAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	projectID := requestProject(r)
+	duplicates := includeDuplicates(r)
+	report := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/analytics/export/"), "/")
+	filename := strings.ReplaceAll(report, "-", "_")
+
+	var buf bytes.Buffer
+	switch report {
+	case "trends":
+		dimension, days, err := parseTrendParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		zone, err := requestTimeZone(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		points, err := database.GetDailyTrend(projectID, dimension, days, zone, duplicates)
+		if err != nil {
+			http.Error(w, "Failed to retrieve trends: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		filename = fmt.Sprintf("trends_%s_%dd", dimension, days)
+		if err := services.WriteTrendCSV(&buf, dimension, points); err != nil {
+			http.Error(w, "Failed to export trends: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case "word-frequency":
+		wordFrequency, err := services.SharedDashboardCache().WordFrequency(projectID, duplicates)
+		if err != nil {
+			http.Error(w, "Failed to retrieve word frequency: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := services.WriteWordFrequencyCSV(&buf, wordFrequency); err != nil {
+			http.Error(w, "Failed to export word frequency: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case "source-comparison":
+		summary, err := services.SharedDashboardCache().Summary(projectID, duplicates)
+		if err != nil {
+			http.Error(w, "Failed to retrieve data summary: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		distribution, err := services.SharedDashboardCache().SentimentDistribution(projectID, duplicates)
+		if err != nil {
+			http.Error(w, "Failed to retrieve sentiment distribution: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := services.WriteSourceComparisonCSV(&buf, summary, distribution); err != nil {
+			http.Error(w, "Failed to export source comparison: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Unknown analytics export; use trends, word-frequency or source-comparison", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+	w.Write(buf.Bytes())
+}
+
+// GetAspectSentiment handles GET /api/analytics/aspect-sentiment and returns
+// the sentiment about each aspect across the records that mention it
+func (h *DataHandler) GetAspectSentiment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	source := r.URL.Query().Get("source")
+	excludeSarcastic := r.URL.Query().Get("exclude_sarcastic") == "true"
+	aspects, err := database.GetAspectSentiment(requestProject(r), source, excludeSarcastic, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve aspect sentiment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":            "success",
+		"timestamp":         time.Now().Format(time.RFC3339),
+		"source":            source,
+		"exclude_sarcastic": excludeSarcastic,
+		"duplicates":        includeDuplicates(r),
+		"aspects":           aspects,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDigest handles GET /api/digest?date=YYYY-MM-DD&tz=WIB and returns the
+// day's top positive and negative items per source, trending terms and
+// anomaly notes. The date defaults to today; days are UTC unless tz is given.
+func (h *DataHandler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	zone, err := requestTimeZone(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	day := time.Now().In(zone)
+	if date, err := parseDateParamIn(r.URL.Query().Get("date"), false, zone); err != nil {
+		http.Error(w, "Invalid date: "+err.Error(), http.StatusBadRequest)
+		return
+	} else if date != nil {
+		day = *date
+	}
+
+	digest, err := services.NewDigestService().BuildDigest(requestProject(r), day, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to build digest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"digest":    digest,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSources handles GET /api/sources and returns the canonical sources of
+// the taxonomy with the spellings mapped to each
+func (h *DataHandler) GetSources(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	sources, err := h.store.GetSources(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve sources: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"count":     len(sources),
+		"sources":   sources,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMethodology handles GET /api/methodology and /api/methodology/{section}
+// and returns the versioned relevance keywords, sentiment lexicons, stop
+// words and topic keywords currently in use. With ?from=YYYY-MM-DD&to=YYYY-MM-DD
+// the full methodology also counts the period's records per analyzer version,
+// showing whether they were all scored with the current lexicons.
+func (h *DataHandler) GetMethodology(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	methodology := services.CurrentMethodology()
+
+	section := ""
+	if strings.HasPrefix(r.URL.Path, "/api/methodology/") {
+		section = strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/methodology/"), "/")
+	}
+	if section != "" {
+		content, ok := methodology.Section(section)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown methodology section %q (available: %s)", section, strings.Join(services.MethodologySections, ", ")), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"timestamp": time.Now().Format(time.RFC3339),
+			"section":   section,
+			section:     content,
+		})
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"methodology": methodology,
+	}
+
+	fromStr, toStr := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if fromStr != "" || toStr != "" {
+		from, err := parseDateParam(fromStr, false)
+		if err != nil {
+			http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseDateParam(toStr, true)
+		if err != nil {
+			http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		versions, err := database.GetAnalyzerVersionCounts(requestProject(r), from, to)
+		if err != nil {
+			http.Error(w, "Failed to retrieve analyzer versions: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		current := true
+		for _, version := range versions {
+			if version.AnalyzerVersion == nil || *version.AnalyzerVersion != services.SentimentAnalyzerVersion {
+				current = false
+			}
+		}
+		response["period"] = map[string]interface{}{
+			"from":              fromStr,
+			"to":                toStr,
+			"analyzer_versions": versions,
+			"current_lexicons":  current, // false when some records need re-scoring to match the methodology
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetCovidStatistics handles GET /api/statistics/covid?from=YYYY-MM-DD&to=YYYY-MM-DD
+// and returns the official daily figures of the configured country. The range
+// defaults to the last 90 days.
+func (h *DataHandler) GetCovidStatistics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	to := time.Now()
+	if date, err := parseDateParam(r.URL.Query().Get("to"), false); err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	} else if date != nil {
+		to = *date
+	}
+	from := to.AddDate(0, 0, -89)
+	if date, err := parseDateParam(r.URL.Query().Get("from"), false); err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	} else if date != nil {
+		from = *date
+	}
+	if from.After(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	cfg, _ := config.LoadConfig()
+	country := cfg.ExternalAPIs.Statistics.Country
+	stats, err := database.GetCovidStatistics(country, from, to)
+	if err != nil {
+		http.Error(w, "Failed to retrieve statistics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"country":   country,
+		"from":      from.Format("2006-01-02"),
+		"to":        to.Format("2006-01-02"),
+		"count":     len(stats),
+		"data":      stats,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSearchInterest handles GET /api/statistics/search-interest?from=YYYY-MM-DD&to=YYYY-MM-DD
+// and returns the Google Trends interest of the configured terms per day. The
+// range defaults to the last 90 days.
+func (h *DataHandler) GetSearchInterest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	to := time.Now()
+	if date, err := parseDateParam(r.URL.Query().Get("to"), false); err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	} else if date != nil {
+		to = *date
+	}
+	from := to.AddDate(0, 0, -89)
+	if date, err := parseDateParam(r.URL.Query().Get("from"), false); err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	} else if date != nil {
+		from = *date
+	}
+	if from.After(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	cfg, _ := config.LoadConfig()
+	trends := cfg.ExternalAPIs.GoogleTrends
+	interest, err := database.GetSearchInterest(trends.Geo, from, to)
+	if err != nil {
+		http.Error(w, "Failed to retrieve search interest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"geo":       trends.Geo,
+		"terms":     trends.Terms,
+		"from":      from.Format("2006-01-02"),
+		"to":        to.Format("2006-01-02"),
+		"count":     len(interest),
+		"data":      interest,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSentimentCaseCorrelation handles GET /api/statistics/covid/sentiment?days=90
+// and returns the project's daily sentiment next to the official figures and
+// search interest, with the correlations between them
+func (h *DataHandler) GetSentimentCaseCorrelation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	days := 90
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed <= 0 || parsed > 3650 {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	cfg, _ := config.LoadConfig()
+	country := cfg.ExternalAPIs.Statistics.Country
+	points, err := database.GetSentimentCaseSeries(requestProject(r), country, days, includeDuplicates(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve sentiment and statistics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	to := time.Now()
+	interest, err := database.GetSearchInterest(cfg.ExternalAPIs.GoogleTrends.Geo, to.AddDate(0, 0, 1-days), to)
+	if err != nil {
+		http.Error(w, "Failed to retrieve search interest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	services.OverlaySearchInterest(points, interest)
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"country":     country,
+		"days":        days,
+		"series":      points,
+		"correlation": services.CorrelateSentimentWithCases(points),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
 // getFreshIndonesiaNewsData fetches fresh data directly from the Indonesia news scraper
 func (h *DataHandler) getFreshIndonesiaNewsData(w http.ResponseWriter, r *http.Request) {
 	// Create ETL extractor to get fresh data
 	extractor := etl.NewDataExtractor()
 
 	// Extract all sources data (we'll filter for Indonesia news)
-	extractedData := extractor.ExtractAllSources()
+	extractedData := extractor.ExtractAllSources(r.Context())
 
 	// Debug logging
 	fmt.Printf("DEBUG: Extracted data sources: %v\n", len(extractedData.Sources))
@@ -535,8 +1876,8 @@ func (h *DataHandler) GetDataSummary(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get summary data from database
-	summary, err := database.GetDataSummary()
+	// Get summary data (cached)
+	summary, err := services.SharedDashboardCache().Summary(requestProject(r), includeDuplicates(r))
 	if err != nil {
 		http.Error(w, "Failed to retrieve data summary: "+err.Error(), http.StatusInternalServerError)
 		return
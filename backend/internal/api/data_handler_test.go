@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+func testStore(t *testing.T) *database.MemoryStore {
+	store := database.NewMemoryStore()
+	for _, record := range []database.ProcessedData{
+		{Source: "youtube", Title: "Vaksin booster dibuka", Sentiment: "positive", ContentHash: "a"},
+		{Source: "google_news", Title: "Kasus naik lagi", Sentiment: "negative", ContentHash: "b"},
+		{Source: "youtube", Title: "Vaksin gratis", Sentiment: "positive", ContentHash: "c"},
+	} {
+		record := record
+		if _, err := store.UpsertProcessedData(context.Background(), &record); err != nil {
+			t.Fatalf("Failed to load a record: %v", err)
+		}
+	}
+	return store
+}
+
+func getJSON(t *testing.T, handler http.HandlerFunc, target string, response interface{}) {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from %s, got %d: %s", target, rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), response); err != nil {
+		t.Fatalf("Failed to decode the response of %s: %v", target, err)
+	}
+}
+
+func TestLatestDataPagesThroughStore(t *testing.T) {
+	handler := NewDataHandler(testStore(t))
+
+	var first struct {
+		TotalCount int `json:"total_count"`
+		Data       []struct{ ID int }
+		Pagination struct {
+			NextCursor string `json:"next_cursor"`
+		}
+	}
+	getJSON(t, handler.GetLatestData, "/api/etl/data?per_page=2", &first)
+	if first.TotalCount != 3 || len(first.Data) != 2 || first.Data[0].ID != 3 || first.Pagination.NextCursor == "" {
+		t.Fatalf("Expected the two newest of 3 records and a cursor, got %+v", first)
+	}
+
+	var next struct {
+		Data       []struct{ ID int }
+		Pagination struct {
+			NextCursor string `json:"next_cursor"`
+		}
+	}
+	getJSON(t, handler.GetLatestData, "/api/etl/data?per_page=2&cursor="+first.Pagination.NextCursor, &next)
+	if len(next.Data) != 1 || next.Data[0].ID != 1 || next.Pagination.NextCursor != "" {
+		t.Errorf("Expected the oldest record on the last page, got %+v", next)
+	}
+}
+
+func TestSentimentCountsFromStore(t *testing.T) {
+	store := testStore(t)
+	updated := database.ProcessedData{Source: "youtube", Title: "Vaksin gratis habis", Sentiment: "negative", ContentHash: "c"}
+	if inserted, err := store.UpsertProcessedData(context.Background(), &updated); err != nil || inserted || updated.ID != 3 {
+		t.Fatalf("Expected the record with the same content hash to be updated, got ID %d, inserted %v, %v", updated.ID, inserted, err)
+	}
+
+	var response struct {
+		Overall database.SentimentCounts
+		Sources []database.SentimentCounts
+	}
+	getJSON(t, NewDataHandler(store).GetSentimentCounts, "/api/analytics/sentiment", &response)
+	if response.Overall.Total != 3 || response.Overall.Positive != 1 || response.Overall.Negative != 2 {
+		t.Errorf("Expected 1 positive and 2 negative records, got %+v", response.Overall)
+	}
+	if len(response.Sources) != 2 || response.Sources[0].Source != "google_news" || response.Sources[1].NegativePct != 50 {
+		t.Errorf("Expected the counts of google_news and youtube, got %+v", response.Sources)
+	}
+}
+
+func TestSQLiteServerServesStoreEndpointsOnly(t *testing.T) {
+	store, err := database.OpenSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open the SQLite store: %v", err)
+	}
+	defer store.Close()
+	for _, record := range []database.ProcessedData{
+		{Source: "youtube", Title: "Vaksin booster dibuka", Sentiment: "positive", ContentHash: "a"},
+		{Source: "google_news", Title: "Kasus naik lagi", Sentiment: "negative", ContentHash: "b"},
+	} {
+		record := record
+		if _, err := store.UpsertProcessedData(context.Background(), &record); err != nil {
+			t.Fatalf("Failed to load a record: %v", err)
+		}
+	}
+
+	r := &Router{dataHandler: NewDataHandler(store), cors: NewCORS(config.APIConfig{}), storeOnly: true}
+	handler := r.storeOnlyMiddleware(r.routes())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/etl/data/youtube", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Vaksin booster dibuka") || strings.Contains(rec.Body.String(), "Kasus naik lagi") {
+		t.Errorf("Expected the youtube record from the SQLite store, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/etl/cleanup/sentiment", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 from an endpoint that needs PostgreSQL, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStorePathsNeedNoPostgres(t *testing.T) {
+	t.Setenv("RAPIDAPI_KEY", "testkey-0123456789")
+	cfg := &config.Config{Database: config.DatabaseConfig{Type: "sqlite"}}
+	handler := NewRouter(cfg, testStore(t)).SetupRoutes()
+
+	for path := range storePaths {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code >= http.StatusInternalServerError {
+			t.Errorf("Expected %s to be served from the store alone, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
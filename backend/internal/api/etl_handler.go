@@ -1,13 +1,17 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"covid19-kms/database"
+	"covid19-kms/internal/config"
 	"covid19-kms/internal/etl"
 	"covid19-kms/internal/services"
 )
@@ -25,6 +29,8 @@ func NewETLHandler() *ETLHandler {
 }
 
 // RunETLPipeline handles POST requests to run the complete ETL pipeline
+// (?sources=youtube,google_news runs only those sources, ?skip=instagram
+// leaves sources out of this run)
 func (h *ETLHandler) RunETLPipeline(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -34,8 +40,19 @@ func (h *ETLHandler) RunETLPipeline(w http.ResponseWriter, r *http.Request) {
 	// Set content type (CORS is handled by middleware)
 	w.Header().Set("Content-Type", "application/json")
 
-	// Run the ETL pipeline
-	result := h.orchestrator.RunETLPipeline()
+	query := r.URL.Query()
+	opts := etl.PipelineOptions{
+		Sources: listParam(query.Get("sources")),
+		Skip:    listParam(query.Get("skip")),
+	}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, "Invalid sources: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Run the ETL pipeline. The run is detached from the request so a client
+	// timing out does not abort it; cancel it via /api/etl/runs/{batch_id}/cancel.
+	result := h.orchestrator.RunETLPipelineWithOptions(context.Background(), requestProject(r), opts)
 
 	// Convert result to JSON
 	jsonData, err := json.MarshalIndent(result, "", "  ")
@@ -49,7 +66,257 @@ func (h *ETLHandler) RunETLPipeline(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonData)
 }
 
-// GetPipelineStatus handles GET requests to check pipeline status
+// listParam splits a comma-separated query parameter, dropping empty items
+func listParam(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// CrawlArchive handles POST requests to backfill archived articles by
+// crawling outlet sitemaps (?outlet=Kompas,Tempo&from=2020-03-01&to=2020-06-30&max=500);
+// omitted parameters fall back to the CRAWLER_* settings
+func (h *ETLHandler) CrawlArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		http.Error(w, "Failed to load configuration: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	opts := etl.NewCrawlOptions(cfg.ETL.Crawler)
+
+	query := r.URL.Query()
+	for _, outlet := range strings.Split(query.Get("outlet"), ",") {
+		if outlet = strings.TrimSpace(outlet); outlet == "" {
+			continue
+		}
+		if _, ok := cfg.ETL.Crawler.Sitemaps[outlet]; !ok {
+			http.Error(w, fmt.Sprintf("Outlet %q has no sitemap in CRAWLER_SITEMAPS", outlet), http.StatusBadRequest)
+			return
+		}
+		opts.Outlets = append(opts.Outlets, outlet)
+	}
+	from, err := parseDateParam(query.Get("from"), false)
+	if err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from != nil {
+		opts.From = *from
+	}
+	to, err := parseDateParam(query.Get("to"), false)
+	if err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to != nil {
+		opts.To = *to
+	}
+	if opts.To.Before(opts.From) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+	if maxStr := query.Get("max"); maxStr != "" {
+		maxArticles, err := strconv.Atoi(maxStr)
+		if err != nil || maxArticles <= 0 {
+			http.Error(w, "max must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		opts.MaxArticles = maxArticles
+	}
+
+	// Like pipeline runs, the crawl is detached from the request; cancel it
+	// via /api/etl/runs/{batch_id}/cancel
+	result := h.orchestrator.RunCrawlBackfill(context.Background(), requestProject(r), opts)
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// ReprocessRawData handles POST requests to transform and load the stored
+// raw payloads of earlier runs again (?from=2024-01-01&to=2024-01-31&source=youtube),
+// so transformer fixes apply to records already loaded without spending
+// API quota on extraction. from is required; to includes its whole day.
+func (h *ETLHandler) ReprocessRawData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	from, err := parseDateParam(query.Get("from"), false)
+	if err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from == nil {
+		http.Error(w, "from is required", http.StatusBadRequest)
+		return
+	}
+	to, err := parseDateParam(query.Get("to"), true)
+	if err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to != nil && !to.After(*from) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+	source := query.Get("source")
+	if source != "" && !etl.IsRegisteredSource(source) {
+		http.Error(w, fmt.Sprintf("Unknown source %q", source), http.StatusBadRequest)
+		return
+	}
+
+	// Like pipeline runs, reprocessing is detached from the request; cancel
+	// it via /api/etl/runs/{batch_id}/cancel
+	result := h.orchestrator.RunReprocess(context.Background(), requestProject(r), etl.ReprocessOptions{
+		From:   *from,
+		To:     to,
+		Source: source,
+	})
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// RetryDeadLetters handles POST requests to load the project's records whose
+// load failed again, oldest first (?limit=500), once the cause is fixed
+func (h *ETLHandler) RetryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 500
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	loader := etl.NewDataLoader()
+	loader.SetProject(requestProject(r))
+
+	result, err := loader.RetryDeadLetters(limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retry dead letters: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"result":    result,
+		"message":   fmt.Sprintf("Loaded %d of %d dead-lettered records", result.Loaded, result.Retried),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// Run history pages hold defaultRunsPerPage runs unless ?per_page asks for
+// up to maxRunsPerPage
+const (
+	defaultRunsPerPage = 20
+	maxRunsPerPage     = 100
+)
+
+// parseRunHistoryPage reads the page and per_page query parameters of the
+// run history
+func parseRunHistoryPage(r *http.Request) (int, int, error) {
+	page, perPage := 1, defaultRunsPerPage
+	if value := r.URL.Query().Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("page must be a positive integer")
+		}
+		page = parsed
+	}
+	if value := r.URL.Query().Get("per_page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxRunsPerPage {
+			return 0, 0, fmt.Errorf("per_page must be between 1 and %d", maxRunsPerPage)
+		}
+		perPage = parsed
+	}
+	return page, perPage, nil
+}
+
+// runHistoryPagination returns the pagination details and links of a page
+// of the run history
+func runHistoryPagination(r *http.Request, page, perPage, total int) map[string]interface{} {
+	pagination := map[string]interface{}{
+		"page":        page,
+		"per_page":    perPage,
+		"total_count": total,
+		"total_pages": (total + perPage - 1) / perPage,
+	}
+	if page > 1 {
+		pagination["prev"] = pageLink(r, "page", strconv.Itoa(page-1))
+	}
+	if page*perPage < total {
+		pagination["next"] = pageLink(r, "page", strconv.Itoa(page+1))
+	}
+	return pagination
+}
+
+// GetQuotaUsage handles GET requests for the API requests each source sent
+// today and this month against its daily and monthly quota
+func (h *ETLHandler) GetQuotaUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	usage, err := etl.GetQuotaUsage()
+	if err != nil {
+		http.Error(w, "Failed to read API usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"sources":   usage,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPipelineStatus handles GET requests to check pipeline status: the
+// project's latest run and a page of its run history, most recent first
+// (?page, ?per_page)
 func (h *ETLHandler) GetPipelineStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -59,14 +326,44 @@ func (h *ETLHandler) GetPipelineStatus(w http.ResponseWriter, r *http.Request) {
 	// Set content type (CORS is handled by middleware)
 	w.Header().Set("Content-Type", "application/json")
 
+	page, perPage, err := parseRunHistoryPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	projectID := requestProject(r)
+	runs, total, err := database.GetPipelineRuns(projectID, "", "", perPage, (page-1)*perPage)
+	if err != nil {
+		http.Error(w, "Failed to read run history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	latest, err := database.GetLatestPipelineRun(projectID)
+	if err != nil {
+		http.Error(w, "Failed to read run history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	state := "ready"
+	for _, run := range etl.ActiveRuns() {
+		if run.ProjectID == projectID {
+			state = "running"
+		}
+	}
+
 	// Create status response
 	status := map[string]interface{}{
-		"status":      "ready",
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"service":     "ETL Pipeline API",
-		"version":     "1.0.0",
-		"endpoints":   []string{"/api/etl/run", "/api/etl/status", "/api/etl/extract", "/api/etl/transform", "/api/etl/load", "/api/etl/cleanup/sentiment", "/api/etl/data/*"},
-		"description": "COVID-19 Knowledge Management System ETL Pipeline",
+		"status":        state,
+		"timestamp":     time.Now().Format(time.RFC3339),
+		"service":       "ETL Pipeline API",
+		"version":       "1.0.0",
+		"endpoints":     []string{"/api/etl/run", "/api/etl/crawl", "/api/etl/reprocess", "/api/etl/deadletter/retry", "/api/etl/status", "/api/etl/quota", "/api/etl/extract", "/api/etl/transform", "/api/etl/load", "/api/etl/cleanup/sentiment", "/api/etl/data/*"},
+		"description":   "COVID-19 Knowledge Management System ETL Pipeline",
+		"source_health": etl.SharedSourceHealth().Snapshot(),
+		"active_runs":   etl.ActiveRuns(),
+		"latest_run":    latest,
+		"runs":          runs,
+		"pagination":    runHistoryPagination(r, page, perPage, total),
 	}
 
 	// Convert to JSON
@@ -93,7 +390,7 @@ func (h *ETLHandler) ExtractData(w http.ResponseWriter, r *http.Request) {
 
 	// Create extractor and run extraction
 	extractor := etl.NewDataExtractor()
-	_ = extractor.ExtractAllSources()
+	_ = extractor.ExtractAllSources(r.Context())
 
 	// Create response
 	response := map[string]interface{}{
@@ -129,7 +426,7 @@ func (h *ETLHandler) TransformData(w http.ResponseWriter, r *http.Request) {
 	// For transformation, we need some input data
 	// In a real scenario, this would come from the request body
 	// For now, we'll create sample data
-	_ = &etl.ExtractedData{
+	extractedData := &etl.ExtractedData{
 		Timestamp: time.Now().Format(time.RFC3339),
 		Query:     "covid19",
 		Sources:   make(map[string]interface{}),
@@ -137,7 +434,7 @@ func (h *ETLHandler) TransformData(w http.ResponseWriter, r *http.Request) {
 
 	// Create transformer and run transformation
 	transformer := etl.NewDataTransformer()
-	transformedData := transformer.TransformData(nil, nil, nil) // Using nil for demo
+	transformedData := transformer.TransformData(extractedData.Sources)
 
 	if transformedData == nil {
 		http.Error(w, "Transformation failed", http.StatusInternalServerError)
@@ -177,6 +474,7 @@ func (h *ETLHandler) LoadData(w http.ResponseWriter, r *http.Request) {
 
 	// Create loader
 	loader := etl.NewDataLoader()
+	loader.SetProject(requestProject(r))
 
 	// Create sample data for loading
 	transformedData := &etl.TransformedData{
@@ -255,7 +553,11 @@ func (h *ETLHandler) CleanupSentiments(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create cleanup service
-	cleanupService := services.NewSentimentCleanupService(database.DB)
+	cleanupService := services.NewSentimentCleanupService(database.DB).ForProject(requestProject(r))
+	if r.URL.Query().Get("restart") == "true" {
+		// Discard the checkpoint of an interrupted run instead of resuming it
+		cleanupService.Restart()
+	}
 
 	// Parse query parameters
 	source := r.URL.Query().Get("source")
@@ -265,7 +567,11 @@ func (h *ETLHandler) CleanupSentiments(w http.ResponseWriter, r *http.Request) {
 	var result *services.CleanupResult
 
 	// Determine cleanup type based on parameters
-	if source != "" {
+	if r.URL.Query().Get("stale_only") == "true" {
+		// Re-score only records produced by an outdated analyzer version
+		log.Printf("🧹 Starting sentiment cleanup for stale analyzer versions")
+		result = cleanupService.CleanStaleSentiments()
+	} else if source != "" {
 		// Clean specific source
 		log.Printf("🧹 Starting sentiment cleanup for source: %s", source)
 		result = cleanupService.CleanSentimentBySource(source)
@@ -301,3 +607,450 @@ func (h *ETLHandler) CleanupSentiments(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(response)
 }
+
+// CleanupRelevance starts a job that re-scores COVID-19 relevance for existing records
+func (h *ETLHandler) CleanupRelevance(w http.ResponseWriter, r *http.Request) {
+	h.startCleanupJob(w, r, services.ReprocessRelevance)
+}
+
+// CleanupLanguage starts a job that re-detects the language of existing records
+func (h *ETLHandler) CleanupLanguage(w http.ResponseWriter, r *http.Request) {
+	h.startCleanupJob(w, r, services.ReprocessLanguage)
+}
+
+// startCleanupJob starts a background reprocessing job scoped by the
+// source, start_date and end_date query parameters
+func (h *ETLHandler) startCleanupJob(w http.ResponseWriter, r *http.Request, jobType string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := database.EnsureConnection(); err != nil {
+		http.Error(w, fmt.Sprintf("Database connection failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	scope := database.CleanupScope{Project: requestProject(r), Source: r.URL.Query().Get("source")}
+	var err error
+	if scope.StartDate, err = parseDateParam(r.URL.Query().Get("start_date"), false); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid start_date format: %v", err), http.StatusBadRequest)
+		return
+	}
+	if scope.EndDate, err = parseDateParam(r.URL.Query().Get("end_date"), true); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid end_date format: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	job, err := services.NewReprocessService(database.DB).StartJob(jobType, scope)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to start %s cleanup: %v", jobType, err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":     "accepted",
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"operation":  jobType + "_cleanup",
+		"job":        job,
+		"status_url": fmt.Sprintf("/api/etl/cleanup/jobs/%d", job.ID),
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetCleanupJobs lists recent cleanup jobs
+func (h *ETLHandler) GetCleanupJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	jobs, err := database.GetCleanupJobs(requestProject(r), limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retrieve cleanup jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"count":     len(jobs),
+		"jobs":      jobs,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetCleanupJob returns the progress of a single cleanup job
+func (h *ETLHandler) GetCleanupJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/etl/cleanup/jobs/"), "/"))
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := database.GetCleanupJob(requestProject(r), id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retrieve cleanup job: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if job == nil {
+		http.Error(w, "Cleanup job not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"job":       job,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListRuns handles GET requests for the project's run history, most recent
+// first, e.g. /api/etl/runs?kind=pipeline&status=error&page=1&per_page=20
+func (h *ETLHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	page, perPage, err := parseRunHistoryPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	runs, total, err := database.GetPipelineRuns(requestProject(r), query.Get("kind"), query.Get("status"), perPage, (page-1)*perPage)
+	if err != nil {
+		http.Error(w, "Failed to read run history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"runs":        runs,
+		"count":       len(runs),
+		"total_count": total,
+		"pagination":  runHistoryPagination(r, page, perPage, total),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// RunRoutes dispatches /api/etl/runs/{batch_id}/... to the run, payload,
+// sample and cancel handlers
+func (h *ETLHandler) RunRoutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/cancel"):
+		h.CancelRun(w, r)
+	case strings.HasSuffix(path, "/sample"):
+		h.GetRunSample(w, r)
+	case strings.HasSuffix(path, "/payload"):
+		h.GetRunPayload(w, r)
+	default:
+		h.GetRun(w, r)
+	}
+}
+
+// GetRun handles GET requests for one recorded run by ID or batch ID, e.g.
+// /api/etl/runs/{batch_id}: its outcome, the stage metrics stored when it
+// ended and the records of the run still stored per source
+func (h *ETLHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/etl/runs/"), "/"), "/")
+	if len(parts) != 1 || parts[0] == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	projectID := requestProject(r)
+	run, err := database.GetPipelineRun(projectID, parts[0])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retrieve run: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if run == nil {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	counts, err := database.GetBatchSourceCounts(projectID, run.BatchID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retrieve run records: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":        "success",
+		"timestamp":     time.Now().Format(time.RFC3339),
+		"run":           run,
+		"source_counts": counts, // records of the run still stored per source
+		"links": map[string]string{
+			"payload": fmt.Sprintf("/api/etl/runs/%s/payload", run.BatchID),
+			"sample":  fmt.Sprintf("/api/etl/runs/%s/sample", run.BatchID),
+		},
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// CancelRun handles POST requests cancelling a pipeline run in progress,
+// e.g. /api/etl/runs/{batch_id}/cancel. The run stops at its next step and
+// loads nothing.
+func (h *ETLHandler) CancelRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/etl/runs/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "cancel" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	batchID := parts[0]
+
+	if !etl.CancelRun(batchID) {
+		http.Error(w, "Run not found or already finished", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"batch_id":  batchID,
+		"message":   "Cancellation requested",
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// progressHeartbeat is how often an idle progress stream sends a comment,
+// keeping proxies from closing the connection
+const progressHeartbeat = 15 * time.Second
+
+// StreamProgress handles GET requests for a server-sent event stream of the
+// progress of the project's runs: each step of a run, such as the extraction
+// of a source or a loaded chunk, arrives as a "progress" event with the
+// overall share done. Events of runs already in progress are sent first.
+// With ?batch_id= the stream follows that run only and ends with its
+// outcome; otherwise it stays open for every run of the project.
+func (h *ETLHandler) StreamProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	projectID := requestProject(r)
+	batchID := r.URL.Query().Get("batch_id")
+
+	// The replay holds the events of every run of the project that has not
+	// ended yet, so it also tells whether the requested run is in progress
+	replay, events, unsubscribe := etl.SubscribeProgress(projectID)
+	defer unsubscribe()
+
+	if batchID != "" && !replaysRun(replay, batchID) {
+		http.Error(w, "Run not found or already finished", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// send writes an event and reports whether the stream should go on
+	send := func(event etl.ProgressEvent) bool {
+		if batchID != "" && event.BatchID != batchID {
+			return true
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return true
+		}
+		fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+		flusher.Flush()
+		return batchID == "" || event.Stage != "run" || event.Status == "started"
+	}
+
+	for _, event := range replay {
+		if !send(event) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(progressHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			if !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// replaysRun reports whether replayed progress events include a run
+func replaysRun(replay []etl.ProgressEvent, batchID string) bool {
+	for _, event := range replay {
+		if event.BatchID == batchID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRunPayload handles GET requests for the raw source payloads of one run,
+// e.g. /api/etl/runs/{batch_id}/payload?source=youtube
+func (h *ETLHandler) GetRunPayload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/etl/runs/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "payload" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	batchID := parts[0]
+
+	rows, err := database.GetRawDataByBatch(requestProject(r), batchID, r.URL.Query().Get("source"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retrieve run payload: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(rows) == 0 {
+		http.Error(w, "Run payload not found", http.StatusNotFound)
+		return
+	}
+
+	payloads := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		payloads = append(payloads, map[string]interface{}{
+			"id":           row.ID,
+			"source":       row.Source,
+			"query":        row.Query,
+			"extracted_at": row.ExtractedAt,
+			"payload":      json.RawMessage(row.RawData),
+		})
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"batch_id":  batchID,
+		"payloads":  payloads,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetRunSample handles GET requests for a random sample of the records one
+// run loaded, e.g. /api/etl/runs/{batch_id}/sample?n=50&source=youtube, for
+// spot-checking extraction and scoring after a run. Each request draws a new
+// sample.
+func (h *ETLHandler) GetRunSample(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/etl/runs/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "sample" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	batchID := parts[0]
+
+	n := 50
+	if nStr := r.URL.Query().Get("n"); nStr != "" {
+		parsed, err := strconv.Atoi(nStr)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			http.Error(w, "n must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+
+	projectID := requestProject(r)
+	counts, err := database.GetBatchSourceCounts(projectID, batchID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retrieve run records: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(counts) == 0 {
+		http.Error(w, "Run records not found", http.StatusNotFound)
+		return
+	}
+
+	sample, err := database.GetBatchSample(projectID, batchID, r.URL.Query().Get("source"), n)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to sample run records: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":        "success",
+		"timestamp":     time.Now().Format(time.RFC3339),
+		"batch_id":      batchID,
+		"source_counts": counts, // records the run loaded per source
+		"sample_size":   len(sample),
+		"sample":        sample,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
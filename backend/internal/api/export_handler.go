@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/export"
+	"covid19-kms/internal/services"
+)
+
+// ExportHandler handles asynchronous export jobs
+type ExportHandler struct {
+	exportService *services.ExportService
+	terms         config.TermsConfig
+	maxRecords    int
+}
+
+// ExportRequest is the body of POST /api/exports
+type ExportRequest struct {
+	Format  string `json:"format"`
+	Filters struct {
+		Query     string `json:"query"`
+		Source    string `json:"source"`
+		Sentiment string `json:"sentiment"`
+		Campaign  string `json:"campaign"`
+		From      string `json:"from"` // YYYY-MM-DD, inclusive
+		To        string `json:"to"`   // YYYY-MM-DD, inclusive
+
+		// ExcludeRestricted drops records whose license prohibits redistribution
+		ExcludeRestricted bool `json:"exclude_restricted"`
+
+		// MinToxicity and MaxToxicity (0.0-1.0) select scored social comments
+		MinToxicity *float64 `json:"min_toxicity"`
+		MaxToxicity *float64 `json:"max_toxicity"`
+	} `json:"filters"`
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler() *ExportHandler {
+	cfg, _ := config.LoadConfig()
+
+	return &ExportHandler{
+		exportService: services.NewExportService(cfg.Export),
+		terms:         cfg.Terms,
+		maxRecords:    cfg.Export.MaxRecords,
+	}
+}
+
+// CreateExport handles POST requests that start a background export job
+func (h *ExportHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Format == "" {
+		req.Format = services.ExportFormatCSV
+	}
+
+	filters := database.ProcessedDataFilter{
+		Project:     requestProject(r),
+		Query:       req.Filters.Query,
+		Source:      req.Filters.Source,
+		Sentiment:   req.Filters.Sentiment,
+		Campaign:    req.Filters.Campaign,
+		MinToxicity: req.Filters.MinToxicity,
+		MaxToxicity: req.Filters.MaxToxicity,
+	}
+	if req.Filters.ExcludeRestricted {
+		filters.ExcludeLicenses = h.terms.NoRedistribution
+	}
+	var err error
+	if filters.From, err = parseDateParam(req.Filters.From, false); err != nil {
+		http.Error(w, "Invalid filters.from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filters.To, err = parseDateParam(req.Filters.To, true); err != nil {
+		http.Error(w, "Invalid filters.to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.exportService.StartExport(strings.ToLower(req.Format), filters)
+	if err != nil {
+		http.Error(w, "Failed to start export: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":     "accepted",
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"job_id":     job.ID,
+		"job":        job,
+		"status_url": "/api/exports/" + job.ID,
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ExportJobRoutes dispatches /api/exports/{id} and /api/exports/{id}/download
+func (h *ExportHandler) ExportJobRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/exports/"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		h.getExportStatus(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "download":
+		h.downloadExport(w, r, parts[0])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// getExportStatus returns job status and, once completed, a time-limited download link
+func (h *ExportHandler) getExportStatus(w http.ResponseWriter, r *http.Request, jobID string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	job, err := database.GetExportJob(jobID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve export job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if job == nil || job.Filters.Project != requestProject(r) {
+		http.Error(w, "Export job not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"job":       job,
+	}
+
+	if job.Status == "completed" {
+		expires, signature := h.exportService.SignDownload(job.ID)
+		response["download_url"] = fmt.Sprintf("/api/exports/%s/download?expires=%d&signature=%s", job.ID, expires, signature)
+		response["download_expires_at"] = time.Unix(expires, 0).Format(time.RFC3339)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// downloadExport streams a completed export file after verifying the signed link
+func (h *ExportHandler) downloadExport(w http.ResponseWriter, r *http.Request, jobID string) {
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid or missing expires parameter", http.StatusBadRequest)
+		return
+	}
+	if err := h.exportService.VerifyDownload(jobID, expires, r.URL.Query().Get("signature")); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	job, err := database.GetExportJob(jobID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve export job: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if job == nil || job.Status != "completed" {
+		http.Error(w, "Export is not available", http.StatusNotFound)
+		return
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		http.Error(w, "Export file is no longer available", http.StatusGone)
+		return
+	}
+	defer file.Close()
+
+	contentType := "text/csv"
+	switch job.Format {
+	case services.ExportFormatJSON:
+		contentType = "application/json"
+	case services.ExportFormatParquet:
+		contentType = parquetContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(job.FilePath)))
+
+	http.ServeContent(w, r, filepath.Base(job.FilePath), job.CreatedAt, file)
+}
+
+// parquetContentType is the media type of Parquet files
+const parquetContentType = "application/vnd.apache.parquet"
+
+// ExportParquet handles GET /api/export/parquet?source=&from=YYYY-MM-DD&to=YYYY-MM-DD
+// and returns the project's matching records, at most EXPORT_MAX_RECORDS, as
+// a Parquet file for pandas or Spark. Dates are read in tz, UTC by default.
+// The file is written before it is sent, so a failed export is an error
+// response rather than a truncated file.
+func (h *ExportHandler) ExportParquet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	zone, err := requestTimeZone(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filters := database.ProcessedDataFilter{
+		Project: requestProject(r),
+		Source:  r.URL.Query().Get("source"),
+	}
+	if filters.From, err = parseDateParamIn(r.URL.Query().Get("from"), false, zone); err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filters.To, err = parseDateParamIn(r.URL.Query().Get("to"), true, zone); err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.CreateTemp("", "export_*.parquet")
+	if err != nil {
+		http.Error(w, "Failed to create export file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	count, err := export.WriteRecordsParquet(file, filters, h.maxRecords)
+	if err != nil {
+		http.Error(w, "Failed to export records: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := "records"
+	if filters.Source != "" {
+		filename += "_" + filters.Source
+	}
+	filename += "_" + time.Now().UTC().Format("20060102T150405Z") + ".parquet"
+	w.Header().Set("Content-Type", parquetContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Record-Count", strconv.Itoa(count))
+
+	http.ServeContent(w, r, filename, time.Now(), file)
+}
+
+// parseDateParam parses a YYYY-MM-DD date. With endOfDay the result is the start
+// of the following day so the date can be used as an exclusive upper bound.
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	return parseDateParamIn(value, endOfDay, time.UTC)
+}
+
+// parseDateParamIn parses a YYYY-MM-DD date like parseDateParam, as the
+// start of that day in zone
+func parseDateParamIn(value string, endOfDay bool, zone *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.ParseInLocation("2006-01-02", value, zone)
+	if err != nil {
+		return nil, fmt.Errorf("expected YYYY-MM-DD, got %q", value)
+	}
+	if endOfDay {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+
+	return &parsed, nil
+}
+
+// requestTimeZone returns the time zone of the tz parameter analytics days
+// are bucketed in: WIB, WITA, WIT or an IANA name, UTC by default
+func requestTimeZone(r *http.Request) (*time.Location, error) {
+	return services.LoadTimeZone(r.URL.Query().Get("tz"))
+}
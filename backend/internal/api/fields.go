@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// requestedFields parses the comma separated fields query parameter,
+// e.g. ?fields=id,title,sentiment. It returns nil when no fields were requested.
+func requestedFields(r *http.Request) []string {
+	var fields []string
+	for _, field := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// selectFields reduces each item to the requested fields. Fields that no item
+// has are rejected so typos do not silently produce empty objects.
+func selectFields(items []map[string]interface{}, fields []string) ([]map[string]interface{}, error) {
+	if len(fields) == 0 {
+		return items, nil
+	}
+
+	if len(items) > 0 {
+		for _, field := range fields {
+			if !anyItemHasField(items, field) {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+		}
+	}
+
+	selected := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		reduced := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if value, ok := item[field]; ok {
+				reduced[field] = value
+			}
+		}
+		selected = append(selected, reduced)
+	}
+
+	return selected, nil
+}
+
+// anyItemHasField reports whether at least one item has the field
+func anyItemHasField(items []map[string]interface{}, field string) bool {
+	for _, item := range items {
+		if _, ok := item[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeListResponse applies the fields parameter to response["data"] and
+// writes the response, rejecting it when it is larger than maxBytes
+func writeListResponse(w http.ResponseWriter, r *http.Request, response map[string]interface{}, maxBytes int) {
+	if items, ok := response["data"].([]map[string]interface{}); ok {
+		selected, err := selectFields(items, requestedFields(r))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid fields parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+		response["data"] = selected
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	if maxBytes > 0 && len(jsonData) > maxBytes {
+		http.Error(w, fmt.Sprintf("Response of %d bytes exceeds the %d byte limit; request fewer fields with ?fields=id,title,sentiment and fetch full records from /api/etl/data/record/{id}", len(jsonData), maxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	w.Write(append(jsonData, '\n'))
+}
@@ -0,0 +1,362 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/graphql"
+	"covid19-kms/internal/services"
+)
+
+// maxGraphQLRecords caps the limit argument of the records query
+const maxGraphQLRecords = 500
+
+// GraphQLHandler serves read-only GraphQL queries over the processed
+// records, sentiment aggregates and sources of the caller's project
+type GraphQLHandler struct {
+	schema *graphql.Schema
+}
+
+// NewGraphQLHandler creates a new GraphQL handler resolving through store
+func NewGraphQLHandler(store database.Store) *GraphQLHandler {
+	return &GraphQLHandler{schema: knowledgeSchema(store)}
+}
+
+// ServeGraphQL handles POST requests with a JSON body {query, operationName,
+// variables} and GET requests with the same fields as query parameters.
+// A GET request without a query returns the schema in SDL.
+func (h *GraphQLHandler) ServeGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		if req.Query == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, h.schema.SDL())
+			return
+		}
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				http.Error(w, "Invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	response := h.schema.Execute(r.Context(), req)
+	if response.Data == nil && len(response.Errors) > 0 {
+		// The query could not run at all: a syntax or validation error
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// recordConnection is a page of the records query; the total count is only
+// queried when it is selected. Resolvers return it by value, as the executor
+// passes child resolvers the value behind a pointer.
+type recordConnection struct {
+	filter database.ProcessedDataFilter
+	limit  int
+	offset int
+}
+
+// knowledgeSchema builds the GraphQL schema of the knowledge base over store
+func knowledgeSchema(store database.Store) *graphql.Schema {
+	record := &graphql.Object{
+		Name:        "Record",
+		Description: "A processed record: a video, article, post or comment",
+		Fields: []graphql.Field{
+			{Name: "id", Type: "Int!"},
+			{Name: "source", Type: "String!", Description: "Canonical source, see sources"},
+			{Name: "outlet", Type: "String", Description: "Publisher, channel or account within the source"},
+			{Name: "title", Type: "String!"},
+			{Name: "content", Type: "String!"},
+			{Name: "sentiment", Type: "String", Description: "positive, negative or neutral"},
+			{Name: "sentimentScore", Type: "Float"},
+			{Name: "relevanceScore", Type: "Float!"},
+			{Name: "publishedAt", Type: "String", Description: "RFC 3339, null when the source has no date"},
+			{Name: "processedAt", Type: "String!", Description: "RFC 3339"},
+			{Name: "campaign", Type: "String", Description: "Campaign whose query extracted the record"},
+			{Name: "regionCode", Type: "String", Description: "ISO 3166-2 code of the province"},
+		},
+	}
+
+	connection := &graphql.Object{
+		Name:        "RecordConnection",
+		Description: "A page of records, newest first",
+		Fields: []graphql.Field{
+			{
+				Name:        "totalCount",
+				Type:        "Int!",
+				Description: "Number of records matching the filters across all pages",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					return store.CountProcessedData(ctx, source.(recordConnection).filter)
+				},
+			},
+			{
+				Name: "records",
+				Type: "[Record!]!",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					page := source.(recordConnection)
+					records, err := store.QueryProcessedDataPage(ctx, page.filter, page.limit, page.offset)
+					if err != nil {
+						return nil, err
+					}
+					return graphqlRecords(records), nil
+				},
+			},
+		},
+	}
+
+	counts := &graphql.Object{
+		Name:        "SentimentCounts",
+		Description: "Records per sentiment with percentage shares",
+		Fields: []graphql.Field{
+			{Name: "source", Type: "String", Description: "Null for the overall counts"},
+			{Name: "positive", Type: "Int!"},
+			{Name: "negative", Type: "Int!"},
+			{Name: "neutral", Type: "Int!"},
+			{Name: "total", Type: "Int!"},
+			{Name: "positivePct", Type: "Float!"},
+			{Name: "negativePct", Type: "Float!"},
+			{Name: "neutralPct", Type: "Float!"},
+		},
+	}
+
+	summary := &graphql.Object{
+		Name:        "SentimentSummary",
+		Description: "Sentiment counts overall and per source",
+		Fields: []graphql.Field{
+			{Name: "overall", Type: "SentimentCounts!"},
+			{Name: "bySource", Type: "[SentimentCounts!]!"},
+			{Name: "analyzerVersion", Type: "Int!", Description: "Version of the sentiment lexicons in use"},
+		},
+	}
+
+	source := &graphql.Object{
+		Name:        "Source",
+		Description: "A canonical source of the taxonomy",
+		Fields: []graphql.Field{
+			{Name: "name", Type: "String!"},
+			{Name: "label", Type: "String!"},
+			{Name: "kind", Type: "String!", Description: "video, news, social or other"},
+			{Name: "aliases", Type: "[String!]!", Description: "Spellings mapped to the source"},
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []graphql.Field{
+			{
+				Name:        "records",
+				Type:        "RecordConnection!",
+				Description: "Records of the project matching every given filter, newest first",
+				Args: []graphql.Argument{
+					{Name: "search", Type: "String", Description: "Terms that must all occur in the title or content"},
+					{Name: "source", Type: "String"},
+					{Name: "sentiment", Type: "String"},
+					{Name: "campaign", Type: "String"},
+					{Name: "from", Type: "String", Description: "YYYY-MM-DD"},
+					{Name: "to", Type: "String", Description: "YYYY-MM-DD, inclusive"},
+					{Name: "limit", Type: "Int", Default: 50},
+					{Name: "offset", Type: "Int", Default: 0},
+				},
+				Resolve: resolveRecords,
+			},
+			{
+				Name:        "record",
+				Type:        "Record",
+				Description: "A record of the project by ID",
+				Args:        []graphql.Argument{{Name: "id", Type: "Int!"}},
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					record, err := store.GetProcessedDataByID(ctx, contextProject(ctx), args["id"].(int))
+					if err != nil || record == nil {
+						return nil, err
+					}
+					return graphqlRecord(*record), nil
+				},
+			},
+			{
+				Name:        "sentiment",
+				Type:        "SentimentSummary!",
+				Description: "Sentiment counts of the project's records",
+				Args: []graphql.Argument{
+					{Name: "source", Type: "String"},
+					{Name: "from", Type: "String", Description: "YYYY-MM-DD"},
+					{Name: "to", Type: "String", Description: "YYYY-MM-DD, inclusive"},
+					{Name: "excludeSarcastic", Type: "Boolean", Default: false},
+					{Name: "includeDuplicates", Type: "Boolean", Default: false},
+				},
+				Resolve: sentimentResolver(store),
+			},
+			{
+				Name:        "sources",
+				Type:        "[Source!]!",
+				Description: "The canonical sources of the taxonomy",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					sources, err := store.GetSources(ctx)
+					if err != nil {
+						return nil, err
+					}
+					results := make([]map[string]interface{}, 0, len(sources))
+					for _, s := range sources {
+						aliases := s.Aliases
+						if aliases == nil {
+							aliases = []string{}
+						}
+						results = append(results, map[string]interface{}{
+							"name":    s.Name,
+							"label":   s.Label,
+							"kind":    s.Kind,
+							"aliases": aliases,
+						})
+					}
+					return results, nil
+				},
+			},
+		},
+	}
+
+	return graphql.MustSchema(query, connection, record, summary, counts, source)
+}
+
+// resolveRecords checks the arguments of the records query; the page's
+// records and total count are queried when they are selected
+func resolveRecords(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	filter := database.ProcessedDataFilter{Project: contextProject(ctx)}
+	filter.Query, _ = args["search"].(string)
+	filter.Source, _ = args["source"].(string)
+	filter.Sentiment, _ = args["sentiment"].(string)
+	filter.Campaign, _ = args["campaign"].(string)
+
+	var err error
+	if filter.From, filter.To, err = graphqlDateRange(args); err != nil {
+		return nil, err
+	}
+
+	limit, _ := args["limit"].(int)
+	if limit <= 0 || limit > maxGraphQLRecords {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxGraphQLRecords)
+	}
+	offset, _ := args["offset"].(int)
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+
+	return recordConnection{filter: filter, limit: limit, offset: offset}, nil
+}
+
+// sentimentResolver returns the resolver of the sentiment counts like
+// /api/analytics/sentiment
+func sentimentResolver(analytics database.AnalyticsRepo) graphql.ResolveFunc {
+	return func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		from, to, err := graphqlDateRange(args)
+		if err != nil {
+			return nil, err
+		}
+
+		sourceName, _ := args["source"].(string)
+		excludeSarcastic, _ := args["excludeSarcastic"].(bool)
+		includeDuplicates, _ := args["includeDuplicates"].(bool)
+		overall, bySource, err := analytics.GetSentimentCounts(ctx, contextProject(ctx), sourceName, from, to, excludeSarcastic, includeDuplicates)
+		if err != nil {
+			return nil, err
+		}
+
+		sources := make([]map[string]interface{}, 0, len(bySource))
+		for _, counts := range bySource {
+			sources = append(sources, graphqlSentimentCounts(counts))
+		}
+		return map[string]interface{}{
+			"overall":         graphqlSentimentCounts(overall),
+			"bySource":        sources,
+			"analyzerVersion": services.SentimentAnalyzerVersion,
+		}, nil
+	}
+}
+
+// graphqlDateRange parses the from and to arguments of a query
+func graphqlDateRange(args map[string]interface{}) (*time.Time, *time.Time, error) {
+	fromValue, _ := args["from"].(string)
+	toValue, _ := args["to"].(string)
+
+	from, err := parseDateParam(fromValue, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid from: %v", err)
+	}
+	to, err := parseDateParam(toValue, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid to: %v", err)
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, fmt.Errorf("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// graphqlRecords converts records to the fields of the Record type
+func graphqlRecords(records []database.ProcessedData) []map[string]interface{} {
+	results := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		results = append(results, graphqlRecord(record))
+	}
+	return results
+}
+
+func graphqlRecord(record database.ProcessedData) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":             record.ID,
+		"source":         record.Source,
+		"title":          record.Title,
+		"content":        record.Content,
+		"relevanceScore": record.RelevanceScore,
+		"sentimentScore": record.SentimentScore,
+		"processedAt":    record.ProcessedAt.Format(time.RFC3339),
+	}
+	optional := map[string]string{
+		"outlet":     record.Outlet,
+		"sentiment":  record.Sentiment,
+		"campaign":   record.Campaign,
+		"regionCode": record.RegionCode,
+	}
+	for field, value := range optional {
+		if value != "" {
+			result[field] = value
+		}
+	}
+	if record.PublishedAt != nil {
+		result["publishedAt"] = record.PublishedAt.Format(time.RFC3339)
+	}
+	return result
+}
+
+func graphqlSentimentCounts(counts database.SentimentCounts) map[string]interface{} {
+	result := map[string]interface{}{
+		"positive":    counts.Positive,
+		"negative":    counts.Negative,
+		"neutral":     counts.Neutral,
+		"total":       counts.Total,
+		"positivePct": counts.PositivePct,
+		"negativePct": counts.NegativePct,
+		"neutralPct":  counts.NeutralPct,
+	}
+	if counts.Source != "" {
+		result["source"] = counts.Source
+	}
+	return result
+}
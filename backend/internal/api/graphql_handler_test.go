@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"covid19-kms/database"
+)
+
+func TestGraphQLSchema(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewGraphQLHandler(database.NewMemoryStore()).ServeGraphQL(rec, httptest.NewRequest(http.MethodGet, "/api/graphql", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	for _, expected := range []string{
+		"records(search: String, source: String, sentiment: String, campaign: String, from: String, to: String, limit: Int = 50, offset: Int = 0): RecordConnection!",
+		"record(id: Int!): Record",
+		"sentiment(source: String, from: String, to: String, excludeSarcastic: Boolean = false, includeDuplicates: Boolean = false): SentimentSummary!",
+		"sources: [Source!]!",
+	} {
+		if !strings.Contains(rec.Body.String(), expected) {
+			t.Errorf("Expected the schema to contain %q", expected)
+		}
+	}
+}
+
+func TestGraphQLRejectsInvalidQueries(t *testing.T) {
+	body := `{"query": "{ records(limit: 10) { records { id password } } }"}`
+	rec := httptest.NewRecorder()
+	NewGraphQLHandler(database.NewMemoryStore()).ServeGraphQL(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", rec.Code)
+	}
+	var response struct {
+		Data   interface{}
+		Errors []struct{ Message string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode the response: %v", err)
+	}
+	if response.Data != nil || len(response.Errors) != 1 || !strings.Contains(response.Errors[0].Message, `"password" on type Record`) {
+		t.Errorf("Expected a validation error and no data, got %+v", response)
+	}
+}
+
+func TestGraphQLRecordFromStore(t *testing.T) {
+	body := `{"query": "{ record(id: 2) { id title sentiment } }"}`
+	rec := httptest.NewRecorder()
+	NewGraphQLHandler(testStore(t)).ServeGraphQL(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"title":"Kasus naik lagi"`) {
+		t.Errorf("Expected record 2 in the response, got %s", rec.Body.String())
+	}
+}
+
+func TestGraphQLRecordsFromStore(t *testing.T) {
+	body := `{"query": "{ records(source: \"youtube\", limit: 1) { totalCount records { title } } }"}`
+	rec := httptest.NewRecorder()
+	NewGraphQLHandler(testStore(t)).ServeGraphQL(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+
+	if !strings.Contains(rec.Body.String(), `"totalCount":2`) || !strings.Contains(rec.Body.String(), `"title":"Vaksin gratis"`) {
+		t.Errorf("Expected the newest of 2 youtube records, got %s", rec.Body.String())
+	}
+}
+
+func TestGraphQLSourcesFromStore(t *testing.T) {
+	body := `{"query": "{ sources { name aliases } }"}`
+	rec := httptest.NewRecorder()
+	NewGraphQLHandler(database.NewMemoryStore()).ServeGraphQL(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(body)))
+
+	if !strings.Contains(rec.Body.String(), `{"name":"indonesia_news","aliases":["cnn","cnn indonesia","detik","indonesia news","indonesia_news","kompas","tempo"]}`) {
+		t.Errorf("Expected the default taxonomy, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// apiParam is a query parameter of an API operation
+type apiParam struct {
+	Name        string
+	Type        string // OpenAPI schema type
+	Description string
+}
+
+// apiOperation documents one method of one endpoint. The table below drives
+// the OpenAPI specification at /api/openapi.json and the endpoint list of /api.
+type apiOperation struct {
+	Method   string
+	Path     string // OpenAPI path template, e.g. /api/etl/runs/{id}
+	Tag      string
+	Summary  string
+	Query    []apiParam
+	Body     string // fields of the JSON request body; empty when there is none
+	Status   int    // status of a successful response; 200 when zero
+	Response string
+	Produces string // content type of a successful response; JSON when empty
+	Admin    bool   // requires the admin API key or an admin user
+	Open     bool   // served without credentials
+}
+
+// Query parameters shared by several operations
+var (
+	fromParam     = apiParam{"from", "string", "First day, YYYY-MM-DD"}
+	toParam       = apiParam{"to", "string", "Last day, YYYY-MM-DD"}
+	sourceParam   = apiParam{"source", "string", "Canonical source, e.g. youtube or google_news"}
+	limitParam    = apiParam{"limit", "integer", "Maximum number of results"}
+	pageParam     = apiParam{"page", "integer", "Page number, starting at 1"}
+	perPageParam  = apiParam{"per_page", "integer", "Results per page"}
+	sarcasmParam  = apiParam{"exclude_sarcastic", "boolean", "Leave out records flagged as sarcastic"}
+	listParams    = []apiParam{pageParam, perPageParam, {"cursor", "string", "Continue after the last record of the previous page"}, {"fields", "string", "Comma-separated fields to return"}, {"sentiment", "string", "positive, negative or neutral"}, {"language", "string", "Detected language, e.g. id or en"}, {"campaign", "string", "Campaign name"}, {"hashtag", "string", "Instagram hashtag the records were found under, without #"}, {"min_relevance", "number", "Lowest relevance score"}, {"include_duplicates", "boolean", "Include records grouped under another record"}, fromParam, toParam}
+	dateParams    = []apiParam{fromParam, toParam}
+	cleanupParams = []apiParam{sourceParam, {"start_date", "string", "First day, YYYY-MM-DD"}, {"end_date", "string", "Last day, YYYY-MM-DD"}}
+)
+
+// apiTags describe the groups of the OpenAPI specification, in display order
+var apiTags = []struct{ Name, Description string }{
+	{"info", "API information and health checks"},
+	{"etl", "Pipeline runs, run history and cleanups"},
+	{"data", "Processed records, tags and notes"},
+	{"analytics", "Aggregations for the dashboard and reports"},
+	{"statistics", "Official COVID-19 figures and search interest"},
+	{"methodology", "Keywords, lexicons and sources behind the analyses"},
+	{"search", "Keyword, semantic and saved searches"},
+	{"graphql", "GraphQL queries over records, sentiment aggregates and sources"},
+	{"export", "Export jobs and file downloads"},
+	{"collections", "Curated record collections"},
+	{"auth", "User login and tokens"},
+	{"admin", "Administration (admin API key or admin user)"},
+}
+
+// apiOperations lists every endpoint of the router
+var apiOperations = []apiOperation{
+	{Method: "GET", Path: "/", Tag: "info", Summary: "Service information and the main endpoints", Response: "Service name, version and endpoint URLs", Open: true},
+	{Method: "GET", Path: "/api", Tag: "info", Summary: "API information and the list of endpoints", Response: "API details, authentication, rate limit and endpoints by group", Open: true},
+	{Method: "GET", Path: "/api/openapi.json", Tag: "info", Summary: "OpenAPI 3 specification of the API", Response: "This specification", Open: true},
+	{Method: "GET", Path: "/api/docs", Tag: "info", Summary: "Swagger UI for the OpenAPI specification", Response: "HTML page", Produces: "text/html", Open: true},
+	{Method: "GET", Path: "/api/health", Tag: "info", Summary: "Health check for monitoring", Response: "Health status of the service and database", Open: true},
+	{Method: "GET", Path: "/health", Tag: "info", Summary: "Health check for monitoring", Response: "Health status of the service and database", Open: true},
+
+	{Method: "POST", Path: "/api/etl/run", Tag: "etl", Summary: "Run the complete ETL pipeline for the project", Query: []apiParam{{"sources", "string", "Comma-separated sources to extract instead of the project's, e.g. youtube,google_news"}, {"skip", "string", "Comma-separated sources to leave out of this run"}}, Response: "ETLResult with pipeline execution details"},
+	{Method: "POST", Path: "/api/etl/crawl", Tag: "etl", Summary: "Backfill archived articles by crawling outlet sitemaps", Query: []apiParam{{"outlet", "string", "Comma-separated outlets, e.g. Kompas,Tempo"}, fromParam, toParam, {"max", "integer", "Maximum number of articles"}}, Response: "ETLResult of the crawl"},
+	{Method: "POST", Path: "/api/etl/reprocess", Tag: "etl", Summary: "Transform and load stored raw data again", Query: []apiParam{sourceParam, fromParam, toParam}, Response: "ETLResult of the reprocessing"},
+	{Method: "POST", Path: "/api/etl/deadletter/retry", Tag: "etl", Summary: "Load the records whose load failed again", Query: []apiParam{limitParam}, Response: "Counts of retried, loaded and still failing records and the records left in the queue"},
+	{Method: "GET", Path: "/api/etl/status", Tag: "etl", Summary: "Pipeline status, the latest run and the run history", Query: []apiParam{pageParam, perPageParam}, Response: "Pipeline status, latest run and a page of past runs with their stage metrics"},
+	{Method: "GET", Path: "/api/etl/stream", Tag: "etl", Summary: "Server-sent event stream of the progress of the project's runs", Query: []apiParam{{"batch_id", "string", "Follow one run in progress; the stream ends with its outcome"}}, Response: "\"progress\" events: batch_id, stage, status, source, count and the share of the run done", Produces: "text/event-stream"},
+	{Method: "POST", Path: "/api/etl/extract", Tag: "etl", Summary: "Run only the data extraction stage", Response: "ExtractedData with raw data from all sources"},
+	{Method: "POST", Path: "/api/etl/transform", Tag: "etl", Summary: "Run only the data transformation stage", Response: "TransformedData with cleaned and enriched data"},
+	{Method: "POST", Path: "/api/etl/load", Tag: "etl", Summary: "Run only the data loading stage", Response: "LoadResult with loading operation details"},
+	{Method: "POST", Path: "/api/etl/cleanup/sentiment", Tag: "etl", Summary: "Re-score the sentiment of stored records in a background job", Query: append(cleanupParams, apiParam{"stale_only", "boolean", "Only records scored by an older analyzer version"}, apiParam{"restart", "boolean", "Start over instead of resuming an interrupted job"}), Status: http.StatusAccepted, Response: "The started cleanup job"},
+	{Method: "POST", Path: "/api/etl/cleanup/relevance", Tag: "etl", Summary: "Soft-delete stored records that are no longer relevant in a background job", Query: cleanupParams, Status: http.StatusAccepted, Response: "The started cleanup job"},
+	{Method: "POST", Path: "/api/etl/cleanup/language", Tag: "etl", Summary: "Detect the language of stored records again in a background job", Query: cleanupParams, Status: http.StatusAccepted, Response: "The started cleanup job"},
+	{Method: "GET", Path: "/api/etl/cleanup/jobs", Tag: "etl", Summary: "List cleanup jobs, newest first", Query: []apiParam{limitParam}, Response: "Cleanup jobs with their progress"},
+	{Method: "GET", Path: "/api/etl/cleanup/jobs/{id}", Tag: "etl", Summary: "Get one cleanup job", Response: "The cleanup job and its progress"},
+	{Method: "GET", Path: "/api/etl/quota", Tag: "etl", Summary: "Get the API requests of each source today and this month against its quota", Response: "Daily and monthly calls and limits of each source and whether its quota is used up"},
+	{Method: "GET", Path: "/api/etl/runs", Tag: "etl", Summary: "List the recorded pipeline, crawl and reprocess runs, most recent first", Query: []apiParam{{"kind", "string", "pipeline, crawl or reprocess"}, {"status", "string", "running, success or error"}, pageParam, perPageParam}, Response: "Runs with their outcome and stage metrics, and pagination links"},
+	{Method: "GET", Path: "/api/etl/runs/{id}", Tag: "etl", Summary: "Get one recorded run by ID or batch ID", Response: "The run, its stored records per source and links to its payload and sample"},
+	{Method: "GET", Path: "/api/etl/runs/{id}/payload", Tag: "etl", Summary: "Get the raw source payloads stored by a pipeline run", Query: []apiParam{sourceParam}, Response: "Raw payloads of the run, optionally limited to one source"},
+	{Method: "GET", Path: "/api/etl/runs/{id}/sample", Tag: "etl", Summary: "Get a sample of the records loaded by a run", Query: []apiParam{sourceParam, {"n", "integer", "Number of records"}}, Response: "Sampled records of the run"},
+	{Method: "POST", Path: "/api/etl/runs/{id}/cancel", Tag: "etl", Summary: "Cancel a pipeline run in progress", Response: "Confirmation that cancellation was requested"},
+
+	{Method: "GET", Path: "/api/etl/data", Tag: "data", Summary: "List the latest processed records", Query: listParams, Response: "Records, newest first, with pagination"},
+	{Method: "GET", Path: "/api/etl/data/source", Tag: "data", Summary: "List the processed records of one source", Query: append([]apiParam{sourceParam}, listParams...), Response: "Records of the source, newest first, with pagination"},
+	{Method: "GET", Path: "/api/etl/data/youtube", Tag: "data", Summary: "List YouTube comments", Query: listParams, Response: "YouTube records with pagination"},
+	{Method: "GET", Path: "/api/etl/data/google-news", Tag: "data", Summary: "List Google News articles", Query: listParams, Response: "Google News records with pagination"},
+	{Method: "GET", Path: "/api/etl/data/instagram", Tag: "data", Summary: "List Instagram posts", Query: listParams, Response: "Instagram records with pagination"},
+	{Method: "GET", Path: "/api/etl/data/indonesia-news", Tag: "data", Summary: "List Indonesian news articles", Query: append([]apiParam{{"refresh", "boolean", "Fetch fresh articles from the API instead of the database"}}, listParams...), Response: "Indonesia News records with pagination"},
+	{Method: "GET", Path: "/api/etl/data/stats", Tag: "data", Summary: "Database statistics", Response: "Record counts per table and source"},
+	{Method: "GET", Path: "/api/etl/data/summary", Tag: "data", Summary: "Overall data summary", Response: "Record counts, sentiment and date range per source"},
+	{Method: "GET", Path: "/api/etl/data/duplicates", Tag: "data", Summary: "List duplicate groups of articles", Query: []apiParam{limitParam}, Response: "Canonical records with their duplicates"},
+	{Method: "GET", Path: "/api/etl/data/record/{id}", Tag: "data", Summary: "Get one record with its full processed data", Response: "The record"},
+	{Method: "GET", Path: "/api/etl/data/{id}/related", Tag: "data", Summary: "Records related to a record", Query: []apiParam{limitParam}, Response: "Related records with their similarity"},
+	{Method: "GET", Path: "/api/etl/data/{id}/versions", Tag: "data", Summary: "Stored versions of an edited article", Response: "Versions of the record, oldest first"},
+	{Method: "GET", Path: "/api/etl/data/{id}/notes", Tag: "data", Summary: "List the analyst notes on a record", Response: "Notes, oldest first"},
+	{Method: "POST", Path: "/api/etl/data/{id}/notes", Tag: "data", Summary: "Add an analyst note to a record", Body: "{body}", Status: http.StatusCreated, Response: "The new note"},
+	{Method: "GET", Path: "/api/etl/data/{id}/tags", Tag: "data", Summary: "List the manual and automatic tags of a record", Response: "Tags of the record"},
+	{Method: "POST", Path: "/api/etl/data/{id}/tags", Tag: "data", Summary: "Add manual tags to a record", Body: "{tags}", Response: "Tags of the record"},
+	{Method: "DELETE", Path: "/api/etl/data/{id}/tags/{tag}", Tag: "data", Summary: "Remove a manual tag from a record", Response: "Tags of the record"},
+
+	{Method: "GET", Path: "/api/etl/data/sentiment-distribution", Tag: "analytics", Summary: "Sentiment distribution across all sources", Query: []apiParam{sarcasmParam, {"min_analyzer_version", "integer", "Only records scored by this analyzer version or later"}}, Response: "Sentiment counts per source"},
+	{Method: "GET", Path: "/api/etl/data/word-frequency", Tag: "analytics", Summary: "Word frequency across all sources", Response: "Most frequent words"},
+	{Method: "GET", Path: "/api/etl/data/trends", Tag: "analytics", Summary: "Daily trends from the rollup tables", Query: []apiParam{{"days", "integer", "Number of days"}, {"dimension", "string", "source, sentiment or topic"}}, Response: "Daily counts per dimension value"},
+	{Method: "GET", Path: "/api/analytics/aspect-sentiment", Tag: "analytics", Summary: "Sentiment per aspect (government, vaccines, economy)", Query: []apiParam{sourceParam, sarcasmParam}, Response: "Sentiment counts per aspect"},
+	{Method: "GET", Path: "/api/analytics/tags", Tag: "analytics", Summary: "Records per manual and automatic tag", Query: []apiParam{sourceParam}, Response: "Tag counts"},
+	{Method: "GET", Path: "/api/analytics/entities", Tag: "analytics", Summary: "People, places and organizations by the number of records mentioning them", Query: []apiParam{{"type", "string", "person, place or organization"}, sourceParam, fromParam, toParam, limitParam}, Response: "Entities with their record counts"},
+	{Method: "GET", Path: "/api/analytics/geo", Tag: "analytics", Summary: "Records, sentiment and average score per province", Query: []apiParam{sourceParam, fromParam, toParam}, Response: "Provinces with their ISO 3166-2 codes, and the unlocated count"},
+	{Method: "GET", Path: "/api/analytics/timeseries", Tag: "analytics", Summary: "Record counts and average sentiment per interval", Query: []apiParam{{"interval", "string", "day, week or month"}, sourceParam, fromParam, toParam}, Response: "Time series points"},
+	{Method: "GET", Path: "/api/analytics/trending", Tag: "analytics", Summary: "Terms rising and falling most recently", Query: []apiParam{sourceParam}, Response: "Rising and falling terms"},
+	{Method: "GET", Path: "/api/analytics/sentiment", Tag: "analytics", Summary: "Records per sentiment with percentages", Query: []apiParam{sourceParam, fromParam, toParam, sarcasmParam}, Response: "Sentiment counts and percentages"},
+	{Method: "GET", Path: "/api/analytics/wordcloud", Tag: "analytics", Summary: "Most frequent words for a word cloud", Query: []apiParam{sourceParam, {"sentiment", "string", "positive, negative or neutral"}, {"top", "integer", "Number of words"}}, Response: "Words with their counts"},
+	{Method: "GET", Path: "/api/analytics/export/{report}", Tag: "analytics", Summary: "Download trends, word frequency or the source comparison as CSV", Query: []apiParam{{"tz", "string", "Time zone of the dates, e.g. WIB"}}, Response: "CSV file", Produces: "text/csv"},
+	{Method: "GET", Path: "/api/digest", Tag: "analytics", Summary: "Daily digest of the records of a day", Query: []apiParam{{"date", "string", "Day, YYYY-MM-DD"}, {"tz", "string", "Time zone of the day, e.g. WIB"}}, Response: "Counts, sentiment and top records of the day"},
+
+	{Method: "GET", Path: "/api/statistics/covid", Tag: "statistics", Summary: "Official COVID-19 statistics", Query: dateParams, Response: "Daily cases, deaths and vaccinations"},
+	{Method: "GET", Path: "/api/statistics/covid/sentiment", Tag: "statistics", Summary: "Correlation of sentiment with case numbers", Query: []apiParam{{"days", "integer", "Number of days"}}, Response: "Daily figures with Pearson correlations"},
+	{Method: "GET", Path: "/api/statistics/search-interest", Tag: "statistics", Summary: "Google Trends search interest of the configured terms", Query: dateParams, Response: "Daily interest per term"},
+
+	{Method: "GET", Path: "/api/sources", Tag: "methodology", Summary: "Canonical sources with their aliases", Response: "Sources"},
+	{Method: "GET", Path: "/api/methodology", Tag: "methodology", Summary: "Keywords, lexicons, stop words and topics in use", Query: dateParams, Response: "Versioned methodology with its checksum"},
+	{Method: "GET", Path: "/api/methodology/{section}", Tag: "methodology", Summary: "One section of the methodology: relevance, sentiment, stopwords or topics", Response: "The methodology section"},
+
+	{Method: "GET", Path: "/api/search", Tag: "search", Summary: "Keyword search over processed records (all terms must match)", Query: []apiParam{{"q", "string", "Search terms"}, sourceParam, {"sentiment", "string", "positive, negative or neutral"}, {"campaign", "string", "Campaign name"}, {"min_toxicity", "number", "Lowest toxicity score"}, {"max_toxicity", "number", "Highest toxicity score"}, limitParam}, Response: "Matching records, newest first"},
+	{Method: "GET", Path: "/api/search/semantic", Tag: "search", Summary: "Semantic search by embedding similarity", Query: []apiParam{{"q", "string", "Query text"}, sourceParam, limitParam}, Response: "Most similar records"},
+	{Method: "GET", Path: "/api/searches", Tag: "search", Summary: "List the saved searches of the calling user", Response: "Saved searches"},
+	{Method: "POST", Path: "/api/searches", Tag: "search", Summary: "Save a search; new matches are notified by email or webhook after each load", Body: "{name, query, source, sentiment, notify_email, webhook_url}", Status: http.StatusCreated, Response: "The saved search"},
+	{Method: "DELETE", Path: "/api/searches", Tag: "search", Summary: "Delete a saved search", Query: []apiParam{{"id", "integer", "Saved search ID"}}, Response: "The deleted saved search ID"},
+
+	{Method: "GET", Path: "/api/graphql", Tag: "graphql", Summary: "Run a GraphQL query given as query parameters, or get the schema in SDL without one", Query: []apiParam{{"query", "string", "GraphQL query"}, {"operationName", "string", "Operation to run when the query has several"}, {"variables", "string", "JSON object of variable values"}}, Response: "GraphQL response {data, errors}, or the schema as text"},
+	{Method: "POST", Path: "/api/graphql", Tag: "graphql", Summary: "Run a GraphQL query over records, sentiment aggregates and sources", Body: "{query, operationName, variables}", Response: "GraphQL response {data, errors}"},
+
+	{Method: "POST", Path: "/api/exports", Tag: "export", Summary: "Start a background export of processed data", Body: "{format: csv|json|parquet, filters: {query, source, sentiment, campaign, from, to, exclude_restricted, min_toxicity, max_toxicity}}", Status: http.StatusAccepted, Response: "Job ID and status URL"},
+	{Method: "GET", Path: "/api/exports/{id}", Tag: "export", Summary: "Export job status with a time-limited download link once completed", Response: "Job status, download_url and download_expires_at"},
+	{Method: "GET", Path: "/api/exports/{id}/download", Tag: "export", Summary: "Download a completed export through its signed link", Query: []apiParam{{"expires", "integer", "Expiry of the link, Unix seconds"}, {"signature", "string", "Signature of the link"}}, Response: "Export file", Produces: "application/octet-stream"},
+	{Method: "GET", Path: "/api/export/parquet", Tag: "export", Summary: "Download matching processed records as a Parquet file", Query: []apiParam{sourceParam, fromParam, toParam}, Response: "Parquet file, with the record count in X-Record-Count", Produces: "application/vnd.apache.parquet"},
+
+	{Method: "GET", Path: "/api/collections", Tag: "collections", Summary: "List the collections of the calling user", Response: "Collections with item counts"},
+	{Method: "POST", Path: "/api/collections", Tag: "collections", Summary: "Create a collection of records curated as evidence", Body: "{name, description}", Status: http.StatusCreated, Response: "The new collection"},
+	{Method: "GET", Path: "/api/collections/{id}", Tag: "collections", Summary: "Get a collection with its records and annotations", Response: "Collection and its items in the order they were added"},
+	{Method: "DELETE", Path: "/api/collections/{id}", Tag: "collections", Summary: "Delete a collection", Response: "The deleted collection ID"},
+	{Method: "POST", Path: "/api/collections/{id}/items", Tag: "collections", Summary: "Add a record with an optional annotation; adding it again updates the annotation", Body: "{record_id, annotation}", Response: "Collection and record IDs"},
+	{Method: "DELETE", Path: "/api/collections/{id}/items/{record_id}", Tag: "collections", Summary: "Remove a record from a collection", Response: "Collection and record IDs"},
+	{Method: "GET", Path: "/api/collections/{id}/export", Tag: "collections", Summary: "Download a collection with its annotations", Query: []apiParam{{"format", "string", "csv or pdf"}}, Response: "CSV file or printable PDF report", Produces: "text/csv"},
+
+	{Method: "POST", Path: "/api/auth/login", Tag: "auth", Summary: "Log a dashboard user in (requires AUTH_JWT_SECRET)", Body: "{username, password}", Response: "User and token pair with access and refresh token expiry in seconds", Open: true},
+	{Method: "POST", Path: "/api/auth/refresh", Tag: "auth", Summary: "Exchange a refresh token for a new token pair", Body: "{refresh_token}", Response: "User and new token pair", Open: true},
+	{Method: "GET", Path: "/api/auth/me", Tag: "auth", Summary: "User, role, project and expiry of the access token sent", Response: "Token user details"},
+
+	{Method: "GET", Path: "/api/admin/backups", Tag: "admin", Summary: "List the backup history", Query: []apiParam{limitParam}, Response: "Backups, newest first", Admin: true},
+	{Method: "POST", Path: "/api/admin/backups", Tag: "admin", Summary: "Start a logical backup of the raw and processed tables", Status: http.StatusAccepted, Response: "The started backup", Admin: true},
+	{Method: "GET", Path: "/api/admin/retention", Tag: "admin", Summary: "List the raw data retention runs", Query: []apiParam{limitParam}, Response: "Retention runs with the rows and bytes they pruned, newest first", Admin: true},
+	{Method: "POST", Path: "/api/admin/retention", Tag: "admin", Summary: "Archive or delete the raw data older than the retention age", Query: []apiParam{{"dry_run", "boolean", "Only report what would be pruned"}}, Status: http.StatusAccepted, Response: "The started run, or the finished dry run", Admin: true},
+	{Method: "GET", Path: "/api/admin/records/deleted", Tag: "admin", Summary: "List soft-deleted records", Query: []apiParam{limitParam}, Response: "Deleted records", Admin: true},
+	{Method: "DELETE", Path: "/api/admin/records/{id}", Tag: "admin", Summary: "Soft-delete a processed record", Response: "Record ID and action", Admin: true},
+	{Method: "POST", Path: "/api/admin/records/{id}/restore", Tag: "admin", Summary: "Restore a soft-deleted record", Response: "Record ID and action", Admin: true},
+	{Method: "DELETE", Path: "/api/admin/batches/{batch_id}", Tag: "admin", Summary: "Soft-delete every raw and processed row of an extraction batch", Response: "Rows changed per table", Admin: true},
+	{Method: "POST", Path: "/api/admin/batches/{batch_id}/restore", Tag: "admin", Summary: "Restore every row of an extraction batch", Response: "Rows changed per table", Admin: true},
+	{Method: "POST", Path: "/api/admin/rollups", Tag: "admin", Summary: "Rebuild the daily rollups of recent days", Query: []apiParam{{"days", "integer", "Number of days"}}, Response: "Days rebuilt", Admin: true},
+	{Method: "POST", Path: "/api/admin/duplicates", Tag: "admin", Summary: "Regroup duplicate articles of recent days", Query: []apiParam{{"days", "integer", "Number of days"}}, Response: "Groups found", Admin: true},
+	{Method: "POST", Path: "/api/admin/statistics", Tag: "admin", Summary: "Refresh the official COVID-19 statistics", Response: "Days stored", Admin: true},
+	{Method: "POST", Path: "/api/admin/search-interest", Tag: "admin", Summary: "Refresh the Google Trends search interest", Response: "Days stored", Admin: true},
+	{Method: "POST", Path: "/api/admin/record-updates", Tag: "admin", Summary: "Check articles due for an update check now", Response: "Articles checked and changed", Admin: true},
+	{Method: "GET", Path: "/api/admin/cache", Tag: "admin", Summary: "Hit and miss counts of the dashboard and analytics caches", Response: "Cache statistics", Admin: true},
+	{Method: "DELETE", Path: "/api/admin/cache", Tag: "admin", Summary: "Drop the project's cached analytics responses", Response: "The project whose responses were dropped", Admin: true},
+	{Method: "GET", Path: "/api/selftest", Tag: "admin", Summary: "Check configuration, database schema version and source API keys", Query: []apiParam{{"skip_apis", "boolean", "Leave out the source API probes"}}, Response: "Pass, warn or fail per check; 503 when a check fails", Admin: true},
+	{Method: "GET", Path: "/api/admin/audit", Tag: "admin", Summary: "Audit log of ETL triggers, cleanups, deletes and other mutating requests", Query: []apiParam{{"action", "string", "Action, e.g. etl.run"}, {"actor", "string", "Actor, e.g. user:alice"}, limitParam}, Response: "Audit entries, newest first", Admin: true},
+	{Method: "GET", Path: "/api/admin/projects", Tag: "admin", Summary: "List projects", Response: "Projects", Admin: true},
+	{Method: "POST", Path: "/api/admin/projects", Tag: "admin", Summary: "Create a project", Body: "{id, name}", Status: http.StatusCreated, Response: "The new project", Admin: true},
+	{Method: "GET", Path: "/api/admin/projects/{id}/keys", Tag: "admin", Summary: "List the API keys of a project", Response: "Project keys without their plaintext", Admin: true},
+	{Method: "POST", Path: "/api/admin/projects/{id}/keys", Tag: "admin", Summary: "Create a project-scoped API key", Body: "{label}", Status: http.StatusCreated, Response: "The new key; the plaintext key is only returned here", Admin: true},
+	{Method: "GET", Path: "/api/admin/projects/{id}/settings", Tag: "admin", Summary: "Get the pipeline overrides of a project", Response: "Overrides and the effective settings", Admin: true},
+	{Method: "PUT", Path: "/api/admin/projects/{id}/settings", Tag: "admin", Summary: "Replace the pipeline overrides of a project", Body: "{keywords, sources, min_relevance, schedule_interval}", Response: "Overrides and the effective settings", Admin: true},
+	{Method: "GET", Path: "/api/admin/users", Tag: "admin", Summary: "List dashboard users", Response: "Users without password hashes", Admin: true},
+	{Method: "POST", Path: "/api/admin/users", Tag: "admin", Summary: "Create a dashboard user", Body: "{username, password, role: viewer|admin, project}", Status: http.StatusCreated, Response: "The new user", Admin: true},
+	{Method: "DELETE", Path: "/api/admin/users/{id}", Tag: "admin", Summary: "Delete a dashboard user", Response: "The deleted user ID", Admin: true},
+}
+
+// pathParamPattern matches the parameters of an OpenAPI path template
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// operationIDReplacer turns a method and path into an operation ID
+var operationIDReplacer = strings.NewReplacer("/", "_", "-", "_", ".", "_", "{", "", "}", "")
+
+// openAPISpec generates the OpenAPI 3 specification of apiOperations
+func openAPISpec() map[string]interface{} {
+	paths := make(map[string]map[string]interface{})
+	for _, op := range apiOperations {
+		if paths[op.Path] == nil {
+			paths[op.Path] = make(map[string]interface{})
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = op.spec()
+	}
+
+	tags := make([]map[string]string, 0, len(apiTags))
+	for _, tag := range apiTags {
+		tags = append(tags, map[string]string{"name": tag.Name, "description": tag.Description})
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "COVID-19 KMS ETL API",
+			"version":     "1.0.0",
+			"description": "RESTful API for COVID-19 ETL pipeline operations. Requests are scoped to the project of their API key or user.",
+		},
+		"servers": []map[string]string{{"url": "/"}},
+		"tags":    tags,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"ApiKeyAuth": map[string]string{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"BearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// spec returns the OpenAPI operation object of an operation
+func (op apiOperation) spec() map[string]interface{} {
+	var parameters []map[string]interface{}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]string{"type": "string"},
+		})
+	}
+	for _, param := range op.Query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        param.Name,
+			"in":          "query",
+			"description": param.Description,
+			"schema":      map[string]string{"type": param.Type},
+		})
+	}
+
+	status, produces := op.Status, op.Produces
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if produces == "" {
+		produces = "application/json"
+	}
+	responses := map[string]interface{}{
+		strconv.Itoa(status): map[string]interface{}{
+			"description": op.Response,
+			"content":     map[string]interface{}{produces: map[string]interface{}{}},
+		},
+		"429": map[string]string{"description": "Rate limit exceeded"},
+	}
+
+	operation := map[string]interface{}{
+		"operationId": strings.ToLower(op.Method) + operationIDReplacer.Replace(op.Path),
+		"tags":        []string{op.Tag},
+		"summary":     op.Summary,
+		"responses":   responses,
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if op.Body != "" {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]string{"type": "object", "description": op.Body},
+				},
+			},
+		}
+	}
+
+	if op.Open {
+		operation["security"] = []map[string][]string{}
+		return operation
+	}
+	operation["security"] = []map[string][]string{{"ApiKeyAuth": {}}, {"BearerAuth": {}}}
+	responses["401"] = map[string]string{"description": "Missing or invalid API key or token"}
+	if op.Admin || op.Method != http.MethodGet {
+		responses["403"] = map[string]string{"description": "The user's role may not perform this operation"}
+	}
+	return operation
+}
+
+// apiEndpoints lists the operations by tag for the API info
+func apiEndpoints() map[string][]map[string]string {
+	endpoints := make(map[string][]map[string]string)
+	for _, op := range apiOperations {
+		endpoints[op.Tag] = append(endpoints[op.Tag], map[string]string{
+			"method":      op.Method,
+			"url":         op.Path,
+			"description": op.Summary,
+		})
+	}
+	return endpoints
+}
+
+// handleOpenAPI serves the OpenAPI specification
+func (r *Router) handleOpenAPI(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPISpec())
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at the specification
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>COVID-19 KMS ETL API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui", persistAuthorization: true });
+    };
+  </script>
+</body>
+</html>
+`
+
+// handleDocs serves Swagger UI for the OpenAPI specification
+func (r *Router) handleDocs(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAPIOperationsAreRouted(t *testing.T) {
+	mux := (&Router{}).routes()
+
+	for _, op := range apiOperations {
+		path := pathParamPattern.ReplaceAllString(op.Path, "1")
+		_, pattern := mux.Handler(httptest.NewRequest(op.Method, path, nil))
+		if pattern == "" || (pattern == "/" && op.Path != "/") {
+			t.Errorf("%s %s is documented but not routed", op.Method, op.Path)
+		}
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	tags := make(map[string]bool)
+	for _, tag := range apiTags {
+		tags[tag.Name] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, op := range apiOperations {
+		key := op.Method + " " + op.Path
+		if seen[key] {
+			t.Errorf("%s is documented twice", key)
+		}
+		seen[key] = true
+		if !tags[op.Tag] {
+			t.Errorf("%s has the unknown tag %q", key, op.Tag)
+		}
+		if op.Summary == "" || op.Response == "" {
+			t.Errorf("%s needs a summary and a response", key)
+		}
+	}
+
+	data, err := json.Marshal(openAPISpec())
+	if err != nil {
+		t.Fatalf("Failed to encode the specification: %v", err)
+	}
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Failed to decode the specification: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" {
+		t.Errorf("Expected OpenAPI 3.0.3, got %q", spec.OpenAPI)
+	}
+
+	run := spec.Paths["/api/etl/runs/{id}/payload"]["get"]
+	if len(run.Parameters) != 2 || run.Parameters[0].Name != "id" || run.Parameters[0].In != "path" || run.Parameters[1].In != "query" {
+		t.Errorf("Expected the path and query parameters of the run payload, got %+v", run.Parameters)
+	}
+	if len(run.Security) != 2 {
+		t.Errorf("Expected the API key and bearer security schemes, got %+v", run.Security)
+	}
+	if login := spec.Paths["/api/auth/login"]["post"]; len(login.Security) != 0 {
+		t.Errorf("Expected login to need no credentials, got %+v", login.Security)
+	}
+
+	ids := make(map[string]bool)
+	for _, methods := range spec.Paths {
+		for _, op := range methods {
+			if ids[op.OperationID] {
+				t.Errorf("Duplicate operation ID %s", op.OperationID)
+			}
+			ids[op.OperationID] = true
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"covid19-kms/database"
+)
+
+// Record lists are paginated newest first. ?page and ?per_page select a page
+// by number; ?cursor continues after the last record of the previous page and
+// is not shifted by records loaded in the meantime.
+const (
+	defaultPerPage = 100
+	maxPerPage     = 1000
+)
+
+// pageRequest is the page of a record list requested by a client
+type pageRequest struct {
+	Page    int
+	PerPage int
+	Cursor  int // ID of the last record already returned; 0 without a cursor
+}
+
+// parsePageRequest reads the page, per_page and cursor query parameters
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	page := pageRequest{Page: 1, PerPage: defaultPerPage}
+
+	if value := r.URL.Query().Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return page, fmt.Errorf("page must be a positive integer")
+		}
+		page.Page = parsed
+	}
+	if value := r.URL.Query().Get("per_page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxPerPage {
+			return page, fmt.Errorf("per_page must be between 1 and %d", maxPerPage)
+		}
+		page.PerPage = parsed
+	}
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		id, err := decodeCursor(value)
+		if err != nil {
+			return page, err
+		}
+		if r.URL.Query().Get("page") != "" {
+			return page, fmt.Errorf("page and cursor cannot be combined")
+		}
+		page.Cursor = id
+	}
+
+	return page, nil
+}
+
+// encodeCursor returns the opaque cursor continuing after record id
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("id:" + strconv.Itoa(id)))
+}
+
+// decodeCursor returns the record ID of a cursor
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		if id, err := strconv.Atoi(strings.TrimPrefix(string(decoded), "id:")); err == nil && id > 0 {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor")
+}
+
+// recordPage is a page of a record list and the size of the whole list
+type recordPage struct {
+	Request pageRequest
+	Records []database.ProcessedData
+	Total   int
+	HasNext bool
+}
+
+// queryRecordPage returns the requested page of the records of repo
+// matching filter
+func queryRecordPage(ctx context.Context, repo database.ProcessedDataRepo, filter database.ProcessedDataFilter, page pageRequest) (*recordPage, error) {
+	total, err := repo.CountProcessedData(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra record tells whether another page follows
+	offset := (page.Page - 1) * page.PerPage
+	if page.Cursor > 0 {
+		filter.BeforeID = page.Cursor
+		offset = 0
+	}
+	records, err := repo.QueryProcessedDataPage(ctx, filter, page.PerPage+1, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &recordPage{Request: page, Records: records, Total: total}
+	if len(records) > page.PerPage {
+		result.Records = records[:page.PerPage]
+		result.HasNext = true
+	}
+	return result, nil
+}
+
+// envelope adds the total count and the pagination links of the page to a
+// list response
+func (p *recordPage) envelope(r *http.Request, response map[string]interface{}) map[string]interface{} {
+	pagination := map[string]interface{}{
+		"per_page":    p.Request.PerPage,
+		"total_count": p.Total,
+		"total_pages": (p.Total + p.Request.PerPage - 1) / p.Request.PerPage,
+	}
+	if p.Request.Cursor == 0 {
+		pagination["page"] = p.Request.Page
+		if p.Request.Page > 1 {
+			pagination["prev"] = pageLink(r, "page", strconv.Itoa(p.Request.Page-1))
+		}
+	}
+	if p.HasNext {
+		cursor := encodeCursor(p.Records[len(p.Records)-1].ID)
+		pagination["next_cursor"] = cursor
+		if p.Request.Cursor == 0 {
+			pagination["next"] = pageLink(r, "page", strconv.Itoa(p.Request.Page+1))
+		} else {
+			pagination["next"] = pageLink(r, "cursor", cursor)
+		}
+	}
+
+	response["total_count"] = p.Total
+	response["count"] = len(p.Records)
+	response["pagination"] = pagination
+	return response
+}
+
+// pageLink returns the request URL with one pagination parameter replaced
+func pageLink(r *http.Request, name, value string) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Del("cursor")
+	query.Set(name, value)
+	return r.URL.Path + "?" + query.Encode()
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/services"
+)
+
+// projectContextKey is the request context key holding the resolved project ID
+type projectContextKey struct{}
+
+// projectMiddleware resolves the project a request operates on and stores it
+// in the request context. Project-scoped API keys always map to their own
+// project; the admin key may select any project with X-Project-ID or the
+// project query parameter. Access tokens map to the project of their user,
+// and admin users may select any project like the admin key. Requests
+// without a key use the default project.
+func (r *Router) projectMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		projectID := database.DefaultProject
+		ctx := req.Context()
+
+		key := requestAPIKey(req)
+		switch {
+		case key == "":
+		case r.auth.Enabled() && services.IsToken(key):
+			user, err := r.auth.ParseToken(key, services.AccessToken)
+			if err != nil {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+			projectID = user.Project
+			if selected := requestedProject(req); selected != "" && user.Role == services.RoleAdmin {
+				projectID = selected
+			}
+			ctx = context.WithValue(ctx, userContextKey{}, user)
+		case r.adminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(r.adminAPIKey)) == 1:
+			if selected := requestedProject(req); selected != "" {
+				projectID = selected
+			}
+		default:
+			keyProject, err := database.GetProjectForAPIKey(key)
+			if err != nil {
+				log.Printf("❌ Failed to resolve project API key: %v", err)
+				http.Error(w, "Failed to resolve API key", http.StatusInternalServerError)
+				return
+			}
+			if keyProject == "" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			projectID = keyProject
+		}
+
+		ctx = context.WithValue(ctx, projectContextKey{}, projectID)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// requestProject returns the project resolved for a request, falling back to
+// the default project for requests that bypassed projectMiddleware
+func requestProject(r *http.Request) string {
+	return contextProject(r.Context())
+}
+
+// contextProject returns the project stored in a request context, for code
+// such as GraphQL resolvers that only sees the context
+func contextProject(ctx context.Context) string {
+	if projectID, ok := ctx.Value(projectContextKey{}).(string); ok && projectID != "" {
+		return projectID
+	}
+	return database.DefaultProject
+}
+
+// requestedProject returns the project explicitly selected by the caller
+func requestedProject(r *http.Request) string {
+	if projectID := strings.TrimSpace(r.Header.Get("X-Project-ID")); projectID != "" {
+		return projectID
+	}
+	return strings.TrimSpace(r.URL.Query().Get("project"))
+}
+
+// requestAPIKey returns the API key sent as X-API-Key or as an Authorization bearer token
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
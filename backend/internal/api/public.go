@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// publicPaths are the read-only analytics and search endpoints available to
+// requests without an API key when public mode is enabled
+var publicPaths = map[string]bool{
+	"/":                                       true,
+	"/api":                                    true,
+	"/health":                                 true,
+	"/api/health":                             true,
+	"/api/openapi.json":                       true,
+	"/api/docs":                               true,
+	"/api/search":                             true,
+	"/api/search/semantic":                    true,
+	"/api/etl/data/stats":                     true,
+	"/api/etl/data/summary":                   true,
+	"/api/etl/data/sentiment-distribution":    true,
+	"/api/etl/data/word-frequency":            true,
+	"/api/etl/data/trends":                    true,
+	"/api/analytics/aspect-sentiment":         true,
+	"/api/analytics/tags":                     true,
+	"/api/analytics/entities":                 true,
+	"/api/analytics/geo":                      true,
+	"/api/analytics/timeseries":               true,
+	"/api/analytics/trending":                 true,
+	"/api/analytics/sentiment":                true,
+	"/api/analytics/wordcloud":                true,
+	"/api/analytics/export/trends":            true,
+	"/api/analytics/export/word-frequency":    true,
+	"/api/analytics/export/source-comparison": true,
+	"/api/digest":                             true,
+	"/api/sources":                            true,
+	"/api/methodology":                        true,
+	"/api/methodology/relevance":              true,
+	"/api/methodology/sentiment":              true,
+	"/api/methodology/stopwords":              true,
+	"/api/methodology/topics":                 true,
+	"/.well-known/methodology":                true,
+	"/api/statistics/covid":                   true,
+	"/api/statistics/covid/sentiment":         true,
+	"/api/statistics/search-interest":         true,
+}
+
+// publicContextKey marks requests served in public mode
+type publicContextKey struct{}
+
+// publicMiddleware restricts requests without an API key to publicPaths
+// under the public rate limit. It is a no-op unless public mode is enabled.
+func (r *Router) publicMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.publicLimiter == nil || requestAPIKey(req) != "" || req.Method == http.MethodOptions ||
+			strings.HasPrefix(req.URL.Path, "/api/auth/") {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if !publicPaths[req.URL.Path] {
+			http.Error(w, "Not available in public mode; an API key is required", http.StatusUnauthorized)
+			return
+		}
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "Public mode is read-only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !r.publicLimiter.check(w, clientIP(req)) {
+			return
+		}
+
+		ctx := context.WithValue(req.Context(), publicContextKey{}, true)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// isPublicRequest reports whether a request is served in public mode
+func isPublicRequest(r *http.Request) bool {
+	public, _ := r.Context().Value(publicContextKey{}).(bool)
+	return public
+}
+
+// clientIP returns the remote address of a request without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// publicRecords strips content bodies and raw processed data from records
+// returned to public requests
+func publicRecords(records []database.ProcessedData) []map[string]interface{} {
+	results := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		result := map[string]interface{}{
+			"id":              record.ID,
+			"source":          record.Source,
+			"title":           record.Title,
+			"relevance_score": record.RelevanceScore,
+			"sentiment":       record.Sentiment,
+			"sentiment_score": record.SentimentScore,
+			"processed_at":    record.ProcessedAt.Format(time.RFC3339),
+		}
+		if record.PublishedAt != nil {
+			result["published_at"] = record.PublishedAt.Format(time.RFC3339)
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"covid19-kms/internal/config"
+)
+
+// rateLimiter is a fixed-window request counter per client
+type rateLimiter struct {
+	limit   int
+	window  time.Duration
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+}
+
+// rateWindow counts the requests of one client in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// newRateLimiter creates a limiter allowing limit requests per window
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// allow records a request of client and reports whether it is within the
+// limit, along with the remaining requests and when the window resets
+func (l *rateLimiter) allow(client string, now time.Time) (bool, int, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current, ok := l.clients[client]
+	if !ok || now.Sub(current.start) >= l.window {
+		// Drop expired windows so the map does not grow without bound
+		for key, expired := range l.clients {
+			if now.Sub(expired.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+		current = &rateWindow{start: now}
+		l.clients[client] = current
+	}
+
+	reset := current.start.Add(l.window)
+	if current.count >= l.limit {
+		return false, 0, reset
+	}
+	current.count++
+	return true, l.limit - current.count, reset
+}
+
+// check records a request of client and sets the X-RateLimit-* headers.
+// Beyond the limit it answers 429 with Retry-After and returns false.
+func (l *rateLimiter) check(w http.ResponseWriter, client string) bool {
+	allowed, remaining, reset := l.allow(client, time.Now())
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	if !allowed {
+		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", time.Until(reset).Seconds()+0.5))
+		http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// rateLimitClient identifies the client a request is counted against: its
+// user, its API key, or its IP for requests without credentials
+func rateLimitClient(r *http.Request) string {
+	if user := requestUser(r); user != nil {
+		return "user:" + user.Username
+	}
+	if key := requestAPIKey(r); key != "" {
+		return "api_key:" + key
+	}
+	return "ip:" + clientIP(r)
+}
+
+// RateLimit limits every client to API_RATE_LIMIT_REQUESTS requests per
+// API_RATE_LIMIT_WINDOW
+type RateLimit struct {
+	limiter *rateLimiter // nil when the limit is disabled
+}
+
+// NewRateLimit creates the per-client rate limit of an API configuration
+func NewRateLimit(cfg config.APIConfig) *RateLimit {
+	window, err := time.ParseDuration(cfg.RateLimitWindow)
+	if err != nil || cfg.RateLimitRequests <= 0 || window <= 0 {
+		return &RateLimit{}
+	}
+	return &RateLimit{limiter: newRateLimiter(cfg.RateLimitRequests, window)}
+}
+
+// Allow counts a request against its client and reports whether it may be
+// served; beyond the limit it has already answered 429. Preflight requests,
+// health checks and public mode requests, which the public limiter counts
+// instead, are not counted.
+func (rl *RateLimit) Allow(w http.ResponseWriter, req *http.Request) bool {
+	if rl.limiter == nil || req.Method == http.MethodOptions || isPublicRequest(req) ||
+		req.URL.Path == "/health" || req.URL.Path == "/api/health" {
+		return true
+	}
+	return rl.limiter.check(w, rateLimitClient(req))
+}
+
+// String describes the limit for the API info
+func (rl *RateLimit) String() string {
+	if rl.limiter == nil {
+		return "Disabled (API_RATE_LIMIT_REQUESTS=0)"
+	}
+	return fmt.Sprintf("%d requests per %s per user, API key or IP", rl.limiter.limit, rl.limiter.window)
+}
+
+// rateLimitMiddleware applies the per-client rate limit to every request
+func (r *Router) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.rateLimit.Allow(w, req) {
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+
+	"covid19-kms/internal/services"
+)
+
+// cachedResponse is a successful analytics response as sent to the client
+type cachedResponse struct {
+	contentType string
+	body        []byte
+}
+
+// bodyRecorder passes a response through to the client and keeps a copy
+type bodyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status code before writing it
+func (br *bodyRecorder) WriteHeader(status int) {
+	br.status = status
+	br.ResponseWriter.WriteHeader(status)
+}
+
+// Write copies the body before writing it
+func (br *bodyRecorder) Write(data []byte) (int, error) {
+	br.body.Write(data)
+	return br.ResponseWriter.Write(data)
+}
+
+// analyticsCacheMiddleware serves GET requests to an analytics handler from
+// the shared analytics cache, keyed by project, path and query, and caches
+// successful responses. X-Cache tells whether a response was a HIT or MISS.
+func (r *Router) analyticsCacheMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		analyticsCache := services.SharedAnalyticsCache()
+		key := services.AnalyticsCacheKey(requestProject(req), req.URL.Path+"?"+req.URL.Query().Encode())
+		if value, ok := analyticsCache.Get(key); ok {
+			response := value.(cachedResponse)
+			w.Header().Set("Content-Type", response.contentType)
+			w.Header().Set("X-Cache", "HIT")
+			w.Write(response.body)
+			return
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		recorder := &bodyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+		if recorder.status == http.StatusOK {
+			analyticsCache.Set(key, cachedResponse{
+				contentType: w.Header().Get("Content-Type"),
+				body:        recorder.body.Bytes(),
+			})
+		}
+	}
+}
@@ -1,40 +1,100 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
 )
 
 // Router handles HTTP routing for the ETL API
 type Router struct {
-	etlHandler  *ETLHandler
-	dataHandler *DataHandler
+	etlHandler        *ETLHandler
+	dataHandler       *DataHandler
+	searchHandler     *SearchHandler
+	exportHandler     *ExportHandler
+	collectionHandler *CollectionHandler
+	graphqlHandler    *GraphQLHandler
+	adminHandler      *AdminHandler
+	authHandler       *AuthHandler
+	auth              *services.AuthService
+	adminAPIKey       string
+	cors              *CORS
+	rateLimit         *RateLimit
+	publicLimiter     *rateLimiter // nil unless public mode is enabled
+	storeOnly         bool         // true without PostgreSQL, see storeOnlyMiddleware
 }
 
-// NewRouter creates a new router instance
-func NewRouter() *Router {
-	return &Router{
-		etlHandler:  NewETLHandler(),
-		dataHandler: NewDataHandler(),
+// NewRouter creates a new router instance whose handlers read and write
+// through store
+func NewRouter(cfg *config.Config, store database.Store) *Router {
+	auth := services.NewAuthService(cfg.API)
+
+	router := &Router{
+		etlHandler:        NewETLHandler(),
+		dataHandler:       NewDataHandler(store),
+		searchHandler:     NewSearchHandler(),
+		exportHandler:     NewExportHandler(),
+		collectionHandler: NewCollectionHandler(),
+		graphqlHandler:    NewGraphQLHandler(store),
+		adminHandler:      NewAdminHandler(store),
+		authHandler:       NewAuthHandler(auth),
+		auth:              auth,
+		adminAPIKey:       cfg.API.AdminAPIKey,
+		cors:              NewCORS(cfg.API),
+		rateLimit:         NewRateLimit(cfg.API),
+		storeOnly:         cfg.Database.Type == "sqlite",
+	}
+	if cfg.API.PublicMode {
+		router.publicLimiter = newRateLimiter(cfg.API.PublicRateLimitRequests, cfg.API.PublicRateLimitWindow)
 	}
+	return router
+}
+
+// SetupRoutes configures all API routes behind the middleware chain
+func (r *Router) SetupRoutes() http.Handler {
+	// Every request is scoped to the project of its API key or user; keyless
+	// requests are limited to the public endpoints when public mode is enabled
+	// and rejected when user authentication is enabled. Each client is rate
+	// limited by its user, API key or IP. Without PostgreSQL only the
+	// endpoints served through the store are available.
+	return r.storeOnlyMiddleware(r.projectMiddleware(r.publicMiddleware(r.rateLimitMiddleware(r.authMiddleware(r.routes())))))
 }
 
-// SetupRoutes configures all API routes
-func (r *Router) SetupRoutes() *http.ServeMux {
+// routes registers every endpoint; apiOperations documents them
+func (r *Router) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Wrap all routes with CORS middleware
 	mux.HandleFunc("/", r.corsMiddleware(r.handleRoot))
 	mux.HandleFunc("/api", r.corsMiddleware(r.handleAPIInfo))
-	mux.HandleFunc("/api/etl/run", r.corsMiddleware(r.etlHandler.RunETLPipeline))
+	mux.HandleFunc("/api/openapi.json", r.corsMiddleware(r.handleOpenAPI))
+	mux.HandleFunc("/api/docs", r.corsMiddleware(r.handleDocs))
+	mux.HandleFunc("/api/etl/run", r.corsMiddleware(r.auditMiddleware("etl.run", r.etlHandler.RunETLPipeline)))
+	mux.HandleFunc("/api/etl/crawl", r.corsMiddleware(r.auditMiddleware("etl.crawl", r.etlHandler.CrawlArchive)))
+	mux.HandleFunc("/api/etl/reprocess", r.corsMiddleware(r.auditMiddleware("etl.reprocess", r.etlHandler.ReprocessRawData)))
+	mux.HandleFunc("/api/etl/deadletter/retry", r.corsMiddleware(r.auditMiddleware("etl.deadletter_retry", r.etlHandler.RetryDeadLetters)))
 	mux.HandleFunc("/api/etl/status", r.corsMiddleware(r.etlHandler.GetPipelineStatus))
-	mux.HandleFunc("/api/etl/extract", r.corsMiddleware(r.etlHandler.ExtractData))
-	mux.HandleFunc("/api/etl/transform", r.corsMiddleware(r.etlHandler.TransformData))
-	mux.HandleFunc("/api/etl/load", r.corsMiddleware(r.etlHandler.LoadData))
-	mux.HandleFunc("/api/etl/cleanup/sentiment", r.corsMiddleware(r.etlHandler.CleanupSentiments))
+	mux.HandleFunc("/api/etl/stream", r.corsMiddleware(r.etlHandler.StreamProgress))
+	mux.HandleFunc("/api/etl/extract", r.corsMiddleware(r.auditMiddleware("etl.extract", r.etlHandler.ExtractData)))
+	mux.HandleFunc("/api/etl/transform", r.corsMiddleware(r.auditMiddleware("etl.transform", r.etlHandler.TransformData)))
+	mux.HandleFunc("/api/etl/load", r.corsMiddleware(r.auditMiddleware("etl.load", r.etlHandler.LoadData)))
+	mux.HandleFunc("/api/etl/cleanup/sentiment", r.corsMiddleware(r.auditMiddleware("cleanup.sentiment", r.etlHandler.CleanupSentiments)))
+	mux.HandleFunc("/api/etl/cleanup/relevance", r.corsMiddleware(r.auditMiddleware("cleanup.relevance", r.etlHandler.CleanupRelevance)))
+	mux.HandleFunc("/api/etl/cleanup/language", r.corsMiddleware(r.auditMiddleware("cleanup.language", r.etlHandler.CleanupLanguage)))
+	mux.HandleFunc("/api/etl/cleanup/jobs", r.corsMiddleware(r.etlHandler.GetCleanupJobs))
+	mux.HandleFunc("/api/etl/cleanup/jobs/", r.corsMiddleware(r.etlHandler.GetCleanupJob))
+	mux.HandleFunc("/api/etl/quota", r.corsMiddleware(r.etlHandler.GetQuotaUsage))
+	mux.HandleFunc("/api/etl/runs", r.corsMiddleware(r.etlHandler.ListRuns))
+	mux.HandleFunc("/api/etl/runs/", r.corsMiddleware(r.auditMiddleware("etl.cancel", r.etlHandler.RunRoutes)))
 	mux.HandleFunc("/api/etl/data", r.corsMiddleware(r.dataHandler.GetLatestData))
 	mux.HandleFunc("/api/etl/data/source", r.corsMiddleware(r.dataHandler.GetDataBySource))
 	mux.HandleFunc("/api/etl/data/stats", r.corsMiddleware(r.dataHandler.GetDataStats))
+	mux.HandleFunc("/api/etl/data/record/", r.corsMiddleware(r.dataHandler.GetRecord))
+	mux.HandleFunc("/api/etl/data/", r.corsMiddleware(r.auditMiddleware("record.annotate", r.dataHandler.DataRoutes)))
 
 	// New database query endpoints for individual sources
 	mux.HandleFunc("/api/etl/data/youtube", r.corsMiddleware(r.dataHandler.GetYouTubeData))
@@ -44,6 +104,65 @@ func (r *Router) SetupRoutes() *http.ServeMux {
 	mux.HandleFunc("/api/etl/data/summary", r.corsMiddleware(r.dataHandler.GetDataSummary))
 	mux.HandleFunc("/api/etl/data/sentiment-distribution", r.corsMiddleware(r.dataHandler.GetSentimentDistribution))
 	mux.HandleFunc("/api/etl/data/word-frequency", r.corsMiddleware(r.dataHandler.GetWordFrequency))
+	mux.HandleFunc("/api/etl/data/trends", r.corsMiddleware(r.dataHandler.GetTrends))
+	mux.HandleFunc("/api/etl/data/duplicates", r.corsMiddleware(r.dataHandler.GetDuplicates))
+	mux.HandleFunc("/api/analytics/aspect-sentiment", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetAspectSentiment)))
+	mux.HandleFunc("/api/analytics/tags", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetTagCounts)))
+	mux.HandleFunc("/api/analytics/entities", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetEntityCounts)))
+	mux.HandleFunc("/api/analytics/geo", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetProvinceCounts)))
+	mux.HandleFunc("/api/analytics/timeseries", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetTimeSeries)))
+	mux.HandleFunc("/api/analytics/trending", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetTrendingTerms)))
+	mux.HandleFunc("/api/analytics/sentiment", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetSentimentCounts)))
+	mux.HandleFunc("/api/analytics/wordcloud", r.corsMiddleware(r.analyticsCacheMiddleware(r.dataHandler.GetWordCloud)))
+	mux.HandleFunc("/api/analytics/export/", r.corsMiddleware(r.dataHandler.ExportAnalytics))
+	mux.HandleFunc("/api/digest", r.corsMiddleware(r.dataHandler.GetDigest))
+	mux.HandleFunc("/api/sources", r.corsMiddleware(r.dataHandler.GetSources))
+	mux.HandleFunc("/api/methodology", r.corsMiddleware(r.dataHandler.GetMethodology))
+	mux.HandleFunc("/api/methodology/", r.corsMiddleware(r.dataHandler.GetMethodology))
+	mux.HandleFunc("/.well-known/methodology", r.corsMiddleware(r.dataHandler.GetMethodology))
+	mux.HandleFunc("/api/statistics/covid", r.corsMiddleware(r.dataHandler.GetCovidStatistics))
+	mux.HandleFunc("/api/statistics/covid/sentiment", r.corsMiddleware(r.dataHandler.GetSentimentCaseCorrelation))
+	mux.HandleFunc("/api/statistics/search-interest", r.corsMiddleware(r.dataHandler.GetSearchInterest))
+
+	// Search and saved search endpoints
+	mux.HandleFunc("/api/search", r.corsMiddleware(r.searchHandler.Search))
+	mux.HandleFunc("/api/search/semantic", r.corsMiddleware(r.searchHandler.SemanticSearch))
+	mux.HandleFunc("/api/searches", r.corsMiddleware(r.auditMiddleware("saved_search.modify", r.searchHandler.SavedSearches)))
+
+	// GraphQL queries over records, sentiment aggregates and sources
+	mux.HandleFunc("/api/graphql", r.corsMiddleware(r.graphqlHandler.ServeGraphQL))
+
+	// Asynchronous export jobs
+	mux.HandleFunc("/api/exports", r.corsMiddleware(r.auditMiddleware("export.create", r.exportHandler.CreateExport)))
+	mux.HandleFunc("/api/exports/", r.corsMiddleware(r.exportHandler.ExportJobRoutes))
+	mux.HandleFunc("/api/export/parquet", r.corsMiddleware(r.exportHandler.ExportParquet))
+
+	// Per-user record collections
+	mux.HandleFunc("/api/collections", r.corsMiddleware(r.auditMiddleware("collection.create", r.collectionHandler.Collections)))
+	mux.HandleFunc("/api/collections/", r.corsMiddleware(r.auditMiddleware("collection.modify", r.collectionHandler.CollectionRoutes)))
+
+	// User login and token refresh (enabled by AUTH_JWT_SECRET)
+	mux.HandleFunc("/api/auth/login", r.corsMiddleware(r.authHandler.Login))
+	mux.HandleFunc("/api/auth/refresh", r.corsMiddleware(r.authHandler.Refresh))
+	mux.HandleFunc("/api/auth/me", r.corsMiddleware(r.authHandler.Me))
+
+	// Admin endpoints (require ADMIN_API_KEY or an admin user)
+	mux.HandleFunc("/api/admin/backups", r.corsMiddleware(r.auditMiddleware("backup.create", r.adminMiddleware(r.adminHandler.Backups))))
+	mux.HandleFunc("/api/admin/retention", r.corsMiddleware(r.auditMiddleware("retention.run", r.adminMiddleware(r.adminHandler.Retention))))
+	mux.HandleFunc("/api/admin/records/", r.corsMiddleware(r.auditMiddleware("record.modify", r.adminMiddleware(r.adminHandler.Records))))
+	mux.HandleFunc("/api/admin/batches/", r.corsMiddleware(r.auditMiddleware("batch.modify", r.adminMiddleware(r.adminHandler.Batches))))
+	mux.HandleFunc("/api/admin/rollups", r.corsMiddleware(r.auditMiddleware("rollup.rebuild", r.adminMiddleware(r.adminHandler.Rollups))))
+	mux.HandleFunc("/api/admin/duplicates", r.corsMiddleware(r.auditMiddleware("duplicate.regroup", r.adminMiddleware(r.adminHandler.Duplicates))))
+	mux.HandleFunc("/api/admin/statistics", r.corsMiddleware(r.auditMiddleware("statistics.refresh", r.adminMiddleware(r.adminHandler.Statistics))))
+	mux.HandleFunc("/api/admin/search-interest", r.corsMiddleware(r.auditMiddleware("search_interest.refresh", r.adminMiddleware(r.adminHandler.SearchInterest))))
+	mux.HandleFunc("/api/admin/record-updates", r.corsMiddleware(r.auditMiddleware("record_update.check", r.adminMiddleware(r.adminHandler.RecordUpdates))))
+	mux.HandleFunc("/api/admin/cache", r.corsMiddleware(r.auditMiddleware("cache.clear", r.adminMiddleware(r.adminHandler.Cache))))
+	mux.HandleFunc("/api/selftest", r.corsMiddleware(r.adminMiddleware(r.adminHandler.SelfTest)))
+	mux.HandleFunc("/api/admin/audit", r.corsMiddleware(r.adminMiddleware(r.adminHandler.AuditLog)))
+	mux.HandleFunc("/api/admin/projects", r.corsMiddleware(r.auditMiddleware("project.create", r.adminMiddleware(r.adminHandler.Projects))))
+	mux.HandleFunc("/api/admin/projects/", r.corsMiddleware(r.auditMiddleware("project.modify", r.adminMiddleware(r.adminHandler.ProjectRoutes))))
+	mux.HandleFunc("/api/admin/users", r.corsMiddleware(r.auditMiddleware("user.create", r.adminMiddleware(r.adminHandler.Users))))
+	mux.HandleFunc("/api/admin/users/", r.corsMiddleware(r.auditMiddleware("user.modify", r.adminMiddleware(r.adminHandler.UserRoutes))))
 
 	mux.HandleFunc("/health", r.corsMiddleware(r.etlHandler.HealthCheck))
 	mux.HandleFunc("/api/health", r.corsMiddleware(r.etlHandler.HealthCheck))
@@ -69,17 +188,95 @@ func (r *Router) handleRoot(w http.ResponseWriter, req *http.Request) {
 			"api_info": "/api",
 			"etl": map[string]string{
 				"run_pipeline":   "/api/etl/run",
+				"crawl_archive":  "/api/etl/crawl?outlet=Kompas&from=2020-03-01&to=2020-06-30",
+				"reprocess":      "/api/etl/reprocess?from=2024-01-01",
+				"retry_failed":   "/api/etl/deadletter/retry",
 				"status":         "/api/etl/status",
+				"quota":          "/api/etl/quota",
 				"extract":        "/api/etl/extract",
 				"transform":      "/api/etl/transform",
 				"load":           "/api/etl/load",
 				"data":           "/api/etl/data",
 				"data_by_source": "/api/etl/data/source?source=youtube",
 				"data_stats":     "/api/etl/data/stats",
+				"data_record":    "/api/etl/data/record/{id}",
+				"related":        "/api/etl/data/{id}/related",
+				"notes":          "/api/etl/data/{id}/notes",
+				"versions":       "/api/etl/data/{id}/versions",
+				"tags":           "/api/etl/data/{id}/tags",
+				"trends":         "/api/etl/data/trends?dimension=sentiment&days=30",
+				"duplicates":     "/api/etl/data/duplicates?limit=20",
+				"runs":           "/api/etl/runs?kind=pipeline&status=error",
+				"stream":         "/api/etl/stream",
+				"run":            "/api/etl/runs/{batch_id}",
+				"run_payload":    "/api/etl/runs/{batch_id}/payload",
+				"run_cancel":     "/api/etl/runs/{batch_id}/cancel",
+				"run_sample":     "/api/etl/runs/{batch_id}/sample?n=50",
+				"sources":        "/api/sources",
+				"methodology":    "/api/methodology",
+			},
+			"analytics": map[string]string{
+				"aspect_sentiment": "/api/analytics/aspect-sentiment?source=youtube",
+				"tags":             "/api/analytics/tags?source=youtube",
+				"entities":         "/api/analytics/entities?type=person&limit=20",
+				"geo":              "/api/analytics/geo?from=2021-07-01",
+				"timeseries":       "/api/analytics/timeseries?interval=week&from=2021-06-01&to=2021-08-31",
+				"trending":         "/api/analytics/trending?days=1&baseline_days=7",
+				"sentiment":        "/api/analytics/sentiment?from=2021-07-01&to=2021-07-31",
+				"wordcloud":        "/api/analytics/wordcloud?sentiment=negative&top=100",
+				"export_trends":    "/api/analytics/export/trends?dimension=sentiment&days=30",
+				"export_words":     "/api/analytics/export/word-frequency",
+				"export_sources":   "/api/analytics/export/source-comparison",
+				"digest":           "/api/digest?date=2021-07-15",
+			},
+			"statistics": map[string]string{
+				"covid":           "/api/statistics/covid?from=2021-06-01&to=2021-08-31",
+				"covid_sentiment": "/api/statistics/covid/sentiment?days=90",
+				"search_interest": "/api/statistics/search-interest?from=2021-06-01&to=2021-08-31",
+			},
+			"search": map[string]string{
+				"search":         "/api/search?q=vaksin",
+				"semantic":       "/api/search/semantic?q=masyarakat+menolak+vaksin",
+				"saved_searches": "/api/searches",
+				"graphql":        "/api/graphql",
+			},
+			"exports": map[string]string{
+				"create":  "/api/exports",
+				"status":  "/api/exports/{id}",
+				"parquet": "/api/export/parquet?source=google_news&from=2021-07-01&to=2021-07-31",
 			},
-			"health": "/api/health",
+			"collections": map[string]string{
+				"collections": "/api/collections",
+				"collection":  "/api/collections/{id}",
+				"items":       "/api/collections/{id}/items",
+				"export":      "/api/collections/{id}/export?format=csv",
+			},
+			"auth": map[string]string{
+				"login":   "/api/auth/login",
+				"refresh": "/api/auth/refresh",
+				"me":      "/api/auth/me",
+			},
+			"admin": map[string]string{
+				"backups":         "/api/admin/backups",
+				"retention":       "/api/admin/retention",
+				"records":         "/api/admin/records/{id}",
+				"batches":         "/api/admin/batches/{batch_id}",
+				"audit":           "/api/admin/audit",
+				"projects":        "/api/admin/projects",
+				"users":           "/api/admin/users",
+				"rollups":         "/api/admin/rollups?days=30",
+				"duplicates":      "/api/admin/duplicates?days=30",
+				"statistics":      "/api/admin/statistics",
+				"search_interest": "/api/admin/search-interest",
+				"record_updates":  "/api/admin/record-updates",
+				"cache":           "/api/admin/cache",
+				"selftest":        "/api/selftest",
+			},
+			"health":  "/api/health",
+			"openapi": "/api/openapi.json",
+			"docs":    "/api/docs",
 		},
-		"documentation": "API documentation available at /api",
+		"documentation": "API documentation available at /api, OpenAPI specification at /api/openapi.json and Swagger UI at /api/docs",
 	}
 
 	// Convert to JSON and send response
@@ -107,52 +304,9 @@ func (r *Router) handleAPIInfo(w http.ResponseWriter, req *http.Request) {
 		"version":     "1.0.0",
 		"description": "RESTful API for COVID-19 ETL pipeline operations",
 		"base_url":    "/api",
-		"endpoints": map[string]interface{}{
-			"etl": map[string]interface{}{
-				"run_pipeline": map[string]interface{}{
-					"method":      "POST",
-					"url":         "/api/etl/run",
-					"description": "Run the complete ETL pipeline",
-					"body":        "none",
-					"response":    "ETLResult with pipeline execution details",
-				},
-				"status": map[string]interface{}{
-					"method":      "GET",
-					"url":         "/api/etl/status",
-					"description": "Get current pipeline status and API information",
-					"body":        "none",
-					"response":    "API status and endpoint information",
-				},
-				"extract": map[string]interface{}{
-					"method":      "POST",
-					"url":         "/api/etl/extract",
-					"description": "Run only the data extraction stage",
-					"body":        "none",
-					"response":    "ExtractedData with raw data from all sources",
-				},
-				"transform": map[string]interface{}{
-					"method":      "POST",
-					"url":         "/api/etl/transform",
-					"description": "Run only the data transformation stage",
-					"body":        "none",
-					"response":    "TransformedData with cleaned and enriched data",
-				},
-				"load": map[string]interface{}{
-					"method":      "POST",
-					"url":         "/api/etl/load",
-					"description": "Run only the data loading stage",
-					"body":        "none",
-					"response":    "LoadResult with loading operation details",
-				},
-			},
-			"health": map[string]interface{}{
-				"method":      "GET",
-				"url":         "/api/health",
-				"description": "Health check endpoint for monitoring",
-				"body":        "none",
-				"response":    "Health status information",
-			},
-		},
+		"openapi":     "/api/openapi.json",
+		"docs":        "/api/docs",
+		"endpoints":   apiEndpoints(),
 		"data_sources": []string{
 			"YouTube (videos and comments)",
 			"Google News (articles)",
@@ -164,8 +318,9 @@ func (r *Router) handleAPIInfo(w http.ResponseWriter, req *http.Request) {
 			"CSV (data export)",
 			"SQLite (local storage)",
 		},
-		"authentication": "Currently none (development mode)",
-		"rate_limiting":  "Not implemented (development mode)",
+		"authentication": authenticationMode(r.auth.Enabled()),
+		"rate_limiting":  r.rateLimit.String(),
+		"public_mode":    r.publicLimiter != nil,
 	}
 
 	// Convert to JSON and send response
@@ -182,20 +337,48 @@ func (r *Router) handleAPIInfo(w http.ResponseWriter, req *http.Request) {
 // CORS middleware for handling cross-origin requests
 func (r *Router) corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		// Set comprehensive CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, HEAD, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, Cache-Control, X-File-Name")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range, Content-Disposition")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
-
-		// Handle preflight requests
-		if req.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		// Preflight requests are answered here
+		if r.cors.Apply(w, req) {
 			return
 		}
 
 		next.ServeHTTP(w, req)
 	}
 }
+
+// adminMiddleware restricts a handler to requests carrying the admin API key,
+// sent either as X-API-Key or as an Authorization bearer token, or the access
+// token of an admin user
+func (r *Router) adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if user := requestUser(req); user != nil {
+			if user.Role != services.RoleAdmin {
+				http.Error(w, "Forbidden: this action requires the admin role", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if r.adminAPIKey == "" {
+			http.Error(w, "Admin endpoints are disabled (ADMIN_API_KEY not set)", http.StatusForbidden)
+			return
+		}
+
+		key := requestAPIKey(req)
+		if subtle.ConstantTimeCompare([]byte(key), []byte(r.adminAPIKey)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	}
+}
+
+// authenticationMode describes how requests are authenticated for the API info
+func authenticationMode(usersEnabled bool) string {
+	if usersEnabled {
+		return "API key or user access token (POST /api/auth/login)"
+	}
+	return "API key (user login disabled)"
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/services"
+)
+
+// SearchHandler handles keyword search and saved search management
+type SearchHandler struct{}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler() *SearchHandler {
+	return &SearchHandler{}
+}
+
+// Search handles GET requests for keyword search over processed data
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "q parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	filter := database.ProcessedDataFilter{
+		Project:   requestProject(r),
+		Query:     query,
+		Source:    r.URL.Query().Get("source"),
+		Sentiment: r.URL.Query().Get("sentiment"),
+		Campaign:  r.URL.Query().Get("campaign"),
+		Tags:      requestTags(r),
+	}
+	var err error
+	if filter.MinToxicity, err = parseScoreParam(r.URL.Query().Get("min_toxicity")); err != nil {
+		http.Error(w, "Invalid min_toxicity: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.MaxToxicity, err = parseScoreParam(r.URL.Query().Get("max_toxicity")); err != nil {
+		http.Error(w, "Invalid max_toxicity: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := database.QueryProcessedData(filter, limit)
+	if err != nil {
+		http.Error(w, "Failed to search data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"query":       query,
+		"data":        results,
+		"total_count": len(results),
+	}
+	if isPublicRequest(r) {
+		response["data"] = publicRecords(results)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseScoreParam parses an optional score between 0 and 1
+func parseScoreParam(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	score, err := strconv.ParseFloat(value, 64)
+	if err != nil || score < 0 || score > 1 {
+		return nil, fmt.Errorf("must be a number between 0 and 1")
+	}
+	return &score, nil
+}
+
+// SemanticSearch handles GET requests for natural-language search over record
+// embeddings, e.g. /api/search/semantic?q=masyarakat+menolak+vaksin
+func (h *SearchHandler) SemanticSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	embeddings := services.SharedEmbeddingService()
+	if !embeddings.Enabled() {
+		http.Error(w, "Semantic search is not configured (EMBEDDING_PROVIDER is empty)", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "q parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := embeddings.Search(requestProject(r), query, r.URL.Query().Get("source"), limit)
+	if err != nil {
+		http.Error(w, "Failed to run semantic search: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"query":       query,
+		"data":        results,
+		"total_count": len(results),
+	}
+	if isPublicRequest(r) {
+		records := make([]database.ProcessedData, len(results))
+		for i, result := range results {
+			records[i] = result.ProcessedData
+		}
+		public := publicRecords(records)
+		for i, result := range results {
+			public[i]["score"] = result.Score
+		}
+		response["data"] = public
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// SavedSearches handles listing (GET), creating (POST) and deleting (DELETE) saved searches
+func (h *SearchHandler) SavedSearches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := requestUserID(r)
+	if userID == "" {
+		http.Error(w, "User identification is required (X-User-ID header or user_id parameter)", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listSavedSearches(w, r, userID)
+	case http.MethodPost:
+		h.createSavedSearch(w, r, userID)
+	case http.MethodDelete:
+		h.deleteSavedSearch(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// listSavedSearches returns the saved searches of a user
+func (h *SearchHandler) listSavedSearches(w http.ResponseWriter, r *http.Request, userID string) {
+	searches, err := database.GetSavedSearches(requestProject(r), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve saved searches: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"data":        searches,
+		"total_count": len(searches),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// createSavedSearch stores a saved search from the JSON request body
+func (h *SearchHandler) createSavedSearch(w http.ResponseWriter, r *http.Request, userID string) {
+	var search database.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	search.UserID = userID
+	search.ProjectID = requestProject(r)
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Query == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+	// The name goes into notification subjects, so it is kept to one line
+	search.Name = strings.Join(strings.Fields(search.Name), " ")
+	if search.Name == "" {
+		search.Name = strings.Join(strings.Fields(search.Query), " ")
+	}
+	if search.NotifyEmail != "" {
+		address, err := mail.ParseAddress(search.NotifyEmail)
+		if err != nil {
+			http.Error(w, "notify_email must be a valid email address", http.StatusBadRequest)
+			return
+		}
+		search.NotifyEmail = address.Address
+	}
+	if search.WebhookURL != "" && !strings.HasPrefix(search.WebhookURL, "http://") && !strings.HasPrefix(search.WebhookURL, "https://") {
+		http.Error(w, "webhook_url must be an http(s) URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateSavedSearch(&search); err != nil {
+		http.Error(w, "Failed to create saved search: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"data":      search,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// deleteSavedSearch removes a saved search owned by the user
+func (h *SearchHandler) deleteSavedSearch(w http.ResponseWriter, r *http.Request, userID string) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "id parameter must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := database.DeleteSavedSearch(requestProject(r), id, userID)
+	if err != nil {
+		http.Error(w, "Failed to delete saved search: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Saved search not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status":    "success",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"deleted":   id,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// requestUserID identifies the calling user from the X-User-ID header or user_id parameter
+func requestUserID(r *http.Request) string {
+	if user := requestUser(r); user != nil {
+		return user.Username
+	}
+	if userID := strings.TrimSpace(r.Header.Get("X-User-ID")); userID != "" {
+		return userID
+	}
+	return strings.TrimSpace(r.URL.Query().Get("user_id"))
+}
@@ -2,33 +2,40 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"covid19-kms/database"
 	"covid19-kms/internal/config"
+	"covid19-kms/internal/etl"
+	"covid19-kms/internal/scheduler"
 )
 
-// Server represents the HTTP server
+// Server is the HTTP server of the API: one routing table and middleware
+// chain, configured from the SERVER_* and API_* settings
 type Server struct {
-	config *config.Config
-	router *Router
-	server *http.Server
+	config     *config.Config
+	router     *Router
+	server     *http.Server
+	onShutdown []func()
 }
 
-// NewServer creates a new server instance
-func NewServer(cfg *config.Config) *Server {
-	router := NewRouter()
-	
+// NewServer creates a new server instance serving the records of store
+func NewServer(cfg *config.Config, store database.Store) *Server {
+	router := NewRouter(cfg, store)
+
 	return &Server{
 		config: cfg,
 		router: router,
 		server: &http.Server{
-			Addr:         fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port),
+			Addr:         net.JoinHostPort(cfg.Server.Host, cfg.Server.Port),
 			Handler:      router.SetupRoutes(),
 			ReadTimeout:  cfg.Server.ReadTimeout,
 			WriteTimeout: cfg.Server.WriteTimeout,
@@ -37,18 +44,25 @@ func NewServer(cfg *config.Config) *Server {
 	}
 }
 
-// Start starts the HTTP server
+// OnShutdown registers a function to run when a shutdown signal arrives,
+// before the server stops accepting requests
+func (s *Server) OnShutdown(fn func()) {
+	s.onShutdown = append(s.onShutdown, fn)
+}
+
+// Start starts the HTTP server and blocks until it fails or an interrupt or
+// terminate signal shuts it down gracefully
 func (s *Server) Start() error {
 	// Create a channel to listen for errors coming from the listener
 	serverErrors := make(chan error, 1)
 
 	// Start the server in a goroutine
 	go func() {
-		log.Printf("🚀 Starting ETL API server on %s:%s", s.config.Server.Host, s.config.Server.Port)
+		log.Printf("🚀 Starting ETL API server on %s", s.server.Addr)
 		log.Printf("📊 Environment: %s", s.getEnvironment())
-		log.Printf("🔗 API Documentation: http://%s:%s/api", s.config.Server.Host, s.config.Server.Port)
-		log.Printf("🏥 Health Check: http://%s:%s/api/health", s.config.Server.Host, s.config.Server.Port)
-		
+		log.Printf("🔗 API Documentation: http://%s/api", s.server.Addr)
+		log.Printf("🏥 Health Check: http://%s/api/health", s.server.Addr)
+
 		serverErrors <- s.server.ListenAndServe()
 	}()
 
@@ -63,7 +77,10 @@ func (s *Server) Start() error {
 
 	case sig := <-shutdown:
 		log.Printf("🛑 Start shutdown... Signal: %v", sig)
-		
+		for _, fn := range s.onShutdown {
+			fn()
+		}
+
 		// Give outstanding requests a deadline for completion
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -77,6 +94,7 @@ func (s *Server) Start() error {
 		}
 	}
 
+	log.Println("✅ Server exited gracefully")
 	return nil
 }
 
@@ -96,48 +114,86 @@ func (s *Server) getEnvironment() string {
 	return "development"
 }
 
-// RunServer is a convenience function to start the server with default configuration
+// RunServer loads and validates the configuration, prepares the database,
+// starts the scheduler and serves the API until a shutdown signal arrives
 func RunServer() error {
 	// Load environment variables
 	if err := config.LoadDefaultEnv(); err != nil {
 		log.Printf("⚠️ Warning: Could not load .env file: %v", err)
 	}
 
-	// Load configuration
+	// Refuse to start with settings that would otherwise silently fall back
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		var invalid config.ValidationErrors
+		if errors.As(err, &invalid) {
+			for _, setting := range invalid {
+				log.Printf("❌ %s", setting)
+			}
+		}
+		return fmt.Errorf("invalid configuration, fix the settings above or run 'covidkms config check'")
+	}
 
-	// Validate configuration based on environment
-	if err := validateConfiguration(cfg); err != nil {
-		return fmt.Errorf("configuration validation failed: %w", err)
+	// A SQLite store serves demos without a database server; everything
+	// beyond its records, the scheduled pipelines included, needs PostgreSQL
+	if cfg.Database.Type == "sqlite" {
+		store, err := database.OpenSQLiteStore(cfg.GetDatabaseDSN())
+		if err != nil {
+			return fmt.Errorf("failed to open SQLite store: %w", err)
+		}
+		defer store.Close()
+
+		log.Printf("⚠️ Serving the records of %s (DB_TYPE=sqlite); endpoints beyond the record lists, analytics and GraphQL need PostgreSQL", cfg.GetDatabaseDSN())
+		return NewServer(cfg, store).Start()
 	}
 
-	// Create and start server
-	server := NewServer(cfg)
-	return server.Start()
-}
+	// Initialize database
+	if err := database.InitDatabase(); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer database.CloseDatabase()
 
-// validateConfiguration validates the configuration based on the environment
-func validateConfiguration(cfg *config.Config) error {
-	if cfg.IsProduction() {
-		// In production, API keys are required
-		requiredKeys := config.GetRequiredEnvsForProduction()
-		if err := config.ValidateRequiredEnvs(requiredKeys); err != nil {
-			return fmt.Errorf("production environment requires API keys: %w", err)
+	// Create tables and apply migrations if database is not skipped
+	if os.Getenv("SKIP_DATABASE") != "true" {
+		if err := database.Migrate(); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
 		}
-		log.Println("✅ Production configuration validated")
 	} else {
-		// In development/test, API keys are optional
-		log.Println("✅ Development configuration loaded (API keys optional)")
+		log.Println("⚠️ Database table creation skipped (SKIP_DATABASE=true)")
 	}
 
-	return nil
-}
+	server := NewServer(cfg, database.NewPostgresStore())
+
+	// Report problems with keys or the schema before the first scheduled run
+	if cfg.ETL.SelfTestOnStartup {
+		go func() {
+			report := etl.RunSelfTest(context.Background(), etl.SelfTestOptions{Timeout: 15 * time.Second})
+			for _, check := range report.Checks {
+				log.Printf("🩺 [%s] %s: %s", check.Status, check.Name, check.Message)
+			}
+			if report.Status == etl.SelfTestFail {
+				log.Println("⚠️ Self-test failed, scheduled runs may fail until the checks above pass")
+			}
+		}()
+	}
+
+	// Run project pipelines on their configured schedules
+	etlScheduler := scheduler.NewScheduler()
+	etlScheduler.Start()
+
+	server.OnShutdown(func() {
+		if n := etl.CancelAllRuns(); n > 0 {
+			log.Printf("🛑 Cancelled %d running ETL pipelines", n)
+			// Let the cancelled runs save their checkpoints and outcome
+			if !etl.WaitForRuns(15 * time.Second) {
+				log.Println("⚠️ ETL pipelines still running at shutdown; they resume from their last saved checkpoint")
+			}
+		}
+		etlScheduler.Stop()
+	})
 
-// StartServerWithConfig starts the server with a specific configuration
-func StartServerWithConfig(cfg *config.Config) error {
-	server := NewServer(cfg)
 	return server.Start()
 }
@@ -0,0 +1,38 @@
+package api
+
+import "net/http"
+
+// storePaths are the endpoints served entirely through database.Store, the
+// only ones a server on a SQLite store (DB_TYPE=sqlite) can answer
+var storePaths = map[string]bool{
+	"/":                            true,
+	"/api":                         true,
+	"/health":                      true,
+	"/api/health":                  true,
+	"/api/openapi.json":            true,
+	"/api/docs":                    true,
+	"/api/etl/data":                true,
+	"/api/etl/data/source":         true,
+	"/api/etl/data/stats":          true,
+	"/api/etl/data/youtube":        true,
+	"/api/etl/data/google-news":    true,
+	"/api/etl/data/instagram":      true,
+	"/api/etl/data/indonesia-news": true,
+	"/api/analytics/sentiment":     true,
+	"/api/analytics/wordcloud":     true,
+	"/api/sources":                 true,
+	"/api/graphql":                 true,
+}
+
+// storeOnlyMiddleware answers 501 for every endpoint outside storePaths when
+// the server has no PostgreSQL database, before any handler or middleware
+// reaches for the uninitialized connection. It is a no-op otherwise.
+func (r *Router) storeOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.storeOnly || storePaths[req.URL.Path] || req.Method == http.MethodOptions {
+			next.ServeHTTP(w, req)
+			return
+		}
+		http.Error(w, "Not available with DB_TYPE=sqlite, this endpoint needs PostgreSQL", http.StatusNotImplemented)
+	})
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is a concurrency-safe in-memory cache whose entries expire after a fixed TTL
+type Cache struct {
+	mu     sync.RWMutex
+	ttl    time.Duration
+	items  map[string]entry
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Stats counts the lookups a cache answered and missed since it was created
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"` // including expired entries not yet purged
+}
+
+// entry is a cached value with its expiry time
+type entry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// New creates a cache whose entries live for ttl. A ttl of 0 disables caching:
+// Set is a no-op and Get always misses.
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:   ttl,
+		items: make(map[string]entry),
+	}
+}
+
+// Get returns the value stored under key if it has not expired
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	item, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(item.expiresAt) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return item.value, true
+}
+
+// Set stores value under key, replacing any previous value
+func (c *Cache) Set(key string, value interface{}) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purgeExpired()
+	c.items[key] = entry{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// Delete removes key from the cache
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+}
+
+// DeletePrefix removes every key starting with prefix and returns how many
+// were removed
+func (c *Cache) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Stats returns the hit and miss counts and the number of stored entries
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	entries := len(c.items)
+	c.mu.RUnlock()
+
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
+
+// purgeExpired drops expired entries. The caller must hold the write lock.
+func (c *Cache) purgeExpired() {
+	now := time.Now()
+	for key, item := range c.items {
+		if now.After(item.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCacheStatsAndDeletePrefix tests that DeletePrefix drops only the
+// entries under a prefix and that Stats counts hits, misses and entries
+func TestCacheStatsAndDeletePrefix(t *testing.T) {
+	c := New(time.Minute)
+	c.Set("default|/api/analytics/tags?", "default tags")
+	c.Set("other|/api/analytics/tags?", "other tags")
+
+	if _, ok := c.Get("default|/api/analytics/tags?"); !ok {
+		t.Error("expected a hit for a cached request")
+	}
+	if _, ok := c.Get("other|/api/analytics/geo?"); ok {
+		t.Error("expected a miss for an uncached request")
+	}
+
+	if removed := c.DeletePrefix("other|"); removed != 1 {
+		t.Errorf("expected 1 entry removed, got %d", removed)
+	}
+	if _, ok := c.Get("other|/api/analytics/tags?"); ok {
+		t.Error("expected the other project's response to be dropped")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Entries != 1 {
+		t.Errorf("expected 1 hit, 2 misses and 1 entry, got %+v", stats)
+	}
+}
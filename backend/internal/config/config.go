@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,21 +13,54 @@ import (
 type Config struct {
 	// Server configuration
 	Server ServerConfig `json:"server"`
-	
+
 	// ETL Pipeline configuration
 	ETL ETLConfig `json:"etl"`
-	
+
 	// API configuration
 	API APIConfig `json:"api"`
-	
+
 	// Database configuration
 	Database DatabaseConfig `json:"database"`
-	
+
 	// External APIs configuration
 	ExternalAPIs ExternalAPIsConfig `json:"external_apis"`
-	
+
 	// Logging configuration
 	Logging LoggingConfig `json:"logging"`
+
+	// Notification configuration
+	Notifications NotificationConfig `json:"notifications"`
+
+	// Operator alerting configuration
+	Alerts AlertConfig `json:"alerts"`
+
+	// Export configuration
+	Export ExportConfig `json:"export"`
+
+	// Backup configuration
+	Backup BackupConfig `json:"backup"`
+
+	// Raw data retention configuration
+	Retention RetentionConfig `json:"retention"`
+
+	// Dashboard cache configuration
+	Cache CacheConfig `json:"cache"`
+
+	// Source license and redistribution terms
+	Terms TermsConfig `json:"terms"`
+
+	// Text embedding configuration
+	Embedding EmbeddingConfig `json:"embedding"`
+
+	// Comment toxicity scoring configuration
+	Toxicity ToxicityConfig `json:"toxicity"`
+
+	// Cross-source duplicate detection configuration
+	Dedup DedupConfig `json:"dedup"`
+
+	// Sentiment analyzer weights
+	Sentiment SentimentConfig `json:"sentiment"`
 }
 
 // ServerConfig holds server-related configuration
@@ -41,11 +76,117 @@ type ServerConfig struct {
 type ETLConfig struct {
 	MaxConcurrentExtractions int           `json:"max_concurrent_extractions"`
 	ExtractionTimeout        time.Duration `json:"extraction_timeout"`
+	SourceTimeout            time.Duration `json:"source_timeout"` // bound on extracting a single source; 0 means unbounded
 	TransformationTimeout    time.Duration `json:"transformation_timeout"`
 	LoadingTimeout           time.Duration `json:"loading_timeout"`
 	BatchSize                int           `json:"batch_size"`
 	RetryAttempts            int           `json:"retry_attempts"`
 	RetryDelay               time.Duration `json:"retry_delay"`
+	LoadFlushSize            int           `json:"load_flush_size"` // records loaded per chunk as they are transformed; 0 loads after transforming everything
+	ValidationMode           string        `json:"validation_mode"` // "quarantine", "reject" or "off": what happens to records failing validation
+	ResumeMaxAge             time.Duration `json:"resume_max_age"`  // age up to which a run interrupted by a shutdown is resumed; 0 disables checkpoints
+
+	// EnabledSources are the sources any run may extract. A source left out
+	// is skipped by every run whatever its project's settings, e.g. while
+	// its API is broken or its quota is spent. Empty enables every source.
+	EnabledSources []string `json:"enabled_sources"`
+
+	// Pipeline defaults that individual projects may override
+	Keywords         []string      `json:"keywords"`
+	Sources          []string      `json:"sources"`
+	MinRelevance     float64       `json:"min_relevance"`
+	ScheduleInterval time.Duration `json:"schedule_interval"` // 0 disables scheduled runs
+
+	// Campaigns are named queries extracted one after another in a single
+	// run, each record tagged with its campaign; empty runs Keywords as one query
+	Campaigns []CampaignConfig `json:"campaigns"`
+
+	// Source health: a source is skipped after BreakerThreshold consecutive
+	// failures until BreakerCooldown has passed, or once it has been extracted
+	// DailyBudget times in the current UTC day (0 means unlimited)
+	BreakerThreshold int           `json:"breaker_threshold"`
+	BreakerCooldown  time.Duration `json:"breaker_cooldown"`
+	DailyBudget      int           `json:"daily_budget"`
+
+	// Fault injection for resilience testing in staging
+	FaultInjection FaultInjectionConfig `json:"fault_injection"`
+
+	// Recording and replay of extractor responses as test fixtures
+	Fixtures FixturesConfig `json:"fixtures"`
+
+	// Re-fetching of ingested articles to detect edits after publication
+	UpdateCheck UpdateCheckConfig `json:"update_check"`
+
+	// Sitemap crawling of outlet archives for backfills
+	Crawler CrawlerConfig `json:"crawler"`
+
+	// Log the self-test report at server startup, before the first scheduled run
+	SelfTestOnStartup bool `json:"selftest_on_startup"`
+}
+
+// SourceEnabled reports whether SOURCES_ENABLED lets runs extract a source
+func (e ETLConfig) SourceEnabled(source string) bool {
+	if len(e.EnabledSources) == 0 {
+		return true
+	}
+	for _, enabled := range e.EnabledSources {
+		if enabled == source {
+			return true
+		}
+	}
+	return false
+}
+
+// CrawlerConfig holds the outlet sitemaps walked by the archive crawler to
+// backfill articles that the news APIs cannot search historically. Articles
+// are kept when their URL, title or keywords mention one of Tags and they
+// were published between From and To.
+type CrawlerConfig struct {
+	Sitemaps    map[string]string `json:"sitemaps"` // outlet name to sitemap or sitemap index URL
+	Tags        []string          `json:"tags"`
+	From        string            `json:"from"` // YYYY-MM-DD
+	To          string            `json:"to"`   // YYYY-MM-DD, inclusive
+	UserAgent   string            `json:"user_agent"`
+	RateLimit   RateLimitConfig   `json:"rate_limit"` // per outlet host; robots.txt Crawl-delay may slow it further
+	MaxArticles int               `json:"max_articles"`
+	Timeout     time.Duration     `json:"timeout"`
+}
+
+// UpdateCheckConfig controls how ingested articles are re-fetched to detect
+// edits: every Interval, up to BatchSize articles published within MaxAge
+// that were not checked during the last Interval are fetched again
+type UpdateCheckConfig struct {
+	Interval  time.Duration `json:"interval"` // 0 disables scheduled checks
+	MaxAge    time.Duration `json:"max_age"`
+	BatchSize int           `json:"batch_size"`
+	Timeout   time.Duration `json:"timeout"` // per article request
+}
+
+// CampaignConfig is a named query tracked by the scheduled runs, e.g. one health topic
+type CampaignConfig struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+}
+
+// FaultInjectionConfig makes extractor requests fail on purpose so retries,
+// circuit breaking and partial success can be verified. Each rate is the
+// share (0-1) of requests that get a 429, a 500 or a timeout instead of
+// reaching the API. It is ignored when ENV=production.
+type FaultInjectionConfig struct {
+	Enabled         bool          `json:"enabled"`
+	RateLimitRate   float64       `json:"rate_limit_rate"`
+	ServerErrorRate float64       `json:"server_error_rate"`
+	TimeoutRate     float64       `json:"timeout_rate"`
+	TimeoutAfter    time.Duration `json:"timeout_after"` // how long an injected timeout hangs unless the request deadline is sooner
+	Sources         []string      `json:"sources"`       // sources to inject faults into; empty means all
+}
+
+// FixturesConfig records the responses of the extractor APIs to fixture
+// files, or answers extractor requests from those files instead of calling
+// the APIs. It is ignored when ENV=production.
+type FixturesConfig struct {
+	Mode string `json:"mode"` // "off", "record" or "replay"
+	Dir  string `json:"dir"`  // one subdirectory per source
 }
 
 // APIConfig holds API-related configuration
@@ -55,11 +196,33 @@ type APIConfig struct {
 	EnableMetrics     bool   `json:"enable_metrics"`
 	RateLimitRequests int    `json:"rate_limit_requests"`
 	RateLimitWindow   string `json:"rate_limit_window"`
+	AdminAPIKey       string `json:"-"`
+	MaxResponseBytes  int    `json:"max_response_bytes"` // list responses larger than this are rejected
+
+	// Public mode: requests without an API key may only read analytics and
+	// search endpoints, without content bodies and under a per-IP rate limit
+	PublicMode              bool          `json:"public_mode"`
+	PublicRateLimitRequests int           `json:"public_rate_limit_requests"`
+	PublicRateLimitWindow   time.Duration `json:"public_rate_limit_window"`
+
+	// CORS: browser origins allowed to call the API, with credentials unless
+	// "*" allows any origin. Empty methods or headers fall back to defaults.
+	CORSAllowedOrigins []string      `json:"cors_allowed_origins"`
+	CORSAllowedMethods []string      `json:"cors_allowed_methods"`
+	CORSAllowedHeaders []string      `json:"cors_allowed_headers"`
+	CORSMaxAge         time.Duration `json:"cors_max_age"` // how long browsers may cache a preflight response
+
+	// User authentication: with a JWT secret, dashboard users log in for
+	// tokens, requests need a token or an API key, and only admins may change
+	// anything. Access tokens are renewed with refresh tokens until those expire.
+	JWTSecret       string        `json:"-"` // empty disables user authentication
+	AccessTokenTTL  time.Duration `json:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `json:"refresh_token_ttl"`
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Type      string `json:"type"`       // "sqlite", "postgres", "mysql"
+	Type      string `json:"type"` // "postgres", or "sqlite" for a local file without a server
 	Host      string `json:"host"`
 	Port      int    `json:"port"`
 	Username  string `json:"username"`
@@ -68,88 +231,442 @@ type DatabaseConfig struct {
 	SSLMode   string `json:"ssl_mode"`
 	MaxConns  int    `json:"max_connections"`
 	IdleConns int    `json:"idle_connections"`
+
+	// Destinations are the stores processed records are loaded into:
+	// "postgres" and/or "elasticsearch"
+	Destinations  []string            `json:"destinations"`
+	Elasticsearch ElasticsearchConfig `json:"elasticsearch"`
+}
+
+// ElasticsearchConfig holds the Elasticsearch index processed records are
+// loaded into for full-text search
+type ElasticsearchConfig struct {
+	URL      string        `json:"url"`
+	Index    string        `json:"index"`
+	Username string        `json:"username"`
+	Password string        `json:"-"`
+	BulkSize int           `json:"bulk_size"` // documents per bulk request
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// HasDestination reports whether processed records are loaded into the
+// named destination
+func (c DatabaseConfig) HasDestination(name string) bool {
+	for _, destination := range c.Destinations {
+		if destination == name {
+			return true
+		}
+	}
+	return false
 }
 
 // ExternalAPIsConfig holds external API configuration
 type ExternalAPIsConfig struct {
-	YouTube      YouTubeConfig      `json:"youtube"`
-	GoogleNews   GoogleNewsConfig   `json:"google_news"`
-	Instagram    InstagramConfig    `json:"instagram"`
+	YouTube       YouTubeConfig       `json:"youtube"`
+	GoogleNews    GoogleNewsConfig    `json:"google_news"`
+	Instagram     InstagramConfig     `json:"instagram"`
 	IndonesiaNews IndonesiaNewsConfig `json:"indonesia_news"`
+	Statistics    StatisticsConfig    `json:"statistics"`
+	GoogleTrends  GoogleTrendsConfig  `json:"google_trends"`
 }
 
 // YouTubeConfig holds YouTube API configuration
 type YouTubeConfig struct {
-	APIKey     string `json:"api_key"`
-	Host       string `json:"host"`
-	MaxResults int    `json:"max_results"`
-	Timeout    int    `json:"timeout"`
+	APIKey     string          `json:"api_key"`
+	Host       string          `json:"host"`
+	MaxResults int             `json:"max_results"` // videos searched for before ranking
+	Timeout    int             `json:"timeout"`
+	RateLimit  RateLimitConfig `json:"rate_limit"`
+	Quota      QuotaConfig     `json:"quota"`
+
+	// Search that discovers the videos whose comments are extracted: the
+	// CommentVideos top-ranked of MaxResults videos found in Language and Geo
+	Language      string `json:"language"`
+	Geo           string `json:"geo"`
+	CommentVideos int    `json:"comment_videos"`
+	// MaxComments bounds the comments read per video, page by page
+	MaxComments int `json:"max_comments"`
 }
 
 // GoogleNewsConfig holds Google News API configuration
 type GoogleNewsConfig struct {
-	APIKey     string `json:"api_key"`
-	Host       string `json:"host"`
-	MaxResults int    `json:"max_results"`
-	Language   string `json:"language"`
-	Country    string `json:"country"`
+	APIKey     string          `json:"api_key"`
+	Host       string          `json:"host"`
+	MaxResults int             `json:"max_results"`
+	Language   string          `json:"language"`
+	Country    string          `json:"country"`
+	RateLimit  RateLimitConfig `json:"rate_limit"`
+	Quota      QuotaConfig     `json:"quota"`
 }
 
 // InstagramConfig holds Instagram API configuration
 type InstagramConfig struct {
-	APIKey     string `json:"api_key"`
-	Host       string `json:"host"`
-	MaxResults int    `json:"max_results"`
-	Timeout    int    `json:"timeout"`
+	APIKey     string          `json:"api_key"`
+	Host       string          `json:"host"`
+	MaxResults int             `json:"max_results"` // posts read per hashtag, page by page
+	Timeout    int             `json:"timeout"`
+	RateLimit  RateLimitConfig `json:"rate_limit"`
+	Quota      QuotaConfig     `json:"quota"`
+	// Hashtags are extracted with the hashtag of each run's keywords
+	Hashtags []string `json:"hashtags"`
+	// Comments are extracted from the CommentPosts posts with the most likes
+	// and comments, up to MaxComments each; 0 posts extracts none
+	CommentPosts int `json:"comment_posts"`
+	MaxComments  int `json:"max_comments"`
+	// Locations maps the Instagram location IDs whose recent posts are
+	// extracted, e.g. hospitals and vaccination centers, to their province
+	Locations map[string]string `json:"locations"`
+}
+
+// LocationIDs returns the configured Instagram location IDs in order
+func (c InstagramConfig) LocationIDs() []string {
+	ids := make([]string, 0, len(c.Locations))
+	for id := range c.Locations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
 }
 
 // IndonesiaNewsConfig holds Indonesia News API configuration
 type IndonesiaNewsConfig struct {
-	APIKey     string `json:"api_key"`
-	Host       string `json:"host"`
-	MaxResults int    `json:"max_results"`
-	Sources    string `json:"sources"`
+	APIKey     string          `json:"api_key"`
+	Host       string          `json:"host"`
+	MaxResults int             `json:"max_results"` // items read per outlet, page by page
+	Sources    []string        `json:"sources"`     // outlets searched: cnn, detik or kompas
+	RateLimit  RateLimitConfig `json:"rate_limit"`
+	Quota      QuotaConfig     `json:"quota"`
+	// Pages are read per outlet unless SourcePages sets the outlet's own;
+	// SourceLimits sets the items per page of an outlet, the API's default
+	// otherwise
+	Pages        int            `json:"pages"`
+	SourcePages  map[string]int `json:"source_pages,omitempty"`
+	SourceLimits map[string]int `json:"source_limits,omitempty"`
+	// DetailItems is how many of the most relevant items get their full
+	// article body fetched; 0 keeps the search snippets
+	DetailItems int `json:"detail_items"`
+}
+
+// IndonesiaNewsOutlets are the outlets the Indonesia News API can search
+var IndonesiaNewsOutlets = []string{"cnn", "detik", "kompas"}
+
+// PagesFor returns how many pages of an outlet are read
+func (c IndonesiaNewsConfig) PagesFor(outlet string) int {
+	if pages, ok := c.SourcePages[outlet]; ok {
+		return pages
+	}
+	return c.Pages
+}
+
+// QuotaConfig is how many API requests of a source may be sent per UTC day
+// and per calendar month, to stay within the RapidAPI plan. 0 means no limit.
+type QuotaConfig struct {
+	Daily   int `json:"daily"`
+	Monthly int `json:"monthly"`
+}
+
+// RateLimitConfig is the token bucket of a source's API requests. Sources on
+// the same API host share one bucket. A rate of 0 disables limiting.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// RateLimitFor returns the rate limit configured for an extractor source
+func (e ExternalAPIsConfig) RateLimitFor(source string) RateLimitConfig {
+	switch source {
+	case "youtube":
+		return e.YouTube.RateLimit
+	case "google_news":
+		return e.GoogleNews.RateLimit
+	case "instagram":
+		return e.Instagram.RateLimit
+	case "indonesia_news":
+		return e.IndonesiaNews.RateLimit
+	}
+	return RateLimitConfig{}
+}
+
+// QuotaFor returns the request quota configured for an extractor source
+func (e ExternalAPIsConfig) QuotaFor(source string) QuotaConfig {
+	switch source {
+	case "youtube":
+		return e.YouTube.Quota
+	case "google_news":
+		return e.GoogleNews.Quota
+	case "instagram":
+		return e.Instagram.Quota
+	case "indonesia_news":
+		return e.IndonesiaNews.Quota
+	}
+	return QuotaConfig{}
+}
+
+// StatisticsConfig holds the official COVID-19 statistics source: the
+// covid19.go.id open data for Indonesia or the WHO global dataset
+type StatisticsConfig struct {
+	Provider        string        `json:"provider"` // "covid19.go.id", "who" or "none"
+	CasesURL        string        `json:"cases_url"`
+	VaccinationsURL string        `json:"vaccinations_url"` // covid19.go.id only
+	WHODataURL      string        `json:"who_data_url"`
+	Country         string        `json:"country"` // ISO 3166-1 alpha-2 code
+	Timeout         time.Duration `json:"timeout"`
+}
+
+// GoogleTrendsConfig holds the Google Trends search interest series stored
+// next to the official statistics
+type GoogleTrendsConfig struct {
+	Enabled   bool          `json:"enabled"`
+	Terms     []string      `json:"terms"` // compared together, at most 5
+	Geo       string        `json:"geo"`   // e.g. "ID" or "ID-JK"
+	Timeframe string        `json:"timeframe"`
+	URL       string        `json:"url"`
+	Timeout   time.Duration `json:"timeout"`
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level      string `json:"level"`       // "debug", "info", "warn", "error"
-	Format     string `json:"format"`      // "json", "text"
-	Output     string `json:"output"`      // "stdout", "file"
+	Level      string `json:"level"`  // "debug", "info", "warn", "error"
+	Format     string `json:"format"` // "json", "text"
+	Output     string `json:"output"` // "stdout", "file"
 	FilePath   string `json:"file_path"`
-	MaxSize    int    `json:"max_size"`    // MB
+	MaxSize    int    `json:"max_size"` // MB
 	MaxBackups int    `json:"max_backups"`
-	MaxAge     int    `json:"max_age"`     // days
+	MaxAge     int    `json:"max_age"` // days
+}
+
+// NotificationConfig holds outbound notification configuration
+type NotificationConfig struct {
+	SMTPHost       string        `json:"smtp_host"`
+	SMTPPort       int           `json:"smtp_port"`
+	SMTPUsername   string        `json:"smtp_username"`
+	SMTPPassword   string        `json:"-"`
+	SMTPFrom       string        `json:"smtp_from"`
+	WebhookTimeout time.Duration `json:"webhook_timeout"`
+}
+
+// AlertConfig holds the operator alerts emailed after pipeline runs: a
+// failed run, a source returning no records ZeroRecordRuns runs in a row, and
+// a day's sentiment shares moving more than SentimentShift percentage points
+// from the week before. An alert is not repeated within its suppression
+// window, Suppression unless SuppressionWindows sets one for its kind.
+type AlertConfig struct {
+	Recipients         []string                 `json:"recipients"` // empty disables alerts
+	ZeroRecordRuns     int                      `json:"zero_record_runs"`
+	SentimentShift     float64                  `json:"sentiment_shift"`
+	Suppression        time.Duration            `json:"suppression"`
+	SuppressionWindows map[string]time.Duration `json:"suppression_windows"` // by kind: run_failed, zero_records, sentiment_shift
+}
+
+// SuppressionFor returns the suppression window of an alert kind
+func (c AlertConfig) SuppressionFor(kind string) time.Duration {
+	if window, ok := c.SuppressionWindows[kind]; ok {
+		return window
+	}
+	return c.Suppression
+}
+
+// ExportConfig holds asynchronous export job configuration
+type ExportConfig struct {
+	Directory     string        `json:"directory"`
+	SigningSecret string        `json:"-"`
+	LinkTTL       time.Duration `json:"link_ttl"`
+	MaxRecords    int           `json:"max_records"`
+}
+
+// BackupConfig holds database backup configuration
+type BackupConfig struct {
+	Directory     string `json:"directory"`
+	Method        string `json:"method"` // "auto", "pg_dump" or "snapshot"
+	PgDumpPath    string `json:"pg_dump_path"`
+	PgRestorePath string `json:"pg_restore_path"`
+}
+
+// RetentionConfig holds the raw data retention policy. Raw payloads older
+// than MaxAge are archived or deleted every Interval; a MaxAge of 0 keeps
+// them forever.
+type RetentionConfig struct {
+	MaxAge     time.Duration `json:"max_age"`
+	Interval   time.Duration `json:"interval"`
+	Mode       string        `json:"mode"` // "archive" or "delete"
+	ArchiveDir string        `json:"archive_dir"`
+	BatchSize  int           `json:"batch_size"`
+	DryRun     bool          `json:"dry_run"` // only report what would be pruned
+}
+
+// CacheConfig holds dashboard query and analytics response cache
+// configuration. A TTL of 0 disables the cache.
+type CacheConfig struct {
+	TTL          time.Duration `json:"ttl"`
+	AnalyticsTTL time.Duration `json:"analytics_ttl"`
+}
+
+// TermsConfig holds the license tag of each source and the licenses whose
+// terms prohibit redistributing records, e.g. in exports
+type TermsConfig struct {
+	Licenses         map[string]string `json:"licenses"` // source -> license tag
+	NoRedistribution []string          `json:"no_redistribution"`
+}
+
+// LicenseFor returns the license tag configured for a source
+func (t TermsConfig) LicenseFor(source string) string {
+	if license, ok := t.Licenses[source]; ok {
+		return license
+	}
+	return "unspecified"
+}
+
+// RestrictedSources returns the sources whose license prohibits redistribution
+func (t TermsConfig) RestrictedSources() []string {
+	var sources []string
+	for source, license := range t.Licenses {
+		if !t.Redistributable(license) {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Redistributable reports whether records under license may be redistributed
+func (t TermsConfig) Redistributable(license string) bool {
+	for _, restricted := range t.NoRedistribution {
+		if restricted == license {
+			return false
+		}
+	}
+	return true
+}
+
+// EmbeddingConfig holds the optional text embedding provider. Embeddings are
+// stored with pgvector and power semantic search.
+type EmbeddingConfig struct {
+	Provider   string        `json:"provider"` // "" disables embeddings, "openai" for any OpenAI-compatible API
+	APIURL     string        `json:"api_url"`
+	APIKey     string        `json:"-"`
+	Model      string        `json:"model"`
+	Dimensions int           `json:"dimensions"`
+	BatchSize  int           `json:"batch_size"`
+	Timeout    time.Duration `json:"timeout"`
+}
+
+// ToxicityConfig holds the toxicity scorer for social comments: the built-in
+// wordlist, extended with Wordlist, or an external model behind APIURL
+type ToxicityConfig struct {
+	Provider string        `json:"provider"` // "wordlist", "http" or "none"
+	APIURL   string        `json:"api_url"`
+	APIKey   string        `json:"-"`
+	Wordlist []string      `json:"wordlist"` // extra abusive terms for the wordlist scorer
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// DedupConfig holds the near-duplicate detection run after each pipeline run:
+// articles published within Window of each other whose titles share at
+// least Similarity of their words are the same story
+type DedupConfig struct {
+	Similarity float64       `json:"similarity"` // title word Jaccard similarity, 0-1
+	Window     time.Duration `json:"window"`     // how far back each run compares records
+}
+
+// SentimentConfig holds the sentiment provider, the built-in keyword
+// analyzer or a remote model behind APIURL, and the tunable weights of the
+// keyword analyzer
+type SentimentConfig struct {
+	Provider  string            `json:"provider"` // "keyword" or "http"
+	APIURL    string            `json:"api_url"`
+	APIKey    string            `json:"-"`
+	Labels    map[string]string `json:"labels"` // model label => positive, negative or neutral
+	BatchSize int               `json:"batch_size"`
+	Timeout   time.Duration     `json:"timeout"`
+
+	// EmojiWeight scales every emoji and emoticon weight; 0 ignores them
+	EmojiWeight float64 `json:"emoji_weight"`
+	// EmojiWeights override or extend the built-in emoji lexicon, -1 to 1
+	EmojiWeights map[string]float64 `json:"emoji_weights"`
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
 	config := &Config{
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8000"),
-			Host:         getEnv("SERVER_HOST", "localhost"),
+			Port:         getEnv("SERVER_PORT", getEnv("PORT", "8000")),
+			Host:         getEnv("SERVER_HOST", "0.0.0.0"),
 			ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 30*time.Second),
-			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 0),
 			IdleTimeout:  getDurationEnv("SERVER_IDLE_TIMEOUT", 60*time.Second),
 		},
 		ETL: ETLConfig{
 			MaxConcurrentExtractions: getIntEnv("ETL_MAX_CONCURRENT_EXTRACTIONS", 5),
 			ExtractionTimeout:        getDurationEnv("ETL_EXTRACTION_TIMEOUT", 5*time.Minute),
+			SourceTimeout:            getDurationEnv("ETL_SOURCE_TIMEOUT", 2*time.Minute),
 			TransformationTimeout:    getDurationEnv("ETL_TRANSFORMATION_TIMEOUT", 2*time.Minute),
 			LoadingTimeout:           getDurationEnv("ETL_LOADING_TIMEOUT", 3*time.Minute),
 			BatchSize:                getIntEnv("ETL_BATCH_SIZE", 100),
 			RetryAttempts:            getIntEnv("ETL_RETRY_ATTEMPTS", 3),
 			RetryDelay:               getDurationEnv("ETL_RETRY_DELAY", 5*time.Second),
+			LoadFlushSize:            getIntEnv("ETL_LOAD_FLUSH_SIZE", 500),
+			ValidationMode:           getEnv("ETL_VALIDATION_MODE", "quarantine"),
+			ResumeMaxAge:             getDurationEnv("ETL_RESUME_MAX_AGE", 24*time.Hour),
+			Keywords:                 getListEnv("ETL_KEYWORDS", []string{"COVID-19"}),
+			EnabledSources:           getListEnv("SOURCES_ENABLED", []string{"youtube", "google_news", "instagram", "indonesia_news"}),
+			Sources:                  getListEnv("ETL_SOURCES", []string{"youtube", "google_news", "instagram", "indonesia_news"}),
+			MinRelevance:             getFloatEnv("ETL_MIN_RELEVANCE", 0),
+			ScheduleInterval:         getDurationEnv("ETL_SCHEDULE_INTERVAL", 0),
+			Campaigns:                getCampaignsEnv("ETL_CAMPAIGNS"),
+			BreakerThreshold:         getIntEnv("ETL_BREAKER_THRESHOLD", 3),
+			BreakerCooldown:          getDurationEnv("ETL_BREAKER_COOLDOWN", 30*time.Minute),
+			DailyBudget:              getIntEnv("ETL_SOURCE_DAILY_BUDGET", 0),
+			FaultInjection: FaultInjectionConfig{
+				Enabled:         getBoolEnv("FAULT_INJECTION_ENABLED", false),
+				RateLimitRate:   getFloatEnv("FAULT_INJECTION_429_RATE", 0),
+				ServerErrorRate: getFloatEnv("FAULT_INJECTION_500_RATE", 0),
+				TimeoutRate:     getFloatEnv("FAULT_INJECTION_TIMEOUT_RATE", 0),
+				TimeoutAfter:    getDurationEnv("FAULT_INJECTION_TIMEOUT_AFTER", 30*time.Second),
+				Sources:         getListEnv("FAULT_INJECTION_SOURCES", nil),
+			},
+			Fixtures: FixturesConfig{
+				Mode: getEnv("HTTP_FIXTURES_MODE", "off"),
+				Dir:  getEnv("HTTP_FIXTURES_DIR", "internal/etl/testdata/fixtures"),
+			},
+			UpdateCheck: UpdateCheckConfig{
+				Interval:  getDurationEnv("UPDATE_CHECK_INTERVAL", 24*time.Hour),
+				MaxAge:    getDurationEnv("UPDATE_CHECK_MAX_AGE", 7*24*time.Hour),
+				BatchSize: getIntEnv("UPDATE_CHECK_BATCH_SIZE", 200),
+				Timeout:   getDurationEnv("UPDATE_CHECK_TIMEOUT", 15*time.Second),
+			},
+			Crawler: CrawlerConfig{
+				Sitemaps:    getMapEnv("CRAWLER_SITEMAPS", nil),
+				Tags:        getListEnv("CRAWLER_TAGS", []string{"covid", "corona", "pandemi", "vaksin", "ppkm", "psbb"}),
+				From:        getEnv("CRAWLER_FROM", "2020-01-01"),
+				To:          getEnv("CRAWLER_TO", "2022-12-31"),
+				UserAgent:   getEnv("CRAWLER_USER_AGENT", "covid19-kms-crawler/1.0"),
+				RateLimit:   RateLimitConfig{RequestsPerSecond: getFloatEnv("CRAWLER_RATE_LIMIT", 0.5), Burst: 1},
+				MaxArticles: getIntEnv("CRAWLER_MAX_ARTICLES", 1000),
+				Timeout:     getDurationEnv("CRAWLER_TIMEOUT", 30*time.Second),
+			},
+			SelfTestOnStartup: getBoolEnv("ETL_SELFTEST_ON_STARTUP", false),
 		},
 		API: APIConfig{
-			EnableCORS:        getBoolEnv("API_ENABLE_CORS", true),
-			EnableLogging:     getBoolEnv("API_ENABLE_LOGGING", true),
-			EnableMetrics:     getBoolEnv("API_ENABLE_METRICS", true),
-			RateLimitRequests: getIntEnv("API_RATE_LIMIT_REQUESTS", 100),
-			RateLimitWindow:   getEnv("API_RATE_LIMIT_WINDOW", "1m"),
+			EnableCORS:              getBoolEnv("API_ENABLE_CORS", true),
+			EnableLogging:           getBoolEnv("API_ENABLE_LOGGING", true),
+			EnableMetrics:           getBoolEnv("API_ENABLE_METRICS", true),
+			RateLimitRequests:       getIntEnv("API_RATE_LIMIT_REQUESTS", 100),
+			RateLimitWindow:         getEnv("API_RATE_LIMIT_WINDOW", "1m"),
+			AdminAPIKey:             getEnv("ADMIN_API_KEY", ""),
+			MaxResponseBytes:        getIntEnv("API_MAX_RESPONSE_BYTES", 5*1024*1024),
+			PublicMode:              getBoolEnv("API_PUBLIC_MODE", false),
+			PublicRateLimitRequests: getIntEnv("API_PUBLIC_RATE_LIMIT_REQUESTS", 30),
+			PublicRateLimitWindow:   getDurationEnv("API_PUBLIC_RATE_LIMIT_WINDOW", time.Minute),
+			JWTSecret:               getEnv("AUTH_JWT_SECRET", ""),
+			AccessTokenTTL:          getDurationEnv("AUTH_ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:         getDurationEnv("AUTH_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			CORSAllowedOrigins:      getListEnv("API_CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://127.0.0.1:3000"}),
+			CORSAllowedMethods:      getListEnv("API_CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS"}),
+			CORSAllowedHeaders:      getListEnv("API_CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With", "X-API-Key", "X-User-ID", "X-Project-ID"}),
+			CORSMaxAge:              getDurationEnv("API_CORS_MAX_AGE", 24*time.Hour),
 		},
 		Database: DatabaseConfig{
-			Type:      getEnv("DB_TYPE", "sqlite"),
+			Type:      getEnv("DB_TYPE", "postgres"),
 			Host:      getEnv("DB_HOST", "localhost"),
 			Port:      getIntEnv("DB_PORT", 5432),
 			Username:  getEnv("DB_USERNAME", ""),
@@ -158,13 +675,29 @@ func LoadConfig() (*Config, error) {
 			SSLMode:   getEnv("DB_SSL_MODE", "disable"),
 			MaxConns:  getIntEnv("DB_MAX_CONNECTIONS", 10),
 			IdleConns: getIntEnv("DB_IDLE_CONNECTIONS", 5),
+
+			Destinations: getListEnv("LOAD_DESTINATIONS", []string{"postgres"}),
+			Elasticsearch: ElasticsearchConfig{
+				URL:      getEnv("ELASTICSEARCH_URL", "http://localhost:9200"),
+				Index:    getEnv("ELASTICSEARCH_INDEX", "covid19-kms-records"),
+				Username: getEnv("ELASTICSEARCH_USERNAME", ""),
+				Password: getEnv("ELASTICSEARCH_PASSWORD", ""),
+				BulkSize: getIntEnv("ELASTICSEARCH_BULK_SIZE", 500),
+				Timeout:  getDurationEnv("ELASTICSEARCH_TIMEOUT", 30*time.Second),
+			},
 		},
 		ExternalAPIs: ExternalAPIsConfig{
 			YouTube: YouTubeConfig{
-				APIKey:     getEnv("YOUTUBE_API_KEY", ""),
-				Host:       getEnv("YOUTUBE_HOST", "yt-api.p.rapidapi.com"),
-				MaxResults: getIntEnv("YOUTUBE_MAX_RESULTS", 50),
-				Timeout:    getIntEnv("YOUTUBE_TIMEOUT", 30),
+				APIKey:        getEnv("YOUTUBE_API_KEY", ""),
+				Host:          getEnv("YOUTUBE_HOST", "yt-api.p.rapidapi.com"),
+				MaxResults:    getIntEnv("YOUTUBE_MAX_RESULTS", 50),
+				Timeout:       getIntEnv("YOUTUBE_TIMEOUT", 30),
+				RateLimit:     RateLimitConfig{RequestsPerSecond: getFloatEnv("YOUTUBE_RATE_LIMIT", 2), Burst: getIntEnv("YOUTUBE_RATE_BURST", 2)},
+				Quota:         QuotaConfig{Daily: getIntEnv("YOUTUBE_QUOTA_DAILY", 0), Monthly: getIntEnv("YOUTUBE_QUOTA_MONTHLY", 0)},
+				Language:      getEnv("YOUTUBE_LANGUAGE", "id"),
+				Geo:           getEnv("YOUTUBE_GEO", "ID"),
+				CommentVideos: getIntEnv("YOUTUBE_COMMENT_VIDEOS", 5),
+				MaxComments:   getIntEnv("YOUTUBE_MAX_COMMENTS", 200),
 			},
 			GoogleNews: GoogleNewsConfig{
 				APIKey:     getEnv("GOOGLE_NEWS_API_KEY", ""),
@@ -172,18 +705,48 @@ func LoadConfig() (*Config, error) {
 				MaxResults: getIntEnv("GOOGLE_NEWS_MAX_RESULTS", 100),
 				Language:   getEnv("GOOGLE_NEWS_LANGUAGE", "id"),
 				Country:    getEnv("GOOGLE_NEWS_COUNTRY", "ID"),
+				RateLimit:  RateLimitConfig{RequestsPerSecond: getFloatEnv("GOOGLE_NEWS_RATE_LIMIT", 1), Burst: getIntEnv("GOOGLE_NEWS_RATE_BURST", 1)},
+				Quota:      QuotaConfig{Daily: getIntEnv("GOOGLE_NEWS_QUOTA_DAILY", 0), Monthly: getIntEnv("GOOGLE_NEWS_QUOTA_MONTHLY", 0)},
 			},
 			Instagram: InstagramConfig{
-				APIKey:     getEnv("INSTAGRAM_API_KEY", ""),
-				Host:       getEnv("INSTAGRAM_HOST", "instagram-bulk-profile-scrapper.p.rapidapi.com"),
-				MaxResults: getIntEnv("INSTAGRAM_MAX_RESULTS", 50),
-				Timeout:    getIntEnv("INSTAGRAM_TIMEOUT", 30),
+				APIKey:       getEnv("INSTAGRAM_API_KEY", ""),
+				Host:         getEnv("INSTAGRAM_HOST", "instagram-bulk-profile-scrapper.p.rapidapi.com"),
+				MaxResults:   getIntEnv("INSTAGRAM_MAX_RESULTS", 50),
+				Timeout:      getIntEnv("INSTAGRAM_TIMEOUT", 30),
+				RateLimit:    RateLimitConfig{RequestsPerSecond: getFloatEnv("INSTAGRAM_RATE_LIMIT", 1), Burst: getIntEnv("INSTAGRAM_RATE_BURST", 1)},
+				Quota:        QuotaConfig{Daily: getIntEnv("INSTAGRAM_QUOTA_DAILY", 0), Monthly: getIntEnv("INSTAGRAM_QUOTA_MONTHLY", 0)},
+				Hashtags:     getListEnv("INSTAGRAM_HASHTAGS", []string{"covid19", "vaksinasi", "pandemi"}),
+				CommentPosts: getIntEnv("INSTAGRAM_COMMENT_POSTS", 5),
+				MaxComments:  getIntEnv("INSTAGRAM_MAX_COMMENTS", 20),
+				Locations:    getMapEnv("INSTAGRAM_LOCATIONS", nil),
 			},
 			IndonesiaNews: IndonesiaNewsConfig{
-				APIKey:     getEnv("INDONESIA_NEWS_API_KEY", ""),
-				Host:       getEnv("INDONESIA_NEWS_HOST", "indonesia-news.p.rapidapi.com"),
-				MaxResults: getIntEnv("INDONESIA_NEWS_MAX_RESULTS", 100),
-				Sources:    getEnv("INDONESIA_NEWS_SOURCES", "tempo,kompas,detik"),
+				APIKey:       getEnv("INDONESIA_NEWS_API_KEY", ""),
+				Host:         getEnv("INDONESIA_NEWS_HOST", "indonesia-news.p.rapidapi.com"),
+				MaxResults:   getIntEnv("INDONESIA_NEWS_MAX_RESULTS", 100),
+				Sources:      getListEnv("INDONESIA_NEWS_SOURCES", []string{"kompas", "detik", "cnn"}),
+				RateLimit:    RateLimitConfig{RequestsPerSecond: getFloatEnv("INDONESIA_NEWS_RATE_LIMIT", 0.2), Burst: getIntEnv("INDONESIA_NEWS_RATE_BURST", 1)},
+				Quota:        QuotaConfig{Daily: getIntEnv("INDONESIA_NEWS_QUOTA_DAILY", 0), Monthly: getIntEnv("INDONESIA_NEWS_QUOTA_MONTHLY", 0)},
+				Pages:        getIntEnv("INDONESIA_NEWS_PAGES", 1),
+				SourcePages:  getIntMapEnv("INDONESIA_NEWS_SOURCE_PAGES"),
+				SourceLimits: getIntMapEnv("INDONESIA_NEWS_SOURCE_LIMITS"),
+				DetailItems:  getIntEnv("INDONESIA_NEWS_DETAIL_ITEMS", 10),
+			},
+			Statistics: StatisticsConfig{
+				Provider:        getEnv("COVID_STATS_PROVIDER", "covid19.go.id"),
+				CasesURL:        getEnv("COVID_STATS_CASES_URL", "https://data.covid19.go.id/public/api/update.json"),
+				VaccinationsURL: getEnv("COVID_STATS_VACCINATIONS_URL", "https://data.covid19.go.id/public/api/pemeriksaan-vaksinasi.json"),
+				WHODataURL:      getEnv("COVID_STATS_WHO_URL", "https://covid19.who.int/WHO-COVID-19-global-data.csv"),
+				Country:         getEnv("COVID_STATS_COUNTRY", "ID"),
+				Timeout:         getDurationEnv("COVID_STATS_TIMEOUT", 60*time.Second),
+			},
+			GoogleTrends: GoogleTrendsConfig{
+				Enabled:   getBoolEnv("GOOGLE_TRENDS_ENABLED", true),
+				Terms:     getListEnv("GOOGLE_TRENDS_TERMS", []string{"covid", "vaksin", "ppkm"}),
+				Geo:       getEnv("GOOGLE_TRENDS_GEO", "ID"),
+				Timeframe: getEnv("GOOGLE_TRENDS_TIMEFRAME", "today 3-m"),
+				URL:       getEnv("GOOGLE_TRENDS_URL", "https://trends.google.com/trends/api"),
+				Timeout:   getDurationEnv("GOOGLE_TRENDS_TIMEOUT", 30*time.Second),
 			},
 		},
 		Logging: LoggingConfig{
@@ -195,6 +758,85 @@ func LoadConfig() (*Config, error) {
 			MaxBackups: getIntEnv("LOG_MAX_BACKUPS", 3),
 			MaxAge:     getIntEnv("LOG_MAX_AGE", 7),
 		},
+		Notifications: NotificationConfig{
+			SMTPHost:       getEnv("SMTP_HOST", ""),
+			SMTPPort:       getIntEnv("SMTP_PORT", 587),
+			SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:       getEnv("SMTP_FROM", "covid19-kms@localhost"),
+			WebhookTimeout: getDurationEnv("NOTIFY_WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Alerts: AlertConfig{
+			Recipients:         getListEnv("ALERT_EMAILS", nil),
+			ZeroRecordRuns:     getIntEnv("ALERT_ZERO_RECORD_RUNS", 3),
+			SentimentShift:     getFloatEnv("ALERT_SENTIMENT_SHIFT", 15),
+			Suppression:        getDurationEnv("ALERT_SUPPRESSION", 6*time.Hour),
+			SuppressionWindows: getDurationMapEnv("ALERT_SUPPRESSION_WINDOWS"),
+		},
+		Export: ExportConfig{
+			Directory:     getEnv("EXPORT_DIR", "exports"),
+			SigningSecret: getEnv("EXPORT_SIGNING_SECRET", ""),
+			LinkTTL:       getDurationEnv("EXPORT_LINK_TTL", 15*time.Minute),
+			MaxRecords:    getIntEnv("EXPORT_MAX_RECORDS", 100000),
+		},
+		Backup: BackupConfig{
+			Directory:     getEnv("BACKUP_DIR", "backups"),
+			Method:        getEnv("BACKUP_METHOD", "auto"),
+			PgDumpPath:    getEnv("BACKUP_PG_DUMP_PATH", "pg_dump"),
+			PgRestorePath: getEnv("BACKUP_PG_RESTORE_PATH", "pg_restore"),
+		},
+		Retention: RetentionConfig{
+			MaxAge:     getDurationEnv("RETENTION_RAW_DATA_MAX_AGE", 0),
+			Interval:   getDurationEnv("RETENTION_INTERVAL", 24*time.Hour),
+			Mode:       getEnv("RETENTION_MODE", "archive"),
+			ArchiveDir: getEnv("RETENTION_ARCHIVE_DIR", "archives"),
+			BatchSize:  getIntEnv("RETENTION_BATCH_SIZE", 1000),
+			DryRun:     getBoolEnv("RETENTION_DRY_RUN", false),
+		},
+		Cache: CacheConfig{
+			TTL:          getDurationEnv("DASHBOARD_CACHE_TTL", 10*time.Minute),
+			AnalyticsTTL: getDurationEnv("ANALYTICS_CACHE_TTL", 5*time.Minute),
+		},
+		Terms: TermsConfig{
+			Licenses: getMapEnv("SOURCE_LICENSES", map[string]string{
+				"youtube":        "youtube-tos",
+				"google_news":    "publisher-copyright",
+				"instagram":      "instagram-tos",
+				"indonesia_news": "publisher-copyright",
+				"news_archive":   "publisher-copyright",
+			}),
+			NoRedistribution: getListEnv("LICENSES_NO_REDISTRIBUTION", []string{"youtube-tos", "instagram-tos"}),
+		},
+		Embedding: EmbeddingConfig{
+			Provider:   getEnv("EMBEDDING_PROVIDER", ""),
+			APIURL:     getEnv("EMBEDDING_API_URL", "https://api.openai.com/v1/embeddings"),
+			APIKey:     getEnv("EMBEDDING_API_KEY", ""),
+			Model:      getEnv("EMBEDDING_MODEL", "text-embedding-3-small"),
+			Dimensions: getIntEnv("EMBEDDING_DIMENSIONS", 1536),
+			BatchSize:  getIntEnv("EMBEDDING_BATCH_SIZE", 64),
+			Timeout:    getDurationEnv("EMBEDDING_TIMEOUT", 30*time.Second),
+		},
+		Toxicity: ToxicityConfig{
+			Provider: getEnv("TOXICITY_PROVIDER", "wordlist"),
+			APIURL:   getEnv("TOXICITY_API_URL", ""),
+			APIKey:   getEnv("TOXICITY_API_KEY", ""),
+			Wordlist: getListEnv("TOXICITY_WORDLIST", nil),
+			Timeout:  getDurationEnv("TOXICITY_TIMEOUT", 10*time.Second),
+		},
+		Dedup: DedupConfig{
+			Similarity: getFloatEnv("DEDUP_SIMILARITY", 0.6),
+			Window:     getDurationEnv("DEDUP_WINDOW", 72*time.Hour),
+		},
+		Sentiment: SentimentConfig{
+			Provider:     getEnv("SENTIMENT_PROVIDER", "keyword"),
+			APIURL:       getEnv("SENTIMENT_API_URL", ""),
+			APIKey:       getEnv("SENTIMENT_API_KEY", ""),
+			Labels:       getMapEnv("SENTIMENT_API_LABELS", nil),
+			BatchSize:    getIntEnv("SENTIMENT_BATCH_SIZE", 32),
+			Timeout:      getDurationEnv("SENTIMENT_TIMEOUT", 30*time.Second),
+			EmojiWeight:  getFloatEnv("SENTIMENT_EMOJI_WEIGHT", 1.0),
+			EmojiWeights: getFloatMapEnv("SENTIMENT_EMOJI_WEIGHTS"),
+		},
 	}
 
 	return config, nil
@@ -240,15 +882,148 @@ func getIntEnv(key string, defaultValue int) int {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
+		recordMalformedEnv(key, value, "integer")
 	}
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		recordMalformedEnv(key, value, "number")
+	}
+	return defaultValue
+}
+
+// getListEnv parses a comma-separated list, ignoring empty items
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return defaultValue
+	}
+	return items
+}
+
+// getMapEnv parses comma separated key=value pairs, e.g. "youtube=youtube-tos,instagram=instagram-tos"
+func getMapEnv(key string, defaultValue map[string]string) map[string]string {
+	items := getListEnv(key, nil)
+	if len(items) == 0 {
+		return defaultValue
+	}
+
+	values := make(map[string]string)
+	for _, item := range items {
+		if k, v, ok := strings.Cut(item, "="); ok && strings.TrimSpace(k) != "" {
+			values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getFloatMapEnv parses comma separated key=number pairs, e.g. "😷=-0.2,🙏=0.6".
+// Pairs whose value is not a number are skipped and reported by Validate.
+func getFloatMapEnv(key string) map[string]float64 {
+	pairs := getMapEnv(key, nil)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	values := make(map[string]float64, len(pairs))
+	for k, v := range pairs {
+		floatValue, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			recordMalformedEnv(key, k+"="+v, "key=number pair")
+			continue
+		}
+		values[k] = floatValue
+	}
+	return values
+}
+
+// getIntMapEnv parses comma separated key=integer pairs, e.g. "detik=3,cnn=2".
+// Pairs whose value is not an integer are skipped and reported by Validate.
+func getIntMapEnv(key string) map[string]int {
+	pairs := getMapEnv(key, nil)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	values := make(map[string]int, len(pairs))
+	for k, v := range pairs {
+		intValue, err := strconv.Atoi(v)
+		if err != nil {
+			recordMalformedEnv(key, k+"="+v, "key=integer pair")
+			continue
+		}
+		values[k] = intValue
+	}
+	return values
+}
+
+// getDurationMapEnv parses comma separated key=duration pairs, e.g.
+// "run_failed=1h,zero_records=24h". Pairs whose value is not a duration are
+// skipped and reported by Validate.
+func getDurationMapEnv(key string) map[string]time.Duration {
+	pairs := getMapEnv(key, nil)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	values := make(map[string]time.Duration, len(pairs))
+	for k, v := range pairs {
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			recordMalformedEnv(key, k+"="+v, "key=duration pair")
+			continue
+		}
+		values[k] = duration
+	}
+	return values
+}
+
+// getCampaignsEnv parses campaigns as comma separated name=keywords pairs
+// with the keywords separated by "|", e.g. "covid=COVID-19|corona,dengue=DBD|demam berdarah".
+// Campaigns are returned sorted by name.
+func getCampaignsEnv(key string) []CampaignConfig {
+	pairs := getMapEnv(key, nil)
+
+	campaigns := make([]CampaignConfig, 0, len(pairs))
+	for name, value := range pairs {
+		campaign := CampaignConfig{Name: name}
+		for _, keyword := range strings.Split(value, "|") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				campaign.Keywords = append(campaign.Keywords, keyword)
+			}
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].Name < campaigns[j].Name
+	})
+	return campaigns
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
 			return boolValue
 		}
+		recordMalformedEnv(key, value, "boolean")
 	}
 	return defaultValue
 }
@@ -258,6 +1033,7 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
+		recordMalformedEnv(key, value, "duration")
 	}
 	return defaultValue
 }
@@ -0,0 +1,484 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidationError is an invalid or missing setting, named by its environment variable
+type ValidationError struct {
+	EnvVar  string `json:"env_var"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.EnvVar, e.Message)
+}
+
+// ValidationErrors lists every invalid or missing setting found by Validate
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d invalid settings: %s", len(e), strings.Join(messages, "; "))
+}
+
+// malformedEnv records the environment variables whose value could not be
+// parsed by the typed getters, which fall back to their default. Validate
+// reports them so a typo does not silently run with the default.
+var malformedEnv = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// recordMalformedEnv notes that key holds a value that is not of kind
+func recordMalformedEnv(key, value, kind string) {
+	malformedEnv.Lock()
+	defer malformedEnv.Unlock()
+	malformedEnv.values[key] = fmt.Sprintf("%q is not a valid %s", value, kind)
+}
+
+// extractorSources are the source names accepted in ETL_SOURCES
+var extractorSources = []string{"youtube", "google_news", "instagram", "indonesia_news"}
+
+// loadDestinations are the destination names accepted in LOAD_DESTINATIONS
+var loadDestinations = []string{"postgres", "elasticsearch"}
+
+// databaseTypes are the storage backends accepted in DB_TYPE
+var databaseTypes = []string{"postgres", "sqlite"}
+
+// validator collects the errors of a Validate pass
+type validator struct {
+	errors ValidationErrors
+}
+
+func (v *validator) add(envVar, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{EnvVar: envVar, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(envVar, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(envVar, "is required")
+	}
+}
+
+func (v *validator) positive(envVar string, value int) {
+	if value <= 0 {
+		v.add(envVar, "must be greater than 0, got %d", value)
+	}
+}
+
+func (v *validator) nonNegative(envVar string, value int) {
+	if value < 0 {
+		v.add(envVar, "must not be negative, got %d", value)
+	}
+}
+
+func (v *validator) positiveDuration(envVar string, value time.Duration) {
+	if value <= 0 {
+		v.add(envVar, "must be a positive duration, got %v", value)
+	}
+}
+
+func (v *validator) nonNegativeDuration(envVar string, value time.Duration) {
+	if value < 0 {
+		v.add(envVar, "must not be negative, got %v", value)
+	}
+}
+
+func (v *validator) share(envVar string, value float64) {
+	if value < 0 || value > 1 {
+		v.add(envVar, "must be between 0 and 1, got %g", value)
+	}
+}
+
+func (v *validator) port(envVar string, value int) {
+	if value < 1 || value > 65535 {
+		v.add(envVar, "must be a port between 1 and 65535, got %d", value)
+	}
+}
+
+func (v *validator) oneOf(envVar, value string, allowed ...string) {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return
+		}
+	}
+	v.add(envVar, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+// Validate checks the loaded settings and the environment the rest of the
+// system reads directly, returning ValidationErrors listing every invalid or
+// missing setting, or nil when the configuration is usable
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	malformedEnv.Lock()
+	for key, message := range malformedEnv.values {
+		v.add(key, "%s, the default is used instead", message)
+	}
+	malformedEnv.Unlock()
+
+	port, err := strconv.Atoi(c.Server.Port)
+	if err != nil {
+		v.add("SERVER_PORT", "%q is not a valid integer", c.Server.Port)
+	} else {
+		v.port("SERVER_PORT", port)
+	}
+	v.positiveDuration("SERVER_READ_TIMEOUT", c.Server.ReadTimeout)
+	v.nonNegativeDuration("SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout)
+	v.positiveDuration("SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout)
+
+	c.validateETL(v)
+	c.validateAPI(v)
+	c.validateExternalAPIs(v)
+	c.validateServices(v)
+	c.validateDestinations(v)
+	v.oneOf("DB_TYPE", c.Database.Type, databaseTypes...)
+	if c.Database.Type == "sqlite" {
+		v.required("DB_DATABASE", c.Database.Database)
+	} else {
+		validateDatabaseEnv(v)
+	}
+
+	if len(v.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		return v.errors[i].EnvVar < v.errors[j].EnvVar
+	})
+	return v.errors
+}
+
+func (c *Config) validateETL(v *validator) {
+	etl := c.ETL
+	v.positive("ETL_MAX_CONCURRENT_EXTRACTIONS", etl.MaxConcurrentExtractions)
+	v.positiveDuration("ETL_EXTRACTION_TIMEOUT", etl.ExtractionTimeout)
+	v.nonNegativeDuration("ETL_SOURCE_TIMEOUT", etl.SourceTimeout)
+	v.positiveDuration("ETL_TRANSFORMATION_TIMEOUT", etl.TransformationTimeout)
+	v.positiveDuration("ETL_LOADING_TIMEOUT", etl.LoadingTimeout)
+	v.positive("ETL_BATCH_SIZE", etl.BatchSize)
+	v.nonNegative("ETL_RETRY_ATTEMPTS", etl.RetryAttempts)
+	v.nonNegativeDuration("ETL_RETRY_DELAY", etl.RetryDelay)
+	v.nonNegative("ETL_LOAD_FLUSH_SIZE", etl.LoadFlushSize)
+	v.oneOf("ETL_VALIDATION_MODE", etl.ValidationMode, "quarantine", "reject", "off")
+	v.nonNegativeDuration("ETL_RESUME_MAX_AGE", etl.ResumeMaxAge)
+	v.share("ETL_MIN_RELEVANCE", etl.MinRelevance)
+	v.nonNegativeDuration("ETL_SCHEDULE_INTERVAL", etl.ScheduleInterval)
+	v.nonNegative("ETL_BREAKER_THRESHOLD", etl.BreakerThreshold)
+	v.nonNegativeDuration("ETL_BREAKER_COOLDOWN", etl.BreakerCooldown)
+	v.nonNegative("ETL_SOURCE_DAILY_BUDGET", etl.DailyBudget)
+
+	if len(etl.Keywords) == 0 {
+		v.add("ETL_KEYWORDS", "must list at least one keyword")
+	}
+	for _, source := range etl.EnabledSources {
+		v.oneOf("SOURCES_ENABLED", source, extractorSources...)
+	}
+	for _, source := range etl.Sources {
+		v.oneOf("ETL_SOURCES", source, extractorSources...)
+	}
+	for _, campaign := range etl.Campaigns {
+		if len(campaign.Keywords) == 0 {
+			v.add("ETL_CAMPAIGNS", "campaign %q has no keywords", campaign.Name)
+		}
+	}
+
+	faults := etl.FaultInjection
+	v.share("FAULT_INJECTION_429_RATE", faults.RateLimitRate)
+	v.share("FAULT_INJECTION_500_RATE", faults.ServerErrorRate)
+	v.share("FAULT_INJECTION_TIMEOUT_RATE", faults.TimeoutRate)
+	if total := faults.RateLimitRate + faults.ServerErrorRate + faults.TimeoutRate; total > 1 {
+		v.add("FAULT_INJECTION_429_RATE", "fault rates must add up to at most 1, got %g", total)
+	}
+	v.nonNegativeDuration("FAULT_INJECTION_TIMEOUT_AFTER", faults.TimeoutAfter)
+	for _, source := range faults.Sources {
+		v.oneOf("FAULT_INJECTION_SOURCES", source, append(extractorSources, "covid_statistics")...)
+	}
+	v.oneOf("HTTP_FIXTURES_MODE", etl.Fixtures.Mode, "off", "record", "replay")
+	if etl.Fixtures.Mode != "off" {
+		v.required("HTTP_FIXTURES_DIR", etl.Fixtures.Dir)
+	}
+
+	updates := etl.UpdateCheck
+	v.nonNegativeDuration("UPDATE_CHECK_INTERVAL", updates.Interval)
+	v.positiveDuration("UPDATE_CHECK_MAX_AGE", updates.MaxAge)
+	v.positive("UPDATE_CHECK_BATCH_SIZE", updates.BatchSize)
+	v.positiveDuration("UPDATE_CHECK_TIMEOUT", updates.Timeout)
+
+	crawler := etl.Crawler
+	for outlet, sitemap := range crawler.Sitemaps {
+		if !strings.HasPrefix(sitemap, "http://") && !strings.HasPrefix(sitemap, "https://") {
+			v.add("CRAWLER_SITEMAPS", "sitemap of %s must be an http(s) URL, got %q", outlet, sitemap)
+		}
+	}
+	from, fromErr := time.Parse("2006-01-02", crawler.From)
+	if fromErr != nil {
+		v.add("CRAWLER_FROM", "%q is not a YYYY-MM-DD date", crawler.From)
+	}
+	to, toErr := time.Parse("2006-01-02", crawler.To)
+	if toErr != nil {
+		v.add("CRAWLER_TO", "%q is not a YYYY-MM-DD date", crawler.To)
+	}
+	if fromErr == nil && toErr == nil && to.Before(from) {
+		v.add("CRAWLER_TO", "must not be before CRAWLER_FROM")
+	}
+	v.required("CRAWLER_USER_AGENT", crawler.UserAgent)
+	if crawler.RateLimit.RequestsPerSecond <= 0 {
+		v.add("CRAWLER_RATE_LIMIT", "must be greater than 0, got %g", crawler.RateLimit.RequestsPerSecond)
+	}
+	v.positive("CRAWLER_MAX_ARTICLES", crawler.MaxArticles)
+	v.positiveDuration("CRAWLER_TIMEOUT", crawler.Timeout)
+}
+
+func (c *Config) validateAPI(v *validator) {
+	v.nonNegative("API_RATE_LIMIT_REQUESTS", c.API.RateLimitRequests)
+	if _, err := time.ParseDuration(c.API.RateLimitWindow); err != nil {
+		v.add("API_RATE_LIMIT_WINDOW", "%q is not a valid duration", c.API.RateLimitWindow)
+	}
+	v.positive("API_MAX_RESPONSE_BYTES", c.API.MaxResponseBytes)
+	if c.API.PublicMode {
+		v.positive("API_PUBLIC_RATE_LIMIT_REQUESTS", c.API.PublicRateLimitRequests)
+		v.positiveDuration("API_PUBLIC_RATE_LIMIT_WINDOW", c.API.PublicRateLimitWindow)
+	}
+	if c.API.EnableCORS {
+		for _, origin := range c.API.CORSAllowedOrigins {
+			if origin == "*" {
+				if c.IsProduction() {
+					v.add("API_CORS_ALLOWED_ORIGINS", "must list the dashboard origins in production instead of \"*\"")
+				}
+				continue
+			}
+			if parsed, err := url.Parse(origin); err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Path != "" {
+				v.add("API_CORS_ALLOWED_ORIGINS", "%q is not an origin like https://dashboard.example.com", origin)
+			}
+		}
+		v.nonNegativeDuration("API_CORS_MAX_AGE", c.API.CORSMaxAge)
+	}
+	if c.API.JWTSecret != "" {
+		if len(c.API.JWTSecret) < 32 {
+			v.add("AUTH_JWT_SECRET", "must be at least 32 characters long")
+		}
+		v.positiveDuration("AUTH_ACCESS_TOKEN_TTL", c.API.AccessTokenTTL)
+		v.positiveDuration("AUTH_REFRESH_TOKEN_TTL", c.API.RefreshTokenTTL)
+		if c.API.RefreshTokenTTL < c.API.AccessTokenTTL {
+			v.add("AUTH_REFRESH_TOKEN_TTL", "must not be shorter than AUTH_ACCESS_TOKEN_TTL (%s)", c.API.AccessTokenTTL)
+		}
+	}
+}
+
+func (c *Config) validateExternalAPIs(v *validator) {
+	// Every extractor client authenticates with the shared RapidAPI key
+	if key := os.Getenv("RAPIDAPI_KEY"); key == "" {
+		v.add("RAPIDAPI_KEY", "is required")
+	} else if len(key) < 10 {
+		v.add("RAPIDAPI_KEY", "is too short to be a RapidAPI key")
+	}
+
+	apis := c.ExternalAPIs
+	v.required("YOUTUBE_HOST", apis.YouTube.Host)
+	v.positive("YOUTUBE_MAX_RESULTS", apis.YouTube.MaxResults)
+	v.positive("YOUTUBE_COMMENT_VIDEOS", apis.YouTube.CommentVideos)
+	v.positive("YOUTUBE_MAX_COMMENTS", apis.YouTube.MaxComments)
+	v.positive("YOUTUBE_TIMEOUT", apis.YouTube.Timeout)
+	v.required("GOOGLE_NEWS_HOST", apis.GoogleNews.Host)
+	v.positive("GOOGLE_NEWS_MAX_RESULTS", apis.GoogleNews.MaxResults)
+	v.required("INSTAGRAM_HOST", apis.Instagram.Host)
+	v.positive("INSTAGRAM_MAX_RESULTS", apis.Instagram.MaxResults)
+	v.positive("INSTAGRAM_TIMEOUT", apis.Instagram.Timeout)
+	v.nonNegative("INSTAGRAM_COMMENT_POSTS", apis.Instagram.CommentPosts)
+	v.positive("INSTAGRAM_MAX_COMMENTS", apis.Instagram.MaxComments)
+	for _, hashtag := range apis.Instagram.Hashtags {
+		if strings.ContainsAny(hashtag, "# ") {
+			v.add("INSTAGRAM_HASHTAGS", "hashtag %q must be given without # and spaces", hashtag)
+		}
+	}
+	for _, id := range apis.Instagram.LocationIDs() {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			v.add("INSTAGRAM_LOCATIONS", "location ID %q is not numeric", id)
+		}
+		if apis.Instagram.Locations[id] == "" {
+			v.add("INSTAGRAM_LOCATIONS", "location %s has no province", id)
+		}
+	}
+	v.required("INDONESIA_NEWS_HOST", apis.IndonesiaNews.Host)
+	v.positive("INDONESIA_NEWS_MAX_RESULTS", apis.IndonesiaNews.MaxResults)
+	v.positive("INDONESIA_NEWS_PAGES", apis.IndonesiaNews.Pages)
+	v.nonNegative("INDONESIA_NEWS_DETAIL_ITEMS", apis.IndonesiaNews.DetailItems)
+	if len(apis.IndonesiaNews.Sources) == 0 {
+		v.add("INDONESIA_NEWS_SOURCES", "must list at least one outlet")
+	}
+	for _, outlet := range apis.IndonesiaNews.Sources {
+		v.oneOf("INDONESIA_NEWS_SOURCES", outlet, IndonesiaNewsOutlets...)
+	}
+	for envVar, values := range map[string]map[string]int{
+		"INDONESIA_NEWS_SOURCE_PAGES":  apis.IndonesiaNews.SourcePages,
+		"INDONESIA_NEWS_SOURCE_LIMITS": apis.IndonesiaNews.SourceLimits,
+	} {
+		for outlet, value := range values {
+			v.oneOf(envVar, outlet, IndonesiaNewsOutlets...)
+			v.positive(envVar, value)
+		}
+	}
+
+	for _, source := range extractorSources {
+		prefix := strings.ToUpper(source)
+		limit := apis.RateLimitFor(source)
+		if limit.RequestsPerSecond < 0 {
+			v.add(prefix+"_RATE_LIMIT", "must not be negative, got %g", limit.RequestsPerSecond)
+		}
+		v.nonNegative(prefix+"_RATE_BURST", limit.Burst)
+		quota := apis.QuotaFor(source)
+		v.nonNegative(prefix+"_QUOTA_DAILY", quota.Daily)
+		v.nonNegative(prefix+"_QUOTA_MONTHLY", quota.Monthly)
+	}
+
+	stats := apis.Statistics
+	v.oneOf("COVID_STATS_PROVIDER", stats.Provider, "covid19.go.id", "who", "none")
+	switch stats.Provider {
+	case "covid19.go.id":
+		v.required("COVID_STATS_CASES_URL", stats.CasesURL)
+	case "who":
+		v.required("COVID_STATS_WHO_URL", stats.WHODataURL)
+		v.required("COVID_STATS_COUNTRY", stats.Country)
+	}
+	v.positiveDuration("COVID_STATS_TIMEOUT", stats.Timeout)
+
+	trends := apis.GoogleTrends
+	if trends.Enabled {
+		v.required("GOOGLE_TRENDS_TERMS", strings.Join(trends.Terms, ","))
+		if len(trends.Terms) > 5 {
+			v.add("GOOGLE_TRENDS_TERMS", "at most 5 terms can be compared, got %d", len(trends.Terms))
+		}
+		v.required("GOOGLE_TRENDS_GEO", trends.Geo)
+		v.required("GOOGLE_TRENDS_TIMEFRAME", trends.Timeframe)
+		v.required("GOOGLE_TRENDS_URL", trends.URL)
+		v.positiveDuration("GOOGLE_TRENDS_TIMEOUT", trends.Timeout)
+	}
+}
+
+func (c *Config) validateServices(v *validator) {
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.Logging.Level), "debug", "info", "warn", "error")
+	v.oneOf("LOG_FORMAT", c.Logging.Format, "json", "text")
+
+	v.port("SMTP_PORT", c.Notifications.SMTPPort)
+	v.positiveDuration("NOTIFY_WEBHOOK_TIMEOUT", c.Notifications.WebhookTimeout)
+
+	if len(c.Alerts.Recipients) > 0 {
+		v.required("SMTP_HOST", c.Notifications.SMTPHost)
+		v.positive("ALERT_ZERO_RECORD_RUNS", c.Alerts.ZeroRecordRuns)
+		if c.Alerts.SentimentShift <= 0 || c.Alerts.SentimentShift > 100 {
+			v.add("ALERT_SENTIMENT_SHIFT", "must be between 0 and 100 percentage points, got %g", c.Alerts.SentimentShift)
+		}
+		v.nonNegativeDuration("ALERT_SUPPRESSION", c.Alerts.Suppression)
+		for kind, window := range c.Alerts.SuppressionWindows {
+			if kind != "run_failed" && kind != "zero_records" && kind != "sentiment_shift" {
+				v.add("ALERT_SUPPRESSION_WINDOWS", "unknown alert kind %q; use run_failed, zero_records or sentiment_shift", kind)
+			}
+			if window < 0 {
+				v.add("ALERT_SUPPRESSION_WINDOWS", "window of %s must not be negative, got %s", kind, window)
+			}
+		}
+	}
+
+	v.required("EXPORT_DIR", c.Export.Directory)
+	v.positiveDuration("EXPORT_LINK_TTL", c.Export.LinkTTL)
+	v.positive("EXPORT_MAX_RECORDS", c.Export.MaxRecords)
+
+	v.required("BACKUP_DIR", c.Backup.Directory)
+	v.oneOf("BACKUP_METHOD", c.Backup.Method, "auto", "pg_dump", "snapshot")
+
+	v.nonNegativeDuration("RETENTION_RAW_DATA_MAX_AGE", c.Retention.MaxAge)
+	v.positiveDuration("RETENTION_INTERVAL", c.Retention.Interval)
+	v.oneOf("RETENTION_MODE", c.Retention.Mode, "archive", "delete")
+	if c.Retention.Mode == "archive" {
+		v.required("RETENTION_ARCHIVE_DIR", c.Retention.ArchiveDir)
+	}
+	v.positive("RETENTION_BATCH_SIZE", c.Retention.BatchSize)
+
+	v.positiveDuration("DASHBOARD_CACHE_TTL", c.Cache.TTL)
+	v.nonNegativeDuration("ANALYTICS_CACHE_TTL", c.Cache.AnalyticsTTL)
+
+	if c.Embedding.Provider != "" {
+		v.oneOf("EMBEDDING_PROVIDER", c.Embedding.Provider, "openai")
+		v.required("EMBEDDING_API_URL", c.Embedding.APIURL)
+		v.required("EMBEDDING_API_KEY", c.Embedding.APIKey)
+		v.required("EMBEDDING_MODEL", c.Embedding.Model)
+		v.positive("EMBEDDING_DIMENSIONS", c.Embedding.Dimensions)
+		v.positive("EMBEDDING_BATCH_SIZE", c.Embedding.BatchSize)
+		v.positiveDuration("EMBEDDING_TIMEOUT", c.Embedding.Timeout)
+	}
+
+	v.oneOf("TOXICITY_PROVIDER", c.Toxicity.Provider, "wordlist", "http", "none")
+	if c.Toxicity.Provider == "http" {
+		v.required("TOXICITY_API_URL", c.Toxicity.APIURL)
+		v.positiveDuration("TOXICITY_TIMEOUT", c.Toxicity.Timeout)
+	}
+
+	v.share("DEDUP_SIMILARITY", c.Dedup.Similarity)
+	v.positiveDuration("DEDUP_WINDOW", c.Dedup.Window)
+
+	v.oneOf("SENTIMENT_PROVIDER", c.Sentiment.Provider, "keyword", "http")
+	if c.Sentiment.Provider == "http" {
+		v.required("SENTIMENT_API_URL", c.Sentiment.APIURL)
+		v.positive("SENTIMENT_BATCH_SIZE", c.Sentiment.BatchSize)
+		v.positiveDuration("SENTIMENT_TIMEOUT", c.Sentiment.Timeout)
+		for label, category := range c.Sentiment.Labels {
+			if category != "positive" && category != "negative" && category != "neutral" {
+				v.add("SENTIMENT_API_LABELS", "label %s must map to positive, negative or neutral, got %q", label, category)
+			}
+		}
+	}
+	if c.Sentiment.EmojiWeight < 0 {
+		v.add("SENTIMENT_EMOJI_WEIGHT", "must not be negative, got %g", c.Sentiment.EmojiWeight)
+	}
+	for emoji, weight := range c.Sentiment.EmojiWeights {
+		if weight < -1 || weight > 1 {
+			v.add("SENTIMENT_EMOJI_WEIGHTS", "weight of %s must be between -1 and 1, got %g", emoji, weight)
+		}
+	}
+}
+
+// validateDatabaseEnv checks the connection settings read by the database
+// package, unless the database is skipped or DATABASE_URL overrides them
+func (c *Config) validateDestinations(v *validator) {
+	db := c.Database
+	if len(db.Destinations) == 0 {
+		v.add("LOAD_DESTINATIONS", "must list at least one destination")
+	}
+	for _, destination := range db.Destinations {
+		v.oneOf("LOAD_DESTINATIONS", destination, loadDestinations...)
+	}
+	if db.HasDestination("elasticsearch") {
+		v.required("ELASTICSEARCH_URL", db.Elasticsearch.URL)
+		v.required("ELASTICSEARCH_INDEX", db.Elasticsearch.Index)
+		v.positive("ELASTICSEARCH_BULK_SIZE", db.Elasticsearch.BulkSize)
+		v.positiveDuration("ELASTICSEARCH_TIMEOUT", db.Elasticsearch.Timeout)
+	}
+}
+
+func validateDatabaseEnv(v *validator) {
+	if os.Getenv("SKIP_DATABASE") == "true" || os.Getenv("DATABASE_URL") != "" {
+		return
+	}
+	for _, key := range []string{"DATABASE_HOST", "DATABASE_PORT", "DATABASE_USER", "DATABASE_NAME"} {
+		v.required(key, os.Getenv(key))
+	}
+	if value := os.Getenv("DATABASE_PORT"); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			v.add("DATABASE_PORT", "%q is not a valid integer", value)
+		} else {
+			v.port("DATABASE_PORT", port)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package etl
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// Stages of a campaign a checkpoint records, in the order they complete
+const (
+	stageExtracted   = "extracted"
+	stageTransformed = "transformed"
+	stageLoaded      = "loaded"
+)
+
+// checkpointState holds the outputs of a campaign's completed stages that a
+// resumed run reuses. The extracted payloads stay in raw_data.
+type checkpointState struct {
+	ExtractedAt string           `json:"extracted_at"`
+	Summaries   []SourceSummary  `json:"summaries"`
+	Transformed *TransformedData `json:"transformed,omitempty"`
+	Loaded      *LoadResult      `json:"loaded,omitempty"`
+}
+
+// interruptedRun returns the batch ID and the checkpoints, by campaign index,
+// of the project's run interrupted by a shutdown at most eo.resumeMaxAge ago.
+// Checkpoints of the project's other runs that are no longer in progress are
+// dropped, since they are too old or superseded. Failures are logged only;
+// the new run then starts from scratch.
+func (eo *ETLOrchestrator) interruptedRun(projectID string) (string, map[int]database.PipelineCheckpoint) {
+	if eo.resumeMaxAge <= 0 {
+		return "", nil
+	}
+
+	found, err := database.GetInterruptedPipelineRun(projectID, time.Now().Add(-eo.resumeMaxAge))
+	if err != nil {
+		log.Printf("⚠️ Failed to look up interrupted pipeline runs: %v", err)
+		return "", nil
+	}
+
+	active := activeBatchIDs(projectID)
+	batchID := ""
+	if len(found) > 0 && !active[found[0].BatchID] {
+		batchID = found[0].BatchID
+	}
+	keep := []string{batchID}
+	for id := range active {
+		keep = append(keep, id)
+	}
+	if _, err := database.DeletePipelineCheckpointsExcept(projectID, keep); err != nil {
+		log.Printf("⚠️ Failed to drop stale pipeline checkpoints: %v", err)
+	}
+	if batchID == "" {
+		return "", nil
+	}
+
+	checkpoints := make(map[int]database.PipelineCheckpoint, len(found))
+	for _, checkpoint := range found {
+		checkpoints[checkpoint.CampaignIndex] = checkpoint
+	}
+	return batchID, checkpoints
+}
+
+// campaignCheckpoint returns the checkpoint the i-th campaign of a run keeps
+// up to date, or nil when checkpoints are disabled. It is the campaign's
+// checkpoint in interrupted unless the campaign was changed since.
+func (eo *ETLOrchestrator) campaignCheckpoint(result *ETLResult, i int, campaign campaignSettings, interrupted map[int]database.PipelineCheckpoint) *database.PipelineCheckpoint {
+	if eo.resumeMaxAge <= 0 {
+		return nil
+	}
+
+	query := campaign.settings.Query()
+	if checkpoint, ok := interrupted[i]; ok && checkpoint.Campaign == campaign.name && checkpoint.Query == query {
+		return &checkpoint
+	}
+	return &database.PipelineCheckpoint{
+		BatchID:       result.BatchID,
+		ProjectID:     result.ProjectID,
+		CampaignIndex: i,
+		Campaign:      campaign.name,
+		Query:         query,
+	}
+}
+
+// restoreCheckpoint restores the outputs of the stages the campaign of run
+// completed before its run was interrupted, reading the extracted payloads
+// back from raw_data
+func restoreCheckpoint(run *campaignRun) error {
+	checkpoint := run.checkpoint
+
+	var state checkpointState
+	if err := json.Unmarshal([]byte(checkpoint.State), &state); err != nil {
+		return fmt.Errorf("unreadable checkpoint state: %v", err)
+	}
+	if checkpoint.Stage == stageLoaded && (state.Transformed == nil || state.Loaded == nil) {
+		return fmt.Errorf("loaded checkpoint without load result")
+	}
+	if checkpoint.Stage == stageTransformed && state.Transformed == nil {
+		return fmt.Errorf("transformed checkpoint without transformed data")
+	}
+
+	rows, err := database.GetRawDataByIDs(checkpoint.ProjectID, checkpoint.RawDataIDs)
+	if err != nil {
+		return err
+	}
+	if len(rows) != len(checkpoint.RawDataIDs) {
+		return fmt.Errorf("%d of %d raw payloads were deleted", len(checkpoint.RawDataIDs)-len(rows), len(checkpoint.RawDataIDs))
+	}
+
+	sources := make(map[string]interface{}, len(state.Summaries))
+	for _, row := range rows {
+		var payload interface{}
+		if err := json.Unmarshal([]byte(row.RawData), &payload); err != nil {
+			return fmt.Errorf("unreadable raw payload %d: %v", row.ID, err)
+		}
+		sources[row.Source] = payload
+	}
+	// Skipped sources were never called, so only their reason was kept
+	for _, summary := range state.Summaries {
+		if _, stored := sources[summary.Source]; stored {
+			continue
+		}
+		if summary.Status == "skipped" || summary.Status == "quota_exceeded" {
+			sources[summary.Source] = skippedSource(summary.Reason)
+		}
+	}
+
+	run.extracted = &ExtractedData{
+		Timestamp: state.ExtractedAt,
+		Query:     checkpoint.Query,
+		Sources:   sources,
+		Summaries: state.Summaries,
+		Stored:    true,
+	}
+	switch checkpoint.Stage {
+	case stageTransformed:
+		run.transformed = state.Transformed
+	case stageLoaded:
+		run.transformed = state.Transformed
+		run.loaded = state.Loaded
+	}
+	run.resumedFrom = checkpoint.Stage
+	return nil
+}
+
+// saveCheckpoint records that the campaign of run completed a stage. Loaded
+// campaigns keep only the transformation summary. Failures are logged only;
+// an interrupted run then resumes the campaign from an earlier stage.
+func saveCheckpoint(run *campaignRun, stage string) {
+	checkpoint := run.checkpoint
+	if checkpoint == nil {
+		return
+	}
+
+	state := checkpointState{
+		ExtractedAt: run.extracted.Timestamp,
+		Summaries:   run.extracted.Summaries,
+	}
+	switch stage {
+	case stageTransformed:
+		state.Transformed = run.transformed
+	case stageLoaded:
+		state.Transformed = &TransformedData{
+			Summary:       run.transformed.Summary,
+			Report:        run.transformed.Report,
+			Validation:    run.transformed.Validation,
+			TransformedAt: run.transformed.TransformedAt,
+		}
+		state.Loaded = run.loaded
+	}
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("⚠️ Failed to marshal %s checkpoint: %v", stage, err)
+		return
+	}
+	checkpoint.Stage = stage
+	checkpoint.State = string(stateJSON)
+	if err := database.SavePipelineCheckpoint(checkpoint); err != nil {
+		log.Printf("⚠️ Failed to save %s checkpoint: %v", stage, err)
+	}
+}
+
+// discardCheckpoints drops the checkpoints of a run that ended. Failures are
+// logged only; the next run of the project drops them too.
+func (eo *ETLOrchestrator) discardCheckpoints(batchID string) {
+	if eo.resumeMaxAge <= 0 {
+		return
+	}
+	if err := database.DeletePipelineCheckpoints(batchID); err != nil {
+		log.Printf("⚠️ Failed to drop pipeline checkpoints: %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package etl
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// CovidStatisticsAPI fetches official COVID-19 case, death and vaccination
+// figures from the covid19.go.id open data or the WHO global dataset
+type CovidStatisticsAPI struct {
+	Config config.StatisticsConfig
+	Client *http.Client
+}
+
+// NewCovidStatisticsAPI creates a new official statistics client
+func NewCovidStatisticsAPI(cfg config.StatisticsConfig) *CovidStatisticsAPI {
+	return &CovidStatisticsAPI{
+		Config: cfg,
+		Client: newExtractorClient("covid_statistics", cfg.Timeout),
+	}
+}
+
+// FetchStatistics returns the daily figures of the configured provider
+func (c *CovidStatisticsAPI) FetchStatistics(ctx context.Context) ([]database.CovidStatistic, error) {
+	switch c.Config.Provider {
+	case "covid19.go.id":
+		return c.fetchCovid19GoID(ctx)
+	case "who":
+		return c.fetchWHO(ctx)
+	default:
+		return nil, fmt.Errorf("unknown statistics provider %q", c.Config.Provider)
+	}
+}
+
+// fetchCovid19GoID merges the national daily update with the vaccination
+// feed. Vaccinations are optional: when that feed fails the cases are still
+// returned.
+func (c *CovidStatisticsAPI) fetchCovid19GoID(ctx context.Context) ([]database.CovidStatistic, error) {
+	body, err := c.get(ctx, c.Config.CasesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	stats, err := parseCovid19GoIDCases(body, c.Config.Country)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Config.VaccinationsURL == "" {
+		return stats, nil
+	}
+	vaccinationBody, err := c.get(ctx, c.Config.VaccinationsURL)
+	if err != nil {
+		log.Printf("⚠️ Skipping vaccination statistics: %v", err)
+		return stats, nil
+	}
+	defer vaccinationBody.Close()
+
+	vaccinations, err := parseCovid19GoIDVaccinations(vaccinationBody)
+	if err != nil {
+		log.Printf("⚠️ Skipping vaccination statistics: %v", err)
+		return stats, nil
+	}
+
+	byDay := make(map[string]int, len(stats))
+	for i, stat := range stats {
+		byDay[stat.Day] = i
+	}
+	for day, doses := range vaccinations {
+		doses := doses
+		if i, ok := byDay[day]; ok {
+			stats[i].NewVaccinations = &doses
+			continue
+		}
+		stats = append(stats, database.CovidStatistic{
+			Country:         c.Config.Country,
+			Day:             day,
+			NewVaccinations: &doses,
+			Source:          "covid19.go.id",
+		})
+	}
+	return stats, nil
+}
+
+// fetchWHO reads the configured country's rows from the WHO global CSV
+func (c *CovidStatisticsAPI) fetchWHO(ctx context.Context) ([]database.CovidStatistic, error) {
+	body, err := c.get(ctx, c.Config.WHODataURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return parseWHOStatistics(body, c.Config.Country)
+}
+
+// get requests url and returns the body of a 200 response
+func (c *CovidStatisticsAPI) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// covid19GoIDValue is the {"value": n} wrapper of the covid19.go.id figures
+type covid19GoIDValue struct {
+	Value *float64 `json:"value"`
+}
+
+// intValue returns the figure, or nil when it is missing
+func (v covid19GoIDValue) intValue() *int {
+	if v.Value == nil {
+		return nil
+	}
+	n := int(math.Round(*v.Value))
+	return &n
+}
+
+// parseCovid19GoIDCases parses the daily cases of update.json
+func parseCovid19GoIDCases(r io.Reader, country string) ([]database.CovidStatistic, error) {
+	var payload struct {
+		Update struct {
+			Harian []struct {
+				KeyAsString        string           `json:"key_as_string"`
+				JumlahPositif      covid19GoIDValue `json:"jumlah_positif"`
+				JumlahMeninggal    covid19GoIDValue `json:"jumlah_meninggal"`
+				JumlahSembuh       covid19GoIDValue `json:"jumlah_sembuh"`
+				JumlahPositifKum   covid19GoIDValue `json:"jumlah_positif_kum"`
+				JumlahMeninggalKum covid19GoIDValue `json:"jumlah_meninggal_kum"`
+			} `json:"harian"`
+		} `json:"update"`
+	}
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode covid19.go.id cases: %w", err)
+	}
+
+	stats := make([]database.CovidStatistic, 0, len(payload.Update.Harian))
+	for _, day := range payload.Update.Harian {
+		if len(day.KeyAsString) < 10 {
+			continue
+		}
+		stats = append(stats, database.CovidStatistic{
+			Country:          country,
+			Day:              day.KeyAsString[:10],
+			NewCases:         day.JumlahPositif.intValue(),
+			NewDeaths:        day.JumlahMeninggal.intValue(),
+			NewRecovered:     day.JumlahSembuh.intValue(),
+			CumulativeCases:  day.JumlahPositifKum.intValue(),
+			CumulativeDeaths: day.JumlahMeninggalKum.intValue(),
+			Source:           "covid19.go.id",
+		})
+	}
+	return stats, nil
+}
+
+// parseCovid19GoIDVaccinations parses the daily first and second doses of
+// pemeriksaan-vaksinasi.json, keyed by YYYY-MM-DD
+func parseCovid19GoIDVaccinations(r io.Reader) (map[string]int, error) {
+	var payload struct {
+		Vaksinasi struct {
+			Harian []struct {
+				KeyAsString      string           `json:"key_as_string"`
+				JumlahVaksinasi1 covid19GoIDValue `json:"jumlah_vaksinasi_1"`
+				JumlahVaksinasi2 covid19GoIDValue `json:"jumlah_vaksinasi_2"`
+			} `json:"harian"`
+		} `json:"vaksinasi"`
+	}
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode covid19.go.id vaccinations: %w", err)
+	}
+
+	doses := make(map[string]int, len(payload.Vaksinasi.Harian))
+	for _, day := range payload.Vaksinasi.Harian {
+		if len(day.KeyAsString) < 10 {
+			continue
+		}
+		total := 0
+		for _, value := range []*int{day.JumlahVaksinasi1.intValue(), day.JumlahVaksinasi2.intValue()} {
+			if value != nil {
+				total += *value
+			}
+		}
+		doses[day.KeyAsString[:10]] = total
+	}
+	return doses, nil
+}
+
+// parseWHOStatistics reads a country's rows from the WHO daily CSV
+// (Date_reported, Country_code, Country, WHO_region, New_cases,
+// Cumulative_cases, New_deaths, Cumulative_deaths)
+func parseWHOStatistics(r io.Reader, country string) ([]database.CovidStatistic, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WHO header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")] = i
+	}
+	for _, name := range []string{"Date_reported", "Country_code", "New_cases", "Cumulative_cases", "New_deaths", "Cumulative_deaths"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("WHO data has no %s column", name)
+		}
+	}
+
+	number := func(record []string, name string) *int {
+		n, err := strconv.Atoi(strings.TrimSpace(record[columns[name]]))
+		if err != nil {
+			return nil
+		}
+		return &n
+	}
+
+	stats := []database.CovidStatistic{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WHO data: %w", err)
+		}
+		if !strings.EqualFold(record[columns["Country_code"]], country) {
+			continue
+		}
+		stats = append(stats, database.CovidStatistic{
+			Country:          strings.ToUpper(country),
+			Day:              record[columns["Date_reported"]],
+			NewCases:         number(record, "New_cases"),
+			NewDeaths:        number(record, "New_deaths"),
+			CumulativeCases:  number(record, "Cumulative_cases"),
+			CumulativeDeaths: number(record, "Cumulative_deaths"),
+			Source:           "who",
+		})
+	}
+	return stats, nil
+}
+
+// RefreshCovidStatistics fetches the official figures and stores them,
+// returning the number of days stored
+func RefreshCovidStatistics(ctx context.Context) (int, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	if cfg.ExternalAPIs.Statistics.Provider == "none" {
+		return 0, fmt.Errorf("official statistics are disabled (COVID_STATS_PROVIDER=none)")
+	}
+
+	stats, err := NewCovidStatisticsAPI(cfg.ExternalAPIs.Statistics).FetchStatistics(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch official statistics: %v", err)
+	}
+	return database.UpsertCovidStatistics(stats)
+}
@@ -0,0 +1,119 @@
+package etl
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/services"
+)
+
+// DeadLetterRetryResult is the outcome of loading dead-lettered records again
+type DeadLetterRetryResult struct {
+	Retried   int `json:"retried"`
+	Loaded    int `json:"loaded"`    // loaded and removed from the dead letter queue
+	Failed    int `json:"failed"`    // failed again; kept with the new error
+	Remaining int `json:"remaining"` // records of the project still in the queue
+}
+
+// deadLetter keeps a record that could not be loaded into PostgreSQL in the
+// dead_letter table, with its topics and entities so a retry can tag it.
+// Failures are logged only.
+func (dl *DataLoader) deadLetter(record *database.ProcessedData, topics []string, entities []services.Entity, loadErr error) {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Failed to marshal %s record for the dead letter queue: %v", record.Source, err)
+		return
+	}
+	topicsJSON, _ := json.Marshal(topics)
+	entitiesJSON, _ := json.Marshal(entities)
+
+	err = database.InsertDeadLetter(database.DeadLetter{
+		ProjectID: record.ProjectID,
+		BatchID:   record.BatchID,
+		Source:    record.Source,
+		Record:    string(recordJSON),
+		Topics:    string(topicsJSON),
+		Entities:  string(entitiesJSON),
+		Error:     loadErr.Error(),
+	})
+	if err != nil {
+		log.Printf("Failed to dead-letter %s record: %v", record.Source, err)
+	}
+}
+
+// RetryDeadLetters loads up to limit of the project's dead-lettered records
+// into PostgreSQL again, oldest first, and rebuilds the rollups of the days
+// they fall on. Loaded records leave the queue; records failing again stay
+// with the new error.
+func (dl *DataLoader) RetryDeadLetters(limit int) (*DeadLetterRetryResult, error) {
+	letters, err := database.GetDeadLetters(dl.projectID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DeadLetterRetryResult{}
+	days := make(map[string]time.Time)
+	for _, letter := range letters {
+		result.Retried++
+		day, loadErr := dl.loadDeadLetter(letter)
+		if loadErr != nil {
+			log.Printf("Dead-lettered record %d failed again: %v", letter.ID, loadErr)
+			result.Failed++
+			if err := database.RecordDeadLetterAttempt(letter.ID, loadErr.Error()); err != nil {
+				return result, err
+			}
+			continue
+		}
+		result.Loaded++
+		days[day.Format("2006-01-02")] = day
+		if err := database.DeleteDeadLetter(letter.ID); err != nil {
+			return result, err
+		}
+	}
+
+	for _, day := range days {
+		if _, err := database.RefreshDailyRollups(dl.projectID, day); err != nil {
+			log.Printf("⚠️ Daily rollup refresh failed: %v", err)
+			break
+		}
+	}
+
+	result.Remaining, err = database.CountDeadLetters(dl.projectID)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// loadDeadLetter upserts a dead-lettered record with its warehouse fact and
+// tags its topics and entities. It returns the UTC day the record falls on.
+func (dl *DataLoader) loadDeadLetter(letter database.DeadLetter) (time.Time, error) {
+	var record database.ProcessedData
+	if err := json.Unmarshal([]byte(letter.Record), &record); err != nil {
+		return time.Time{}, fmt.Errorf("unreadable record: %v", err)
+	}
+	var topics []string
+	var entities []services.Entity
+	if letter.Topics != "" {
+		json.Unmarshal([]byte(letter.Topics), &topics)
+	}
+	if letter.Entities != "" {
+		json.Unmarshal([]byte(letter.Entities), &entities)
+	}
+
+	record.ID = 0
+	if _, err := database.UpsertProcessedDataWithFact(&record); err != nil {
+		return time.Time{}, err
+	}
+	dl.tagTopics(&record, topics)
+	dl.linkEntities(&record, entities)
+
+	day := time.Now().UTC()
+	if record.PublishedAt != nil {
+		day = record.PublishedAt.UTC()
+	}
+	return day.Truncate(24 * time.Hour), nil
+}
@@ -0,0 +1,233 @@
+package etl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// elasticsearchMapping analyzes titles and contents as Indonesian text and
+// keeps the other fields exact for filtering and aggregations
+const elasticsearchMapping = `{
+	"mappings": {
+		"properties": {
+			"record_id": {"type": "long"},
+			"project_id": {"type": "keyword"},
+			"source": {"type": "keyword"},
+			"outlet": {"type": "keyword"},
+			"title": {"type": "text", "analyzer": "indonesian"},
+			"content": {"type": "text", "analyzer": "indonesian"},
+			"relevance_score": {"type": "float"},
+			"sentiment": {"type": "keyword"},
+			"sentiment_score": {"type": "float"},
+			"toxicity_score": {"type": "float"},
+			"campaign": {"type": "keyword"},
+			"hashtag": {"type": "keyword"},
+			"license": {"type": "keyword"},
+			"batch_id": {"type": "keyword"},
+			"published_at": {"type": "date"},
+			"indexed_at": {"type": "date"}
+		}
+	}
+}`
+
+// ElasticsearchLoader indexes processed records in Elasticsearch so articles
+// and comments can be searched in full text next to the PostgreSQL store
+type ElasticsearchLoader struct {
+	Config config.ElasticsearchConfig
+	Client *http.Client
+
+	indexReady bool // the index exists, so it is not checked again
+}
+
+// NewElasticsearchLoader creates a new Elasticsearch loader
+func NewElasticsearchLoader(cfg config.ElasticsearchConfig) *ElasticsearchLoader {
+	return &ElasticsearchLoader{
+		Config: cfg,
+		Client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// elasticsearchDocument is the indexed form of a processed record
+type elasticsearchDocument struct {
+	RecordID       int        `json:"record_id,omitempty"` // zero when PostgreSQL is not a destination
+	ProjectID      string     `json:"project_id"`
+	Source         string     `json:"source"`
+	Outlet         string     `json:"outlet,omitempty"`
+	Title          string     `json:"title"`
+	Content        string     `json:"content"`
+	RelevanceScore float64    `json:"relevance_score"`
+	Sentiment      string     `json:"sentiment"`
+	SentimentScore *float64   `json:"sentiment_score,omitempty"`
+	ToxicityScore  *float64   `json:"toxicity_score,omitempty"`
+	Campaign       string     `json:"campaign,omitempty"`
+	Hashtag        string     `json:"hashtag,omitempty"`
+	License        string     `json:"license,omitempty"`
+	BatchID        string     `json:"batch_id,omitempty"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+	IndexedAt      time.Time  `json:"indexed_at"`
+}
+
+// documentID identifies a record in the index by its content hash, so
+// reloading a record replaces its document like the PostgreSQL upsert
+func documentID(record *database.ProcessedData) string {
+	return record.ProjectID + ":" + record.ContentHash
+}
+
+// IndexRecords indexes records with bulk requests of the configured size. It
+// returns the number of records indexed and failed; the error is set when a
+// request fails, and the records of the remaining requests are not sent.
+func (el *ElasticsearchLoader) IndexRecords(records []*database.ProcessedData) (indexed, failed int, err error) {
+	if len(records) == 0 {
+		return 0, 0, nil
+	}
+	if err := el.ensureIndex(); err != nil {
+		return 0, 0, err
+	}
+
+	now := time.Now().UTC()
+	for start := 0; start < len(records); start += el.Config.BulkSize {
+		end := start + el.Config.BulkSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		var body bytes.Buffer
+		encoder := json.NewEncoder(&body)
+		for _, record := range records[start:end] {
+			action := map[string]interface{}{
+				"index": map[string]string{"_index": el.Config.Index, "_id": documentID(record)},
+			}
+			if err := encoder.Encode(action); err != nil {
+				return indexed, failed, fmt.Errorf("failed to encode bulk action: %w", err)
+			}
+			if err := encoder.Encode(elasticsearchDocument{
+				RecordID:       record.ID,
+				ProjectID:      record.ProjectID,
+				Source:         record.Source,
+				Outlet:         record.Outlet,
+				Title:          record.Title,
+				Content:        record.Content,
+				RelevanceScore: record.RelevanceScore,
+				Sentiment:      record.Sentiment,
+				SentimentScore: record.SentimentScore,
+				ToxicityScore:  record.ToxicityScore,
+				Campaign:       record.Campaign,
+				Hashtag:        record.Hashtag,
+				License:        record.License,
+				BatchID:        record.BatchID,
+				PublishedAt:    record.PublishedAt,
+				IndexedAt:      now,
+			}); err != nil {
+				return indexed, failed, fmt.Errorf("failed to encode document: %w", err)
+			}
+		}
+
+		ok, rejected, err := el.bulk(&body)
+		indexed += ok
+		failed += rejected
+		if err != nil {
+			return indexed, failed, err
+		}
+	}
+	return indexed, failed, nil
+}
+
+// bulk sends a bulk request and counts the documents indexed and rejected
+func (el *ElasticsearchLoader) bulk(body io.Reader) (indexed, failed int, err error) {
+	resp, err := el.do("POST", "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to send bulk request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("bulk request failed: %s", elasticsearchError(resp))
+	}
+
+	var result struct {
+		Items []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, 0, fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+
+	for _, item := range result.Items {
+		for _, outcome := range item {
+			if outcome.Status >= 300 {
+				failed++
+				if failed == 1 {
+					log.Printf("⚠️ Elasticsearch rejected a document: %s", outcome.Error)
+				}
+				continue
+			}
+			indexed++
+		}
+	}
+	return indexed, failed, nil
+}
+
+// ensureIndex creates the index with its mapping when it does not exist yet
+func (el *ElasticsearchLoader) ensureIndex() error {
+	if el.indexReady {
+		return nil
+	}
+
+	resp, err := el.do("HEAD", "/"+el.Config.Index, "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to check index %s: %w", el.Config.Index, err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		resp, err := el.do("PUT", "/"+el.Config.Index, "application/json", strings.NewReader(elasticsearchMapping))
+		if err != nil {
+			return fmt.Errorf("failed to create index %s: %w", el.Config.Index, err)
+		}
+		defer resp.Body.Close()
+		// Another loader may have created the index in the meantime
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+			return fmt.Errorf("failed to create index %s: %s", el.Config.Index, elasticsearchError(resp))
+		}
+	default:
+		return fmt.Errorf("failed to check index %s: HTTP %d", el.Config.Index, resp.StatusCode)
+	}
+
+	el.indexReady = true
+	return nil
+}
+
+// do sends a request to the Elasticsearch cluster
+func (el *ElasticsearchLoader) do(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(el.Config.URL, "/")+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if el.Config.Username != "" {
+		req.SetBasicAuth(el.Config.Username, el.Config.Password)
+	}
+	return el.Client.Do(req)
+}
+
+// elasticsearchError describes a failed response by its status and the
+// start of its body
+func elasticsearchError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Sprintf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
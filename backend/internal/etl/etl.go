@@ -24,11 +24,11 @@ package etl
 // Usage Example:
 // ```
 // orchestrator := etl.NewETLOrchestrator()
-// result := orchestrator.RunETLPipeline()
+// result := orchestrator.RunETLPipeline(context.Background())
 // 
 // if result.Status == "success" {
 //     fmt.Printf("Pipeline completed in %s\n", result.PipelineDuration)
-//     fmt.Printf("Extracted from %d sources\n", len(result.Extraction.Sources))
+//     fmt.Printf("Extracted from %d sources\n", len(result.Extraction))
 //     fmt.Printf("Transformed %d videos and %d articles\n", 
 //         len(result.Transformation.YouTube), 
 //         len(result.Transformation.News))
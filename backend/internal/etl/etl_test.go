@@ -1,8 +1,23 @@
 package etl
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
 )
 
 func TestNewDataExtractor(t *testing.T) {
@@ -81,19 +96,46 @@ func TestDataTransformerParseDateTime(t *testing.T) {
 
 	// Test RFC3339 format
 	dateStr := "2023-12-25T10:30:00Z"
-	parsed := transformer.parseDateTime(dateStr)
-	if parsed == "" {
-		t.Error("DateTime parsing failed for RFC3339 format")
+	parsed := transformer.parseDateTime(dateStr, services.WIB)
+	if parsed != dateStr {
+		t.Errorf("DateTime parsing failed for RFC3339 format, got %s", parsed)
+	}
+
+	// Local times are stored in UTC, in the given zone unless they name one
+	if parsed = transformer.parseDateTime("2023-12-25 05:30:00", services.WIB); parsed != "2023-12-24T22:30:00Z" {
+		t.Errorf("Expected a WIB time converted to UTC, got %s", parsed)
+	}
+	if parsed = transformer.parseDateTime("2023-12-25 05:30 WITA", services.WIB); parsed != "2023-12-24T21:30:00Z" {
+		t.Errorf("Expected a WITA time converted to UTC, got %s", parsed)
 	}
 
 	// Test invalid format
 	invalidDate := "invalid-date"
-	parsed = transformer.parseDateTime(invalidDate)
+	parsed = transformer.parseDateTime(invalidDate, services.WIB)
 	if parsed != invalidDate {
 		t.Error("DateTime parsing should return original string for invalid format")
 	}
 }
 
+func TestParseRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	if parsed := parseRelativeTime("2 weeks ago (edited)", now); parsed != "2024-03-01T12:00:00Z" {
+		t.Errorf("Expected 2024-03-01T12:00:00Z, got '%s'", parsed)
+	}
+	if parsed := parseRelativeTime("1 year ago", now); parsed != "2023-03-15T12:00:00Z" {
+		t.Errorf("Expected 2023-03-15T12:00:00Z, got '%s'", parsed)
+	}
+	if parsed := parseRelativeTime("Premiered Mar 1, 2024", now); parsed != "" {
+		t.Errorf("Non-relative text should not parse, got '%s'", parsed)
+	}
+
+	// Unparsable publish dates fall back to processed_at
+	if parsePublishedAt("3 days ago") != nil {
+		t.Error("Non-RFC3339 publish dates should be ignored")
+	}
+}
+
 func TestDataTransformerCreateSummary(t *testing.T) {
 	transformer := NewDataTransformer()
 
@@ -226,3 +268,1575 @@ func TestTransformedDataStructure(t *testing.T) {
 		t.Error("News should be initialized")
 	}
 }
+
+func TestResolveRunSettings(t *testing.T) {
+	cfg := config.ETLConfig{
+		Keywords:         []string{"COVID-19"},
+		Sources:          []string{"youtube", "google_news"},
+		MinRelevance:     0.1,
+		ScheduleInterval: time.Hour,
+	}
+
+	// Without overrides the global configuration applies
+	settings, err := ResolveRunSettings(cfg, nil)
+	if err != nil {
+		t.Fatalf("ResolveRunSettings failed: %v", err)
+	}
+	if !settings.SourceEnabled("youtube") || settings.SourceEnabled("instagram") {
+		t.Errorf("Unexpected sources: %v", settings.Sources)
+	}
+	if settings.Hashtag() != "covid19" {
+		t.Errorf("Expected hashtag 'covid19', got '%s'", settings.Hashtag())
+	}
+
+	// Overrides replace only the fields they set
+	minRelevance := 0.5
+	settings, err = ResolveRunSettings(cfg, &database.ProjectOverrides{
+		Keywords:         []string{"vaksin", "booster"},
+		Sources:          map[string]bool{"youtube": false, "instagram": true},
+		MinRelevance:     &minRelevance,
+		ScheduleInterval: "30m",
+	})
+	if err != nil {
+		t.Fatalf("ResolveRunSettings failed: %v", err)
+	}
+	if settings.Query() != "vaksin OR booster" {
+		t.Errorf("Expected query 'vaksin OR booster', got '%s'", settings.Query())
+	}
+	if settings.SourceEnabled("youtube") || !settings.SourceEnabled("instagram") || !settings.SourceEnabled("google_news") {
+		t.Errorf("Unexpected sources: %v", settings.Sources)
+	}
+	if settings.MinRelevance != 0.5 || settings.ScheduleInterval != 30*time.Minute {
+		t.Errorf("Unexpected overrides: %+v", settings)
+	}
+
+	// Invalid overrides are rejected
+	if _, err := ResolveRunSettings(cfg, &database.ProjectOverrides{Sources: map[string]bool{"twitter": true}}); err == nil {
+		t.Error("Expected an error for an unknown source")
+	}
+	if _, err := ResolveRunSettings(cfg, &database.ProjectOverrides{ScheduleInterval: "daily"}); err == nil {
+		t.Error("Expected an error for an invalid schedule interval")
+	}
+}
+
+func TestSourceHealthSkipping(t *testing.T) {
+	health := NewSourceHealth(config.ETLConfig{BreakerThreshold: 2, BreakerCooldown: time.Hour, DailyBudget: 3})
+
+	// The breaker opens after the threshold of consecutive failures
+	health.RecordFailure("youtube")
+	if reason := health.SkipReason("youtube"); reason != "" {
+		t.Errorf("Source should not be skipped after one failure, got '%s'", reason)
+	}
+	health.RecordFailure("youtube")
+	if health.SkipReason("youtube") == "" {
+		t.Error("Source should be skipped once the breaker is open")
+	}
+
+	// A success closes it again
+	health.RecordSuccess("youtube")
+	if reason := health.SkipReason("youtube"); reason != "" {
+		t.Errorf("Source should run after a success, got '%s'", reason)
+	}
+
+	// The daily budget limits attempts
+	for i := 0; i < 3; i++ {
+		health.RecordAttempt("instagram")
+	}
+	if health.SkipReason("instagram") == "" {
+		t.Error("Source should be skipped once its daily budget is exhausted")
+	}
+}
+
+func TestFaultInjectingTransport(t *testing.T) {
+	passed := 0
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		passed++
+		return injectedResponse(req, http.StatusOK), nil
+	})
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/search", nil)
+
+	// Every request is rate limited at a rate of 1
+	transport := newFaultInjectingTransport(next, config.FaultInjectionConfig{RateLimitRate: 1}, "youtube", 1)
+	resp, err := transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests || passed != 0 {
+		t.Errorf("Expected an injected 429, got %v (err %v, passed %d)", resp, err, passed)
+	}
+
+	// Injected timeouts give up when the request is cancelled
+	transport = newFaultInjectingTransport(next, config.FaultInjectionConfig{TimeoutRate: 1, TimeoutAfter: time.Hour}, "youtube", 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := transport.RoundTrip(req.WithContext(ctx)); err != context.DeadlineExceeded {
+		t.Errorf("Expected the injected timeout to end with the request deadline, got %v", err)
+	}
+
+	// With no rates every request reaches the API
+	transport = newFaultInjectingTransport(next, config.FaultInjectionConfig{}, "youtube", 1)
+	for i := 0; i < 10; i++ {
+		if resp, err := transport.RoundTrip(req); err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected the request to pass, got %v (err %v)", resp, err)
+		}
+	}
+	if passed != 10 {
+		t.Errorf("Expected 10 requests to pass, got %d", passed)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransformDataInChunks(t *testing.T) {
+	var posts []InstagramPost
+	for i := 0; i < 5; i++ {
+		posts = append(posts, InstagramPost{
+			CaptionText: fmt.Sprintf("Vaksinasi COVID-19 hari ke-%d", i),
+			Code:        fmt.Sprintf("post%d", i),
+			User:        &InstagramUser{Username: "tester"},
+		})
+	}
+
+	var chunkSizes []int
+	transformed, err := NewDataTransformer().TransformDataInChunks(map[string]interface{}{"instagram": &InstagramData{Posts: posts}}, 2, func(chunk *TransformedData) error {
+		chunkSizes = append(chunkSizes, len(chunk.News))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TransformDataInChunks failed: %v", err)
+	}
+	if fmt.Sprint(chunkSizes) != "[2 2 1]" {
+		t.Errorf("Expected chunks of [2 2 1], got %v", chunkSizes)
+	}
+	if transformed.Summary.TotalArticles != 5 || len(transformed.News) != 0 {
+		t.Errorf("Expected a summary of 5 articles without records, got %d and %d records", transformed.Summary.TotalArticles, len(transformed.News))
+	}
+
+	// A flush error stops the transformation
+	flushes := 0
+	_, err = NewDataTransformer().TransformDataInChunks(map[string]interface{}{"instagram": &InstagramData{Posts: posts}}, 2, func(chunk *TransformedData) error {
+		flushes++
+		return context.Canceled
+	})
+	if err != context.Canceled || flushes != 1 {
+		t.Errorf("Expected one flush and context.Canceled, got %d flushes and %v", flushes, err)
+	}
+}
+
+// TestTransformDataScoresSentiment tests that every transformed item is
+// scored before it reaches the loader
+func TestTransformDataScoresSentiment(t *testing.T) {
+	posts := []InstagramPost{
+		{
+			CaptionText: "Pasien COVID-19 berhasil sembuh berkat vaksin",
+			Code:        "positive",
+			User:        &InstagramUser{Username: "tester"},
+		},
+		{
+			CaptionText: "Banyak pasien COVID-19 meninggal, situasi buruk dan mengkhawatirkan",
+			Code:        "negative",
+			User:        &InstagramUser{Username: "tester"},
+		},
+	}
+
+	transformed := NewDataTransformer().TransformData(map[string]interface{}{"instagram": &InstagramData{Posts: posts}})
+	if len(transformed.News) != 2 {
+		t.Fatalf("Expected 2 transformed posts, got %d", len(transformed.News))
+	}
+	for i, expected := range []string{"positive", "negative"} {
+		post := transformed.News[i]
+		if post.Sentiment != expected || post.SentimentConfidence == 0 {
+			t.Errorf("Expected post %d to be scored %s, got %q with confidence %v", i, expected, post.Sentiment, post.SentimentConfidence)
+		}
+	}
+}
+
+// TestHTTPSentimentProvider tests batching and label mapping of the remote
+// sentiment provider, and that transformed items keep their keyword scores
+// when it fails
+func TestHTTPSentimentProvider(t *testing.T) {
+	var batches []int
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var request struct {
+			Inputs []string `json:"inputs"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		batches = append(batches, len(request.Inputs))
+
+		var results [][]map[string]interface{}
+		for _, text := range request.Inputs {
+			positive := 0.1
+			if strings.Contains(text, "sembuh") {
+				positive = 0.8
+			}
+			results = append(results, []map[string]interface{}{
+				{"label": "LABEL_0", "score": positive},
+				{"label": "LABEL_1", "score": 0.1},
+				{"label": "LABEL_2", "score": 0.9 - positive},
+			})
+		}
+		json.NewEncoder(w).Encode(results)
+	}))
+	defer server.Close()
+
+	provider, err := services.NewSentimentProvider(config.SentimentConfig{
+		Provider:  "http",
+		APIURL:    server.URL,
+		Labels:    map[string]string{"LABEL_0": "positive", "LABEL_1": "neutral", "LABEL_2": "negative"},
+		BatchSize: 2,
+		Timeout:   time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewSentimentProvider failed: %v", err)
+	}
+	results, err := provider.AnalyzeBatch([]string{"pasien sembuh", "kasus naik", "warga sembuh"})
+	if err != nil {
+		t.Fatalf("AnalyzeBatch failed: %v", err)
+	}
+	if fmt.Sprint(batches) != "[2 1]" {
+		t.Errorf("Expected batches of [2 1], got %v", batches)
+	}
+	for i, expected := range []string{"positive", "negative", "positive"} {
+		if results[i].Category != expected {
+			t.Errorf("Expected text %d to be %s, got %s (%.2f)", i, expected, results[i].Category, results[i].Score)
+		}
+	}
+
+	t.Setenv("SENTIMENT_PROVIDER", "http")
+	t.Setenv("SENTIMENT_API_URL", server.URL)
+	t.Setenv("SENTIMENT_API_LABELS", "LABEL_0=positive,LABEL_1=neutral,LABEL_2=negative")
+	posts := map[string]interface{}{"instagram": &InstagramData{Posts: []InstagramPost{
+		{CaptionText: "Banyak pasien meninggal, situasi buruk", Code: "p1", User: &InstagramUser{Username: "tester"}},
+	}}}
+
+	transformed := NewDataTransformer().TransformData(posts)
+	if post := transformed.News[0]; post.SentimentProvider != "http" || post.Sentiment != "negative" || post.SentimentConfidence != 0.8 {
+		t.Errorf("Expected the remote score, got %s from %q with confidence %v", post.Sentiment, post.SentimentProvider, post.SentimentConfidence)
+	}
+
+	failing = true
+	transformed = NewDataTransformer().TransformData(posts)
+	if post := transformed.News[0]; post.SentimentProvider != "" || post.Sentiment != "negative" {
+		t.Errorf("Expected the keyword score after a remote failure, got %s from %q", post.Sentiment, post.SentimentProvider)
+	}
+}
+
+func TestExtractEntities(t *testing.T) {
+	text := "Menkes Budi Gunadi Sadikin dan Jokowi meninjau vaksinasi di Papua Barat. WHO memuji Kemenkes; who knows, kata warga Jakarta. Jokowi optimistis."
+	entities := services.ExtractEntities(text)
+
+	found := make(map[string]services.Entity)
+	for _, entity := range entities {
+		found[entity.Name] = entity
+	}
+	expected := map[string]int{
+		"Joko Widodo":               2,
+		"Budi Gunadi Sadikin":       1,
+		"Papua Barat":               1,
+		"World Health Organization": 1,
+		"Kementerian Kesehatan":     1,
+		"DKI Jakarta":               1,
+	}
+	for name, mentions := range expected {
+		if found[name].Mentions != mentions {
+			t.Errorf("Expected %d mentions of %s, got %d", mentions, name, found[name].Mentions)
+		}
+	}
+	if len(found) != len(expected) {
+		t.Errorf("Expected %d entities, got %+v", len(expected), entities)
+	}
+	if entities[0].Name != "Joko Widodo" || entities[0].Type != services.EntityPerson {
+		t.Errorf("Expected the most mentioned entity first, got %+v", entities[0])
+	}
+	if found["Papua Barat"].Type != services.EntityPlace || found["Kementerian Kesehatan"].Type != services.EntityOrganization {
+		t.Errorf("Expected gazetteer types, got %+v", entities)
+	}
+}
+
+func TestDetectProvince(t *testing.T) {
+	cases := map[string]string{
+		"Antrean vaksinasi di Surabaya dan Sidoarjo, Jawa Timur":         "Jawa Timur",
+		"RS di Bandung penuh, pasien dirujuk ke Jakarta. Bandung siaga.": "Jawa Barat",
+		"Kasus baru di Papua Barat":                                      "Papua Barat",
+		"WHO memantau varian baru di India":                              "",
+	}
+	for text, expected := range cases {
+		if province := services.DetectProvince(services.ExtractEntities(text)); province != expected {
+			t.Errorf("%q: expected %q, got %q", text, expected, province)
+		}
+	}
+
+	posts := map[string]interface{}{"instagram": &InstagramData{Posts: []InstagramPost{
+		{CaptionText: "Vaksinasi massal hari ini di Makassar", Code: "p1", User: &InstagramUser{Username: "tester"}},
+	}}}
+	post := NewDataTransformer().TransformData(posts).News[0]
+	if post.Region != "Sulawesi Selatan" || database.ProvinceCodes[post.Region] != "ID-SN" {
+		t.Errorf("Expected the caption to place the post in Sulawesi Selatan, got %q", post.Region)
+	}
+}
+
+func TestRankTermTrends(t *testing.T) {
+	now := time.Date(2021, 7, 15, 0, 0, 0, 0, time.UTC)
+	var current, baseline []database.RecordWords
+	for i := 0; i < 6; i++ {
+		// oksigen is new and recent, vaksin steady, ppkm fading
+		current = append(current, database.RecordWords{At: now.Add(-time.Duration(i) * 4 * time.Hour), Words: []string{"oksigen", "vaksin"}})
+	}
+	for i := 0; i < 42; i++ {
+		words := []string{"vaksin"}
+		if i%7 < 5 {
+			words = append(words, "ppkm")
+		}
+		baseline = append(baseline, database.RecordWords{At: now.Add(-24*time.Hour - time.Duration(i)*4*time.Hour), Words: words})
+	}
+
+	rising, falling := services.RankTermTrends(current, baseline, now, 24*time.Hour, 7*24*time.Hour, 12*time.Hour, 10)
+	if len(rising) == 0 || rising[0].Term != "oksigen" || rising[0].Count != 6 {
+		t.Fatalf("Expected oksigen to rise most, got %+v", rising)
+	}
+	for _, trend := range rising {
+		if trend.Term == "vaksin" && trend.Growth > 1.2 {
+			t.Errorf("Expected a term at its usual rate to have a growth of about 1, got %+v", trend)
+		}
+	}
+	if len(falling) != 1 || falling[0].Term != "ppkm" || falling[0].BaselineCount != 30 {
+		t.Errorf("Expected ppkm to fall, got %+v", falling)
+	}
+}
+
+func TestRunMetrics(t *testing.T) {
+	result := &ETLResult{
+		PipelineDuration: "3s",
+		Extraction:       []SourceSummary{{Source: "youtube", Status: "error", Error: "quota exceeded"}},
+		Loading:          &LoadResult{Success: true, RecordsCount: 10, UpdatedCount: 2},
+	}
+	for _, run := range []*campaignRun{
+		{durations: map[string]time.Duration{"extraction": time.Second, "loading": 500 * time.Millisecond}},
+		{durations: map[string]time.Duration{"extraction": 2 * time.Second}},
+	} {
+		result.addStageDurations(run)
+	}
+
+	metrics := runMetrics(result)
+	durations, ok := metrics["stage_durations"].(map[string]string)
+	if !ok || durations["extraction"] != "3s" || durations["loading"] != "500ms" {
+		t.Errorf("expected stage durations summed over campaigns, got %v", metrics["stage_durations"])
+	}
+	if _, ok := metrics["transformation"]; ok {
+		t.Error("expected no transformation metrics for a run that did not transform")
+	}
+	loading, ok := metrics["loading"].(map[string]interface{})
+	if !ok || loading["records_count"] != 10 || loading["updated_count"] != 2 {
+		t.Errorf("expected loading counts, got %v", metrics["loading"])
+	}
+}
+
+// TestEmptySources tests that a source counts as empty only when none of its
+// campaigns extracted a record, and never when it was skipped
+func TestEmptySources(t *testing.T) {
+	result := &ETLResult{Extraction: []SourceSummary{
+		{Source: "youtube", Status: "success", RecordCount: 0, Campaign: "covid"},
+		{Source: "youtube", Status: "success", RecordCount: 4, Campaign: "dengue"},
+		{Source: "google_news", Status: "error"},
+		{Source: "instagram", Status: "skipped"},
+	}}
+	if sources := emptySources(result); len(sources) != 1 || sources[0] != "google_news" {
+		t.Errorf("expected only google_news to be empty, got %v", sources)
+	}
+}
+
+// TestParseCovidStatistics tests parsing of the covid19.go.id and WHO feeds
+func TestParseCovidStatistics(t *testing.T) {
+	update := `{"update": {"harian": [
+		{"key_as_string": "2021-07-15T00:00:00.000Z", "jumlah_positif": {"value": 56757}, "jumlah_meninggal": {"value": 982},
+		 "jumlah_sembuh": {"value": 19049}, "jumlah_positif_kum": {"value": 2780803}, "jumlah_meninggal_kum": {"value": 71397}},
+		{"key_as_string": "2021-07-16T00:00:00.000Z", "jumlah_positif": {"value": 54000}}
+	]}}`
+	stats, err := parseCovid19GoIDCases(strings.NewReader(update), "ID")
+	if err != nil {
+		t.Fatalf("Failed to parse cases: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Day != "2021-07-15" || *stats[0].NewCases != 56757 || *stats[0].CumulativeDeaths != 71397 {
+		t.Errorf("Unexpected cases: %+v", stats)
+	}
+	if stats[1].NewDeaths != nil {
+		t.Errorf("Expected missing deaths to be nil, got %d", *stats[1].NewDeaths)
+	}
+
+	vaccinations := `{"vaksinasi": {"harian": [{"key_as_string": "2021-07-15T00:00:00.000Z",
+		"jumlah_vaksinasi_1": {"value": 800000}, "jumlah_vaksinasi_2": {"value": 200000}}]}}`
+	doses, err := parseCovid19GoIDVaccinations(strings.NewReader(vaccinations))
+	if err != nil || doses["2021-07-15"] != 1000000 {
+		t.Errorf("Expected 1000000 doses, got %v (%v)", doses, err)
+	}
+
+	who := "\ufeffDate_reported,Country_code,Country,WHO_region,New_cases,Cumulative_cases,New_deaths,Cumulative_deaths\n" +
+		"2021-07-15,ID,Indonesia,SEARO,56757,2780803,982,71397\n" +
+		"2021-07-15,MY,Malaysia,WPRO,13215,880782,,6728\n"
+	stats, err = parseWHOStatistics(strings.NewReader(who), "id")
+	if err != nil {
+		t.Fatalf("Failed to parse WHO data: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Country != "ID" || *stats[0].NewCases != 56757 || stats[0].Source != "who" {
+		t.Errorf("Unexpected WHO statistics: %+v", stats)
+	}
+}
+
+// TestParseTrendsTimeline tests parsing of a Google Trends multiline widget
+func TestParseTrendsTimeline(t *testing.T) {
+	body := ")]}',\n" + `{"default": {"timelineData": [
+		{"time": "1626307200", "formattedTime": "15 Jul 2021", "value": [100, 42], "hasData": [true, true]},
+		{"time": "1626393600", "formattedTime": "16 Jul 2021", "value": [87, 0], "hasData": [true, false]}
+	]}}`
+	points, err := parseTrendsTimeline(strings.NewReader(body), []string{"covid", "ppkm"}, "ID")
+	if err != nil {
+		t.Fatalf("Failed to parse interest over time: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 values, got %+v", points)
+	}
+	if points[0].Day != "2021-07-15" || points[0].Term != "covid" || points[0].Interest != 100 || points[0].Geo != "ID" {
+		t.Errorf("Unexpected first value: %+v", points[0])
+	}
+	if points[1].Term != "ppkm" || points[1].Interest != 42 || points[2].Day != "2021-07-16" || points[2].Term != "covid" {
+		t.Errorf("Unexpected values: %+v", points)
+	}
+}
+
+// TestSourceAdapterAttribution tests that records are attributed to the
+// source whose payload they came from, whatever fields they carry
+func TestSourceAdapterAttribution(t *testing.T) {
+	sources := map[string]interface{}{
+		"google_news": &NewsData{Articles: []NewsArticle{
+			{Title: "Kasus COVID-19 meningkat", Channel: "DETIK"},
+		}},
+		"indonesia_news": &IndonesiaNewsData{Sources: IndonesiaNewsSources{Items: []NewsArticle{
+			{Title: "Vaksinasi massal", SourceName: "Kompas", ArticleID: "a1"},
+		}}},
+		"instagram": &InstagramData{Posts: []InstagramPost{
+			{CaptionText: "Ayo vaksin #covid19", Code: "p1"},
+		}},
+		"unknown": &NewsData{Articles: []NewsArticle{{Title: "Ignored"}}},
+	}
+
+	transformed := NewDataTransformer().TransformData(sources)
+	if len(transformed.News) != 3 {
+		t.Fatalf("Expected 3 articles, got %d", len(transformed.News))
+	}
+	got := map[string]string{}
+	for _, article := range transformed.News {
+		got[article.Title] = article.SourceKey
+	}
+	if got["Kasus COVID-19 meningkat"] != "google_news" || got["Vaksinasi massal"] != "indonesia_news" || got["Instagram Post by @"] != "instagram" {
+		t.Errorf("Unexpected source attribution: %v", got)
+	}
+}
+
+// TestSourceTaxonomyResolve tests mapping of source and outlet spellings to the canonical taxonomy
+func TestSourceTaxonomyResolve(t *testing.T) {
+	taxonomy := database.DefaultSourceTaxonomy()
+
+	tests := []struct {
+		name, outlet       string
+		source, wantOutlet string
+	}{
+		{"indonesia_news", "DETIK", "indonesia_news", "Detik"},
+		{"Real-Time News", "Jakarta Daily", "google_news", "Jakarta Daily"},
+		{"", "kompas", "indonesia_news", "Kompas"},
+		{"instagram", "@warga", "instagram", "@warga"},
+		{"news", "", database.OtherSource, ""},
+	}
+	for _, test := range tests {
+		source, outlet := taxonomy.Resolve(test.name, test.outlet)
+		if source != test.source || outlet != test.wantOutlet {
+			t.Errorf("Resolve(%q, %q) = %q, %q; expected %q, %q", test.name, test.outlet, source, outlet, test.source, test.wantOutlet)
+		}
+	}
+}
+
+// TestTokenBucketReserve tests that reservations beyond the burst wait for refilled tokens
+func TestTokenBucketReserve(t *testing.T) {
+	start := time.Unix(0, 0)
+	bucket := newTokenBucket(config.RateLimitConfig{RequestsPerSecond: 2, Burst: 2}, start)
+
+	waits := []time.Duration{}
+	for i := 0; i < 4; i++ {
+		waits = append(waits, bucket.reserve(start))
+	}
+	expected := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for i := range expected {
+		if waits[i] != expected[i] {
+			t.Errorf("Reservation %d waits %v; expected %v", i, waits[i], expected[i])
+		}
+	}
+
+	if wait := bucket.reserve(start.Add(2 * time.Second)); wait != 0 {
+		t.Errorf("Expected a refilled bucket after 2s, waited %v", wait)
+	}
+}
+
+// TestExtractSourceWithTimeout tests that hanging and panicking sources still produce an error result
+func TestExtractSourceWithTimeout(t *testing.T) {
+	de := &DataExtractor{sourceTimeout: 50 * time.Millisecond}
+
+	hanging := registeredSource{
+		name: "hanging",
+		extract: func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int) {
+			select {} // ignores its context
+		},
+	}
+	panicking := registeredSource{
+		name: "panicking",
+		extract: func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int) {
+			panic("boom")
+		},
+	}
+
+	for _, source := range []registeredSource{hanging, panicking} {
+		result := de.extractSourceWithTimeout(context.Background(), source, DefaultRunSettings())
+		errMap, ok := result.data.(map[string]string)
+		if !ok || errMap["error"] == "" {
+			t.Errorf("Expected an error result for %s, got %v", source.name, result.data)
+		}
+	}
+}
+
+// TestExtractConcurrentlyBoundsWorkers tests that no more than the configured
+// number of sources run at once and that queued sources are not called once
+// the run is cancelled
+func TestExtractConcurrentlyBoundsWorkers(t *testing.T) {
+	de := &DataExtractor{health: NewSourceHealth(config.ETLConfig{}), maxConcurrent: 2}
+
+	var mu sync.Mutex
+	running, maxRunning, calls := 0, 0, 0
+	var sources []registeredSource
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		sources = append(sources, registeredSource{
+			name: name,
+			extract: func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int) {
+				mu.Lock()
+				running++
+				calls++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return []string{"record"}, 1
+			},
+		})
+	}
+
+	results := de.extractConcurrently(context.Background(), sources, DefaultRunSettings())
+	if maxRunning != 2 {
+		t.Errorf("Expected at most 2 sources at once, got %d", maxRunning)
+	}
+	for i, result := range results {
+		if result.name != sources[i].name || result.count != 1 {
+			t.Errorf("Expected result %d to be %s with 1 record, got %+v", i, sources[i].name, result)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	for _, result := range de.extractConcurrently(ctx, sources, DefaultRunSettings()) {
+		if summary := result.summary(); summary.Status != "error" || !strings.Contains(summary.Error, "before it started") {
+			t.Errorf("Expected %s to be cancelled before it started, got %+v", result.name, summary)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Expected no source to be called after cancellation, got %d calls", calls)
+	}
+}
+
+// TestRunSettingsForCampaigns tests that each campaign runs with its own keywords
+func TestRunSettingsForCampaigns(t *testing.T) {
+	cfg := config.ETLConfig{
+		Keywords: []string{"COVID-19"},
+		Sources:  []string{"youtube"},
+		Campaigns: []config.CampaignConfig{
+			{Name: "covid", Keywords: []string{"COVID-19", "corona"}},
+			{Name: "dengue", Keywords: []string{"DBD"}},
+		},
+	}
+
+	settings, err := ResolveRunSettings(cfg, nil)
+	if err != nil {
+		t.Fatalf("ResolveRunSettings failed: %v", err)
+	}
+	campaigns := settings.forCampaigns()
+	if len(campaigns) != 2 || campaigns[0].name != "covid" || campaigns[1].settings.Query() != "DBD" {
+		t.Fatalf("Unexpected campaigns: %+v", campaigns)
+	}
+	if campaigns[0].settings.Query() != "COVID-19 OR corona" || !campaigns[0].settings.SourceEnabled("youtube") {
+		t.Errorf("Unexpected campaign settings: %+v", campaigns[0].settings)
+	}
+
+	// Projects with their own keywords run them as a single unnamed query
+	settings, _ = ResolveRunSettings(cfg, &database.ProjectOverrides{Keywords: []string{"vaksin"}})
+	campaigns = settings.forCampaigns()
+	if len(campaigns) != 1 || campaigns[0].name != "" || campaigns[0].settings.Query() != "vaksin" {
+		t.Errorf("Unexpected campaigns for keyword override: %+v", campaigns)
+	}
+}
+
+// TestSourceToggles tests that SOURCES_ENABLED overrules project settings and
+// that a run's source selection narrows the sources it extracts
+func TestSourceToggles(t *testing.T) {
+	cfg := config.ETLConfig{
+		Keywords:       []string{"COVID-19"},
+		Sources:        []string{"youtube", "google_news", "indonesia_news"},
+		EnabledSources: []string{"youtube", "google_news", "indonesia_news"},
+	}
+
+	settings, err := ResolveRunSettings(cfg, &database.ProjectOverrides{Sources: map[string]bool{"instagram": true}})
+	if err != nil {
+		t.Fatalf("ResolveRunSettings failed: %v", err)
+	}
+	if settings.SourceEnabled("instagram") || settings.DisabledSources["instagram"] != "disabled by SOURCES_ENABLED" {
+		t.Errorf("Expected instagram to stay disabled by SOURCES_ENABLED, got %+v", settings)
+	}
+
+	settings.selectSources(PipelineOptions{Sources: []string{"youtube", "google_news", "instagram"}, Skip: []string{"google_news"}})
+	if !settings.SourceEnabled("youtube") || settings.SourceEnabled("google_news") || settings.SourceEnabled("indonesia_news") || settings.SourceEnabled("instagram") {
+		t.Errorf("Expected only youtube to be extracted, got %v", settings.Sources)
+	}
+	if settings.DisabledSources["indonesia_news"] != "not selected for this run" || settings.DisabledSources["google_news"] != "skipped for this run" {
+		t.Errorf("Unexpected disabled sources: %v", settings.DisabledSources)
+	}
+
+	if err := (PipelineOptions{Skip: []string{"twitter"}}).Validate(); err == nil {
+		t.Error("Expected an unknown source to be rejected")
+	}
+}
+
+// TestQuotaReason tests that a source stops once its daily or monthly quota
+// is used up and that a quota of 0 does not limit it
+func TestQuotaReason(t *testing.T) {
+	quota := config.QuotaConfig{Daily: 10, Monthly: 100}
+	if reason := quotaReason(quota, 9, 99); reason != "" {
+		t.Errorf("Expected requests below the quota to be allowed, got %q", reason)
+	}
+	if reason := quotaReason(quota, 10, 50); reason != "daily quota of 10 requests used up" {
+		t.Errorf("Expected the daily quota to be used up, got %q", reason)
+	}
+	if reason := quotaReason(quota, 10, 100); reason != "monthly quota of 100 requests used up" {
+		t.Errorf("Expected the monthly quota to be reported first, got %q", reason)
+	}
+	if reason := quotaReason(config.QuotaConfig{}, 1000, 10000); reason != "" {
+		t.Errorf("Expected no limit without a quota, got %q", reason)
+	}
+
+	result := &ETLResult{Extraction: []SourceSummary{{Source: "youtube", Status: "quota_exceeded", Reason: "monthly quota of 100 requests used up"}}}
+	if sources := emptySources(result); len(sources) != 0 {
+		t.Errorf("Expected a source stopped by its quota not to be reported empty, got %v", sources)
+	}
+}
+
+// TestRetentionServiceChecks tests that retention refuses to prune without a
+// retention age or with an unknown mode, before touching the database
+func TestRetentionServiceChecks(t *testing.T) {
+	if _, err := services.NewRetentionService(config.RetentionConfig{Mode: "archive"}, database.NewMemoryStore()).RunRetention("test", true); !errors.Is(err, services.ErrRetentionDisabled) {
+		t.Errorf("Expected retention without a max age to be disabled, got %v", err)
+	}
+
+	retention := services.NewRetentionService(config.RetentionConfig{MaxAge: time.Hour, Mode: "truncate", BatchSize: 10}, database.NewMemoryStore())
+	if !retention.Enabled() {
+		t.Error("Expected retention with a max age to be enabled")
+	}
+	if _, err := retention.RunRetention("test", false); err == nil || !strings.Contains(err.Error(), "unsupported retention mode") {
+		t.Errorf("Expected an unknown mode to be rejected, got %v", err)
+	}
+}
+
+// TestMethodologyChecksum tests that the methodology export is stable and versioned
+func TestMethodologyChecksum(t *testing.T) {
+	first, second := services.CurrentMethodology(), services.CurrentMethodology()
+	if first.Checksum == "" || first.Checksum != second.Checksum {
+		t.Errorf("Expected a stable checksum, got %q and %q", first.Checksum, second.Checksum)
+	}
+	if first.Sentiment.Version != services.SentimentAnalyzerVersion || len(first.Sentiment.Positive) == 0 {
+		t.Errorf("Unexpected sentiment methodology: version %d, %d positive words", first.Sentiment.Version, len(first.Sentiment.Positive))
+	}
+	for _, name := range services.MethodologySections {
+		if _, ok := first.Section(name); !ok {
+			t.Errorf("Missing methodology section %s", name)
+		}
+	}
+}
+
+// TestContentHash tests that content hashes ignore case and whitespace only
+func TestContentHash(t *testing.T) {
+	hash := database.ContentHash("Vaksin  COVID-19\n", "https://example.com/a")
+	if hash != database.ContentHash("vaksin covid-19", " https://example.com/a ") {
+		t.Errorf("Expected formatting differences to keep the hash")
+	}
+	if hash == database.ContentHash("vaksin covid-19", "https://example.com/b") {
+		t.Errorf("Expected a different URL to change the hash")
+	}
+	if len(hash) != 64 {
+		t.Errorf("Expected a hex SHA-256, got %q", hash)
+	}
+}
+
+// TestInstagramPostLocation tests that posts at configured locations get their province
+func TestInstagramPostLocation(t *testing.T) {
+	transformer := NewDataTransformer()
+	transformer.instagramLocations = map[string]string{"213385402": "Jawa Timur"}
+
+	post := InstagramPost{
+		CaptionText: "Antrean vaksinasi di RSUD",
+		Code:        "abc",
+		User:        &InstagramUser{Username: "rsud"},
+		Location:    &InstagramLocation{PK: "213385402", Name: "RSUD Dr. Soetomo", Lat: -7.27, Lng: 112.76},
+	}
+	article := transformer.transformInstagramPost(post)
+	if article.Location == nil || article.Location.ID != "213385402" || article.Region != "Jawa Timur" {
+		t.Errorf("Expected the configured location's province, got %+v and region %q", article.Location, article.Region)
+	}
+
+	post.Location = &InstagramLocation{PK: "999", Name: "Somewhere"}
+	article = transformer.transformInstagramPost(post)
+	if article.Location == nil || article.Region != "" {
+		t.Errorf("Expected a location without province, got %+v and region %q", article.Location, article.Region)
+	}
+}
+
+// TestExtractYouTubeData tests that videos are discovered by search over several
+// pages, ranked by views per day and that only the top ones get their comments
+// extracted, following the comment pages up to the maximum per video
+func TestExtractYouTubeData(t *testing.T) {
+	pages := map[string]string{
+		"": `{"contents": [
+			{"type": "video", "video": {"videoId": "old", "title": "Old", "publishedTimeText": "2 years ago", "stats": {"views": 900000}}},
+			{"type": "channel"},
+			{"type": "video", "video": {"videoId": "new", "title": "New", "publishedTimeText": "1 day ago", "lengthSeconds": 125, "stats": {"views": 50000}}}
+		], "cursorNext": "page2"}`,
+		"page2": `{"contents": [
+			{"type": "video", "video": {"videoId": "new", "title": "New again"}},
+			{"type": "video", "video": {"videoId": "week", "title": "Week", "publishedTimeText": "1 week ago", "stats": {"views": 70000}}},
+			{"type": "video", "video": {"videoId": "extra", "title": "Beyond the maximum"}}
+		], "cursorNext": "page3"}`,
+	}
+	var commented []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/":
+			if r.URL.Query().Get("gl") != "ID" {
+				t.Errorf("Expected the configured geo, got %q", r.URL.Query().Get("gl"))
+			}
+			page, ok := pages[r.URL.Query().Get("cursor")]
+			if !ok {
+				t.Errorf("Unexpected search page %q", r.URL.Query().Get("cursor"))
+			}
+			fmt.Fprint(w, page)
+		case "/video/comments/":
+			id, cursor := r.URL.Query().Get("id"), r.URL.Query().Get("cursor")
+			commented = append(commented, id+cursor)
+			switch {
+			case id == "new" && cursor == "":
+				fmt.Fprint(w, `{"comments": [{"commentId": "new-1", "content": "Tetap pakai masker"}, {"commentId": "new-2"}], "cursorNext": "c2"}`)
+			case id == "new" && cursor == "c2":
+				fmt.Fprint(w, `{"comments": [{"commentId": "new-2"}, {"commentId": "new-3"}, {"commentId": "new-4"}], "cursorNext": "c3"}`)
+			default:
+				fmt.Fprintf(w, `{"comments": [{"commentId": "%s-1"}]}`, id)
+			}
+		}
+	}))
+	defer server.Close()
+
+	extractor := &DataExtractor{
+		youtubeAPI: &YouTubeAPI{APIKey: "test-key", Host: strings.TrimPrefix(server.URL, "https://"), Client: server.Client()},
+		youtube:    config.YouTubeConfig{Geo: "ID", MaxResults: 3, CommentVideos: 2, MaxComments: 3},
+	}
+	data, err := extractor.ExtractYouTubeData(context.Background(), "covid")
+	if err != nil {
+		t.Fatalf("ExtractYouTubeData failed: %v", err)
+	}
+
+	if strings.Join(commented, ",") != "new,newc2,week" {
+		t.Errorf("Expected the comment pages of the two top-ranked videos, got %v", commented)
+	}
+	if len(data.Videos) != 4 || data.Videos[2].Comment.CommentID != "new-3" {
+		t.Fatalf("Expected 3 distinct comments of the first video and 1 of the second, got %d", len(data.Videos))
+	}
+	first := data.Videos[0]
+	if first.Comment == nil || first.Comment.CommentID != "new-1" || first.Video == nil ||
+		first.Video.URL != "https://www.youtube.com/watch?v=new" || first.Video.Views != "50000" || first.Video.Duration != "2:05" {
+		t.Errorf("Expected the comment with its video metadata, got %+v", first)
+	}
+}
+
+// TestExtractInstagramHashtags tests that each hashtag is read chunk by chunk up
+// to the maximum, a failing hashtag does not fail the others and that the posts
+// carry their hashtag through transformation
+func TestExtractInstagramHashtags(t *testing.T) {
+	var requested []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, maxID := r.URL.Query().Get("name"), r.URL.Query().Get("max_id")
+		requested = append(requested, name+maxID)
+		switch {
+		case name == "covid19" && maxID == "":
+			fmt.Fprint(w, `[[{"code": "a", "caption_text": "Covid"}, {"code": "b"}], "m2"]`)
+		case name == "covid19" && maxID == "m2":
+			fmt.Fprint(w, `[[{"code": "b"}, {"code": "c"}, {"code": "d"}], "m3"]`)
+		case name == "vaksinasi":
+			fmt.Fprint(w, `[[{"code": "v", "caption_text": "Vaksinasi covid"}], ""]`)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	extractor := &DataExtractor{
+		instagramAPI:      &InstagramAPI{APIKey: "test-key", Host: strings.TrimPrefix(server.URL, "https://"), Client: server.Client()},
+		instagramMaxPosts: 3,
+	}
+	settings := RunSettings{Keywords: []string{"COVID-19"}}
+	data, err := extractor.extractInstagramData(context.Background(), settings.Hashtags([]string{"#covid19", "vaksinasi", "pandemi"}))
+	if err != nil {
+		t.Fatalf("extractInstagramData failed: %v", err)
+	}
+
+	if strings.Join(requested, ",") != "covid19,covid19m2,vaksinasi,pandemi" {
+		t.Errorf("Expected the chunks of each hashtag once, got %v", requested)
+	}
+	if len(data.Hashtags) != 3 || len(data.Hashtags[0].Posts) != 3 || len(data.Hashtags[1].Posts) != 1 || data.Hashtags[2].Error == "" {
+		t.Fatalf("Expected 3 posts of #covid19, 1 of #vaksinasi and an error for #pandemi, got %+v", data.Hashtags)
+	}
+
+	transformed := NewDataTransformer().TransformData(map[string]interface{}{"instagram": data})
+	hashtags := map[string]int{}
+	for _, article := range transformed.News {
+		hashtags[article.Hashtag]++
+	}
+	if hashtags["covid19"] != 3 || hashtags["vaksinasi"] != 1 {
+		t.Errorf("Expected the articles to carry their hashtag, got %v", hashtags)
+	}
+
+	if _, err := extractor.extractInstagramData(context.Background(), []string{"pandemi"}); err == nil {
+		t.Error("Expected an error when every hashtag fails")
+	}
+}
+
+// TestExtractInstagramComments tests that the comments of the most engaging posts
+// are extracted and transformed as comments keeping their post
+func TestExtractInstagramComments(t *testing.T) {
+	var commented []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/hashtag/medias/top/recent/chunk":
+			fmt.Fprint(w, `[[
+				{"pk": 1, "code": "quiet", "like_count": 5, "comment_count": 1},
+				{"pk": 2, "code": "popular", "caption_text": "Vaksin booster", "like_count": 900, "comment_count": 40, "user": {"username": "kemenkes"}},
+				{"pk": 3, "code": "uncommented", "like_count": 5000},
+				{"pk": 4, "code": "busy", "like_count": 300, "comment_count": 12}
+			], ""]`)
+		case "/v1/media/comments":
+			id := r.URL.Query().Get("id")
+			commented = append(commented, id)
+			if r.URL.Query().Get("amount") != "2" {
+				t.Errorf("Expected the configured amount, got %q", r.URL.Query().Get("amount"))
+			}
+			fmt.Fprintf(w, `[[{"pk": %s1, "text": "Sudah vaksin covid booster", "created_at": 1700000000, "user": {"username": "warga"}}, {"pk": %s2, "text": " "}]]`, id, id)
+		}
+	}))
+	defer server.Close()
+
+	extractor := &DataExtractor{
+		instagramAPI:          &InstagramAPI{APIKey: "test-key", Host: strings.TrimPrefix(server.URL, "https://"), Client: server.Client()},
+		instagramMaxPosts:     10,
+		instagramCommentPosts: 2,
+		instagramMaxComments:  2,
+	}
+	data, err := extractor.extractInstagramData(context.Background(), []string{"vaksinasi"})
+	if err != nil {
+		t.Fatalf("extractInstagramData failed: %v", err)
+	}
+	if strings.Join(commented, ",") != "2,4" {
+		t.Errorf("Expected the comments of the two most engaging commented posts, got %v", commented)
+	}
+
+	transformed := NewDataTransformer().TransformData(map[string]interface{}{"instagram": data})
+	var comments []TransformedArticle
+	for _, article := range transformed.News {
+		if article.ContentType == contentTypeComment {
+			comments = append(comments, article)
+		}
+	}
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 comments with text, got %d", len(comments))
+	}
+	comment := comments[0]
+	if comment.Parent == nil || comment.Parent.Code != "popular" || comment.Parent.Author != "kemenkes" || comment.Hashtag != "vaksinasi" {
+		t.Errorf("Expected the comment to keep its post and hashtag, got %+v", comment)
+	}
+	if comment.URL != "https://instagram.com/p/popular/c/21" || comment.PublishedAt != "2023-11-14T22:13:20Z" || len(articleViolations(comment)) > 0 {
+		t.Errorf("Expected a valid comment record, got URL %q, published %q", comment.URL, comment.PublishedAt)
+	}
+}
+
+// TestExtractIndonesiaNewsOutlets tests that the configured outlets are read page
+// by page with their own page counts and limits, up to the maximum per outlet,
+// and that a failing outlet is reported in the stats
+func TestExtractIndonesiaNewsOutlets(t *testing.T) {
+	var requested []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, limit := r.URL.Query().Get("page"), r.URL.Query().Get("limit")
+		requested = append(requested, r.URL.Path+"@"+page)
+		switch r.URL.Path {
+		case "/search/kompas":
+			fmt.Fprint(w, `{"xml": {"pencarian": {"item": [{"title": "Kompas 1"}, {"title": "Kompas 2"}]}}}`)
+		case "/search/detik":
+			if limit != "10" {
+				t.Errorf("Expected the client's default limit for detik, got %q", limit)
+			}
+			fmt.Fprintf(w, `{"item": [{"title": "Detik %s-1"}, {"title": "Detik %s-2"}]}`, page, page)
+		case "/search/cnn":
+			if limit != "5" {
+				t.Errorf("Expected the configured limit for cnn, got %q", limit)
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	extractor := &DataExtractor{
+		indonesiaNewsAPI: &IndonesiaNewsAPI{APIKey: "test-key", Host: strings.TrimPrefix(server.URL, "https://"), Client: server.Client()},
+		indonesiaNews: config.IndonesiaNewsConfig{
+			Sources:      []string{"kompas", "detik", "cnn"},
+			MaxResults:   3,
+			Pages:        1,
+			SourcePages:  map[string]int{"detik": 3},
+			SourceLimits: map[string]int{"cnn": 5},
+		},
+	}
+	data, count := extractor.extractIndonesiaNewsSource(context.Background(), "covid")
+	if count != 5 {
+		t.Fatalf("Expected 2 kompas and 3 detik items, got %d", count)
+	}
+	if strings.Join(requested, ",") != "/search/kompas@1,/search/detik@1,/search/detik@2,/search/cnn@1" {
+		t.Errorf("Unexpected requests %v", requested)
+	}
+
+	summary := sourceResult{name: "indonesia_news", data: data, count: count}.summary()
+	want := []OutletStats{{Outlet: "kompas", Pages: 1, Items: 2}, {Outlet: "detik", Pages: 2, Items: 3}}
+	if len(summary.Outlets) != 3 || summary.Outlets[0] != want[0] || summary.Outlets[1] != want[1] || summary.Outlets[2].Error == "" {
+		t.Errorf("Expected the stats of each outlet in the summary, got %+v", summary.Outlets)
+	}
+}
+
+// TestExtractIndonesiaNewsBodies tests that the most relevant items get their full article body
+func TestExtractIndonesiaNewsBodies(t *testing.T) {
+	var details []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/search/kompas":
+			fmt.Fprint(w, `{"xml": {"pencarian": {"item": [{"title": "Harga cabai naik", "guid": "k2"}, {"title": "Vaksin covid dipercepat", "guid": "k1"}]}}}`)
+		case "/search/detik":
+			fmt.Fprint(w, `{"item": [{"title": "PPKM Jakarta", "url": "https://detik.com/d2"}, {"title": "Kasus covid", "url": "https://detik.com/d1"}, {"title": "Pandemi"}]}`)
+		case "/detail/kompas":
+			details = append(details, query.Get("guid"))
+			fmt.Fprint(w, `{"xml": {"pencarian": {"item": [{"content": "<p>Vaksinasi <b>covid</b> dipercepat di Jakarta.</p>"}]}}}`)
+		case "/detail/detik":
+			details = append(details, query.Get("url"))
+			if query.Get("url") == "https://detik.com/d1" {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			fmt.Fprint(w, `{"item": [{"content": "PPKM Jakarta diperpanjang."}]}`)
+		}
+	}))
+	defer server.Close()
+
+	extractor := &DataExtractor{
+		indonesiaNewsAPI: &IndonesiaNewsAPI{APIKey: "test-key", Host: strings.TrimPrefix(server.URL, "https://"), Client: server.Client()},
+		indonesiaNews: config.IndonesiaNewsConfig{
+			Sources:     []string{"kompas", "detik"},
+			MaxResults:  10,
+			Pages:       1,
+			DetailItems: 3,
+		},
+	}
+	data, err := extractor.extractIndonesiaNewsData(context.Background(), "covid")
+	if err != nil {
+		t.Fatalf("extractIndonesiaNewsData failed: %v", err)
+	}
+	if strings.Join(details, ",") != "k1,https://detik.com/d1,https://detik.com/d2" {
+		t.Errorf("Expected the details of the relevant items, most relevant first, got %v", details)
+	}
+
+	bodies := map[string]string{}
+	for _, item := range data.Sources.Items {
+		bodies[item.Title] = item.Content
+	}
+	if bodies["Vaksin covid dipercepat"] != "Vaksinasi covid dipercepat di Jakarta." || bodies["PPKM Jakarta"] != "PPKM Jakarta diperpanjang." {
+		t.Errorf("Expected the cleaned full bodies, got %v", bodies)
+	}
+	if bodies["Kasus covid"] != "" || bodies["Harga cabai naik"] != "" {
+		t.Errorf("Expected the failed and irrelevant items to keep their snippets, got %v", bodies)
+	}
+	if data.Outlets[0].Bodies != 1 || data.Outlets[1].Bodies != 1 {
+		t.Errorf("Expected one body per outlet, got %+v", data.Outlets)
+	}
+}
+
+// TestFixtureRecordAndReplay tests that recorded responses are replayed for the same request only
+func TestFixtureRecordAndReplay(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, "Too many requests")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"item": [{"title": "Kasus covid"}]}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: newRecordingTransport(server.Client().Transport, dir, "indonesia_news")}
+	for _, query := range []string{"page=1&keyword=covid", "page=2&keyword=covid"} {
+		resp, err := recorder.Get(server.URL + "/search/detik?" + query)
+		if err != nil {
+			t.Fatalf("Recorded request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	replayer := &http.Client{Transport: newReplayTransport(dir, "indonesia_news")}
+	resp, err := replayer.Get("https://indonesia-news.p.rapidapi.com/search/detik?keyword=covid&page=1")
+	if err != nil {
+		t.Fatalf("Replayed request failed: %v", err)
+	}
+	var replayed indonesiaNewsBody
+	json.NewDecoder(resp.Body).Decode(&replayed)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(replayed.Item) != 1 || replayed.Item[0].Title != "Kasus covid" || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the recorded response whatever the host and query order, got %d %+v", resp.StatusCode, replayed)
+	}
+
+	resp, err = replayer.Get("https://indonesia-news.p.rapidapi.com/search/detik?keyword=covid&page=2")
+	if err != nil {
+		t.Fatalf("Replayed request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || string(body) != "Too many requests" {
+		t.Errorf("Expected the recorded error response, got %d %q", resp.StatusCode, body)
+	}
+
+	if _, err := replayer.Get("https://indonesia-news.p.rapidapi.com/search/detik?keyword=covid&page=3"); err == nil {
+		t.Error("Expected a request without a fixture to fail")
+	}
+}
+
+// TestReplayExtractorFixtures tests extraction and transformation against the recorded
+// API responses in testdata/fixtures
+func TestReplayExtractorFixtures(t *testing.T) {
+	replayClient := func(source string) *http.Client {
+		return &http.Client{Transport: newReplayTransport("testdata/fixtures", source)}
+	}
+	extractor := &DataExtractor{
+		youtubeAPI:       &YouTubeAPI{Host: "youtube138.p.rapidapi.com", Client: replayClient("youtube")},
+		youtube:          config.YouTubeConfig{Language: "id", Geo: "ID", MaxResults: 2, CommentVideos: 1, MaxComments: 2},
+		indonesiaNewsAPI: &IndonesiaNewsAPI{Host: "indonesia-news.p.rapidapi.com", Client: replayClient("indonesia_news")},
+		indonesiaNews:    config.IndonesiaNewsConfig{Sources: []string{"kompas", "detik"}, MaxResults: 10, Pages: 1, DetailItems: 1},
+	}
+
+	youtube, err := extractor.ExtractYouTubeData(context.Background(), "covid")
+	if err != nil {
+		t.Fatalf("ExtractYouTubeData failed: %v", err)
+	}
+	news, err := extractor.extractIndonesiaNewsData(context.Background(), "covid")
+	if err != nil {
+		t.Fatalf("extractIndonesiaNewsData failed: %v", err)
+	}
+
+	transformed := NewDataTransformer().TransformData(map[string]interface{}{"youtube": youtube, "indonesia_news": news})
+	if len(transformed.YouTube) != 2 {
+		t.Fatalf("Expected the 2 comments of the top-ranked video, got %d", len(transformed.YouTube))
+	}
+	if len(transformed.News) != 3 {
+		t.Fatalf("Expected 2 kompas and 1 detik articles, got %d", len(transformed.News))
+	}
+	for _, article := range transformed.News {
+		if strings.HasPrefix(article.Title, "Kasus Covid-19 di Jakarta") && !strings.Contains(article.Content, "vaksin booster diimbau") {
+			t.Errorf("Expected the full kompas body, got %q", article.Content)
+		}
+	}
+}
+
+// TestCancelAllRunsOnShutdown tests that runs cancelled on shutdown can tell
+// from a cancellation by batch ID and that WaitForRuns waits for them to end
+func TestCancelAllRunsOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	done := registerRun("run_shutdown", "default", cancel)
+	other, cancelOther := context.WithCancelCause(context.Background())
+	doneOther := registerRun("run_cancelled", "default", cancelOther)
+
+	if !CancelRun("run_cancelled") || CancelAllRuns() != 2 {
+		t.Fatal("expected both runs to be cancelled")
+	}
+	if !errors.Is(context.Cause(ctx), errShutdown) {
+		t.Errorf("expected the shutdown cause, got %v", context.Cause(ctx))
+	}
+	if cause := context.Cause(other); cause != context.Canceled {
+		t.Errorf("expected a run cancelled by batch ID to keep context.Canceled, got %v", cause)
+	}
+
+	doneOther()
+	if WaitForRuns(10 * time.Millisecond) {
+		t.Error("expected WaitForRuns to time out while a run is in progress")
+	}
+	done()
+	if !WaitForRuns(time.Second) {
+		t.Error("expected WaitForRuns to return once the runs ended")
+	}
+}
+
+// TestCampaignCheckpoint tests that a campaign resumes its checkpoint only
+// while its query is unchanged, and keeps no checkpoint when resuming is off
+func TestCampaignCheckpoint(t *testing.T) {
+	cfg := config.ETLConfig{
+		Keywords:  []string{"COVID-19"},
+		Sources:   []string{"youtube"},
+		Campaigns: []config.CampaignConfig{{Name: "covid", Keywords: []string{"COVID-19"}}, {Name: "dengue", Keywords: []string{"DBD"}}},
+	}
+	settings, err := ResolveRunSettings(cfg, nil)
+	if err != nil {
+		t.Fatalf("ResolveRunSettings failed: %v", err)
+	}
+	campaigns := settings.forCampaigns()
+
+	result := &ETLResult{BatchID: "run_1", ProjectID: "default"}
+	interrupted := map[int]database.PipelineCheckpoint{
+		0: {BatchID: "run_1", CampaignIndex: 0, Campaign: "covid", Query: "COVID-19", Stage: stageTransformed, RawDataIDs: []int{4, 5}},
+		1: {BatchID: "run_1", CampaignIndex: 1, Campaign: "dengue", Query: "DBD OR demam berdarah", Stage: stageExtracted},
+	}
+	eo := &ETLOrchestrator{resumeMaxAge: time.Hour}
+
+	resumed := eo.campaignCheckpoint(result, 0, campaigns[0], interrupted)
+	if resumed.Stage != stageTransformed || len(resumed.RawDataIDs) != 2 {
+		t.Errorf("expected the unchanged campaign to resume its checkpoint, got %+v", resumed)
+	}
+	fresh := eo.campaignCheckpoint(result, 1, campaigns[1], interrupted)
+	if fresh.Stage != "" || fresh.Query != "DBD" || fresh.BatchID != "run_1" || fresh.CampaignIndex != 1 {
+		t.Errorf("expected a fresh checkpoint for the changed campaign, got %+v", fresh)
+	}
+
+	eo.resumeMaxAge = 0
+	if eo.campaignCheckpoint(result, 0, campaigns[0], interrupted) != nil {
+		t.Error("expected no checkpoint with resuming disabled")
+	}
+}
+
+// TestElasticsearchIndexRecords tests that records are bulk indexed by content hash and rejections are counted
+func TestElasticsearchIndexRecords(t *testing.T) {
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "HEAD":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "PUT":
+			w.Write([]byte(`{"acknowledged":true}`))
+		case r.URL.Path == "/_bulk":
+			body, _ := io.ReadAll(r.Body)
+			lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+			for i := 0; i < len(lines); i += 2 {
+				actions = append(actions, lines[i])
+			}
+			w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	loader := NewElasticsearchLoader(config.ElasticsearchConfig{URL: server.URL, Index: "records", BulkSize: 10, Timeout: time.Second})
+	records := []*database.ProcessedData{
+		{ProjectID: "default", Source: "youtube", Title: "Vaksin", ContentHash: "a"},
+		{ProjectID: "default", Source: "kompas", Title: "PPKM", ContentHash: "b"},
+	}
+	indexed, failed, err := loader.IndexRecords(records)
+	if err != nil {
+		t.Fatalf("IndexRecords failed: %v", err)
+	}
+	if indexed != 1 || failed != 1 {
+		t.Errorf("Expected 1 indexed and 1 failed, got %d and %d", indexed, failed)
+	}
+	if len(actions) != 2 || !strings.Contains(actions[0], `"_id":"default:a"`) {
+		t.Errorf("Expected bulk actions keyed by content hash, got %v", actions)
+	}
+}
+
+// TestParseArticlePage tests that the headline and article paragraphs are extracted from a page
+func TestParseArticlePage(t *testing.T) {
+	page := `<html><head><title>Ignored | Kompas</title>
+		<meta property="og:title" content="PPKM Diperpanjang &amp; Diperketat">
+		<script>var p = "<p>not text</p>";</script></head>
+		<body><p>Menu</p><article><p>Pemerintah <b>memperpanjang</b> PPKM.</p><p></p><p>Vaksinasi  dipercepat.</p></article></body></html>`
+
+	snapshot := parseArticlePage(page)
+	if snapshot.Title != "PPKM Diperpanjang & Diperketat" {
+		t.Errorf("Expected the og:title, got %q", snapshot.Title)
+	}
+	if snapshot.Content != "Pemerintah memperpanjang PPKM.\nVaksinasi dipercepat." {
+		t.Errorf("Expected the article paragraphs, got %q", snapshot.Content)
+	}
+
+	edited := snapshot
+	edited.Title = "PPKM Diperpanjang"
+	if edited.Digest() == snapshot.Digest() {
+		t.Errorf("Expected an edited headline to change the digest")
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := `User-agent: *
+Disallow: /
+
+User-agent: covid19-kms-crawler
+Disallow: /search
+Disallow: /*.pdf$
+Allow: /search/covid
+Crawl-delay: 2
+`
+	rules := parseRobots(strings.NewReader(robots), "covid19-kms-crawler/1.0")
+	if rules.crawlDelay != 2*time.Second {
+		t.Errorf("Expected crawl delay 2s, got %v", rules.crawlDelay)
+	}
+
+	cases := map[string]bool{
+		"/read/2020/03/02/covid":  true,
+		"/search?q=vaksin":        false,
+		"/search/covid/2020":      true,
+		"/files/laporan.pdf":      false,
+		"/files/laporan.pdf.html": true,
+	}
+	for path, want := range cases {
+		if got := rules.allowed(path); got != want {
+			t.Errorf("allowed(%q) = %v, expected %v", path, got, want)
+		}
+	}
+
+	general := parseRobots(strings.NewReader(robots), "OtherBot/2.0")
+	if general.allowed("/read/2020/03/02/covid") {
+		t.Error("Expected the * group to disallow every path")
+	}
+
+	partial := parseRobots(strings.NewReader("User-agent: *\nDisallow: /\n\nUser-agent: covid19\nAllow: /\n"), "covid19-kms-crawler/1.0")
+	if partial.allowed("/read/2020/03/02/covid") {
+		t.Error("Expected a group naming part of the product token not to apply")
+	}
+	upper := parseRobots(strings.NewReader("User-agent: *\nDisallow: /\n\nUser-agent: COVID19-KMS-Crawler\nAllow: /\n"), "covid19-kms-crawler/1.0")
+	if !upper.allowed("/read/2020/03/02/covid") {
+		t.Error("Expected the product token to match case-insensitively")
+	}
+}
+
+func TestRobotsServerErrorDisallows(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	crawler := NewSitemapCrawler(config.CrawlerConfig{UserAgent: "covid19-kms-crawler/1.0", Timeout: time.Second})
+	target, _ := url.Parse(server.URL + "/read/2020/03/02/covid")
+	if crawler.robotsFor(context.Background(), target).allowed(target.Path) {
+		t.Error("Expected a robots.txt server error to disallow the host")
+	}
+
+	status = http.StatusNotFound
+	crawler = NewSitemapCrawler(config.CrawlerConfig{UserAgent: "covid19-kms-crawler/1.0", Timeout: time.Second})
+	if !crawler.robotsFor(context.Background(), target).allowed(target.Path) {
+		t.Error("Expected a missing robots.txt to allow the host")
+	}
+}
+
+// TestTransformStoredPayloads tests that payloads read back from raw_data
+// transform like the payloads of a run
+func TestTransformStoredPayloads(t *testing.T) {
+	extracted := map[string]interface{}{
+		"youtube": &YouTubeData{Videos: []YouTubeEntry{
+			{
+				Comment: &YouTubeComment{Content: "Sudah vaksin booster", Stats: YouTubeCommentStats{Votes: 3}},
+				Video:   &YouTubeVideo{Title: "Update COVID-19"},
+			},
+		}},
+		"google_news": &NewsData{Articles: []NewsArticle{
+			{Title: "Kasus COVID-19 meningkat"},
+		}},
+	}
+
+	stored := map[string]interface{}{}
+	for name, payload := range extracted {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("Failed to marshal %s payload: %v", name, err)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatalf("Failed to unmarshal %s payload: %v", name, err)
+		}
+		stored[name] = decoded
+	}
+
+	fresh := NewDataTransformer().TransformData(extracted)
+	replayed := NewDataTransformer().TransformData(stored)
+	if len(replayed.YouTube) != 1 || len(replayed.News) != 1 {
+		t.Fatalf("Expected 1 comment and 1 article, got %d and %d", len(replayed.YouTube), len(replayed.News))
+	}
+	if replayed.YouTube[0].Description != fresh.YouTube[0].Description || replayed.YouTube[0].Title != fresh.YouTube[0].Title {
+		t.Errorf("Expected the stored comment to transform like the fresh one, got %+v", replayed.YouTube[0])
+	}
+}
+
+// TestTransformMalformedPayloads tests that fields of unexpected types are
+// skipped instead of failing or panicking the transformation
+func TestTransformMalformedPayloads(t *testing.T) {
+	var stored map[string]interface{}
+	payloads := `{
+		"youtube": {"videos": [
+			{"comment": {"content": "Vaksin gratis", "author": {"title": "@warga", "channelId": "UC1"}}, "video": {"title": "Update"}},
+			{"comment": {"content": "Stats tidak ada", "author": "viewer", "stats": "n/a"}, "video": {"title": "Update"}},
+			"not a comment"
+		]},
+		"instagram": {"posts": [
+			{"caption_text": "Ayo vaksin", "code": "p1", "like_count": "12", "location": {"pk": "213385402", "name": "RSUD"}},
+			42
+		]},
+		"google_news": {"articles": [{"title": "Kasus menurun", "date": "2021-07-15"}, null]},
+		"indonesia_news": {"sources": "unavailable"}
+	}`
+	if err := json.Unmarshal([]byte(payloads), &stored); err != nil {
+		t.Fatalf("Failed to unmarshal payloads: %v", err)
+	}
+
+	transformed := NewDataTransformer().TransformData(stored)
+	if len(transformed.YouTube) != 2 || len(transformed.News) != 2 {
+		t.Fatalf("Expected 2 comments and 2 articles, got %d and %d", len(transformed.YouTube), len(transformed.News))
+	}
+	comment := transformed.YouTube[0].Metadata["comment"].(map[string]interface{})
+	if comment["author"] != "@warga" || transformed.YouTube[1].Metadata["comment"].(map[string]interface{})["author"] != "viewer" {
+		t.Errorf("Expected the authors of both comment shapes, got %v", comment["author"])
+	}
+	for _, article := range transformed.News {
+		if article.SourceKey == "instagram" && (article.Location == nil || article.Location.ID != "213385402") {
+			t.Errorf("Expected the post's location to survive the bad like count, got %+v", article.Location)
+		}
+	}
+	if report := transformed.Report; report.Skipped != 3 || report.Sources["youtube"].Reasons["neither a comment with its video nor a video"] != 1 ||
+		report.Sources["instagram"].Reasons["empty record"] != 1 || report.Sources["google_news"].Reasons["empty record"] != 1 {
+		t.Errorf("Expected 3 skipped records with their reasons, got %+v", report)
+	}
+}
+
+// panickingScorer is a toxicity scorer that panics on texts mentioning "boom"
+type panickingScorer struct{}
+
+func (panickingScorer) Score(text string) (float64, error) {
+	if strings.Contains(text, "boom") {
+		panic("scorer exploded")
+	}
+	return 0.1, nil
+}
+
+// TestTransformRecoversFromPanics tests that a record whose transformation
+// panics is skipped and reported while the other records are transformed
+func TestTransformRecoversFromPanics(t *testing.T) {
+	transformer := NewDataTransformer()
+	transformer.toxicityScorer = panickingScorer{}
+
+	posts := []InstagramPost{
+		{CaptionText: "Vaksinasi lancar", Code: "p1"},
+		{CaptionText: "boom", Code: "p2"},
+		{CaptionText: "Antrean vaksin", Code: "p3"},
+	}
+	transformed, err := transformer.TransformDataInChunks(map[string]interface{}{"instagram": &InstagramData{Posts: posts}}, 10, func(chunk *TransformedData) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TransformDataInChunks failed: %v", err)
+	}
+	if transformed.Summary.TotalArticles != 2 {
+		t.Errorf("Expected the other 2 posts to be transformed, got %d", transformed.Summary.TotalArticles)
+	}
+	report := transformed.Report
+	if report.Skipped != 1 || report.Sources["instagram"].Reasons["panic: scorer exploded"] != 1 {
+		t.Fatalf("Expected the panicking post in the report, got %+v", report)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Record != "posts[1]" {
+		t.Errorf("Expected the position of the panicking post, got %+v", report.Errors)
+	}
+
+	// Reports of campaigns add up in the run result
+	merged := mergeTransformedData(&TransformedData{Report: report}, &TransformedData{Report: report})
+	if merged.Report.Skipped != 2 || merged.Report.Sources["instagram"].Skipped != 2 || len(merged.Report.Errors) != 2 {
+		t.Errorf("Expected the merged report to count both campaigns, got %+v", merged.Report)
+	}
+}
+
+func TestValidateRecords(t *testing.T) {
+	now := time.Now().Format(time.RFC3339)
+	data := &TransformedData{
+		YouTube: []TransformedVideo{
+			{ID: "v1", Title: "Vaksin booster", CovidRelevanceScore: 0.8, TransformedAt: now},
+			{ID: "v2", Title: " ", CovidRelevanceScore: 0.8, TransformedAt: now},
+		},
+		News: []TransformedArticle{
+			{ID: "a1", Title: "PPKM diperpanjang", URL: "https://www.kompas.com/a1", PublishedAt: now, CovidRelevanceScore: 1, TransformedAt: now},
+			{ID: "a2", Title: "Tanpa tautan", CovidRelevanceScore: 0, TransformedAt: now},
+			{ID: "a3", Title: "Tautan rusak", URL: "kompas.com/a3", CovidRelevanceScore: 0.5, TransformedAt: now},
+			{ID: "a4", Title: "Skor aneh", URL: "https://detik.com/a4", PublishedAt: "kemarin", CovidRelevanceScore: 1.5, TransformedAt: now},
+		},
+	}
+
+	report := newValidationReport("reject")
+	invalid := validateRecords(data, report)
+
+	if report.Checked != 6 || report.Passed != 3 || report.Rejected != 3 {
+		t.Fatalf("Expected 3 of 6 records to pass, got %+v", report)
+	}
+	if len(data.YouTube) != 1 || len(data.News) != 2 || data.Summary.TotalArticles != 2 {
+		t.Errorf("Expected the invalid records to be removed, got %d videos and %d articles", len(data.YouTube), len(data.News))
+	}
+	expected := map[string]int{ruleEmptyTitle: 1, ruleInvalidURL: 1, ruleRelevanceRange: 1, ruleInvalidTimestamp: 1}
+	for rule, count := range expected {
+		if report.Rules[rule] != count {
+			t.Errorf("Expected %d records breaking %s, got %d", count, rule, report.Rules[rule])
+		}
+	}
+	if len(invalid) != 3 || invalid[2].id != "a4" || len(invalid[2].reasons) != 2 {
+		t.Errorf("Expected the invalid records with their reasons, got %+v", invalid)
+	}
+
+	if newValidationReport("bogus").Mode != ValidationQuarantine {
+		t.Error("Expected unknown modes to quarantine")
+	}
+}
+
+func TestSelfTestSourceAPI(t *testing.T) {
+	t.Setenv("RAPIDAPI_KEY", "test-key-0123456789")
+
+	cases := map[int]string{
+		http.StatusOK:              SelfTestPass,
+		http.StatusForbidden:       SelfTestFail,
+		http.StatusTooManyRequests: SelfTestWarn,
+		http.StatusBadGateway:      SelfTestWarn,
+	}
+	for code, want := range cases {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("x-rapidapi-key") != "test-key-0123456789" {
+				t.Errorf("Expected the RapidAPI key header, got %q", r.Header.Get("x-rapidapi-key"))
+			}
+			w.WriteHeader(code)
+		}))
+		status, message := checkSourceAPI(context.Background(), server.Client(), strings.TrimPrefix(server.URL, "https://"), "/search?q=covid")
+		server.Close()
+		if status != want {
+			t.Errorf("HTTP %d: expected %s, got %s (%s)", code, want, status, message)
+		}
+	}
+
+	report := (&SelfTestReport{Checks: []SelfTestCheck{{Status: SelfTestPass}, {Status: SelfTestWarn}}}).finish()
+	if report.Status != SelfTestWarn {
+		t.Errorf("Expected the report to take its worst status, got %s", report.Status)
+	}
+}
+
+func TestProgressStream(t *testing.T) {
+	result := &ETLResult{BatchID: NewBatchID("run"), ProjectID: "progress-test"}
+	ctx, finish := trackProgress(context.Background(), "pipeline", result)
+	progress := progressFrom(ctx)
+	progress.setCampaigns(2)
+	progress.startCampaign(0, "covid")
+	progress.startExtraction(2)
+	progress.sourceDone(SourceSummary{Source: "youtube", Status: "success", RecordCount: 10})
+
+	// A late subscriber first receives the events so far
+	replay, events, unsubscribe := SubscribeProgress("progress-test")
+	defer unsubscribe()
+	if len(replay) != 3 || replay[0].Stage != "run" || replay[2].Source != "youtube" {
+		t.Fatalf("Expected the run start, extraction start and youtube events, got %+v", replay)
+	}
+	if replay[2].Campaign != "covid" || math.Abs(replay[2].Progress-0.135) > 1e-9 {
+		t.Errorf("Expected youtube at 13.5%% of campaign covid, got %+v", replay[2])
+	}
+
+	progress.sourceDone(SourceSummary{Source: "google_news", Status: "error", Error: "timeout"})
+	progress.stageDone("transformation", "completed", 8)
+	progress.stageDone("loading", "completed", 8)
+	progress.step("deduplication")
+	result.Status = "success"
+	finish()
+
+	var received []ProgressEvent
+	for len(received) < 5 {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected 5 more events, got %+v", received)
+		}
+	}
+	expected := []struct {
+		stage, status string
+		progress      float64
+	}{
+		{"extraction", "error", 0.27},
+		{"transformation", "completed", 0.36},
+		{"loading", "completed", 0.45},
+		{"deduplication", "started", 0.9},
+		{"run", "success", 1},
+	}
+	for i, want := range expected {
+		got := received[i]
+		if got.Stage != want.stage || got.Status != want.status || math.Abs(got.Progress-want.progress) > 1e-9 {
+			t.Errorf("Event %d: expected %s %s at %.2f, got %s %s at %.3f", i, want.stage, want.status, want.progress, got.Stage, got.Status, got.Progress)
+		}
+	}
+
+	// Finished runs are no longer replayed
+	replay, _, unsubscribeLate := SubscribeProgress("progress-test")
+	unsubscribeLate()
+	if len(replay) != 0 {
+		t.Errorf("Expected no replay after the run ended, got %+v", replay)
+	}
+}
+
+// TestSeedDemoDataIntoStore tests that the demo dataset loads into a store
+// without PostgreSQL, as it does into a SQLite file, and that seeding again
+// updates the same records
+func TestSeedDemoDataIntoStore(t *testing.T) {
+	store := database.NewMemoryStore()
+	opts := SeedOptions{Records: 40, Seed: 7, End: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), Store: store}
+	for i := 0; i < 2; i++ {
+		if _, err := SeedDemoData(opts); err != nil {
+			t.Fatalf("Failed to seed the demo data: %v", err)
+		}
+	}
+
+	count, err := store.CountProcessedData(context.Background(), database.ProcessedDataFilter{Project: database.DefaultProject})
+	if err != nil || count != 40 {
+		t.Fatalf("Expected 40 demo records after seeding twice, got %d (%v)", count, err)
+	}
+	overall, _, err := store.GetSentimentCounts(context.Background(), database.DefaultProject, "", nil, nil, false, true)
+	if err != nil || overall.Total == 0 {
+		t.Errorf("Expected the demo records to carry sentiments, got %+v (%v)", overall, err)
+	}
+}
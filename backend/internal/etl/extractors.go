@@ -1,12 +1,18 @@
 package etl
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"runtime/debug"
+	"sort"
+	"sync"
 	"time"
+
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
 )
 
 // DataExtractor orchestrates data extraction from all API sources
@@ -15,6 +21,29 @@ type DataExtractor struct {
 	realTimeNewsAPI  *RealTimeNewsAPI
 	instagramAPI     *InstagramAPI
 	indonesiaNewsAPI *IndonesiaNewsAPI
+	health           *SourceHealth
+	// instagramLocations are the location IDs whose recent posts are extracted
+	// along with the hashtags
+	instagramLocations []string
+	// instagramHashtags are extracted with the hashtag of the run's keywords,
+	// up to instagramMaxPosts posts each
+	instagramHashtags []string
+	instagramMaxPosts int
+	// instagramCommentPosts are the most engaging posts whose comments are
+	// extracted, up to instagramMaxComments each
+	instagramCommentPosts int
+	instagramMaxComments  int
+	// youtube sets the search that discovers the videos whose comments are extracted
+	youtube config.YouTubeConfig
+	// indonesiaNews sets the outlets searched and how many pages of each
+	indonesiaNews config.IndonesiaNewsConfig
+	// sourceTimeout bounds the extraction of each source; 0 means no bound
+	sourceTimeout time.Duration
+	// maxConcurrent is how many sources are extracted at once; 0 means all
+	maxConcurrent int
+	// quotas are the request quotas of the sources; sources without one are
+	// not limited
+	quotas map[string]config.QuotaConfig
 }
 
 // ExtractedData represents the structure of extracted data from all sources
@@ -22,6 +51,30 @@ type ExtractedData struct {
 	Timestamp string                 `json:"timestamp"`
 	Query     string                 `json:"query"`
 	Sources   map[string]interface{} `json:"sources"`
+	Summaries []SourceSummary        `json:"summaries"`
+	Stored    bool                   `json:"-"` // payloads read back from raw_data, which are not stored again
+}
+
+// SourceSummary describes the outcome of extracting one source
+type SourceSummary struct {
+	Source      string `json:"source"`
+	Status      string `json:"status"` // "success", "error", "skipped" or "quota_exceeded"
+	RecordCount int    `json:"record_count"`
+	Duration    string `json:"duration"`
+	Error       string `json:"error,omitempty"`
+	Reason      string `json:"reason,omitempty"` // why a source was skipped or stopped
+	Campaign    string `json:"campaign,omitempty"`
+	// Outlets break down sources that search several outlets
+	Outlets []OutletStats `json:"outlets,omitempty"`
+}
+
+// OutletStats describes what one outlet of a source returned
+type OutletStats struct {
+	Outlet string `json:"outlet"`
+	Pages  int    `json:"pages"` // pages read
+	Items  int    `json:"items"`
+	Bodies int    `json:"bodies,omitempty"` // full article bodies fetched
+	Error  string `json:"error,omitempty"`
 }
 
 // NewDataExtractor creates a new data extractor instance
@@ -31,11 +84,26 @@ func NewDataExtractor() *DataExtractor {
 	rapidAPIKey := os.Getenv("RAPIDAPI_KEY")
 	log.Printf("🔧 RAPIDAPI_KEY from environment: %s...", rapidAPIKey[:10])
 
+	cfg, _ := config.LoadConfig()
 	extractor := &DataExtractor{
-		youtubeAPI:       NewYouTubeAPI(rapidAPIKey),
-		realTimeNewsAPI:  NewRealTimeNewsAPI(),
-		instagramAPI:     NewInstagramAPI(),
-		indonesiaNewsAPI: NewIndonesiaNewsAPI(),
+		youtubeAPI:            NewYouTubeAPI(rapidAPIKey),
+		realTimeNewsAPI:       NewRealTimeNewsAPI(),
+		instagramAPI:          NewInstagramAPI(),
+		indonesiaNewsAPI:      NewIndonesiaNewsAPI(),
+		health:                SharedSourceHealth(),
+		instagramLocations:    cfg.ExternalAPIs.Instagram.LocationIDs(),
+		instagramHashtags:     cfg.ExternalAPIs.Instagram.Hashtags,
+		instagramMaxPosts:     cfg.ExternalAPIs.Instagram.MaxResults,
+		instagramCommentPosts: cfg.ExternalAPIs.Instagram.CommentPosts,
+		instagramMaxComments:  cfg.ExternalAPIs.Instagram.MaxComments,
+		youtube:               cfg.ExternalAPIs.YouTube,
+		indonesiaNews:         cfg.ExternalAPIs.IndonesiaNews,
+		sourceTimeout:         cfg.ETL.SourceTimeout,
+		maxConcurrent:         cfg.ETL.MaxConcurrentExtractions,
+		quotas:                make(map[string]config.QuotaConfig),
+	}
+	for _, source := range KnownSources {
+		extractor.quotas[source] = cfg.ExternalAPIs.QuotaFor(source)
 	}
 
 	log.Printf("🔧 DataExtractor created successfully")
@@ -44,309 +112,446 @@ func NewDataExtractor() *DataExtractor {
 	return extractor
 }
 
-// ExtractAllSources extracts data from all sources concurrently using goroutines
-func (de *DataExtractor) ExtractAllSources() *ExtractedData {
+// ExtractAllSources extracts data from all sources enabled in the global configuration
+func (de *DataExtractor) ExtractAllSources(ctx context.Context) *ExtractedData {
+	return de.ExtractSources(ctx, DefaultRunSettings())
+}
+
+// ExtractSources extracts data from the sources enabled in settings concurrently,
+// at most ETL_MAX_CONCURRENT_EXTRACTIONS at a time.
+// Sources turned off by SOURCES_ENABLED or the run's source selection, and sources
+// whose circuit breaker is open or whose daily budget is spent, are recorded
+// as skipped without being called, and healthy sources are started first.
+// Sources whose API request quota is used up are recorded as "quota_exceeded"
+// without being called, as are sources that use it up while extracting.
+// Each source is bounded by the ETL source timeout from the moment it starts
+// and the call returns once every source has finished, timed out or panicked.
+// Cancelling ctx aborts the in-flight API calls and the sources still waiting
+// to start; the affected sources are reported as errors but do not count
+// against their circuit breakers.
+func (de *DataExtractor) ExtractSources(ctx context.Context, settings RunSettings) *ExtractedData {
 	log.Println("🚀 Starting data extraction from all sources...")
 	log.Printf("🔧 DataExtractor instance: %v", de != nil)
 	log.Printf("🔧 YouTube API client: %v", de.youtubeAPI != nil)
 
 	extractedData := &ExtractedData{
 		Timestamp: time.Now().Format(time.RFC3339),
-		Query:     "covid19",
+		Query:     settings.Query(),
 		Sources:   make(map[string]interface{}),
 	}
 
-	// Create channels for concurrent extraction
-	youtubeChan := make(chan interface{})
-	googleNewsChan := make(chan interface{})
-	instagramChan := make(chan interface{})
-	indonesiaNewsChan := make(chan interface{})
-
-	log.Println("🔧 Created channels for concurrent extraction")
-	log.Println("🔧 Starting YouTube extraction goroutine...")
-
-	// Extract YouTube data concurrently
-	go func() {
-		// Add panic recovery to catch any crashes
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("🚨 PANIC in YouTube extraction goroutine: %v", r)
-				log.Printf("🚨 Stack trace: %s", debug.Stack())
-				youtubeChan <- map[string]string{"error": fmt.Sprintf("Panic: %v", r)}
+	var runnable []registeredSource
+	for _, source := range registeredSources {
+		if source.extract == nil {
+			continue
+		}
+		status, reason := "skipped", settings.DisabledSources[source.name]
+		if reason == "" {
+			if !settings.SourceEnabled(source.name) {
+				continue
 			}
-		}()
-
-		log.Println("📺 Starting YouTube extraction goroutine...")
-
-		// Check if YouTube API client is initialized
-		if de.youtubeAPI == nil {
-			log.Printf("🚨 YouTube API client is nil!")
-			youtubeChan <- map[string]string{"error": "YouTube API client not initialized"}
-			return
+			reason = de.health.SkipReason(source.name)
+		}
+		if reason == "" {
+			if reason = de.quotaExceededReason(source.name); reason != "" {
+				status = "quota_exceeded"
+			}
+		}
+		if reason != "" {
+			log.Printf("⏭️ Skipping %s: %s", source.name, reason)
+			extractedData.Sources[source.name] = skippedSource(reason)
+			extractedData.Summaries = append(extractedData.Summaries, SourceSummary{
+				Source:   source.name,
+				Status:   status,
+				Duration: "0s",
+				Reason:   reason,
+			})
+			continue
 		}
+		runnable = append(runnable, source)
+	}
+	sort.SliceStable(runnable, func(i, j int) bool {
+		return de.health.ConsecutiveFailures(runnable[i].name) < de.health.ConsecutiveFailures(runnable[j].name)
+	})
+
+	progress := progressFrom(ctx)
+	progress.startExtraction(len(runnable))
+	for _, summary := range extractedData.Summaries {
+		progress.sourceDone(summary)
+	}
 
-		log.Printf("📺 YouTube API client initialized successfully")
-		log.Printf("📺 YouTube API Host: %s", de.youtubeAPI.Host)
-		log.Printf("📺 YouTube API Key (first 10 chars): %s...", de.youtubeAPI.APIKey[:10])
+	results := de.extractConcurrently(ctx, runnable, settings)
+	for _, result := range results {
+		extractedData.Sources[result.name] = result.data
 
-		log.Println("📺 Extracting YouTube data...")
-		data, err := de.ExtractYouTubeData()
-		if err != nil {
-			log.Printf("❌ YouTube extraction failed: %v", err)
-			youtubeChan <- map[string]string{"error": err.Error()}
-		} else {
-			// Check if videos data exists and get length
-			if data.Videos != nil {
-				if videos, ok := data.Videos.([]interface{}); ok {
-					log.Printf("✅ YouTube: %d videos extracted", len(videos))
-				} else {
-					log.Printf("✅ YouTube: data extracted (type: %T)", data.Videos)
-				}
-			} else {
-				log.Printf("✅ YouTube: data extracted")
+		summary := result.summary()
+		if summary.Status == "error" {
+			if reason := de.quotaExceededReason(result.name); reason != "" {
+				summary.Status, summary.Reason = "quota_exceeded", reason
 			}
-			youtubeChan <- data
 		}
-	}()
-
-	// Extract Google News data concurrently
-	go func() {
-		log.Println("📰 Extracting Google News data...")
-		data, err := de.extractGoogleNewsData()
-		if err != nil {
-			log.Printf("❌ Google News extraction failed: %v", err)
-			googleNewsChan <- map[string]string{"error": err.Error()}
-		} else {
-			// Check if articles data exists and get length
-			if data.Articles != nil {
-				if articles, ok := data.Articles.([]interface{}); ok {
-					log.Printf("✅ Google News: %d articles extracted", len(articles))
-				} else {
-					log.Printf("✅ Google News: data extracted (type: %T)", data.Articles)
-				}
-			} else {
-				log.Printf("✅ Google News: data extracted")
+		switch summary.Status {
+		case "error":
+			if ctx.Err() == nil {
+				de.health.RecordFailure(result.name)
 			}
-			googleNewsChan <- data
+		case "quota_exceeded":
+			// A used up quota says nothing about the health of the source
+		default:
+			de.health.RecordSuccess(result.name)
 		}
-	}()
+		extractedData.Summaries = append(extractedData.Summaries, summary)
+		log.Printf("🔧 %s result received", result.name)
+	}
 
-	// Extract Instagram data concurrently
-	go func() {
-		log.Println("📱 Extracting Instagram data...")
-		data, err := de.extractInstagramData()
-		if err != nil {
-			log.Printf("❌ Instagram extraction failed: %v", err)
-			instagramChan <- map[string]string{"error": err.Error()}
-		} else {
-			// Check if posts data exists and get length
-			if data.Posts != nil {
-				if posts, ok := data.Posts.([]interface{}); ok {
-					log.Printf("✅ Instagram: %d posts extracted", len(posts))
+	sort.Slice(extractedData.Summaries, func(i, j int) bool {
+		return extractedData.Summaries[i].Source < extractedData.Summaries[j].Source
+	})
+
+	log.Println("🎉 Data extraction completed!")
+	return extractedData
+}
+
+// extractConcurrently extracts sources on a pool of at most de.maxConcurrent
+// workers, starting them in the given order, and returns their results in
+// the same order. Sources still waiting when ctx is cancelled are reported
+// as cancelled without being called.
+func (de *DataExtractor) extractConcurrently(ctx context.Context, sources []registeredSource, settings RunSettings) []sourceResult {
+	workers := de.maxConcurrent
+	if workers <= 0 || workers > len(sources) {
+		workers = len(sources)
+	}
+	progress := progressFrom(ctx)
+
+	// Each source writes only its own slot, so no result can be lost or
+	// block another source
+	results := make([]sourceResult, len(sources))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				source := sources[i]
+				if err := ctx.Err(); err != nil {
+					results[i] = sourceResult{
+						name: source.name,
+						data: map[string]string{"error": fmt.Sprintf("extraction cancelled before it started: %v", err)},
+					}
 				} else {
-					log.Printf("✅ Instagram: data extracted (type: %T)", data.Posts)
+					log.Printf("🔧 Starting %s extraction...", source.name)
+					de.health.RecordAttempt(source.name)
+					results[i] = de.extractSourceWithTimeout(ctx, source, settings)
 				}
-			} else {
-				log.Printf("✅ Instagram: data extracted")
+				progress.sourceDone(results[i].summary())
 			}
-			instagramChan <- data
-		}
-	}()
+		}()
+	}
+	for i := range sources {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+// sourceResult is the outcome of extracting one source
+type sourceResult struct {
+	name     string
+	data     interface{}
+	count    int
+	duration time.Duration
+}
 
-	// Extract Indonesia News data concurrently
+// summary returns the run summary of an extracted source
+func (result sourceResult) summary() SourceSummary {
+	summary := SourceSummary{
+		Source:      result.name,
+		Status:      "success",
+		RecordCount: result.count,
+		Duration:    result.duration.String(),
+	}
+	if errMap, ok := result.data.(map[string]string); ok && errMap["error"] != "" {
+		summary.Status = "error"
+		summary.Error = errMap["error"]
+	}
+	if news, ok := result.data.(*IndonesiaNewsData); ok {
+		summary.Outlets = news.Outlets
+	}
+	return summary
+}
+
+// extractSourceWithTimeout extracts one source, recovering from its panics.
+// It returns an error result once the source timeout passes or ctx is
+// cancelled, even when the source ignores its context and keeps running.
+func (de *DataExtractor) extractSourceWithTimeout(ctx context.Context, source registeredSource, settings RunSettings) sourceResult {
+	start := time.Now()
+	sourceCtx := ctx
+	if de.sourceTimeout > 0 {
+		var cancel context.CancelFunc
+		sourceCtx, cancel = context.WithTimeout(ctx, de.sourceTimeout)
+		defer cancel()
+	}
+
+	// Buffered so a source finishing after its timeout does not leak blocked
+	done := make(chan sourceResult, 1)
 	go func() {
-		log.Println("🇮🇩 Extracting Indonesia News data...")
-		data, err := de.extractIndonesiaNewsData()
-		if err != nil {
-			log.Printf("❌ Indonesia News extraction failed: %v", err)
-			indonesiaNewsChan <- map[string]string{"error": err.Error()}
-		} else {
-			totalArticles := 0
-			for _, source := range data.Sources {
-				if sourceData, ok := source.(map[string]interface{}); ok {
-					if items, exists := sourceData["items"]; exists {
-						if itemsList, ok := items.([]interface{}); ok {
-							totalArticles += len(itemsList)
-						}
-					}
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("🚨 PANIC in %s extraction goroutine: %v", source.name, r)
+				log.Printf("🚨 Stack trace: %s", debug.Stack())
+				done <- sourceResult{
+					name:     source.name,
+					data:     map[string]string{"error": fmt.Sprintf("Panic: %v", r)},
+					duration: time.Since(start),
 				}
 			}
-			log.Printf("✅ Indonesia News: %d articles extracted", totalArticles)
-			indonesiaNewsChan <- data
-		}
+		}()
+
+		data, count := source.extract(de, sourceCtx, settings)
+		done <- sourceResult{name: source.name, data: data, count: count, duration: time.Since(start)}
 	}()
 
-	// Collect results from all channels
-	log.Println("🔧 Waiting for YouTube channel...")
-	extractedData.Sources["youtube"] = <-youtubeChan
-	log.Println("🔧 YouTube channel received")
+	select {
+	case result := <-done:
+		return result
+	case <-sourceCtx.Done():
+		message := fmt.Sprintf("extraction timed out after %v", de.sourceTimeout)
+		if ctx.Err() != nil {
+			message = fmt.Sprintf("extraction cancelled: %v", ctx.Err())
+		}
+		log.Printf("⏱️ %s: %s", source.name, message)
+		return sourceResult{
+			name:     source.name,
+			data:     map[string]string{"error": message},
+			duration: time.Since(start),
+		}
+	}
+}
 
-	log.Println("🔧 Waiting for Google News channel...")
-	extractedData.Sources["google_news"] = <-googleNewsChan
-	log.Println("🔧 Google News channel received")
+// extractYouTubeSource extracts YouTube data for ExtractSources
+func (de *DataExtractor) extractYouTubeSource(ctx context.Context, query string) (interface{}, int) {
+	log.Println("📺 Starting YouTube extraction goroutine...")
 
-	log.Println("🔧 Waiting for Instagram channel...")
-	extractedData.Sources["instagram"] = <-instagramChan
-	log.Println("🔧 Instagram channel received")
+	// Check if YouTube API client is initialized
+	if de.youtubeAPI == nil {
+		log.Printf("🚨 YouTube API client is nil!")
+		return map[string]string{"error": "YouTube API client not initialized"}, 0
+	}
 
-	log.Println("🔧 Waiting for Indonesia News channel...")
-	extractedData.Sources["indonesia_news"] = <-indonesiaNewsChan
-	log.Println("🔧 Indonesia News channel received")
+	log.Printf("📺 YouTube API client initialized successfully")
+	log.Printf("📺 YouTube API Host: %s", de.youtubeAPI.Host)
+	log.Printf("📺 YouTube API Key (first 10 chars): %s...", de.youtubeAPI.APIKey[:10])
 
-	log.Println("🎉 Data extraction completed!")
-	return extractedData
+	log.Println("📺 Extracting YouTube data...")
+	data, err := de.ExtractYouTubeData(ctx, query)
+	if err != nil {
+		log.Printf("❌ YouTube extraction failed: %v", err)
+		return map[string]string{"error": err.Error()}, 0
+	}
+
+	count := len(data.Videos)
+	log.Printf("✅ YouTube: %d videos extracted", count)
+	return data, count
 }
 
-// ExtractYouTubeData extracts YouTube data with comments for just one video
-func (de *DataExtractor) ExtractYouTubeData() (*YouTubeData, error) {
-	// Try different COVID-19 video IDs to find one that works
-	videoIDs := []string{
-		"B_NwHxJkKqE", // Dr. Fauci on COVID-19: What You Need to Know
-		"qAeJ2wQ0c98", // WHO Director-General's opening remarks at the media briefing on COVID-19
-		"1APwq1df6Mw", // Coronavirus: How to protect yourself
-		"9A6y8Q8TpmE", // COVID-19: What You Need to Know
+// extractGoogleNewsSource extracts Google News data for ExtractSources
+func (de *DataExtractor) extractGoogleNewsSource(ctx context.Context, query string) (interface{}, int) {
+	log.Println("📰 Extracting Google News data...")
+	data, err := de.extractGoogleNewsData(ctx, query)
+	if err != nil {
+		log.Printf("❌ Google News extraction failed: %v", err)
+		return map[string]string{"error": err.Error()}, 0
 	}
 
-	// Try each video ID until we find one that works
-	var videoID string
-	var commentsResult *YouTubeResponse
-	var err error
+	count := len(data.Articles)
+	log.Printf("✅ Google News: %d articles extracted", count)
+	return data, count
+}
 
-	for _, vid := range videoIDs {
-		log.Printf("📺 Trying video ID: %s", vid)
+// extractInstagramSource extracts Instagram data for ExtractSources
+func (de *DataExtractor) extractInstagramSource(ctx context.Context, hashtags []string) (interface{}, int) {
+	log.Println("📱 Extracting Instagram data...")
+	data, err := de.extractInstagramData(ctx, hashtags)
+	if err != nil {
+		log.Printf("❌ Instagram extraction failed: %v", err)
+		return map[string]string{"error": err.Error()}, 0
+	}
 
-		commentsResult, err = de.youtubeAPI.GetVideoComments(vid)
-		if err == nil && commentsResult.Status == "success" && commentsResult.Comments != nil {
-			videoID = vid
-			log.Printf("✅ Successfully found working video ID: %s", videoID)
-			break
-		} else {
-			log.Printf("⚠️ Video ID %s failed: %v", vid, err)
-		}
-
-		// Small delay between attempts
-		time.Sleep(500 * time.Millisecond)
-	}
-
-	if videoID == "" {
-		log.Printf("❌ All video IDs failed, creating mock data for testing")
-
-		// Create mock YouTube data for testing purposes
-		mockVideoID := "mock_covid19_video_001"
-		videoInfo := map[string]interface{}{
-			"title":     "COVID-19: Understanding the Pandemic",
-			"videoId":   mockVideoID,
-			"url":       fmt.Sprintf("https://www.youtube.com/watch?v=%s", mockVideoID),
-			"published": "2020-03-20",
-			"author":    "World Health Organization",
-			"views":     "1,250,000",
-			"duration":  "15:30",
-		}
-
-		// Create mock comments with the structure expected by the transformer
-		mockComments := []interface{}{
-			map[string]interface{}{
-				"comment": map[string]interface{}{
-					"content":           "Very informative video about COVID-19 safety measures",
-					"author":            "HealthExpert2020",
-					"publishedTimeText": "2020-03-21",
-					"commentId":         "mock_comment_001",
-					"stats": map[string]interface{}{
-						"replies": 5,
-						"votes":   45,
-					},
-				},
-				"video": videoInfo,
-			},
-			map[string]interface{}{
-				"comment": map[string]interface{}{
-					"content":           "This helped me understand how to protect my family",
-					"author":            "ConcernedParent",
-					"publishedTimeText": "2020-03-22",
-					"commentId":         "mock_comment_002",
-					"stats": map[string]interface{}{
-						"replies": 3,
-						"votes":   32,
-					},
-				},
-				"video": videoInfo,
-			},
-			map[string]interface{}{
-				"comment": map[string]interface{}{
-					"content":           "Great explanation of social distancing guidelines",
-					"author":            "SafetyFirst",
-					"publishedTimeText": "2020-03-23",
-					"commentId":         "mock_comment_003",
-					"stats": map[string]interface{}{
-						"replies": 2,
-						"votes":   28,
-					},
-				},
-				"video": videoInfo,
-			},
-		}
-
-		log.Printf("✅ Created mock YouTube data with %d comments", len(mockComments))
-
-		return &YouTubeData{
-			Timestamp: time.Now().Format(time.RFC3339),
-			Videos:    mockComments,
-		}, nil
-	}
-
-	log.Printf("📺 Successfully using video ID: %s", videoID)
-
-	// Create video info manually since we're not searching
-	videoInfo := map[string]interface{}{
-		"title":     "Dr. Fauci on COVID-19: What You Need to Know",
-		"videoId":   videoID,
-		"url":       fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
-		"published": "2020-03-20",
-		"author":    "White House",
-		"views":     "N/A", // We'll get this from comments if available
-		"duration":  "N/A",
-	}
-
-	// commentsResult and err are already available from the loop above
+	count := 0
+	for _, hashtag := range data.Hashtags {
+		if hashtag.Error == "" {
+			count += len(hashtag.Posts)
+			log.Printf("✅ Instagram: %d posts extracted for #%s", len(hashtag.Posts), hashtag.Hashtag)
+		}
+	}
+	for _, location := range data.Locations {
+		if location.Error == "" {
+			count += len(location.Posts)
+			log.Printf("✅ Instagram: %d posts extracted at location %s", len(location.Posts), location.LocationID)
+		}
+	}
+	for _, thread := range data.Comments {
+		count += len(thread.Comments)
+	}
+	return data, count
+}
+
+// extractIndonesiaNewsSource extracts Indonesia News data for ExtractSources
+func (de *DataExtractor) extractIndonesiaNewsSource(ctx context.Context, query string) (interface{}, int) {
+	log.Println("🇮🇩 Extracting Indonesia News data...")
+	data, err := de.extractIndonesiaNewsData(ctx, query)
 	if err != nil {
-		log.Printf("⚠️ Failed to fetch comments for video %s: %v", videoID, err)
-		// Return empty data instead of error to avoid breaking the pipeline
-		return &YouTubeData{
-			Timestamp: time.Now().Format(time.RFC3339),
-			Videos:    []interface{}{},
-		}, nil
+		log.Printf("❌ Indonesia News extraction failed: %v", err)
+		return map[string]string{"error": err.Error()}, 0
 	}
 
-	var allComments []interface{}
+	totalArticles := len(data.Sources.Items)
+	log.Printf("✅ Indonesia News: %d articles extracted", totalArticles)
+	return data, totalArticles
+}
 
-	if commentsResult.Status == "success" && commentsResult.Comments != nil {
-		log.Printf("✅ Found %d comments for video %s", len(commentsResult.Comments), videoID)
+// maxYouTubeSearchPages bounds the search result pages read to find
+// YOUTUBE_MAX_RESULTS videos, since every page costs quota
+const maxYouTubeSearchPages = 5
+
+// ExtractYouTubeData discovers current videos matching query with the
+// search API, ranks them by views per day since publication and extracts
+// the comments of the top-ranked ones with the metadata of their video.
+// A video whose comments cannot be fetched is skipped; the source fails only
+// when the search fails or no video's comments could be fetched.
+func (de *DataExtractor) ExtractYouTubeData(ctx context.Context, query string) (*YouTubeData, error) {
+	videos, err := de.searchYouTubeVideos(ctx, query)
+	if err != nil {
+		return nil, err
+	}
 
-		// Add video metadata to each comment
-		for _, comment := range commentsResult.Comments {
-			if commentMap, ok := comment.(map[string]interface{}); ok {
-				commentWithVideo := map[string]interface{}{
-					"comment": commentMap,
-					"video":   videoInfo,
-				}
-				allComments = append(allComments, commentWithVideo)
+	top := rankYouTubeVideos(videos, time.Now())
+	if len(top) > de.youtube.CommentVideos {
+		top = top[:de.youtube.CommentVideos]
+	}
+	log.Printf("📺 Found %d videos for %q, extracting the comments of %d", len(videos), query, len(top))
+
+	entries := []YouTubeEntry{}
+	var lastErr error
+	failed := 0
+	for _, video := range top {
+		comments, err := de.videoComments(ctx, video.VideoID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
 			}
+			log.Printf("⚠️ Failed to fetch comments for video %s: %v", video.VideoID, err)
+			lastErr = err
+			failed++
+			continue
 		}
-	} else {
-		log.Printf("⚠️ No comments found or API error: %s", commentsResult.Error)
+
+		info := youTubeVideoInfo(video)
+		for i := range comments {
+			entries = append(entries, YouTubeEntry{Comment: &comments[i], Video: info})
+		}
+		log.Printf("✅ Found %d comments for video %s", len(comments), video.VideoID)
+	}
+	if failed > 0 && failed == len(top) {
+		return nil, fmt.Errorf("failed to fetch the comments of all %d videos: %w", failed, lastErr)
 	}
 
-	log.Printf("🎯 YouTube extraction complete: %d comments from 1 video", len(allComments))
+	log.Printf("🎯 YouTube extraction complete: %d comments from %d videos", len(entries), len(top)-failed)
 
 	return &YouTubeData{
 		Timestamp: time.Now().Format(time.RFC3339),
-		Videos:    allComments, // Contains comments with video metadata
+		Videos:    entries, // Contains comments with video metadata
 	}, nil
 }
 
-// extractGoogleNewsData extracts Real-Time News data
-func (de *DataExtractor) extractGoogleNewsData() (*NewsData, error) {
-	searchResult, err := de.realTimeNewsAPI.SearchNews("COVID-19", "ID", "id", 10, "anytime")
+// videoComments returns up to YOUTUBE_MAX_COMMENTS distinct comments of a
+// video, following the comment cursor page by page. A failing first page
+// fails the video; a failing later page ends it with the comments read so far.
+func (de *DataExtractor) videoComments(ctx context.Context, videoID string) ([]YouTubeComment, error) {
+	var comments []YouTubeComment
+	seen := make(map[string]bool)
+	cursor := ""
+	for page := 0; len(comments) < de.youtube.MaxComments; page++ {
+		result, err := de.youtubeAPI.GetVideoComments(ctx, videoID, cursor)
+		if err == nil && result.Status != "success" {
+			err = fmt.Errorf("%s", result.Error)
+		}
+		if err != nil {
+			if page == 0 || ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("⚠️ Comment page %d of video %s failed: %v", page+1, videoID, err)
+			break
+		}
+
+		added := 0
+		for _, comment := range result.Comments {
+			if comment.CommentID != "" && seen[comment.CommentID] {
+				continue
+			}
+			seen[comment.CommentID] = true
+			comments = append(comments, comment)
+			added++
+			if len(comments) == de.youtube.MaxComments {
+				break
+			}
+		}
+
+		// A page without new comments would make the cursor loop
+		cursor = result.CursorNext
+		if cursor == "" || added == 0 {
+			break
+		}
+	}
+	return comments, nil
+}
+
+// searchYouTubeVideos returns up to YOUTUBE_MAX_RESULTS distinct videos
+// matching query, following the search cursor page by page. A failing
+// first page fails the search; a failing later page ends it with the
+// videos found so far.
+func (de *DataExtractor) searchYouTubeVideos(ctx context.Context, query string) ([]YouTubeSearchVideo, error) {
+	var videos []YouTubeSearchVideo
+	seen := make(map[string]bool)
+	cursor := ""
+	for page := 0; page < maxYouTubeSearchPages && len(videos) < de.youtube.MaxResults; page++ {
+		result, err := de.youtubeAPI.SearchVideos(ctx, query, de.youtube.Language, de.youtube.Geo, cursor)
+		if err == nil && result.Status != "success" {
+			err = fmt.Errorf("YouTube API returned error: %s", result.Error)
+		}
+		if err != nil {
+			if page == 0 || ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to search videos: %w", err)
+			}
+			log.Printf("⚠️ YouTube search page %d failed: %v", page+1, err)
+			break
+		}
+
+		for _, item := range result.Contents {
+			if item.Type != "video" || item.Video == nil || item.Video.VideoID == "" || seen[item.Video.VideoID] {
+				continue
+			}
+			seen[item.Video.VideoID] = true
+			videos = append(videos, *item.Video)
+			if len(videos) == de.youtube.MaxResults {
+				break
+			}
+		}
+
+		cursor = result.CursorNext
+		if cursor == "" {
+			break
+		}
+	}
+	return videos, nil
+}
+
+// extractGoogleNewsData extracts Real-Time News data matching query
+func (de *DataExtractor) extractGoogleNewsData(ctx context.Context, query string) (*NewsData, error) {
+	searchResult, err := de.realTimeNewsAPI.SearchNews(ctx, query, "ID", "id", 10, "anytime")
 	if err != nil {
 		return nil, fmt.Errorf("failed to search news: %w", err)
 	}
@@ -362,123 +567,336 @@ func (de *DataExtractor) extractGoogleNewsData() (*NewsData, error) {
 	}, nil
 }
 
-// extractInstagramData extracts Instagram data
-func (de *DataExtractor) extractInstagramData() (*InstagramData, error) {
-	hashtagResult, err := de.instagramAPI.GetHashtagMedia("covid19", "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get hashtag media: %w", err)
+// extractInstagramData extracts the recent posts of each hashtag and of
+// each configured location. A failing hashtag is recorded with its error;
+// the source fails only when every hashtag fails.
+func (de *DataExtractor) extractInstagramData(ctx context.Context, hashtags []string) (*InstagramData, error) {
+	data := &InstagramData{Timestamp: time.Now().Format(time.RFC3339)}
+	var lastErr error
+	for _, name := range hashtags {
+		hashtag := InstagramHashtagData{Hashtag: name}
+		posts, err := de.hashtagPosts(ctx, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Warning: Failed to extract Instagram hashtag #%s: %v", name, err)
+			hashtag.Error = err.Error()
+			lastErr = err
+		}
+		hashtag.Posts = posts
+		data.Hashtags = append(data.Hashtags, hashtag)
 	}
-
-	if hashtagResult.Status != "success" {
-		return nil, fmt.Errorf("Instagram API returned error: %s", hashtagResult.Error)
+	if lastErr != nil && !data.extractedAnyHashtag() {
+		return nil, fmt.Errorf("failed to get hashtag media: %w", lastErr)
 	}
 
-	return &InstagramData{
-		Timestamp: time.Now().Format(time.RFC3339),
-		Posts:     hashtagResult.Posts, // Use Posts instead of Data
-	}, nil
+	data.Locations = de.extractInstagramLocations(ctx)
+	data.Comments = de.extractInstagramComments(ctx, data)
+	return data, nil
 }
 
-// extractIndonesiaNewsData extracts Indonesia News data
-func (de *DataExtractor) extractIndonesiaNewsData() (*IndonesiaNewsData, error) {
-	sources := []string{"kompas", "detik", "cnn"} // Removed tempo
-	sourceData := make(map[string]interface{})
+// extractInstagramComments extracts the comments of the posts of data with
+// the most likes and comments. A post whose comments cannot be fetched is
+// recorded with its error and does not fail the source.
+func (de *DataExtractor) extractInstagramComments(ctx context.Context, data *InstagramData) []InstagramPostComments {
+	var threads []InstagramPostComments
+	for _, thread := range mostEngagingPosts(data, de.instagramCommentPosts) {
+		result, err := de.instagramAPI.GetMediaComments(ctx, thread.Post.PK.String(), de.instagramMaxComments)
+		switch {
+		case err != nil:
+			thread.Error = err.Error()
+		case result.Status != "success":
+			thread.Error = result.Error
+		default:
+			thread.Comments = result.Comments
+			if len(thread.Comments) > de.instagramMaxComments {
+				thread.Comments = thread.Comments[:de.instagramMaxComments]
+			}
+		}
+		if thread.Error != "" {
+			log.Printf("Warning: Failed to extract the comments of Instagram post %s: %s", thread.Post.Code, thread.Error)
+		}
 
-	for i, source := range sources {
-		log.Printf("🔍 Extracting from source: %s", source)
+		threads = append(threads, thread)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return threads
+}
 
-		// Add delay between requests to avoid rate limiting
-		if i > 0 {
-			time.Sleep(5 * time.Second) // 5 second delay between sources to avoid rate limiting
+// mostEngagingPosts returns up to n distinct commented posts of data with
+// the most likes and comments, each with the hashtag it was found under
+func mostEngagingPosts(data *InstagramData, n int) []InstagramPostComments {
+	var candidates []InstagramPostComments
+	seen := make(map[string]bool)
+	add := func(posts []InstagramPost, hashtag string) {
+		for _, post := range posts {
+			if post.PK == "" || post.CommentCount == 0 || seen[post.PK.String()] {
+				continue
+			}
+			seen[post.PK.String()] = true
+			candidates = append(candidates, InstagramPostComments{Post: post, Hashtag: hashtag})
 		}
+	}
+	for _, hashtag := range data.Hashtags {
+		add(hashtag.Posts, hashtag.Hashtag)
+	}
+	for _, location := range data.Locations {
+		add(location.Posts, "")
+	}
 
-		searchResult, err := de.indonesiaNewsAPI.SearchNews(source, "COVID-19", nil)
+	engagement := func(post InstagramPost) int { return post.LikeCount + post.CommentCount }
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return engagement(candidates[i].Post) > engagement(candidates[j].Post)
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// hashtagPosts returns up to INSTAGRAM_MAX_RESULTS distinct recent posts of
+// a hashtag, following the max_id cursor chunk by chunk. A failing first
+// chunk fails the hashtag; a failing later chunk ends it with the posts read
+// so far.
+func (de *DataExtractor) hashtagPosts(ctx context.Context, hashtag string) ([]InstagramPost, error) {
+	var posts []InstagramPost
+	seen := make(map[string]bool)
+	maxID := ""
+	for page := 0; len(posts) < de.instagramMaxPosts; page++ {
+		result, err := de.instagramAPI.GetHashtagMedia(ctx, hashtag, maxID)
+		if err == nil && result.Status != "success" {
+			err = fmt.Errorf("Instagram API returned error: %s", result.Error)
+		}
 		if err != nil {
-			log.Printf("Warning: Failed to extract %s news: %v", source, err)
-			sourceData[source] = map[string]string{"error": err.Error()}
-			continue
+			if page == 0 || ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("⚠️ Instagram #%s chunk %d failed: %v", hashtag, page+1, err)
+			break
+		}
+
+		added := 0
+		for _, post := range result.Posts {
+			if post.Code != "" && seen[post.Code] {
+				continue
+			}
+			seen[post.Code] = true
+			posts = append(posts, post)
+			added++
+			if len(posts) == de.instagramMaxPosts {
+				break
+			}
 		}
 
-		log.Printf("📊 %s API response - Status: %s, Items: %d, Error: %s",
-			source, searchResult.Status, len(searchResult.Items), searchResult.Error)
+		// A chunk without new posts would make the cursor loop
+		maxID = result.Cursor
+		if maxID == "" || added == 0 {
+			break
+		}
+	}
+	return posts, nil
+}
 
-		if searchResult.Status == "success" {
-			// Use the new Items field instead of Data
-			if len(searchResult.Items) > 0 {
-				sourceData[source] = map[string]interface{}{
-					"items":    searchResult.Items,
-					"metadata": searchResult.Metadata,
-					"count":    len(searchResult.Items),
-				}
-				log.Printf("✅ %s: Successfully extracted %d items", source, len(searchResult.Items))
-			} else {
-				sourceData[source] = map[string]string{"error": searchResult.Error}
-				log.Printf("⚠️ %s: No items found, error: %s", source, searchResult.Error)
+// extractInstagramLocations extracts the recent posts of each configured
+// location. A failing location is recorded with its error and does not fail
+// the source.
+func (de *DataExtractor) extractInstagramLocations(ctx context.Context) []InstagramLocationData {
+	var locations []InstagramLocationData
+	for _, locationID := range de.instagramLocations {
+		location := InstagramLocationData{LocationID: locationID}
+
+		result, err := de.instagramAPI.GetLocationMedia(ctx, locationID, "")
+		switch {
+		case err != nil:
+			location.Error = err.Error()
+		case result.Status != "success":
+			location.Error = result.Error
+		default:
+			location.Posts = result.Posts
+		}
+		if location.Error != "" {
+			log.Printf("Warning: Failed to extract Instagram location %s: %s", locationID, location.Error)
+		}
+
+		locations = append(locations, location)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return locations
+}
+
+// extractIndonesiaNewsData extracts the items matching query from each
+// configured outlet, reading up to its configured number of pages. A failing
+// outlet is recorded in the outlet stats; the source fails only when every
+// outlet fails. The most relevant items then get their full article body.
+func (de *DataExtractor) extractIndonesiaNewsData(ctx context.Context, query string) (*IndonesiaNewsData, error) {
+	// Flatten the items of all outlets into one list for easier transformation
+	var flattened IndonesiaNewsSources
+	var itemOutlets []string // outlet of each flattened item
+	var outlets []OutletStats
+	var lastErr error
+
+	// Requests are spaced by the Indonesia News rate limiter
+	for _, outlet := range de.indonesiaNews.Sources {
+		log.Printf("🔍 Extracting from source: %s", outlet)
+		stats := OutletStats{Outlet: outlet}
+
+		for page := 1; page <= de.indonesiaNews.PagesFor(outlet) && stats.Items < de.indonesiaNews.MaxResults; page++ {
+			params := map[string]interface{}{"page": page}
+			if limit := de.indonesiaNews.SourceLimits[outlet]; limit > 0 {
+				params["limit"] = limit
 			}
-		} else {
-			sourceData[source] = map[string]string{"error": searchResult.Error}
-			log.Printf("❌ %s: API returned error status: %s", source, searchResult.Error)
-		}
-	}
-
-	// Create the final data structure - flatten all items into one array
-	var allItems []interface{}
-	var allMetadata []interface{}
-
-	log.Printf("🔄 Flattening data from %d sources", len(sources))
-	for _, source := range sources {
-		log.Printf("🔍 Processing source: %s", source)
-		if sourceDataItem, ok := sourceData[source]; ok {
-			log.Printf("📊 Source %s data type: %T", source, sourceDataItem)
-
-			// Handle both map[string]interface{} and map[string]string
-			var sourceMap map[string]interface{}
-			if sourceMapInterface, ok := sourceDataItem.(map[string]interface{}); ok {
-				sourceMap = sourceMapInterface
-			} else if sourceMapString, ok := sourceDataItem.(map[string]string); ok {
-				// Convert map[string]string to map[string]interface{}
-				sourceMap = make(map[string]interface{})
-				for k, v := range sourceMapString {
-					sourceMap[k] = v
+
+			searchResult, err := de.indonesiaNewsAPI.SearchNews(ctx, outlet, query, params)
+			if err == nil && searchResult.Status != "success" {
+				err = fmt.Errorf("API returned error status: %s", searchResult.Error)
+			}
+			if err != nil {
+				if page == 1 {
+					stats.Error = err.Error()
+					lastErr = err
 				}
-			} else {
-				log.Printf("⚠️ Source %s: sourceDataItem is not map[string]interface{} or map[string]string, got %T", source, sourceDataItem)
-				continue
+				log.Printf("❌ %s page %d: %v", outlet, page, err)
+				break
 			}
 
-			if items, ok := sourceMap["items"]; ok {
-				if itemsList, ok := items.([]interface{}); ok {
-					log.Printf("✅ Source %s: Adding %d items to flattened data", source, len(itemsList))
-					allItems = append(allItems, itemsList...)
-				} else {
-					log.Printf("⚠️ Source %s: items is not []interface{}, got %T", source, items)
-				}
-			} else {
-				log.Printf("⚠️ Source %s: No 'items' key found", source)
+			items := searchResult.Items
+			if remaining := de.indonesiaNews.MaxResults - stats.Items; len(items) > remaining {
+				items = items[:remaining]
+			}
+			stats.Pages++
+			stats.Items += len(items)
+			flattened.Items = append(flattened.Items, items...)
+			for range items {
+				itemOutlets = append(itemOutlets, outlet)
+			}
+			if searchResult.Metadata != nil {
+				flattened.Metadata = append(flattened.Metadata, searchResult.Metadata)
 			}
-			if metadata, ok := sourceMap["metadata"]; ok {
-				allMetadata = append(allMetadata, metadata)
+			if len(searchResult.Items) == 0 {
+				break
 			}
-		} else {
-			log.Printf("⚠️ Source %s: No data found", source)
+		}
+
+		if stats.Error == "" {
+			log.Printf("✅ %s: Successfully extracted %d items from %d pages", outlet, stats.Items, stats.Pages)
+		}
+		outlets = append(outlets, stats)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
 	}
 
-	log.Printf("📊 Flattening complete: %d total items, %d metadata", len(allItems), len(allMetadata))
+	if lastErr != nil && !anyOutletExtracted(outlets) {
+		return nil, fmt.Errorf("failed to extract any outlet: %w", lastErr)
+	}
 
-	// Create flattened structure for easier transformation
-	flattenedData := map[string]interface{}{
-		"items":    allItems,
-		"metadata": allMetadata,
-		"count":    len(allItems),
+	bodies := de.fetchArticleBodies(ctx, flattened.Items, itemOutlets)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
+	for i := range outlets {
+		outlets[i].Bodies = bodies[outlets[i].Outlet]
+	}
+
+	flattened.Count = len(flattened.Items)
+	log.Printf("📊 Flattening complete: %d total items, %d metadata", len(flattened.Items), len(flattened.Metadata))
 
 	return &IndonesiaNewsData{
 		Timestamp: time.Now().Format(time.RFC3339),
-		Sources:   flattenedData,
+		Sources:   flattened,
+		Outlets:   outlets,
 	}, nil
 }
 
+// anyOutletExtracted reports whether any outlet was extracted without error
+func anyOutletExtracted(outlets []OutletStats) bool {
+	for _, outlet := range outlets {
+		if outlet.Error == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchArticleBodies replaces the search snippet of the INDONESIA_NEWS_DETAIL_ITEMS
+// most COVID-relevant items with their full article body from GetNewsDetail.
+// outlets holds the outlet of each item. An item whose detail cannot be
+// fetched keeps its snippet. It returns the bodies fetched per outlet.
+func (de *DataExtractor) fetchArticleBodies(ctx context.Context, items []NewsArticle, outlets []string) map[string]int {
+	bodies := make(map[string]int)
+	fetched := 0
+	// Detail requests are spaced by the Indonesia News rate limiter
+	for _, i := range mostRelevantItems(items, outlets, de.indonesiaNews.DetailItems) {
+		detail, err := de.indonesiaNewsAPI.GetNewsDetail(ctx, outlets[i], newsDetailID(outlets[i], items[i]))
+		if err == nil && detail.Status != "success" {
+			err = fmt.Errorf("API returned error status: %s", detail.Error)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("Warning: Failed to fetch the body of %s article %q: %v", outlets[i], items[i].Title, err)
+			continue
+		}
+
+		for _, article := range detail.Items {
+			if body := cleanHTMLText(article.Content); body != "" {
+				items[i].Content = body
+				bodies[outlets[i]]++
+				fetched++
+				break
+			}
+		}
+	}
+	if fetched > 0 {
+		log.Printf("📄 Fetched the full bodies of %d articles", fetched)
+	}
+	return bodies
+}
+
+// mostRelevantItems returns the indexes of up to n COVID-relevant items that
+// can be looked up with GetNewsDetail, most relevant first
+func mostRelevantItems(items []NewsArticle, outlets []string, n int) []int {
+	scorer := services.NewRelevanceScorer()
+	scores := make(map[int]float64)
+	var candidates []int
+	for i, item := range items {
+		if newsDetailID(outlets[i], item) == "" {
+			continue
+		}
+		score := scorer.Score(item.Title + " " + item.Summary + " " + item.Description + " " + item.Snippet)
+		if score > 0 {
+			scores[i] = score
+			candidates = append(candidates, i)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i]] > scores[candidates[j]]
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// newsDetailID returns what GetNewsDetail looks an outlet's item up by:
+// its GUID on kompas and its URL on the other outlets
+func newsDetailID(outlet string, item NewsArticle) string {
+	if outlet == "kompas" {
+		return item.GUID
+	}
+	if item.URL != "" {
+		return item.URL
+	}
+	return item.Link
+}
+
 // ToJSON converts the extracted data to JSON
 func (ed *ExtractedData) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(ed, "", "  ")
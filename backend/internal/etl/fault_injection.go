@@ -0,0 +1,141 @@
+package etl
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"covid19-kms/internal/config"
+)
+
+// newExtractorClient creates the HTTP client of a source's API client. Its
+// requests wait for the rate limiter of their API host, and go through the
+// fault injector when fault injection is enabled for the source. Requests
+// reaching the API are counted against the source's quota and refused once
+// it is used up. With HTTP_FIXTURES_MODE their responses are recorded to, or
+// replayed from, fixture files.
+func newExtractorClient(source string, timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+
+	cfg, _ := config.LoadConfig()
+	var transport http.RoundTripper = &quotaTransport{next: http.DefaultTransport, quota: cfg.ExternalAPIs.QuotaFor(source), source: source}
+
+	fixtures := cfg.ETL.Fixtures
+	if fixtures.Mode == FixturesRecord || fixtures.Mode == FixturesReplay {
+		if cfg.IsProduction() {
+			log.Printf("⚠️ Ignoring HTTP_FIXTURES_MODE=%s for %s in production", fixtures.Mode, source)
+			fixtures.Mode = FixturesOff
+		} else if fixtures.Mode == FixturesRecord {
+			log.Printf("📼 Recording %s responses to %s", source, fixtures.Dir)
+			transport = newRecordingTransport(transport, fixtures.Dir, source)
+		} else {
+			log.Printf("📼 Replaying %s responses from %s", source, fixtures.Dir)
+			transport = newReplayTransport(fixtures.Dir, source)
+		}
+	}
+
+	faults := cfg.ETL.FaultInjection
+	if faults.Enabled && faultInjectionApplies(faults, source) {
+		if cfg.IsProduction() {
+			log.Printf("⚠️ Ignoring FAULT_INJECTION_ENABLED for %s in production", source)
+		} else {
+			log.Printf("💥 Fault injection enabled for %s: 429 %.0f%%, 500 %.0f%%, timeout %.0f%%",
+				source, faults.RateLimitRate*100, faults.ServerErrorRate*100, faults.TimeoutRate*100)
+			transport = newFaultInjectingTransport(transport, faults, source, time.Now().UnixNano())
+		}
+	}
+
+	// Replayed requests use no API quota
+	if limit := cfg.ExternalAPIs.RateLimitFor(source); limit.RequestsPerSecond > 0 && fixtures.Mode != FixturesReplay {
+		transport = &rateLimitedTransport{next: transport, limit: limit, source: source}
+	}
+
+	client.Transport = transport
+	return client
+}
+
+// faultInjectionApplies reports whether faults are injected into a source
+func faultInjectionApplies(faults config.FaultInjectionConfig, source string) bool {
+	if len(faults.Sources) == 0 {
+		return true
+	}
+	for _, name := range faults.Sources {
+		if name == source {
+			return true
+		}
+	}
+	return false
+}
+
+// faultInjectingTransport fails a configurable share of requests before they
+// reach the API: with 429 Too Many Requests, 500 Internal Server Error, or a
+// timeout that hangs until the request deadline or TimeoutAfter
+type faultInjectingTransport struct {
+	next   http.RoundTripper
+	faults config.FaultInjectionConfig
+	source string
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newFaultInjectingTransport wraps next; seed makes the injected faults reproducible
+func newFaultInjectingTransport(next http.RoundTripper, faults config.FaultInjectionConfig, source string, seed int64) *faultInjectingTransport {
+	return &faultInjectingTransport{
+		next:   next,
+		faults: faults,
+		source: source,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// RoundTrip injects a fault or passes the request on
+func (t *faultInjectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	draw := t.rng.Float64()
+	t.mu.Unlock()
+
+	switch {
+	case draw < t.faults.RateLimitRate:
+		log.Printf("💥 Injected 429 for %s %s", t.source, req.URL.Path)
+		resp := injectedResponse(req, http.StatusTooManyRequests)
+		resp.Header.Set("Retry-After", "1")
+		return resp, nil
+	case draw < t.faults.RateLimitRate+t.faults.ServerErrorRate:
+		log.Printf("💥 Injected 500 for %s %s", t.source, req.URL.Path)
+		return injectedResponse(req, http.StatusInternalServerError), nil
+	case draw < t.faults.RateLimitRate+t.faults.ServerErrorRate+t.faults.TimeoutRate:
+		log.Printf("💥 Injected timeout for %s %s", t.source, req.URL.Path)
+		timer := time.NewTimer(t.faults.TimeoutAfter)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+			return nil, fmt.Errorf("injected timeout after %v", t.faults.TimeoutAfter)
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// injectedResponse builds a synthetic error response to req
+func injectedResponse(req *http.Request, status int) *http.Response {
+	body := fmt.Sprintf(`{"message": "fault injected by covid19-kms: %s"}`, http.StatusText(status))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
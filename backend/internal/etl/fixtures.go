@@ -0,0 +1,174 @@
+package etl
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// What extractor clients do with fixture files, set with HTTP_FIXTURES_MODE
+const (
+	FixturesOff    = "off"
+	FixturesRecord = "record" // store every API response in a fixture file
+	FixturesReplay = "replay" // answer requests from the fixture files without calling the APIs
+)
+
+// httpFixture is a recorded API response. The request headers, and with
+// them the RapidAPI key, are not recorded.
+type httpFixture struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"` // path and query, without the host
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"` // JSON bodies, kept readable
+	Text        string          `json:"text,omitempty"` // any other body
+}
+
+// fixturePath returns the fixture file of a source's request: named after
+// the request path, with a hash of the method, path and sorted query so that
+// the same request always maps to the same file
+func fixturePath(dir, source string, req *http.Request) string {
+	key := req.Method + " " + req.URL.Path + "?" + req.URL.Query().Encode()
+	sum := sha1.Sum([]byte(key))
+
+	name := strings.Trim(req.URL.Path, "/")
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	return filepath.Join(dir, source, fmt.Sprintf("%s-%s.json", name, hex.EncodeToString(sum[:6])))
+}
+
+// fixtureURL returns the path and query of a request as recorded
+func fixtureURL(req *http.Request) string {
+	if req.URL.RawQuery == "" {
+		return req.URL.Path
+	}
+	return req.URL.Path + "?" + req.URL.Query().Encode()
+}
+
+// recordingTransport passes requests on and stores each response in the
+// fixture file of its request, overwriting earlier recordings
+type recordingTransport struct {
+	next   http.RoundTripper
+	dir    string
+	source string
+
+	mu sync.Mutex
+}
+
+// newRecordingTransport records the responses of a source's requests under dir
+func newRecordingTransport(next http.RoundTripper, dir, source string) *recordingTransport {
+	return &recordingTransport{next: next, dir: dir, source: source}
+}
+
+// RoundTrip sends the request and records its response. A response that
+// cannot be recorded is logged and still returned.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := httpFixture{
+		Method:      req.Method,
+		URL:         fixtureURL(req),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if json.Valid(body) {
+		fixture.Body = body
+	} else {
+		fixture.Text = string(body)
+	}
+
+	path := fixturePath(t.dir, t.source, req)
+	if err := t.write(path, fixture); err != nil {
+		log.Printf("⚠️ Failed to record %s fixture %s: %v", t.source, path, err)
+	} else {
+		log.Printf("📼 Recorded %s %s to %s", t.source, req.URL.Path, path)
+	}
+	return resp, nil
+}
+
+// write stores a fixture as indented JSON
+func (t *recordingTransport) write(path string, fixture httpFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// replayTransport answers requests from the fixture files of a source and
+// never calls the API. A request without a fixture fails.
+type replayTransport struct {
+	dir    string
+	source string
+}
+
+// newReplayTransport replays the fixtures of a source recorded under dir
+func newReplayTransport(dir, source string) *replayTransport {
+	return &replayTransport{dir: dir, source: source}
+}
+
+// RoundTrip returns the recorded response of the request
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	path := fixturePath(t.dir, t.source, req)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no %s fixture for %s %s: %v", t.source, req.Method, fixtureURL(req), err)
+	}
+	var fixture httpFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid %s fixture %s: %v", t.source, path, err)
+	}
+
+	body := fixture.Text
+	if len(fixture.Body) > 0 {
+		body = string(fixture.Body)
+	}
+	header := http.Header{}
+	if fixture.ContentType != "" {
+		header.Set("Content-Type", fixture.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -1,7 +1,7 @@
 package etl
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -18,20 +18,20 @@ type RealTimeNewsAPI struct {
 
 // RealTimeNewsResponse represents the API response structure
 type RealTimeNewsResponse struct {
-	Status    string      `json:"status"`
-	RequestID string      `json:"request_id"`
-	Data      interface{} `json:"data,omitempty"`
-	Error     interface{} `json:"error,omitempty"` // Can be string or object
-	Query     string      `json:"query,omitempty"`
-	Country   string      `json:"country,omitempty"`
-	Lang      string      `json:"lang,omitempty"`
-	Limit     int         `json:"limit,omitempty"`
+	Status    string        `json:"status"`
+	RequestID string        `json:"request_id"`
+	Data      []NewsArticle `json:"data,omitempty"`
+	Error     interface{}   `json:"error,omitempty"` // Can be string or object
+	Query     string        `json:"query,omitempty"`
+	Country   string        `json:"country,omitempty"`
+	Lang      string        `json:"lang,omitempty"`
+	Limit     int           `json:"limit,omitempty"`
 }
 
 // NewsData represents the extracted news data
 type NewsData struct {
-	Timestamp string      `json:"timestamp"`
-	Articles  interface{} `json:"articles"`
+	Timestamp string        `json:"timestamp"`
+	Articles  []NewsArticle `json:"articles"`
 }
 
 // NewRealTimeNewsAPI creates a new Real-Time News Data API client
@@ -44,14 +44,12 @@ func NewRealTimeNewsAPI() *RealTimeNewsAPI {
 	return &RealTimeNewsAPI{
 		APIKey: apiKey,
 		Host:   "real-time-news-data.p.rapidapi.com",
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		Client: newExtractorClient("google_news", 30*time.Second),
 	}
 }
 
 // SearchNews searches for news articles with the given parameters
-func (rt *RealTimeNewsAPI) SearchNews(query, country, lang string, limit int, timePublished string) (*RealTimeNewsResponse, error) {
+func (rt *RealTimeNewsAPI) SearchNews(ctx context.Context, query, country, lang string, limit int, timePublished string) (*RealTimeNewsResponse, error) {
 	// Build query parameters
 	params := url.Values{}
 	params.Set("query", query)
@@ -72,7 +70,7 @@ func (rt *RealTimeNewsAPI) SearchNews(query, country, lang string, limit int, ti
 	}
 
 	// Create request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s/search?%s", rt.Host, params.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/search?%s", rt.Host, params.Encode()), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -90,7 +88,7 @@ func (rt *RealTimeNewsAPI) SearchNews(query, country, lang string, limit int, ti
 
 	// Parse response
 	var result RealTimeNewsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := decodeJSON("google_news", resp.Body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
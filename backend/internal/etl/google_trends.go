@@ -0,0 +1,189 @@
+package etl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// GoogleTrendsAPI fetches the interest over time of the configured terms
+// from the Google Trends web API: the explore request returns a token for
+// the time series widget, which the multiline request then reads
+type GoogleTrendsAPI struct {
+	Config config.GoogleTrendsConfig
+	Client *http.Client
+}
+
+// NewGoogleTrendsAPI creates a new Google Trends client. Google Trends
+// answers the first request of a session with 429 and a session cookie, so
+// the client keeps cookies.
+func NewGoogleTrendsAPI(cfg config.GoogleTrendsConfig) *GoogleTrendsAPI {
+	client := newExtractorClient("google_trends", cfg.Timeout)
+	client.Jar, _ = cookiejar.New(nil)
+	return &GoogleTrendsAPI{Config: cfg, Client: client}
+}
+
+// trendsWidget is a widget of an explore response
+type trendsWidget struct {
+	ID      string          `json:"id"`
+	Token   string          `json:"token"`
+	Request json.RawMessage `json:"request"`
+}
+
+// FetchInterest returns the daily interest of the configured terms, compared
+// with each other in the configured geo
+func (g *GoogleTrendsAPI) FetchInterest(ctx context.Context) ([]database.SearchInterest, error) {
+	type comparisonItem struct {
+		Keyword string `json:"keyword"`
+		Geo     string `json:"geo"`
+		Time    string `json:"time"`
+	}
+	explore := struct {
+		ComparisonItem []comparisonItem `json:"comparisonItem"`
+		Category       int              `json:"category"`
+		Property       string           `json:"property"`
+	}{}
+	for _, term := range g.Config.Terms {
+		explore.ComparisonItem = append(explore.ComparisonItem, comparisonItem{Keyword: term, Geo: g.Config.Geo, Time: g.Config.Timeframe})
+	}
+	exploreRequest, err := json.Marshal(explore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode explore request: %w", err)
+	}
+
+	var widgets struct {
+		Widgets []trendsWidget `json:"widgets"`
+	}
+	if err := g.get(ctx, "/explore", url.Values{"req": {string(exploreRequest)}}, &widgets); err != nil {
+		return nil, fmt.Errorf("failed to explore terms: %w", err)
+	}
+
+	for _, widget := range widgets.Widgets {
+		if widget.ID != "TIMESERIES" {
+			continue
+		}
+		body, err := g.getBody(ctx, "/widgetdata/multiline", url.Values{"req": {string(widget.Request)}, "token": {widget.Token}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch interest over time: %w", err)
+		}
+		return parseTrendsTimeline(bytes.NewReader(body), g.Config.Terms, g.Config.Geo)
+	}
+	return nil, fmt.Errorf("explore response has no time series widget")
+}
+
+// get requests a Google Trends API path and decodes its JSON response
+func (g *GoogleTrendsAPI) get(ctx context.Context, path string, params url.Values, result interface{}) error {
+	body, err := g.getBody(ctx, path, params)
+	if err != nil {
+		return err
+	}
+	return decodeTrendsJSON(bytes.NewReader(body), result)
+}
+
+// getBody requests a Google Trends API path in UTC days, retrying once when
+// the first request only set the session cookie
+func (g *GoogleTrendsAPI) getBody(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	params.Set("hl", "id")
+	params.Set("tz", "0")
+	endpoint := g.Config.URL + path + "?" + params.Encode()
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := g.Client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make request: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		}
+		return body, nil
+	}
+}
+
+// decodeTrendsJSON decodes a Google Trends response, which starts with the
+// ")]}'" anti-hijacking prefix
+func decodeTrendsJSON(r io.Reader, result interface{}) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	start := bytes.IndexByte(body, '{')
+	if start < 0 {
+		return fmt.Errorf("response has no JSON object")
+	}
+	return json.Unmarshal(body[start:], result)
+}
+
+// parseTrendsTimeline reads the daily interest of terms, in the order they
+// were compared, from a multiline widget response. Days Google Trends has no
+// data for are skipped.
+func parseTrendsTimeline(r io.Reader, terms []string, geo string) ([]database.SearchInterest, error) {
+	var payload struct {
+		Default struct {
+			TimelineData []struct {
+				Time    string `json:"time"`
+				Value   []int  `json:"value"`
+				HasData []bool `json:"hasData"`
+			} `json:"timelineData"`
+		} `json:"default"`
+	}
+	if err := decodeTrendsJSON(r, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode interest over time: %w", err)
+	}
+
+	points := []database.SearchInterest{}
+	for _, entry := range payload.Default.TimelineData {
+		seconds, err := strconv.ParseInt(entry.Time, 10, 64)
+		if err != nil {
+			continue
+		}
+		day := time.Unix(seconds, 0).UTC().Format("2006-01-02")
+		for i, value := range entry.Value {
+			if i >= len(terms) || (i < len(entry.HasData) && !entry.HasData[i]) {
+				continue
+			}
+			points = append(points, database.SearchInterest{Geo: geo, Term: terms[i], Day: day, Interest: value})
+		}
+	}
+	return points, nil
+}
+
+// RefreshSearchInterest fetches the Google Trends interest of the configured
+// terms and stores it, returning the number of values stored
+func RefreshSearchInterest(ctx context.Context) (int, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	if !cfg.ExternalAPIs.GoogleTrends.Enabled {
+		return 0, fmt.Errorf("Google Trends is disabled (GOOGLE_TRENDS_ENABLED=false)")
+	}
+
+	points, err := NewGoogleTrendsAPI(cfg.ExternalAPIs.GoogleTrends).FetchInterest(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch search interest: %v", err)
+	}
+	return database.UpsertSearchInterest(points)
+}
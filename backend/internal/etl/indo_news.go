@@ -1,7 +1,7 @@
 package etl
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -19,7 +19,7 @@ type IndonesiaNewsAPI struct {
 
 // IndonesiaNewsResponse represents the actual API response structure from RapidAPI
 type IndonesiaNewsResponse struct {
-	Items    []interface{}          `json:"items,omitempty"`
+	Items    []NewsArticle          `json:"items,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 	Status   string                 `json:"status,omitempty"`
 	Error    string                 `json:"error,omitempty"`
@@ -28,10 +28,46 @@ type IndonesiaNewsResponse struct {
 	Params   interface{}            `json:"params,omitempty"`
 }
 
+// indonesiaNewsBody is a response of the scraper. Each outlet returns its
+// items under a different key.
+type indonesiaNewsBody struct {
+	Items []NewsArticle `json:"items"` // cnn
+	Item  []NewsArticle `json:"item"`  // detik
+	XML   struct {
+		Pencarian struct {
+			Item []NewsArticle `json:"item"`
+		} `json:"pencarian"`
+	} `json:"xml"` // kompas
+	Metadata map[string]interface{} `json:"metadata"`
+	Error    interface{}            `json:"error"`
+}
+
+// items returns the items of the outlet's response
+func (b *indonesiaNewsBody) items(source string) []NewsArticle {
+	switch source {
+	case "cnn":
+		return b.Items
+	case "detik":
+		return b.Item
+	case "kompas":
+		return b.XML.Pencarian.Item
+	}
+	return nil
+}
+
 // IndonesiaNewsData represents the extracted Indonesia news data
 type IndonesiaNewsData struct {
-	Timestamp string                 `json:"timestamp"`
-	Sources   map[string]interface{} `json:"sources"`
+	Timestamp string               `json:"timestamp"`
+	Sources   IndonesiaNewsSources `json:"sources"`
+	Outlets   []OutletStats        `json:"outlets,omitempty"` // what each outlet returned
+}
+
+// IndonesiaNewsSources holds the items of all outlets, flattened for the
+// transformer
+type IndonesiaNewsSources struct {
+	Items    []NewsArticle            `json:"items"`
+	Metadata []map[string]interface{} `json:"metadata"`
+	Count    int                      `json:"count"`
 }
 
 // NewIndonesiaNewsAPI creates a new Indonesia News API client
@@ -44,14 +80,12 @@ func NewIndonesiaNewsAPI() *IndonesiaNewsAPI {
 	return &IndonesiaNewsAPI{
 		APIKey: apiKey,
 		Host:   "indonesia-news.p.rapidapi.com",
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		Client: newExtractorClient("indonesia_news", 30*time.Second),
 	}
 }
 
 // SearchNews searches for news from different Indonesian sources
-func (in *IndonesiaNewsAPI) SearchNews(source, query string, params map[string]interface{}) (*IndonesiaNewsResponse, error) {
+func (in *IndonesiaNewsAPI) SearchNews(ctx context.Context, source, query string, params map[string]interface{}) (*IndonesiaNewsResponse, error) {
 	var endpoint string
 
 	// Build endpoint based on source
@@ -78,7 +112,7 @@ func (in *IndonesiaNewsAPI) SearchNews(source, query string, params map[string]i
 	}
 
 	// Create request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s%s", in.Host, endpoint), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s%s", in.Host, endpoint), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -105,8 +139,8 @@ func (in *IndonesiaNewsAPI) SearchNews(source, query string, params map[string]i
 	}
 
 	// Parse response into the actual API structure
-	var apiResponse map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+	var apiResponse indonesiaNewsBody
+	if err := decodeJSON("indonesia_news", resp.Body, &apiResponse); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -119,44 +153,12 @@ func (in *IndonesiaNewsAPI) SearchNews(source, query string, params map[string]i
 	}
 
 	// Extract items based on source-specific response structure
-	switch source {
-	case "cnn":
-		// CNN uses "items" field directly
-		if items, ok := apiResponse["items"]; ok {
-			if itemsArray, ok := items.([]interface{}); ok {
-				result.Items = itemsArray
-			}
-		}
-	case "detik":
-		// DETIK uses "item" field (singular)
-		if items, ok := apiResponse["item"]; ok {
-			if itemsArray, ok := items.([]interface{}); ok {
-				result.Items = itemsArray
-			}
-		}
-	case "kompas":
-		// KOMPAS uses nested structure: xml.pencarian.item
-		if xmlData, ok := apiResponse["xml"].(map[string]interface{}); ok {
-			if pencarian, ok := xmlData["pencarian"].(map[string]interface{}); ok {
-				if items, ok := pencarian["item"]; ok {
-					if itemsArray, ok := items.([]interface{}); ok {
-						result.Items = itemsArray
-					}
-				}
-			}
-		}
-	}
-
-	// Extract metadata if it exists
-	if metadata, ok := apiResponse["metadata"]; ok {
-		if metadataMap, ok := metadata.(map[string]interface{}); ok {
-			result.Metadata = metadataMap
-		}
-	}
+	result.Items = apiResponse.items(source)
+	result.Metadata = apiResponse.Metadata
 
 	// If no items found, check if there's an error
 	if len(result.Items) == 0 {
-		if errorMsg, ok := apiResponse["error"]; ok {
+		if errorMsg := apiResponse.Error; errorMsg != nil {
 			result.Status = "error"
 			result.Error = fmt.Sprintf("%v", errorMsg)
 		} else {
@@ -170,7 +172,7 @@ func (in *IndonesiaNewsAPI) SearchNews(source, query string, params map[string]i
 }
 
 // GetNewsDetail retrieves detailed news article
-func (in *IndonesiaNewsAPI) GetNewsDetail(source, identifier string) (*IndonesiaNewsResponse, error) {
+func (in *IndonesiaNewsAPI) GetNewsDetail(ctx context.Context, source, identifier string) (*IndonesiaNewsResponse, error) {
 	var endpoint string
 
 	// Build endpoint based on source
@@ -190,7 +192,7 @@ func (in *IndonesiaNewsAPI) GetNewsDetail(source, identifier string) (*Indonesia
 	}
 
 	// Create request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s%s", in.Host, endpoint), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s%s", in.Host, endpoint), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -216,8 +218,8 @@ func (in *IndonesiaNewsAPI) GetNewsDetail(source, identifier string) (*Indonesia
 	}
 
 	// Parse response
-	var apiResponse map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+	var apiResponse indonesiaNewsBody
+	if err := decodeJSON("indonesia_news", resp.Body, &apiResponse); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -228,41 +230,12 @@ func (in *IndonesiaNewsAPI) GetNewsDetail(source, identifier string) (*Indonesia
 	}
 
 	// Extract items based on source-specific response structure
-	switch source {
-	case "cnn":
-		if items, ok := apiResponse["items"]; ok {
-			if itemsArray, ok := items.([]interface{}); ok {
-				result.Items = itemsArray
-			}
-		}
-	case "detik":
-		if items, ok := apiResponse["item"]; ok {
-			if itemsArray, ok := items.([]interface{}); ok {
-				result.Items = itemsArray
-			}
-		}
-	case "kompas":
-		if xmlData, ok := apiResponse["xml"].(map[string]interface{}); ok {
-			if pencarian, ok := xmlData["pencarian"].(map[string]interface{}); ok {
-				if items, ok := pencarian["item"]; ok {
-					if itemsArray, ok := items.([]interface{}); ok {
-						result.Items = itemsArray
-					}
-				}
-			}
-		}
-	}
-
-	// Extract metadata if it exists
-	if metadata, ok := apiResponse["metadata"]; ok {
-		if metadataMap, ok := metadata.(map[string]interface{}); ok {
-			result.Metadata = metadataMap
-		}
-	}
+	result.Items = apiResponse.items(source)
+	result.Metadata = apiResponse.Metadata
 
 	// If no items found, check if there's an error
 	if len(result.Items) == 0 {
-		if errorMsg, ok := apiResponse["error"]; ok {
+		if errorMsg := apiResponse.Error; errorMsg != nil {
 			result.Status = "error"
 			result.Error = fmt.Sprintf("%v", errorMsg)
 		} else {
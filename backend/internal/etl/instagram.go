@@ -1,6 +1,8 @@
 package etl
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,23 +20,100 @@ type InstagramAPI struct {
 
 // InstagramResponse represents the API response structure
 type InstagramResponse struct {
-	Status  string      `json:"status"`
-	Data    interface{} `json:"data,omitempty"` // For backward compatibility
-	Error   string      `json:"error,omitempty"`
-	Hashtag string      `json:"hashtag,omitempty"`
-	MaxID   string      `json:"max_id,omitempty"`
-	MediaID string      `json:"media_id,omitempty"`
-	Amount  int         `json:"amount,omitempty"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Hashtag string `json:"hashtag,omitempty"`
+	MaxID   string `json:"max_id,omitempty"`
+	MediaID string `json:"media_id,omitempty"`
+	Amount  int    `json:"amount,omitempty"`
 
 	// Direct API response fields for array structure
-	Posts  []interface{} `json:"posts,omitempty"`  // Posts data from first array element
-	Cursor string        `json:"cursor,omitempty"` // Cursor token from second array element
+	Posts    []InstagramPost    `json:"posts,omitempty"`    // Posts data from first array element
+	Comments []InstagramComment `json:"comments,omitempty"` // Comments data from first array element
+	Cursor   string             `json:"cursor,omitempty"`   // Cursor token from second array element
+}
+
+// InstagramPost is a media post of a hashtag or location feed
+type InstagramPost struct {
+	PK           json.Number        `json:"pk,omitempty"`
+	Code         string             `json:"code"`
+	CaptionText  string             `json:"caption_text"`
+	LikeCount    int                `json:"like_count"`
+	CommentCount int                `json:"comment_count"`
+	TakenAt      int64              `json:"taken_at,omitempty"` // Unix seconds
+	User         *InstagramUser     `json:"user,omitempty"`
+	Location     *InstagramLocation `json:"location,omitempty"`
+}
+
+// InstagramUser is the account of a post or comment
+type InstagramUser struct {
+	PK       json.Number `json:"pk,omitempty"`
+	Username string      `json:"username"`
+	FullName string      `json:"full_name,omitempty"`
+}
+
+// InstagramLocation is the place a post is tagged with. The API sends its
+// pk as a number or a string.
+type InstagramLocation struct {
+	PK   json.Number `json:"pk"`
+	Name string      `json:"name"`
+	Lat  float64     `json:"lat,omitempty"`
+	Lng  float64     `json:"lng,omitempty"`
+}
+
+// InstagramComment is a comment on a post
+type InstagramComment struct {
+	PK        json.Number    `json:"pk,omitempty"`
+	Text      string         `json:"text"`
+	CreatedAt int64          `json:"created_at,omitempty"` // Unix seconds
+	LikeCount int            `json:"comment_like_count,omitempty"`
+	User      *InstagramUser `json:"user,omitempty"`
 }
 
 // InstagramData represents the extracted Instagram data
 type InstagramData struct {
-	Timestamp string      `json:"timestamp"`
-	Posts     interface{} `json:"posts"`
+	Timestamp string `json:"timestamp"`
+	// Posts are the posts of the single hashtag of older stored payloads
+	Posts []InstagramPost `json:"posts,omitempty"`
+	// Hashtags are the recent posts of each extracted hashtag
+	Hashtags []InstagramHashtagData `json:"hashtags,omitempty"`
+	// Locations are the recent posts of each configured location
+	Locations []InstagramLocationData `json:"locations,omitempty"`
+	// Comments are the comments of the most engaging posts
+	Comments []InstagramPostComments `json:"comments,omitempty"`
+}
+
+// InstagramPostComments holds the comments of one post, found in the feed
+// of Hashtag when it came from a hashtag
+type InstagramPostComments struct {
+	Post     InstagramPost      `json:"post"`
+	Hashtag  string             `json:"hashtag,omitempty"`
+	Comments []InstagramComment `json:"comments"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// InstagramHashtagData holds the recent posts of one hashtag
+type InstagramHashtagData struct {
+	Hashtag string          `json:"hashtag"`
+	Posts   []InstagramPost `json:"posts"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// extractedAnyHashtag reports whether any hashtag was extracted
+func (d *InstagramData) extractedAnyHashtag() bool {
+	for _, hashtag := range d.Hashtags {
+		if hashtag.Error == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// InstagramLocationData holds the recent posts tagged with one location
+type InstagramLocationData struct {
+	LocationID string          `json:"location_id"`
+	Posts      []InstagramPost `json:"posts"`
+	Error      string          `json:"error,omitempty"`
 }
 
 // NewInstagramAPI creates a new Instagram API client
@@ -47,14 +126,12 @@ func NewInstagramAPI() *InstagramAPI {
 	return &InstagramAPI{
 		APIKey: apiKey,
 		Host:   "instagram-premium-api-2023.p.rapidapi.com",
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		Client: newExtractorClient("instagram", 30*time.Second),
 	}
 }
 
 // GetHashtagMedia retrieves media for a specific hashtag
-func (ig *InstagramAPI) GetHashtagMedia(name, maxID string) (*InstagramResponse, error) {
+func (ig *InstagramAPI) GetHashtagMedia(ctx context.Context, name, maxID string) (*InstagramResponse, error) {
 	// Build query parameters
 	params := url.Values{}
 	params.Set("name", name)
@@ -62,10 +139,43 @@ func (ig *InstagramAPI) GetHashtagMedia(name, maxID string) (*InstagramResponse,
 		params.Set("max_id", maxID)
 	}
 
+	result := &InstagramResponse{
+		Hashtag: name,
+		MaxID:   maxID,
+		Status:  "success",
+	}
+	if err := ig.getMediaChunk(ctx, "/v1/hashtag/medias/top/recent/chunk", params, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetLocationMedia retrieves the recent media tagged with a location, by its
+// Instagram location ID (pk)
+func (ig *InstagramAPI) GetLocationMedia(ctx context.Context, locationID, maxID string) (*InstagramResponse, error) {
+	params := url.Values{}
+	params.Set("location_pk", locationID)
+	if maxID != "" {
+		params.Set("max_id", maxID)
+	}
+
+	result := &InstagramResponse{
+		MaxID:  maxID,
+		Status: "success",
+	}
+	if err := ig.getMediaChunk(ctx, "/v1/location/medias/recent/chunk", params, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getMediaChunk requests one chunk of a media feed and fills result with its
+// posts and cursor. HTTP errors are reported in result, not as an error.
+func (ig *InstagramAPI) getMediaChunk(ctx context.Context, path string, params url.Values, result *InstagramResponse) error {
 	// Create request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s/v1/hashtag/medias/top/recent/chunk?%s", ig.Host, params.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s%s?%s", ig.Host, path, params.Encode()), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
@@ -75,36 +185,28 @@ func (ig *InstagramAPI) GetHashtagMedia(name, maxID string) (*InstagramResponse,
 	// Make request
 	resp, err := ig.Client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// First, try to decode as array to handle the actual API response structure
-	var rawResponse []interface{}
+	var rawResponse []json.RawMessage
 	if err := json.NewDecoder(resp.Body).Decode(&rawResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode response as array: %w", err)
-	}
-
-	// Create result
-	result := &InstagramResponse{
-		Hashtag: name,
-		MaxID:   maxID,
-		Status:  "success",
+		return fmt.Errorf("failed to decode response as array: %w", err)
 	}
 
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
 		result.Status = "error"
 		result.Error = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status)
-		return result, nil
+		return nil
 	}
 
 	// Handle array response structure
 	if len(rawResponse) >= 1 {
 		// First element contains the posts data
-		if postsData, ok := rawResponse[0].([]interface{}); ok {
-			result.Posts = postsData
-			result.Data = postsData // Keep backward compatibility
+		if bytes.HasPrefix(rawResponse[0], []byte("[")) {
+			decodeJSON("instagram", bytes.NewReader(rawResponse[0]), &result.Posts)
 		} else {
 			result.Error = "First array element is not a posts array"
 		}
@@ -112,23 +214,21 @@ func (ig *InstagramAPI) GetHashtagMedia(name, maxID string) (*InstagramResponse,
 
 	// Second element contains cursor/pagination info
 	if len(rawResponse) >= 2 {
-		if cursorData, ok := rawResponse[1].(string); ok {
-			result.Cursor = cursorData
-		}
+		json.Unmarshal(rawResponse[1], &result.Cursor)
 	}
 
-	return result, nil
+	return nil
 }
 
 // GetMediaComments retrieves comments for a specific media post
-func (ig *InstagramAPI) GetMediaComments(mediaID string, amount int) (*InstagramResponse, error) {
+func (ig *InstagramAPI) GetMediaComments(ctx context.Context, mediaID string, amount int) (*InstagramResponse, error) {
 	// Build query parameters
 	params := url.Values{}
 	params.Set("amount", fmt.Sprintf("%d", amount))
 	params.Set("id", mediaID)
 
 	// Create request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s/v1/media/comments?%s", ig.Host, params.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/v1/media/comments?%s", ig.Host, params.Encode()), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -145,7 +245,7 @@ func (ig *InstagramAPI) GetMediaComments(mediaID string, amount int) (*Instagram
 	defer resp.Body.Close()
 
 	// Parse response - comments might also return array structure
-	var rawResponse []interface{}
+	var rawResponse []json.RawMessage
 	if err := json.NewDecoder(resp.Body).Decode(&rawResponse); err != nil {
 		return nil, fmt.Errorf("failed to decode comments response: %w", err)
 	}
@@ -167,9 +267,8 @@ func (ig *InstagramAPI) GetMediaComments(mediaID string, amount int) (*Instagram
 	// Handle array response structure for comments
 	if len(rawResponse) >= 1 {
 		// First element contains the comments data
-		if commentsData, ok := rawResponse[0].([]interface{}); ok {
-			result.Posts = commentsData // Reuse Posts field for comments
-			result.Data = commentsData  // Keep backward compatibility
+		if bytes.HasPrefix(rawResponse[0], []byte("[")) {
+			decodeJSON("instagram", bytes.NewReader(rawResponse[0]), &result.Comments)
 		} else {
 			result.Error = "First array element is not a comments array"
 		}
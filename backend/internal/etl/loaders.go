@@ -1,17 +1,36 @@
 package etl
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
 	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
 )
 
-// DataLoader handles loading data to PostgreSQL database
+// DataLoader handles loading data to PostgreSQL and the other configured
+// destinations
 type DataLoader struct {
-	// No outputDir needed for database
+	// batchID tags every row written by this loader with the ETL run that produced it
+	batchID string
+	// projectID namespaces every row written by this loader
+	projectID string
+	// campaign tags every row with the campaign whose query extracted it
+	campaign string
+	// terms supplies the license tag stored on every row
+	terms config.TermsConfig
+	// postgres is set when processed records are stored in PostgreSQL
+	postgres bool
+	// store replaces the configured destinations when set, see SetStore
+	store database.ProcessedDataRepo
+	// elasticsearch indexes processed records; nil when it is not a destination
+	elasticsearch *ElasticsearchLoader
 }
 
 // LoadResult represents the result of a data loading operation
@@ -20,22 +39,106 @@ type LoadResult struct {
 	Message      string `json:"message"`
 	Timestamp    string `json:"timestamp"`
 	RecordsCount int    `json:"records_count"`
+	// UpdatedCount is how many of the records were already stored and updated
+	// in place rather than inserted
+	UpdatedCount int    `json:"updated_count,omitempty"`
+	Error        string `json:"error,omitempty"`
+
+	// Destinations are the results of the individual load destinations
+	Destinations []DestinationResult `json:"destinations,omitempty"`
+
+	// Chunks are the results of the individual chunks of a chunked load
+	Chunks []LoadResult `json:"chunks,omitempty"`
+
+	// RawDataIDs are the raw_data rows a raw data load stored
+	RawDataIDs []int `json:"-"`
+}
+
+// DestinationResult is the outcome of loading processed records into one
+// destination. A destination fails when it cannot be reached; records it
+// rejected are counted in FailedCount, and those PostgreSQL rejected are kept
+// in the dead_letter table for RetryDeadLetters.
+type DestinationResult struct {
+	Destination  string `json:"destination"` // "postgres" or "elasticsearch"
+	Success      bool   `json:"success"`
+	RecordsCount int    `json:"records_count"`
+	FailedCount  int    `json:"failed_count,omitempty"`
 	Error        string `json:"error,omitempty"`
 }
 
 // NewDataLoader creates a new DataLoader instance
 func NewDataLoader() *DataLoader {
-	return &DataLoader{}
+	cfg, _ := config.LoadConfig()
+	loader := &DataLoader{
+		batchID:   NewBatchID("load"),
+		projectID: database.DefaultProject,
+		terms:     cfg.Terms,
+		postgres:  cfg.Database.HasDestination("postgres"),
+	}
+	if cfg.Database.HasDestination("elasticsearch") {
+		loader.elasticsearch = NewElasticsearchLoader(cfg.Database.Elasticsearch)
+	}
+	return loader
+}
+
+// loadSourceTaxonomy returns the source taxonomy records are mapped to,
+// falling back to the built-in aliases when the database cannot be read
+func loadSourceTaxonomy() *database.SourceTaxonomy {
+	taxonomy, err := database.LoadSourceTaxonomy()
+	if err != nil {
+		log.Printf("⚠️ Using built-in source taxonomy: %v", err)
+		return database.DefaultSourceTaxonomy()
+	}
+	return taxonomy
+}
+
+// NewBatchID returns a unique identifier for an ETL run or standalone load
+func NewBatchID(prefix string) string {
+	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+}
+
+// SetBatchID sets the batch ID recorded on subsequently loaded rows
+func (dl *DataLoader) SetBatchID(batchID string) {
+	dl.batchID = batchID
+}
+
+// BatchID returns the batch ID recorded on loaded rows
+func (dl *DataLoader) BatchID() string {
+	return dl.batchID
+}
+
+// SetProject sets the project that subsequently loaded rows belong to
+func (dl *DataLoader) SetProject(projectID string) {
+	dl.projectID = projectID
+}
+
+// SetCampaign sets the campaign recorded on subsequently loaded rows; empty
+// for runs without campaigns
+func (dl *DataLoader) SetCampaign(campaign string) {
+	dl.campaign = campaign
+}
+
+// SetStore loads subsequent records into store alone, instead of the
+// configured destinations. Only the records themselves are stored: topic
+// tags, entities and warehouse facts need PostgreSQL.
+func (dl *DataLoader) SetStore(store database.ProcessedDataRepo) {
+	dl.store = store
 }
 
-// LoadData loads transformed data to PostgreSQL database
+// LoadData loads transformed data to the configured destinations
 func (dl *DataLoader) LoadData(data *TransformedData) *LoadResult {
-	log.Println("Loading data to PostgreSQL database...")
+	log.Println("Loading data to the configured destinations...")
 
 	// Count total records
 	totalRecords := len(data.YouTube) + len(data.News)
 
-	// Save to database
+	// Sentiment is scored during transformation by the current analyzer
+	analyzerVersion := services.SentimentAnalyzerVersion
+	taxonomy := loadSourceTaxonomy()
+	records := make([]*database.ProcessedData, 0, totalRecords)
+	topics := make([][]string, 0, totalRecords)
+	entities := make([][]services.Entity, 0, totalRecords)
+
 	for _, video := range data.YouTube {
 		// Convert video to JSON string
 		videoJSON, err := json.Marshal(video)
@@ -44,20 +147,31 @@ func (dl *DataLoader) LoadData(data *TransformedData) *LoadResult {
 			continue
 		}
 
+		sourceName, outlet := taxonomy.Resolve("youtube", video.ChannelTitle)
 		processedData := &database.ProcessedData{
-			Source:              "youtube",
+			Source:              sourceName,
+			Outlet:              outlet,
 			Title:               video.Title,
 			Content:             video.Description,
 			RelevanceScore:      video.CovidRelevanceScore,
 			Sentiment:           video.Sentiment,
 			SentimentScore:      &video.SentimentScore,
 			SentimentConfidence: &video.SentimentConfidence,
+			AnalyzerVersion:     &analyzerVersion,
+			BatchID:             dl.batchID,
+			ProjectID:           dl.projectID,
+			Campaign:            dl.campaign,
+			RegionCode:          database.ProvinceCodes[video.Region],
+			PublishedAt:         parsePublishedAt(video.PublishedAt),
+			License:             dl.terms.LicenseFor(sourceName),
+			ToxicityScore:       video.ToxicityScore,
 			ProcessedData:       string(videoJSON),
 		}
 
-		if err := database.InsertProcessedData(processedData); err != nil {
-			log.Printf("Failed to insert video data: %v", err)
-		}
+		processedData.ContentHash = database.ContentHash(video.Title, "youtube:"+video.ID)
+		records = append(records, processedData)
+		topics = append(topics, video.Topics)
+		entities = append(entities, video.Entities)
 	}
 
 	for _, article := range data.News {
@@ -68,50 +182,190 @@ func (dl *DataLoader) LoadData(data *TransformedData) *LoadResult {
 			continue
 		}
 
-		// Determine the specific source based on the article source field
-		sourceName := "news" // default
-		if article.Source != "" {
-			switch article.Source {
-			case "CNN", "DETIK", "KOMPAS", "Indonesia News":
-				sourceName = "indonesia_news"
-			case "Real-Time News":
-				sourceName = "google_news" // Store as google_news for backward compatibility
-			case "Instagram":
-				sourceName = "instagram"
-			default:
-				// Check if it contains Instagram-related keywords
-				if strings.Contains(strings.ToLower(article.Source), "instagram") {
-					sourceName = "instagram"
-				} else if strings.Contains(strings.ToLower(article.Source), "indonesia") {
-					sourceName = "indonesia_news"
-				} else {
-					sourceName = "news"
-				}
-			}
-		}
-
+		// Articles carry the source whose adapter transformed them
+		sourceName, outlet := taxonomy.Resolve(article.SourceKey, article.Outlet)
 		processedData := &database.ProcessedData{
 			Source:              sourceName,
+			Outlet:              outlet,
 			Title:               article.Title,
 			Content:             article.Content,
 			RelevanceScore:      article.CovidRelevanceScore,
 			Sentiment:           article.Sentiment,
 			SentimentScore:      &article.SentimentScore,
 			SentimentConfidence: &article.SentimentConfidence,
+			AnalyzerVersion:     &analyzerVersion,
+			BatchID:             dl.batchID,
+			ProjectID:           dl.projectID,
+			Campaign:            dl.campaign,
+			RegionCode:          database.ProvinceCodes[article.Region],
+			Hashtag:             article.Hashtag,
+			PublishedAt:         parsePublishedAt(article.PublishedAt),
+			License:             dl.terms.LicenseFor(sourceName),
+			ToxicityScore:       article.ToxicityScore,
 			ProcessedData:       string(articleJSON),
 		}
 
-		if err := database.InsertProcessedData(processedData); err != nil {
-			log.Printf("Failed to insert article data: %v", err)
-		}
+		processedData.ContentHash = database.ContentHash(article.Title, articleLocator(article))
+		records = append(records, processedData)
+		topics = append(topics, article.Topics)
+		entities = append(entities, article.Entities)
 	}
 
-	return &LoadResult{
+	result := &LoadResult{
 		Success:      true,
-		Message:      "Data successfully loaded to PostgreSQL database",
+		Message:      "Data successfully loaded",
 		Timestamp:    time.Now().Format(time.RFC3339),
 		RecordsCount: totalRecords,
 	}
+
+	if dl.store != nil {
+		destination, updated := dl.loadStore(records)
+		result.UpdatedCount = updated
+		result.addDestination(destination)
+		return result
+	}
+
+	// PostgreSQL goes first so the index carries the record IDs
+	if dl.postgres {
+		destination, updated := dl.loadPostgres(records, topics, entities)
+		result.UpdatedCount = updated
+		result.addDestination(destination)
+	}
+	if dl.elasticsearch != nil {
+		result.addDestination(dl.loadElasticsearch(records))
+	}
+
+	return result
+}
+
+// loadPostgres upserts records into PostgreSQL with their warehouse facts
+// and tags their topics and entities, returning the destination result and
+// how many records were updated
+func (dl *DataLoader) loadPostgres(records []*database.ProcessedData, topics [][]string, entities [][]services.Entity) (DestinationResult, int) {
+	result := DestinationResult{Destination: "postgres", Success: true}
+	updated := 0
+
+	for i, record := range records {
+		inserted, err := database.UpsertProcessedDataWithFact(record)
+		if err != nil {
+			log.Printf("Failed to insert %s data: %v", record.Source, err)
+			result.FailedCount++
+			dl.deadLetter(record, topics[i], entities[i], err)
+			continue
+		}
+		result.RecordsCount++
+		if !inserted {
+			updated++
+		}
+		dl.tagTopics(record, topics[i])
+		dl.linkEntities(record, entities[i])
+	}
+
+	return result, updated
+}
+
+// loadStore upserts records into the store set with SetStore, returning the
+// destination result and how many records were updated
+func (dl *DataLoader) loadStore(records []*database.ProcessedData) (DestinationResult, int) {
+	result := DestinationResult{Destination: "store", Success: true}
+	updated := 0
+
+	for _, record := range records {
+		inserted, err := dl.store.UpsertProcessedData(context.Background(), record)
+		if err != nil {
+			log.Printf("Failed to store %s data: %v", record.Source, err)
+			result.FailedCount++
+			continue
+		}
+		result.RecordsCount++
+		if !inserted {
+			updated++
+		}
+	}
+
+	return result, updated
+}
+
+// loadElasticsearch indexes records in Elasticsearch
+func (dl *DataLoader) loadElasticsearch(records []*database.ProcessedData) DestinationResult {
+	result := DestinationResult{Destination: "elasticsearch", Success: true}
+
+	indexed, failed, err := dl.elasticsearch.IndexRecords(records)
+	result.RecordsCount = indexed
+	result.FailedCount = failed
+	if err != nil {
+		log.Printf("⚠️ Elasticsearch indexing failed: %v", err)
+		result.Success = false
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// addDestination records the result of a destination; the load fails when
+// any destination does
+func (lr *LoadResult) addDestination(destination DestinationResult) {
+	lr.Destinations = append(lr.Destinations, destination)
+	if !destination.Success {
+		lr.Success = false
+		lr.Error = fmt.Sprintf("%s: %s", destination.Destination, destination.Error)
+		lr.Message = "Data loading failed for " + destination.Destination
+	}
+}
+
+// mergeDestinationResults adds up the results of the same destinations
+// across chunks or campaigns
+func mergeDestinationResults(into, loaded []DestinationResult) []DestinationResult {
+	for _, destination := range loaded {
+		merged := false
+		for i := range into {
+			if into[i].Destination != destination.Destination {
+				continue
+			}
+			into[i].Success = into[i].Success && destination.Success
+			into[i].RecordsCount += destination.RecordsCount
+			into[i].FailedCount += destination.FailedCount
+			if destination.Error != "" {
+				into[i].Error = destination.Error
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			into = append(into, destination)
+		}
+	}
+	return into
+}
+
+// articleLocator identifies an article for its content hash: its URL, or its
+// content for sources that do not link to one
+func articleLocator(article TransformedArticle) string {
+	if article.URL != "" {
+		return article.URL
+	}
+	return article.Content
+}
+
+// tagTopics adds the classified topics of a loaded record as automatic tags
+func (dl *DataLoader) tagTopics(record *database.ProcessedData, topics []string) {
+	if len(topics) == 0 {
+		return
+	}
+	if err := database.AddRecordTags(record.ProjectID, record.ID, topics, database.TagOriginAuto, ""); err != nil {
+		log.Printf("Failed to tag record %d: %v", record.ID, err)
+	}
+}
+
+// linkEntities links a loaded record to the named entities it mentions,
+// replacing those of an earlier load
+func (dl *DataLoader) linkEntities(record *database.ProcessedData, entities []services.Entity) {
+	links := make([]database.RecordEntity, len(entities))
+	for i, entity := range entities {
+		links[i] = database.RecordEntity{Name: entity.Name, Type: entity.Type, Mentions: entity.Mentions}
+	}
+	if err := database.SetRecordEntities(record.ProjectID, record.ID, links); err != nil {
+		log.Printf("Failed to link entities of record %d: %v", record.ID, err)
+	}
 }
 
 // LoadRawData loads raw extracted data to PostgreSQL database
@@ -119,24 +373,84 @@ func (dl *DataLoader) LoadRawData(data *ExtractedData) *LoadResult {
 	log.Println("Loading raw data to PostgreSQL database...")
 
 	// Save raw data to database
+	var ids []int
+	var failed []string
 	for sourceName, sourceData := range data.Sources {
-		if err := database.InsertRawData(sourceName, data.Query, sourceData); err != nil {
+		// Skipped sources were never called, so there is no payload to keep
+		if isSkippedSource(sourceData) {
+			continue
+		}
+		id, err := database.InsertRawData(dl.projectID, sourceName, data.Query, dl.batchID, sourceData)
+		if err != nil {
 			log.Printf("Failed to insert raw data for source %s: %v", sourceName, err)
+			failed = append(failed, sourceName)
+			continue
 		}
+		ids = append(ids, id)
 	}
 
-	return &LoadResult{
+	result := &LoadResult{
 		Success:      true,
 		Message:      "Raw data successfully loaded to PostgreSQL database",
 		Timestamp:    time.Now().Format(time.RFC3339),
 		RecordsCount: len(data.Sources),
+		RawDataIDs:   ids,
 	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		result.Success = false
+		result.Error = "failed to store the payloads of " + strings.Join(failed, ", ")
+	}
+	return result
+}
+
+// QuarantineRecords stores records that failed validation in the
+// quarantined_records table and returns how many were stored
+func (dl *DataLoader) QuarantineRecords(records []invalidRecord) (int, error) {
+	quarantined := make([]database.QuarantinedRecord, 0, len(records))
+	for _, record := range records {
+		recordJSON, err := json.Marshal(record.record)
+		if err != nil {
+			log.Printf("Failed to marshal invalid record %s: %v", record.id, err)
+			continue
+		}
+		quarantined = append(quarantined, database.QuarantinedRecord{
+			ProjectID: dl.projectID,
+			BatchID:   dl.batchID,
+			Campaign:  dl.campaign,
+			Source:    record.source,
+			Kind:      record.kind,
+			RecordID:  record.id,
+			Reasons:   record.reasons,
+			Record:    string(recordJSON),
+		})
+	}
+	return database.InsertQuarantinedRecords(quarantined)
 }
 
 // GetLoadReport generates a load report
 func (dl *DataLoader) GetLoadReport() map[string]interface{} {
+	destinations := []string{}
+	if dl.postgres {
+		destinations = append(destinations, "postgres")
+	}
+	if dl.elasticsearch != nil {
+		destinations = append(destinations, "elasticsearch")
+	}
 	return map[string]interface{}{
 		"storage_type": "postgresql",
+		"destinations": destinations,
 		"timestamp":    time.Now().Format(time.RFC3339),
 	}
 }
+
+// parsePublishedAt parses an RFC3339 publish date, returning nil for missing
+// or unparsable values so the record falls back to its processed_at time
+func parsePublishedAt(value string) *time.Time {
+	published, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	published = published.UTC()
+	return &published
+}
@@ -1,10 +1,16 @@
 package etl
 
 import (
+	"context"
 	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/services"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -13,32 +19,79 @@ type ETLOrchestrator struct {
 	extractor   *DataExtractor
 	transformer *DataTransformer
 	loader      *DataLoader
+	matcher     *services.SavedSearchMatcher
+	dedup       *services.DedupService
+	alerts      *services.AlertService
+
+	extractionTimeout time.Duration
+	loadFlushSize     int
+	validationMode    string
+	resumeMaxAge      time.Duration
 }
 
 // ETLResult represents the result of the entire ETL pipeline
 type ETLResult struct {
 	Status           string                 `json:"status"`
+	BatchID          string                 `json:"batch_id,omitempty"`
+	ProjectID        string                 `json:"project_id,omitempty"`
+	Resumed          bool                   `json:"resumed,omitempty"` // the run resumed one interrupted by a shutdown
+	Settings         *RunSettings           `json:"settings,omitempty"`
+	Campaigns        []CampaignSummary      `json:"campaigns,omitempty"` // per-campaign statistics of runs with campaigns
 	Message          string                 `json:"message"`
 	Timestamp        string                 `json:"timestamp"`
 	PipelineDuration string                 `json:"pipeline_duration"`
-	Extraction       *ExtractedData         `json:"extraction,omitempty"`
+	Extraction       []SourceSummary        `json:"extraction,omitempty"` // full payloads: GET PayloadURL
+	PayloadURL       string                 `json:"payload_url,omitempty"`
 	Transformation   *TransformedData       `json:"transformation,omitempty"`
 	Loading          *LoadResult            `json:"loading,omitempty"`
 	Summary          map[string]interface{} `json:"summary,omitempty"`
 	Error            string                 `json:"error,omitempty"`
+
+	// stageDurations is the time spent in each step, summed over campaigns
+	stageDurations map[string]time.Duration
 }
 
 // NewETLOrchestrator creates a new ETL orchestrator
 func NewETLOrchestrator() *ETLOrchestrator {
+	cfg, _ := config.LoadConfig()
+	notifier := services.NewNotifier(cfg.Notifications)
+
 	return &ETLOrchestrator{
 		extractor:   NewDataExtractor(),
 		transformer: NewDataTransformer(),
 		loader:      NewDataLoader(),
+		matcher:     services.NewSavedSearchMatcher(notifier),
+		dedup:       services.NewDedupService(),
+		alerts:      services.NewAlertService(cfg.Alerts, notifier),
+
+		extractionTimeout: cfg.ETL.ExtractionTimeout,
+		loadFlushSize:     cfg.ETL.LoadFlushSize,
+		validationMode:    cfg.ETL.ValidationMode,
+		resumeMaxAge:      cfg.ETL.ResumeMaxAge,
 	}
 }
 
-// RunETLPipeline executes the complete ETL pipeline
-func (eo *ETLOrchestrator) RunETLPipeline() *ETLResult {
+// RunETLPipeline executes the complete ETL pipeline for the default project
+func (eo *ETLOrchestrator) RunETLPipeline(ctx context.Context) *ETLResult {
+	return eo.RunETLPipelineForProject(ctx, database.DefaultProject)
+}
+
+// RunETLPipelineForProject executes the complete ETL pipeline with the project's sources
+func (eo *ETLOrchestrator) RunETLPipelineForProject(ctx context.Context, projectID string) *ETLResult {
+	return eo.RunETLPipelineWithOptions(ctx, projectID, PipelineOptions{})
+}
+
+// RunETLPipelineWithOptions executes the complete ETL pipeline, loading rows into the given project.
+// opts narrows or replaces the project's sources for this run only.
+// The run stops between steps when ctx is cancelled, or when it is cancelled
+// by batch ID via CancelRun; nothing more is loaded once that happens, but
+// chunks loaded earlier in a chunked run stay loaded.
+//
+// Each campaign checkpoints its extraction, transformation and load. A run
+// cancelled by a shutdown keeps its checkpoints, and the project's next run
+// resumes it under its batch ID, starting each campaign after its last
+// completed stage.
+func (eo *ETLOrchestrator) RunETLPipelineWithOptions(ctx context.Context, projectID string, opts PipelineOptions) *ETLResult {
 	startTime := time.Now()
 	log.Println("🚀 Starting ETL pipeline...")
 
@@ -53,101 +106,770 @@ func (eo *ETLOrchestrator) RunETLPipeline() *ETLResult {
 	}
 	defer database.CloseDatabase()
 
+	// Tag every row loaded by this run so the batch can be managed as a unit.
+	// A resumed run keeps the batch ID of the run it resumes.
+	batchID, interrupted := eo.interruptedRun(projectID)
+	if batchID == "" {
+		batchID = NewBatchID("run")
+	} else {
+		log.Printf("⏯️ Resuming interrupted ETL pipeline %s", batchID)
+	}
+	eo.loader.SetBatchID(batchID)
+	eo.loader.SetProject(projectID)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	defer registerRun(batchID, projectID, cancel)()
+
+	result := &ETLResult{
+		Timestamp: startTime.Format(time.RFC3339),
+		BatchID:   batchID,
+		ProjectID: projectID,
+		Resumed:   len(interrupted) > 0,
+	}
+	// Keep the run in the history, with its outcome and metrics once it
+	// ends, and alert operators when it failed or its data looks off
+	defer eo.recordRun("pipeline", result)()
+
+	// A run cancelled by a shutdown keeps its checkpoints for the next run
+	// of the project to resume; any other outcome ends the run for good
+	runCtx := ctx
+	defer func() {
+		if result.Status == "cancelled" && errors.Is(context.Cause(runCtx), errShutdown) {
+			log.Printf("💾 Kept the checkpoints of ETL pipeline %s for the next run to resume", batchID)
+			return
+		}
+		eo.discardCheckpoints(batchID)
+	}()
+
+	// Stream the progress of each step to /api/etl/stream subscribers
+	ctx, finishProgress := trackProgress(ctx, "pipeline", result)
+	defer finishProgress()
+	progress := progressFrom(ctx)
+
+	// Merge the project's overrides over the global configuration
+	settings, err := LoadRunSettings(projectID)
+	if err != nil {
+		result.Status = "error"
+		result.Message = "ETL pipeline failed: invalid project settings"
+		result.Error = err.Error()
+		result.PipelineDuration = time.Since(startTime).String()
+		return result
+	}
+	settings.selectSources(opts)
+	result.Settings = &settings
+
+	// Steps 1-3 run once per campaign: the campaign's query is extracted,
+	// then its records are transformed and loaded tagged with the campaign
+	var runs []*campaignRun
+	campaigns := settings.forCampaigns()
+	progress.setCampaigns(len(campaigns))
+	for i, campaign := range campaigns {
+		progress.startCampaign(i, campaign.name)
+		run, err := eo.runCampaign(ctx, campaign, eo.campaignCheckpoint(result, i, campaign, interrupted))
+		runs = append(runs, run)
+		eo.addCampaignRun(result, run)
+		if ctx.Err() != nil {
+			return eo.cancelled(ctx, result, startTime)
+		}
+		if err != nil {
+			result.Status = "error"
+			result.Message = "ETL pipeline failed during " + run.failedStep
+			if run.name != "" {
+				result.Message += fmt.Sprintf(" of campaign %q", run.name)
+			}
+			result.Error = err.Error()
+			result.PipelineDuration = time.Since(startTime).String()
+			return result
+		}
+	}
+
+	// Step 4: Group the run's articles with earlier reports of the same story
+	progress.step("deduplication")
+	eo.deduplicate(projectID)
+
+	// Step 5: Notify saved search subscribers about new matches
+	progress.step("notifications")
+	eo.notifySavedSearches()
+
+	// Step 6: Prime the dashboard cache so the first request after the run is fast
+	progress.step("cache")
+	eo.warmDashboardCache(projectID)
+
+	// Step 7: Refresh today's rollups so trend endpoints include this run
+	progress.step("rollups")
+	eo.refreshRollups(projectID)
+
+	// Step 8: Embed the new records for semantic search, when a provider is configured
+	progress.step("embeddings")
+	eo.embedRecords(projectID)
+
+	// Create summary
+	result.Summary = eo.createSummary(runs, result)
+
+	// Calculate pipeline duration
+	duration := time.Since(startTime)
+	result.PipelineDuration = duration.String()
+
+	// Set final status
+	result.Status = "success"
+	result.Message = "ETL pipeline completed successfully"
+
+	log.Printf("✅ ETL pipeline completed in %s", duration)
+	return result
+}
+
+// cancelled finishes the result of a run whose context was cancelled
+func (eo *ETLOrchestrator) cancelled(ctx context.Context, result *ETLResult, startTime time.Time) *ETLResult {
+	log.Printf("🛑 ETL pipeline %s cancelled: %v", result.BatchID, context.Cause(ctx))
+	result.Status = "cancelled"
+	result.Message = "ETL pipeline cancelled"
+	result.Error = context.Cause(ctx).Error()
+	result.PipelineDuration = time.Since(startTime).String()
+	return result
+}
+
+// RunCrawlBackfill crawls the configured outlet sitemaps for archived
+// articles and passes them through the standard transform and load steps
+// into the given project, as a backfill where the news APIs offer no
+// historical search. Instead of the recent days, the rollups of the days the
+// articles were published on are rebuilt. Crawled articles are not grouped
+// with recent stories and do not notify saved searches.
+func (eo *ETLOrchestrator) RunCrawlBackfill(ctx context.Context, projectID string, opts CrawlOptions) *ETLResult {
+	startTime := time.Now()
+	log.Println("🕸️ Starting archive crawl backfill...")
+
+	if err := database.InitDatabase(); err != nil {
+		return &ETLResult{
+			Status:  "error",
+			Message: "Archive crawl failed: database initialization failed",
+			Error:   err.Error(),
+		}
+	}
+	defer database.CloseDatabase()
+
+	batchID := NewBatchID("crawl")
+	eo.loader.SetBatchID(batchID)
+	eo.loader.SetProject(projectID)
+	eo.loader.SetCampaign("")
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	defer registerRun(batchID, projectID, cancel)()
+
 	result := &ETLResult{
 		Timestamp: startTime.Format(time.RFC3339),
+		BatchID:   batchID,
+		ProjectID: projectID,
 	}
+	defer eo.recordRun("crawl", result)()
 
-	// Step 1: Extract data from all sources
-	log.Println("📊 Step 1: Data Extraction")
-	extractedData, err := eo.extractData()
+	ctx, finishProgress := trackProgress(ctx, "crawl", result)
+	defer finishProgress()
+	progress := progressFrom(ctx)
+	progress.setCampaigns(1)
+
+	settings, err := LoadRunSettings(projectID)
 	if err != nil {
 		result.Status = "error"
-		result.Message = "ETL pipeline failed during extraction"
+		result.Message = "Archive crawl failed: invalid project settings"
 		result.Error = err.Error()
 		result.PipelineDuration = time.Since(startTime).String()
 		return result
 	}
-	result.Extraction = extractedData
+	result.Settings = &settings
 
-	// Step 2: Transform and clean data
-	log.Println("🔄 Step 2: Data Transformation")
-	transformedData, err := eo.transformData(extractedData)
+	// Step 1: Crawl the sitemaps in place of the source extraction
+	log.Println("📊 Step 1: Archive Crawl")
+	progress.startExtraction(1)
+	cfg, _ := config.LoadConfig()
+	archive, err := NewSitemapCrawler(cfg.ETL.Crawler).Crawl(ctx, opts)
+	summary := SourceSummary{
+		Source:      "news_archive",
+		Status:      "success",
+		RecordCount: len(archive.Items),
+		Duration:    time.Since(startTime).String(),
+	}
+	if err != nil {
+		summary.Status = "error"
+		summary.Error = err.Error()
+	}
+	progress.sourceDone(summary)
+	run := &campaignRun{
+		query: settings.Query(),
+		extracted: &ExtractedData{
+			Timestamp: startTime.Format(time.RFC3339),
+			Query:     settings.Query(),
+			Sources:   map[string]interface{}{"news_archive": archive},
+			Summaries: []SourceSummary{summary},
+		},
+	}
+	run.timeStep("extraction", startTime)
+	if ctx.Err() != nil {
+		eo.addCampaignRun(result, run)
+		return eo.cancelled(ctx, result, startTime)
+	}
 	if err != nil {
+		eo.addCampaignRun(result, run)
 		result.Status = "error"
-		result.Message = "ETL pipeline failed during transformation"
+		result.Message = "Archive crawl failed during extraction"
 		result.Error = err.Error()
 		result.PipelineDuration = time.Since(startTime).String()
 		return result
 	}
-	result.Transformation = transformedData
 
-	// Step 3: Load data to destinations
-	log.Println("💾 Step 3: Data Loading")
-	loadResult, err := eo.loadData(extractedData, transformedData)
+	// Steps 2-3: Transform and load like the articles of a run
+	err = eo.transformAndLoadRun(ctx, run, settings.MinRelevance)
+	eo.addCampaignRun(result, run)
+	if ctx.Err() != nil {
+		return eo.cancelled(ctx, result, startTime)
+	}
 	if err != nil {
 		result.Status = "error"
-		result.Message = "ETL pipeline failed during loading"
+		result.Message = "Archive crawl failed during " + run.failedStep
 		result.Error = err.Error()
 		result.PipelineDuration = time.Since(startTime).String()
 		return result
 	}
-	result.Loading = loadResult
 
-	// Create summary
-	result.Summary = eo.createSummary(extractedData, transformedData, loadResult)
+	// Step 4: Rebuild the rollups of the days the articles were published on
+	log.Println("📈 Step 4: Rollup Rebuild of Crawled Days")
+	progress.step("rollups")
+	for _, day := range archiveDays(archive) {
+		if _, err := database.RefreshDailyRollups(projectID, day); err != nil {
+			log.Printf("⚠️ Daily rollup refresh failed: %v", err)
+			break
+		}
+	}
 
-	// Calculate pipeline duration
-	duration := time.Since(startTime)
-	result.PipelineDuration = duration.String()
+	eo.warmDashboardCache(projectID)
+	eo.embedRecords(projectID)
 
-	// Set final status
+	result.Summary = eo.createSummary([]*campaignRun{run}, result)
+	result.PipelineDuration = time.Since(startTime).String()
 	result.Status = "success"
-	result.Message = "ETL pipeline completed successfully"
+	result.Message = fmt.Sprintf("Archive crawl completed: %d articles", len(archive.Items))
 
-	log.Printf("✅ ETL pipeline completed in %s", duration)
+	log.Printf("✅ Archive crawl completed in %s", result.PipelineDuration)
 	return result
 }
 
-// extractData extracts data from all sources
-func (eo *ETLOrchestrator) extractData() (*ExtractedData, error) {
-	log.Println("🔄 Starting data extraction...")
+// ReprocessOptions select the stored raw payloads a reprocess run
+// transforms and loads again
+type ReprocessOptions struct {
+	From   time.Time  // first extraction time
+	To     *time.Time // end of the extraction times, nil for up to now
+	Source string     // registered source, empty for every source
+}
 
-	extractedData := eo.extractor.ExtractAllSources()
+// RunReprocess reads the raw payloads a project's runs stored in the
+// extraction time range and passes each one through the transform and load
+// steps again, so transformer fixes reach records already loaded without
+// extracting them again. Records are upserted by content hash and tagged with
+// the new batch; the rollups of the days they fall on are rebuilt. Saved
+// searches are not notified, since the records matched when first loaded.
+func (eo *ETLOrchestrator) RunReprocess(ctx context.Context, projectID string, opts ReprocessOptions) *ETLResult {
+	startTime := time.Now()
+	log.Println("♻️ Starting reprocess of stored raw data...")
 
-	if extractedData == nil {
-		return nil, fmt.Errorf("data extraction returned nil")
+	if err := database.InitDatabase(); err != nil {
+		return &ETLResult{
+			Status:  "error",
+			Message: "Reprocess failed: database initialization failed",
+			Error:   err.Error(),
+		}
 	}
+	defer database.CloseDatabase()
 
-	log.Printf("✅ Data extraction completed. Sources: %d", len(extractedData.Sources))
-	return extractedData, nil
+	batchID := NewBatchID("reprocess")
+	eo.loader.SetBatchID(batchID)
+	eo.loader.SetProject(projectID)
+	eo.loader.SetCampaign("")
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	defer registerRun(batchID, projectID, cancel)()
+
+	result := &ETLResult{
+		Timestamp: startTime.Format(time.RFC3339),
+		BatchID:   batchID,
+		ProjectID: projectID,
+	}
+	defer eo.recordRun("reprocess", result)()
+
+	// The number of stored payloads is not known up front, so the stream
+	// reports the counts of each payload without an overall progress
+	ctx, finishProgress := trackProgress(ctx, "reprocess", result)
+	defer finishProgress()
+
+	settings, err := LoadRunSettings(projectID)
+	if err != nil {
+		result.Status = "error"
+		result.Message = "Reprocess failed: invalid project settings"
+		result.Error = err.Error()
+		result.PipelineDuration = time.Since(startTime).String()
+		return result
+	}
+	result.Settings = &settings
+
+	// Steps 2-3 run once per stored payload; the result keeps only the
+	// transformation summary, as chunked runs do
+	payloads, unreadable := 0, 0
+	sources := make(map[string]int)
+	err = database.StreamRawData(projectID, opts.Source, opts.From, opts.To, func(row database.RawData) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var payload interface{}
+		if err := json.Unmarshal([]byte(row.RawData), &payload); err != nil {
+			log.Printf("⚠️ Skipping unreadable raw payload %d: %v", row.ID, err)
+			unreadable++
+			return nil
+		}
+		payloads++
+		sources[row.Source]++
+
+		run := &campaignRun{
+			query: row.Query,
+			extracted: &ExtractedData{
+				Timestamp: row.ExtractedAt.Format(time.RFC3339),
+				Query:     row.Query,
+				Sources:   map[string]interface{}{row.Source: payload},
+				Stored:    true,
+			},
+		}
+		err := eo.transformAndLoadRun(ctx, run, settings.MinRelevance)
+		result.addStageDurations(run)
+		if run.transformed != nil {
+			result.Transformation = mergeTransformedData(result.Transformation, &TransformedData{
+				Summary:       run.transformed.Summary,
+				Report:        run.transformed.Report,
+				Validation:    run.transformed.Validation,
+				TransformedAt: run.transformed.TransformedAt,
+			})
+		}
+		if run.loaded != nil {
+			result.Loading = mergeLoadResults(result.Loading, run.loaded)
+		}
+		if err != nil {
+			return fmt.Errorf("%s of raw payload %d failed: %w", run.failedStep, row.ID, err)
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		return eo.cancelled(ctx, result, startTime)
+	}
+	if err != nil {
+		result.Status = "error"
+		result.Message = "Reprocess failed"
+		result.Error = err.Error()
+		result.PipelineDuration = time.Since(startTime).String()
+		return result
+	}
+
+	// Step 4: Rebuild the rollups of the days the reprocessed records fall on
+	log.Println("📈 Step 4: Rollup Rebuild of Reprocessed Days")
+	progressFrom(ctx).step("rollups")
+	days, err := database.GetBatchDays(projectID, batchID)
+	if err != nil {
+		log.Printf("⚠️ Daily rollup refresh failed: %v", err)
+	}
+	for _, day := range days {
+		if _, err := database.RefreshDailyRollups(projectID, day); err != nil {
+			log.Printf("⚠️ Daily rollup refresh failed: %v", err)
+			break
+		}
+	}
+
+	eo.warmDashboardCache(projectID)
+	eo.embedRecords(projectID)
+
+	summary := map[string]interface{}{
+		"pipeline_status":     "completed",
+		"payloads":            payloads,
+		"unreadable_payloads": unreadable,
+		"payloads_per_source": sources,
+		"rebuilt_rollup_days": len(days),
+	}
+	if result.Transformation != nil {
+		summary["transformation"] = map[string]interface{}{
+			"videos_count":      result.Transformation.Summary.TotalVideos,
+			"articles_count":    result.Transformation.Summary.TotalArticles,
+			"average_relevance": result.Transformation.Summary.AverageRelevance,
+			"skipped_records":   skippedRecords(result.Transformation),
+			"invalid_records":   invalidRecords(result.Transformation),
+		}
+		if result.Transformation.Validation != nil {
+			summary["validation"] = result.Transformation.Validation
+		}
+	}
+	if result.Loading != nil {
+		summary["loading"] = map[string]interface{}{
+			"success":       result.Loading.Success,
+			"records_count": result.Loading.RecordsCount,
+			"updated_count": result.Loading.UpdatedCount,
+		}
+	}
+	result.Summary = summary
+	result.PipelineDuration = time.Since(startTime).String()
+	result.Status = "success"
+	result.Message = fmt.Sprintf("Reprocess completed: %d raw payloads", payloads)
+
+	log.Printf("✅ Reprocess completed in %s", result.PipelineDuration)
+	return result
 }
 
-// transformData transforms and cleans the extracted data
-func (eo *ETLOrchestrator) transformData(extractedData *ExtractedData) (*TransformedData, error) {
-	log.Println("🔄 Starting data transformation...")
+// archiveDays returns the days crawled articles were published on, sorted.
+// Each day's previous day is included too, since outlets publish local
+// times that may fall on the previous UTC day.
+func archiveDays(archive *ArchiveData) []time.Time {
+	seen := make(map[string]time.Time)
+	for _, article := range archive.Items {
+		published, ok := parseSitemapTime(article.PublishedAt)
+		if !ok {
+			continue
+		}
+		for _, day := range []time.Time{published.UTC(), published.UTC().AddDate(0, 0, -1)} {
+			seen[day.Format("2006-01-02")] = day
+		}
+	}
 
-	// Extract YouTube, news, and Instagram data for transformation
-	var youtubeData, instagramData interface{}
-	var allNewsData []interface{}
+	days := make([]time.Time, 0, len(seen))
+	for _, day := range seen {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
 
-	if source, exists := extractedData.Sources["youtube"]; exists {
-		youtubeData = source
+// campaignRun is the outcome of extracting, transforming and loading the
+// query of one campaign
+type campaignRun struct {
+	name        string // empty when the run has no campaigns
+	query       string
+	extracted   *ExtractedData
+	transformed *TransformedData
+	loaded      *LoadResult
+	failedStep  string // "extraction", "transformation" or "loading" when the campaign failed
+	durations   map[string]time.Duration
+
+	checkpoint  *database.PipelineCheckpoint // nil when the run keeps no checkpoints
+	resumedFrom string                       // stage of the checkpoint the campaign resumed from
+}
+
+// CampaignSummary aggregates the outcome of one campaign of a run
+type CampaignSummary struct {
+	Campaign         string  `json:"campaign"`
+	Query            string  `json:"query"`
+	Sources          int     `json:"sources"`        // sources extracted successfully
+	FailedSources    int     `json:"failed_sources"` // sources that returned an error
+	Videos           int     `json:"videos"`
+	Articles         int     `json:"articles"`
+	AverageRelevance float64 `json:"average_relevance"`
+	SkippedRecords   int     `json:"skipped_records"` // malformed records the transformation skipped
+	InvalidRecords   int     `json:"invalid_records"` // records rejected by the validation stage
+	RecordsLoaded    int     `json:"records_loaded"`
+	ResumedFrom      string  `json:"resumed_from,omitempty"` // stage the campaign had completed before the run was interrupted
+}
+
+// runCampaign extracts the query of a campaign, then transforms and loads
+// its records. It stops between steps when ctx is cancelled. With a
+// checkpoint, the completed stages are recorded in it, and a campaign
+// resumed from an interrupted run skips the stages it had completed.
+func (eo *ETLOrchestrator) runCampaign(ctx context.Context, campaign campaignSettings, checkpoint *database.PipelineCheckpoint) (*campaignRun, error) {
+	run := &campaignRun{name: campaign.name, query: campaign.settings.Query(), checkpoint: checkpoint}
+	eo.loader.SetCampaign(campaign.name)
+	if campaign.name != "" {
+		log.Printf("🎯 Campaign %s: %s", campaign.name, run.query)
 	}
 
-	// Collect all news sources (Indonesia News and Real-Time News)
-	if source, exists := extractedData.Sources["indonesia_news"]; exists {
-		allNewsData = append(allNewsData, source)
+	if checkpoint != nil && checkpoint.Stage != "" {
+		if err := restoreCheckpoint(run); err != nil {
+			log.Printf("⚠️ Cannot resume from the %s checkpoint, starting over: %v", checkpoint.Stage, err)
+			run.extracted, run.transformed, run.loaded, run.resumedFrom = nil, nil, nil, ""
+		} else {
+			log.Printf("⏩ Resuming after the %s stage", checkpoint.Stage)
+		}
 	}
-	if source, exists := extractedData.Sources["google_news"]; exists {
-		allNewsData = append(allNewsData, source)
+	if run.loaded != nil {
+		return run, nil
 	}
 
-	// Extract Instagram data
-	if source, exists := extractedData.Sources["instagram"]; exists {
-		instagramData = source
+	if run.extracted == nil {
+		// Step 1: Extract data from all sources
+		log.Println("📊 Step 1: Data Extraction")
+		extractStart := time.Now()
+		extractedData, err := eo.extractData(ctx, campaign.settings)
+		run.timeStep("extraction", extractStart)
+		if err != nil {
+			run.failedStep = "extraction"
+			return run, err
+		}
+		run.extracted = extractedData
+		if ctx.Err() != nil {
+			return run, nil
+		}
+
+		// Store the payloads right away, so a run interrupted from here on
+		// resumes without calling the sources again
+		if run.checkpoint != nil {
+			ids, stored := eo.loadRawData(extractedData)
+			extractedData.Stored = true
+			if stored {
+				run.checkpoint.RawDataIDs = ids
+				saveCheckpoint(run, stageExtracted)
+			} else {
+				run.checkpoint = nil
+			}
+		}
+	}
+
+	return run, eo.transformAndLoadRun(ctx, run, campaign.settings.MinRelevance)
+}
+
+// transformAndLoadRun runs steps 2 and 3 on the extracted data of a run:
+// the data is transformed and cleaned, then loaded. With a flush size the
+// records are loaded chunk by chunk as they are transformed.
+func (eo *ETLOrchestrator) transformAndLoadRun(ctx context.Context, run *campaignRun, minRelevance float64) error {
+	extractedData := run.extracted
+	progress := progressFrom(ctx)
+	var err error
+	if eo.loadFlushSize > 0 {
+		log.Println("🔄 Steps 2-3: Chunked Data Transformation and Loading")
+		start := time.Now()
+		run.transformed, run.loaded, err = eo.transformAndLoad(ctx, extractedData, minRelevance)
+		run.timeStep("transformation_and_loading", start)
+		if err != nil {
+			run.failedStep = "loading"
+			return err
+		}
+		progress.stageDone("loading", "completed", run.loaded.RecordsCount)
+		if run.loaded.Success {
+			saveCheckpoint(run, stageLoaded)
+		}
+		return nil
+	}
+
+	// A campaign resumed after its transformation loads the stored records
+	transformedData := run.transformed
+	if transformedData == nil {
+		log.Println("🔄 Step 2: Data Transformation")
+		start := time.Now()
+		transformedData, err = eo.transformData(extractedData)
+		run.timeStep("transformation", start)
+		if err != nil {
+			run.failedStep = "transformation"
+			return err
+		}
+		transformedData.Validation = newValidationReport(eo.validationMode)
+		eo.validate(transformedData, transformedData.Validation)
+		if dropped := filterByRelevance(transformedData, minRelevance); dropped > 0 {
+			log.Printf("🔍 Dropped %d records below relevance threshold %.2f", dropped, minRelevance)
+		}
+		run.transformed = transformedData
+		progress.stageDone("transformation", "completed", len(transformedData.YouTube)+len(transformedData.News))
+		saveCheckpoint(run, stageTransformed)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	log.Println("💾 Step 3: Data Loading")
+	start := time.Now()
+	run.loaded, err = eo.loadData(extractedData, transformedData)
+	run.timeStep("loading", start)
+	if err != nil {
+		run.failedStep = "loading"
+		return err
+	}
+	progress.stageDone("loading", "completed", run.loaded.RecordsCount)
+	if run.loaded.Success {
+		saveCheckpoint(run, stageLoaded)
+	}
+	return nil
+}
+
+// validate removes the records of data that fail validation, counting them
+// in report. In quarantine mode they are stored for inspection; failures to
+// store them are logged only, the records are kept out of the load either way.
+func (eo *ETLOrchestrator) validate(data *TransformedData, report *ValidationReport) {
+	if report.Mode == ValidationOff {
+		return
+	}
+
+	invalid := validateRecords(data, report)
+	if len(invalid) == 0 {
+		return
+	}
+	log.Printf("🚧 Rejected %d records failing validation", len(invalid))
+	if report.Mode != ValidationQuarantine {
+		return
+	}
+
+	quarantined, err := eo.loader.QuarantineRecords(invalid)
+	if err != nil {
+		log.Printf("⚠️ Quarantining invalid records failed: %v", err)
+	}
+	report.Quarantined += quarantined
+}
+
+// timeStep adds the time since start to the duration of a step of the run
+func (run *campaignRun) timeStep(step string, start time.Time) {
+	if run.durations == nil {
+		run.durations = make(map[string]time.Duration)
+	}
+	run.durations[step] += time.Since(start)
+}
+
+// addStageDurations adds the step durations of a campaign or stored payload
+// to those of the run
+func (result *ETLResult) addStageDurations(run *campaignRun) {
+	if result.stageDurations == nil {
+		result.stageDurations = make(map[string]time.Duration)
+	}
+	for step, duration := range run.durations {
+		result.stageDurations[step] += duration
+	}
+}
+
+// addCampaignRun adds the outcome of a campaign to the run result
+func (eo *ETLOrchestrator) addCampaignRun(result *ETLResult, run *campaignRun) {
+	result.addStageDurations(run)
+	if run.extracted != nil {
+		for _, summary := range run.extracted.Summaries {
+			summary.Campaign = run.name
+			result.Extraction = append(result.Extraction, summary)
+		}
+		result.PayloadURL = fmt.Sprintf("/api/etl/runs/%s/payload", result.BatchID)
+	}
+	if run.transformed != nil {
+		result.Transformation = mergeTransformedData(result.Transformation, run.transformed)
 	}
+	if run.loaded != nil {
+		result.Loading = mergeLoadResults(result.Loading, run.loaded)
+	}
+	if run.name != "" {
+		result.Campaigns = append(result.Campaigns, run.summary())
+	}
+}
+
+// summary returns the statistics of a campaign
+func (run *campaignRun) summary() CampaignSummary {
+	summary := CampaignSummary{Campaign: run.name, Query: run.query}
+	if run.extracted != nil {
+		for _, source := range run.extracted.Summaries {
+			switch source.Status {
+			case "success":
+				summary.Sources++
+			case "error":
+				summary.FailedSources++
+			}
+		}
+	}
+	if run.transformed != nil {
+		summary.Videos = run.transformed.Summary.TotalVideos
+		summary.Articles = run.transformed.Summary.TotalArticles
+		summary.AverageRelevance = run.transformed.Summary.AverageRelevance
+		summary.SkippedRecords = skippedRecords(run.transformed)
+		summary.InvalidRecords = invalidRecords(run.transformed)
+	}
+	if run.loaded != nil {
+		summary.RecordsLoaded = run.loaded.RecordsCount
+	}
+	summary.ResumedFrom = run.resumedFrom
+	return summary
+}
+
+// mergeTransformedData adds the records and statistics of data to those of
+// the earlier campaigns; into is nil for the first campaign
+func mergeTransformedData(into, data *TransformedData) *TransformedData {
+	if into == nil {
+		return data
+	}
+
+	countBefore := into.Summary.TotalVideos + into.Summary.TotalArticles
+	countAdded := data.Summary.TotalVideos + data.Summary.TotalArticles
+	averageRelevance := 0.0
+	if total := countBefore + countAdded; total > 0 {
+		averageRelevance = (into.Summary.AverageRelevance*float64(countBefore) + data.Summary.AverageRelevance*float64(countAdded)) / float64(total)
+	}
+
+	report := newTransformReport()
+	report.merge(into.Report)
+	report.merge(data.Report)
+
+	var validation *ValidationReport
+	if into.Validation != nil || data.Validation != nil {
+		validation = &ValidationReport{Rules: make(map[string]int)}
+		validation.merge(into.Validation)
+		validation.merge(data.Validation)
+	}
+
+	return &TransformedData{
+		YouTube:    append(into.YouTube, data.YouTube...),
+		News:       append(into.News, data.News...),
+		Report:     report,
+		Validation: validation,
+		Summary: DataSummary{
+			TotalVideos:         into.Summary.TotalVideos + data.Summary.TotalVideos,
+			TotalArticles:       into.Summary.TotalArticles + data.Summary.TotalArticles,
+			AverageRelevance:    averageRelevance,
+			ProcessingTimestamp: data.Summary.ProcessingTimestamp,
+		},
+		TransformedAt: data.TransformedAt,
+	}
+}
+
+// mergeLoadResults adds the load result of a campaign to those of the
+// earlier campaigns; into is nil for the first campaign
+func mergeLoadResults(into, loaded *LoadResult) *LoadResult {
+	if into == nil {
+		return loaded
+	}
+
+	merged := &LoadResult{
+		Success:      into.Success && loaded.Success,
+		Timestamp:    loaded.Timestamp,
+		RecordsCount: into.RecordsCount + loaded.RecordsCount,
+		UpdatedCount: into.UpdatedCount + loaded.UpdatedCount,
+		Error:        into.Error,
+		Destinations: mergeDestinationResults(into.Destinations, loaded.Destinations),
+		Chunks:       append(into.Chunks, loaded.Chunks...),
+	}
+	if loaded.Error != "" {
+		merged.Error = loaded.Error
+	}
+	merged.Message = fmt.Sprintf("Loaded %d records", merged.RecordsCount)
+	return merged
+}
+
+// extractData extracts data from the sources enabled in settings, giving up
+// on sources still running after the configured extraction timeout
+func (eo *ETLOrchestrator) extractData(ctx context.Context, settings RunSettings) (*ExtractedData, error) {
+	log.Println("🔄 Starting data extraction...")
+
+	if eo.extractionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, eo.extractionTimeout)
+		defer cancel()
+	}
+
+	extractedData := eo.extractor.ExtractSources(ctx, settings)
+
+	if extractedData == nil {
+		return nil, fmt.Errorf("data extraction returned nil")
+	}
+
+	log.Printf("✅ Data extraction completed. Sources: %d", len(extractedData.Sources))
+	return extractedData, nil
+}
+
+// transformData transforms and cleans the extracted data
+func (eo *ETLOrchestrator) transformData(extractedData *ExtractedData) (*TransformedData, error) {
+	log.Println("🔄 Starting data transformation...")
 
-	transformedData := eo.transformer.TransformData(youtubeData, allNewsData, instagramData)
+	transformedData := eo.transformer.TransformData(extractedData.Sources)
 
 	if transformedData == nil {
 		return nil, fmt.Errorf("data transformation returned nil")
@@ -163,11 +885,7 @@ func (eo *ETLOrchestrator) transformData(extractedData *ExtractedData) (*Transfo
 func (eo *ETLOrchestrator) loadData(extractedData *ExtractedData, transformedData *TransformedData) (*LoadResult, error) {
 	log.Println("🔄 Starting data loading...")
 
-	// Load raw data to local storage
-	rawLoadResult := eo.loader.LoadRawData(extractedData)
-	if !rawLoadResult.Success {
-		log.Printf("⚠️ Raw data loading failed: %s", rawLoadResult.Error)
-	}
+	eo.loadRawData(extractedData)
 
 	// Load transformed data to local storage
 	processedLoadResult := eo.loader.LoadData(transformedData)
@@ -179,20 +897,179 @@ func (eo *ETLOrchestrator) loadData(extractedData *ExtractedData, transformedDat
 	return processedLoadResult, nil
 }
 
-// createSummary creates a comprehensive summary of the ETL pipeline
-func (eo *ETLOrchestrator) createSummary(extractedData *ExtractedData, transformedData *TransformedData, loadResult *LoadResult) map[string]interface{} {
+// transformAndLoad transforms the extracted data and loads every
+// eo.loadFlushSize records as soon as they are transformed, so the run never
+// holds more than one chunk of transformed records. The returned data holds
+// only the summary, and the load result aggregates the per-chunk results.
+// Chunks loaded before ctx is cancelled stay loaded.
+func (eo *ETLOrchestrator) transformAndLoad(ctx context.Context, extractedData *ExtractedData, minRelevance float64) (*TransformedData, *LoadResult, error) {
+	log.Printf("🔄 Starting chunked transformation and loading (flush size %d)...", eo.loadFlushSize)
+
+	eo.loadRawData(extractedData)
+
+	loadResult := &LoadResult{Success: true}
+	validation := newValidationReport(eo.validationMode)
+	videos, articles, dropped := 0, 0, 0
+	flush := func(chunk *TransformedData) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		eo.validate(chunk, validation)
+		dropped += filterByRelevance(chunk, minRelevance)
+		videos += len(chunk.YouTube)
+		articles += len(chunk.News)
+
+		chunkResult := eo.loader.LoadData(chunk)
+		if !chunkResult.Success {
+			log.Printf("⚠️ Processed data chunk %d loading failed: %s", len(loadResult.Chunks)+1, chunkResult.Error)
+			loadResult.Success = false
+			loadResult.Error = chunkResult.Error
+		}
+		loadResult.RecordsCount += chunkResult.RecordsCount
+		loadResult.UpdatedCount += chunkResult.UpdatedCount
+		loadResult.Destinations = mergeDestinationResults(loadResult.Destinations, chunkResult.Destinations)
+		loadResult.Chunks = append(loadResult.Chunks, *chunkResult)
+		progressFrom(ctx).stageDone("loading", "progress", loadResult.RecordsCount)
+		return nil
+	}
+
+	transformedData, err := eo.transformer.TransformDataInChunks(extractedData.Sources, eo.loadFlushSize, flush)
+
+	if dropped > 0 {
+		log.Printf("🔍 Dropped %d records below relevance threshold %.2f", dropped, minRelevance)
+	}
+	transformedData.Summary.TotalVideos = videos
+	transformedData.Summary.TotalArticles = articles
+	transformedData.Validation = validation
+
+	loadResult.Timestamp = time.Now().Format(time.RFC3339)
+	loadResult.Message = fmt.Sprintf("Loaded %d records in %d chunks", loadResult.RecordsCount, len(loadResult.Chunks))
+	if err != nil {
+		return transformedData, loadResult, err
+	}
+
+	log.Printf("✅ Chunked transformation and loading completed. Videos: %d, Articles: %d, Chunks: %d",
+		videos, articles, len(loadResult.Chunks))
+	return transformedData, loadResult, nil
+}
+
+// loadRawData loads the raw extracted payloads and returns the IDs of the
+// stored rows, and whether every payload was stored. Failures are logged only.
+func (eo *ETLOrchestrator) loadRawData(extractedData *ExtractedData) ([]int, bool) {
+	if extractedData.Stored {
+		return nil, false
+	}
+	rawLoadResult := eo.loader.LoadRawData(extractedData)
+	if !rawLoadResult.Success {
+		log.Printf("⚠️ Raw data loading failed: %s", rawLoadResult.Error)
+	}
+	return rawLoadResult.RawDataIDs, rawLoadResult.Success
+}
+
+// deduplicate regroups the project's recent articles into stories. Failures
+// are logged only; the next run regroups the same window.
+func (eo *ETLOrchestrator) deduplicate(projectID string) {
+	log.Println("🧬 Step 4: Near-duplicate Grouping")
+
+	if _, err := eo.dedup.Deduplicate(projectID, time.Now()); err != nil {
+		log.Printf("⚠️ Near-duplicate grouping failed: %v", err)
+	}
+}
+
+// notifySavedSearches checks freshly loaded records against saved searches.
+// Failures are logged only; notifications never fail the pipeline.
+func (eo *ETLOrchestrator) notifySavedSearches() {
+	log.Println("🔔 Step 5: Saved Search Notifications")
+
+	if _, err := eo.matcher.MatchNewRecords(); err != nil {
+		log.Printf("⚠️ Saved search matching failed: %v", err)
+	}
+}
+
+// warmDashboardCache recomputes the cached dashboard aggregates of a project
+// and drops its cached analytics responses. Failures are logged only; the
+// cache is filled on demand instead.
+func (eo *ETLOrchestrator) warmDashboardCache(projectID string) {
+	log.Println("🔥 Step 6: Dashboard Cache Warm-up")
+
+	services.InvalidateAnalytics(projectID)
+	if err := services.SharedDashboardCache().Warm(projectID); err != nil {
+		log.Printf("⚠️ Dashboard cache warm-up failed: %v", err)
+	}
+}
+
+// refreshRollups recomputes the daily rollups of a project from today back
+// through the deduplication window, whose records may have been regrouped.
+// Failures are logged only; the nightly rollup job rebuilds the days again.
+func (eo *ETLOrchestrator) refreshRollups(projectID string) {
+	log.Println("📈 Step 7: Daily Rollup Refresh")
+
+	now := time.Now()
+	days := int(eo.dedup.Window()/(24*time.Hour)) + 1
+	for i := 0; i < days; i++ {
+		if _, err := database.RefreshDailyRollups(projectID, now.AddDate(0, 0, -i)); err != nil {
+			log.Printf("⚠️ Daily rollup refresh failed: %v", err)
+			return
+		}
+	}
+}
+
+// embedRecords embeds the project's records that have no embedding yet.
+// Failures are logged only; pending records are picked up by the next run.
+func (eo *ETLOrchestrator) embedRecords(projectID string) {
+	embeddings := services.SharedEmbeddingService()
+	if !embeddings.Enabled() {
+		return
+	}
+
+	log.Println("🧭 Step 8: Text Embeddings")
+
+	count, err := embeddings.EmbedPending(projectID)
+	if err != nil {
+		log.Printf("⚠️ Embedding failed after %d records: %v", count, err)
+		return
+	}
+	log.Printf("✅ Embedded %d records", count)
+}
+
+// createSummary creates a comprehensive summary of the ETL pipeline from
+// its campaigns and the merged result
+func (eo *ETLOrchestrator) createSummary(runs []*campaignRun, result *ETLResult) map[string]interface{} {
+	queries := []string{}
+	sources := map[string]bool{}
+	skipped := map[string]bool{}
+	for _, run := range runs {
+		queries = append(queries, run.query)
+		for name := range run.extracted.Sources {
+			sources[name] = true
+		}
+		for _, name := range skippedSourceNames(run.extracted) {
+			skipped[name] = true
+		}
+	}
+	skippedSources := []string{}
+	for name := range skipped {
+		skippedSources = append(skippedSources, name)
+	}
+	sort.Strings(skippedSources)
+
+	transformedData, loadResult := result.Transformation, result.Loading
 	summary := map[string]interface{}{
 		"pipeline_status": "completed",
 		"extraction": map[string]interface{}{
-			"timestamp": extractedData.Timestamp,
-			"query":     extractedData.Query,
-			"sources":   len(extractedData.Sources),
+			"timestamp":       runs[0].extracted.Timestamp,
+			"query":           strings.Join(queries, "; "),
+			"sources":         len(sources),
+			"skipped_sources": skippedSources,
+			"outlets":         outletStats(runs),
 		},
 		"transformation": map[string]interface{}{
 			"timestamp":         transformedData.TransformedAt,
-			"videos_count":      len(transformedData.YouTube),
-			"articles_count":    len(transformedData.News),
+			"videos_count":      transformedData.Summary.TotalVideos,
+			"articles_count":    transformedData.Summary.TotalArticles,
 			"average_relevance": transformedData.Summary.AverageRelevance,
+			"skipped_records":   skippedRecords(transformedData),
+			"invalid_records":   invalidRecords(transformedData),
 		},
 		"loading": map[string]interface{}{
 			"success":       loadResult.Success,
@@ -202,10 +1079,67 @@ func (eo *ETLOrchestrator) createSummary(extractedData *ExtractedData, transform
 		},
 		"load_report": eo.loader.GetLoadReport(),
 	}
+	if transformedData.Validation != nil {
+		summary["validation"] = transformedData.Validation
+	}
+	if len(result.Campaigns) > 0 {
+		summary["campaigns"] = result.Campaigns
+	}
 
 	return summary
 }
 
+// outletStats adds up the stats of each outlet over the campaigns of a run,
+// keyed by source and outlet, e.g. "indonesia_news/kompas". An outlet keeps
+// the last error it failed with.
+func outletStats(runs []*campaignRun) map[string]OutletStats {
+	stats := map[string]OutletStats{}
+	for _, run := range runs {
+		for _, summary := range run.extracted.Summaries {
+			for _, outlet := range summary.Outlets {
+				key := summary.Source + "/" + outlet.Outlet
+				total := stats[key]
+				total.Outlet = outlet.Outlet
+				total.Pages += outlet.Pages
+				total.Items += outlet.Items
+				if outlet.Error != "" {
+					total.Error = outlet.Error
+				}
+				stats[key] = total
+			}
+		}
+	}
+	return stats
+}
+
+// skippedRecords returns how many malformed records the transformation skipped
+func skippedRecords(transformedData *TransformedData) int {
+	if transformedData.Report == nil {
+		return 0
+	}
+	return transformedData.Report.Skipped
+}
+
+// invalidRecords returns how many records the validation stage rejected
+func invalidRecords(transformedData *TransformedData) int {
+	if transformedData.Validation == nil {
+		return 0
+	}
+	return transformedData.Validation.Rejected
+}
+
+// skippedSourceNames lists the sources the extractor skipped because of their health or budget
+func skippedSourceNames(extractedData *ExtractedData) []string {
+	skipped := []string{}
+	for name, data := range extractedData.Sources {
+		if isSkippedSource(data) {
+			skipped = append(skipped, name)
+		}
+	}
+	sort.Strings(skipped)
+	return skipped
+}
+
 // ToJSON converts the ETL result to JSON
 func (er *ETLResult) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(er, "", "  ")
@@ -224,12 +1158,12 @@ func (er *ETLResult) GetPipelineMetrics() map[string]interface{} {
 	}
 
 	if er.Extraction != nil {
-		metrics["extraction_sources"] = len(er.Extraction.Sources)
+		metrics["extraction_sources"] = len(er.Extraction)
 	}
 
 	if er.Transformation != nil {
-		metrics["transformed_videos"] = len(er.Transformation.YouTube)
-		metrics["transformed_articles"] = len(er.Transformation.News)
+		metrics["transformed_videos"] = er.Transformation.Summary.TotalVideos
+		metrics["transformed_articles"] = er.Transformation.Summary.TotalArticles
 	}
 
 	if er.Loading != nil {
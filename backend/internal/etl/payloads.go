@@ -0,0 +1,67 @@
+package etl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+)
+
+// NewsArticle is an item of the news sources. The Real-Time News API, the
+// outlets of the Indonesia news scraper and the archive crawler name their
+// fields differently; each sets the fields it has.
+type NewsArticle struct {
+	ArticleID    string           `json:"article_id,omitempty"`
+	GUID         string           `json:"guid,omitempty"`     // Kompas article ID, for GetNewsDetail
+	IDBerita     string           `json:"idberita,omitempty"` // article ID of the Indonesian outlets
+	Title        string           `json:"title,omitempty"`
+	Summary      string           `json:"summary,omitempty"`
+	Description  string           `json:"description,omitempty"`
+	Snippet      string           `json:"snippet,omitempty"`
+	Content      string           `json:"content,omitempty"`
+	URL          string           `json:"url,omitempty"`
+	Link         string           `json:"link,omitempty"`
+	PhotoURL     string           `json:"photo_url,omitempty"`
+	PublishedAt  string           `json:"published_at,omitempty"`           // local WIB time unless it has an offset
+	PublishedUTC string           `json:"published_datetime_utc,omitempty"` // Real-Time News API
+	Date         *NewsArticleDate `json:"date,omitempty"`                   // detik
+	Author       string           `json:"author,omitempty"`
+	Channel      string           `json:"namakanal,omitempty"`   // outlet of the Indonesia news scraper
+	SourceName   string           `json:"source_name,omitempty"` // publisher of the Real-Time News API and the archive
+}
+
+// NewsArticleDate holds the dates of a detik article
+type NewsArticleDate struct {
+	Publish string `json:"publish,omitempty"`
+}
+
+// decodeJSON decodes the JSON of a source's response or payload into v. A
+// value of the wrong type is left at its zero value and logged instead of
+// failing the whole payload, since the APIs change field types between
+// responses.
+func decodeJSON(source string, r io.Reader, v interface{}) error {
+	err := json.NewDecoder(r).Decode(v)
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		log.Printf("⚠️ %s: ignoring a %s value at %s, expected %s", source, typeErr.Value, typeErr.Field, typeErr.Type)
+		return nil
+	}
+	return err
+}
+
+// decodePayload converts an extracted payload that is not already of its
+// source's type, such as one read back from raw_data, into v. It reports
+// whether the payload could be converted.
+func decodePayload(source string, data interface{}, v interface{}) bool {
+	raw, err := json.Marshal(data)
+	if err == nil {
+		err = decodeJSON(source, bytes.NewReader(raw), v)
+	}
+	if err != nil {
+		log.Printf("⚠️ %s: failed to decode payload: %v", source, err)
+		return false
+	}
+	return true
+}
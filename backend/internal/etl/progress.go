@@ -0,0 +1,252 @@
+package etl
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProgressEvent reports a step of a run in progress to stream subscribers
+type ProgressEvent struct {
+	BatchID   string    `json:"batch_id"`
+	ProjectID string    `json:"project_id"`
+	Kind      string    `json:"kind"`  // "pipeline", "crawl" or "reprocess"
+	Stage     string    `json:"stage"` // "run", "extraction", "transformation", "loading" or a finishing step
+	Status    string    `json:"status"`
+	Campaign  string    `json:"campaign,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Count     int       `json:"count,omitempty"` // records extracted, transformed or loaded
+	Message   string    `json:"message,omitempty"`
+	Progress  float64   `json:"progress"` // share of the run done, 0 to 1; stays 0 for reprocess runs until they end
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Shares of a campaign's slot of the progress reached after each step; the
+// finishing steps after the last campaign start at campaignsShare
+const (
+	extractionShare     = 0.6
+	transformationShare = 0.8
+	campaignsShare      = 0.9
+)
+
+// maxProgressEvents caps the events kept per run for late subscribers
+const maxProgressEvents = 500
+
+// progressBroker fans the events of runs out to subscribers and keeps the
+// events of the runs in progress for subscribers joining late
+type progressBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan ProgressEvent]string // project filter, empty for every project
+	events      map[string][]ProgressEvent    // by batch ID
+}
+
+var runProgress = &progressBroker{
+	subscribers: make(map[chan ProgressEvent]string),
+	events:      make(map[string][]ProgressEvent),
+}
+
+// SubscribeProgress streams the progress events of a project's runs, or of
+// every project's runs when projectID is empty. It returns the events of the
+// runs already in progress, oldest first, and the channel of later events.
+// A subscriber that falls behind misses events rather than slowing runs
+// down; every event carries the overall progress. unsubscribe must be called
+// when the subscriber is done.
+func SubscribeProgress(projectID string) (replay []ProgressEvent, events <-chan ProgressEvent, unsubscribe func()) {
+	ch := make(chan ProgressEvent, 64)
+
+	runProgress.mu.Lock()
+	defer runProgress.mu.Unlock()
+
+	for _, runEvents := range runProgress.events {
+		if projectID == "" || runEvents[0].ProjectID == projectID {
+			replay = append(replay, runEvents...)
+		}
+	}
+	sort.SliceStable(replay, func(i, j int) bool { return replay[i].Timestamp.Before(replay[j].Timestamp) })
+	runProgress.subscribers[ch] = projectID
+
+	return replay, ch, func() {
+		runProgress.mu.Lock()
+		delete(runProgress.subscribers, ch)
+		runProgress.mu.Unlock()
+	}
+}
+
+// publish sends an event to the subscribers of its project. The final event
+// of a run also drops the events kept for it.
+func (b *progressBroker) publish(event ProgressEvent, final bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if final {
+		delete(b.events, event.BatchID)
+	} else if kept := b.events[event.BatchID]; len(kept) < maxProgressEvents {
+		b.events[event.BatchID] = append(kept, event)
+	}
+
+	for ch, projectID := range b.subscribers {
+		if projectID != "" && projectID != event.ProjectID {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// progressContextKey is the context key holding the tracker of a run
+type progressContextKey struct{}
+
+// progressTracker reports the steps of one run. Its methods may be called
+// concurrently, e.g. by the extraction goroutines, and do nothing on a nil
+// tracker, so code shared with untracked callers needs no checks.
+type progressTracker struct {
+	batchID   string
+	projectID string
+	kind      string
+
+	mu        sync.Mutex
+	campaigns int // 0 when the amount of work is not known up front
+	campaign  int
+	name      string
+	sources   int
+	extracted int
+	progress  float64
+}
+
+// trackProgress starts streaming the progress of a run and returns the
+// context its steps report through. finish publishes the outcome of result
+// and must be called when the run ends.
+func trackProgress(ctx context.Context, kind string, result *ETLResult) (context.Context, func()) {
+	tracker := &progressTracker{batchID: result.BatchID, projectID: result.ProjectID, kind: kind}
+	tracker.report(ProgressEvent{Stage: "run", Status: "started"}, false)
+
+	return context.WithValue(ctx, progressContextKey{}, tracker), func() {
+		tracker.mu.Lock()
+		tracker.progress = 1
+		tracker.mu.Unlock()
+		tracker.report(ProgressEvent{Stage: "run", Status: result.Status, Message: result.Message}, true)
+	}
+}
+
+// progressFrom returns the tracker of the run of ctx, or nil
+func progressFrom(ctx context.Context) *progressTracker {
+	tracker, _ := ctx.Value(progressContextKey{}).(*progressTracker)
+	return tracker
+}
+
+// setCampaigns sets how many campaigns the run extracts
+func (t *progressTracker) setCampaigns(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.campaigns = n
+	t.mu.Unlock()
+}
+
+// startCampaign moves the tracker to the campaign with the given index
+func (t *progressTracker) startCampaign(index int, name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.campaign, t.name = index, name
+	t.sources, t.extracted = 0, 0
+	t.mu.Unlock()
+}
+
+// startExtraction reports the number of sources the campaign extracts
+func (t *progressTracker) startExtraction(sources int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.sources = sources
+	t.mu.Unlock()
+	t.report(ProgressEvent{Stage: "extraction", Status: "started", Count: sources}, false)
+}
+
+// sourceDone reports the outcome of extracting or skipping a source;
+// status is "success", "error", "skipped" or "quota_exceeded"
+func (t *progressTracker) sourceDone(summary SourceSummary) {
+	if t == nil {
+		return
+	}
+	if summary.Status != "skipped" && summary.Status != "quota_exceeded" {
+		t.mu.Lock()
+		t.extracted++
+		if t.sources > 0 {
+			t.advance(extractionShare * float64(t.extracted) / float64(t.sources))
+		}
+		t.mu.Unlock()
+	}
+
+	message := summary.Error
+	if message == "" {
+		message = summary.Reason
+	}
+	t.report(ProgressEvent{Stage: "extraction", Status: summary.Status, Source: summary.Source, Count: summary.RecordCount, Message: message}, false)
+}
+
+// stageDone reports a completed transformation or loading step of the
+// campaign, or with status "progress" a loaded chunk, with the record count
+func (t *progressTracker) stageDone(stage, status string, count int) {
+	if t == nil {
+		return
+	}
+	if status == "completed" {
+		share := 1.0
+		if stage == "transformation" {
+			share = transformationShare
+		}
+		t.mu.Lock()
+		t.advance(share)
+		t.mu.Unlock()
+	}
+	t.report(ProgressEvent{Stage: stage, Status: status, Count: count}, false)
+}
+
+// step reports the start of a finishing step after the campaigns
+func (t *progressTracker) step(stage string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.campaigns > 0 && t.progress < campaignsShare {
+		t.progress = campaignsShare
+	}
+	t.mu.Unlock()
+	t.report(ProgressEvent{Stage: stage, Status: "started"}, false)
+}
+
+// advance moves the progress to the given share of the current campaign's
+// slot; it never moves backwards. The caller holds t.mu.
+func (t *progressTracker) advance(share float64) {
+	if t.campaigns == 0 {
+		return
+	}
+	reached := campaignsShare * (float64(t.campaign) + share) / float64(t.campaigns)
+	if reached > t.progress {
+		t.progress = reached
+	}
+}
+
+// report stamps an event with the run and its progress and publishes it
+func (t *progressTracker) report(event ProgressEvent, final bool) {
+	// Publishing under the lock keeps the events of a run in order
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	event.BatchID = t.batchID
+	event.ProjectID = t.projectID
+	event.Kind = t.kind
+	event.Progress = t.progress
+	if event.Stage != "run" {
+		event.Campaign = t.name
+	}
+	event.Timestamp = time.Now()
+	runProgress.publish(event, final)
+}
@@ -0,0 +1,115 @@
+package etl
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// errQuotaExceeded is returned for requests a source's quota no longer allows
+var errQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaUsage is the API usage of a source against its request quota
+type QuotaUsage struct {
+	Source         string `json:"source"`
+	DailyLimit     int    `json:"daily_limit"`   // 0 means unlimited
+	MonthlyLimit   int    `json:"monthly_limit"` // 0 means unlimited
+	CallsToday     int    `json:"calls_today"`
+	CallsThisMonth int    `json:"calls_this_month"`
+	Exceeded       bool   `json:"exceeded"`
+	Reason         string `json:"reason,omitempty"` // which quota is used up
+}
+
+// GetQuotaUsage returns the usage of every source against its configured
+// quota on the current UTC day and calendar month
+func GetQuotaUsage() ([]QuotaUsage, error) {
+	cfg, _ := config.LoadConfig()
+
+	usage := make([]QuotaUsage, 0, len(KnownSources))
+	for _, source := range KnownSources {
+		sourceUsage, err := sourceQuotaUsage(source, cfg.ExternalAPIs.QuotaFor(source), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, sourceUsage)
+	}
+	return usage, nil
+}
+
+// sourceQuotaUsage reads the requests of a source sent on the day and month
+// of at and compares them with its quota
+func sourceQuotaUsage(source string, quota config.QuotaConfig, at time.Time) (QuotaUsage, error) {
+	usage := QuotaUsage{Source: source, DailyLimit: quota.Daily, MonthlyLimit: quota.Monthly}
+
+	var err error
+	usage.CallsToday, usage.CallsThisMonth, err = database.GetAPIUsage(source, at)
+	if err != nil {
+		return usage, err
+	}
+
+	usage.Reason = quotaReason(quota, usage.CallsToday, usage.CallsThisMonth)
+	usage.Exceeded = usage.Reason != ""
+	return usage, nil
+}
+
+// quotaReason returns which quota the calls of a day and month used up, or
+// "" when another request is allowed
+func quotaReason(quota config.QuotaConfig, callsToday, callsThisMonth int) string {
+	switch {
+	case quota.Monthly > 0 && callsThisMonth >= quota.Monthly:
+		return fmt.Sprintf("monthly quota of %d requests used up", quota.Monthly)
+	case quota.Daily > 0 && callsToday >= quota.Daily:
+		return fmt.Sprintf("daily quota of %d requests used up", quota.Daily)
+	}
+	return ""
+}
+
+// quotaExceededReason returns which quota of a source is used up, or "" when
+// it may send requests. Sources without a quota are not looked up; failures
+// to read the usage are logged and let the source run.
+func (de *DataExtractor) quotaExceededReason(source string) string {
+	quota := de.quotas[source]
+	if quota.Daily <= 0 && quota.Monthly <= 0 {
+		return ""
+	}
+
+	usage, err := sourceQuotaUsage(source, quota, time.Now())
+	if err != nil {
+		log.Printf("⚠️ Failed to check the %s quota: %v", source, err)
+		return ""
+	}
+	return usage.Reason
+}
+
+// quotaTransport counts every request of a source that reaches its API and
+// refuses requests once the source's daily or monthly quota is used up.
+// Failures to read or count the usage are logged and let the request through.
+type quotaTransport struct {
+	next   http.RoundTripper
+	quota  config.QuotaConfig
+	source string
+}
+
+// RoundTrip sends the request if the quota allows it and counts it
+func (t *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := time.Now()
+	if t.quota.Daily > 0 || t.quota.Monthly > 0 {
+		usage, err := sourceQuotaUsage(t.source, t.quota, now)
+		if err != nil {
+			log.Printf("⚠️ Failed to check the %s quota: %v", t.source, err)
+		} else if usage.Exceeded {
+			log.Printf("🪫 Refusing %s request to %s: %s", t.source, req.URL.Host, usage.Reason)
+			return nil, fmt.Errorf("%w: %s", errQuotaExceeded, usage.Reason)
+		}
+	}
+
+	if err := database.IncrementAPIUsage(t.source, now); err != nil {
+		log.Printf("⚠️ Failed to count %s request: %v", t.source, err)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,134 @@
+package etl
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"covid19-kms/internal/config"
+)
+
+// tokenBucket allows rate requests per second with bursts of up to burst
+// requests. Reservations may overdraw the bucket, so concurrent callers
+// queue behind each other instead of all retrying at once.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(limit config.RateLimitConfig, now time.Time) *tokenBucket {
+	b := &tokenBucket{last: now}
+	b.configure(limit)
+	b.tokens = b.burst
+	return b
+}
+
+// configure changes the rate and burst, keeping the tokens already saved
+func (b *tokenBucket) configure(limit config.RateLimitConfig) {
+	b.rate = limit.RequestsPerSecond
+	b.burst = float64(limit.Burst)
+	if b.burst < 1 {
+		b.burst = 1
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// release returns the token of a reservation that was not used
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// wait blocks until a token is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.release()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// hostLimiters holds one bucket per API host, shared by every extractor
+// client of the process so concurrent extractions draw from one quota
+var hostLimiters = struct {
+	sync.Mutex
+	buckets map[string]*tokenBucket
+}{buckets: make(map[string]*tokenBucket)}
+
+// hostLimiter returns the bucket of host, applying limit to it
+func hostLimiter(host string, limit config.RateLimitConfig) *tokenBucket {
+	hostLimiters.Lock()
+	defer hostLimiters.Unlock()
+
+	bucket, ok := hostLimiters.buckets[host]
+	if !ok {
+		bucket = newTokenBucket(limit, time.Now())
+		hostLimiters.buckets[host] = bucket
+		return bucket
+	}
+	bucket.mu.Lock()
+	bucket.configure(limit)
+	bucket.mu.Unlock()
+	return bucket
+}
+
+// rateLimitedTransport waits for the bucket of the request's host before
+// passing the request on
+type rateLimitedTransport struct {
+	next   http.RoundTripper
+	limit  config.RateLimitConfig
+	source string
+}
+
+// RoundTrip waits for a token of the request's host, then sends the request
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	bucket := hostLimiter(req.URL.Host, t.limit)
+	if err := bucket.wait(req.Context()); err != nil {
+		log.Printf("⏳ %s request to %s cancelled while rate limited: %v", t.source, req.URL.Host, err)
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
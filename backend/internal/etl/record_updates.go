@@ -0,0 +1,187 @@
+package etl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// maxArticlePageBytes bounds how much of an article page is read
+const maxArticlePageBytes = 5 * 1024 * 1024
+
+var (
+	ogTitlePattern   = regexp.MustCompile(`(?is)<meta[^>]+property=["']og:title["'][^>]*content=["']([^"']*)["']`)
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	articlePattern   = regexp.MustCompile(`(?is)<article[^>]*>(.*?)</article>`)
+	paragraphPattern = regexp.MustCompile(`(?is)<p[^>]*>(.*?)</p>`)
+	scriptPattern    = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	tagPattern       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// UpdateCheckResult summarizes a pass of article update checks
+type UpdateCheckResult struct {
+	Checked int `json:"checked"` // articles fetched again
+	Changed int `json:"changed"` // articles whose title or content changed
+	First   int `json:"first"`   // articles fetched for the first time, stored as version 1
+	Failed  int `json:"failed"`  // articles that could not be fetched
+}
+
+// ArticleSnapshot is the headline and body text of an article page
+type ArticleSnapshot struct {
+	Title   string
+	Content string
+}
+
+// Digest identifies the snapshot's text, so unchanged pages are recognized
+// without comparing their contents
+func (s ArticleSnapshot) Digest() string {
+	sum := sha256.Sum256([]byte(s.Title + "\n" + s.Content))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseArticlePage extracts the headline and body text of an article page:
+// the og:title or <title>, and the paragraphs of its <article> element, or
+// of the whole page when it has none
+func parseArticlePage(page string) ArticleSnapshot {
+	page = scriptPattern.ReplaceAllString(page, " ")
+
+	var snapshot ArticleSnapshot
+	if match := ogTitlePattern.FindStringSubmatch(page); match != nil {
+		snapshot.Title = cleanHTMLText(match[1])
+	} else if match := titlePattern.FindStringSubmatch(page); match != nil {
+		snapshot.Title = cleanHTMLText(match[1])
+	}
+
+	body := page
+	if match := articlePattern.FindStringSubmatch(page); match != nil {
+		body = match[1]
+	}
+	var paragraphs []string
+	for _, match := range paragraphPattern.FindAllStringSubmatch(body, -1) {
+		if text := cleanHTMLText(match[1]); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	snapshot.Content = strings.Join(paragraphs, "\n")
+	return snapshot
+}
+
+// cleanHTMLText strips tags and entities from an HTML fragment and
+// normalizes its whitespace
+func cleanHTMLText(fragment string) string {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(fragment, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// fetchArticle fetches an article page and extracts its snapshot
+func fetchArticle(ctx context.Context, client *http.Client, url string) (ArticleSnapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return ArticleSnapshot{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ArticleSnapshot{}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ArticleSnapshot{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxArticlePageBytes))
+	if err != nil {
+		return ArticleSnapshot{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	snapshot := parseArticlePage(string(page))
+	if snapshot.Title == "" && snapshot.Content == "" {
+		return ArticleSnapshot{}, fmt.Errorf("page has no title or paragraphs")
+	}
+	return snapshot, nil
+}
+
+// CheckRecordUpdates fetches again the articles due for an update check and
+// stores a new version of each one whose title or content changed since its
+// last fetch. The first fetch of an article is its baseline version; the
+// ingested text is not compared, since sources often deliver only a snippet.
+func CheckRecordUpdates(ctx context.Context) (*UpdateCheckResult, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	checks := cfg.ETL.UpdateCheck
+
+	// A disabled schedule still lets on-demand checks cover a day
+	interval := checks.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	now := time.Now()
+	targets, err := database.GetUpdateCheckTargets(now.Add(-checks.MaxAge), now.Add(-interval), checks.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: checks.Timeout}
+	result := &UpdateCheckResult{}
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		snapshot, err := fetchArticle(ctx, client, target.URL)
+		if err != nil {
+			log.Printf("⚠️ Update check of record %d failed: %v", target.RecordID, err)
+			result.Failed++
+			if err := database.MarkRecordChecked(target.RecordID); err != nil {
+				return result, err
+			}
+			continue
+		}
+		result.Checked++
+
+		previous, err := database.GetLatestRecordVersion(target.RecordID)
+		if err != nil {
+			return result, err
+		}
+
+		version := &database.RecordVersion{
+			RecordID:      target.RecordID,
+			Title:         snapshot.Title,
+			Content:       snapshot.Content,
+			ContentDigest: snapshot.Digest(),
+		}
+		switch {
+		case previous == nil:
+			result.First++
+		case previous.ContentDigest == version.ContentDigest:
+			if err := database.MarkRecordChecked(target.RecordID); err != nil {
+				return result, err
+			}
+			continue
+		default:
+			version.TitleChanged = previous.Title != version.Title
+			version.ContentChanged = previous.Content != version.Content
+			result.Changed++
+		}
+
+		if err := database.AddRecordVersion(version); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,209 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// RunInfo describes an ETL run that is currently in progress
+type RunInfo struct {
+	BatchID   string    `json:"batch_id"`
+	ProjectID string    `json:"project_id"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// activeRun is a run in progress and the function cancelling it
+type activeRun struct {
+	info   RunInfo
+	cancel context.CancelCauseFunc
+}
+
+// errShutdown is the cancellation cause of runs cancelled by CancelAllRuns
+var errShutdown = errors.New("server shutting down")
+
+var (
+	activeRunsMu sync.Mutex
+	activeRuns   = make(map[string]activeRun)
+	runsDone     sync.WaitGroup
+)
+
+// registerRun records a run in progress so it can be cancelled by batch ID.
+// The returned function removes it again and must be called when the run ends.
+func registerRun(batchID, projectID string, cancel context.CancelCauseFunc) func() {
+	activeRunsMu.Lock()
+	activeRuns[batchID] = activeRun{
+		info:   RunInfo{BatchID: batchID, ProjectID: projectID, StartedAt: time.Now()},
+		cancel: cancel,
+	}
+	activeRunsMu.Unlock()
+	runsDone.Add(1)
+
+	return func() {
+		activeRunsMu.Lock()
+		delete(activeRuns, batchID)
+		activeRunsMu.Unlock()
+		runsDone.Done()
+	}
+}
+
+// CancelRun cancels the run with the given batch ID. It reports false when no
+// such run is in progress.
+func CancelRun(batchID string) bool {
+	activeRunsMu.Lock()
+	defer activeRunsMu.Unlock()
+
+	run, ok := activeRuns[batchID]
+	if ok {
+		run.cancel(nil)
+	}
+	return ok
+}
+
+// CancelAllRuns cancels every run in progress on server shutdown and returns
+// how many were cancelled. Pipeline runs cancelled this way keep their stage
+// checkpoints, so the next run of their project resumes them.
+func CancelAllRuns() int {
+	activeRunsMu.Lock()
+	defer activeRunsMu.Unlock()
+
+	for _, run := range activeRuns {
+		run.cancel(errShutdown)
+	}
+	return len(activeRuns)
+}
+
+// WaitForRuns waits up to timeout for the runs in progress to end, e.g. for
+// cancelled runs to save their checkpoints and outcome, and reports whether
+// they all did
+func WaitForRuns(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		runsDone.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// activeBatchIDs returns the batch IDs of a project's runs in progress
+func activeBatchIDs(projectID string) map[string]bool {
+	activeRunsMu.Lock()
+	defer activeRunsMu.Unlock()
+
+	batchIDs := make(map[string]bool)
+	for batchID, run := range activeRuns {
+		if run.info.ProjectID == projectID {
+			batchIDs[batchID] = true
+		}
+	}
+	return batchIDs
+}
+
+// ActiveRuns lists the runs in progress, oldest first
+func ActiveRuns() []RunInfo {
+	activeRunsMu.Lock()
+	defer activeRunsMu.Unlock()
+
+	runs := make([]RunInfo, 0, len(activeRuns))
+	for _, run := range activeRuns {
+		runs = append(runs, run.info)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	return runs
+}
+
+// recordRun adds a run to the pipeline run history as running. The returned
+// function stores the outcome and stage metrics of result, then checks the
+// run for alerts, and must be called when the run ends. History failures are
+// logged only.
+func (eo *ETLOrchestrator) recordRun(kind string, result *ETLResult) func() {
+	run := &database.PipelineRun{
+		BatchID:   result.BatchID,
+		ProjectID: result.ProjectID,
+		Kind:      kind,
+		Status:    "running",
+	}
+	if err := database.CreatePipelineRun(run); err != nil {
+		log.Printf("⚠️ Failed to record pipeline run: %v", err)
+		return func() {}
+	}
+
+	return func() {
+		completedAt := time.Now()
+		run.Status = result.Status
+		if run.Status == "" {
+			run.Status = "error"
+		}
+		run.Message = result.Message
+		run.Error = result.Error
+		run.Metrics = runMetrics(result)
+		run.CompletedAt = &completedAt
+		if err := database.FinishPipelineRun(run); err != nil {
+			log.Printf("⚠️ Failed to record pipeline run outcome: %v", err)
+		}
+		eo.alerts.CheckRun(run, emptySources(result))
+	}
+}
+
+// emptySources lists the sources a run extracted no records from in any of
+// its campaigns, leaving out sources skipped for their health, budget or quota
+func emptySources(result *ETLResult) []string {
+	records := make(map[string]int)
+	for _, summary := range result.Extraction {
+		if summary.Status == "skipped" || summary.Status == "quota_exceeded" {
+			continue
+		}
+		records[summary.Source] += summary.RecordCount
+	}
+
+	var sources []string
+	for source, count := range records {
+		if count == 0 {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// runMetrics collects the metrics of the stages a run got through
+func runMetrics(result *ETLResult) map[string]interface{} {
+	metrics := map[string]interface{}{
+		"duration": result.PipelineDuration,
+	}
+	if len(result.stageDurations) > 0 {
+		durations := make(map[string]string, len(result.stageDurations))
+		for step, duration := range result.stageDurations {
+			durations[step] = duration.String()
+		}
+		metrics["stage_durations"] = durations
+	}
+	if len(result.Extraction) > 0 {
+		metrics["extraction"] = result.Extraction
+	}
+	if result.Transformation != nil {
+		metrics["transformation"] = result.Transformation.Summary
+	}
+	if result.Loading != nil {
+		metrics["loading"] = map[string]interface{}{
+			"success":       result.Loading.Success,
+			"records_count": result.Loading.RecordsCount,
+			"updated_count": result.Loading.UpdatedCount,
+		}
+	}
+	if len(result.Campaigns) > 0 {
+		metrics["campaigns"] = result.Campaigns
+	}
+	return metrics
+}
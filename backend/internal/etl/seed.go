@@ -0,0 +1,244 @@
+package etl
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// DemoBatchID marks the records loaded by SeedDemoData, so seeding again
+// replaces them instead of adding duplicates
+const DemoBatchID = "seed_demo_v1"
+
+//go:embed data/demo_dataset.json
+var demoDatasetJSON []byte
+
+// demoDataset is the bundled sample of anonymized texts the demo records are
+// generated from
+type demoDataset struct {
+	Version     int                          `json:"version"`
+	Description string                       `json:"description"`
+	Days        int                          `json:"days"`
+	PeakDay     int                          `json:"peak_day"`
+	Regions     []string                     `json:"regions"`
+	Sources     map[string]demoDatasetSource `json:"sources"`
+}
+
+// demoDatasetSource holds the sample texts of one source. News texts are
+// "title|body"; {region} is replaced with a province.
+type demoDatasetSource struct {
+	Share    float64  `json:"share"`
+	Videos   []string `json:"videos,omitempty"`
+	Outlets  []string `json:"outlets,omitempty"`
+	Accounts int      `json:"accounts,omitempty"`
+	Texts    []string `json:"texts"`
+}
+
+// demoSources fixes the order sources are drawn in, so the same seed always
+// produces the same records
+var demoSources = []string{"youtube", "google_news", "instagram", "indonesia_news"}
+
+// SeedOptions configures SeedDemoData
+type SeedOptions struct {
+	ProjectID string
+	Records   int       // number of records to generate
+	End       time.Time // day of the newest records; zero uses today
+	Seed      int64     // random seed; the same seed reproduces the same dataset
+
+	// Store receives the records instead of PostgreSQL when set. Earlier demo
+	// records are then updated in place rather than replaced, and no daily
+	// rollups are computed.
+	Store database.ProcessedDataRepo
+}
+
+// SeedResult reports what SeedDemoData loaded
+type SeedResult struct {
+	BatchID  string         `json:"batch_id"`
+	Records  map[string]int `json:"records"` // generated records per source
+	Replaced int64          `json:"replaced"`
+	From     string         `json:"from"` // YYYY-MM-DD
+	To       string         `json:"to"`   // YYYY-MM-DD
+}
+
+// SeedDemoData loads the bundled demo dataset into a project. The records go
+// through the regular transformers and loader, so sentiment, topics and tags
+// are computed as for extracted data. The timeline keeps its shape (a wave
+// peaking mid-period, quieter weekends) but ends on opts.End, and earlier demo
+// records of the project are replaced.
+func SeedDemoData(opts SeedOptions) (*SeedResult, error) {
+	var dataset demoDataset
+	if err := json.Unmarshal(demoDatasetJSON, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse demo dataset: %v", err)
+	}
+	if opts.Records <= 0 {
+		return nil, fmt.Errorf("record count must be positive")
+	}
+
+	end := opts.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -(dataset.Days - 1))
+
+	replaced := map[string]int64{}
+	if opts.Store == nil {
+		var err error
+		if replaced, err = database.PurgeBatch(opts.ProjectID, DemoBatchID); err != nil {
+			return nil, err
+		}
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	dayWeights := demoDayWeights(dataset, start)
+	dt := NewDataTransformer()
+	data := &TransformedData{TransformedAt: time.Now().Format(time.RFC3339)}
+	result := &SeedResult{
+		BatchID:  DemoBatchID,
+		Records:  make(map[string]int),
+		Replaced: replaced["processed_data"],
+		From:     start.Format("2006-01-02"),
+		To:       end.Format("2006-01-02"),
+	}
+
+	for i := 0; i < opts.Records; i++ {
+		source := pickDemoSource(rng, dataset)
+		day := pickWeighted(rng, dayWeights)
+		publishedAt := start.AddDate(0, 0, day).Add(time.Duration(6*3600+rng.Intn(17*3600)) * time.Second)
+
+		src := dataset.Sources[source]
+		text := strings.ReplaceAll(src.Texts[rng.Intn(len(src.Texts))], "{region}", dataset.Regions[rng.Intn(len(dataset.Regions))])
+		id := fmt.Sprintf("seed_%s_%05d", source, i)
+
+		switch source {
+		case "youtube":
+			video := demoVideo(rng, src, dataset.Regions)
+			comment := YouTubeComment{
+				CommentID: id,
+				Author:    YouTubeAuthor{Title: fmt.Sprintf("viewer_%03d", rng.Intn(500))},
+				Content:   text,
+				Stats:     YouTubeCommentStats{Replies: rng.Intn(20), Votes: rng.Intn(300)},
+			}
+			transformed := dt.transformYouTubeComment(comment, video)
+			transformed.ID = id
+			transformed.PublishedAt = publishedAt.Format(time.RFC3339)
+			data.YouTube = append(data.YouTube, *transformed)
+		case "instagram":
+			post := InstagramPost{
+				Code:         id,
+				CaptionText:  text,
+				LikeCount:    rng.Intn(2000),
+				CommentCount: rng.Intn(150),
+				User:         &InstagramUser{Username: fmt.Sprintf("warga_%03d", rng.Intn(src.Accounts))},
+				TakenAt:      publishedAt.Unix(),
+			}
+			transformed := dt.transformInstagramPost(post)
+			transformed.ID = id
+			data.News = append(data.News, *transformed)
+		default:
+			title, body, _ := strings.Cut(text, "|")
+			outlet := src.Outlets[rng.Intn(len(src.Outlets))]
+			item := NewsArticle{
+				Title:   title,
+				Summary: body,
+				URL:     fmt.Sprintf("https://news.example.org/%s/%s", strings.ToLower(strings.ReplaceAll(outlet, " ", "-")), id),
+			}
+			if source == "google_news" {
+				item.ArticleID = id
+				item.SourceName = outlet
+			} else {
+				item.IDBerita = id
+				item.Channel = outlet
+			}
+			transformed := dt.transformNewsItem(item, source)
+			transformed.ID = id
+			transformed.PublishedAt = publishedAt.Format(time.RFC3339)
+			data.News = append(data.News, *transformed)
+		}
+		result.Records[source]++
+	}
+
+	data.Summary = dt.createSummary(data.YouTube, data.News)
+
+	loader := NewDataLoader()
+	loader.SetBatchID(DemoBatchID)
+	loader.SetProject(opts.ProjectID)
+	if opts.Store != nil {
+		loader.SetStore(opts.Store)
+	}
+	if load := loader.LoadData(data); !load.Success {
+		return nil, fmt.Errorf("failed to load demo data: %s", load.Error)
+	}
+	if opts.Store != nil {
+		return result, nil
+	}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if _, err := database.RefreshDailyRollups(opts.ProjectID, day); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// demoDayWeights returns the relative volume of each day: a baseline with a
+// wave around the peak day and fewer posts on weekends
+func demoDayWeights(dataset demoDataset, start time.Time) []float64 {
+	weights := make([]float64, dataset.Days)
+	for day := range weights {
+		distance := float64(day-dataset.PeakDay) / 12
+		weights[day] = 1 + 3*math.Exp(-distance*distance)
+		if weekday := start.AddDate(0, 0, day).Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+			weights[day] *= 0.7
+		}
+	}
+	return weights
+}
+
+// pickDemoSource draws a source according to the dataset's source shares
+func pickDemoSource(rng *rand.Rand, dataset demoDataset) string {
+	weights := make([]float64, len(demoSources))
+	for i, source := range demoSources {
+		weights[i] = dataset.Sources[source].Share
+	}
+	return demoSources[pickWeighted(rng, weights)]
+}
+
+// pickWeighted draws an index with probability proportional to its weight
+func pickWeighted(rng *rand.Rand, weights []float64) int {
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+	target := rng.Float64() * total
+	for i, weight := range weights {
+		if target < weight {
+			return i
+		}
+		target -= weight
+	}
+	return len(weights) - 1
+}
+
+// demoVideo builds the video a demo comment was posted under
+func demoVideo(rng *rand.Rand, src demoDatasetSource, regions []string) YouTubeVideo {
+	index := rng.Intn(len(src.Videos))
+	title := strings.ReplaceAll(src.Videos[index], "{region}", regions[index%len(regions)])
+	videoID := fmt.Sprintf("seedvideo%02d", index)
+	return YouTubeVideo{
+		Title:    title,
+		VideoID:  videoID,
+		URL:      "https://www.youtube.com/watch?v=" + videoID,
+		Views:    strconv.Itoa(10000 * (index + 1)),
+		Duration: "10:00",
+		Author:   fmt.Sprintf("channel_%02d", index),
+	}
+}
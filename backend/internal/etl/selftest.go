@@ -0,0 +1,239 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// Self-test check statuses, from best to worst
+const (
+	SelfTestPass = "pass"
+	SelfTestWarn = "warn"
+	SelfTestFail = "fail"
+)
+
+// selfTestRank orders the statuses so a report takes its worst check's
+var selfTestRank = map[string]int{SelfTestPass: 0, SelfTestWarn: 1, SelfTestFail: 2}
+
+// SelfTestCheck is the outcome of one self-test check
+type SelfTestCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"` // "pass", "warn" or "fail"
+	Message  string `json:"message"`
+	Duration string `json:"duration"`
+}
+
+// SelfTestReport lists the self-test checks; its status is that of the
+// worst check
+type SelfTestReport struct {
+	Status    string          `json:"status"`
+	Timestamp string          `json:"timestamp"`
+	Checks    []SelfTestCheck `json:"checks"`
+}
+
+// SelfTestOptions select the checks of a self-test
+type SelfTestOptions struct {
+	// SkipExternalAPIs leaves out the source API probes, each of which
+	// spends one request of the source's quota
+	SkipExternalAPIs bool
+	Timeout          time.Duration // per probe request
+}
+
+// sourceProbes are the cheapest search requests of each source, which
+// check that the source API is reachable and accepts the RapidAPI key
+var sourceProbes = map[string]struct {
+	host func(apis config.ExternalAPIsConfig) string
+	path string
+}{
+	"youtube": {
+		host: func(apis config.ExternalAPIsConfig) string { return apis.YouTube.Host },
+		path: "/search/?q=covid",
+	},
+	"google_news": {
+		host: func(apis config.ExternalAPIsConfig) string { return apis.GoogleNews.Host },
+		path: "/search?query=covid&limit=1",
+	},
+	"instagram": {
+		host: func(apis config.ExternalAPIsConfig) string { return apis.Instagram.Host },
+		path: "/v1/hashtag/medias/top/recent/chunk?name=covid19",
+	},
+	"indonesia_news": {
+		host: func(apis config.ExternalAPIsConfig) string { return apis.IndonesiaNews.Host },
+		path: "/search/kompas?command=covid&page=1&limit=1",
+	},
+}
+
+// RunSelfTest checks that the service is ready for its first run: the
+// configuration is complete and valid, the database is reachable and at the
+// schema version of this build, and the enabled source APIs answer with the
+// configured key. Every check runs even when an earlier one fails.
+func RunSelfTest(ctx context.Context, opts SelfTestOptions) *SelfTestReport {
+	report := &SelfTestReport{Timestamp: time.Now().Format(time.RFC3339)}
+
+	cfg, err := config.LoadConfig()
+	report.run("config", func() (string, string) {
+		if err != nil {
+			return SelfTestFail, fmt.Sprintf("failed to load configuration: %v", err)
+		}
+		return checkConfig(cfg)
+	})
+	if err != nil {
+		return report.finish()
+	}
+
+	report.run("database", checkDatabase)
+
+	if !opts.SkipExternalAPIs {
+		client := &http.Client{Timeout: opts.Timeout}
+		for _, source := range cfg.ETL.Sources {
+			probe, ok := sourceProbes[source]
+			if !ok || !cfg.ETL.SourceEnabled(source) {
+				continue
+			}
+			host := probe.host(cfg.ExternalAPIs)
+			report.run("api:"+source, func() (string, string) {
+				return checkSourceAPI(ctx, client, host, probe.path)
+			})
+		}
+		if cfg.Database.HasDestination("elasticsearch") {
+			report.run("elasticsearch", func() (string, string) {
+				return checkElasticsearch(cfg.Database.Elasticsearch)
+			})
+		}
+	}
+
+	return report.finish()
+}
+
+// run times a check and adds its outcome to the report
+func (r *SelfTestReport) run(name string, check func() (status, message string)) {
+	start := time.Now()
+	status, message := check()
+	r.Checks = append(r.Checks, SelfTestCheck{
+		Name:     name,
+		Status:   status,
+		Message:  message,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+	})
+}
+
+// finish sets the report status to that of its worst check
+func (r *SelfTestReport) finish() *SelfTestReport {
+	r.Status = SelfTestPass
+	for _, check := range r.Checks {
+		if selfTestRank[check.Status] > selfTestRank[r.Status] {
+			r.Status = check.Status
+		}
+	}
+	return r
+}
+
+// WriteText writes the report as a readable table, one check per line
+func (r *SelfTestReport) WriteText(w io.Writer) {
+	for _, check := range r.Checks {
+		fmt.Fprintf(w, "[%s] %-22s %s (%s)\n", strings.ToUpper(check.Status), check.Name, check.Message, check.Duration)
+	}
+	fmt.Fprintf(w, "\nSelf-test result: %s\n", strings.ToUpper(r.Status))
+}
+
+// checkConfig validates the configuration
+func checkConfig(cfg *config.Config) (string, string) {
+	err := cfg.Validate()
+	var invalid config.ValidationErrors
+	if errors.As(err, &invalid) {
+		return SelfTestFail, invalid.Error()
+	}
+	if err != nil {
+		return SelfTestFail, err.Error()
+	}
+	return SelfTestPass, "configuration is valid"
+}
+
+// checkDatabase connects to the database and compares its schema version
+// with the version this build expects
+func checkDatabase() (string, string) {
+	if os.Getenv("SKIP_DATABASE") == "true" {
+		return SelfTestWarn, "database is skipped (SKIP_DATABASE=true)"
+	}
+	// The server's connection is reused; the CLI opens one for the check
+	if database.EnsureConnection() != nil {
+		if err := database.InitDatabase(); err != nil {
+			return SelfTestFail, err.Error()
+		}
+		defer database.CloseDatabase()
+	}
+
+	version, err := database.SchemaVersion()
+	if err != nil {
+		return SelfTestFail, err.Error()
+	}
+	latest := database.LatestSchemaVersion()
+	switch {
+	case version < latest:
+		return SelfTestFail, fmt.Sprintf("schema is at version %d, this build expects %d; run 'covidkms db migrate'", version, latest)
+	case version > latest:
+		return SelfTestWarn, fmt.Sprintf("schema is at version %d, newer than the %d this build expects", version, latest)
+	}
+	return SelfTestPass, fmt.Sprintf("connected, schema is at version %d", version)
+}
+
+// checkSourceAPI sends a source's probe request with the RapidAPI key and
+// judges the answer: a rejected key fails, a spent quota only warns
+func checkSourceAPI(ctx context.Context, client *http.Client, host, path string) (string, string) {
+	key := os.Getenv("RAPIDAPI_KEY")
+	if key == "" {
+		return SelfTestFail, "RAPIDAPI_KEY is not set"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://"+host+path, nil)
+	if err != nil {
+		return SelfTestFail, fmt.Sprintf("failed to create request: %v", err)
+	}
+	req.Header.Set("x-rapidapi-key", key)
+	req.Header.Set("x-rapidapi-host", host)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && urlErr.Timeout() {
+			return SelfTestFail, fmt.Sprintf("%s did not answer in time", host)
+		}
+		return SelfTestFail, fmt.Sprintf("%s is unreachable: %v", host, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return SelfTestPass, fmt.Sprintf("%s accepted the key", host)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return SelfTestFail, fmt.Sprintf("%s rejected the key (HTTP %d); check RAPIDAPI_KEY and the API subscription", host, resp.StatusCode)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return SelfTestWarn, fmt.Sprintf("%s accepted the key but its quota is spent (HTTP 429)", host)
+	}
+	return SelfTestWarn, fmt.Sprintf("%s answered HTTP %d", host, resp.StatusCode)
+}
+
+// checkElasticsearch checks that the Elasticsearch cluster answers with the
+// configured credentials
+func checkElasticsearch(cfg config.ElasticsearchConfig) (string, string) {
+	resp, err := NewElasticsearchLoader(cfg).do("GET", "/", "", nil)
+	if err != nil {
+		return SelfTestFail, fmt.Sprintf("%s is unreachable: %v", cfg.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return SelfTestFail, elasticsearchError(resp)
+	}
+	return SelfTestPass, fmt.Sprintf("%s answered", cfg.URL)
+}
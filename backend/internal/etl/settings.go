@@ -0,0 +1,261 @@
+package etl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// RunSettings are the effective pipeline settings of a run: the global ETL
+// configuration with a project's overrides merged on top
+type RunSettings struct {
+	Keywords         []string        `json:"keywords"`
+	Sources          map[string]bool `json:"sources"`
+	MinRelevance     float64         `json:"min_relevance"`
+	ScheduleInterval time.Duration   `json:"schedule_interval"`
+
+	// DisabledSources are the sources turned off by SOURCES_ENABLED or by
+	// the run's source selection, with the reason; runs report them as skipped
+	DisabledSources map[string]string `json:"disabled_sources,omitempty"`
+
+	// Campaigns are the named queries of the run; when empty the keywords are run as one query
+	Campaigns []config.CampaignConfig `json:"campaigns,omitempty"`
+}
+
+// KnownSources lists the source names that can be toggled
+var KnownSources = []string{"youtube", "google_news", "instagram", "indonesia_news"}
+
+var hashtagCleaner = regexp.MustCompile(`[^a-z0-9_]`)
+
+// DefaultRunSettings returns the settings from the global configuration only
+func DefaultRunSettings() RunSettings {
+	cfg, _ := config.LoadConfig()
+	settings, _ := ResolveRunSettings(cfg.ETL, nil)
+	return settings
+}
+
+// LoadRunSettings merges a project's stored overrides over the global configuration
+func LoadRunSettings(projectID string) (RunSettings, error) {
+	cfg, _ := config.LoadConfig()
+
+	stored, err := database.GetProjectSettings(projectID)
+	if err != nil {
+		return RunSettings{}, err
+	}
+
+	var overrides *database.ProjectOverrides
+	if stored != nil {
+		overrides = &stored.Overrides
+	}
+	return ResolveRunSettings(cfg.ETL, overrides)
+}
+
+// ResolveRunSettings merges overrides over the global ETL configuration.
+// Overrides may be nil. An error is returned for invalid override values.
+func ResolveRunSettings(cfg config.ETLConfig, overrides *database.ProjectOverrides) (RunSettings, error) {
+	settings := RunSettings{
+		Keywords:         cfg.Keywords,
+		Sources:          make(map[string]bool),
+		MinRelevance:     cfg.MinRelevance,
+		ScheduleInterval: cfg.ScheduleInterval,
+		Campaigns:        cfg.Campaigns,
+	}
+	for _, source := range cfg.Sources {
+		settings.Sources[source] = true
+	}
+	// Sources turned off by SOURCES_ENABLED stay off whatever a project enables
+	for _, source := range KnownSources {
+		if !cfg.SourceEnabled(source) {
+			settings.disable(source, "disabled by SOURCES_ENABLED")
+		}
+	}
+
+	if overrides == nil {
+		return settings, nil
+	}
+
+	// A project tracking its own keywords runs them instead of the global campaigns
+	if len(overrides.Keywords) > 0 {
+		settings.Keywords = overrides.Keywords
+		settings.Campaigns = nil
+	}
+	for source, enabled := range overrides.Sources {
+		if !isKnownSource(source) {
+			return settings, fmt.Errorf("unknown source %q", source)
+		}
+		if settings.DisabledSources[source] == "" {
+			settings.Sources[source] = enabled
+		}
+	}
+	if overrides.MinRelevance != nil {
+		if *overrides.MinRelevance < 0 || *overrides.MinRelevance > 1 {
+			return settings, fmt.Errorf("min_relevance must be between 0 and 1")
+		}
+		settings.MinRelevance = *overrides.MinRelevance
+	}
+	if overrides.ScheduleInterval != "" {
+		interval, err := time.ParseDuration(overrides.ScheduleInterval)
+		if err != nil || interval < 0 {
+			return settings, fmt.Errorf("invalid schedule_interval %q", overrides.ScheduleInterval)
+		}
+		settings.ScheduleInterval = interval
+	}
+
+	return settings, nil
+}
+
+// SourceEnabled reports whether a source should be extracted
+func (rs RunSettings) SourceEnabled(source string) bool {
+	return rs.Sources[source]
+}
+
+// PipelineOptions select the sources of a single pipeline run
+type PipelineOptions struct {
+	Sources []string // sources the run extracts instead of the project's; empty keeps the project's
+	Skip    []string // sources the run leaves out
+}
+
+// Validate rejects unknown source names
+func (opts PipelineOptions) Validate() error {
+	for _, source := range append(append([]string{}, opts.Sources...), opts.Skip...) {
+		if !isKnownSource(source) {
+			return fmt.Errorf("unknown source %q", source)
+		}
+	}
+	return nil
+}
+
+// selectSources applies the source selection of a run: with opts.Sources
+// only those sources are extracted, and none of opts.Skip. Sources disabled
+// by SOURCES_ENABLED stay disabled.
+func (rs *RunSettings) selectSources(opts PipelineOptions) {
+	if len(opts.Sources) > 0 {
+		selected := make(map[string]bool, len(opts.Sources))
+		for _, source := range opts.Sources {
+			selected[source] = true
+		}
+		for _, source := range KnownSources {
+			switch {
+			case rs.DisabledSources[source] != "":
+			case selected[source]:
+				rs.Sources[source] = true
+			case rs.Sources[source]:
+				rs.disable(source, "not selected for this run")
+			}
+		}
+	}
+	for _, source := range opts.Skip {
+		if rs.Sources[source] {
+			rs.disable(source, "skipped for this run")
+		}
+	}
+}
+
+// disable turns a source off for the given reason
+func (rs *RunSettings) disable(source, reason string) {
+	rs.Sources[source] = false
+	if rs.DisabledSources == nil {
+		rs.DisabledSources = make(map[string]string)
+	}
+	rs.DisabledSources[source] = reason
+}
+
+// campaignSettings are the settings of one campaign of a run
+type campaignSettings struct {
+	name     string // empty when the run has no campaigns
+	settings RunSettings
+}
+
+// forCampaigns returns the settings of each campaign of the run, which use
+// the campaign's keywords. A run without campaigns is a single unnamed one.
+func (rs RunSettings) forCampaigns() []campaignSettings {
+	if len(rs.Campaigns) == 0 {
+		return []campaignSettings{{settings: rs}}
+	}
+
+	campaigns := make([]campaignSettings, 0, len(rs.Campaigns))
+	for _, campaign := range rs.Campaigns {
+		settings := rs
+		settings.Keywords = campaign.Keywords
+		settings.Campaigns = nil
+		campaigns = append(campaigns, campaignSettings{name: campaign.Name, settings: settings})
+	}
+	return campaigns
+}
+
+// Query returns the news search query built from the keywords
+func (rs RunSettings) Query() string {
+	if len(rs.Keywords) == 0 {
+		return "COVID-19"
+	}
+	return strings.Join(rs.Keywords, " OR ")
+}
+
+// Hashtag returns the Instagram hashtag for the first keyword, e.g. "COVID-19" -> "covid19"
+func (rs RunSettings) Hashtag() string {
+	if len(rs.Keywords) == 0 {
+		return "covid19"
+	}
+	return hashtagCleaner.ReplaceAllString(strings.ToLower(rs.Keywords[0]), "")
+}
+
+// Hashtags returns the hashtag of the keywords followed by the configured
+// hashtags, without duplicates
+func (rs RunSettings) Hashtags(configured []string) []string {
+	hashtags := []string{rs.Hashtag()}
+	seen := map[string]bool{rs.Hashtag(): true}
+	for _, hashtag := range configured {
+		hashtag = strings.ToLower(strings.TrimPrefix(hashtag, "#"))
+		if hashtag != "" && !seen[hashtag] {
+			seen[hashtag] = true
+			hashtags = append(hashtags, hashtag)
+		}
+	}
+	return hashtags
+}
+
+// isKnownSource reports whether name is one of KnownSources
+func isKnownSource(name string) bool {
+	for _, source := range KnownSources {
+		if source == name {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByRelevance drops transformed records scoring below minRelevance
+func filterByRelevance(data *TransformedData, minRelevance float64) int {
+	if minRelevance <= 0 {
+		return 0
+	}
+
+	dropped := 0
+	videos := data.YouTube[:0]
+	for _, video := range data.YouTube {
+		if video.CovidRelevanceScore < minRelevance {
+			dropped++
+			continue
+		}
+		videos = append(videos, video)
+	}
+	data.YouTube = videos
+
+	articles := data.News[:0]
+	for _, article := range data.News {
+		if article.CovidRelevanceScore < minRelevance {
+			dropped++
+			continue
+		}
+		articles = append(articles, article)
+	}
+	data.News = articles
+	data.Summary.TotalVideos = len(data.YouTube)
+	data.Summary.TotalArticles = len(data.News)
+
+	return dropped
+}
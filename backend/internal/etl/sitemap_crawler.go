@@ -0,0 +1,512 @@
+package etl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/internal/config"
+)
+
+// maxSitemapBytes bounds how much of a sitemap is read
+const maxSitemapBytes = 50 * 1024 * 1024
+
+var (
+	publishedTimePattern = regexp.MustCompile(`(?is)<meta[^>]+property=["']article:published_time["'][^>]*content=["']([^"']*)["']`)
+	sitemapYearPattern   = regexp.MustCompile(`(?:^|[^0-9])(20[0-9]{2})(?:[^0-9]|$)`)
+)
+
+// NewCrawlOptions returns the options of a crawl of every configured outlet
+// over the configured date range
+func NewCrawlOptions(cfg config.CrawlerConfig) CrawlOptions {
+	from, _ := time.Parse("2006-01-02", cfg.From)
+	to, _ := time.Parse("2006-01-02", cfg.To)
+	return CrawlOptions{From: from, To: to, MaxArticles: cfg.MaxArticles}
+}
+
+// CrawlOptions narrows a crawl of the configured sitemaps
+type CrawlOptions struct {
+	Outlets     []string  // outlets to crawl; empty crawls every configured outlet
+	From        time.Time // earliest publication day
+	To          time.Time // latest publication day, inclusive
+	MaxArticles int
+}
+
+// ArchiveData is the payload of a crawl: articles in the shape of the news
+// sources' items, so the news transformer adapter handles them
+type ArchiveData struct {
+	Items           []NewsArticle `json:"items"`
+	Sitemaps        int           `json:"sitemaps"`         // sitemaps read
+	DisallowedPages int           `json:"disallowed_pages"` // pages robots.txt disallows
+	FailedPages     int           `json:"failed_pages"`     // sitemaps and articles that could not be fetched
+}
+
+// SitemapCrawler walks outlet sitemaps for tagged articles published in a
+// date range and fetches them, as a backfill path for periods the news APIs
+// cannot search. It honours robots.txt, including Crawl-delay, and the
+// configured per-host rate limit.
+type SitemapCrawler struct {
+	Config config.CrawlerConfig
+	Client *http.Client
+
+	robots map[string]*robotsRules // by host
+}
+
+// NewSitemapCrawler creates a new sitemap crawler
+func NewSitemapCrawler(cfg config.CrawlerConfig) *SitemapCrawler {
+	return &SitemapCrawler{
+		Config: cfg,
+		Client: &http.Client{Timeout: cfg.Timeout},
+		robots: make(map[string]*robotsRules),
+	}
+}
+
+// sitemapDocument is a sitemap index or URL set. Elements are matched by
+// local name, so the news and image extensions need no namespaces.
+type sitemapDocument struct {
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+	URLs []sitemapURL `xml:"url"`
+}
+
+// sitemapURL is a page of a URL set, with its Google News extension
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+	News    struct {
+		PublicationDate string `xml:"publication_date"`
+		Title           string `xml:"title"`
+		Keywords        string `xml:"keywords"`
+	} `xml:"news"`
+}
+
+// Crawl walks the sitemaps of the selected outlets and returns the tagged
+// articles published between opts.From and opts.To, at most opts.MaxArticles.
+// Pages that cannot be fetched are counted and skipped; the error is set for
+// unknown outlets and when ctx is done.
+func (c *SitemapCrawler) Crawl(ctx context.Context, opts CrawlOptions) (*ArchiveData, error) {
+	outlets := opts.Outlets
+	if len(outlets) == 0 {
+		for outlet := range c.Config.Sitemaps {
+			outlets = append(outlets, outlet)
+		}
+	}
+
+	data := &ArchiveData{Items: []NewsArticle{}}
+	if len(outlets) == 0 {
+		return data, fmt.Errorf("no outlet sitemaps are configured (CRAWLER_SITEMAPS)")
+	}
+	for _, outlet := range outlets {
+		if _, ok := c.Config.Sitemaps[outlet]; !ok {
+			return data, fmt.Errorf("outlet %q has no sitemap in CRAWLER_SITEMAPS", outlet)
+		}
+	}
+	sort.Strings(outlets)
+
+	seen := make(map[string]bool)
+	for _, outlet := range outlets {
+		sitemap := c.Config.Sitemaps[outlet]
+
+		log.Printf("🕸️ Crawling %s sitemap %s", outlet, sitemap)
+		pages := c.collectPages(ctx, sitemap, opts, data, 0)
+		for _, page := range pages {
+			if len(data.Items) >= opts.MaxArticles {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return data, err
+			}
+			// Outlets list articles in several sitemaps, e.g. by day and by section
+			if seen[page.Loc] {
+				continue
+			}
+			seen[page.Loc] = true
+
+			item, err := c.fetchArticleItem(ctx, page, outlet, opts)
+			if err != nil {
+				log.Printf("⚠️ Failed to fetch %s: %v", page.Loc, err)
+				data.FailedPages++
+				continue
+			}
+			if item != nil {
+				data.Items = append(data.Items, *item)
+			}
+		}
+		log.Printf("🕸️ Crawled %d articles so far (%d disallowed, %d failed)", len(data.Items), data.DisallowedPages, data.FailedPages)
+	}
+
+	return data, ctx.Err()
+}
+
+// collectPages reads a sitemap, following sitemap indexes into the child
+// sitemaps that may cover the date range, and returns its tagged pages
+// published in the range
+func (c *SitemapCrawler) collectPages(ctx context.Context, sitemap string, opts CrawlOptions, data *ArchiveData, depth int) []sitemapURL {
+	if depth > 3 || ctx.Err() != nil {
+		return nil
+	}
+
+	doc, err := c.fetchSitemap(ctx, sitemap)
+	if err != nil {
+		log.Printf("⚠️ Failed to read sitemap %s: %v", sitemap, err)
+		data.FailedPages++
+		return nil
+	}
+	if doc == nil {
+		data.DisallowedPages++
+		return nil
+	}
+	data.Sitemaps++
+
+	var pages []sitemapURL
+	for _, child := range doc.Sitemaps {
+		if !sitemapMayCover(child.Loc, child.LastMod, opts.From, opts.To) {
+			continue
+		}
+		pages = append(pages, c.collectPages(ctx, strings.TrimSpace(child.Loc), opts, data, depth+1)...)
+		if len(pages) >= opts.MaxArticles {
+			return pages
+		}
+	}
+	for _, page := range doc.URLs {
+		page.Loc = strings.TrimSpace(page.Loc)
+		if !pageInRange(page, opts.From, opts.To) || !c.tagged(page.Loc+" "+page.News.Title+" "+page.News.Keywords) {
+			continue
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// sitemapMayCover reports whether a child sitemap can hold pages published
+// in the range: one last modified before the range cannot, and neither can
+// one whose URL names a year outside the range, e.g. sitemap-2019-05.xml
+func sitemapMayCover(loc, lastMod string, from, to time.Time) bool {
+	if modified, ok := parseSitemapTime(lastMod); ok && modified.Before(from) {
+		return false
+	}
+	if match := sitemapYearPattern.FindStringSubmatch(loc); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		if year < from.Year() || year > to.Year() {
+			return false
+		}
+	}
+	return true
+}
+
+// pageInRange reports whether a page was published in the range, by its
+// news publication date or else its last modification. Pages without a
+// date are kept; the article's own date decides after fetching.
+func pageInRange(page sitemapURL, from, to time.Time) bool {
+	date := page.News.PublicationDate
+	if date == "" {
+		date = page.LastMod
+	}
+	published, ok := parseSitemapTime(date)
+	if !ok {
+		return true
+	}
+	return !published.Before(from) && published.Before(to.AddDate(0, 0, 1))
+}
+
+// tagged reports whether text mentions one of the configured tags; URL
+// slugs separate words with hyphens
+func (c *SitemapCrawler) tagged(text string) bool {
+	text = strings.ToLower(text)
+	for _, tag := range c.Config.Tags {
+		tag = strings.ToLower(tag)
+		if strings.Contains(text, tag) || strings.Contains(text, strings.ReplaceAll(tag, " ", "-")) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSitemapTime parses the W3C datetime of a sitemap: a date, or a date
+// and time with a zone
+func parseSitemapTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fetchSitemap fetches and decodes a sitemap, gzip-compressed or not. It
+// returns nil without an error when robots.txt disallows the sitemap.
+func (c *SitemapCrawler) fetchSitemap(ctx context.Context, sitemap string) (*sitemapDocument, error) {
+	body, err := c.get(ctx, sitemap, maxSitemapBytes)
+	if err != nil || body == nil {
+		return nil, err
+	}
+
+	var reader io.Reader = bytes.NewReader(body)
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+		defer gz.Close()
+		reader = io.LimitReader(gz, maxSitemapBytes)
+	}
+
+	var doc sitemapDocument
+	if err := xml.NewDecoder(reader).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode sitemap: %w", err)
+	}
+	return &doc, nil
+}
+
+// fetchArticleItem fetches an article page and returns it as a news item,
+// or nil when robots.txt disallows it or it was published outside the range
+func (c *SitemapCrawler) fetchArticleItem(ctx context.Context, page sitemapURL, outlet string, opts CrawlOptions) (*NewsArticle, error) {
+	body, err := c.get(ctx, page.Loc, maxArticlePageBytes)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, nil
+	}
+
+	snapshot := parseArticlePage(string(body))
+	if snapshot.Title == "" {
+		snapshot.Title = page.News.Title
+	}
+	if snapshot.Title == "" && snapshot.Content == "" {
+		return nil, fmt.Errorf("page has no title or paragraphs")
+	}
+
+	published := page.News.PublicationDate
+	if match := publishedTimePattern.FindStringSubmatch(string(body)); match != nil {
+		published = match[1]
+	}
+	if published == "" {
+		published = page.LastMod
+	}
+	if !pageInRange(sitemapURL{LastMod: published}, opts.From, opts.To) {
+		return nil, nil
+	}
+
+	return &NewsArticle{
+		Title:       snapshot.Title,
+		Content:     snapshot.Content,
+		URL:         page.Loc,
+		PublishedAt: published,
+		SourceName:  outlet,
+	}, nil
+}
+
+// get fetches a URL after checking robots.txt and waiting for the host's
+// rate limit. It returns nil without an error when robots.txt disallows it.
+func (c *SitemapCrawler) get(ctx context.Context, rawURL string, maxBytes int64) ([]byte, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q", rawURL)
+	}
+
+	rules := c.robotsFor(ctx, target)
+	if !rules.allowed(target.RequestURI()) {
+		return nil, nil
+	}
+	if err := c.wait(ctx, target.Host, rules.crawlDelay); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.Config.UserAgent)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// wait blocks until the host's rate limit, slowed to its Crawl-delay, lets
+// another request through
+func (c *SitemapCrawler) wait(ctx context.Context, host string, crawlDelay time.Duration) error {
+	limit := c.Config.RateLimit
+	if crawlDelay > 0 {
+		if perSecond := 1 / crawlDelay.Seconds(); perSecond < limit.RequestsPerSecond {
+			limit.RequestsPerSecond = perSecond
+		}
+	}
+	return hostLimiter("crawler:"+host, limit).wait(ctx)
+}
+
+// robotsFor returns the robots.txt rules of a URL's host for the crawler's
+// user agent, fetching them once per host. A missing or unreachable
+// robots.txt allows everything; a server error disallows everything until
+// the next crawl (RFC 9309 section 2.3.1.4).
+func (c *SitemapCrawler) robotsFor(ctx context.Context, target *url.URL) *robotsRules {
+	if rules, ok := c.robots[target.Host]; ok {
+		return rules
+	}
+
+	rules := &robotsRules{}
+	c.robots[target.Host] = rules
+
+	robotsURL := target.Scheme + "://" + target.Host + "/robots.txt"
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return rules
+	}
+	req.Header.Set("User-Agent", c.Config.UserAgent)
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		log.Printf("⚠️ Failed to fetch %s: %v", robotsURL, err)
+		return rules
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		*rules = parseRobots(io.LimitReader(resp.Body, 512*1024), c.Config.UserAgent)
+	case resp.StatusCode >= 500:
+		log.Printf("⚠️ %s answered %d, treating the host as disallowed", robotsURL, resp.StatusCode)
+		rules.rules = []robotsRule{{allow: false, pattern: "/"}}
+	}
+	return rules
+}
+
+// robotsRules are the robots.txt rules that apply to the crawler
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsRule allows or disallows the paths matching a pattern
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// parseRobots reads the rules of the group naming the user agent's product
+// token, matched whole and case-insensitively, or of the "*" group when no
+// group names it
+func parseRobots(r io.Reader, userAgent string) robotsRules {
+	product := strings.ToLower(strings.SplitN(userAgent, "/", 2)[0])
+
+	var specific, general robotsRules
+	var foundSpecific bool
+	var current []*robotsRules // groups the current record applies to
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+			agent := strings.ToLower(value)
+			if agent == "*" {
+				current = append(current, &general)
+			} else if agent == product {
+				current = append(current, &specific)
+				foundSpecific = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			for _, group := range current {
+				// An empty Disallow allows everything and adds no rule
+				if value != "" {
+					group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+				}
+			}
+		case "crawl-delay":
+			inAgents = false
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				for _, group := range current {
+					group.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if foundSpecific {
+		return specific
+	}
+	return general
+}
+
+// allowed reports whether a path may be fetched: the longest matching rule
+// decides, and Allow wins a tie
+func (r *robotsRules) allowed(path string) bool {
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// robotsMatch matches a path against a robots.txt pattern, where "*"
+// matches any characters and a trailing "$" anchors the end
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if anchored && rest != "" {
+		// A later occurrence of the last part may end the path
+		last := parts[len(parts)-1]
+		return len(parts) > 1 && strings.HasSuffix(path, last)
+	}
+	return true
+}
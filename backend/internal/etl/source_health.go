@@ -0,0 +1,158 @@
+package etl
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"covid19-kms/internal/config"
+)
+
+// SourceHealth tracks the health and daily usage of each extraction source.
+// It is a simple circuit breaker: after a number of consecutive failures the
+// source is skipped until a cooldown has passed, after which one attempt is
+// allowed again. Usage is counted per UTC day against an optional budget.
+type SourceHealth struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	budget    int
+	sources   map[string]*SourceStatus
+}
+
+// SourceStatus is the health state of one source
+type SourceStatus struct {
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	BreakerOpen         bool       `json:"breaker_open"`
+	UsageDay            string     `json:"usage_day"`
+	UsageCount          int        `json:"usage_count"`
+}
+
+var (
+	sharedSourceHealth     *SourceHealth
+	sharedSourceHealthOnce sync.Once
+)
+
+// NewSourceHealth creates a new source health tracker
+func NewSourceHealth(cfg config.ETLConfig) *SourceHealth {
+	return &SourceHealth{
+		threshold: cfg.BreakerThreshold,
+		cooldown:  cfg.BreakerCooldown,
+		budget:    cfg.DailyBudget,
+		sources:   make(map[string]*SourceStatus),
+	}
+}
+
+// SharedSourceHealth returns the process-wide tracker shared by all extractors
+func SharedSourceHealth() *SourceHealth {
+	sharedSourceHealthOnce.Do(func() {
+		cfg, _ := config.LoadConfig()
+		sharedSourceHealth = NewSourceHealth(cfg.ETL)
+	})
+	return sharedSourceHealth
+}
+
+// SkipReason returns why a source should not be extracted now, or "" if it may run
+func (sh *SourceHealth) SkipReason(source string) string {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	status := sh.status(source)
+	if status.BreakerOpen {
+		if status.LastFailure != nil && time.Since(*status.LastFailure) < sh.cooldown {
+			return fmt.Sprintf("circuit breaker open after %d consecutive failures", status.ConsecutiveFailures)
+		}
+	}
+	if sh.budget > 0 && status.UsageDay == today() && status.UsageCount >= sh.budget {
+		return fmt.Sprintf("daily budget of %d extractions exhausted", sh.budget)
+	}
+
+	return ""
+}
+
+// RecordAttempt counts an extraction against the source's daily budget
+func (sh *SourceHealth) RecordAttempt(source string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	status := sh.status(source)
+	if day := today(); status.UsageDay != day {
+		status.UsageDay = day
+		status.UsageCount = 0
+	}
+	status.UsageCount++
+}
+
+// RecordSuccess closes the source's breaker
+func (sh *SourceHealth) RecordSuccess(source string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := time.Now()
+	status := sh.status(source)
+	status.ConsecutiveFailures = 0
+	status.BreakerOpen = false
+	status.LastSuccess = &now
+}
+
+// RecordFailure counts a failure and opens the breaker once the threshold is reached
+func (sh *SourceHealth) RecordFailure(source string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := time.Now()
+	status := sh.status(source)
+	status.ConsecutiveFailures++
+	status.LastFailure = &now
+	if sh.threshold > 0 && status.ConsecutiveFailures >= sh.threshold {
+		status.BreakerOpen = true
+	}
+}
+
+// ConsecutiveFailures returns the number of failures since the source last succeeded
+func (sh *SourceHealth) ConsecutiveFailures(source string) int {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	return sh.status(source).ConsecutiveFailures
+}
+
+// Snapshot returns a copy of the state of every tracked source
+func (sh *SourceHealth) Snapshot() map[string]SourceStatus {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	snapshot := make(map[string]SourceStatus, len(sh.sources))
+	for name, status := range sh.sources {
+		snapshot[name] = *status
+	}
+	return snapshot
+}
+
+// status returns the state of a source, creating it if needed. The caller must hold the lock.
+func (sh *SourceHealth) status(source string) *SourceStatus {
+	status, ok := sh.sources[source]
+	if !ok {
+		status = &SourceStatus{}
+		sh.sources[source] = status
+	}
+	return status
+}
+
+// skippedSource is the extraction result recorded for a source that was not called
+func skippedSource(reason string) map[string]string {
+	return map[string]string{"status": "skipped", "reason": reason}
+}
+
+// isSkippedSource reports whether an extraction result marks a skipped source
+func isSkippedSource(data interface{}) bool {
+	marker, ok := data.(map[string]string)
+	return ok && marker["status"] == "skipped"
+}
+
+// today returns the current UTC date
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
@@ -0,0 +1,69 @@
+package etl
+
+import "context"
+
+// registeredSource pairs a source's extractor with the transformer adapter
+// for its payloads, so every record is attributed to the source that
+// extracted it instead of being guessed from its fields
+type registeredSource struct {
+	name string
+	// extract returns either the source payload and its record count, or an
+	// error map; nil for sources fed by the archive crawler instead of runs
+	extract func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int)
+	// transform turns an extracted payload of the source into records
+	transform func(dt *DataTransformer, data interface{}, out *transformCollector)
+}
+
+// registeredSources lists the sources in the order their payloads are transformed
+var registeredSources = []registeredSource{
+	{
+		name: "youtube",
+		extract: func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int) {
+			return de.extractYouTubeSource(ctx, settings.Query())
+		},
+		transform: (*DataTransformer).transformYouTubeData,
+	},
+	{
+		name: "indonesia_news",
+		extract: func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int) {
+			return de.extractIndonesiaNewsSource(ctx, settings.Query())
+		},
+		transform: (*DataTransformer).transformIndonesiaNewsData,
+	},
+	{
+		name: "google_news",
+		extract: func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int) {
+			return de.extractGoogleNewsSource(ctx, settings.Query())
+		},
+		transform: (*DataTransformer).transformGoogleNewsData,
+	},
+	{
+		name: "instagram",
+		extract: func(de *DataExtractor, ctx context.Context, settings RunSettings) (interface{}, int) {
+			return de.extractInstagramSource(ctx, settings.Hashtags(de.instagramHashtags))
+		},
+		transform: (*DataTransformer).transformInstagramData,
+	},
+	{
+		name:      "news_archive",
+		transform: (*DataTransformer).transformArchiveData,
+	},
+}
+
+// IsRegisteredSource reports whether name is a registered source, i.e. a
+// source name raw payloads are stored under
+func IsRegisteredSource(name string) bool {
+	for _, source := range registeredSources {
+		if source.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newsSourceLabels are the Source labels of articles from the news sources
+var newsSourceLabels = map[string]string{
+	"google_news":    "Real-Time News",
+	"indonesia_news": "Indonesia News",
+	"news_archive":   "News Archive",
+}
@@ -4,15 +4,28 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
+	"covid19-kms/internal/config"
 	"covid19-kms/internal/services"
 )
 
 // DataTransformer handles data cleaning, transformation, and enrichment
 type DataTransformer struct {
-	covidKeywords []string
+	relevanceScorer  *services.RelevanceScorer
+	languageDetector *services.LanguageDetector
+	// sentimentAnalyzer scores every item before it is loaded
+	sentimentAnalyzer *services.SentimentAnalyzer
+	// sentimentProvider scores the items of each chunk again when it is a
+	// remote model; the keyword scores stay when it fails
+	sentimentProvider services.SentimentProvider
+	// toxicityScorer rates social comments; nil when toxicity scoring is disabled
+	toxicityScorer services.ToxicityScorer
+	// instagramLocations maps configured Instagram location IDs to their province
+	instagramLocations map[string]string
 }
 
 // TransformedData represents the structure of transformed data
@@ -20,45 +33,180 @@ type TransformedData struct {
 	YouTube       []TransformedVideo   `json:"youtube"`
 	News          []TransformedArticle `json:"news"`
 	Summary       DataSummary          `json:"summary"`
+	Report        *TransformReport     `json:"report,omitempty"`     // records skipped by the transformation; not set on chunks
+	Validation    *ValidationReport    `json:"validation,omitempty"` // records checked before loading; not set on chunks
 	TransformedAt string               `json:"transformed_at"`
 }
 
 // TransformedVideo represents a transformed YouTube video
 type TransformedVideo struct {
-	ID                  string                 `json:"id"`
-	Title               string                 `json:"title"`
-	Description         string                 `json:"description"`
-	PublishedAt         string                 `json:"published_at"`
-	ChannelTitle        string                 `json:"channel_title"`
-	ThumbnailURL        string                 `json:"thumbnail_url"`
-	Source              string                 `json:"source"`
-	CovidRelevanceScore float64                `json:"covid_relevance_score"`
-	Language            string                 `json:"language"`
-	WordCount           int                    `json:"word_count"`
-	ExtractedAt         string                 `json:"extracted_at"`
-	TransformedAt       string                 `json:"transformed_at"`
-	Sentiment           string                 `json:"sentiment"`
-	SentimentScore      float64                `json:"sentiment_score"`
-	SentimentConfidence float64                `json:"sentiment_confidence"`
-	Metadata            map[string]interface{} `json:"metadata,omitempty"`
+	ID                  string                      `json:"id"`
+	Title               string                      `json:"title"`
+	Description         string                      `json:"description"`
+	PublishedAt         string                      `json:"published_at"`
+	ChannelTitle        string                      `json:"channel_title"`
+	ThumbnailURL        string                      `json:"thumbnail_url"`
+	Source              string                      `json:"source"`
+	CovidRelevanceScore float64                     `json:"covid_relevance_score"`
+	Language            string                      `json:"language"`
+	WordCount           int                         `json:"word_count"`
+	ExtractedAt         string                      `json:"extracted_at"`
+	TransformedAt       string                      `json:"transformed_at"`
+	Sentiment           string                      `json:"sentiment"`
+	SentimentScore      float64                     `json:"sentiment_score"`
+	SentimentConfidence float64                     `json:"sentiment_confidence"`
+	Aspects             services.AspectScores       `json:"aspects,omitempty"`
+	SentenceSentiment   *services.SentenceSentiment `json:"sentence_sentiment,omitempty"`
+	SentimentProvider   string                      `json:"sentiment_provider,omitempty"`
+	Sarcastic           bool                        `json:"sarcastic,omitempty"`      // likely sarcastic social comment
+	ToxicityScore       *float64                    `json:"toxicity_score,omitempty"` // nil when toxicity scoring is disabled
+	Topics              []string                    `json:"topics,omitempty"`         // topics from services.ClassifyTopics, strongest first
+	Entities            []services.Entity           `json:"entities,omitempty"`       // people, places and organizations from services.ExtractEntities
+	Category            string                      `json:"category,omitempty"`       // strongest topic, the rollups' topic dimension
+	Region              string                      `json:"region,omitempty"`         // province, the rollups' province dimension
+	Metadata            map[string]interface{}      `json:"metadata,omitempty"`
+
+	// sentimentText is the text the sentiment was scored on; SentimentProvider
+	// names the remote provider that scored it again, if any
+	sentimentText string
 }
 
 // TransformedArticle represents a transformed news article
 type TransformedArticle struct {
-	ID                  string  `json:"id"`
-	Title               string  `json:"title"`
-	Description         string  `json:"description"`
-	Content             string  `json:"content"`
-	URL                 string  `json:"url"`
-	Source              string  `json:"source"`
-	CovidRelevanceScore float64 `json:"covid_relevance_score"`
-	Language            string  `json:"language"`
-	WordCount           int     `json:"word_count"`
-	ExtractedAt         string  `json:"extracted_at"`
-	TransformedAt       string  `json:"transformed_at"`
-	Sentiment           string  `json:"sentiment"`
-	SentimentScore      float64 `json:"sentiment_score"`
-	SentimentConfidence float64 `json:"sentiment_confidence"`
+	ID                  string                      `json:"id"`
+	Title               string                      `json:"title"`
+	Description         string                      `json:"description"`
+	Content             string                      `json:"content"`
+	URL                 string                      `json:"url"`
+	PublishedAt         string                      `json:"published_at,omitempty"` // RFC3339 when the source provides a date
+	Source              string                      `json:"source"`                 // display label, e.g. "Instagram (@user)"
+	SourceKey           string                      `json:"source_key"`             // registered source that extracted the record
+	Outlet              string                      `json:"outlet,omitempty"`       // publisher or account, mapped through the source taxonomy
+	CovidRelevanceScore float64                     `json:"covid_relevance_score"`
+	Language            string                      `json:"language"`
+	WordCount           int                         `json:"word_count"`
+	ExtractedAt         string                      `json:"extracted_at"`
+	TransformedAt       string                      `json:"transformed_at"`
+	Sentiment           string                      `json:"sentiment"`
+	SentimentScore      float64                     `json:"sentiment_score"`
+	SentimentConfidence float64                     `json:"sentiment_confidence"`
+	Aspects             services.AspectScores       `json:"aspects,omitempty"`
+	SentenceSentiment   *services.SentenceSentiment `json:"sentence_sentiment,omitempty"`
+	SentimentProvider   string                      `json:"sentiment_provider,omitempty"`
+	Sarcastic           bool                        `json:"sarcastic,omitempty"`      // likely sarcastic social comment
+	ToxicityScore       *float64                    `json:"toxicity_score,omitempty"` // nil when toxicity scoring is disabled
+	Topics              []string                    `json:"topics,omitempty"`         // topics from services.ClassifyTopics, strongest first
+	Entities            []services.Entity           `json:"entities,omitempty"`       // people, places and organizations from services.ExtractEntities
+	Category            string                      `json:"category,omitempty"`       // strongest topic, the rollups' topic dimension
+	Location            *PostLocation               `json:"location,omitempty"`       // place a post is tagged with
+	Hashtag             string                      `json:"hashtag,omitempty"`        // hashtag whose feed a post was found in
+	ContentType         string                      `json:"content_type,omitempty"`   // set when it differs from the source's, e.g. "comment"
+	Parent              *ParentPost                 `json:"parent,omitempty"`         // post a comment was left on
+	Region              string                      `json:"region,omitempty"`         // province, the rollups' province dimension
+
+	// sentimentText is the text the sentiment was scored on; SentimentProvider
+	// names the remote provider that scored it again, if any
+	sentimentText string
+}
+
+// PostLocation is the place an Instagram post is tagged with. Province is
+// set for the configured locations only.
+type PostLocation struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Lat      float64 `json:"lat,omitempty"`
+	Lng      float64 `json:"lng,omitempty"`
+	Province string  `json:"province,omitempty"`
+}
+
+// ParentPost is the Instagram post a comment was left on
+type ParentPost struct {
+	Code         string `json:"code"`
+	URL          string `json:"url"`
+	Author       string `json:"author,omitempty"`
+	Caption      string `json:"caption,omitempty"`
+	LikeCount    int    `json:"like_count"`
+	CommentCount int    `json:"comment_count"`
+}
+
+// contentTypeComment marks records that are comments of a source whose
+// records are otherwise posts or articles
+const contentTypeComment = "comment"
+
+// Limits of what a TransformReport keeps; skipped records past them are
+// only counted
+const (
+	maxSkipReasons  = 20 // distinct reasons per source
+	maxRecordErrors = 50
+)
+
+// TransformReport lists the records a transformation skipped because they
+// were malformed or their transformation failed
+type TransformReport struct {
+	Skipped int                              `json:"skipped"`
+	Sources map[string]*SourceTransformStats `json:"sources,omitempty"`
+	Errors  []RecordError                    `json:"errors,omitempty"` // the first skipped records
+}
+
+// SourceTransformStats counts the skipped records of one source
+type SourceTransformStats struct {
+	Skipped int            `json:"skipped"`
+	Reasons map[string]int `json:"reasons"` // skipped records by reason
+}
+
+// RecordError describes a skipped record
+type RecordError struct {
+	Source string `json:"source"`
+	Record string `json:"record"` // position in the payload, e.g. "videos[3]", or "payload"
+	Reason string `json:"reason"`
+}
+
+// newTransformReport creates an empty report
+func newTransformReport() *TransformReport {
+	return &TransformReport{Sources: make(map[string]*SourceTransformStats)}
+}
+
+// add records a skipped record
+func (r *TransformReport) add(source, record, reason string) {
+	stats := r.Sources[source]
+	if stats == nil {
+		stats = &SourceTransformStats{Reasons: make(map[string]int)}
+		r.Sources[source] = stats
+	}
+	r.Skipped++
+	stats.Skipped++
+	if _, known := stats.Reasons[reason]; !known && len(stats.Reasons) >= maxSkipReasons {
+		reason = "other"
+	}
+	stats.Reasons[reason]++
+	if len(r.Errors) < maxRecordErrors {
+		r.Errors = append(r.Errors, RecordError{Source: source, Record: record, Reason: reason})
+	}
+}
+
+// merge adds the skipped records of other to the report
+func (r *TransformReport) merge(other *TransformReport) {
+	if other == nil {
+		return
+	}
+	r.Skipped += other.Skipped
+	for source, stats := range other.Sources {
+		into := r.Sources[source]
+		if into == nil {
+			into = &SourceTransformStats{Reasons: make(map[string]int)}
+			r.Sources[source] = into
+		}
+		into.Skipped += stats.Skipped
+		for reason, count := range stats.Reasons {
+			into.Reasons[reason] += count
+		}
+	}
+	for _, recordErr := range other.Errors {
+		if len(r.Errors) >= maxRecordErrors {
+			break
+		}
+		r.Errors = append(r.Errors, recordErr)
+	}
 }
 
 // DataSummary represents summary statistics
@@ -71,189 +219,328 @@ type DataSummary struct {
 
 // NewDataTransformer creates a new DataTransformer instance
 func NewDataTransformer() *DataTransformer {
+	cfg, _ := config.LoadConfig()
 	return &DataTransformer{
-		covidKeywords: []string{
-			"covid", "coronavirus", "pandemic", "vaccine", "vaccination",
-			"lockdown", "quarantine", "social distancing", "mask",
-			"indonesia", "jakarta", "jawa", "sulawesi", "sumatra",
-		},
+		relevanceScorer:    services.NewRelevanceScorer(),
+		languageDetector:   services.NewLanguageDetector(),
+		sentimentAnalyzer:  services.NewSentimentAnalyzer(),
+		sentimentProvider:  services.NewConfiguredSentimentProvider(),
+		toxicityScorer:     services.NewConfiguredToxicityScorer(),
+		instagramLocations: cfg.ExternalAPIs.Instagram.Locations,
 	}
 }
 
-// TransformData transforms all extracted data
-func (dt *DataTransformer) TransformData(youtubeData, newsData, instagramData interface{}) *TransformedData {
-	log.Println("Starting data transformation...")
+// TransformData transforms the extracted payloads of all sources, keyed by
+// source name as in ExtractedData.Sources
+func (dt *DataTransformer) TransformData(sources map[string]interface{}) *TransformedData {
+	out := &transformCollector{chunk: &TransformedData{}, report: newTransformReport()}
+	dt.transformAll(sources, out)
+	dt.scoreSentiment(out.chunk)
+
+	transformedData := out.chunk
+	transformedData.TransformedAt = time.Now().Format(time.RFC3339)
+	transformedData.Summary = dt.createSummary(transformedData.YouTube, transformedData.News)
+	transformedData.Report = out.report
+	return transformedData
+}
+
+// TransformDataInChunks transforms all extracted data like TransformData, but
+// hands the records to flush in chunks of flushSize as they are transformed
+// instead of keeping them all. The returned data holds only the summary.
+// Transformation stops at the first error returned by flush.
+func (dt *DataTransformer) TransformDataInChunks(sources map[string]interface{}, flushSize int, flush func(chunk *TransformedData) error) (*TransformedData, error) {
+	out := &transformCollector{chunk: &TransformedData{}, flushSize: flushSize, flush: flush, score: dt.scoreSentiment, report: newTransformReport()}
+	dt.transformAll(sources, out)
+	out.flushChunk()
 
 	transformedData := &TransformedData{
 		TransformedAt: time.Now().Format(time.RFC3339),
+		Summary:       out.summary(),
+		Report:        out.report,
 	}
+	return transformedData, out.err
+}
 
-	// Transform YouTube data
-	if youtubeData != nil {
-		transformedData.YouTube = dt.transformYouTubeData(youtubeData)
-	}
+// transformAll passes the payload of every registered source to the
+// source's transformer adapter. Payloads of unknown sources are ignored.
+// A panic in an adapter skips the rest of its payload only.
+func (dt *DataTransformer) transformAll(sources map[string]interface{}, out *transformCollector) {
+	log.Println("Starting data transformation...")
 
-	// Transform news data (can be single source or slice of sources)
-	if newsData != nil {
-		switch v := newsData.(type) {
-		case []interface{}:
-			// Handle multiple news sources
-			for _, source := range v {
-				if source != nil {
-					transformedArticles := dt.transformNewsData(source)
-					transformedData.News = append(transformedData.News, transformedArticles...)
-				}
-			}
-		default:
-			// Handle single news source
-			transformedData.News = dt.transformNewsData(newsData)
+	for _, source := range registeredSources {
+		if data, ok := sources[source.name]; ok && data != nil {
+			out.try(source.name, "payload", func() { source.transform(dt, data, out) })
 		}
 	}
 
-	// Transform Instagram data
-	if instagramData != nil {
-		transformedData.News = append(transformedData.News, dt.transformInstagramData(instagramData)...)
+	log.Println("Data transformation completed")
+}
+
+// transformCollector receives transformed records. Without a flush size it
+// keeps them all in chunk; with one it passes every flushSize records to
+// flush and starts a new chunk, so memory stays flat on large backfills.
+type transformCollector struct {
+	chunk     *TransformedData
+	flushSize int
+	flush     func(chunk *TransformedData) error
+	score     func(chunk *TransformedData) // scores the sentiment of a chunk before it is flushed
+	err       error                        // first flush error; later records are discarded
+	report    *TransformReport             // records skipped across all chunks
+
+	// Running totals across all chunks for the summary
+	videos       int
+	articles     int
+	relevanceSum float64
+}
+
+// addVideo collects a transformed YouTube record
+func (c *transformCollector) addVideo(video TransformedVideo) {
+	if c.err != nil {
+		return
 	}
+	c.videos++
+	c.relevanceSum += video.CovidRelevanceScore
+	c.chunk.YouTube = append(c.chunk.YouTube, video)
+	c.flushIfFull()
+}
 
-	// Create summary
-	transformedData.Summary = dt.createSummary(transformedData.YouTube, transformedData.News)
+// addArticle collects a transformed news or Instagram record
+func (c *transformCollector) addArticle(article TransformedArticle) {
+	if c.err != nil {
+		return
+	}
+	c.articles++
+	c.relevanceSum += article.CovidRelevanceScore
+	c.chunk.News = append(c.chunk.News, article)
+	c.flushIfFull()
+}
 
-	log.Println("Data transformation completed")
-	return transformedData
+// skip reports a malformed record of source that is not transformed
+func (c *transformCollector) skip(source, record, reason string) {
+	log.Printf("⚠️ Skipping %s %s: %s", source, record, reason)
+	c.report.add(source, record, reason)
 }
 
-// transformYouTubeData transforms YouTube data (now comments with video metadata)
-func (dt *DataTransformer) transformYouTubeData(data interface{}) []TransformedVideo {
-	var transformedVideos []TransformedVideo
+// try runs transform, the transformation of one record of source. A panic
+// is recovered and the record reported as skipped, so one malformed record
+// cannot fail the run. It reports whether transform completed.
+func (c *transformCollector) try(source, record string, transform func()) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Transforming %s %s panicked: %v\n%s", source, record, r, debug.Stack())
+			c.skip(source, record, fmt.Sprintf("panic: %v", r))
+			ok = false
+		}
+	}()
+	transform()
+	return true
+}
+
+// flushIfFull flushes the current chunk once it holds flushSize records
+func (c *transformCollector) flushIfFull() {
+	if c.flushSize > 0 && len(c.chunk.YouTube)+len(c.chunk.News) >= c.flushSize {
+		c.flushChunk()
+	}
+}
 
+// flushChunk passes the current chunk to flush and starts a new one
+func (c *transformCollector) flushChunk() {
+	if c.flush == nil || c.err != nil || len(c.chunk.YouTube)+len(c.chunk.News) == 0 {
+		return
+	}
+	chunk := c.chunk
+	chunk.TransformedAt = time.Now().Format(time.RFC3339)
+	if c.score != nil {
+		c.score(chunk)
+	}
+	c.chunk = &TransformedData{}
+	c.err = c.flush(chunk)
+}
+
+// summary returns the statistics of all collected records
+func (c *transformCollector) summary() DataSummary {
+	averageRelevance := 0.0
+	if count := c.videos + c.articles; count > 0 {
+		averageRelevance = c.relevanceSum / float64(count)
+	}
+	return DataSummary{
+		TotalVideos:         c.videos,
+		TotalArticles:       c.articles,
+		AverageRelevance:    averageRelevance,
+		ProcessingTimestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// transformYouTubeData transforms YouTube data (now comments with video metadata)
+func (dt *DataTransformer) transformYouTubeData(data interface{}, out *transformCollector) {
 	log.Println("Transforming YouTube data (comments)...")
 
-	// Handle different data structures from different sources
-	switch v := data.(type) {
-	case *YouTubeData:
-		// Handle YouTube API response structure - now contains comments with video metadata
-		if v.Videos != nil {
-			if commentsList, ok := v.Videos.([]interface{}); ok {
-				log.Printf("Transforming %d YouTube comments", len(commentsList))
-				for _, commentData := range commentsList {
-					if commentMap, ok := commentData.(map[string]interface{}); ok {
-						// Extract comment and video info
-						if comment, exists := commentMap["comment"]; exists {
-							if video, exists := commentMap["video"]; exists {
-								transformedVideo := dt.transformYouTubeComment(comment, video)
-								if transformedVideo != nil {
-									transformedVideos = append(transformedVideos, *transformedVideo)
-								}
-							}
-						}
-					}
-				}
-			}
+	payload, ok := data.(*YouTubeData)
+	if !ok {
+		// Payloads read back from JSON
+		payload = &YouTubeData{}
+		if !decodePayload("youtube", data, payload) {
+			out.skip("youtube", "payload", "undecodable payload")
+			return
 		}
-	case map[string]interface{}:
-		// Handle other YouTube API response structures
-		if videos, ok := v["videos"]; ok {
-			if videosList, ok := videos.([]interface{}); ok {
-				for _, video := range videosList {
-					if videoMap, ok := video.(map[string]interface{}); ok {
-						transformedVideo := dt.transformYouTubeVideo(videoMap)
-						if transformedVideo != nil {
-							transformedVideos = append(transformedVideos, *transformedVideo)
-						}
-					}
-				}
+	}
+
+	log.Printf("Transforming %d YouTube comments", len(payload.Videos))
+	transformed := 0
+	for i, entry := range payload.Videos {
+		record := fmt.Sprintf("videos[%d]", i)
+		var transformedVideo *TransformedVideo
+		completed := out.try("youtube", record, func() {
+			switch {
+			case entry.Comment != nil && entry.Video != nil:
+				transformedVideo = dt.transformYouTubeComment(*entry.Comment, *entry.Video)
+			case entry.VideoID != "" || entry.Title != "":
+				// Older payloads hold the videos of a search
+				transformedVideo = dt.transformYouTubeVideo(entry.YouTubeSearchVideo)
 			}
+		})
+		if !completed {
+			continue
+		}
+		if transformedVideo == nil {
+			out.skip("youtube", record, "neither a comment with its video nor a video")
+			continue
 		}
+		out.addVideo(*transformedVideo)
+		transformed++
 	}
 
-	log.Printf("Transformed %d YouTube comments", len(transformedVideos))
-	return transformedVideos
+	log.Printf("Transformed %d YouTube comments", transformed)
 }
 
 // transformYouTubeComment transforms a YouTube comment with video metadata
-func (dt *DataTransformer) transformYouTubeComment(comment interface{}, video interface{}) *TransformedVideo {
-	if commentMap, ok := comment.(map[string]interface{}); ok {
-		if videoMap, ok := video.(map[string]interface{}); ok {
-			// Extract comment content
-			content := ""
-			if commentContent, exists := commentMap["content"]; exists {
-				content = fmt.Sprintf("%v", commentContent)
-			}
-
-			// Extract video metadata
-			title := ""
-			if videoTitle, exists := videoMap["title"]; exists {
-				title = fmt.Sprintf("%v", videoTitle)
-			}
+func (dt *DataTransformer) transformYouTubeComment(comment YouTubeComment, video YouTubeVideo) *TransformedVideo {
+	content := comment.Content
+
+	// Calculate COVID relevance score based on content
+	relevanceScore := dt.calculateCOVIDRelevance(content)
+
+	// Create rich metadata
+	metadata := map[string]interface{}{
+		"video": map[string]interface{}{
+			"title":     video.Title,
+			"videoId":   video.VideoID,
+			"url":       video.URL,
+			"views":     video.Views,
+			"duration":  video.Duration,
+			"author":    video.Author,
+			"published": video.Published,
+		},
+		"comment": map[string]interface{}{
+			"author":            comment.Author.Title,
+			"content":           comment.Content,
+			"publishedTimeText": comment.PublishedTimeText,
+			"replies":           comment.Stats.Replies,
+			"votes":             comment.Stats.Votes,
+			"commentId":         comment.CommentID,
+		},
+	}
 
-			// Calculate COVID relevance score based on content
-			relevanceScore := dt.calculateCOVIDRelevance(content)
-
-			// Create rich metadata
-			metadata := map[string]interface{}{
-				"video": map[string]interface{}{
-					"title":     videoMap["title"],
-					"videoId":   videoMap["videoId"],
-					"url":       videoMap["url"],
-					"views":     videoMap["views"],
-					"duration":  videoMap["duration"],
-					"author":    videoMap["author"],
-					"published": videoMap["published"],
-				},
-				"comment": map[string]interface{}{
-					"author":            commentMap["author"],
-					"content":           commentMap["content"],
-					"publishedTimeText": commentMap["publishedTimeText"],
-					"replies":           commentMap["stats"].(map[string]interface{})["replies"],
-					"votes":             commentMap["stats"].(map[string]interface{})["votes"],
-					"commentId":         commentMap["commentId"],
-				},
-			}
+	sentimentResult := dt.sentimentAnalyzer.AnalyzeSentiment(content)
+	sarcastic, _ := dt.sentimentAnalyzer.DetectSarcasm(content)
+	toxicityScore := dt.scoreToxicity(content)
+	topics := services.ClassifyTopics(content)
+	entities := services.ExtractEntities(content)
+
+	// Create transformed video entry (representing a comment)
+	return &TransformedVideo{
+		ID:                  fmt.Sprintf("comment_%v", time.Now().UnixNano()),
+		Title:               video.Title,
+		Description:         content, // Comment content goes in description
+		PublishedAt:         parseRelativeTime(comment.PublishedTimeText, time.Now()),
+		ChannelTitle:        "YouTube Comments",
+		ThumbnailURL:        "",
+		Source:              "YouTube",
+		CovidRelevanceScore: relevanceScore,
+		Language:            "en",
+		WordCount:           len(strings.Split(content, " ")),
+		ExtractedAt:         time.Now().Format(time.RFC3339),
+		TransformedAt:       time.Now().Format(time.RFC3339),
+		Sentiment:           sentimentResult.Category,
+		SentimentScore:      sentimentResult.Score,
+		SentimentConfidence: sentimentResult.Confidence,
+		Aspects:             sentimentResult.Aspects,
+		SentenceSentiment:   sentimentResult.Sentences,
+		sentimentText:       content,
+		Sarcastic:           sarcastic,
+		ToxicityScore:       toxicityScore,
+		Topics:              topics,
+		Entities:            entities,
+		Category:            primaryTopic(topics),
+		Region:              services.DetectProvince(entities),
+		Metadata:            metadata,
+	}
+}
 
-			// NEW: Calculate real sentiment using sentiment analyzer
-			sentimentAnalyzer := services.NewSentimentAnalyzer()
-			sentimentResult := sentimentAnalyzer.AnalyzeSentiment(content)
-
-			// Create transformed video entry (representing a comment)
-			return &TransformedVideo{
-				ID:                  fmt.Sprintf("comment_%v", time.Now().UnixNano()),
-				Title:               title,
-				Description:         content, // Comment content goes in description
-				PublishedAt:         time.Now().Format(time.RFC3339),
-				ChannelTitle:        "YouTube Comments",
-				ThumbnailURL:        "",
-				Source:              "YouTube",
-				CovidRelevanceScore: relevanceScore,
-				Language:            "en",
-				WordCount:           len(strings.Split(content, " ")),
-				ExtractedAt:         time.Now().Format(time.RFC3339),
-				TransformedAt:       time.Now().Format(time.RFC3339),
-				Sentiment:           sentimentResult.Category,
-				SentimentScore:      sentimentResult.Score,
-				SentimentConfidence: sentimentResult.Confidence,
-				Metadata:            metadata,
-			}
-		}
+// primaryTopic returns the strongest of the classified topics, or "" when
+// none matched
+func primaryTopic(topics []string) string {
+	if len(topics) == 0 {
+		return ""
 	}
-	return nil
+	return topics[0]
 }
 
-// calculateCOVIDRelevance calculates relevance score for COVID-19 content
-func (dt *DataTransformer) calculateCOVIDRelevance(content string) float64 {
-	contentLower := strings.ToLower(content)
-	score := 0.0
+// scoreSentiment scores the items of a chunk again with a remote sentiment
+// provider, in batches. The keyword analyzer has scored every item already;
+// its scores stay when the provider fails.
+func (dt *DataTransformer) scoreSentiment(chunk *TransformedData) {
+	if _, keyword := dt.sentimentProvider.(*services.SentimentAnalyzer); keyword || dt.sentimentProvider == nil {
+		return
+	}
 
-	// Check for COVID-related keywords
-	for _, keyword := range dt.covidKeywords {
-		if strings.Contains(contentLower, strings.ToLower(keyword)) {
-			score += 0.2
-		}
+	texts := make([]string, 0, len(chunk.YouTube)+len(chunk.News))
+	for _, video := range chunk.YouTube {
+		texts = append(texts, video.sentimentText)
+	}
+	for _, article := range chunk.News {
+		texts = append(texts, article.sentimentText)
+	}
+	if len(texts) == 0 {
+		return
+	}
+
+	results, err := dt.sentimentProvider.AnalyzeBatch(texts)
+	if err != nil {
+		log.Printf("⚠️ Sentiment provider %s failed, keeping keyword scores of %d items: %v", dt.sentimentProvider.Name(), len(texts), err)
+		return
+	}
+
+	name := dt.sentimentProvider.Name()
+	for i := range chunk.YouTube {
+		video, result := &chunk.YouTube[i], results[i]
+		video.Sentiment, video.SentimentScore, video.SentimentConfidence = result.Category, result.Score, result.Confidence
+		video.SentimentProvider = name
+	}
+	for i := range chunk.News {
+		article, result := &chunk.News[i], results[len(chunk.YouTube)+i]
+		article.Sentiment, article.SentimentScore, article.SentimentConfidence = result.Category, result.Score, result.Confidence
+		article.SentimentProvider = name
 	}
+}
 
-	// Cap at 1.0
-	if score > 1.0 {
-		score = 1.0
+// scoreToxicity rates a social comment, returning nil when toxicity scoring is
+// disabled or the scorer fails
+func (dt *DataTransformer) scoreToxicity(text string) *float64 {
+	if dt.toxicityScorer == nil {
+		return nil
+	}
+	score, err := dt.toxicityScorer.Score(text)
+	if err != nil {
+		log.Printf("⚠️ Toxicity scoring failed: %v", err)
+		return nil
 	}
+	return &score
+}
+
+// calculateCOVIDRelevance calculates relevance score for COVID-19 comments
+func (dt *DataTransformer) calculateCOVIDRelevance(content string) float64 {
+	score := dt.relevanceScorer.Score(content)
 
 	// Minimum relevance for any comment
 	if score < 0.1 {
@@ -264,90 +551,97 @@ func (dt *DataTransformer) calculateCOVIDRelevance(content string) float64 {
 }
 
 // transformInstagramData transforms Instagram data to TransformedArticle format
-func (dt *DataTransformer) transformInstagramData(data interface{}) []TransformedArticle {
-	var transformedArticles []TransformedArticle
-
+func (dt *DataTransformer) transformInstagramData(data interface{}, out *transformCollector) {
 	log.Println("Transforming Instagram data...")
 
-	// Handle different Instagram data structures
-	switch v := data.(type) {
-	case *InstagramData:
-		// Handle Instagram API response structure
-		if v.Posts != nil {
-			if postsList, ok := v.Posts.([]interface{}); ok {
-				log.Printf("Transforming %d Instagram posts", len(postsList))
-				for _, post := range postsList {
-					if postMap, ok := post.(map[string]interface{}); ok {
-						transformedArticle := dt.transformInstagramPost(postMap)
-						if transformedArticle != nil {
-							transformedArticles = append(transformedArticles, *transformedArticle)
-						}
-					}
-				}
-			}
-		}
-	case map[string]interface{}:
-		// Handle other Instagram API response structures
-		if posts, ok := v["posts"]; ok {
-			if postsList, ok := posts.([]interface{}); ok {
-				for _, post := range postsList {
-					if postMap, ok := post.(map[string]interface{}); ok {
-						transformedArticle := dt.transformInstagramPost(postMap)
-						if transformedArticle != nil {
-							transformedArticles = append(transformedArticles, *transformedArticle)
-						}
-					}
-				}
-			}
+	payload, ok := data.(*InstagramData)
+	if !ok {
+		// Payloads read back from JSON
+		payload = &InstagramData{}
+		if !decodePayload("instagram", data, payload) {
+			out.skip("instagram", "payload", "undecodable payload")
+			return
 		}
 	}
 
-	log.Printf("Transformed %d Instagram posts", len(transformedArticles))
-	return transformedArticles
+	transformed := dt.transformInstagramPosts(payload.Posts, "posts", "", out)
+	for i, hashtag := range payload.Hashtags {
+		transformed += dt.transformInstagramPosts(hashtag.Posts, fmt.Sprintf("hashtags[%d].posts", i), hashtag.Hashtag, out)
+	}
+	for i, location := range payload.Locations {
+		transformed += dt.transformInstagramPosts(location.Posts, fmt.Sprintf("locations[%d].posts", i), "", out)
+	}
+	for i, thread := range payload.Comments {
+		transformed += dt.transformInstagramComments(thread, fmt.Sprintf("comments[%d].comments", i), out)
+	}
+
+	log.Printf("Transformed %d Instagram posts", transformed)
 }
 
-// transformYouTubeVideo transforms a single YouTube video
-func (dt *DataTransformer) transformYouTubeVideo(videoMap map[string]interface{}) *TransformedVideo {
-	// Extract title
-	title := ""
-	if titleVal, ok := videoMap["title"]; ok {
-		title = dt.cleanText(fmt.Sprintf("%v", titleVal))
+// transformInstagramPosts transforms a list of Instagram posts, found at
+// field of the payload in the feed of hashtag, if any, and returns how many
+// were transformed. Posts that decoded to nothing are skipped.
+func (dt *DataTransformer) transformInstagramPosts(posts []InstagramPost, field, hashtag string, out *transformCollector) int {
+	log.Printf("Transforming %d Instagram posts", len(posts))
+	transformed := 0
+	for i, post := range posts {
+		record := fmt.Sprintf("%s[%d]", field, i)
+		if post == (InstagramPost{}) {
+			out.skip("instagram", record, "empty record")
+			continue
+		}
+		var article *TransformedArticle
+		if out.try("instagram", record, func() { article = dt.transformInstagramPost(post) }) {
+			article.Hashtag = hashtag
+			out.addArticle(*article)
+			transformed++
+		}
 	}
+	return transformed
+}
 
-	// Extract description
-	description := ""
-	if descVal, ok := videoMap["descriptionSnippet"]; ok {
-		description = dt.cleanText(fmt.Sprintf("%v", descVal))
+// transformInstagramComments transforms the comments of a post, found at
+// field of the payload, and returns how many were transformed. Comments
+// without text are skipped.
+func (dt *DataTransformer) transformInstagramComments(thread InstagramPostComments, field string, out *transformCollector) int {
+	transformed := 0
+	for i, comment := range thread.Comments {
+		record := fmt.Sprintf("%s[%d]", field, i)
+		if strings.TrimSpace(comment.Text) == "" {
+			out.skip("instagram", record, "empty record")
+			continue
+		}
+		var article *TransformedArticle
+		if out.try("instagram", record, func() { article = dt.transformInstagramComment(comment, thread.Post) }) {
+			article.Hashtag = thread.Hashtag
+			out.addArticle(*article)
+			transformed++
+		}
 	}
+	return transformed
+}
+
+// transformYouTubeVideo transforms a single YouTube video
+func (dt *DataTransformer) transformYouTubeVideo(video YouTubeSearchVideo) *TransformedVideo {
+	title := dt.cleanText(video.Title)
+	description := dt.cleanText(video.DescriptionSnippet)
 
 	// Extract published date
-	publishedAt := ""
-	if publishedVal, ok := videoMap["publishedTimeText"]; ok {
-		publishedAt = fmt.Sprintf("%v", publishedVal)
+	publishedAt := video.PublishedTimeText
+	if parsed := parseRelativeTime(publishedAt, time.Now()); parsed != "" {
+		publishedAt = parsed
 	}
 
 	// Extract channel title
 	channelTitle := ""
-	if authorVal, ok := videoMap["author"].(map[string]interface{}); ok {
-		if authorTitleVal, ok := authorVal["title"]; ok {
-			channelTitle = fmt.Sprintf("%v", authorTitleVal)
-		}
+	if video.Author != nil {
+		channelTitle = video.Author.Title
 	}
 
 	// Extract thumbnail URL
 	thumbnailURL := ""
-	if thumbnailsVal, ok := videoMap["thumbnails"].([]interface{}); ok && len(thumbnailsVal) > 0 {
-		if firstThumb, ok := thumbnailsVal[0].(map[string]interface{}); ok {
-			if urlVal, ok := firstThumb["url"]; ok {
-				thumbnailURL = fmt.Sprintf("%v", urlVal)
-			}
-		}
-	}
-
-	// Extract video ID
-	_ = ""
-	if idVal, ok := videoMap["videoId"]; ok {
-		_ = fmt.Sprintf("%v", idVal)
+	if len(video.Thumbnails) > 0 {
+		thumbnailURL = video.Thumbnails[0].URL
 	}
 
 	// Calculate COVID-19 relevance score
@@ -360,12 +654,12 @@ func (dt *DataTransformer) transformYouTubeVideo(videoMap map[string]interface{}
 	wordCount := len(strings.Fields(title + " " + description))
 
 	// Generate unique ID
-	id := dt.generateVideoID(videoMap)
+	id := dt.generateVideoID(video.VideoID)
 
-	// NEW: Calculate real sentiment using sentiment analyzer
-	sentimentAnalyzer := services.NewSentimentAnalyzer()
 	combinedText := title + " " + description
-	sentimentResult := sentimentAnalyzer.AnalyzeSentiment(combinedText)
+	sentimentResult := dt.sentimentAnalyzer.AnalyzeSentiment(combinedText)
+	topics := services.ClassifyTopics(combinedText)
+	entities := services.ExtractEntities(combinedText)
 
 	// Create transformed video
 	transformedVideo := &TransformedVideo{
@@ -384,256 +678,120 @@ func (dt *DataTransformer) transformYouTubeVideo(videoMap map[string]interface{}
 		Sentiment:           sentimentResult.Category,
 		SentimentScore:      sentimentResult.Score,
 		SentimentConfidence: sentimentResult.Confidence,
+		Aspects:             sentimentResult.Aspects,
+		SentenceSentiment:   sentimentResult.Sentences,
+		sentimentText:       combinedText,
+		Topics:              topics,
+		Entities:            entities,
+		Category:            primaryTopic(topics),
+		Region:              services.DetectProvince(entities),
 	}
 
 	return transformedVideo
 }
 
-// transformNewsData transforms news data
-func (dt *DataTransformer) transformNewsData(data interface{}) []TransformedArticle {
-	var transformedArticles []TransformedArticle
-
-	log.Println("Transforming news data...")
-	log.Printf("Debug: News data type: %T", data)
-	log.Printf("Debug: News data value: %+v", data)
-
-	// Handle different data structures from different sources
-	switch v := data.(type) {
-	case *IndonesiaNewsData:
-		// Handle Indonesia News API response structure
-		if v.Sources != nil {
-			if items, ok := v.Sources["items"]; ok {
-				if itemsList, ok := items.([]interface{}); ok {
-					log.Printf("Transforming %d Indonesia news items", len(itemsList))
-					for _, item := range itemsList {
-						if articleMap, ok := item.(map[string]interface{}); ok {
-							transformedArticle := dt.transformNewsItem(articleMap)
-							if transformedArticle != nil {
-								transformedArticles = append(transformedArticles, *transformedArticle)
-							}
-						}
-					}
-				}
-			}
+// transformIndonesiaNewsData transforms the flattened items of the
+// Indonesia news scraper
+func (dt *DataTransformer) transformIndonesiaNewsData(data interface{}, out *transformCollector) {
+	payload, ok := data.(*IndonesiaNewsData)
+	if !ok {
+		// Payloads read back from JSON
+		payload = &IndonesiaNewsData{}
+		if !decodePayload("indonesia_news", data, payload) {
+			out.skip("indonesia_news", "payload", "undecodable payload")
+			return
 		}
-	case *InstagramData:
-		// Handle Instagram posts structure
-		if v.Posts != nil {
-			if postsList, ok := v.Posts.([]interface{}); ok {
-				log.Printf("Transforming %d Instagram posts", len(postsList))
-				for _, post := range postsList {
-					if postMap, ok := post.(map[string]interface{}); ok {
-						transformedArticle := dt.transformInstagramPost(postMap)
-						if transformedArticle != nil {
-							transformedArticles = append(transformedArticles, *transformedArticle)
-						}
-					}
-				}
-			}
+	}
+	dt.transformNewsItems(payload.Sources.Items, "indonesia_news", "sources.items", out)
+}
+
+// transformGoogleNewsData transforms the articles of the Real-Time News API
+func (dt *DataTransformer) transformGoogleNewsData(data interface{}, out *transformCollector) {
+	payload, ok := data.(*NewsData)
+	if !ok {
+		// Payloads read back from JSON
+		payload = &NewsData{}
+		if !decodePayload("google_news", data, payload) {
+			out.skip("google_news", "payload", "undecodable payload")
+			return
 		}
-	case *NewsData:
-		// Handle Real-Time News API response structure
-		if v.Articles != nil {
-			if articlesList, ok := v.Articles.([]interface{}); ok {
-				log.Printf("Transforming %d Real-Time news articles", len(articlesList))
-				for _, article := range articlesList {
-					if articleMap, ok := article.(map[string]interface{}); ok {
-						transformedArticle := dt.transformNewsItem(articleMap)
-						if transformedArticle != nil {
-							transformedArticles = append(transformedArticles, *transformedArticle)
-						}
-					}
-				}
-			}
+	}
+	dt.transformNewsItems(payload.Articles, "google_news", "articles", out)
+}
+
+// transformArchiveData transforms the articles found by the archive crawler
+func (dt *DataTransformer) transformArchiveData(data interface{}, out *transformCollector) {
+	payload, ok := data.(*ArchiveData)
+	if !ok {
+		// Payloads read back from JSON
+		payload = &ArchiveData{}
+		if !decodePayload("news_archive", data, payload) {
+			out.skip("news_archive", "payload", "undecodable payload")
+			return
 		}
-	case map[string]interface{}:
-		// Handle other news API response structures
-		log.Printf("Debug: Processing map[string]interface{} with keys: %v", getMapKeys(v))
-
-		if items, ok := v["items"]; ok {
-			if itemsList, ok := items.([]interface{}); ok {
-				log.Printf("Debug: Found 'items' key with %d items", len(itemsList))
-
-				// Check if this looks like Indonesia News data by examining the first item
-				isIndonesiaNews := false
-				if len(itemsList) > 0 {
-					if firstItem, ok := itemsList[0].(map[string]interface{}); ok {
-						log.Printf("Debug: First item keys: %v", getMapKeys(firstItem))
-						// Check for Indonesia News specific fields
-						for key := range firstItem {
-							if key == "namakanal" || key == "idberita" || key == "namaparent" || key == "namasubkanal" {
-								isIndonesiaNews = true
-								log.Printf("Debug: Detected Indonesia News field: %s", key)
-								break
-							}
-						}
-					}
-				}
-
-				if isIndonesiaNews {
-					log.Printf("Transforming %d Indonesia news items (flattened structure)", len(itemsList))
-					for _, item := range itemsList {
-						if articleMap, ok := item.(map[string]interface{}); ok {
-							transformedArticle := dt.transformNewsItem(articleMap)
-							if transformedArticle != nil {
-								transformedArticles = append(transformedArticles, *transformedArticle)
-							}
-						}
-					}
-				} else {
-					log.Printf("Debug: Not Indonesia News, processing as generic news")
-					// Handle other news sources
-					for _, item := range itemsList {
-						if articleMap, ok := item.(map[string]interface{}); ok {
-							transformedArticle := dt.transformNewsItem(articleMap)
-							if transformedArticle != nil {
-								transformedArticles = append(transformedArticles, *transformedArticle)
-							}
-						}
-					}
-				}
-			}
+	}
+	dt.transformNewsItems(payload.Items, "news_archive", "items", out)
+}
+
+// transformNewsItems transforms a list of news items of one source, found
+// at field of its payload. Items that decoded to nothing, e.g. because they
+// were not objects, are skipped.
+func (dt *DataTransformer) transformNewsItems(items []NewsArticle, source, field string, out *transformCollector) {
+	log.Printf("Transforming %d %s items", len(items), source)
+
+	transformed := 0
+	for i, item := range items {
+		record := fmt.Sprintf("%s[%d]", field, i)
+		if item == (NewsArticle{}) {
+			out.skip(source, record, "empty record")
+			continue
 		}
-		// Handle Instagram posts structure
-		if posts, ok := v["posts"]; ok {
-			if postsList, ok := posts.([]interface{}); ok {
-				for _, post := range postsList {
-					if postMap, ok := post.(map[string]interface{}); ok {
-						transformedArticle := dt.transformInstagramPost(postMap)
-						if transformedArticle != nil {
-							transformedArticles = append(transformedArticles, *transformedArticle)
-						}
-					}
-				}
-			}
+		var article *TransformedArticle
+		if out.try(source, record, func() { article = dt.transformNewsItem(item, source) }) {
+			out.addArticle(*article)
+			transformed++
 		}
 	}
 
-	log.Printf("Transformed %d news articles", len(transformedArticles))
-	return transformedArticles
+	log.Printf("Transformed %d %s articles", transformed, source)
 }
 
-// transformNewsItem transforms a single news item to TransformedArticle
-func (dt *DataTransformer) transformNewsItem(articleMap map[string]interface{}) *TransformedArticle {
-	// Debug: Log available fields for Indonesia News
-	log.Printf("Debug: transformNewsItem called with fields: %v", getMapKeys(articleMap))
-
-	// Extract title
-	title := ""
-	if titleVal, ok := articleMap["title"]; ok {
-		title = dt.cleanText(fmt.Sprintf("%v", titleVal))
-	}
+// transformNewsItem transforms a single news item of a registered news
+// source to TransformedArticle
+func (dt *DataTransformer) transformNewsItem(item NewsArticle, source string) *TransformedArticle {
+	title := dt.cleanText(item.Title)
 
 	// Extract description/summary
 	description := ""
-	if descVal, ok := articleMap["summary"]; ok {
-		description = dt.cleanText(fmt.Sprintf("%v", descVal))
-	} else if descVal, ok := articleMap["description"]; ok {
-		description = dt.cleanText(fmt.Sprintf("%v", descVal))
-	} else if descVal, ok := articleMap["snippet"]; ok {
-		description = dt.cleanText(fmt.Sprintf("%v", descVal))
+	for _, text := range []string{item.Summary, item.Description, item.Snippet} {
+		if text != "" {
+			description = dt.cleanText(text)
+			break
+		}
 	}
 
 	// Extract content (use description if no content)
 	content := description
-	if contentVal, ok := articleMap["content"]; ok {
-		content = dt.cleanText(fmt.Sprintf("%v", contentVal))
+	if item.Content != "" {
+		content = dt.cleanText(item.Content)
 	}
 
 	// Extract URL
-	url := ""
-	if urlVal, ok := articleMap["url"]; ok {
-		url = fmt.Sprintf("%v", urlVal)
-	} else if urlVal, ok := articleMap["link"]; ok {
-		url = fmt.Sprintf("%v", urlVal)
-	}
-
-	// Extract source
-	source := ""
-
-	// For Real-Time News, check first by looking for specific fields
-	if _, hasArticleID := articleMap["article_id"]; hasArticleID {
-		log.Printf("Debug: Found article_id field, setting source to 'Real-Time News'")
-		source = "Real-Time News"
-	} else if _, hasSourceName := articleMap["source_name"]; hasSourceName {
-		log.Printf("Debug: Found source_name field, setting source to 'Real-Time News'")
-		source = "Real-Time News"
-	}
-
-	// For Indonesia News, check next
-	if source == "" {
-		log.Printf("Debug: Source is empty, checking for Indonesia News fields")
-		// Check if this is from Indonesia News by looking for specific fields
-		if _, hasNamakanal := articleMap["namakanal"]; hasNamakanal {
-			log.Printf("Debug: Found namakanal field, setting source to 'Indonesia News'")
-			source = "Indonesia News"
-		} else if _, hasIdberita := articleMap["idberita"]; hasIdberita {
-			log.Printf("Debug: Found idberita field, setting source to 'Indonesia News'")
-			source = "Indonesia News"
-		}
-	}
-
-	// Fallback to other source fields if still empty
-	if source == "" {
-		if sourceVal, ok := articleMap["source"]; ok {
-			source = fmt.Sprintf("%v", sourceVal)
-			log.Printf("Debug: Found 'source' field: %s", source)
-		} else if sourceVal, ok := articleMap["source_name"]; ok {
-			source = fmt.Sprintf("%v", sourceVal)
-			log.Printf("Debug: Found 'source_name' field: %s", source)
-		}
-	}
-
-	// Debug: Log available fields for Indonesia News
-	if source == "" {
-		// Check if this looks like Indonesia News data
-		hasIndonesiaFields := false
-		for key := range articleMap {
-			if key == "namakanal" || key == "idberita" || key == "namaparent" || key == "namasubkanal" {
-				hasIndonesiaFields = true
-				break
-			}
-		}
-		if hasIndonesiaFields {
-			source = "Indonesia News"
-			// Log the available fields for debugging
-			log.Printf("Debug: Indonesia News article with fields: %v", getMapKeys(articleMap))
-		}
+	url := item.URL
+	if url == "" {
+		url = item.Link
 	}
 
-	// Check URL-based detection for Indonesian news sources
-	if source == "" && url != "" {
-		urlLower := strings.ToLower(url)
-		if strings.Contains(urlLower, "detik.com") ||
-			strings.Contains(urlLower, "kompas.com") ||
-			strings.Contains(urlLower, "cnnindonesia.com") {
-			source = "Indonesia News"
-			log.Printf("Debug: Detected Indonesian news source from URL: %s", url)
-		}
-	}
-
-	// Extract published date (for future use if needed)
-	_ = ""
-	if dateVal, ok := articleMap["published_at"]; ok {
-		_ = dt.parseDateTime(fmt.Sprintf("%v", dateVal))
-	} else if dateVal, ok := articleMap["date"]; ok {
-		if dateMap, ok := dateVal.(map[string]interface{}); ok {
-			if publishVal, ok := dateMap["publish"]; ok {
-				_ = dt.parseDateTime(fmt.Sprintf("%v", publishVal))
-			}
-		}
-	} else if dateVal, ok := articleMap["published_datetime_utc"]; ok {
-		_ = dt.parseDateTime(fmt.Sprintf("%v", dateVal))
-	}
-
-	// Extract author (for future use if needed)
-	_ = ""
-	if authorVal, ok := articleMap["author"]; ok {
-		_ = fmt.Sprintf("%v", authorVal)
-	} else if authorVal, ok := articleMap["penulis"]; ok {
-		_ = fmt.Sprintf("%v", authorVal)
-	} else if authorVal, ok := articleMap["editor"]; ok {
-		_ = fmt.Sprintf("%v", authorVal)
+	// Extract published date
+	// Indonesian outlets publish local times without a zone, taken to be WIB
+	publishedAt := ""
+	switch {
+	case item.PublishedAt != "":
+		publishedAt = dt.parseDateTime(item.PublishedAt, services.WIB)
+	case item.Date != nil && item.Date.Publish != "":
+		publishedAt = dt.parseDateTime(item.Date.Publish, services.WIB)
+	case item.PublishedUTC != "":
+		publishedAt = dt.parseDateTime(item.PublishedUTC, time.UTC)
 	}
 
 	// Calculate COVID-19 relevance score
@@ -645,13 +803,13 @@ func (dt *DataTransformer) transformNewsItem(articleMap map[string]interface{})
 	// Calculate word count
 	wordCount := len(strings.Fields(title + " " + description + " " + content))
 
-	// NEW: Calculate real sentiment using sentiment analyzer
-	sentimentAnalyzer := services.NewSentimentAnalyzer()
 	combinedText := title + " " + description + " " + content
-	sentimentResult := sentimentAnalyzer.AnalyzeSentiment(combinedText)
+	sentimentResult := dt.sentimentAnalyzer.AnalyzeSentiment(combinedText)
+	topics := services.ClassifyTopics(combinedText)
+	entities := services.ExtractEntities(combinedText)
 
 	// Generate unique ID
-	id := dt.generateArticleID(articleMap)
+	id := dt.generateArticleID(item.Title, item.URL)
 
 	// Create transformed article
 	transformedArticle := &TransformedArticle{
@@ -660,7 +818,10 @@ func (dt *DataTransformer) transformNewsItem(articleMap map[string]interface{})
 		Description:         description,
 		Content:             content,
 		URL:                 url,
-		Source:              source,
+		PublishedAt:         publishedAt,
+		Source:              newsSourceLabels[source],
+		SourceKey:           source,
+		Outlet:              newsOutlet(item),
 		CovidRelevanceScore: relevanceScore,
 		Language:            language,
 		WordCount:           wordCount,
@@ -669,59 +830,56 @@ func (dt *DataTransformer) transformNewsItem(articleMap map[string]interface{})
 		Sentiment:           sentimentResult.Category,
 		SentimentScore:      sentimentResult.Score,
 		SentimentConfidence: sentimentResult.Confidence,
+		Aspects:             sentimentResult.Aspects,
+		SentenceSentiment:   sentimentResult.Sentences,
+		sentimentText:       combinedText,
+		Topics:              topics,
+		Entities:            entities,
+		Category:            primaryTopic(topics),
+		Region:              services.DetectProvince(entities),
 	}
 
 	return transformedArticle
 }
 
-// transformInstagramPost transforms a single Instagram post to TransformedArticle
-func (dt *DataTransformer) transformInstagramPost(postMap map[string]interface{}) *TransformedArticle {
-	// Extract caption text
-	caption := ""
-	if captionVal, ok := postMap["caption_text"]; ok {
-		caption = dt.cleanText(fmt.Sprintf("%v", captionVal))
-	}
-
-	// Extract post code/ID
-	postCode := ""
-	if codeVal, ok := postMap["code"]; ok {
-		postCode = fmt.Sprintf("%v", codeVal)
+// newsOutlet returns the publisher of a news item: the channel of the
+// Indonesia news scraper or the publisher of the Real-Time News API
+func newsOutlet(item NewsArticle) string {
+	if item.Channel != "" {
+		return item.Channel
 	}
+	return item.SourceName
+}
 
-	// Extract like count
-	likeCount := 0
-	if likeVal, ok := postMap["like_count"]; ok {
-		if likeInt, ok := likeVal.(float64); ok {
-			likeCount = int(likeInt)
-		}
+// instagramOutlet returns the @handle of a post's account
+func instagramOutlet(username string) string {
+	if username == "" {
+		return ""
 	}
+	return "@" + username
+}
 
-	// Extract comment count
-	commentCount := 0
-	if commentVal, ok := postMap["comment_count"]; ok {
-		if commentInt, ok := commentVal.(float64); ok {
-			commentCount = int(commentInt)
-		}
-	}
+// transformInstagramPost transforms a single Instagram post to TransformedArticle
+func (dt *DataTransformer) transformInstagramPost(post InstagramPost) *TransformedArticle {
+	caption := dt.cleanText(post.CaptionText)
 
-	// Extract user info
 	username := ""
-	if userVal, ok := postMap["user"].(map[string]interface{}); ok {
-		if usernameVal, ok := userVal["username"]; ok {
-			username = fmt.Sprintf("%v", usernameVal)
-		}
+	if post.User != nil {
+		username = post.User.Username
 	}
 
 	// Extract timestamp
 	timestamp := ""
-	if timeVal, ok := postMap["taken_at"]; ok {
-		timestamp = fmt.Sprintf("%v", timeVal)
+	publishedAt := ""
+	if post.TakenAt != 0 {
+		timestamp = strconv.FormatInt(post.TakenAt, 10)
+		publishedAt = time.Unix(post.TakenAt, 0).UTC().Format(time.RFC3339)
 	}
 
 	// Create a description combining caption and engagement metrics
 	description := caption
-	if likeCount > 0 || commentCount > 0 {
-		description += fmt.Sprintf(" (Likes: %d, Comments: %d)", likeCount, commentCount)
+	if post.LikeCount > 0 || post.CommentCount > 0 {
+		description += fmt.Sprintf(" (Likes: %d, Comments: %d)", post.LikeCount, post.CommentCount)
 	}
 
 	// Calculate COVID-19 relevance score
@@ -733,12 +891,22 @@ func (dt *DataTransformer) transformInstagramPost(postMap map[string]interface{}
 	// Calculate word count
 	wordCount := len(strings.Fields(caption))
 
-	// NEW: Calculate real sentiment using sentiment analyzer
-	sentimentAnalyzer := services.NewSentimentAnalyzer()
-	sentimentResult := sentimentAnalyzer.AnalyzeSentiment(caption)
+	sentimentResult := dt.sentimentAnalyzer.AnalyzeSentiment(caption)
+	sarcastic, _ := dt.sentimentAnalyzer.DetectSarcasm(caption)
+	toxicityScore := dt.scoreToxicity(caption)
+	topics := services.ClassifyTopics(caption)
+	entities := services.ExtractEntities(caption)
 
 	// Generate unique ID
-	id := dt.generateInstagramPostID(postMap)
+	id := dt.generateInstagramPostID(post.Code, timestamp)
+
+	// Posts at a configured location carry its province as ground truth;
+	// others are placed by the province their caption mentions most
+	location := dt.instagramLocation(post.Location)
+	region := services.DetectProvince(entities)
+	if location != nil && location.Province != "" {
+		region = location.Province
+	}
 
 	// Create transformed article
 	transformedArticle := &TransformedArticle{
@@ -746,8 +914,11 @@ func (dt *DataTransformer) transformInstagramPost(postMap map[string]interface{}
 		Title:               fmt.Sprintf("Instagram Post by @%s", username),
 		Description:         description,
 		Content:             caption,
-		URL:                 fmt.Sprintf("https://instagram.com/p/%s", postCode),
+		URL:                 fmt.Sprintf("https://instagram.com/p/%s", post.Code),
+		PublishedAt:         publishedAt,
 		Source:              fmt.Sprintf("Instagram (@%s)", username),
+		SourceKey:           "instagram",
+		Outlet:              instagramOutlet(username),
 		CovidRelevanceScore: relevanceScore,
 		Language:            language,
 		WordCount:           wordCount,
@@ -756,11 +927,105 @@ func (dt *DataTransformer) transformInstagramPost(postMap map[string]interface{}
 		Sentiment:           sentimentResult.Category,
 		SentimentScore:      sentimentResult.Score,
 		SentimentConfidence: sentimentResult.Confidence,
+		Aspects:             sentimentResult.Aspects,
+		SentenceSentiment:   sentimentResult.Sentences,
+		sentimentText:       caption,
+		Sarcastic:           sarcastic,
+		ToxicityScore:       toxicityScore,
+		Topics:              topics,
+		Entities:            entities,
+		Category:            primaryTopic(topics),
+		Location:            location,
+		Region:              region,
 	}
 
 	return transformedArticle
 }
 
+// transformInstagramComment transforms a comment on an Instagram post into
+// a conversational record that keeps the post it answers
+func (dt *DataTransformer) transformInstagramComment(comment InstagramComment, post InstagramPost) *TransformedArticle {
+	text := dt.cleanText(comment.Text)
+
+	username := ""
+	if comment.User != nil {
+		username = comment.User.Username
+	}
+	postAuthor := ""
+	if post.User != nil {
+		postAuthor = post.User.Username
+	}
+
+	publishedAt := ""
+	if comment.CreatedAt != 0 {
+		publishedAt = time.Unix(comment.CreatedAt, 0).UTC().Format(time.RFC3339)
+	}
+
+	sentimentResult := dt.sentimentAnalyzer.AnalyzeSentiment(text)
+	sarcastic, _ := dt.sentimentAnalyzer.DetectSarcasm(text)
+	topics := services.ClassifyTopics(text)
+	entities := services.ExtractEntities(text)
+	postURL := fmt.Sprintf("https://instagram.com/p/%s", post.Code)
+	commentURL := postURL
+	if comment.PK != "" {
+		commentURL = fmt.Sprintf("%s/c/%s", postURL, comment.PK)
+	}
+
+	return &TransformedArticle{
+		ID:                  "instagram_comment_" + timestampHash(comment.PK.String()+text),
+		Title:               fmt.Sprintf("Comment by @%s on @%s's post", username, postAuthor),
+		Description:         text,
+		Content:             text,
+		URL:                 commentURL,
+		PublishedAt:         publishedAt,
+		Source:              fmt.Sprintf("Instagram (@%s)", username),
+		SourceKey:           "instagram",
+		Outlet:              instagramOutlet(username),
+		CovidRelevanceScore: dt.calculateCovidRelevance(text),
+		Language:            dt.detectLanguage(text),
+		WordCount:           len(strings.Fields(text)),
+		ExtractedAt:         strconv.FormatInt(comment.CreatedAt, 10),
+		TransformedAt:       time.Now().Format(time.RFC3339),
+		Sentiment:           sentimentResult.Category,
+		SentimentScore:      sentimentResult.Score,
+		SentimentConfidence: sentimentResult.Confidence,
+		Aspects:             sentimentResult.Aspects,
+		SentenceSentiment:   sentimentResult.Sentences,
+		sentimentText:       text,
+		Sarcastic:           sarcastic,
+		ToxicityScore:       dt.scoreToxicity(text),
+		Topics:              topics,
+		Entities:            entities,
+		Category:            primaryTopic(topics),
+		Region:              services.DetectProvince(entities),
+		ContentType:         contentTypeComment,
+		Parent: &ParentPost{
+			Code:         post.Code,
+			URL:          postURL,
+			Author:       postAuthor,
+			Caption:      dt.cleanText(post.CaptionText),
+			LikeCount:    post.LikeCount,
+			CommentCount: post.CommentCount,
+		},
+	}
+}
+
+// instagramLocation returns the place a post is tagged with, with the
+// province of configured locations, or nil for posts without a location
+func (dt *DataTransformer) instagramLocation(tagged *InstagramLocation) *PostLocation {
+	if tagged == nil || tagged.PK == "" {
+		return nil
+	}
+
+	return &PostLocation{
+		ID:       tagged.PK.String(),
+		Name:     tagged.Name,
+		Lat:      tagged.Lat,
+		Lng:      tagged.Lng,
+		Province: dt.instagramLocations[tagged.PK.String()],
+	}
+}
+
 // cleanText cleans and normalizes text
 func (dt *DataTransformer) cleanText(text string) string {
 	if text == "" {
@@ -779,155 +1044,93 @@ func (dt *DataTransformer) cleanText(text string) string {
 
 // calculateCovidRelevance calculates relevance score for COVID-19 content
 func (dt *DataTransformer) calculateCovidRelevance(text string) float64 {
-	if text == "" {
-		return 0.0
-	}
-
-	text = strings.ToLower(text)
-	score := 0.0
-
-	for _, keyword := range dt.covidKeywords {
-		if strings.Contains(text, keyword) {
-			score += 1.0
-		}
-	}
-
-	// Normalize score to 0-1 range
-	maxPossibleScore := float64(len(dt.covidKeywords))
-	if maxPossibleScore > 0 {
-		score = score / maxPossibleScore
-	}
-
-	return score
+	return dt.relevanceScorer.Score(text)
 }
 
-// detectLanguage detects the language of the text (simplified)
+// detectLanguage detects the language of the text
 func (dt *DataTransformer) detectLanguage(text string) string {
-	if text == "" {
-		return "unknown"
-	}
-
-	// Simple language detection based on common words
-	text = strings.ToLower(text)
-
-	// Indonesian words
-	indonesianWords := []string{"yang", "dan", "atau", "dengan", "untuk", "dari", "ke", "di", "pada"}
-	for _, word := range indonesianWords {
-		if strings.Contains(text, word) {
-			return "id"
-		}
-	}
-
-	// English words
-	englishWords := []string{"the", "and", "or", "with", "for", "from", "to", "in", "on", "at"}
-	for _, word := range englishWords {
-		if strings.Contains(text, word) {
-			return "en"
-		}
-	}
-
-	return "unknown"
+	return dt.languageDetector.Detect(text)
 }
 
-// parseDateTime parses datetime strings
-func (dt *DataTransformer) parseDateTime(dateStr string) string {
+// parseDateTime parses a source's datetime string to RFC3339 in UTC.
+// Datetimes without an offset or zone suffix are read in zone. It returns
+// the string unchanged when it cannot be parsed.
+func (dt *DataTransformer) parseDateTime(dateStr string, zone *time.Location) string {
 	if dateStr == "" {
 		return ""
 	}
 
-	// Try to parse various date formats
-	formats := []string{
-		time.RFC3339,
-		"2006-01-02T15:04:05Z",
-		"2006-01-02 15:04:05",
-		"2006-01-02",
-	}
-
-	for _, format := range formats {
-		if parsed, err := time.Parse(format, dateStr); err == nil {
-			return parsed.Format(time.RFC3339)
-		}
+	if parsed, ok := services.ParseTimestamp(dateStr, zone); ok {
+		return parsed.UTC().Format(time.RFC3339)
 	}
 
 	// Return original if parsing fails
 	return dateStr
 }
 
-// generateArticleID generates a unique ID for an article
-func (dt *DataTransformer) generateArticleID(article interface{}) string {
-	// Generate a hash-based ID from article content to ensure uniqueness
-	if articleMap, ok := article.(map[string]interface{}); ok {
-		// Use title and URL to create a unique hash
-		title := ""
-		url := ""
-		if titleVal, ok := articleMap["title"]; ok {
-			title = fmt.Sprintf("%v", titleVal)
-		}
-		if urlVal, ok := articleMap["url"]; ok {
-			url = fmt.Sprintf("%v", urlVal)
-		}
+// relativeTimePattern matches YouTube style relative dates such as "3 days ago"
+var relativeTimePattern = regexp.MustCompile(`(\d+)\s+(second|minute|hour|day|week|month|year)s?\s+ago`)
 
-		// Create a simple hash from title + url + timestamp
-		content := title + url + fmt.Sprintf("%d", time.Now().UnixNano())
-		hash := 0
-		for _, char := range content {
-			hash = ((hash << 5) - hash + int(char)) & 0xffffffff
-		}
-		return fmt.Sprintf("article_%x", hash)
+// parseRelativeTime converts a relative date like "2 weeks ago (edited)" to an
+// RFC3339 timestamp relative to now. It returns "" when the text is not relative.
+func parseRelativeTime(text string, now time.Time) string {
+	match := relativeTimePattern.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return ""
 	}
 
-	// Fallback to timestamp-based ID
-	return fmt.Sprintf("article_%d", time.Now().UnixNano())
-}
+	var n int
+	fmt.Sscanf(match[1], "%d", &n)
+
+	var published time.Time
+	switch match[2] {
+	case "second":
+		published = now.Add(-time.Duration(n) * time.Second)
+	case "minute":
+		published = now.Add(-time.Duration(n) * time.Minute)
+	case "hour":
+		published = now.Add(-time.Duration(n) * time.Hour)
+	case "day":
+		published = now.AddDate(0, 0, -n)
+	case "week":
+		published = now.AddDate(0, 0, -7*n)
+	case "month":
+		published = now.AddDate(0, -n, 0)
+	case "year":
+		published = now.AddDate(-n, 0, 0)
+	}
 
-// generateVideoID generates a unique ID for a YouTube video
-func (dt *DataTransformer) generateVideoID(video interface{}) string {
-	// Generate a hash-based ID from video content to ensure uniqueness
-	if videoMap, ok := video.(map[string]interface{}); ok {
-		// Use video ID to create a unique hash
-		videoID := ""
-		if idVal, ok := videoMap["videoId"]; ok {
-			videoID = fmt.Sprintf("%v", idVal)
-		}
+	return published.UTC().Format(time.RFC3339)
+}
 
-		// Create a simple hash from video ID + timestamp
-		content := videoID + fmt.Sprintf("%d", time.Now().UnixNano())
-		hash := 0
-		for _, char := range content {
-			hash = ((hash << 5) - hash + int(char)) & 0xffffffff
-		}
-		return fmt.Sprintf("video_%x", hash)
-	}
+// generateArticleID generates a unique ID for an article from its title and URL
+func (dt *DataTransformer) generateArticleID(title, url string) string {
+	// Create a simple hash from title + url + timestamp
+	return "article_" + timestampHash(title+url)
+}
 
-	// Fallback to timestamp-based ID
-	return fmt.Sprintf("video_%d", time.Now().UnixNano())
+// generateVideoID generates a unique ID for a YouTube video
+func (dt *DataTransformer) generateVideoID(videoID string) string {
+	// Create a simple hash from video ID + timestamp
+	return "video_" + timestampHash(videoID)
 }
 
-// generateInstagramPostID generates a unique ID for an Instagram post
-func (dt *DataTransformer) generateInstagramPostID(post interface{}) string {
-	// Generate a hash-based ID from post content to ensure uniqueness
-	if postMap, ok := post.(map[string]interface{}); ok {
-		// Use post code and timestamp to create a unique hash
-		postCode := ""
-		timestamp := ""
-		if codeVal, ok := postMap["code"]; ok {
-			postCode = fmt.Sprintf("%v", codeVal)
-		}
-		if timeVal, ok := postMap["taken_at"]; ok {
-			timestamp = fmt.Sprintf("%v", timeVal)
-		}
+// generateInstagramPostID generates a unique ID for an Instagram post from
+// its code and timestamp
+func (dt *DataTransformer) generateInstagramPostID(code, takenAt string) string {
+	// Create a simple hash from post code + timestamp + current time
+	return "instagram_" + timestampHash(code+takenAt)
+}
 
-		// Create a simple hash from post code + timestamp + current time
-		content := postCode + timestamp + fmt.Sprintf("%d", time.Now().UnixNano())
-		hash := 0
-		for _, char := range content {
-			hash = ((hash << 5) - hash + int(char)) & 0xffffffff
-		}
-		return fmt.Sprintf("instagram_%x", hash)
+// timestampHash hashes content with the current time, so records with the
+// same content still get distinct IDs
+func timestampHash(content string) string {
+	content += fmt.Sprintf("%d", time.Now().UnixNano())
+	hash := 0
+	for _, char := range content {
+		hash = ((hash << 5) - hash + int(char)) & 0xffffffff
 	}
-
-	// Fallback to timestamp-based ID
-	return fmt.Sprintf("instagram_%d", time.Now().UnixNano())
+	return fmt.Sprintf("%x", hash)
 }
 
 // createSummary creates summary statistics
@@ -961,12 +1164,3 @@ func (dt *DataTransformer) createSummary(videos []TransformedVideo, articles []T
 		ProcessingTimestamp: time.Now().Format(time.RFC3339),
 	}
 }
-
-// getMapKeys returns the keys of a map as a slice of strings
-func getMapKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
-}
@@ -0,0 +1,163 @@
+package etl
+
+import (
+	"math"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// What the validation stage does with records that break a rule, set with
+// ETL_VALIDATION_MODE
+const (
+	ValidationQuarantine = "quarantine" // store them in quarantined_records instead of loading them
+	ValidationReject     = "reject"     // drop them
+	ValidationOff        = "off"        // load every record without validation
+)
+
+// Validation rules a transformed record can break
+const (
+	ruleEmptyTitle       = "empty_title"
+	ruleInvalidURL       = "invalid_url"
+	ruleRelevanceRange   = "relevance_out_of_range"
+	ruleInvalidTimestamp = "invalid_timestamp"
+)
+
+// ValidationReport counts the records checked between transformation and
+// loading and the rules the invalid ones broke
+type ValidationReport struct {
+	Mode        string         `json:"mode"`
+	Checked     int            `json:"checked"`
+	Passed      int            `json:"passed"`
+	Rejected    int            `json:"rejected"`    // invalid records kept out of the load
+	Quarantined int            `json:"quarantined"` // rejected records stored in quarantined_records
+	Rules       map[string]int `json:"rules"`       // rejected records per broken rule
+}
+
+// newValidationReport returns an empty report; unknown modes quarantine
+func newValidationReport(mode string) *ValidationReport {
+	if mode != ValidationReject && mode != ValidationOff {
+		mode = ValidationQuarantine
+	}
+	return &ValidationReport{Mode: mode, Rules: make(map[string]int)}
+}
+
+// merge adds the counts of another report; other may be nil
+func (r *ValidationReport) merge(other *ValidationReport) {
+	if other == nil {
+		return
+	}
+	r.Mode = other.Mode
+	r.Checked += other.Checked
+	r.Passed += other.Passed
+	r.Rejected += other.Rejected
+	r.Quarantined += other.Quarantined
+	for rule, count := range other.Rules {
+		r.Rules[rule] += count
+	}
+}
+
+// invalidRecord is a transformed record that broke one or more rules
+type invalidRecord struct {
+	source  string
+	kind    string // "video" or "article"
+	id      string
+	reasons []string
+	record  interface{}
+}
+
+// validateRecords removes the records of data that break a rule, counts
+// them in report and returns them
+func validateRecords(data *TransformedData, report *ValidationReport) []invalidRecord {
+	invalid := []invalidRecord{}
+	reject := func(record invalidRecord) {
+		invalid = append(invalid, record)
+		report.Rejected++
+		for _, reason := range record.reasons {
+			report.Rules[reason]++
+		}
+	}
+
+	videos := data.YouTube[:0]
+	for _, video := range data.YouTube {
+		report.Checked++
+		if reasons := videoViolations(video); len(reasons) > 0 {
+			reject(invalidRecord{source: "youtube", kind: "video", id: video.ID, reasons: reasons, record: video})
+			continue
+		}
+		report.Passed++
+		videos = append(videos, video)
+	}
+	data.YouTube = videos
+
+	articles := data.News[:0]
+	for _, article := range data.News {
+		report.Checked++
+		if reasons := articleViolations(article); len(reasons) > 0 {
+			reject(invalidRecord{source: article.SourceKey, kind: "article", id: article.ID, reasons: reasons, record: article})
+			continue
+		}
+		report.Passed++
+		articles = append(articles, article)
+	}
+	data.News = articles
+	data.Summary.TotalVideos = len(data.YouTube)
+	data.Summary.TotalArticles = len(data.News)
+
+	return invalid
+}
+
+// videoViolations returns the rules a video breaks
+func videoViolations(video TransformedVideo) []string {
+	reasons := []string{}
+	if strings.TrimSpace(video.Title) == "" {
+		reasons = append(reasons, ruleEmptyTitle)
+	}
+	if !validRelevance(video.CovidRelevanceScore) {
+		reasons = append(reasons, ruleRelevanceRange)
+	}
+	if !validTimestamp(video.PublishedAt, true) || !validTimestamp(video.TransformedAt, false) {
+		reasons = append(reasons, ruleInvalidTimestamp)
+	}
+	return reasons
+}
+
+// articleViolations returns the rules an article breaks. Articles without
+// a URL are allowed; they are identified by their ID instead.
+func articleViolations(article TransformedArticle) []string {
+	reasons := []string{}
+	if strings.TrimSpace(article.Title) == "" {
+		reasons = append(reasons, ruleEmptyTitle)
+	}
+	if article.URL != "" && !validURL(article.URL) {
+		reasons = append(reasons, ruleInvalidURL)
+	}
+	if !validRelevance(article.CovidRelevanceScore) {
+		reasons = append(reasons, ruleRelevanceRange)
+	}
+	if !validTimestamp(article.PublishedAt, true) || !validTimestamp(article.TransformedAt, false) {
+		reasons = append(reasons, ruleInvalidTimestamp)
+	}
+	return reasons
+}
+
+// validURL reports whether value is an absolute http(s) URL
+func validURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validRelevance reports whether a relevance score is within [0, 1]
+func validRelevance(score float64) bool {
+	return !math.IsNaN(score) && score >= 0 && score <= 1
+}
+
+// validTimestamp reports whether value is an RFC3339 timestamp; optional
+// timestamps may also be empty
+func validTimestamp(value string, optional bool) bool {
+	if value == "" {
+		return optional
+	}
+	_, err := time.Parse(time.RFC3339, value)
+	return err == nil
+}
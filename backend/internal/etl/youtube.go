@@ -1,11 +1,13 @@
 package etl
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"time"
 )
 
@@ -26,22 +28,114 @@ type YouTubeResponse struct {
 	VideoID string      `json:"video_id,omitempty"`
 
 	// Direct API response fields
-	Contents         []interface{} `json:"contents,omitempty"`
-	CursorNext       string        `json:"cursorNext,omitempty"`
-	EstimatedResults int64         `json:"estimatedResults,omitempty"`
-	FilterGroups     interface{}   `json:"filterGroups,omitempty"`
-	Refinements      interface{}   `json:"refinements,omitempty"`
+	Contents         []YouTubeSearchItem `json:"contents,omitempty"`
+	CursorNext       string              `json:"cursorNext,omitempty"` // next page of search results or comments
+	EstimatedResults int64               `json:"estimatedResults,omitempty"`
+	FilterGroups     interface{}         `json:"filterGroups,omitempty"`
+	Refinements      interface{}         `json:"refinements,omitempty"`
 
 	// Comments API response fields
-	Comments           []interface{} `json:"comments,omitempty"`
-	TotalCommentsCount int64         `json:"totalCommentsCount,omitempty"`
-	Filters            interface{}   `json:"filters,omitempty"`
+	Comments           []YouTubeComment `json:"comments,omitempty"`
+	TotalCommentsCount int64            `json:"totalCommentsCount,omitempty"`
+	Filters            interface{}      `json:"filters,omitempty"`
+}
+
+// YouTubeComment is a comment of the comments API
+type YouTubeComment struct {
+	CommentID         string              `json:"commentId"`
+	Content           string              `json:"content"`
+	Author            YouTubeAuthor       `json:"author"`
+	PublishedTimeText string              `json:"publishedTimeText"` // relative, e.g. "2 weeks ago"
+	Stats             YouTubeCommentStats `json:"stats"`
+}
+
+// YouTubeCommentStats holds the engagement of a comment
+type YouTubeCommentStats struct {
+	Replies int `json:"replies"`
+	Votes   int `json:"votes"`
+}
+
+// YouTubeAuthor is the channel that wrote a comment or published a video.
+// The API sends it as an object; older stored payloads send the
+// channel title as a string.
+type YouTubeAuthor struct {
+	ChannelID string `json:"channelId,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// UnmarshalJSON decodes an author object or a plain channel title. Other
+// values leave the author empty.
+func (a *YouTubeAuthor) UnmarshalJSON(data []byte) error {
+	var title string
+	if err := json.Unmarshal(data, &title); err == nil {
+		*a = YouTubeAuthor{Title: title}
+		return nil
+	}
+
+	type author YouTubeAuthor
+	var decoded author
+	if err := json.Unmarshal(data, &decoded); err == nil {
+		*a = YouTubeAuthor(decoded)
+	}
+	return nil
+}
+
+// YouTubeVideo describes the video a comment was left on
+type YouTubeVideo struct {
+	VideoID   string `json:"videoId"`
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	Published string `json:"published"`
+	Author    string `json:"author"`
+	Views     string `json:"views"`
+	Duration  string `json:"duration"`
+}
+
+// YouTubeSearchItem is an entry of the search API; only entries of type
+// "video" hold a video, others are channels and playlists
+type YouTubeSearchItem struct {
+	Type  string              `json:"type"`
+	Video *YouTubeSearchVideo `json:"video,omitempty"`
+}
+
+// YouTubeSearchVideo is a video of the search API
+type YouTubeSearchVideo struct {
+	VideoID            string             `json:"videoId,omitempty"`
+	Title              string             `json:"title,omitempty"`
+	DescriptionSnippet string             `json:"descriptionSnippet,omitempty"`
+	PublishedTimeText  string             `json:"publishedTimeText,omitempty"` // relative, e.g. "3 days ago"
+	LengthSeconds      int                `json:"lengthSeconds,omitempty"`
+	Author             *YouTubeAuthor     `json:"author,omitempty"`
+	Stats              *YouTubeVideoStats `json:"stats,omitempty"`
+	Thumbnails         []YouTubeThumbnail `json:"thumbnails,omitempty"`
+}
+
+// YouTubeVideoStats holds the audience of a video
+type YouTubeVideoStats struct {
+	Views int64 `json:"views"`
+}
+
+// YouTubeThumbnail is a thumbnail image of a video
+type YouTubeThumbnail struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// YouTubeEntry is an entry of the extracted YouTube data: a comment with
+// the video it was left on or, in older payloads, a video of a search
+type YouTubeEntry struct {
+	Comment *YouTubeComment `json:"comment,omitempty"`
+	Video   *YouTubeVideo   `json:"video,omitempty"`
+
+	// Search results carry the video fields at the top level
+	YouTubeSearchVideo
 }
 
 // YouTubeData represents the extracted YouTube data
 type YouTubeData struct {
-	Timestamp string      `json:"timestamp"`
-	Videos    interface{} `json:"videos"`
+	Timestamp string         `json:"timestamp"`
+	Videos    []YouTubeEntry `json:"videos"`
 }
 
 // NewYouTubeAPI creates a new YouTube API client
@@ -65,9 +159,7 @@ func NewYouTubeAPI(apiKey string) *YouTubeAPI {
 	client := &YouTubeAPI{
 		APIKey: apiKey,
 		Host:   host,
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		Client: newExtractorClient("youtube", 30*time.Second),
 	}
 
 	fmt.Printf("✅ YouTube API client created successfully\n")
@@ -77,8 +169,9 @@ func NewYouTubeAPI(apiKey string) *YouTubeAPI {
 	return client
 }
 
-// SearchVideos searches for videos using the correct YouTube API endpoint
-func (yt *YouTubeAPI) SearchVideos(query, lang, geo string) (*YouTubeResponse, error) {
+// SearchVideos searches for videos using the correct YouTube API endpoint.
+// cursor is the CursorNext of the previous page, empty for the first page.
+func (yt *YouTubeAPI) SearchVideos(ctx context.Context, query, lang, geo, cursor string) (*YouTubeResponse, error) {
 	// Build query parameters
 	params := url.Values{}
 	params.Set("q", query)
@@ -88,9 +181,12 @@ func (yt *YouTubeAPI) SearchVideos(query, lang, geo string) (*YouTubeResponse, e
 	if geo != "" {
 		params.Set("gl", geo)
 	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
 
 	// Create request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s/search/?%s", yt.Host, params.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/search/?%s", yt.Host, params.Encode()), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -113,7 +209,7 @@ func (yt *YouTubeAPI) SearchVideos(query, lang, geo string) (*YouTubeResponse, e
 
 	// Parse response
 	var result YouTubeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := decodeJSON("youtube", resp.Body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -134,14 +230,18 @@ func (yt *YouTubeAPI) SearchVideos(query, lang, geo string) (*YouTubeResponse, e
 	return &result, nil
 }
 
-// GetVideoComments retrieves comments for a specific video
-func (yt *YouTubeAPI) GetVideoComments(videoID string) (*YouTubeResponse, error) {
+// GetVideoComments retrieves a page of comments for a specific video.
+// cursor is the CursorNext of the previous page, empty for the first page.
+func (yt *YouTubeAPI) GetVideoComments(ctx context.Context, videoID, cursor string) (*YouTubeResponse, error) {
 	// Build query parameters
 	params := url.Values{}
 	params.Set("id", videoID)
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
 
 	// Create request
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s/video/comments/?%s", yt.Host, params.Encode()), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/video/comments/?%s", yt.Host, params.Encode()), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -160,7 +260,7 @@ func (yt *YouTubeAPI) GetVideoComments(videoID string) (*YouTubeResponse, error)
 
 	// Parse response
 	var result YouTubeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := decodeJSON("youtube", resp.Body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -179,3 +279,55 @@ func (yt *YouTubeAPI) GetVideoComments(videoID string) (*YouTubeResponse, error)
 
 	return &result, nil
 }
+
+// Videos get the dates of their publication only as relative text; a video
+// whose text cannot be read counts as unrankedVideoAge old
+const unrankedVideoAge = 365 * 24 * time.Hour
+
+// rankYouTubeVideos orders videos by their views per day since publication,
+// so a recent video drawing a large audience ranks above an older one with
+// more views in total
+func rankYouTubeVideos(videos []YouTubeSearchVideo, now time.Time) []YouTubeSearchVideo {
+	score := func(video YouTubeSearchVideo) float64 {
+		views := 0.0
+		if video.Stats != nil {
+			views = float64(video.Stats.Views)
+		}
+		age := unrankedVideoAge
+		if published, err := time.Parse(time.RFC3339, parseRelativeTime(video.PublishedTimeText, now)); err == nil {
+			age = now.Sub(published)
+		}
+		days := age.Hours() / 24
+		if days < 1 {
+			days = 1
+		}
+		return views / days
+	}
+
+	ranked := append([]YouTubeSearchVideo(nil), videos...)
+	sort.SliceStable(ranked, func(i, j int) bool { return score(ranked[i]) > score(ranked[j]) })
+	return ranked
+}
+
+// youTubeVideoInfo returns the metadata of a searched video that is kept
+// with each of its comments
+func youTubeVideoInfo(video YouTubeSearchVideo) *YouTubeVideo {
+	info := &YouTubeVideo{
+		VideoID:   video.VideoID,
+		Title:     video.Title,
+		URL:       fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.VideoID),
+		Published: video.PublishedTimeText,
+		Views:     "N/A",
+		Duration:  "N/A",
+	}
+	if video.Author != nil {
+		info.Author = video.Author.Title
+	}
+	if video.Stats != nil {
+		info.Views = fmt.Sprintf("%d", video.Stats.Views)
+	}
+	if video.LengthSeconds > 0 {
+		info.Duration = fmt.Sprintf("%d:%02d", video.LengthSeconds/60, video.LengthSeconds%60)
+	}
+	return info
+}
@@ -0,0 +1,347 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ColumnType is the type of a Parquet column
+type ColumnType int
+
+// Column types. Every column is optional, so any value may be nil.
+const (
+	String    ColumnType = iota // UTF-8 byte array
+	Int64                       // 64-bit integer
+	Double                      // 64-bit float
+	Timestamp                   // milliseconds since the epoch, UTC
+)
+
+// Column is a named column of a Parquet file
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Parquet physical types, converted types, encodings and codecs
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	repetitionOptional = 1
+	codecGzip          = 2
+	pageTypeData       = 0
+)
+
+// parquetRowGroupSize is the number of rows buffered per row group
+const parquetRowGroupSize = 10000
+
+// parquetMagic starts and ends every Parquet file
+var parquetMagic = []byte("PAR1")
+
+// columnBuffer holds the values of one column of the current row group:
+// a definition level per row (1 when set, 0 when nil) and the PLAIN
+// encoding of the set values
+type columnBuffer struct {
+	levels []byte
+	values bytes.Buffer
+}
+
+// ParquetWriter streams rows into a Parquet file readable by pandas, Spark
+// and DuckDB, so exports need no Parquet library. Rows are written in
+// gzip-compressed row groups of parquetRowGroupSize rows with one PLAIN
+// encoded data page per column.
+type ParquetWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []Column
+	buffers   []columnBuffer
+	rows      int
+	rowGroups [][]byte // encoded RowGroup metadata
+	totalRows int64
+	err       error
+}
+
+// NewParquetWriter writes the file header to w and returns a writer for
+// rows of the given columns
+func NewParquetWriter(w io.Writer, columns []Column) *ParquetWriter {
+	pw := &ParquetWriter{w: w, columns: columns, buffers: make([]columnBuffer, len(columns))}
+	pw.write(parquetMagic)
+	return pw
+}
+
+// Write adds a row with one value per column: a string, int64, float64,
+// time.Time or pointer to one of them matching the column type, or nil
+func (pw *ParquetWriter) Write(row []interface{}) error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(row), len(pw.columns))
+	}
+
+	for i, value := range row {
+		buffer := &pw.buffers[i]
+		value = dereference(value)
+		if value == nil {
+			buffer.levels = append(buffer.levels, 0)
+			continue
+		}
+		if err := encodePlain(&buffer.values, pw.columns[i], value); err != nil {
+			return err
+		}
+		buffer.levels = append(buffer.levels, 1)
+	}
+
+	pw.rows++
+	if pw.rows >= parquetRowGroupSize {
+		pw.flushRowGroup()
+	}
+	return pw.err
+}
+
+// Close writes the buffered rows and the file footer. It does not close the
+// underlying writer.
+func (pw *ParquetWriter) Close() error {
+	if pw.rows > 0 {
+		pw.flushRowGroup()
+	}
+	if pw.err != nil {
+		return pw.err
+	}
+
+	footer := pw.fileMetadata()
+	pw.write(footer)
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(footer)))
+	pw.write(length)
+	pw.write(parquetMagic)
+	return pw.err
+}
+
+// write writes b to the file, keeping the first error
+func (pw *ParquetWriter) write(b []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	pw.err = err
+}
+
+// flushRowGroup writes the buffered rows as a row group
+func (pw *ParquetWriter) flushRowGroup() {
+	rowGroup := newCompactWriter()
+	rowGroup.list(1, thriftStruct, len(pw.columns))
+	var totalSize int64
+
+	for i, column := range pw.columns {
+		buffer := &pw.buffers[i]
+
+		var page bytes.Buffer
+		levels := encodeLevels(buffer.levels)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+		page.Write(buffer.values.Bytes())
+
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write(page.Bytes())
+		if err := gz.Close(); err != nil {
+			pw.err = fmt.Errorf("failed to compress column %s: %w", column.Name, err)
+			return
+		}
+
+		header := newCompactWriter()
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(compressed.Len()))
+		header.beginStruct(5)
+		header.i32(1, int32(pw.rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		headerBytes := header.Bytes()
+
+		pageOffset := pw.offset
+		pw.write(headerBytes)
+		pw.write(compressed.Bytes())
+		uncompressedSize := int64(len(headerBytes) + page.Len())
+		compressedSize := int64(len(headerBytes) + compressed.Len())
+		totalSize += uncompressedSize
+
+		// ColumnChunk with its ColumnMetaData
+		rowGroup.beginElement()
+		rowGroup.i64(2, pageOffset)
+		rowGroup.beginStruct(3)
+		rowGroup.i32(1, physicalType(column.Type))
+		rowGroup.list(2, thriftI32, 2)
+		rowGroup.zigzag(encodingPlain)
+		rowGroup.zigzag(encodingRLE)
+		rowGroup.list(3, thriftBinary, 1)
+		rowGroup.str(column.Name)
+		rowGroup.i32(4, codecGzip)
+		rowGroup.i64(5, int64(pw.rows))
+		rowGroup.i64(6, uncompressedSize)
+		rowGroup.i64(7, compressedSize)
+		rowGroup.i64(9, pageOffset)
+		rowGroup.endStruct()
+		rowGroup.endStruct()
+
+		buffer.levels = buffer.levels[:0]
+		buffer.values.Reset()
+	}
+
+	rowGroup.i64(2, totalSize)
+	rowGroup.i64(3, int64(pw.rows))
+	pw.rowGroups = append(pw.rowGroups, rowGroup.Bytes())
+	pw.totalRows += int64(pw.rows)
+	pw.rows = 0
+}
+
+// fileMetadata encodes the FileMetaData footer
+func (pw *ParquetWriter) fileMetadata() []byte {
+	meta := newCompactWriter()
+	meta.i32(1, 1)
+
+	meta.list(2, thriftStruct, len(pw.columns)+1)
+	meta.beginElement()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(pw.columns)))
+	meta.endStruct()
+	for _, column := range pw.columns {
+		meta.beginElement()
+		meta.i32(1, physicalType(column.Type))
+		meta.i32(3, repetitionOptional)
+		meta.binary(4, column.Name)
+		switch column.Type {
+		case String:
+			meta.i32(6, convertedUTF8)
+		case Timestamp:
+			meta.i32(6, convertedTimestampMillis)
+		}
+		meta.endStruct()
+	}
+
+	meta.i64(3, pw.totalRows)
+	meta.list(4, thriftStruct, len(pw.rowGroups))
+	for _, rowGroup := range pw.rowGroups {
+		// Row groups are encoded as top-level structs, ending with their stop
+		meta.buf = append(meta.buf, rowGroup...)
+	}
+	meta.binary(6, "covid19-kms")
+	return meta.Bytes()
+}
+
+// physicalType returns the Parquet physical type of a column type
+func physicalType(t ColumnType) int32 {
+	switch t {
+	case Int64, Timestamp:
+		return parquetInt64
+	case Double:
+		return parquetDouble
+	default:
+		return parquetByteArray
+	}
+}
+
+// dereference returns the value a typed pointer points to, or nil for a
+// nil pointer
+func dereference(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case *int:
+		if v != nil {
+			return int64(*v)
+		}
+	case *int64:
+		if v != nil {
+			return *v
+		}
+	case *float64:
+		if v != nil {
+			return *v
+		}
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+	case int:
+		return int64(v)
+	default:
+		return value
+	}
+	return nil
+}
+
+// encodePlain appends the PLAIN encoding of a value of a column
+func encodePlain(buf *bytes.Buffer, column Column, value interface{}) error {
+	var scratch [8]byte
+	switch column.Type {
+	case String:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		binary.LittleEndian.PutUint32(scratch[:4], uint32(len(s)))
+		buf.Write(scratch[:4])
+		buf.WriteString(s)
+		return nil
+	case Int64:
+		n, ok := value.(int64)
+		if !ok {
+			break
+		}
+		binary.LittleEndian.PutUint64(scratch[:], uint64(n))
+		buf.Write(scratch[:])
+		return nil
+	case Double:
+		f, ok := value.(float64)
+		if !ok {
+			break
+		}
+		binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(f))
+		buf.Write(scratch[:])
+		return nil
+	case Timestamp:
+		t, ok := value.(time.Time)
+		if !ok {
+			break
+		}
+		binary.LittleEndian.PutUint64(scratch[:], uint64(t.UnixMilli()))
+		buf.Write(scratch[:])
+		return nil
+	}
+	return fmt.Errorf("column %s cannot hold a %T", column.Name, value)
+}
+
+// encodeLevels encodes definition levels of bit width 1 as RLE runs of the
+// RLE/bit-packing hybrid encoding
+func encodeLevels(levels []byte) []byte {
+	var out []byte
+	for start := 0; start < len(levels); {
+		end := start + 1
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		out = binary.AppendUvarint(out, uint64(end-start)<<1)
+		out = append(out, levels[start])
+		start = end
+	}
+	return out
+}
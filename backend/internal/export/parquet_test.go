@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// TestParquetRoundTrip tests that a file written by ParquetWriter reads back
+// with an independent Parquet reader: schema, converted types, codec, nulls
+// and values across more than one row group
+func TestParquetRoundTrip(t *testing.T) {
+	columns := []Column{
+		{Name: "title", Type: String},
+		{Name: "views", Type: Int64},
+		{Name: "sentiment_score", Type: Double},
+		{Name: "published_at", Type: Timestamp},
+	}
+	published := time.Date(2021, 7, 14, 8, 30, 15, 250_000_000, time.UTC)
+	title := "Vaksinasi dosis kedua 💉 dimulai"
+	var missing *string
+
+	var buf bytes.Buffer
+	pw := NewParquetWriter(&buf, columns)
+	rows := [][]interface{}{
+		{title, int64(1200), -0.25, published},
+		{missing, nil, nil, nil},
+		{&title, 7, 0.0, &published},
+	}
+	for len(rows) < parquetRowGroupSize+2 {
+		rows = append(rows, []interface{}{"", int64(len(rows)), 1.5, published})
+	}
+	for _, row := range rows {
+		if err := pw.Write(row); err != nil {
+			t.Fatalf("Failed to write a row: %v", err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatalf("Failed to close the writer: %v", err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open the written file: %v", err)
+	}
+
+	metadata := file.Metadata()
+	if metadata.NumRows != int64(len(rows)) || len(metadata.RowGroups) != 2 {
+		t.Fatalf("Expected %d rows in 2 row groups, got %d rows in %d", len(rows), metadata.NumRows, len(metadata.RowGroups))
+	}
+	converted := map[string]deprecated.ConvertedType{}
+	for _, element := range metadata.Schema[1:] {
+		if element.ConvertedType != nil {
+			converted[element.Name] = *element.ConvertedType
+		}
+	}
+	if converted["title"] != deprecated.UTF8 || converted["published_at"] != deprecated.TimestampMillis || len(converted) != 2 {
+		t.Errorf("Expected UTF8 and TIMESTAMP_MILLIS annotations, got %v", converted)
+	}
+	for _, rowGroup := range metadata.RowGroups {
+		for _, chunk := range rowGroup.Columns {
+			if chunk.MetaData.Codec != format.Gzip {
+				t.Errorf("Expected GZIP pages for %v, got %v", chunk.MetaData.PathInSchema, chunk.MetaData.Codec)
+			}
+		}
+	}
+
+	var read []parquet.Row
+	for _, rowGroup := range file.RowGroups() {
+		reader := rowGroup.Rows()
+		batch := make([]parquet.Row, 1000)
+		for {
+			n, err := reader.ReadRows(batch)
+			for _, row := range batch[:n] {
+				read = append(read, row.Clone())
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to read rows: %v", err)
+			}
+		}
+		reader.Close()
+	}
+	if len(read) != len(rows) {
+		t.Fatalf("Expected %d rows back, got %d", len(rows), len(read))
+	}
+
+	first := read[0]
+	if string(first[0].ByteArray()) != title || first[1].Int64() != 1200 || first[2].Double() != -0.25 || first[3].Int64() != published.UnixMilli() {
+		t.Errorf("Expected the first row back unchanged, got %v", first)
+	}
+	for i, value := range read[1] {
+		if !value.IsNull() {
+			t.Errorf("Expected column %d of the second row to be null, got %v", i, value)
+		}
+	}
+	third := read[2]
+	if string(third[0].ByteArray()) != title || third[1].Int64() != 7 || third[2].Double() != 0 || third[3].Int64() != published.UnixMilli() {
+		t.Errorf("Expected the pointer values of the third row back, got %v", third)
+	}
+	if last := read[len(read)-1]; last[1].Int64() != int64(len(rows)-1) || last[0].IsNull() {
+		t.Errorf("Expected the last row of the second row group back, got %v", last)
+	}
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"io"
+
+	"covid19-kms/database"
+)
+
+// recordColumns are the columns of a processed record export; the
+// processed_data JSON is kept as a string for the per-source fields
+var recordColumns = []Column{
+	{Name: "id", Type: Int64},
+	{Name: "project_id", Type: String},
+	{Name: "source", Type: String},
+	{Name: "outlet", Type: String},
+	{Name: "published_at", Type: Timestamp},
+	{Name: "processed_at", Type: Timestamp},
+	{Name: "title", Type: String},
+	{Name: "content", Type: String},
+	{Name: "relevance_score", Type: Double},
+	{Name: "sentiment", Type: String},
+	{Name: "sentiment_score", Type: Double},
+	{Name: "sentiment_confidence", Type: Double},
+	{Name: "analyzer_version", Type: Int64},
+	{Name: "toxicity_score", Type: Double},
+	{Name: "campaign", Type: String},
+	{Name: "license", Type: String},
+	{Name: "batch_id", Type: String},
+	{Name: "duplicate_of", Type: Int64},
+	{Name: "processed_data", Type: String},
+}
+
+// WriteRecordsParquet streams the processed records matching filters, at
+// most maxRecords of them, to w as a Parquet file and returns their number
+func WriteRecordsParquet(w io.Writer, filters database.ProcessedDataFilter, maxRecords int) (int, error) {
+	writer := NewParquetWriter(w, recordColumns)
+
+	count := 0
+	err := database.StreamProcessedData(filters, maxRecords, func(record database.ProcessedData) error {
+		count++
+		return writer.Write([]interface{}{
+			record.ID,
+			record.ProjectID,
+			record.Source,
+			optionalString(record.Outlet),
+			record.PublishedAt,
+			record.ProcessedAt,
+			record.Title,
+			record.Content,
+			record.RelevanceScore,
+			record.Sentiment,
+			record.SentimentScore,
+			record.SentimentConfidence,
+			record.AnalyzerVersion,
+			record.ToxicityScore,
+			optionalString(record.Campaign),
+			optionalString(record.License),
+			optionalString(record.BatchID),
+			record.DuplicateOf,
+			record.ProcessedData,
+		})
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, writer.Close()
+}
+
+// optionalString returns nil for an empty string, so it is null in Parquet
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package export
+
+import "encoding/binary"
+
+// Thrift compact protocol type IDs used by the Parquet metadata
+const (
+	thriftI32    byte = 5
+	thriftI64    byte = 6
+	thriftBinary byte = 8
+	thriftList   byte = 9
+	thriftStruct byte = 12
+)
+
+// compactWriter encodes Thrift structs with the compact protocol, which is
+// how Parquet stores its page headers and file metadata. Fields must be
+// written in increasing ID order within a struct.
+type compactWriter struct {
+	buf  []byte
+	last []int16 // last field ID of each open struct
+}
+
+// newCompactWriter starts encoding a top-level struct
+func newCompactWriter() *compactWriter {
+	return &compactWriter{last: []int16{0}}
+}
+
+// Bytes ends the top-level struct and returns the encoding
+func (c *compactWriter) Bytes() []byte {
+	c.endStruct()
+	return c.buf
+}
+
+func (c *compactWriter) varint(v uint64) {
+	c.buf = binary.AppendUvarint(c.buf, v)
+}
+
+func (c *compactWriter) zigzag(v int64) {
+	c.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (c *compactWriter) field(id int16, typ byte) {
+	top := len(c.last) - 1
+	if delta := id - c.last[top]; delta > 0 && delta <= 15 {
+		c.buf = append(c.buf, byte(delta)<<4|typ)
+	} else {
+		c.buf = append(c.buf, typ)
+		c.zigzag(int64(id))
+	}
+	c.last[top] = id
+}
+
+func (c *compactWriter) i32(id int16, v int32) {
+	c.field(id, thriftI32)
+	c.zigzag(int64(v))
+}
+
+func (c *compactWriter) i64(id int16, v int64) {
+	c.field(id, thriftI64)
+	c.zigzag(v)
+}
+
+func (c *compactWriter) binary(id int16, v string) {
+	c.field(id, thriftBinary)
+	c.str(v)
+}
+
+// str writes a string list element
+func (c *compactWriter) str(v string) {
+	c.varint(uint64(len(v)))
+	c.buf = append(c.buf, v...)
+}
+
+// list writes the header of a list of n elements of type elem; the
+// elements follow with str, zigzag or beginElement
+func (c *compactWriter) list(id int16, elem byte, n int) {
+	c.field(id, thriftList)
+	if n < 15 {
+		c.buf = append(c.buf, byte(n)<<4|elem)
+	} else {
+		c.buf = append(c.buf, 0xf0|elem)
+		c.varint(uint64(n))
+	}
+}
+
+// beginStruct starts a struct field
+func (c *compactWriter) beginStruct(id int16) {
+	c.field(id, thriftStruct)
+	c.last = append(c.last, 0)
+}
+
+// beginElement starts a struct list element
+func (c *compactWriter) beginElement() {
+	c.last = append(c.last, 0)
+}
+
+// endStruct ends the innermost open struct
+func (c *compactWriter) endStruct() {
+	c.buf = append(c.buf, 0)
+	c.last = c.last[:len(c.last)-1]
+}
@@ -0,0 +1,955 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ResolveFunc resolves a field from its parent value and coerced arguments.
+// Int arguments are passed as int, Float as float64, String and ID as
+// string, Boolean as bool and lists as []interface{}; arguments that are
+// neither given nor defaulted are absent from args.
+type ResolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// Argument is an argument of a field. Type is a type reference in SDL
+// notation, e.g. "Int!" or "[String!]".
+type Argument struct {
+	Name        string
+	Type        string
+	Description string
+	Default     interface{}
+}
+
+// Field is a field of an object type. Without a resolver the field is read
+// from a map[string]interface{} parent by name.
+type Field struct {
+	Name        string
+	Type        string
+	Description string
+	Args        []Argument
+	Resolve     ResolveFunc
+}
+
+// Object is an object type of a schema
+type Object struct {
+	Name        string
+	Description string
+	Fields      []Field
+}
+
+// field returns the field with the given name
+func (o *Object) field(name string) *Field {
+	for i := range o.Fields {
+		if o.Fields[i].Name == name {
+			return &o.Fields[i]
+		}
+	}
+	return nil
+}
+
+// scalars are the built-in scalar types
+var scalars = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+// typeRef is a parsed type reference
+type typeRef struct {
+	name    string // named type, empty for a list
+	elem    *typeRef
+	nonNull bool
+}
+
+// parseTypeRef parses a type reference in SDL notation
+func parseTypeRef(s string) (*typeRef, error) {
+	ref := &typeRef{}
+	if strings.HasSuffix(s, "!") {
+		ref.nonNull = true
+		s = strings.TrimSuffix(s, "!")
+	}
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		elem, err := parseTypeRef(s[1 : len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		ref.elem = elem
+		return ref, nil
+	}
+	if s == "" || strings.ContainsAny(s, "[]! ") {
+		return nil, fmt.Errorf("invalid type reference %q", s)
+	}
+	ref.name = s
+	return ref, nil
+}
+
+// named returns the innermost named type
+func (t *typeRef) named() string {
+	for t.elem != nil {
+		t = t.elem
+	}
+	return t.name
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// Schema is an executable read-only schema: a query root type and the object
+// types reachable from it. Mutations, subscriptions and introspection
+// queries are not supported; SDL describes the schema instead.
+type Schema struct {
+	query   *Object
+	objects []*Object
+	types   map[string]*Object
+	refs    map[string]*typeRef
+}
+
+// NewSchema creates a schema from its query root type and the other object
+// types, and checks that every type reference resolves
+func NewSchema(query *Object, objects ...*Object) (*Schema, error) {
+	s := &Schema{
+		query: query,
+		types: make(map[string]*Object),
+		refs:  make(map[string]*typeRef),
+	}
+	for _, object := range append([]*Object{query}, objects...) {
+		if scalars[object.Name] || s.types[object.Name] != nil {
+			return nil, fmt.Errorf("type %s is defined more than once", object.Name)
+		}
+		if len(object.Fields) == 0 {
+			return nil, fmt.Errorf("type %s has no fields", object.Name)
+		}
+		s.types[object.Name] = object
+		s.objects = append(s.objects, object)
+	}
+
+	for _, object := range s.objects {
+		for _, f := range object.Fields {
+			ref, err := s.checkRef(f.Type, false)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %v", object.Name, f.Name, err)
+			}
+			s.refs[f.Type] = ref
+			for _, arg := range f.Args {
+				ref, err := s.checkRef(arg.Type, true)
+				if err != nil {
+					return nil, fmt.Errorf("argument %s.%s(%s): %v", object.Name, f.Name, arg.Name, err)
+				}
+				s.refs[arg.Type] = ref
+			}
+		}
+	}
+	return s, nil
+}
+
+// MustSchema is like NewSchema but panics on an invalid schema
+func MustSchema(query *Object, objects ...*Object) *Schema {
+	s, err := NewSchema(query, objects...)
+	if err != nil {
+		panic(fmt.Sprintf("graphql: %v", err))
+	}
+	return s
+}
+
+// ref returns a type reference of the schema, or parses one from a query,
+// such as a variable type; input types must be scalars. Only NewSchema
+// fills the cache, so executing queries concurrently is safe.
+func (s *Schema) ref(typ string, input bool) (*typeRef, error) {
+	if ref, ok := s.refs[typ]; ok && (!input || scalars[ref.named()]) {
+		return ref, nil
+	}
+	return s.checkRef(typ, input)
+}
+
+// checkRef parses a type reference and checks that its named type exists
+func (s *Schema) checkRef(typ string, input bool) (*typeRef, error) {
+	ref, err := parseTypeRef(typ)
+	if err != nil {
+		return nil, err
+	}
+	name := ref.named()
+	switch {
+	case scalars[name]:
+	case input:
+		return nil, fmt.Errorf("%s is not an input type", name)
+	case s.types[name] == nil:
+		return nil, fmt.Errorf("unknown type %s", name)
+	}
+	return ref, nil
+}
+
+// Request is a GraphQL request as posted by clients
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is absent when the request
+// could not be executed at all.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a request or field error
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Location is the line and column of a field in the query
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Execute validates and runs a query
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	if strings.TrimSpace(req.Query) == "" {
+		return errorResponse(&Error{Message: "query is required"})
+	}
+	doc, err := parse(req.Query)
+	if err != nil {
+		return errorResponse(&Error{Message: err.Error()})
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return errorResponse(&Error{Message: err.Error()})
+	}
+	if op.kind != "query" {
+		return errorResponse(&Error{Message: fmt.Sprintf("%s operations are not supported, the schema is read-only", op.kind)})
+	}
+
+	e := &executor{schema: s, doc: doc, src: req.Query}
+	if errs := e.validate(op); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	variables, errs := e.coerceVariables(op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	e.variables = variables
+
+	data, _ := e.executeSelections(ctx, s.query, nil, op.selections, nil)
+	response := &Response{Errors: e.errors}
+	if data != nil {
+		response.Data = data
+	}
+	return response
+}
+
+func errorResponse(err *Error) *Response {
+	return &Response{Errors: []*Error{err}}
+}
+
+// selectOperation picks the operation to run from a document
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document contains several operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// executor runs one operation of a parsed document
+type executor struct {
+	schema    *Schema
+	doc       *document
+	src       string
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+// fieldError builds an error located at a field
+func (e *executor) fieldError(f *field, path []interface{}, format string, args ...interface{}) *Error {
+	line, column := position(e.src, f.pos)
+	err := &Error{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []Location{{Line: line, Column: column}},
+	}
+	if path != nil {
+		err.Path = append([]interface{}(nil), path...)
+	}
+	return err
+}
+
+// validate checks an operation against the schema before it runs
+func (e *executor) validate(op *operation) []*Error {
+	v := &validator{executor: e, variables: make(map[string]variableDef)}
+	for _, def := range op.variables {
+		if _, exists := v.variables[def.name]; exists {
+			v.errs = append(v.errs, &Error{Message: fmt.Sprintf("variable $%s is declared more than once", def.name)})
+			continue
+		}
+		ref, err := e.schema.ref(def.typ, true)
+		if err != nil {
+			v.errs = append(v.errs, &Error{Message: fmt.Sprintf("variable $%s: %v", def.name, err)})
+			continue
+		}
+		if def.defaultValue != nil {
+			if _, err := coerceLiteral(*def.defaultValue, ref, nil); err != nil {
+				v.errs = append(v.errs, &Error{Message: fmt.Sprintf("variable $%s: default value: %v", def.name, err)})
+			}
+		}
+		v.variables[def.name] = def
+	}
+	v.selections(e.schema.query, op.selections, nil)
+	return v.errs
+}
+
+// validator walks the selections of an operation and collects errors
+type validator struct {
+	*executor
+	variables map[string]variableDef
+	errs      []*Error
+}
+
+func (v *validator) selections(object *Object, selections []selection, spreading []string) {
+	fields := make(map[string]*field)
+	v.collect(object, selections, spreading, fields)
+}
+
+// collect validates selections and checks that fields sharing a response
+// key select the same field
+func (v *validator) collect(object *Object, selections []selection, spreading []string, fields map[string]*field) {
+	for _, sel := range selections {
+		for _, d := range sel.directives {
+			v.directive(d)
+		}
+
+		switch {
+		case sel.field != nil:
+			f := sel.field
+			if previous, ok := fields[f.responseKey()]; ok && previous.name != f.name {
+				v.errs = append(v.errs, v.fieldError(f, nil, "fields %q and %q both use the response key %q", previous.name, f.name, f.responseKey()))
+			}
+			fields[f.responseKey()] = f
+			v.field(object, f, spreading)
+
+		case sel.inline != nil:
+			if sel.inline.typeCondition != "" && sel.inline.typeCondition != object.Name {
+				v.errs = append(v.errs, &Error{Message: fmt.Sprintf("inline fragment on %s cannot be spread on type %s", sel.inline.typeCondition, object.Name)})
+				continue
+			}
+			v.collect(object, sel.inline.selections, spreading, fields)
+
+		default:
+			fragment, ok := v.doc.fragments[sel.fragmentSpread]
+			if !ok {
+				v.errs = append(v.errs, &Error{Message: fmt.Sprintf("unknown fragment %q", sel.fragmentSpread)})
+				continue
+			}
+			if contains(spreading, fragment.name) {
+				v.errs = append(v.errs, &Error{Message: fmt.Sprintf("fragment %q spreads itself", fragment.name)})
+				continue
+			}
+			if fragment.typeCondition != object.Name {
+				v.errs = append(v.errs, &Error{Message: fmt.Sprintf("fragment %q on %s cannot be spread on type %s", fragment.name, fragment.typeCondition, object.Name)})
+				continue
+			}
+			v.collect(object, fragment.selections, append(spreading, fragment.name), fields)
+		}
+	}
+}
+
+func (v *validator) field(object *Object, f *field, spreading []string) {
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 || f.selections != nil {
+			v.errs = append(v.errs, v.fieldError(f, nil, "__typename takes no arguments or selections"))
+		}
+		return
+	}
+
+	def := object.field(f.name)
+	if def == nil {
+		v.errs = append(v.errs, v.fieldError(f, nil, "cannot query field %q on type %s", f.name, object.Name))
+		return
+	}
+
+	given := make(map[string]bool)
+	for _, arg := range f.arguments {
+		argDef := findArgument(def.Args, arg.name)
+		if argDef == nil {
+			v.errs = append(v.errs, v.fieldError(f, nil, "unknown argument %q on field %s.%s", arg.name, object.Name, f.name))
+			continue
+		}
+		given[arg.name] = true
+		ref, _ := v.schema.ref(argDef.Type, true)
+		v.value(f, arg.value, ref, fmt.Sprintf("argument %q", arg.name))
+	}
+	for _, argDef := range def.Args {
+		ref, _ := v.schema.ref(argDef.Type, true)
+		if ref.nonNull && argDef.Default == nil && !given[argDef.Name] {
+			v.errs = append(v.errs, v.fieldError(f, nil, "field %s.%s requires argument %q of type %s", object.Name, f.name, argDef.Name, argDef.Type))
+		}
+	}
+
+	ref, _ := v.schema.ref(def.Type, false)
+	child := v.schema.types[ref.named()]
+	switch {
+	case child == nil && f.selections != nil:
+		v.errs = append(v.errs, v.fieldError(f, nil, "field %q of type %s cannot have selections", f.name, def.Type))
+	case child != nil && f.selections == nil:
+		v.errs = append(v.errs, v.fieldError(f, nil, "field %q of type %s must have selections", f.name, def.Type))
+	case child != nil:
+		v.selections(child, f.selections, spreading)
+	}
+}
+
+// value checks a literal against its type and the variables it uses
+// against their declarations
+func (v *validator) value(f *field, val value, ref *typeRef, what string) {
+	if val.kind == valueVariable {
+		def, ok := v.variables[val.raw]
+		if !ok {
+			v.errs = append(v.errs, v.fieldError(f, nil, "%s uses undeclared variable $%s", what, val.raw))
+			return
+		}
+		varRef, err := v.schema.ref(def.typ, true)
+		if err != nil {
+			return
+		}
+		if !assignable(varRef, ref, def.defaultValue != nil) {
+			v.errs = append(v.errs, v.fieldError(f, nil, "%s expects %s, variable $%s is %s", what, ref, val.raw, def.typ))
+		}
+		return
+	}
+
+	if val.kind == valueList && ref.elem != nil {
+		for _, item := range val.list {
+			v.value(f, item, ref.elem, what)
+		}
+		return
+	}
+	if _, err := coerceLiteral(val, ref, map[string]interface{}{}); err != nil {
+		v.errs = append(v.errs, v.fieldError(f, nil, "%s: %v", what, err))
+	}
+}
+
+func (v *validator) directive(d directive) {
+	if d.name != "include" && d.name != "skip" {
+		v.errs = append(v.errs, &Error{Message: fmt.Sprintf("unknown directive @%s", d.name)})
+		return
+	}
+	if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+		v.errs = append(v.errs, &Error{Message: fmt.Sprintf("directive @%s takes exactly the argument \"if\"", d.name)})
+		return
+	}
+	val := d.arguments[0].value
+	if val.kind == valueVariable {
+		if _, ok := v.variables[val.raw]; !ok {
+			v.errs = append(v.errs, &Error{Message: fmt.Sprintf("directive @%s uses undeclared variable $%s", d.name, val.raw)})
+		}
+		return
+	}
+	if val.kind != valueBoolean {
+		v.errs = append(v.errs, &Error{Message: fmt.Sprintf("directive @%s expects a Boolean", d.name)})
+	}
+}
+
+// assignable reports whether a variable of type from can be passed where
+// type to is expected; Int variables may be passed as Float
+func assignable(from, to *typeRef, hasDefault bool) bool {
+	if to.nonNull && !from.nonNull && !hasDefault {
+		return false
+	}
+	if (from.elem == nil) != (to.elem == nil) {
+		return false
+	}
+	if from.elem != nil {
+		return assignable(from.elem, to.elem, false)
+	}
+	return from.name == to.name || (from.name == "Int" && to.name == "Float")
+}
+
+// coerceVariables applies defaults to the variables of a request and
+// coerces them to their declared types
+func (e *executor) coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, []*Error) {
+	variables := make(map[string]interface{})
+	var errs []*Error
+	for _, def := range op.variables {
+		ref, _ := e.schema.ref(def.typ, true)
+		raw, ok := given[def.name]
+		if !ok {
+			switch {
+			case def.defaultValue != nil:
+				variables[def.name], _ = coerceLiteral(*def.defaultValue, ref, nil)
+			case ref.nonNull:
+				errs = append(errs, &Error{Message: fmt.Sprintf("variable $%s of type %s is required", def.name, def.typ)})
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(raw, ref)
+		if err != nil {
+			errs = append(errs, &Error{Message: fmt.Sprintf("variable $%s: %v", def.name, err)})
+			continue
+		}
+		variables[def.name] = coerced
+	}
+	return variables, errs
+}
+
+// coerceInput coerces a JSON-decoded variable value to an input type
+func coerceInput(raw interface{}, ref *typeRef) (interface{}, error) {
+	if raw == nil {
+		if ref.nonNull {
+			return nil, fmt.Errorf("expected a non-null %s", ref)
+		}
+		return nil, nil
+	}
+
+	if ref.elem != nil {
+		items, ok := raw.([]interface{})
+		if !ok {
+			// A single value is accepted where a list is expected
+			items = []interface{}{raw}
+		}
+		list := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			coerced, err := coerceInput(item, ref.elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, coerced)
+		}
+		return list, nil
+	}
+
+	switch ref.name {
+	case "Int":
+		if n, ok := raw.(float64); ok && n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int(n), nil
+		}
+	case "Float":
+		if n, ok := raw.(float64); ok {
+			return n, nil
+		}
+	case "String":
+		if s, ok := raw.(string); ok {
+			return s, nil
+		}
+	case "ID":
+		switch id := raw.(type) {
+		case string:
+			return id, nil
+		case float64:
+			if id == math.Trunc(id) {
+				return strconv.FormatFloat(id, 'f', -1, 64), nil
+			}
+		}
+	case "Boolean":
+		if b, ok := raw.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot use %v as %s", raw, ref.name)
+}
+
+// coerceLiteral coerces a literal from the query to an input type; variables
+// are looked up in variables, or rejected when variables is nil
+func coerceLiteral(val value, ref *typeRef, variables map[string]interface{}) (interface{}, error) {
+	switch val.kind {
+	case valueVariable:
+		if variables == nil {
+			return nil, fmt.Errorf("variables are not allowed here")
+		}
+		return variables[val.raw], nil
+	case valueNull:
+		if ref.nonNull {
+			return nil, fmt.Errorf("expected a non-null %s", ref)
+		}
+		return nil, nil
+	}
+
+	if ref.elem != nil {
+		items := val.list
+		if val.kind != valueList {
+			items = []value{val}
+		}
+		list := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			coerced, err := coerceLiteral(item, ref.elem, variables)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, coerced)
+		}
+		return list, nil
+	}
+
+	switch {
+	case ref.name == "Int" && val.kind == valueInt:
+		n, err := strconv.ParseInt(val.raw, 10, 32)
+		if err == nil {
+			return int(n), nil
+		}
+	case ref.name == "Float" && (val.kind == valueInt || val.kind == valueFloat):
+		return strconv.ParseFloat(val.raw, 64)
+	case ref.name == "String" && val.kind == valueString:
+		return val.raw, nil
+	case ref.name == "ID" && (val.kind == valueString || val.kind == valueInt):
+		return val.raw, nil
+	case ref.name == "Boolean" && val.kind == valueBoolean:
+		return val.raw == "true", nil
+	}
+	return nil, fmt.Errorf("cannot use %s as %s", literalString(val), ref.name)
+}
+
+// literalString renders a literal for error messages
+func literalString(val value) string {
+	switch val.kind {
+	case valueString:
+		return strconv.Quote(val.raw)
+	case valueList:
+		return "a list"
+	case valueObject:
+		return "an object"
+	}
+	return val.raw
+}
+
+// fieldGroup is the fields of a selection set sharing a response key
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+// collectFields resolves fragments and directives into the fields to run,
+// in query order
+func (e *executor) collectFields(selections []selection, groups []*fieldGroup, index map[string]*fieldGroup) []*fieldGroup {
+	for _, sel := range selections {
+		if !e.included(sel.directives) {
+			continue
+		}
+		switch {
+		case sel.field != nil:
+			key := sel.field.responseKey()
+			if group, ok := index[key]; ok {
+				group.fields = append(group.fields, sel.field)
+				continue
+			}
+			group := &fieldGroup{key: key, fields: []*field{sel.field}}
+			index[key] = group
+			groups = append(groups, group)
+		case sel.inline != nil:
+			groups = e.collectFields(sel.inline.selections, groups, index)
+		default:
+			groups = e.collectFields(e.doc.fragments[sel.fragmentSpread].selections, groups, index)
+		}
+	}
+	return groups
+}
+
+// included evaluates the @include and @skip directives of a selection
+func (e *executor) included(directives []directive) bool {
+	for _, d := range directives {
+		condition, _ := coerceLiteral(d.arguments[0].value, &typeRef{name: "Boolean"}, e.variables)
+		if b, _ := condition.(bool); b != (d.name == "include") {
+			return false
+		}
+	}
+	return true
+}
+
+// executeSelections resolves the selected fields of an object. It returns
+// false when a non-null field failed, which nulls the object itself.
+func (e *executor) executeSelections(ctx context.Context, object *Object, source interface{}, selections []selection, path []interface{}) (*orderedMap, bool) {
+	result := &orderedMap{values: make(map[string]interface{})}
+	for _, group := range e.collectFields(selections, nil, make(map[string]*fieldGroup)) {
+		f := group.fields[0]
+		fieldPath := append(append([]interface{}(nil), path...), group.key)
+
+		if f.name == "__typename" {
+			result.set(group.key, object.Name)
+			continue
+		}
+
+		def := object.field(f.name)
+		ref, _ := e.schema.ref(def.Type, false)
+		resolved, err := e.resolve(ctx, def, f, source)
+		if err != nil {
+			e.errors = append(e.errors, e.fieldError(f, fieldPath, "%v", err))
+			if ref.nonNull {
+				return nil, false
+			}
+			result.set(group.key, nil)
+			continue
+		}
+
+		var children []selection
+		for _, merged := range group.fields {
+			children = append(children, merged.selections...)
+		}
+		completed, ok := e.complete(ctx, ref, f, resolved, children, fieldPath)
+		if !ok {
+			return nil, false
+		}
+		result.set(group.key, completed)
+	}
+	return result, true
+}
+
+// resolve coerces the arguments of a field and runs its resolver
+func (e *executor) resolve(ctx context.Context, def *Field, f *field, source interface{}) (result interface{}, err error) {
+	args := make(map[string]interface{})
+	for _, argDef := range def.Args {
+		if argDef.Default != nil {
+			args[argDef.Name] = argDef.Default
+		}
+	}
+	for _, arg := range f.arguments {
+		argDef := findArgument(def.Args, arg.name)
+		if arg.value.kind == valueVariable {
+			if _, ok := e.variables[arg.value.raw]; !ok {
+				// An omitted variable leaves the argument unset
+				continue
+			}
+		}
+		ref, _ := e.schema.ref(argDef.Type, true)
+		coerced, err := coerceLiteral(arg.value, ref, e.variables)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", arg.name, err)
+		}
+		if coerced == nil && ref.nonNull {
+			return nil, fmt.Errorf("argument %q of type %s must not be null", arg.name, argDef.Type)
+		}
+		args[arg.name] = coerced
+	}
+
+	if def.Resolve == nil {
+		if m, ok := source.(map[string]interface{}); ok {
+			return m[def.Name], nil
+		}
+		return nil, nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("internal error resolving %s", def.Name)
+		}
+	}()
+	return def.Resolve(ctx, source, args)
+}
+
+// complete converts a resolved value to its type: scalars are checked and
+// normalized, lists and objects are completed recursively
+func (e *executor) complete(ctx context.Context, ref *typeRef, f *field, resolved interface{}, selections []selection, path []interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(resolved)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			rv = reflect.Value{}
+			break
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		if ref.nonNull {
+			e.errors = append(e.errors, e.fieldError(f, path, "non-null field %q resolved to null", f.name))
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if ref.elem != nil {
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.errors = append(e.errors, e.fieldError(f, path, "field %q expects a list", f.name))
+			return nil, !ref.nonNull
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			item, ok := e.complete(ctx, ref.elem, f, rv.Index(i).Interface(), selections, append(path, i))
+			if !ok {
+				if ref.nonNull {
+					return nil, false
+				}
+				return nil, true
+			}
+			list[i] = item
+		}
+		return list, true
+	}
+
+	if object := e.schema.types[ref.name]; object != nil {
+		result, ok := e.executeSelections(ctx, object, rv.Interface(), selections, path)
+		if !ok {
+			return nil, !ref.nonNull
+		}
+		return result, true
+	}
+
+	scalar, err := serialize(ref.name, rv)
+	if err != nil {
+		e.errors = append(e.errors, e.fieldError(f, path, "%v", err))
+		return nil, !ref.nonNull
+	}
+	return scalar, true
+}
+
+// serialize converts a resolved value to the JSON value of a scalar type
+func serialize(scalar string, rv reflect.Value) (interface{}, error) {
+	switch scalar {
+	case "Int":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return rv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return rv.Uint(), nil
+		case reflect.Float32, reflect.Float64:
+			if n := rv.Float(); n == math.Trunc(n) {
+				return int64(n), nil
+			}
+		}
+	case "Float":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(rv.Int()), nil
+		case reflect.Float32, reflect.Float64:
+			if n := rv.Float(); !math.IsNaN(n) && !math.IsInf(n, 0) {
+				return n, nil
+			}
+		}
+	case "String", "ID":
+		switch rv.Kind() {
+		case reflect.String:
+			return rv.String(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(rv.Int(), 10), nil
+		}
+		if s, ok := rv.Interface().(fmt.Stringer); ok {
+			return s.String(), nil
+		}
+	case "Boolean":
+		if rv.Kind() == reflect.Bool {
+			return rv.Bool(), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot serialize %v as %s", rv.Interface(), scalar)
+}
+
+func findArgument(args []Argument, name string) *Argument {
+	for i := range args {
+		if args[i].Name == name {
+			return &args[i]
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedMap is a JSON object that keeps the order of the selected fields
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON encodes the map with its keys in selection order
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(encodedKey)
+		b.WriteByte(':')
+		b.Write(encodedValue)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// SDL renders the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "schema {\n  query: %s\n}\n", s.query.Name)
+
+	for _, object := range s.objects {
+		b.WriteByte('\n')
+		if object.Description != "" {
+			fmt.Fprintf(&b, "%s\n", description(object.Description, ""))
+		}
+		fmt.Fprintf(&b, "type %s {\n", object.Name)
+		for _, f := range object.Fields {
+			if f.Description != "" {
+				fmt.Fprintf(&b, "%s\n", description(f.Description, "  "))
+			}
+			fmt.Fprintf(&b, "  %s%s: %s\n", f.Name, sdlArguments(f.Args), f.Type)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// sdlArguments renders the argument list of a field
+func sdlArguments(args []Argument) string {
+	if len(args) == 0 {
+		return ""
+	}
+	rendered := make([]string, 0, len(args))
+	for _, arg := range args {
+		s := arg.Name + ": " + arg.Type
+		if arg.Default != nil {
+			encoded, _ := json.Marshal(arg.Default)
+			s += " = " + string(encoded)
+		}
+		rendered = append(rendered, s)
+	}
+	return "(" + strings.Join(rendered, ", ") + ")"
+}
+
+// description renders a description as a string or block string
+func description(text, indent string) string {
+	if !strings.Contains(text, "\n") {
+		return indent + strconv.Quote(text)
+	}
+	lines := strings.Split(text, "\n")
+	return indent + `"""` + "\n" + indent + strings.Join(lines, "\n"+indent) + "\n" + indent + `"""`
+}
@@ -0,0 +1,207 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testSchema() *Schema {
+	items := []map[string]interface{}{
+		{"id": 1, "title": "Vaccine drive", "score": 0.5, "tags": []string{"health"}},
+		{"id": 2, "title": "Lockdown", "score": nil, "tags": []string{}},
+	}
+
+	item := &Object{
+		Name: "Item",
+		Fields: []Field{
+			{Name: "id", Type: "Int!"},
+			{Name: "title", Type: "String!"},
+			{Name: "score", Type: "Float"},
+			{Name: "tags", Type: "[String!]!"},
+			{Name: "broken", Type: "String", Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return nil, errors.New("boom")
+			}},
+		},
+	}
+	query := &Object{
+		Name: "Query",
+		Fields: []Field{
+			{
+				Name: "items",
+				Type: "[Item!]!",
+				Args: []Argument{{Name: "limit", Type: "Int", Default: 10}, {Name: "title", Type: "String"}},
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					var matched []map[string]interface{}
+					for _, item := range items {
+						if title, ok := args["title"].(string); ok && item["title"] != title {
+							continue
+						}
+						matched = append(matched, item)
+					}
+					if limit := args["limit"].(int); limit < len(matched) {
+						matched = matched[:limit]
+					}
+					return matched, nil
+				},
+			},
+			{
+				Name: "item",
+				Type: "Item",
+				Args: []Argument{{Name: "id", Type: "Int!"}},
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					for _, item := range items {
+						if item["id"] == args["id"] {
+							return item, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+			{
+				Name: "required",
+				Type: "String!",
+				Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					return nil, errors.New("unavailable")
+				},
+			},
+		},
+	}
+	return MustSchema(query, item)
+}
+
+func execute(t *testing.T, req Request) (string, []*Error) {
+	t.Helper()
+	response := testSchema().Execute(context.Background(), req)
+	data, err := json.Marshal(response.Data)
+	if err != nil {
+		t.Fatalf("Failed to encode the response: %v", err)
+	}
+	return string(data), response.Errors
+}
+
+func TestExecuteQuery(t *testing.T) {
+	data, errs := execute(t, Request{Query: `
+		# Fields come back in the order they were selected
+		query Items($limit: Int = 1) {
+			first: items(limit: $limit) { title id __typename }
+			items { ...itemFields }
+		}
+		fragment itemFields on Item { id ... on Item { score tags } }
+	`})
+
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs[0])
+	}
+	expected := `{"first":[{"title":"Vaccine drive","id":1,"__typename":"Item"}],` +
+		`"items":[{"id":1,"score":0.5,"tags":["health"]},{"id":2,"score":null,"tags":[]}]}`
+	if data != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestExecuteVariablesAndDirectives(t *testing.T) {
+	data, errs := execute(t, Request{
+		Query:     `query ($id: Int!, $full: Boolean!) { item(id: $id) { id title @include(if: $full) score @skip(if: true) } }`,
+		Variables: map[string]interface{}{"id": float64(2), "full": false},
+	})
+
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs[0])
+	}
+	if expected := `{"item":{"id":2}}`; data != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestExecuteFieldErrors(t *testing.T) {
+	data, errs := execute(t, Request{Query: `{ items(title: "Lockdown") { id broken } }`})
+
+	if expected := `{"items":[{"id":2,"broken":null}]}`; data != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+	if len(errs) != 1 || errs[0].Message != "boom" {
+		t.Fatalf("Expected the resolver error, got %v", errs)
+	}
+	path, _ := json.Marshal(errs[0].Path)
+	if string(path) != `["items",0,"broken"]` {
+		t.Errorf("Expected the error path of the field, got %s", path)
+	}
+	if errs[0].Locations[0].Line != 1 || errs[0].Locations[0].Column != 40 {
+		t.Errorf("Expected the error at 1:40, got %+v", errs[0].Locations[0])
+	}
+
+	// A failed non-null field nulls its parent, here the whole result
+	data, errs = execute(t, Request{Query: `{ items { id } required }`})
+	if data != "null" || len(errs) != 1 || errs[0].Message != "unavailable" {
+		t.Errorf("Expected null data and the resolver error, got %s %v", data, errs)
+	}
+}
+
+func TestExecuteRejectsInvalidQueries(t *testing.T) {
+	tests := []struct {
+		query string
+		error string
+	}{
+		{`{ items { id `, "syntax error"},
+		{`{ items { missing } }`, `cannot query field "missing" on type Item`},
+		{`{ items }`, "must have selections"},
+		{`{ items { id { title } } }`, "cannot have selections"},
+		{`{ item { id } }`, `requires argument "id"`},
+		{`{ items(limit: "ten") { id } }`, `cannot use "ten" as Int`},
+		{`{ items(page: 2) { id } }`, `unknown argument "page"`},
+		{`query ($title: Int) { items(title: $title) { id } }`, "variable $title is Int"},
+		{`{ items(title: $title) { id } }`, "undeclared variable $title"},
+		{`{ items { ...missing } }`, `unknown fragment "missing"`},
+		{`{ items { id: title id } }`, "both use the response key"},
+		{`mutation { items { id } }`, "mutation operations are not supported"},
+		{`query A { items { id } } query B { items { id } }`, "operationName is required"},
+	}
+
+	for _, test := range tests {
+		data, errs := execute(t, Request{Query: test.query})
+		if data != "null" {
+			t.Errorf("%s: expected no data, got %s", test.query, data)
+		}
+		if len(errs) == 0 || !strings.Contains(errs[0].Message, test.error) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.query, test.error, errs)
+		}
+	}
+}
+
+func TestExecuteRequiredVariable(t *testing.T) {
+	_, errs := execute(t, Request{Query: `query ($id: Int!) { item(id: $id) { id } }`})
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "$id of type Int! is required") {
+		t.Errorf("Expected a missing variable error, got %v", errs)
+	}
+
+	_, errs = execute(t, Request{
+		Query:     `query ($id: Int!) { item(id: $id) { id } }`,
+		Variables: map[string]interface{}{"id": 1.5},
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "cannot use 1.5 as Int") {
+		t.Errorf("Expected a variable type error, got %v", errs)
+	}
+}
+
+func TestSDL(t *testing.T) {
+	sdl := testSchema().SDL()
+	for _, expected := range []string{
+		"schema {\n  query: Query\n}",
+		"  items(limit: Int = 10, title: String): [Item!]!",
+		"type Item {\n  id: Int!",
+	} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("Expected the SDL to contain %q, got:\n%s", expected, sdl)
+		}
+	}
+}
+
+func TestNewSchemaRejectsUnknownTypes(t *testing.T) {
+	query := &Object{Name: "Query", Fields: []Field{{Name: "item", Type: "Missing"}}}
+	if _, err := NewSchema(query); err == nil || !strings.Contains(err.Error(), "unknown type Missing") {
+		t.Errorf("Expected an unknown type error, got %v", err)
+	}
+}
@@ -0,0 +1,551 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind is the lexical class of a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a lexical token of a query and its byte offset
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// document is a parsed query document
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a query operation with its variable definitions
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDef
+	selections []selection
+}
+
+// variableDef declares an operation variable, e.g. $source: String = "youtube"
+type variableDef struct {
+	name         string
+	typ          string
+	defaultValue *value
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection struct {
+	field          *field
+	fragmentSpread string
+	inline         *fragment
+	directives     []directive
+}
+
+// field is a selected field with its alias, arguments and sub-selections
+type field struct {
+	alias      string
+	name       string
+	arguments  []argument
+	selections []selection
+	pos        int
+}
+
+// responseKey is the key of a field in the response
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// argument is a named argument value of a field or directive
+type argument struct {
+	name  string
+	value value
+}
+
+// directive is a directive applied to a selection, e.g. @include(if: $full)
+type directive struct {
+	name      string
+	arguments []argument
+}
+
+// fragment is a named or inline fragment; typeCondition is empty for an
+// inline fragment without one
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+}
+
+// valueKind is the kind of a literal or variable value
+type valueKind int
+
+const (
+	valueVariable valueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueBoolean
+	valueNull
+	valueEnum
+	valueList
+	valueObject
+)
+
+// value is a literal or variable value in a query
+type value struct {
+	kind   valueKind
+	raw    string
+	list   []value
+	fields []argument
+}
+
+// parser is a recursive descent parser over the tokens of a query
+type parser struct {
+	src   string
+	pos   int
+	token token
+}
+
+// parse parses a query document
+func parse(src string) (doc *document, err error) {
+	p := &parser{src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			syntax, ok := r.(syntaxError)
+			if !ok {
+				panic(r)
+			}
+			err = syntax
+		}
+	}()
+
+	p.advance()
+	doc = &document{fragments: make(map[string]*fragment)}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: p.parseSelectionSet()})
+		case p.token.kind == tokenName && p.token.value == "fragment":
+			fragment := p.parseFragmentDefinition()
+			if _, exists := doc.fragments[fragment.name]; exists {
+				p.fail("fragment %q is defined more than once", fragment.name)
+			}
+			doc.fragments[fragment.name] = fragment
+		case p.token.kind == tokenName:
+			doc.operations = append(doc.operations, p.parseOperation())
+		default:
+			p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("syntax error: the document contains no operation")
+	}
+	return doc, nil
+}
+
+// syntaxError is raised by the parser and recovered by parse
+type syntaxError struct {
+	message string
+}
+
+func (e syntaxError) Error() string {
+	return e.message
+}
+
+// fail aborts parsing with an error at the current token
+func (p *parser) fail(format string, args ...interface{}) {
+	line, column := position(p.src, p.token.pos)
+	panic(syntaxError{fmt.Sprintf("syntax error at %d:%d: %s", line, column, fmt.Sprintf(format, args...))})
+}
+
+// unexpected aborts parsing at an unexpected token
+func (p *parser) unexpected() {
+	if p.token.kind == tokenEOF {
+		p.fail("unexpected end of document")
+	}
+	p.fail("unexpected %q", p.token.value)
+}
+
+// peek reports whether the current token is the punctuator punct
+func (p *parser) peek(punct string) bool {
+	return p.token.kind == tokenPunct && p.token.value == punct
+}
+
+// expect consumes the punctuator punct
+func (p *parser) expect(punct string) {
+	if !p.peek(punct) {
+		p.fail("expected %q, got %q", punct, p.token.value)
+	}
+	p.advance()
+}
+
+// skip consumes the punctuator punct if it is the current token
+func (p *parser) skip(punct string) bool {
+	if p.peek(punct) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+// name consumes a name
+func (p *parser) name() string {
+	if p.token.kind != tokenName {
+		p.fail("expected a name, got %q", p.token.value)
+	}
+	name := p.token.value
+	p.advance()
+	return name
+}
+
+func (p *parser) parseOperation() *operation {
+	op := &operation{kind: p.name()}
+	if op.kind != "query" && op.kind != "mutation" && op.kind != "subscription" {
+		p.fail("unknown operation type %q", op.kind)
+	}
+	if p.token.kind == tokenName {
+		op.name = p.name()
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			p.expect("$")
+			def := variableDef{name: p.name()}
+			p.expect(":")
+			def.typ = p.parseType()
+			if p.skip("=") {
+				defaultValue := p.parseValue(true)
+				def.defaultValue = &defaultValue
+			}
+			op.variables = append(op.variables, def)
+		}
+	}
+	p.parseDirectives()
+	op.selections = p.parseSelectionSet()
+	return op
+}
+
+func (p *parser) parseFragmentDefinition() *fragment {
+	p.advance()
+	fragment := &fragment{name: p.name()}
+	if fragment.name == "on" {
+		p.fail("a fragment cannot be named \"on\"")
+	}
+	if p.name() != "on" {
+		p.fail("expected \"on\" after the fragment name")
+	}
+	fragment.typeCondition = p.name()
+	p.parseDirectives()
+	fragment.selections = p.parseSelectionSet()
+	return fragment
+}
+
+// parseType parses a type reference and returns it in SDL notation
+func (p *parser) parseType() string {
+	var typ string
+	if p.skip("[") {
+		typ = "[" + p.parseType() + "]"
+		p.expect("]")
+	} else {
+		typ = p.name()
+	}
+	if p.skip("!") {
+		typ += "!"
+	}
+	return typ
+}
+
+func (p *parser) parseSelectionSet() []selection {
+	p.expect("{")
+	var selections []selection
+	for !p.skip("}") {
+		selections = append(selections, p.parseSelection())
+	}
+	if len(selections) == 0 {
+		p.fail("a selection set cannot be empty")
+	}
+	return selections
+}
+
+func (p *parser) parseSelection() selection {
+	if p.skip("...") {
+		if p.token.kind == tokenName && p.token.value != "on" {
+			name := p.name()
+			return selection{fragmentSpread: name, directives: p.parseDirectives()}
+		}
+		inline := &fragment{}
+		if p.token.kind == tokenName {
+			p.advance()
+			inline.typeCondition = p.name()
+		}
+		directives := p.parseDirectives()
+		inline.selections = p.parseSelectionSet()
+		return selection{inline: inline, directives: directives}
+	}
+
+	f := &field{pos: p.token.pos, name: p.name()}
+	if p.skip(":") {
+		f.alias = f.name
+		f.name = p.name()
+	}
+	f.arguments = p.parseArguments(false)
+	directives := p.parseDirectives()
+	if p.peek("{") {
+		f.selections = p.parseSelectionSet()
+	}
+	return selection{field: f, directives: directives}
+}
+
+func (p *parser) parseArguments(constant bool) []argument {
+	if !p.skip("(") {
+		return nil
+	}
+	var arguments []argument
+	for !p.skip(")") {
+		arg := argument{name: p.name()}
+		p.expect(":")
+		arg.value = p.parseValue(constant)
+		arguments = append(arguments, arg)
+	}
+	return arguments
+}
+
+func (p *parser) parseDirectives() []directive {
+	var directives []directive
+	for p.skip("@") {
+		directives = append(directives, directive{name: p.name(), arguments: p.parseArguments(false)})
+	}
+	return directives
+}
+
+// parseValue parses a value; constant values, such as variable defaults,
+// cannot reference variables
+func (p *parser) parseValue(constant bool) value {
+	tok := p.token
+	switch tok.kind {
+	case tokenInt:
+		p.advance()
+		return value{kind: valueInt, raw: tok.value}
+	case tokenFloat:
+		p.advance()
+		return value{kind: valueFloat, raw: tok.value}
+	case tokenString:
+		p.advance()
+		return value{kind: valueString, raw: tok.value}
+	case tokenName:
+		p.advance()
+		switch tok.value {
+		case "true", "false":
+			return value{kind: valueBoolean, raw: tok.value}
+		case "null":
+			return value{kind: valueNull}
+		}
+		return value{kind: valueEnum, raw: tok.value}
+	}
+
+	switch {
+	case p.skip("$"):
+		if constant {
+			p.fail("variables are not allowed here")
+		}
+		return value{kind: valueVariable, raw: p.name()}
+	case p.skip("["):
+		list := value{kind: valueList}
+		for !p.skip("]") {
+			list.list = append(list.list, p.parseValue(constant))
+		}
+		return list
+	case p.skip("{"):
+		object := value{kind: valueObject}
+		for !p.skip("}") {
+			field := argument{name: p.name()}
+			p.expect(":")
+			field.value = p.parseValue(constant)
+			object.fields = append(object.fields, field)
+		}
+		return object
+	}
+	p.unexpected()
+	return value{}
+}
+
+// advance reads the next token
+func (p *parser) advance() {
+	p.skipIgnored()
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.token = token{kind: tokenEOF, pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.token = token{kind: tokenPunct, value: "...", pos: start}
+	case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+		p.pos++
+		p.token = token{kind: tokenPunct, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.token = token{kind: tokenName, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.token = p.readNumber()
+	case c == '"':
+		p.token = token{kind: tokenString, value: p.readString(), pos: start}
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.token = token{kind: tokenPunct, value: string(r), pos: start}
+		p.fail("unexpected character %q", r)
+	}
+}
+
+// skipIgnored skips whitespace, commas and comments
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "\ufeff"):
+			p.pos += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+// readNumber reads an Int or Float token
+func (p *parser) readNumber() token {
+	start := p.pos
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		begin := p.pos
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+		if p.pos == begin {
+			p.token = token{kind: tokenPunct, value: p.src[start:p.pos], pos: start}
+			p.fail("invalid number %q", p.src[start:p.pos])
+		}
+	}
+	digits()
+
+	kind := tokenInt
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	return token{kind: kind, value: p.src[start:p.pos], pos: start}
+}
+
+// readString reads a string or block string and returns its value
+func (p *parser) readString() string {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			p.token = token{kind: tokenString, pos: start}
+			p.fail("unterminated block string")
+		}
+		p.pos += 3 + end + 3
+		return strings.TrimSpace(strings.ReplaceAll(p.src[start+3:p.pos-3], `\"""`, `"""`))
+	}
+
+	var b strings.Builder
+	p.pos++
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.token = token{kind: tokenString, pos: start}
+			p.fail("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String()
+		case c == '\\' && p.pos+1 < len(p.src):
+			escape := p.src[p.pos+1]
+			p.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					p.fail("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					p.fail("invalid unicode escape %q", p.src[p.pos:p.pos+4])
+				}
+				b.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				p.fail("invalid escape \\%c", escape)
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// position returns the line and column of a byte offset
+func position(src string, offset int) (int, int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	before := src[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return line, column
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,245 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/etl"
+	"covid19-kms/internal/services"
+)
+
+// checkInterval is how often the scheduler looks for projects that are due
+const checkInterval = time.Minute
+
+// Scheduler runs the ETL pipeline of every project on that project's
+// effective schedule interval. Projects whose interval is 0 are never run.
+// Once a day it also rebuilds the daily rollups of every project and
+// refreshes the official COVID-19 statistics and creates the coming months'
+// processed_data partitions, and every update check
+// interval it fetches recent articles again to detect edits. Every retention
+// interval it prunes the raw data older than the retention age.
+type Scheduler struct {
+	orchestrator     *etl.ETLOrchestrator
+	lastRun          map[string]time.Time
+	lastRollupDay    string
+	lastStatsDay     string
+	lastPartitionDay string
+	lastUpdates      time.Time
+	lastRetention    time.Time
+	stop             chan struct{}
+	ctx              context.Context
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+}
+
+// NewScheduler creates a new scheduler with its own orchestrator so scheduled
+// runs do not share state with API-triggered ones
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		orchestrator: etl.NewETLOrchestrator(),
+		lastRun:      make(map[string]time.Time),
+		stop:         make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start begins checking for due projects in the background
+func (s *Scheduler) Start() {
+	log.Println("⏰ ETL scheduler started")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				s.runNightlyRollups(now)
+				s.runDailyStatistics(now)
+				s.runPartitionMaintenance(now)
+				s.runUpdateChecks(now)
+				s.runRetention(now)
+				s.runDue(now)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler, cancelling a running pipeline and waiting for it to return
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.cancel()
+	s.wg.Wait()
+	log.Println("⏰ ETL scheduler stopped")
+}
+
+// runDue runs the pipeline of each project whose interval has elapsed.
+// Runs are sequential so projects never run concurrently.
+func (s *Scheduler) runDue(now time.Time) {
+	projects, err := database.GetProjects()
+	if err != nil {
+		log.Printf("⚠️ Scheduler failed to load projects: %v", err)
+		return
+	}
+
+	for _, project := range projects {
+		settings, err := etl.LoadRunSettings(project.ID)
+		if err != nil {
+			log.Printf("⚠️ Scheduler skipping project %s: %v", project.ID, err)
+			continue
+		}
+		if settings.ScheduleInterval <= 0 {
+			continue
+		}
+
+		// The first interval is counted from when the project was first seen
+		last, seen := s.lastRun[project.ID]
+		if !seen {
+			s.lastRun[project.ID] = now
+			continue
+		}
+		if now.Sub(last) < settings.ScheduleInterval {
+			continue
+		}
+
+		log.Printf("⏰ Running scheduled ETL pipeline for project %s", project.ID)
+		s.lastRun[project.ID] = now
+		result := s.orchestrator.RunETLPipelineForProject(s.ctx, project.ID)
+		if result.Status != "success" {
+			log.Printf("❌ Scheduled run for project %s failed: %s", project.ID, result.Error)
+		}
+	}
+}
+
+// runNightlyRollups rebuilds yesterday's and today's rollups of every project
+// on the first check of each day, so the last hours of yesterday loaded after
+// its last rebuild are counted. Deletes, restores and rescores of older days
+// rebuild their own days when they happen.
+func (s *Scheduler) runNightlyRollups(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if day == s.lastRollupDay {
+		return
+	}
+
+	projects, err := database.GetProjects()
+	if err != nil {
+		log.Printf("⚠️ Scheduler failed to load projects for rollups: %v", err)
+		return
+	}
+	s.lastRollupDay = day
+
+	for _, project := range projects {
+		for _, rollupDay := range []time.Time{now.AddDate(0, 0, -1), now} {
+			if _, err := database.RefreshDailyRollups(project.ID, rollupDay); err != nil {
+				log.Printf("⚠️ Rollup refresh for project %s failed: %v", project.ID, err)
+			}
+		}
+	}
+	log.Printf("📈 Rebuilt daily rollups of %d projects", len(projects))
+}
+
+// runPartitionMaintenance creates the processed_data partitions of the
+// coming months on the first check of each day, so records are never loaded
+// into the default partition
+func (s *Scheduler) runPartitionMaintenance(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if day == s.lastPartitionDay {
+		return
+	}
+
+	created, err := database.EnsureProcessedDataPartitions(now)
+	if err != nil {
+		log.Printf("⚠️ Partition maintenance failed: %v", err)
+		return
+	}
+	s.lastPartitionDay = day
+
+	if len(created) > 0 {
+		log.Printf("🗂️ Created processed_data partitions for %s", strings.Join(created, ", "))
+	}
+}
+
+// runDailyStatistics refreshes the official statistics and the Google Trends
+// search interest on the first check of each day, skipping disabled ones
+func (s *Scheduler) runDailyStatistics(now time.Time) {
+	day := now.Format("2006-01-02")
+	if day == s.lastStatsDay {
+		return
+	}
+	s.lastStatsDay = day
+
+	cfg, _ := config.LoadConfig()
+	if cfg.ExternalAPIs.Statistics.Provider != "none" {
+		if days, err := etl.RefreshCovidStatistics(s.ctx); err != nil {
+			log.Printf("⚠️ Official statistics refresh failed: %v", err)
+		} else {
+			log.Printf("📊 Refreshed %d days of official COVID-19 statistics", days)
+		}
+	}
+	if cfg.ExternalAPIs.GoogleTrends.Enabled {
+		if values, err := etl.RefreshSearchInterest(s.ctx); err != nil {
+			log.Printf("⚠️ Search interest refresh failed: %v", err)
+		} else {
+			log.Printf("🔎 Refreshed %d Google Trends search interest values", values)
+		}
+	}
+}
+
+// runUpdateChecks fetches the articles due for an update check once every
+// UPDATE_CHECK_INTERVAL, counted from when the scheduler started
+func (s *Scheduler) runUpdateChecks(now time.Time) {
+	cfg, _ := config.LoadConfig()
+	interval := cfg.ETL.UpdateCheck.Interval
+	if interval <= 0 {
+		return
+	}
+	if s.lastUpdates.IsZero() {
+		s.lastUpdates = now
+		return
+	}
+	if now.Sub(s.lastUpdates) < interval {
+		return
+	}
+	s.lastUpdates = now
+
+	result, err := etl.CheckRecordUpdates(s.ctx)
+	if err != nil {
+		log.Printf("⚠️ Article update check failed: %v", err)
+		return
+	}
+	log.Printf("📝 Checked %d articles for edits: %d changed, %d failed", result.Checked, result.Changed, result.Failed)
+}
+
+// runRetention prunes the expired raw data once every RETENTION_INTERVAL,
+// counted from when the scheduler started, unless retention is disabled
+func (s *Scheduler) runRetention(now time.Time) {
+	cfg, _ := config.LoadConfig()
+	retention := services.NewRetentionService(cfg.Retention, database.NewPostgresStore())
+	if !retention.Enabled() {
+		return
+	}
+	if s.lastRetention.IsZero() {
+		s.lastRetention = now
+		return
+	}
+	if now.Sub(s.lastRetention) < cfg.Retention.Interval {
+		return
+	}
+	s.lastRetention = now
+
+	if _, err := retention.RunRetention("scheduler", false); err != nil {
+		log.Printf("⚠️ Raw data retention failed: %v", err)
+	}
+}
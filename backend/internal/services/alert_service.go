@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// alertMinSentimentRecords keeps days with too few records for their
+// sentiment shares to mean much out of the sentiment shift check
+const alertMinSentimentRecords = 30
+
+// AlertService emails operators when a pipeline run fails or the data it
+// loaded looks off
+type AlertService struct {
+	config   config.AlertConfig
+	notifier *Notifier
+}
+
+// NewAlertService creates a new alert service sending through notifier
+func NewAlertService(cfg config.AlertConfig, notifier *Notifier) *AlertService {
+	return &AlertService{
+		config:   cfg,
+		notifier: notifier,
+	}
+}
+
+// Enabled reports whether alert recipients and an SMTP server are configured
+func (as *AlertService) Enabled() bool {
+	return len(as.config.Recipients) > 0 && as.notifier.EmailEnabled()
+}
+
+// CheckRun emails the alerts a finished run raises: the run failed, a source
+// in emptySources has now returned no records ZeroRecordRuns pipeline runs in
+// a row, or the last day's sentiment shares shifted from the week before.
+// Failures are logged only.
+func (as *AlertService) CheckRun(run *database.PipelineRun, emptySources []string) {
+	if !as.Enabled() {
+		return
+	}
+
+	if run.Status == "error" {
+		as.send(run.ProjectID, "run_failed", run.ProjectID+":"+run.Kind,
+			fmt.Sprintf("%s run failed in project %s", run.Kind, run.ProjectID),
+			fmt.Sprintf("Batch: %s\nStarted: %s\nMessage: %s\nError: %s\n\nDetails: /api/etl/runs/%s",
+				run.BatchID, run.StartedAt.Format(time.RFC3339), run.Message, run.Error, run.BatchID))
+	}
+	if run.Kind != "pipeline" {
+		return
+	}
+
+	for _, source := range emptySources {
+		count, err := database.CountZeroRecordRuns(run.ProjectID, source, as.config.ZeroRecordRuns)
+		if err != nil {
+			log.Printf("⚠️ Zero record alert check failed: %v", err)
+			continue
+		}
+		if count < as.config.ZeroRecordRuns {
+			continue
+		}
+		as.send(run.ProjectID, "zero_records", run.ProjectID+":"+source,
+			fmt.Sprintf("%s returned no records %d runs in a row in project %s", source, count, run.ProjectID),
+			fmt.Sprintf("The last %d pipeline runs extracted no records from %s.\nLatest batch: %s\n\nDetails: /api/etl/runs/%s",
+				count, source, run.BatchID, run.BatchID))
+	}
+
+	if run.Status == "success" {
+		as.checkSentimentShift(run.ProjectID)
+	}
+}
+
+// checkSentimentShift compares the sentiment shares of the last day's
+// records with those of the week before
+func (as *AlertService) checkSentimentShift(projectID string) {
+	now := time.Now()
+	dayStart := now.Add(-24 * time.Hour)
+	weekStart := dayStart.AddDate(0, 0, -7)
+
+	current, _, err := database.GetSentimentCounts(projectID, "", &dayStart, &now, false, false)
+	if err != nil {
+		log.Printf("⚠️ Sentiment shift alert check failed: %v", err)
+		return
+	}
+	baseline, _, err := database.GetSentimentCounts(projectID, "", &weekStart, &dayStart, false, false)
+	if err != nil {
+		log.Printf("⚠️ Sentiment shift alert check failed: %v", err)
+		return
+	}
+	if current.Total < alertMinSentimentRecords || baseline.Total < alertMinSentimentRecords {
+		return
+	}
+
+	shift := SentimentShift(current, baseline)
+	if shift <= as.config.SentimentShift {
+		return
+	}
+	as.send(projectID, "sentiment_shift", projectID,
+		fmt.Sprintf("Sentiment shifted %.1f points in project %s", shift, projectID),
+		fmt.Sprintf("Sentiment shares of the last 24 hours (%d records) against the 7 days before (%d records):\n"+
+			"Positive: %.1f%% (was %.1f%%)\nNegative: %.1f%% (was %.1f%%)\nNeutral: %.1f%% (was %.1f%%)",
+			current.Total, baseline.Total,
+			current.PositivePct, baseline.PositivePct,
+			current.NegativePct, baseline.NegativePct,
+			current.NeutralPct, baseline.NeutralPct))
+}
+
+// SentimentShift returns the largest change in percentage points of the
+// positive, negative or neutral share from baseline to current
+func SentimentShift(current, baseline database.SentimentCounts) float64 {
+	return math.Max(math.Abs(current.PositivePct-baseline.PositivePct),
+		math.Max(math.Abs(current.NegativePct-baseline.NegativePct), math.Abs(current.NeutralPct-baseline.NeutralPct)))
+}
+
+// send emails an alert to every recipient unless an alert of the same kind
+// and key was sent within its suppression window, and records it
+func (as *AlertService) send(projectID, kind, key, subject, body string) {
+	key = kind + ":" + key
+	if window := as.config.SuppressionFor(kind); window > 0 {
+		sent, err := database.AlertSentWithin(key, window)
+		if err != nil {
+			log.Printf("⚠️ Alert suppression check failed: %v", err)
+			return
+		}
+		if sent {
+			log.Printf("🔕 Alert %s suppressed, already sent within %s", key, window)
+			return
+		}
+	}
+
+	var errs []string
+	for _, to := range as.config.Recipients {
+		if err := as.notifier.SendEmail(to, "[covid19-kms] "+subject, body); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	alert := &database.Alert{ProjectID: projectID, Kind: kind, Key: key, Subject: subject, Body: body, Error: strings.Join(errs, "; ")}
+	if err := database.RecordAlert(alert); err != nil {
+		log.Printf("⚠️ Failed to record alert: %v", err)
+	}
+	if alert.Error != "" {
+		log.Printf("⚠️ Alert %s could not be emailed: %s", key, alert.Error)
+		return
+	}
+	log.Printf("🚨 Alert %s emailed to %d recipients", key, len(as.config.Recipients))
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// TestAlertChecks tests the sentiment shift between two periods and the
+// suppression window of each alert type
+func TestAlertChecks(t *testing.T) {
+	current := database.SentimentCounts{PositivePct: 20, NegativePct: 55, NeutralPct: 25}
+	baseline := database.SentimentCounts{PositivePct: 35, NegativePct: 35, NeutralPct: 30}
+	if shift := SentimentShift(current, baseline); shift != 20 {
+		t.Errorf("expected a shift of 20 points, got %g", shift)
+	}
+
+	alerts := config.AlertConfig{Suppression: 6 * time.Hour, SuppressionWindows: map[string]time.Duration{"run_failed": time.Hour}}
+	if window := alerts.SuppressionFor("run_failed"); window != time.Hour {
+		t.Errorf("expected the run_failed window, got %s", window)
+	}
+	if window := alerts.SuppressionFor("zero_records"); window != 6*time.Hour {
+		t.Errorf("expected the default window, got %s", window)
+	}
+}
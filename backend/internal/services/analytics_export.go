@@ -0,0 +1,155 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"covid19-kms/database"
+)
+
+// Analytics exports write the same aggregates the dashboard endpoints return
+// as CSV, so report charts can be rebuilt from exactly the same numbers
+
+// WriteTrendCSV writes a daily trend series, one row per day and dimension value
+func WriteTrendCSV(w io.Writer, dimension string, points []database.TrendPoint) error {
+	csvWriter := csv.NewWriter(w)
+	header := []string{"day", dimension, "record_count", "avg_sentiment_score", "avg_relevance_score"}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, point := range points {
+		err := csvWriter.Write([]string{
+			point.Day,
+			point.Value,
+			strconv.Itoa(point.RecordCount),
+			formatFloat(point.AvgSentimentScore),
+			formatFloat(point.AvgRelevanceScore),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteWordFrequencyCSV writes the word frequency table of
+// database.GetWordFrequency, with one count column per source
+func WriteWordFrequencyCSV(w io.Writer, wordFrequency map[string]interface{}) error {
+	words, _ := wordFrequency["words"].([]map[string]interface{})
+
+	// Every source any listed word appears in gets a column
+	sourceSet := make(map[string]bool)
+	for _, word := range words {
+		sources, _ := word["sources"].(map[string]int)
+		for source := range sources {
+			sourceSet[source] = true
+		}
+	}
+	sources := make([]string, 0, len(sourceSet))
+	for source := range sourceSet {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	csvWriter := csv.NewWriter(w)
+	header := []string{"word", "count", "positive_count", "negative_count", "neutral_count", "avg_sentiment"}
+	for _, source := range sources {
+		header = append(header, source+"_count")
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, word := range words {
+		avgSentiment, _ := word["avg_sentiment"].(float64)
+		row := []string{
+			fmt.Sprint(word["word"]),
+			fmt.Sprint(word["count"]),
+			fmt.Sprint(word["positive_count"]),
+			fmt.Sprint(word["negative_count"]),
+			fmt.Sprint(word["neutral_count"]),
+			formatFloat(avgSentiment),
+		}
+		counts, _ := word["sources"].(map[string]int)
+		for _, source := range sources {
+			row = append(row, strconv.Itoa(counts[source]))
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteSourceComparisonCSV writes one row per source combining the record
+// counts of database.GetDataSummary with the sentiment counts of
+// database.GetSentimentDistribution
+func WriteSourceComparisonCSV(w io.Writer, summary, distribution map[string]interface{}) error {
+	sourceCounts, _ := summary["source_counts"].(map[string]int)
+	sentiments, _ := distribution["sources"].(map[string]interface{})
+
+	sourceSet := make(map[string]bool)
+	for source := range sourceCounts {
+		sourceSet[source] = true
+	}
+	for source := range sentiments {
+		sourceSet[source] = true
+	}
+	sources := make([]string, 0, len(sourceSet))
+	for source := range sourceSet {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	csvWriter := csv.NewWriter(w)
+	header := []string{"source", "record_count", "positive", "negative", "neutral", "positive_share", "negative_share", "neutral_share"}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		counts, _ := sentiments[source].(map[string]interface{})
+		positive, _ := counts["positive"].(int)
+		negative, _ := counts["negative"].(int)
+		neutral, _ := counts["neutral"].(int)
+
+		// Shares are of the records with a sentiment, as in the dashboard charts
+		share := func(n int) string {
+			total := positive + negative + neutral
+			if total == 0 {
+				return ""
+			}
+			return formatFloat(float64(n) / float64(total))
+		}
+
+		err := csvWriter.Write([]string{
+			source,
+			strconv.Itoa(sourceCounts[source]),
+			strconv.Itoa(positive),
+			strconv.Itoa(negative),
+			strconv.Itoa(neutral),
+			share(positive),
+			share(negative),
+			share(neutral),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// formatFloat formats an aggregate with enough precision to reproduce charts
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 4, 64)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+)
+
+// AspectSentiment is the sentiment expressed about one aspect of a text
+type AspectSentiment struct {
+	Score    float64 `json:"score"`    // average of the sentences mentioning the aspect
+	Category string  `json:"category"` // "positive", "negative", "neutral"
+	Mentions int     `json:"mentions"` // sentences mentioning the aspect
+}
+
+// AspectScores maps aspect names to their sentiment
+type AspectScores map[string]AspectSentiment
+
+// AspectKeywords lists the words (English and Indonesian) that mark a
+// sentence as being about an aspect
+var AspectKeywords = map[string][]string{
+	"government": {
+		"government", "minister", "ministry", "president", "policy", "policies", "regulation", "official", "officials", "governor",
+		"pemerintah", "menteri", "kementerian", "kemenkes", "presiden", "jokowi", "kebijakan", "regulasi", "pejabat", "gubernur",
+		"satgas", "dpr", "ppkm", "psbb",
+	},
+	"vaccine": {
+		"vaccine", "vaccines", "vaccination", "vaccinated", "booster", "dose", "doses", "sinovac", "pfizer", "moderna", "astrazeneca",
+		"vaksin", "vaksinasi", "divaksin", "imunisasi", "dosis",
+	},
+	"economy": {
+		"economy", "economic", "business", "businesses", "jobs", "unemployment", "prices", "inflation", "income", "layoffs",
+		"ekonomi", "bisnis", "umkm", "pekerjaan", "pengangguran", "harga", "inflasi", "pendapatan", "phk", "bansos",
+	},
+}
+
+// aspectLookup maps every aspect keyword to its aspect
+var aspectLookup = func() map[string]string {
+	lookup := make(map[string]string)
+	for aspect, keywords := range AspectKeywords {
+		for _, keyword := range keywords {
+			lookup[keyword] = aspect
+		}
+	}
+	return lookup
+}()
+
+// AnalyzeAspects scores each sentence of text that mentions an aspect and
+// averages the scores per aspect. Aspect keywords are left out of the score
+// so that e.g. "vaksin" (positive in the lexicon) does not make a sentence
+// rejecting vaccines look positive. Aspects that are not mentioned are omitted.
+func (sa *SentimentAnalyzer) AnalyzeAspects(text string) AspectScores {
+	totals := make(map[string]float64)
+	mentions := make(map[string]int)
+
+	for _, sentence := range splitSentences(text) {
+		words := sa.tokenizeText(sentence)
+
+		mentioned := make(map[string]bool)
+		var remaining []string
+		for _, word := range words {
+			if aspect, ok := aspectLookup[strings.ToLower(word)]; ok {
+				mentioned[aspect] = true
+				continue
+			}
+			remaining = append(remaining, word)
+		}
+		if len(mentioned) == 0 {
+			continue
+		}
+
+		score := sa.scoreWords(remaining).Score
+		for aspect := range mentioned {
+			totals[aspect] += score
+			mentions[aspect]++
+		}
+	}
+
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	aspects := make(AspectScores, len(mentions))
+	for aspect, count := range mentions {
+		score := totals[aspect] / float64(count)
+		aspects[aspect] = AspectSentiment{
+			Score:    score,
+			Category: sentimentCategory(score),
+			Mentions: count,
+		}
+	}
+	return aspects
+}
+
+// splitSentences splits text on sentence-ending punctuation and line breaks
+func splitSentences(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == '!' || r == '?' || r == '\n'
+	})
+}
+
+// sentimentCategory maps a score to a category using the same thresholds as
+// the overall sentiment
+func sentimentCategory(score float64) string {
+	switch {
+	case score > 0.02:
+		return "positive"
+	case score < -0.02:
+		return "negative"
+	default:
+		return "neutral"
+	}
+}
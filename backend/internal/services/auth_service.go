@@ -0,0 +1,235 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User roles: viewers may read the data of their project, admins may also
+// trigger runs, cleanups and other changes
+const (
+	RoleViewer = "viewer"
+	RoleAdmin  = "admin"
+)
+
+// Token types: access tokens authenticate requests, refresh tokens only
+// renew access tokens
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+var (
+	// ErrInvalidCredentials is returned for an unknown user or a wrong password
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidToken is returned for a malformed, forged or expired token
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// jwtHeader is the encoded header of every token: HMAC-SHA256 signed JWTs
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// TokenClaims are the claims of an access or refresh token
+type TokenClaims struct {
+	Subject   string `json:"sub"` // user ID
+	Username  string `json:"name"`
+	Role      string `json:"role"`
+	Project   string `json:"project"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenPair is the response to a login or token refresh
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`         // seconds until the access token expires
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // seconds until the refresh token expires
+}
+
+// AuthService logs dashboard users in and issues and verifies their tokens
+type AuthService struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewAuthService creates a new auth service from the API configuration
+func NewAuthService(cfg config.APIConfig) *AuthService {
+	return &AuthService{
+		secret:     []byte(cfg.JWTSecret),
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+	}
+}
+
+// Enabled reports whether a JWT secret is configured
+func (as *AuthService) Enabled() bool {
+	return len(as.secret) > 0
+}
+
+// HashPassword returns the bcrypt hash of a password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Login checks a user's password and issues a token pair
+func (as *AuthService) Login(username, password string) (*database.User, *TokenPair, error) {
+	if !as.Enabled() {
+		return nil, nil, fmt.Errorf("user authentication is disabled (AUTH_JWT_SECRET not set)")
+	}
+
+	user, err := database.GetUserByUsername(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	tokens, err := as.IssueTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := database.RecordUserLogin(user.ID); err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Refresh issues a new token pair for a refresh token. The user is read
+// again, so role changes apply and deleted users can no longer refresh.
+func (as *AuthService) Refresh(refreshToken string) (*database.User, *TokenPair, error) {
+	claims, err := as.ParseToken(refreshToken, RefreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	user, err := database.GetUser(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	tokens, err := as.IssueTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// IsToken reports whether a credential is shaped like a JWT rather than an
+// API key
+func IsToken(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+// ParseToken verifies the signature, type and expiry of a token and returns
+// its claims
+func (as *AuthService) ParseToken(token, tokenType string) (*TokenClaims, error) {
+	if !as.Enabled() {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, as.signature(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != tokenType || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+// IssueTokens signs a new access and refresh token for a user
+func (as *AuthService) IssueTokens(user *database.User) (*TokenPair, error) {
+	now := time.Now()
+	claims := TokenClaims{
+		Subject:  strconv.Itoa(user.ID),
+		Username: user.Username,
+		Role:     user.Role,
+		Project:  user.ProjectID,
+		IssuedAt: now.Unix(),
+	}
+
+	claims.Type = AccessToken
+	claims.ExpiresAt = now.Add(as.accessTTL).Unix()
+	access, err := as.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	claims.Type = RefreshToken
+	claims.ExpiresAt = now.Add(as.refreshTTL).Unix()
+	refresh, err := as.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(as.accessTTL.Seconds()),
+		RefreshExpiresIn: int(as.refreshTTL.Seconds()),
+	}, nil
+}
+
+// sign encodes and signs the claims of a token
+func (as *AuthService) sign(claims TokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token claims: %w", err)
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(as.signature(unsigned)), nil
+}
+
+// signature returns the HMAC-SHA256 of the header and payload of a token
+func (as *AuthService) signature(unsigned string) []byte {
+	mac := hmac.New(sha256.New, as.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// TestAuthTokens tests issuing and parsing access tokens, and that refresh,
+// modified, foreign and expired tokens are rejected
+func TestAuthTokens(t *testing.T) {
+	auth := NewAuthService(config.APIConfig{
+		JWTSecret:       "test-secret-0123456789-0123456789",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	user := &database.User{ID: 7, Username: "analyst", Role: RoleViewer, ProjectID: "jakarta"}
+
+	tokens, err := auth.IssueTokens(user)
+	if err != nil {
+		t.Fatalf("Failed to issue tokens: %v", err)
+	}
+	if !IsToken(tokens.AccessToken) || IsToken("plain-api-key") {
+		t.Error("Expected tokens, and only tokens, to be recognised as such")
+	}
+
+	claims, err := auth.ParseToken(tokens.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to parse access token: %v", err)
+	}
+	if claims.Subject != "7" || claims.Username != "analyst" || claims.Role != RoleViewer || claims.Project != "jakarta" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	if _, err := auth.ParseToken(tokens.RefreshToken, AccessToken); err != ErrInvalidToken {
+		t.Errorf("Expected a refresh token to be rejected as access token, got %v", err)
+	}
+
+	// An admin's claims under the viewer's signature
+	admin, err := auth.IssueTokens(&database.User{ID: 1, Username: "root", Role: RoleAdmin})
+	if err != nil {
+		t.Fatalf("Failed to issue tokens: %v", err)
+	}
+	viewerParts, adminParts := strings.Split(tokens.AccessToken, "."), strings.Split(admin.AccessToken, ".")
+	forged := viewerParts[0] + "." + adminParts[1] + "." + viewerParts[2]
+	if _, err := auth.ParseToken(forged, AccessToken); err != ErrInvalidToken {
+		t.Errorf("Expected a modified token to be rejected, got %v", err)
+	}
+
+	other := NewAuthService(config.APIConfig{JWTSecret: "another-secret-0123456789-0123456", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour})
+	if _, err := other.ParseToken(tokens.AccessToken, AccessToken); err != ErrInvalidToken {
+		t.Errorf("Expected a token signed with another secret to be rejected, got %v", err)
+	}
+
+	expired := NewAuthService(config.APIConfig{JWTSecret: "test-secret-0123456789-0123456789", AccessTokenTTL: -time.Minute, RefreshTokenTTL: time.Hour})
+	stale, err := expired.IssueTokens(user)
+	if err != nil {
+		t.Fatalf("Failed to issue tokens: %v", err)
+	}
+	if _, err := auth.ParseToken(stale.AccessToken, AccessToken); err != ErrInvalidToken {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// Backup methods
+const (
+	BackupMethodAuto     = "auto"
+	BackupMethodPgDump   = "pg_dump"
+	BackupMethodSnapshot = "snapshot"
+)
+
+// BackupService produces logical backups of the raw and processed tables
+type BackupService struct {
+	directory     string
+	method        string
+	pgDumpPath    string
+	pgRestorePath string
+}
+
+// SnapshotLine is one line of a snapshot backup file
+type SnapshotLine struct {
+	Table string                 `json:"table"`
+	Row   map[string]interface{} `json:"row"`
+}
+
+// NewBackupService creates a new backup service
+func NewBackupService(cfg config.BackupConfig) *BackupService {
+	return &BackupService{
+		directory:     cfg.Directory,
+		method:        cfg.Method,
+		pgDumpPath:    cfg.PgDumpPath,
+		pgRestorePath: cfg.PgRestorePath,
+	}
+}
+
+// StartBackup registers a backup and runs it in the background
+func (bs *BackupService) StartBackup(trigger string) (*database.BackupRecord, error) {
+	record, err := bs.prepareBackup(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	go bs.runBackup(record)
+
+	return record, nil
+}
+
+// RunBackup performs a backup and waits for it to finish
+func (bs *BackupService) RunBackup(trigger string) (*database.BackupRecord, error) {
+	record, err := bs.prepareBackup(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	bs.runBackup(record)
+	if record.Status == "failed" {
+		return record, fmt.Errorf("backup failed: %s", record.ErrorMessage)
+	}
+
+	return record, nil
+}
+
+// prepareBackup resolves the backup method and records the backup as running
+func (bs *BackupService) prepareBackup(trigger string) (*database.BackupRecord, error) {
+	method, err := bs.resolveMethod()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(bs.directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	extension := "jsonl.gz"
+	if method == BackupMethodPgDump {
+		extension = "dump"
+	}
+	filename := fmt.Sprintf("covid19_kms_%s.%s", time.Now().UTC().Format("20060102T150405Z"), extension)
+
+	record := &database.BackupRecord{
+		Method:   method,
+		Trigger:  trigger,
+		Status:   "running",
+		FilePath: filepath.Join(bs.directory, filename),
+	}
+	if err := database.CreateBackupRecord(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// resolveMethod picks pg_dump when available unless a method is forced
+func (bs *BackupService) resolveMethod() (string, error) {
+	switch bs.method {
+	case BackupMethodPgDump:
+		if _, err := exec.LookPath(bs.pgDumpPath); err != nil {
+			return "", fmt.Errorf("pg_dump not found at %q: %w", bs.pgDumpPath, err)
+		}
+		return BackupMethodPgDump, nil
+	case BackupMethodSnapshot:
+		return BackupMethodSnapshot, nil
+	case BackupMethodAuto, "":
+		if _, err := exec.LookPath(bs.pgDumpPath); err == nil {
+			return BackupMethodPgDump, nil
+		}
+		return BackupMethodSnapshot, nil
+	default:
+		return "", fmt.Errorf("unsupported backup method %q (supported: auto, pg_dump, snapshot)", bs.method)
+	}
+}
+
+// runBackup writes the backup file and records the outcome
+func (bs *BackupService) runBackup(record *database.BackupRecord) {
+	log.Printf("💾 Starting %s backup #%d to %s", record.Method, record.ID, record.FilePath)
+
+	var err error
+	if record.Method == BackupMethodPgDump {
+		err = bs.runPgDump(record.FilePath)
+	} else {
+		record.RowCounts, err = writeSnapshot(record.FilePath)
+	}
+
+	completedAt := time.Now()
+	record.CompletedAt = &completedAt
+
+	if err != nil {
+		record.Status = "failed"
+		record.ErrorMessage = err.Error()
+		os.Remove(record.FilePath)
+		log.Printf("❌ Backup #%d failed: %v", record.ID, err)
+	} else {
+		record.Status = "completed"
+		if info, statErr := os.Stat(record.FilePath); statErr == nil {
+			record.SizeBytes = info.Size()
+		}
+		log.Printf("✅ Backup #%d completed (%d bytes)", record.ID, record.SizeBytes)
+	}
+
+	if err := database.UpdateBackupRecord(record); err != nil {
+		log.Printf("⚠️ Failed to record backup #%d result: %v", record.ID, err)
+	}
+}
+
+// runPgDump dumps the backup tables in pg_dump's custom format. pg_dump
+// reads all tables from a single snapshot, so the dump is consistent.
+func (bs *BackupService) runPgDump(path string) error {
+	args, env, err := pgConnection()
+	if err != nil {
+		return err
+	}
+	args = append(args,
+		"--format=custom",
+		"--no-owner",
+		"--file="+path,
+	)
+	for _, table := range database.BackupTables {
+		args = append(args, "--table="+table)
+	}
+	// processed_data keeps its rows in monthly partitions, whose data is
+	// restored through processed_data whatever partitions the target has
+	args = append(args, "--load-via-partition-root")
+	for _, pattern := range database.ProcessedDataPartitionPatterns {
+		args = append(args, "--table="+pattern)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(bs.pgDumpPath, args...)
+	cmd.Env = env
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pg_dump failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// pgConnection returns the connection flags of pg_dump and pg_restore and
+// the environment to run them in. The password is passed as PGPASSWORD, as
+// command line arguments show in the process list.
+func pgConnection() ([]string, []string, error) {
+	params, err := database.ConnectionParameters()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var args []string
+	for _, flag := range []struct{ name, value string }{
+		{"--host", params.Host},
+		{"--port", params.Port},
+		{"--username", params.User},
+		{"--dbname", params.DBName},
+	} {
+		if flag.value != "" {
+			args = append(args, flag.name+"="+flag.value)
+		}
+	}
+
+	env := os.Environ()
+	if params.Password != "" {
+		env = append(env, "PGPASSWORD="+params.Password)
+	}
+	if params.SSLMode != "" {
+		env = append(env, "PGSSLMODE="+params.SSLMode)
+	}
+	return args, env, nil
+}
+
+// writeSnapshot exports the backup tables as gzipped JSON lines
+func writeSnapshot(path string) (map[string]int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	writer := bufio.NewWriter(gz)
+	encoder := json.NewEncoder(writer)
+
+	counts, err := database.SnapshotTables(database.BackupTables, func(table string, row map[string]interface{}) error {
+		return encoder.Encode(SnapshotLine{Table: table, Row: row})
+	})
+	if err != nil {
+		return counts, err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return counts, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return counts, fmt.Errorf("failed to finish backup file: %w", err)
+	}
+
+	return counts, nil
+}
+
+// RestoreBackup loads a backup file into an empty, fully migrated database.
+// pg_dump archives are restored with pg_restore, snapshots row by row; the
+// warehouse facts and daily rollups are then derived from the records.
+func (bs *BackupService) RestoreBackup(path string) (map[string]int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("backup file not available: %w", err)
+	}
+
+	if err := database.Migrate(); err != nil {
+		return nil, err
+	}
+	if err := database.EnsureTablesEmpty(database.BackupTables); err != nil {
+		return nil, err
+	}
+
+	log.Printf("♻️ Restoring backup %s", path)
+
+	var counts map[string]int
+	var err error
+	if strings.HasSuffix(path, ".dump") {
+		err = bs.runPgRestore(path)
+	} else {
+		counts, err = restoreSnapshot(path)
+	}
+	if err != nil {
+		return counts, err
+	}
+
+	log.Println("📈 Rebuilding warehouse facts and daily rollups of the restored records")
+	if err := database.RebuildDerivedData(); err != nil {
+		return counts, err
+	}
+
+	return counts, nil
+}
+
+// runPgRestore loads the data section of a pg_dump archive. The schema comes
+// from Migrate, so older archives load into the current schema.
+func (bs *BackupService) runPgRestore(path string) error {
+	args, env, err := pgConnection()
+	if err != nil {
+		return err
+	}
+	args = append(args,
+		"--data-only",
+		"--no-owner",
+		"--single-transaction",
+		path,
+	)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(bs.pgRestorePath, args...)
+	cmd.Env = env
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pg_restore failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// restoreSnapshot reads a gzipped JSON lines snapshot into the database
+func restoreSnapshot(path string) (map[string]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	defer gz.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(gz))
+	decoder.UseNumber()
+
+	return database.RestoreSnapshot(func() (string, map[string]interface{}, error) {
+		var line SnapshotLine
+		if err := decoder.Decode(&line); err != nil {
+			if err == io.EOF {
+				return "", nil, io.EOF
+			}
+			return "", nil, fmt.Errorf("failed to decode backup line: %w", err)
+		}
+		return line.Table, line.Row, nil
+	})
+}
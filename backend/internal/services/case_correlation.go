@@ -0,0 +1,129 @@
+package services
+
+import (
+	"math"
+
+	"covid19-kms/database"
+)
+
+// caseCorrelationMinDays is the number of days with both sentiment and
+// official figures below which no correlation is reported
+const caseCorrelationMinDays = 7
+
+// CaseCorrelation holds the Pearson correlations between daily social
+// sentiment and official figures. A correlation is nil when fewer than
+// caseCorrelationMinDays days have both values or one side is constant.
+type CaseCorrelation struct {
+	Days                       int      `json:"days"` // days with records and case figures
+	SentimentVsNewCases        *float64 `json:"sentiment_vs_new_cases"`
+	SentimentVsNewDeaths       *float64 `json:"sentiment_vs_new_deaths"`
+	NegativeShareVsNewCases    *float64 `json:"negative_share_vs_new_cases"`
+	NegativeShareVsNewDeaths   *float64 `json:"negative_share_vs_new_deaths"`
+	SentimentVsNewVaccinations *float64 `json:"sentiment_vs_new_vaccinations"`
+	RecordCountVsNewCases      *float64 `json:"record_count_vs_new_cases"`
+	// Search interest is the sum of the interest in the Google Trends terms
+	RecordCountVsSearchInterest *float64 `json:"record_count_vs_search_interest"`
+	SentimentVsSearchInterest   *float64 `json:"sentiment_vs_search_interest"`
+}
+
+// CorrelateSentimentWithCases correlates the days of a sentiment case series
+// that have records with the official figures of the same days
+func CorrelateSentimentWithCases(points []database.SentimentCasePoint) CaseCorrelation {
+	sentiment := func(p database.SentimentCasePoint) float64 { return p.AvgSentimentScore }
+	negative := func(p database.SentimentCasePoint) float64 { return p.NegativeShare }
+	volume := func(p database.SentimentCasePoint) float64 { return float64(p.RecordCount) }
+	cases := func(p database.SentimentCasePoint) *int { return p.NewCases }
+	deaths := func(p database.SentimentCasePoint) *int { return p.NewDeaths }
+	vaccinations := func(p database.SentimentCasePoint) *int { return p.NewVaccinations }
+	interest := func(p database.SentimentCasePoint) *int {
+		if len(p.SearchInterest) == 0 {
+			return nil
+		}
+		total := 0
+		for _, value := range p.SearchInterest {
+			total += value
+		}
+		return &total
+	}
+
+	result := CaseCorrelation{}
+	for _, point := range points {
+		if point.RecordCount > 0 && point.NewCases != nil {
+			result.Days++
+		}
+	}
+
+	result.SentimentVsNewCases = correlateSeries(points, sentiment, cases)
+	result.SentimentVsNewDeaths = correlateSeries(points, sentiment, deaths)
+	result.NegativeShareVsNewCases = correlateSeries(points, negative, cases)
+	result.NegativeShareVsNewDeaths = correlateSeries(points, negative, deaths)
+	result.SentimentVsNewVaccinations = correlateSeries(points, sentiment, vaccinations)
+	result.RecordCountVsNewCases = correlateSeries(points, volume, cases)
+	result.RecordCountVsSearchInterest = correlateSeries(points, volume, interest)
+	result.SentimentVsSearchInterest = correlateSeries(points, sentiment, interest)
+	return result
+}
+
+// OverlaySearchInterest adds the search interest of each term to the points
+// of the same day. Days with search interest only are not added.
+func OverlaySearchInterest(points []database.SentimentCasePoint, interest []database.SearchInterest) {
+	index := make(map[string]int, len(points))
+	for i, point := range points {
+		index[point.Day] = i
+	}
+	for _, value := range interest {
+		i, ok := index[value.Day]
+		if !ok {
+			continue
+		}
+		if points[i].SearchInterest == nil {
+			points[i].SearchInterest = make(map[string]int)
+		}
+		points[i].SearchInterest[value.Term] = value.Interest
+	}
+}
+
+// correlateSeries pairs a sentiment measure with an official figure over the
+// days that have records and the figure
+func correlateSeries(points []database.SentimentCasePoint, measure func(database.SentimentCasePoint) float64, figure func(database.SentimentCasePoint) *int) *float64 {
+	var xs, ys []float64
+	for _, point := range points {
+		value := figure(point)
+		if point.RecordCount == 0 || value == nil {
+			continue
+		}
+		xs = append(xs, measure(point))
+		ys = append(ys, float64(*value))
+	}
+	if len(xs) < caseCorrelationMinDays {
+		return nil
+	}
+	return pearson(xs, ys)
+}
+
+// pearson returns the Pearson correlation coefficient of two equally long
+// series, or nil when either is constant
+func pearson(xs, ys []float64) *float64 {
+	n := float64(len(xs))
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return nil
+	}
+
+	r := math.Round(cov/math.Sqrt(varX*varY)*1000) / 1000
+	return &r
+}
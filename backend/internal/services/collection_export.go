@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// ExportFormatPDF is the printable report format of collection exports
+const ExportFormatPDF = "pdf"
+
+// collectionExcerptLength caps the content printed per record in PDF reports
+const collectionExcerptLength = 600
+
+// WriteCollectionCSV writes the records of a collection with their annotations
+// and analyst notes as CSV
+func WriteCollectionCSV(w io.Writer, collection *database.Collection, items []database.CollectionItem) error {
+	csvWriter := csv.NewWriter(w)
+	header := []string{"id", "source", "published_at", "title", "content", "sentiment", "sentiment_score", "license", "annotation", "notes", "added_at"}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		publishedAt := ""
+		if item.PublishedAt != nil {
+			publishedAt = item.PublishedAt.Format(time.RFC3339)
+		}
+		err := csvWriter.Write([]string{
+			strconv.Itoa(item.ID),
+			item.Source,
+			publishedAt,
+			item.Title,
+			item.Content,
+			item.Sentiment,
+			formatOptionalFloat(item.SentimentScore),
+			item.License,
+			item.Annotation,
+			formatNotes(item.Notes),
+			item.AddedAt.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteCollectionPDF writes a printable report of a collection: one entry per
+// record with its metadata, an excerpt of its content, its annotation and
+// the analyst notes on it
+func WriteCollectionPDF(w io.Writer, collection *database.Collection, items []database.CollectionItem) error {
+	pdf := newTextPDF()
+
+	pdf.Heading(collection.Name, 16)
+	if collection.Description != "" {
+		pdf.Text(collection.Description)
+	}
+	pdf.Text(fmt.Sprintf("%d records, exported %s", len(items), time.Now().Format("2006-01-02 15:04")))
+	pdf.Space(10)
+
+	for i, item := range items {
+		title := item.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		pdf.Heading(fmt.Sprintf("%d. %s", i+1, title), 11)
+
+		meta := fmt.Sprintf("Record %d | %s | %s", item.ID, item.Source, item.Sentiment)
+		if item.PublishedAt != nil {
+			meta += " | published " + item.PublishedAt.Format("2006-01-02")
+		}
+		pdf.Text(meta)
+
+		if excerpt := truncateRunes(item.Content, collectionExcerptLength); excerpt != "" {
+			pdf.Text(excerpt)
+		}
+		if item.Annotation != "" {
+			pdf.Heading("Annotation", 10)
+			pdf.Text(item.Annotation)
+		}
+		if len(item.Notes) > 0 {
+			pdf.Heading("Notes", 10)
+			pdf.Text(formatNotes(item.Notes))
+		}
+		pdf.Space(8)
+	}
+
+	return pdf.Render(w)
+}
+
+// formatNotes renders notes one per line as "author (date): body"
+func formatNotes(notes []database.RecordNote) string {
+	lines := make([]string, len(notes))
+	for i, note := range notes {
+		lines[i] = fmt.Sprintf("%s (%s): %s", note.Author, note.CreatedAt.Format("2006-01-02"), note.Body)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncateRunes shortens text to at most n characters, marking the cut
+func truncateRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n]) + "..."
+}
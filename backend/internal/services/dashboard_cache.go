@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/cache"
+	"covid19-kms/internal/config"
+)
+
+// DashboardCache caches the expensive dashboard aggregates per project so
+// they can be primed after an ETL run instead of computed on first request
+type DashboardCache struct {
+	cache *cache.Cache
+}
+
+var (
+	sharedDashboardCache     *DashboardCache
+	sharedDashboardCacheOnce sync.Once
+	sharedAnalyticsCache     *cache.Cache
+	sharedAnalyticsCacheOnce sync.Once
+)
+
+// NewDashboardCache creates a new dashboard cache
+func NewDashboardCache(cfg config.CacheConfig) *DashboardCache {
+	return &DashboardCache{
+		cache: cache.New(cfg.TTL),
+	}
+}
+
+// SharedDashboardCache returns the process-wide dashboard cache shared by
+// the API handlers and the ETL orchestrator
+func SharedDashboardCache() *DashboardCache {
+	sharedDashboardCacheOnce.Do(func() {
+		cfg, _ := config.LoadConfig()
+		sharedDashboardCache = NewDashboardCache(cfg.Cache)
+	})
+	return sharedDashboardCache
+}
+
+// SharedAnalyticsCache returns the process-wide cache of analytics
+// responses, keyed by AnalyticsCacheKey
+func SharedAnalyticsCache() *cache.Cache {
+	sharedAnalyticsCacheOnce.Do(func() {
+		cfg, _ := config.LoadConfig()
+		sharedAnalyticsCache = cache.New(cfg.Cache.AnalyticsTTL)
+	})
+	return sharedAnalyticsCache
+}
+
+// AnalyticsCacheKey builds the cache key of an analytics response of a
+// project, so InvalidateAnalytics can drop all of them. An empty projectID
+// is the default project.
+func AnalyticsCacheKey(projectID, request string) string {
+	if projectID == "" {
+		projectID = database.DefaultProject
+	}
+	return projectID + "|" + request
+}
+
+// InvalidateAnalytics drops the cached analytics responses of a project
+func InvalidateAnalytics(projectID string) {
+	SharedAnalyticsCache().DeletePrefix(AnalyticsCacheKey(projectID, ""))
+}
+
+// RefreshAnalytics rebuilds a project's daily rollups of the days records
+// were deleted, restored or rescored on and drops its cached analytics, so
+// the dashboards reflect the change before the nightly rollup rebuild
+func RefreshAnalytics(projectID string, days []time.Time) {
+	for _, day := range days {
+		if _, err := database.RefreshDailyRollups(projectID, day); err != nil {
+			log.Printf("⚠️ Daily rollup refresh failed: %v", err)
+			break
+		}
+	}
+	InvalidateAnalytics(projectID)
+}
+
+// Summary returns the project's data summary, from the cache when available
+func (dc *DashboardCache) Summary(projectID string, includeDuplicates bool) (map[string]interface{}, error) {
+	return dc.getOrLoad(dashboardKey("summary", projectID, includeDuplicates), func() (map[string]interface{}, error) {
+		return database.GetDataSummary(projectID, includeDuplicates)
+	})
+}
+
+// SentimentDistribution returns the project's sentiment distribution over all
+// analyzer versions, from the cache when available
+func (dc *DashboardCache) SentimentDistribution(projectID string, includeDuplicates bool) (map[string]interface{}, error) {
+	return dc.getOrLoad(dashboardKey("sentiment", projectID, includeDuplicates), func() (map[string]interface{}, error) {
+		return database.GetSentimentDistribution(projectID, 0, false, includeDuplicates)
+	})
+}
+
+// WordFrequency returns the project's word frequency, from the cache when available
+func (dc *DashboardCache) WordFrequency(projectID string, includeDuplicates bool) (map[string]interface{}, error) {
+	return dc.getOrLoad(dashboardKey("words", projectID, includeDuplicates), func() (map[string]interface{}, error) {
+		return database.GetWordFrequency(projectID, includeDuplicates)
+	})
+}
+
+// Warm recomputes every dashboard aggregate of a project and replaces the
+// cached values. Only the default canonical-only aggregates are computed;
+// the ones including duplicates are loaded on request.
+func (dc *DashboardCache) Warm(projectID string) error {
+	dc.Invalidate(projectID)
+
+	if _, err := dc.Summary(projectID, false); err != nil {
+		return fmt.Errorf("failed to warm summary: %w", err)
+	}
+	if _, err := dc.SentimentDistribution(projectID, false); err != nil {
+		return fmt.Errorf("failed to warm sentiment distribution: %w", err)
+	}
+	if _, err := dc.WordFrequency(projectID, false); err != nil {
+		return fmt.Errorf("failed to warm word frequency: %w", err)
+	}
+
+	log.Printf("🔥 Dashboard cache warmed for project %s", projectID)
+	return nil
+}
+
+// Invalidate drops every cached aggregate of a project
+func (dc *DashboardCache) Invalidate(projectID string) {
+	for _, name := range []string{"summary", "sentiment", "words"} {
+		dc.cache.Delete(dashboardKey(name, projectID, false))
+		dc.cache.Delete(dashboardKey(name, projectID, true))
+	}
+}
+
+// Stats returns the hit and miss counts of the dashboard cache
+func (dc *DashboardCache) Stats() cache.Stats {
+	return dc.cache.Stats()
+}
+
+// getOrLoad returns the cached value for key, or loads and caches it.
+// Failed loads are never cached.
+func (dc *DashboardCache) getOrLoad(key string, load func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	if value, ok := dc.cache.Get(key); ok {
+		return value.(map[string]interface{}), nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	dc.cache.Set(key, value)
+	return value, nil
+}
+
+// dashboardKey builds the cache key of an aggregate
+func dashboardKey(name, projectID string, includeDuplicates bool) string {
+	if includeDuplicates {
+		return name + ":" + projectID + ":all"
+	}
+	return name + ":" + projectID
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"covid19-kms/database"
+)
+
+// TestAnalyticsCacheKey tests that the empty project shares the default
+// project's keys and that a project's keys share the prefix
+// InvalidateAnalytics drops
+func TestAnalyticsCacheKey(t *testing.T) {
+	if AnalyticsCacheKey("", "/api/analytics/tags?") != AnalyticsCacheKey(database.DefaultProject, "/api/analytics/tags?") {
+		t.Error("expected the empty project to share the default project's key")
+	}
+	if !strings.HasPrefix(AnalyticsCacheKey("other", "/api/analytics/tags?"), AnalyticsCacheKey("other", "")) {
+		t.Error("expected a project's keys to start with its invalidation prefix")
+	}
+	if strings.HasPrefix(AnalyticsCacheKey("other-city", "/api/analytics/tags?"), AnalyticsCacheKey("other", "")) {
+		t.Error("expected another project's keys not to share the prefix")
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"hash/fnv"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+const (
+	// minHashBands and minHashRows split a MinHash signature for locality
+	// sensitive hashing: titles agreeing on all rows of any band are compared.
+	// With 16 bands of 4 rows, titles sharing 60% of their words are found
+	// with 89% probability, and ones sharing 30% with 12%.
+	minHashBands = 16
+	minHashRows  = 4
+	// minHashSize is the number of hash functions of a signature
+	minHashSize = minHashBands * minHashRows
+)
+
+// minHashSeeds salt the hash functions of a signature, derived once from a
+// fixed splitmix64 sequence so signatures are stable across processes
+var minHashSeeds = func() []uint64 {
+	seeds := make([]uint64, minHashSize)
+	var state uint64
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}()
+
+// DedupService groups near-duplicate articles: the same story published by
+// several outlets or aggregated by Google News under a slightly different
+// title. The earliest article of a group is its canonical record.
+type DedupService struct {
+	config    config.DedupConfig
+	stopWords map[string]bool
+}
+
+// DedupResult summarizes a deduplication pass
+type DedupResult struct {
+	Compared   int `json:"compared"`   // articles compared
+	Groups     int `json:"groups"`     // stories with more than one article
+	Duplicates int `json:"duplicates"` // articles marked as a duplicate of another
+}
+
+// NewDedupService creates a DedupService from the configuration
+func NewDedupService() *DedupService {
+	cfg, _ := config.LoadConfig()
+	stopWords := make(map[string]bool)
+	for _, word := range database.StopWords() {
+		stopWords[word] = true
+	}
+	return &DedupService{config: cfg.Dedup, stopWords: stopWords}
+}
+
+// Window returns how far back each pipeline run regroups articles
+func (ds *DedupService) Window() time.Duration {
+	return ds.config.Window
+}
+
+// Deduplicate regroups the project's articles published within the
+// configured window before now
+func (ds *DedupService) Deduplicate(projectID string, now time.Time) (*DedupResult, error) {
+	return ds.DeduplicateSince(projectID, now.Add(-ds.config.Window))
+}
+
+// DeduplicateSince regroups the project's articles published since since
+// and stores each article's canonical record
+func (ds *DedupService) DeduplicateSince(projectID string, since time.Time) (*DedupResult, error) {
+	records, err := database.GetDedupCandidates(projectID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]string, len(records))
+	for i, record := range records {
+		titles[i] = record.Title
+	}
+	groups := ds.groupTitles(titles)
+
+	// Candidates are ordered by publication, so the first of a group is the
+	// earliest report of the story
+	result := &DedupResult{Compared: len(records)}
+	ids := make([]int, len(records))
+	canonical := make(map[int]int)
+	for i, record := range records {
+		ids[i] = record.ID
+		if first := groups[i]; first != i {
+			canonical[record.ID] = records[first].ID
+		}
+	}
+	counted := make(map[int]bool)
+	for _, first := range canonical {
+		if !counted[first] {
+			counted[first] = true
+			result.Groups++
+		}
+	}
+	result.Duplicates = len(canonical)
+
+	if err := database.SetDuplicates(projectID, ids, canonical); err != nil {
+		return nil, err
+	}
+	log.Printf("🧬 Grouped %d duplicates of %d articles into %d stories", result.Duplicates, result.Compared, result.Groups)
+	return result, nil
+}
+
+// groupTitles returns, for each title, the index of the first title of its
+// group. Titles are grouped when their word Jaccard similarity reaches the
+// configured threshold, transitively.
+func (ds *DedupService) groupTitles(titles []string) []int {
+	parent := make([]int, len(titles))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	shingles := make([]map[string]bool, len(titles))
+	buckets := make(map[[2]uint64][]int)
+	for i, title := range titles {
+		shingles[i] = ds.titleWords(title)
+		if len(shingles[i]) == 0 {
+			continue
+		}
+		signature := minHashSignature(shingles[i])
+		for band := 0; band < minHashBands; band++ {
+			key := [2]uint64{uint64(band), bandHash(signature[band*minHashRows : (band+1)*minHashRows])}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	for _, members := range buckets {
+		for a := 0; a < len(members); a++ {
+			for b := a + 1; b < len(members); b++ {
+				i, j := members[a], members[b]
+				if find(i) == find(j) || jaccard(shingles[i], shingles[j]) < ds.config.Similarity {
+					continue
+				}
+				// The root of a group is always its lowest index
+				ri, rj := find(i), find(j)
+				if ri < rj {
+					parent[rj] = ri
+				} else {
+					parent[ri] = rj
+				}
+			}
+		}
+	}
+
+	groups := make([]int, len(titles))
+	for i := range titles {
+		groups[i] = find(i)
+	}
+	return groups
+}
+
+// titleWords returns the distinct non-stop words of a title. A trailing
+// " - Outlet" as added by Google News is dropped first.
+func (ds *DedupService) titleWords(title string) map[string]bool {
+	if i := strings.LastIndex(title, " - "); i > 0 && len(strings.Fields(title[i+3:])) <= 3 {
+		title = title[:i]
+	}
+
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) > 1 && !ds.stopWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// minHashSignature returns the minimum of each salted hash over the words
+func minHashSignature(words map[string]bool) []uint64 {
+	signature := make([]uint64, minHashSize)
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	for word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		base := h.Sum64()
+		for i, seed := range minHashSeeds {
+			if value := mix64(base ^ seed); value < signature[i] {
+				signature[i] = value
+			}
+		}
+	}
+	return signature
+}
+
+// mix64 is the splitmix64 finalizer, turning a salted hash into an
+// independent one
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// bandHash combines the rows of one band of a signature
+func bandHash(rows []uint64) uint64 {
+	var h uint64
+	for _, row := range rows {
+		h = mix64(h ^ row)
+	}
+	return h
+}
+
+// jaccard returns the share of the words of either set that both contain
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"covid19-kms/database"
+)
+
+const (
+	// digestItemsPerSource is how many positive and negative items each source contributes
+	digestItemsPerSource = 3
+	// digestTrendingTerms is how many trending terms a digest lists
+	digestTrendingTerms = 10
+	// digestBaselineDays is the window a day is compared with for trends and anomalies
+	digestBaselineDays = 7
+	// digestMinTermCount keeps terms mentioned by only a couple of records out of the trends
+	digestMinTermCount = 3
+	// digestMinVolume is the daily volume below which anomaly notes are not raised
+	digestMinVolume = 10
+)
+
+// Digest summarizes the notable items of one day for the dashboard homepage
+type Digest struct {
+	Date          string                  `json:"date"` // YYYY-MM-DD
+	TopPositive   map[string][]DigestItem `json:"top_positive"`
+	TopNegative   map[string][]DigestItem `json:"top_negative"`
+	TrendingTerms []TrendingTerm          `json:"trending_terms"`
+	Anomalies     []string                `json:"anomalies"`
+}
+
+// DigestItem is a record as listed in a digest, without its body
+type DigestItem struct {
+	ID             int      `json:"id"`
+	Title          string   `json:"title"`
+	Sentiment      string   `json:"sentiment"`
+	SentimentScore *float64 `json:"sentiment_score,omitempty"`
+	RelevanceScore float64  `json:"relevance_score"`
+	PublishedAt    string   `json:"published_at,omitempty"`
+}
+
+// TrendingTerm is a term mentioned by more records than usual
+type TrendingTerm struct {
+	Term        string  `json:"term"`
+	Count       int     `json:"count"`        // records mentioning the term on the day
+	BaselineAvg float64 `json:"baseline_avg"` // daily average over the baseline window
+	Growth      float64 `json:"growth"`       // smoothed ratio of Count to BaselineAvg
+}
+
+// DigestService assembles daily digests
+type DigestService struct{}
+
+// NewDigestService creates a new digest service
+func NewDigestService() *DigestService {
+	return &DigestService{}
+}
+
+// BuildDigest returns the digest of a project for one calendar day in the
+// time zone of day. Duplicates are listed and counted only with
+// includeDuplicates.
+func (ds *DigestService) BuildDigest(projectID string, day time.Time, includeDuplicates bool) (*Digest, error) {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	digest := &Digest{Date: day.Format("2006-01-02")}
+
+	var err error
+	if digest.TopPositive, err = ds.topItems(projectID, day, "positive", includeDuplicates); err != nil {
+		return nil, err
+	}
+	if digest.TopNegative, err = ds.topItems(projectID, day, "negative", includeDuplicates); err != nil {
+		return nil, err
+	}
+	if digest.TrendingTerms, err = ds.trendingTerms(projectID, day, includeDuplicates); err != nil {
+		return nil, err
+	}
+	if digest.Anomalies, err = ds.anomalies(projectID, day, includeDuplicates); err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
+
+// topItems groups the day's strongest items of a sentiment by source
+func (ds *DigestService) topItems(projectID string, day time.Time, sentiment string, includeDuplicates bool) (map[string][]DigestItem, error) {
+	records, err := database.GetTopItemsPerSource(projectID, day, sentiment, digestItemsPerSource, includeDuplicates)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string][]DigestItem)
+	for _, record := range records {
+		item := DigestItem{
+			ID:             record.ID,
+			Title:          record.Title,
+			Sentiment:      record.Sentiment,
+			SentimentScore: record.SentimentScore,
+			RelevanceScore: record.RelevanceScore,
+		}
+		if record.PublishedAt != nil {
+			item.PublishedAt = record.PublishedAt.Format(time.RFC3339)
+		}
+		items[record.Source] = append(items[record.Source], item)
+	}
+	return items, nil
+}
+
+// trendingTerms compares the day's keyphrase counts with the baseline window
+func (ds *DigestService) trendingTerms(projectID string, day time.Time, includeDuplicates bool) ([]TrendingTerm, error) {
+	current, err := database.GetTermCounts(projectID, day, day.AddDate(0, 0, 1), includeDuplicates)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := database.GetTermCounts(projectID, day.AddDate(0, 0, -digestBaselineDays), day, includeDuplicates)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []TrendingTerm{}
+	for term, count := range current {
+		if count < digestMinTermCount {
+			continue
+		}
+		avg := float64(baseline[term]) / digestBaselineDays
+		growth := (float64(count) + 1) / (avg + 1)
+		if growth <= 1 {
+			continue
+		}
+		terms = append(terms, TrendingTerm{Term: term, Count: count, BaselineAvg: avg, Growth: growth})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Growth != terms[j].Growth {
+			return terms[i].Growth > terms[j].Growth
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	if len(terms) > digestTrendingTerms {
+		terms = terms[:digestTrendingTerms]
+	}
+	return terms, nil
+}
+
+// anomalies notes sources whose volume or share of negative items on the day
+// differs sharply from the baseline window
+func (ds *DigestService) anomalies(projectID string, day time.Time, includeDuplicates bool) ([]string, error) {
+	counts, err := database.GetSourceDayCounts(projectID, day.AddDate(0, 0, -digestBaselineDays), day, includeDuplicates)
+	if err != nil {
+		return nil, err
+	}
+
+	dayStr := day.Format("2006-01-02")
+	today := make(map[string]database.SourceDayCount)
+	baselineTotal := make(map[string]int)
+	baselineNegative := make(map[string]int)
+	for _, count := range counts {
+		if count.Day == dayStr {
+			today[count.Source] = count
+			continue
+		}
+		baselineTotal[count.Source] += count.RecordCount
+		baselineNegative[count.Source] += count.NegativeCount
+	}
+
+	sources := make([]string, 0, len(baselineTotal)+len(today))
+	for source := range baselineTotal {
+		sources = append(sources, source)
+	}
+	for source := range today {
+		if _, ok := baselineTotal[source]; !ok {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+
+	notes := []string{}
+	for _, source := range sources {
+		count := today[source].RecordCount
+		avg := float64(baselineTotal[source]) / digestBaselineDays
+
+		switch {
+		case count == 0 && avg >= digestMinVolume:
+			notes = append(notes, fmt.Sprintf("%s: no items (%d-day average %.0f); check the extractor", source, digestBaselineDays, avg))
+			continue
+		case count >= digestMinVolume && avg == 0:
+			notes = append(notes, fmt.Sprintf("%s: %d items after none in the previous %d days", source, count, digestBaselineDays))
+		case count >= digestMinVolume && float64(count) >= 2*avg:
+			notes = append(notes, fmt.Sprintf("%s: %d items, %.1fx the %d-day average of %.0f", source, count, float64(count)/avg, digestBaselineDays, avg))
+		case avg >= digestMinVolume && float64(count) <= avg/2:
+			notes = append(notes, fmt.Sprintf("%s: only %d items against a %d-day average of %.0f", source, count, digestBaselineDays, avg))
+		}
+
+		if count >= digestMinVolume && baselineTotal[source] > 0 {
+			share := float64(today[source].NegativeCount) / float64(count)
+			baselineShare := float64(baselineNegative[source]) / float64(baselineTotal[source])
+			if share-baselineShare >= 0.2 {
+				notes = append(notes, fmt.Sprintf("%s: %.0f%% negative against %.0f%% over the previous %d days", source, share*100, baselineShare*100, digestBaselineDays))
+			}
+		}
+	}
+	return notes, nil
+}
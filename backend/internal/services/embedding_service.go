@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// EmbeddingProvider turns texts into fixed-size vectors
+type EmbeddingProvider interface {
+	// Embed returns one vector per text, in order
+	Embed(texts []string) ([][]float32, error)
+	// Model identifies the vectors so different models are never compared
+	Model() string
+}
+
+// NewEmbeddingProvider creates the provider named in the configuration.
+// It returns nil when embeddings are disabled.
+func NewEmbeddingProvider(cfg config.EmbeddingConfig) (EmbeddingProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "openai":
+		return &openAIEmbeddingProvider{
+			config: cfg,
+			client: &http.Client{Timeout: cfg.Timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", cfg.Provider)
+	}
+}
+
+// openAIEmbeddingProvider calls an OpenAI-compatible /embeddings endpoint
+type openAIEmbeddingProvider struct {
+	config config.EmbeddingConfig
+	client *http.Client
+}
+
+// Model returns the configured model name
+func (p *openAIEmbeddingProvider) Model() string {
+	return p.config.Model
+}
+
+// Embed requests embeddings for a batch of texts
+func (p *openAIEmbeddingProvider) Embed(texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model":      p.config.Model,
+		"input":      texts,
+		"dimensions": p.config.Dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.config.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding API returned %d vectors for %d texts", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding API returned invalid index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+// EmbeddingService embeds processed records and runs semantic searches
+type EmbeddingService struct {
+	provider   EmbeddingProvider
+	dimensions int
+	batchSize  int
+
+	schemaOnce sync.Once
+	schemaErr  error
+}
+
+var (
+	sharedEmbeddingService     *EmbeddingService
+	sharedEmbeddingServiceOnce sync.Once
+)
+
+// NewEmbeddingService creates a new embedding service. Enabled reports false
+// when no provider is configured.
+func NewEmbeddingService(cfg config.EmbeddingConfig) *EmbeddingService {
+	provider, err := NewEmbeddingProvider(cfg)
+	if err != nil {
+		log.Printf("⚠️ Embeddings disabled: %v", err)
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 64
+	}
+
+	return &EmbeddingService{
+		provider:   provider,
+		dimensions: cfg.Dimensions,
+		batchSize:  batchSize,
+	}
+}
+
+// SharedEmbeddingService returns the process-wide embedding service shared by
+// the API handlers and the ETL orchestrator
+func SharedEmbeddingService() *EmbeddingService {
+	sharedEmbeddingServiceOnce.Do(func() {
+		cfg, _ := config.LoadConfig()
+		sharedEmbeddingService = NewEmbeddingService(cfg.Embedding)
+	})
+	return sharedEmbeddingService
+}
+
+// Enabled reports whether an embedding provider is configured
+func (es *EmbeddingService) Enabled() bool {
+	return es.provider != nil
+}
+
+// ensureSchema prepares the pgvector table once per process
+func (es *EmbeddingService) ensureSchema() error {
+	es.schemaOnce.Do(func() {
+		es.schemaErr = database.EnsureEmbeddingSchema(es.dimensions)
+	})
+	return es.schemaErr
+}
+
+// EmbedPending embeds every record of a project that has no embedding for
+// the current model yet, in batches, and returns how many were embedded
+func (es *EmbeddingService) EmbedPending(projectID string) (int, error) {
+	if !es.Enabled() {
+		return 0, nil
+	}
+	if err := es.ensureSchema(); err != nil {
+		return 0, err
+	}
+
+	embedded := 0
+	for {
+		records, err := database.GetRecordsWithoutEmbedding(projectID, es.provider.Model(), es.batchSize)
+		if err != nil {
+			return embedded, err
+		}
+		if len(records) == 0 {
+			return embedded, nil
+		}
+
+		texts := make([]string, len(records))
+		for i, record := range records {
+			texts[i] = record.Title + "\n" + record.Content
+		}
+
+		vectors, err := es.provider.Embed(texts)
+		if err != nil {
+			return embedded, err
+		}
+
+		for i, record := range records {
+			if err := database.SaveEmbedding(record.ID, es.provider.Model(), vectors[i]); err != nil {
+				return embedded, err
+			}
+			embedded++
+		}
+	}
+}
+
+// Search embeds a natural-language query and returns the most similar records
+func (es *EmbeddingService) Search(projectID, query, source string, limit int) ([]database.RelatedRecord, error) {
+	if !es.Enabled() {
+		return nil, fmt.Errorf("semantic search is not configured (EMBEDDING_PROVIDER is empty)")
+	}
+	if err := es.ensureSchema(); err != nil {
+		return nil, err
+	}
+
+	vectors, err := es.provider.Embed([]string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	return database.SemanticSearch(projectID, es.provider.Model(), vectors[0], source, limit)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"covid19-kms/internal/config"
+)
+
+// defaultEmojiSentiment weighs the emoji and emoticons common in Instagram
+// captions and YouTube comments. Laughing faces weigh little, as Indonesian
+// comments use them as often to mock as to cheer; the mask leans negative, as
+// it mostly accompanies news of illness.
+var defaultEmojiSentiment = map[string]float64{
+	// Positive emoji
+	"❤️": 0.8, "♥️": 0.8, "😍": 0.8, "🥰": 0.8, "😊": 0.7, "☺️": 0.6, "🙂": 0.4,
+	"😀": 0.6, "😃": 0.6, "😄": 0.6, "😁": 0.6, "😇": 0.6, "🤗": 0.6, "🥳": 0.7,
+	"😂": 0.2, "🤣": 0.2, "👍": 0.6, "👏": 0.6, "🙏": 0.5, "💪": 0.6, "🎉": 0.7,
+	"✨": 0.4, "💯": 0.6, "✅": 0.4,
+
+	// Negative emoji
+	"😷": -0.3, "🤒": -0.5, "🤧": -0.3, "😭": -0.7, "😢": -0.6, "😥": -0.5,
+	"😞": -0.6, "😔": -0.5, "😟": -0.5, "😩": -0.6, "😫": -0.6, "🥺": -0.4,
+	"😰": -0.6, "😨": -0.6, "😱": -0.6, "😡": -0.8, "😠": -0.7, "🤬": -0.9,
+	"😤": -0.5, "🙄": -0.4, "😒": -0.4, "👎": -0.6, "💔": -0.7, "🤮": -0.8,
+	"🤢": -0.6, "💀": -0.5, "⚰️": -0.7,
+
+	// Emoticons
+	":)": 0.6, ":-)": 0.6, ":D": 0.7, ":-D": 0.7, ";)": 0.4, "^_^": 0.6, "<3": 0.8,
+	":(": -0.6, ":-(": -0.6, ":'(": -0.7, "T_T": -0.6, "</3": -0.7, "-_-": -0.3,
+}
+
+// newEmojiLexicon builds the emoji lexicon from the defaults and the
+// configured overrides, scaled by the configured weight. Variation selectors
+// are stripped from the keys, as the tokenizer drops them.
+func newEmojiLexicon(cfg config.SentimentConfig) map[string]float64 {
+	lexicon := make(map[string]float64, len(defaultEmojiSentiment)+len(cfg.EmojiWeights))
+	if cfg.EmojiWeight == 0 {
+		return lexicon
+	}
+	for emoji, weight := range defaultEmojiSentiment {
+		lexicon[normalizeEmoji(emoji)] = weight * cfg.EmojiWeight
+	}
+	for emoji, weight := range cfg.EmojiWeights {
+		lexicon[normalizeEmoji(emoji)] = weight * cfg.EmojiWeight
+	}
+	return lexicon
+}
+
+// lexiconEmoticons returns the emoticons of a lexicon, longest first so
+// ":-)" is matched before ":)" could be
+func lexiconEmoticons(lexicon map[string]float64) [][]rune {
+	var keys []string
+	for key := range lexicon {
+		if isEmoticon(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	emoticons := make([][]rune, len(keys))
+	for i, key := range keys {
+		emoticons[i] = []rune(key)
+	}
+	return emoticons
+}
+
+// isEmoticon reports whether a lexicon key is an ASCII emoticon rather than
+// an emoji
+func isEmoticon(key string) bool {
+	for _, r := range key {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeEmoji strips variation selectors, joiners and skin tones
+func normalizeEmoji(emoji string) string {
+	return strings.Map(func(r rune) rune {
+		if isEmojiModifier(r) {
+			return -1
+		}
+		return r
+	}, emoji)
+}
+
+// isEmojiModifier reports whether r only modifies the emoji before it
+func isEmojiModifier(r rune) bool {
+	return r == '\uFE0F' || r == '\uFE0E' || r == '\u200D' || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// emoticonAt returns the emoticon starting at runes[i], or "". An emoticon
+// edged by a letter or digit must not touch another one, so ":D" is not
+// found in "Ket:Dinas".
+func (sa *SentimentAnalyzer) emoticonAt(runes []rune, i int) string {
+	for _, candidate := range sa.emoticons {
+		end := i + len(candidate)
+		if candidate[0] != runes[i] || end > len(runes) || string(runes[i:end]) != string(candidate) {
+			continue
+		}
+		if isAlphanumeric(candidate[0]) && i > 0 && isAlphanumeric(runes[i-1]) {
+			continue
+		}
+		if isAlphanumeric(candidate[len(candidate)-1]) && end < len(runes) && isAlphanumeric(runes[end]) {
+			continue
+		}
+		return string(candidate)
+	}
+	return ""
+}
+
+// isAlphanumeric reports whether r is a letter or digit
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"covid19-kms/database"
+)
+
+// EntityExtractorVersion identifies the entity gazetteer. Bump it when
+// EntityGazetteer changes so records can be re-extracted by reprocessing.
+const EntityExtractorVersion = 1
+
+// Entity types
+const (
+	EntityPerson       = "person"
+	EntityPlace        = "place"
+	EntityOrganization = "organization"
+)
+
+// Entity is a person, place or organization mentioned in a text
+type Entity struct {
+	Name     string `json:"name"` // canonical name from EntityGazetteer
+	Type     string `json:"type"`
+	Mentions int    `json:"mentions"`
+}
+
+// GazetteerEntry is a known entity and the spellings that mention it
+type GazetteerEntry struct {
+	Type    string   `json:"type"`
+	Aliases []string `json:"aliases"`
+}
+
+// EntityGazetteer lists the people, places and organizations of the
+// Indonesian pandemic coverage by canonical name. Aliases written in
+// capitals are acronyms and match case-sensitively, so "WHO" is not found in
+// "who"; the others match in any case.
+var EntityGazetteer = map[string]GazetteerEntry{
+	// People
+	"Joko Widodo":              {EntityPerson, []string{"joko widodo", "jokowi"}},
+	"Ma'ruf Amin":              {EntityPerson, []string{"ma'ruf amin", "maruf amin"}},
+	"Budi Gunadi Sadikin":      {EntityPerson, []string{"budi gunadi sadikin", "budi gunadi"}},
+	"Terawan Agus Putranto":    {EntityPerson, []string{"terawan agus putranto", "terawan"}},
+	"Luhut Binsar Pandjaitan":  {EntityPerson, []string{"luhut binsar pandjaitan", "luhut"}},
+	"Airlangga Hartarto":       {EntityPerson, []string{"airlangga hartarto", "airlangga"}},
+	"Sri Mulyani":              {EntityPerson, []string{"sri mulyani"}},
+	"Doni Monardo":             {EntityPerson, []string{"doni monardo"}},
+	"Achmad Yurianto":          {EntityPerson, []string{"achmad yurianto", "yurianto"}},
+	"Wiku Adisasmito":          {EntityPerson, []string{"wiku adisasmito", "wiku"}},
+	"Siti Nadia Tarmizi":       {EntityPerson, []string{"siti nadia tarmizi", "nadia tarmizi"}},
+	"Anies Baswedan":           {EntityPerson, []string{"anies baswedan", "anies"}},
+	"Ridwan Kamil":             {EntityPerson, []string{"ridwan kamil", "kang emil"}},
+	"Ganjar Pranowo":           {EntityPerson, []string{"ganjar pranowo", "ganjar"}},
+	"Khofifah Indar Parawansa": {EntityPerson, []string{"khofifah indar parawansa", "khofifah"}},
+	"Tedros Adhanom":           {EntityPerson, []string{"tedros adhanom ghebreyesus", "tedros adhanom", "tedros"}},
+
+	// Organizations
+	"Kementerian Kesehatan":             {EntityOrganization, []string{"kementerian kesehatan", "kemenkes", "ministry of health"}},
+	"World Health Organization":         {EntityOrganization, []string{"world health organization", "organisasi kesehatan dunia", "WHO"}},
+	"Satgas Penanganan COVID-19":        {EntityOrganization, []string{"satgas penanganan covid", "satgas covid", "satgas"}},
+	"BNPB":                              {EntityOrganization, []string{"badan nasional penanggulangan bencana", "BNPB"}},
+	"BPOM":                              {EntityOrganization, []string{"badan pengawas obat dan makanan", "BPOM"}},
+	"Ikatan Dokter Indonesia":           {EntityOrganization, []string{"ikatan dokter indonesia", "IDI"}},
+	"Majelis Ulama Indonesia":           {EntityOrganization, []string{"majelis ulama indonesia", "MUI"}},
+	"Bio Farma":                         {EntityOrganization, []string{"bio farma", "biofarma"}},
+	"Kementerian Dalam Negeri":          {EntityOrganization, []string{"kementerian dalam negeri", "kemendagri"}},
+	"Kementerian Pendidikan":            {EntityOrganization, []string{"kementerian pendidikan", "kemendikbud", "kemendikbudristek"}},
+	"Kominfo":                           {EntityOrganization, []string{"kementerian komunikasi dan informatika", "kominfo"}},
+	"Polri":                             {EntityOrganization, []string{"kepolisian negara republik indonesia", "polri"}},
+	"TNI":                               {EntityOrganization, []string{"tentara nasional indonesia", "TNI"}},
+	"DPR":                               {EntityOrganization, []string{"dewan perwakilan rakyat", "DPR"}},
+	"Lembaga Biologi Molekuler Eijkman": {EntityOrganization, []string{"lembaga eijkman", "eijkman"}},
+	"Sinovac":                           {EntityOrganization, []string{"sinovac"}},
+	"Pfizer":                            {EntityOrganization, []string{"pfizer"}},
+	"AstraZeneca":                       {EntityOrganization, []string{"astrazeneca"}},
+	"Moderna":                           {EntityOrganization, []string{"moderna"}},
+
+	// Places: the provinces, with their capitals where those are mentioned
+	// as often, and the places abroad the coverage follows
+	"Indonesia":           {EntityPlace, []string{"indonesia"}},
+	"Aceh":                {EntityPlace, []string{"aceh", "banda aceh"}},
+	"Sumatera Utara":      {EntityPlace, []string{"sumatera utara", "sumut", "medan"}},
+	"Sumatera Barat":      {EntityPlace, []string{"sumatera barat", "sumbar", "padang"}},
+	"Riau":                {EntityPlace, []string{"riau", "pekanbaru"}},
+	"Kepulauan Riau":      {EntityPlace, []string{"kepulauan riau", "kepri", "batam"}},
+	"Jambi":               {EntityPlace, []string{"jambi"}},
+	"Sumatera Selatan":    {EntityPlace, []string{"sumatera selatan", "sumsel", "palembang"}},
+	"Bangka Belitung":     {EntityPlace, []string{"bangka belitung", "babel"}},
+	"Bengkulu":            {EntityPlace, []string{"bengkulu"}},
+	"Lampung":             {EntityPlace, []string{"lampung"}},
+	"DKI Jakarta":         {EntityPlace, []string{"dki jakarta", "jakarta"}},
+	"Banten":              {EntityPlace, []string{"banten", "tangerang"}},
+	"Jawa Barat":          {EntityPlace, []string{"jawa barat", "jabar", "bandung", "bekasi", "bogor", "depok"}},
+	"Jawa Tengah":         {EntityPlace, []string{"jawa tengah", "jateng", "semarang"}},
+	"DI Yogyakarta":       {EntityPlace, []string{"di yogyakarta", "yogyakarta", "jogja", "yogya"}},
+	"Jawa Timur":          {EntityPlace, []string{"jawa timur", "jatim", "surabaya"}},
+	"Bali":                {EntityPlace, []string{"bali", "denpasar"}},
+	"Nusa Tenggara Barat": {EntityPlace, []string{"nusa tenggara barat", "NTB", "mataram"}},
+	"Nusa Tenggara Timur": {EntityPlace, []string{"nusa tenggara timur", "NTT", "kupang"}},
+	"Kalimantan Barat":    {EntityPlace, []string{"kalimantan barat", "kalbar", "pontianak"}},
+	"Kalimantan Tengah":   {EntityPlace, []string{"kalimantan tengah", "kalteng", "palangkaraya"}},
+	"Kalimantan Selatan":  {EntityPlace, []string{"kalimantan selatan", "kalsel", "banjarmasin"}},
+	"Kalimantan Timur":    {EntityPlace, []string{"kalimantan timur", "kaltim", "samarinda", "balikpapan"}},
+	"Kalimantan Utara":    {EntityPlace, []string{"kalimantan utara", "kaltara"}},
+	"Sulawesi Utara":      {EntityPlace, []string{"sulawesi utara", "sulut", "manado"}},
+	"Gorontalo":           {EntityPlace, []string{"gorontalo"}},
+	"Sulawesi Tengah":     {EntityPlace, []string{"sulawesi tengah", "sulteng"}},
+	"Sulawesi Barat":      {EntityPlace, []string{"sulawesi barat", "sulbar"}},
+	"Sulawesi Selatan":    {EntityPlace, []string{"sulawesi selatan", "sulsel", "makassar"}},
+	"Sulawesi Tenggara":   {EntityPlace, []string{"sulawesi tenggara", "sultra", "kendari"}},
+	"Maluku":              {EntityPlace, []string{"maluku", "ambon"}},
+	"Maluku Utara":        {EntityPlace, []string{"maluku utara", "malut", "ternate"}},
+	"Papua":               {EntityPlace, []string{"papua", "jayapura"}},
+	"Papua Barat":         {EntityPlace, []string{"papua barat", "manokwari"}},
+	"Wuhan":               {EntityPlace, []string{"wuhan"}},
+	"China":               {EntityPlace, []string{"china", "tiongkok", "cina"}},
+	"India":               {EntityPlace, []string{"india"}},
+	"Singapura":           {EntityPlace, []string{"singapura", "singapore"}},
+	"Malaysia":            {EntityPlace, []string{"malaysia"}},
+	"Amerika Serikat":     {EntityPlace, []string{"amerika serikat", "united states", "USA"}},
+}
+
+// gazetteerAlias is one spelling of a gazetteer entity, as words
+type gazetteerAlias struct {
+	words         []string
+	name          string
+	caseSensitive bool
+}
+
+// gazetteerIndex maps the first word of every alias (lowercased unless
+// case-sensitive) to its aliases, longest first
+var gazetteerIndex = buildGazetteerIndex()
+
+// buildGazetteerIndex indexes the aliases of EntityGazetteer
+func buildGazetteerIndex() map[string][]gazetteerAlias {
+	index := make(map[string][]gazetteerAlias)
+	for name, entry := range EntityGazetteer {
+		for _, alias := range entry.Aliases {
+			words := entityWords(alias)
+			if len(words) == 0 {
+				continue
+			}
+			caseSensitive := strings.ToUpper(alias) == alias
+			if !caseSensitive {
+				for i := range words {
+					words[i] = strings.ToLower(words[i])
+				}
+			}
+			index[words[0]] = append(index[words[0]], gazetteerAlias{words: words, name: name, caseSensitive: caseSensitive})
+		}
+	}
+	for _, aliases := range index {
+		sort.Slice(aliases, func(i, j int) bool {
+			if len(aliases[i].words) != len(aliases[j].words) {
+				return len(aliases[i].words) > len(aliases[j].words)
+			}
+			return aliases[i].name < aliases[j].name
+		})
+	}
+	return index
+}
+
+// entityWords splits text into words of letters, digits and apostrophes
+func entityWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// ExtractEntities returns the gazetteer entities a text mentions, the most
+// mentioned first. Overlapping aliases count once, for the longest one, so
+// "Papua Barat" is not also a mention of "Papua".
+func ExtractEntities(text string) []Entity {
+	words := entityWords(text)
+	lower := make([]string, len(words))
+	for i, word := range words {
+		lower[i] = strings.ToLower(word)
+	}
+
+	mentions := make(map[string]int)
+	for i := 0; i < len(words); {
+		match := longestAlias(gazetteerIndex[lower[i]], words[i:], lower[i:])
+		if words[i] != lower[i] {
+			if exact := longestAlias(gazetteerIndex[words[i]], words[i:], lower[i:]); exact != nil && (match == nil || len(exact.words) > len(match.words)) {
+				match = exact
+			}
+		}
+		if match == nil {
+			i++
+			continue
+		}
+		mentions[match.name]++
+		i += len(match.words)
+	}
+
+	entities := make([]Entity, 0, len(mentions))
+	for name, count := range mentions {
+		entities = append(entities, Entity{Name: name, Type: EntityGazetteer[name].Type, Mentions: count})
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Mentions != entities[j].Mentions {
+			return entities[i].Mentions > entities[j].Mentions
+		}
+		return entities[i].Name < entities[j].Name
+	})
+	return entities
+}
+
+// longestAlias returns the longest of the aliases starting the text words,
+// or nil
+func longestAlias(aliases []gazetteerAlias, words, lower []string) *gazetteerAlias {
+	for i := range aliases {
+		if aliasMatches(aliases[i], words, lower) {
+			return &aliases[i]
+		}
+	}
+	return nil
+}
+
+// aliasMatches reports whether the words of an alias start the text words
+func aliasMatches(alias gazetteerAlias, words, lower []string) bool {
+	if len(alias.words) > len(words) {
+		return false
+	}
+	for i, word := range alias.words {
+		if alias.caseSensitive && words[i] != word || !alias.caseSensitive && lower[i] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectProvince returns the province the extracted entities of a text
+// mention most, counting cities toward their province, or "" when they
+// mention none. Ties go to the province named first alphabetically.
+func DetectProvince(entities []Entity) string {
+	for _, entity := range entities {
+		if entity.Type == EntityPlace && database.ProvinceCodes[entity.Name] != "" {
+			return entity.Name
+		}
+	}
+	return ""
+}
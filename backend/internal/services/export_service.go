@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+	"covid19-kms/internal/export"
+)
+
+// Supported export formats
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatJSON    = "json"
+	ExportFormatParquet = "parquet"
+)
+
+// ExportService generates export files in the background and signs download links
+type ExportService struct {
+	directory  string
+	secret     []byte
+	linkTTL    time.Duration
+	maxRecords int
+}
+
+// NewExportService creates a new export service
+func NewExportService(cfg config.ExportConfig) *ExportService {
+	secret := []byte(cfg.SigningSecret)
+	if len(secret) == 0 {
+		// Without a configured secret, links stay valid only for this process
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Printf("⚠️ Failed to generate export signing secret: %v", err)
+		}
+		log.Println("⚠️ EXPORT_SIGNING_SECRET not set, download links will not survive a restart")
+	}
+
+	return &ExportService{
+		directory:  cfg.Directory,
+		secret:     secret,
+		linkTTL:    cfg.LinkTTL,
+		maxRecords: cfg.MaxRecords,
+	}
+}
+
+// StartExport registers an export job and generates its file in the background
+func (es *ExportService) StartExport(format string, filters database.ProcessedDataFilter) (*database.ExportJob, error) {
+	job, err := es.createJob(format, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	go es.runExport(job)
+
+	return job, nil
+}
+
+// RunExport registers an export job and generates its file before returning
+// the finished job; a failed export is reported in the job's status
+func (es *ExportService) RunExport(format string, filters database.ProcessedDataFilter) (*database.ExportJob, error) {
+	job, err := es.createJob(format, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	es.runExport(job)
+
+	return job, nil
+}
+
+// createJob validates the format and registers a pending export job
+func (es *ExportService) createJob(format string, filters database.ProcessedDataFilter) (*database.ExportJob, error) {
+	if format != ExportFormatCSV && format != ExportFormatJSON && format != ExportFormatParquet {
+		return nil, fmt.Errorf("unsupported export format %q (supported: csv, json, parquet)", format)
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &database.ExportJob{
+		ID:      id,
+		Format:  format,
+		Filters: filters,
+		Status:  "pending",
+	}
+	if err := database.CreateExportJob(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// runExport writes the export file and records the outcome on the job
+func (es *ExportService) runExport(job *database.ExportJob) {
+	log.Printf("📦 Starting export job %s (%s)", job.ID, job.Format)
+
+	job.Status = "running"
+	if err := database.UpdateExportJobStatus(job); err != nil {
+		log.Printf("⚠️ Failed to mark export job %s as running: %v", job.ID, err)
+	}
+
+	count, path, err := es.writeExportFile(job)
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	job.RecordCount = count
+
+	if err != nil {
+		job.Status = "failed"
+		job.ErrorMessage = err.Error()
+		os.Remove(path)
+		log.Printf("❌ Export job %s failed: %v", job.ID, err)
+	} else {
+		job.Status = "completed"
+		job.FilePath = path
+		log.Printf("✅ Export job %s completed: %d records", job.ID, count)
+	}
+
+	if err := database.UpdateExportJobStatus(job); err != nil {
+		log.Printf("⚠️ Failed to record export job %s result: %v", job.ID, err)
+	}
+}
+
+// writeExportFile streams matching records into the export file
+func (es *ExportService) writeExportFile(job *database.ExportJob) (int, string, error) {
+	if err := os.MkdirAll(es.directory, 0o755); err != nil {
+		return 0, "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	path := filepath.Join(es.directory, fmt.Sprintf("export_%s.%s", job.ID, job.Format))
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	var count int
+
+	switch job.Format {
+	case ExportFormatCSV:
+		count, err = writeCSVExport(writer, job.Filters, es.maxRecords)
+	case ExportFormatJSON:
+		count, err = writeJSONExport(writer, job.Filters, es.maxRecords)
+	case ExportFormatParquet:
+		count, err = export.WriteRecordsParquet(writer, job.Filters, es.maxRecords)
+	}
+	if err != nil {
+		return count, path, err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return count, path, fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return count, path, nil
+}
+
+// writeCSVExport writes matching records as CSV rows
+func writeCSVExport(w *bufio.Writer, filters database.ProcessedDataFilter, maxRecords int) (int, error) {
+	csvWriter := csv.NewWriter(w)
+	header := []string{"id", "source", "processed_at", "title", "content", "relevance_score", "sentiment", "sentiment_score", "sentiment_confidence", "license"}
+	if err := csvWriter.Write(header); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := database.StreamProcessedData(filters, maxRecords, func(record database.ProcessedData) error {
+		count++
+		return csvWriter.Write([]string{
+			strconv.Itoa(record.ID),
+			record.Source,
+			record.ProcessedAt.Format(time.RFC3339),
+			record.Title,
+			record.Content,
+			strconv.FormatFloat(record.RelevanceScore, 'f', 2, 64),
+			record.Sentiment,
+			formatOptionalFloat(record.SentimentScore),
+			formatOptionalFloat(record.SentimentConfidence),
+			record.License,
+		})
+	})
+	if err != nil {
+		return count, err
+	}
+
+	csvWriter.Flush()
+	return count, csvWriter.Error()
+}
+
+// writeJSONExport writes matching records as a JSON array
+func writeJSONExport(w *bufio.Writer, filters database.ProcessedDataFilter, maxRecords int) (int, error) {
+	if _, err := w.WriteString("[\n"); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := database.StreamProcessedData(filters, maxRecords, func(record database.ProcessedData) error {
+		if count > 0 {
+			if _, err := w.WriteString(",\n"); err != nil {
+				return err
+			}
+		}
+		count++
+
+		recordJSON, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(recordJSON)
+		return err
+	})
+	if err != nil {
+		return count, err
+	}
+
+	_, err = w.WriteString("\n]\n")
+	return count, err
+}
+
+// SignDownload returns the expiry timestamp and signature for a download link
+func (es *ExportService) SignDownload(jobID string) (int64, string) {
+	expires := time.Now().Add(es.linkTTL).Unix()
+	return expires, es.signature(jobID, expires)
+}
+
+// VerifyDownload checks that a download link is authentic and not expired
+func (es *ExportService) VerifyDownload(jobID string, expires int64, signature string) error {
+	if time.Now().Unix() > expires {
+		return fmt.Errorf("download link has expired")
+	}
+
+	expected := es.signature(jobID, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid download signature")
+	}
+
+	return nil
+}
+
+// signature computes the HMAC of a job ID and expiry timestamp
+func (es *ExportService) signature(jobID string, expires int64) string {
+	mac := hmac.New(sha256.New, es.secret)
+	fmt.Fprintf(mac, "%s|%d", jobID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newJobID generates a random identifier for background jobs
+func newJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// formatOptionalFloat formats a nullable score for CSV output
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 2, 64)
+}
@@ -0,0 +1,49 @@
+package services
+
+// LanguageDetector guesses the language of a text from common function words
+type LanguageDetector struct {
+	stopwords map[string]map[string]bool
+}
+
+// NewLanguageDetector creates a new language detector instance
+func NewLanguageDetector() *LanguageDetector {
+	return &LanguageDetector{
+		stopwords: map[string]map[string]bool{
+			"id": wordSet("yang", "dan", "atau", "dengan", "untuk", "dari", "ke", "di", "pada",
+				"ini", "itu", "tidak", "akan", "juga", "sudah", "dalam", "adalah", "kami", "mereka"),
+			"en": wordSet("the", "and", "or", "with", "for", "from", "to", "in", "on", "at",
+				"is", "are", "was", "this", "that", "of", "it", "be", "have", "not"),
+		},
+	}
+}
+
+// Detect returns "id", "en" or "unknown". Whole words are counted so that
+// e.g. "dish" is not mistaken for the Indonesian "di".
+func (ld *LanguageDetector) Detect(text string) string {
+	counts := make(map[string]int)
+	for _, token := range tokenize(text) {
+		for language, words := range ld.stopwords {
+			if words[token] {
+				counts[language]++
+			}
+		}
+	}
+
+	best, bestCount := "unknown", 0
+	for _, language := range []string{"id", "en"} {
+		if counts[language] > bestCount {
+			best, bestCount = language, counts[language]
+		}
+	}
+
+	return best
+}
+
+// wordSet builds a lookup set from a list of words
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
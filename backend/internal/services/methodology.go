@@ -0,0 +1,151 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"covid19-kms/database"
+)
+
+// Methodology is the keyword and lexicon configuration records are scored
+// with. Each section carries its version and a checksum of its content, so an
+// analysis can cite the exact configuration it was produced with.
+type Methodology struct {
+	Checksum  string               `json:"checksum"` // checksum of all sections
+	Relevance RelevanceMethodology `json:"relevance"`
+	Sentiment SentimentMethodology `json:"sentiment"`
+	Stopwords StopwordsMethodology `json:"stopwords"`
+	Topics    TopicsMethodology    `json:"topics"`
+}
+
+// RelevanceMethodology holds the COVID-19 relevance terms and their weights
+type RelevanceMethodology struct {
+	Version      int                `json:"version"`
+	Checksum     string             `json:"checksum"`
+	CoreTerms    map[string]float64 `json:"core_terms"`    // make a text relevant on their own
+	ContextTerms map[string]float64 `json:"context_terms"` // only count alongside a core term
+}
+
+// SentimentMethodology holds the sentiment lexicons and aspect keywords
+type SentimentMethodology struct {
+	Version  int                 `json:"version"`
+	Checksum string              `json:"checksum"`
+	Positive map[string]float64  `json:"positive"`
+	Negative map[string]float64  `json:"negative"`
+	Neutral  []string            `json:"neutral"`
+	Aspects  map[string][]string `json:"aspects"`
+
+	// Negators flip, and intensifiers multiply, the score of the keywords
+	// that follow them; trailing intensifiers the keyword before them
+	Negators             []string           `json:"negators"`
+	Intensifiers         map[string]float64 `json:"intensifiers"`
+	TrailingIntensifiers map[string]float64 `json:"trailing_intensifiers"`
+
+	// Emoji weighs emoji and emoticons as configured
+	Emoji map[string]float64 `json:"emoji"`
+}
+
+// StopwordsMethodology holds the words excluded from word frequencies and
+// the function words used to detect languages
+type StopwordsMethodology struct {
+	Version           int                 `json:"version"`
+	Checksum          string              `json:"checksum"`
+	WordFrequency     []string            `json:"word_frequency"`
+	LanguageDetection map[string][]string `json:"language_detection"`
+}
+
+// TopicsMethodology holds the keywords of each automatic topic tag
+type TopicsMethodology struct {
+	Version  int                 `json:"version"`
+	Checksum string              `json:"checksum"`
+	Keywords map[string][]string `json:"keywords"`
+}
+
+// MethodologySections lists the names accepted by Methodology.Section
+var MethodologySections = []string{"relevance", "sentiment", "stopwords", "topics"}
+
+// CurrentMethodology returns the configuration of the running analyzers
+func CurrentMethodology() *Methodology {
+	relevance := NewRelevanceScorer()
+	sentiment := NewSentimentAnalyzer()
+
+	m := &Methodology{
+		Relevance: RelevanceMethodology{
+			Version:      RelevanceScorerVersion,
+			CoreTerms:    relevance.coreTerms,
+			ContextTerms: relevance.contextTerms,
+		},
+		Sentiment: SentimentMethodology{
+			Version:  SentimentAnalyzerVersion,
+			Positive: sentiment.positiveKeywords,
+			Negative: sentiment.negativeKeywords,
+			Neutral:  make([]string, 0, len(sentiment.neutralKeywords)),
+			Aspects:  AspectKeywords,
+
+			Negators:             sortedWords(Negators),
+			Intensifiers:         Intensifiers,
+			TrailingIntensifiers: TrailingIntensifiers,
+
+			Emoji: sentiment.emojiKeywords,
+		},
+		Stopwords: StopwordsMethodology{
+			Version:           database.StopWordsVersion,
+			WordFrequency:     database.StopWords(),
+			LanguageDetection: make(map[string][]string),
+		},
+		Topics: TopicsMethodology{
+			Version:  TopicClassifierVersion,
+			Keywords: TopicKeywords,
+		},
+	}
+	// Neutral words all weigh 0, so only the words are listed
+	for word := range sentiment.neutralKeywords {
+		m.Sentiment.Neutral = append(m.Sentiment.Neutral, word)
+	}
+	sort.Strings(m.Sentiment.Neutral)
+	for language, words := range NewLanguageDetector().stopwords {
+		m.Stopwords.LanguageDetection[language] = sortedWords(words)
+	}
+
+	m.Relevance.Checksum = methodologyChecksum(m.Relevance)
+	m.Sentiment.Checksum = methodologyChecksum(m.Sentiment)
+	m.Stopwords.Checksum = methodologyChecksum(m.Stopwords)
+	m.Topics.Checksum = methodologyChecksum(m.Topics)
+	m.Checksum = methodologyChecksum(m)
+	return m
+}
+
+// Section returns one section of the methodology by name
+func (m *Methodology) Section(name string) (interface{}, bool) {
+	switch name {
+	case "relevance":
+		return m.Relevance, true
+	case "sentiment":
+		return m.Sentiment, true
+	case "stopwords":
+		return m.Stopwords, true
+	case "topics":
+		return m.Topics, true
+	}
+	return nil, false
+}
+
+// methodologyChecksum returns a short SHA-256 of v's JSON encoding. Map keys
+// are encoded in sorted order, so equal configurations hash equally.
+func methodologyChecksum(v interface{}) string {
+	encoded, _ := json.Marshal(v)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
+
+// sortedWords returns the words of a word set in order
+func sortedWords(words map[string]bool) []string {
+	sorted := make([]string, 0, len(words))
+	for word := range words {
+		sorted = append(sorted, word)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
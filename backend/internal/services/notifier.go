@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+
+	"covid19-kms/internal/config"
+)
+
+// Notifier delivers notifications by email (SMTP) or webhook
+type Notifier struct {
+	config config.NotificationConfig
+	client *http.Client
+}
+
+// NewNotifier creates a new notifier from the notification configuration
+func NewNotifier(cfg config.NotificationConfig) *Notifier {
+	return &Notifier{
+		config: cfg,
+		client: &http.Client{
+			Timeout: cfg.WebhookTimeout,
+		},
+	}
+}
+
+// EmailEnabled reports whether an SMTP server is configured
+func (n *Notifier) EmailEnabled() bool {
+	return n.config.SMTPHost != ""
+}
+
+// SendEmail sends a plain-text email to a single recipient. The recipient
+// must be a valid address and the subject is MIME encoded, so neither can
+// add header lines to the message.
+func (n *Notifier) SendEmail(to, subject, body string) error {
+	if !n.EmailEnabled() {
+		return fmt.Errorf("email notifications are not configured (SMTP_HOST is empty)")
+	}
+
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid email recipient %q: %w", to, err)
+	}
+
+	var auth smtp.Auth
+	if n.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", n.config.SMTPUsername, n.config.SMTPPassword, n.config.SMTPHost)
+	}
+
+	message := strings.Join([]string{
+		"From: " + n.config.SMTPFrom,
+		"To: " + recipient.String(),
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", n.config.SMTPHost, n.config.SMTPPort)
+	if err := smtp.SendMail(addr, auth, n.config.SMTPFrom, []string{recipient.Address}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", recipient.Address, err)
+	}
+
+	return nil
+}
+
+// PostWebhook posts a JSON payload to a webhook URL
+func (n *Notifier) PostWebhook(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+
+	return nil
+}
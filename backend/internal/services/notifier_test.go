@@ -0,0 +1,19 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"covid19-kms/internal/config"
+)
+
+// TestSendEmailRejectsHeaderInjection tests that a recipient carrying extra
+// header lines is refused before anything is sent
+func TestSendEmailRejectsHeaderInjection(t *testing.T) {
+	notifier := NewNotifier(config.NotificationConfig{SMTPHost: "smtp.invalid", SMTPPort: 25, SMTPFrom: "kms@example.com"})
+
+	err := notifier.SendEmail("analyst@example.com\r\nBcc: everyone@example.com", "New matches", "body")
+	if err == nil || !strings.Contains(err.Error(), "invalid email recipient") {
+		t.Errorf("Expected the recipient to be rejected, got %v", err)
+	}
+}
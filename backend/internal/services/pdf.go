@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// A4 page layout of generated PDF documents, in points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+)
+
+// pdfLine is one line of text placed on a page
+type pdfLine struct {
+	text string
+	size float64
+	bold bool
+	y    float64
+}
+
+// textPDF lays out plain text on A4 pages and renders it as a minimal PDF
+// using the standard Helvetica fonts, so reports need no PDF library.
+// Characters outside Latin-1 are replaced with "?".
+type textPDF struct {
+	pages [][]pdfLine
+	y     float64
+}
+
+// newTextPDF creates an empty document
+func newTextPDF() *textPDF {
+	pdf := &textPDF{}
+	pdf.newPage()
+	return pdf
+}
+
+// newPage starts a new page
+func (p *textPDF) newPage() {
+	p.pages = append(p.pages, nil)
+	p.y = pdfPageHeight - pdfMargin
+}
+
+// Heading adds a bold line of the given font size
+func (p *textPDF) Heading(text string, size float64) {
+	p.write(text, size, true)
+}
+
+// Text adds a paragraph of regular 10pt text
+func (p *textPDF) Text(text string) {
+	p.write(text, 10, false)
+}
+
+// Space adds vertical space
+func (p *textPDF) Space(points float64) {
+	p.y -= points
+}
+
+// write wraps text to the page width and places its lines, starting new
+// pages as needed
+func (p *textPDF) write(text string, size float64, bold bool) {
+	// Helvetica glyphs average about half the font size in width
+	maxChars := int((pdfPageWidth - 2*pdfMargin) / (size * 0.5))
+	leading := size * 1.4
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrapText(paragraph, maxChars) {
+			if p.y-leading < pdfMargin {
+				p.newPage()
+			}
+			p.y -= leading
+			page := len(p.pages) - 1
+			p.pages[page] = append(p.pages[page], pdfLine{text: line, size: size, bold: bold, y: p.y})
+		}
+	}
+}
+
+// wrapText splits text into lines of at most maxChars characters at word
+// boundaries, breaking words longer than a line
+func wrapText(text string, maxChars int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	var line string
+	for _, word := range words {
+		for utf8.RuneCountInString(word) > maxChars {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:maxChars]))
+			word = string(runes[maxChars:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= maxChars:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfString encodes text as a PDF literal string in WinAnsiEncoding
+func pdfString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// Render writes the document as PDF 1.4
+func (p *textPDF) Render(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page adds a page
+	// object and its content stream
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+
+		var content strings.Builder
+		for _, line := range lines {
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.1f %.1f Td %s Tj ET\n", font, line.size, pdfMargin, line.y, pdfString(line.text))
+		}
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
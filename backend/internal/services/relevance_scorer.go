@@ -0,0 +1,79 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// RelevanceScorerVersion identifies the relevance terms and weights. Bump it
+// whenever either changes so cited methodology stays reproducible.
+const RelevanceScorerVersion = 1
+
+// RelevanceScorer scores how relevant a text is to COVID-19
+type RelevanceScorer struct {
+	coreTerms    map[string]float64
+	contextTerms map[string]float64
+}
+
+// NewRelevanceScorer creates a new relevance scorer instance
+func NewRelevanceScorer() *RelevanceScorer {
+	return &RelevanceScorer{
+		// Terms that make a text about COVID-19 on their own
+		coreTerms: map[string]float64{
+			// English
+			"covid": 1.0, "covid-19": 1.0, "covid19": 1.0, "coronavirus": 1.0, "sars-cov-2": 1.0,
+			"pandemic": 0.8, "vaccine": 0.7, "vaccination": 0.7, "lockdown": 0.7,
+			"quarantine": 0.6, "social distancing": 0.6, "mask": 0.3, "omicron": 0.8, "delta variant": 0.8,
+
+			// Indonesian
+			"korona": 1.0, "corona": 0.9, "pandemi": 0.8, "vaksin": 0.7, "vaksinasi": 0.7,
+			"karantina": 0.6, "masker": 0.3, "ppkm": 0.8, "psbb": 0.8, "isolasi mandiri": 0.6,
+		},
+		// Terms that only add relevance alongside a core term
+		contextTerms: map[string]float64{
+			"indonesia": 0.1, "jakarta": 0.1, "jawa": 0.1, "sulawesi": 0.1, "sumatra": 0.1,
+		},
+	}
+}
+
+// Score returns a relevance score between 0.0 and 1.0
+func (rs *RelevanceScorer) Score(text string) float64 {
+	if text == "" {
+		return 0.0
+	}
+
+	tokens := tokenize(text)
+	normalized := " " + strings.Join(tokens, " ") + " "
+
+	core := matchWeight(normalized, rs.coreTerms)
+	if core == 0 {
+		// Location names alone do not make a text COVID-related
+		return 0.0
+	}
+
+	score := (core + matchWeight(normalized, rs.contextTerms)) / 2.0
+	if score > 1.0 {
+		score = 1.0
+	}
+
+	return score
+}
+
+// matchWeight sums the weights of terms that appear as whole words in text.
+// text must be tokenized and padded with spaces.
+func matchWeight(text string, terms map[string]float64) float64 {
+	total := 0.0
+	for term, weight := range terms {
+		if strings.Contains(text, " "+term+" ") {
+			total += weight
+		}
+	}
+	return total
+}
+
+// tokenize lowercases text and splits it into words, keeping hyphenated terms
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// Reprocessing job types
+const (
+	ReprocessRelevance = "relevance"
+	ReprocessLanguage  = "language"
+)
+
+// maxJobErrors caps the number of error messages stored on a job
+const maxJobErrors = 50
+
+// ReprocessService re-runs the relevance scorer and language detector over
+// existing processed_data records in batches, tracking progress as cleanup jobs
+type ReprocessService struct {
+	db               *sql.DB
+	relevanceScorer  *RelevanceScorer
+	languageDetector *LanguageDetector
+	batchSize        int
+}
+
+// reprocessRecord is the subset of processed_data needed for reprocessing
+type reprocessRecord struct {
+	ID             int
+	Text           string
+	RelevanceScore float64
+	Language       string
+}
+
+// NewReprocessService creates a new reprocessing service
+func NewReprocessService(db *sql.DB) *ReprocessService {
+	return &ReprocessService{
+		db:               db,
+		relevanceScorer:  NewRelevanceScorer(),
+		languageDetector: NewLanguageDetector(),
+		batchSize:        100,
+	}
+}
+
+// StartJob registers a cleanup job and runs it in the background
+func (rs *ReprocessService) StartJob(jobType string, scope database.CleanupScope) (*database.CleanupJob, error) {
+	if jobType != ReprocessRelevance && jobType != ReprocessLanguage {
+		return nil, fmt.Errorf("unsupported cleanup job type %q", jobType)
+	}
+
+	where, args := cleanupScopeWhere(scope)
+	var total int
+	if err := rs.db.QueryRow("SELECT COUNT(*) FROM processed_data"+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count records: %v", err)
+	}
+
+	job := &database.CleanupJob{
+		JobType:      jobType,
+		Scope:        scope,
+		Status:       "running",
+		TotalRecords: total,
+	}
+	if err := database.CreateCleanupJob(job); err != nil {
+		return nil, err
+	}
+
+	go rs.runJob(job)
+
+	return job, nil
+}
+
+// runJob walks the scoped records in ID order and updates changed values
+func (rs *ReprocessService) runJob(job *database.CleanupJob) {
+	log.Printf("🧹 Starting %s cleanup job #%d for %d records", job.JobType, job.ID, job.TotalRecords)
+
+	lastID := 0
+	for {
+		records, err := rs.getBatch(job.Scope, lastID)
+		if err != nil {
+			job.Errors = appendJobError(job.Errors, fmt.Sprintf("Failed to get batch after id %d: %v", lastID, err))
+			job.Status = "failed"
+			break
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		for _, record := range records {
+			job.ProcessedRecords++
+			updated, err := rs.reprocessRecord(job.JobType, record)
+			if err != nil {
+				job.ErrorRecords++
+				job.Errors = appendJobError(job.Errors, fmt.Sprintf("Failed to update record %d: %v", record.ID, err))
+			} else if updated {
+				job.UpdatedRecords++
+			}
+		}
+		lastID = records[len(records)-1].ID
+
+		if err := database.UpdateCleanupJob(job); err != nil {
+			log.Printf("⚠️ Failed to record progress for cleanup job #%d: %v", job.ID, err)
+		}
+		log.Printf("📊 Cleanup job #%d: %d/%d records", job.ID, job.ProcessedRecords, job.TotalRecords)
+	}
+
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	if job.Status != "failed" {
+		if job.ErrorRecords > 0 {
+			job.Status = "completed_with_errors"
+		} else {
+			job.Status = "completed"
+		}
+	}
+
+	if err := database.UpdateCleanupJob(job); err != nil {
+		log.Printf("⚠️ Failed to record result for cleanup job #%d: %v", job.ID, err)
+	}
+	log.Printf("✅ Cleanup job #%d %s: %d updated, %d errors", job.ID, job.Status, job.UpdatedRecords, job.ErrorRecords)
+}
+
+// reprocessRecord recomputes one value and reports whether it changed
+func (rs *ReprocessService) reprocessRecord(jobType string, record reprocessRecord) (bool, error) {
+	switch jobType {
+	case ReprocessRelevance:
+		score := math.Round(rs.relevanceScorer.Score(record.Text)*100) / 100
+		if score == record.RelevanceScore {
+			return false, nil
+		}
+		_, err := rs.db.Exec(`
+			UPDATE processed_data
+			SET relevance_score = $1,
+			    processed_data = jsonb_set(processed_data, '{covid_relevance_score}', to_jsonb($1::float8))
+			WHERE id = $2
+		`, score, record.ID)
+		return err == nil, err
+	case ReprocessLanguage:
+		language := rs.languageDetector.Detect(record.Text)
+		if language == record.Language {
+			return false, nil
+		}
+		_, err := rs.db.Exec(`
+			UPDATE processed_data
+			SET processed_data = jsonb_set(processed_data, '{language}', to_jsonb($1::text))
+			WHERE id = $2
+		`, language, record.ID)
+		return err == nil, err
+	}
+
+	return false, fmt.Errorf("unsupported cleanup job type %q", jobType)
+}
+
+// getBatch returns the next batch of scoped records after lastID
+func (rs *ReprocessService) getBatch(scope database.CleanupScope, lastID int) ([]reprocessRecord, error) {
+	where, args := cleanupScopeWhere(scope)
+	args = append(args, lastID, rs.batchSize)
+
+	query := fmt.Sprintf(`
+		SELECT id, COALESCE(title, '') || ' ' || COALESCE(content, ''), COALESCE(relevance_score, 0),
+		       COALESCE(processed_data->>'language', '')
+		FROM processed_data%s AND id > $%d
+		ORDER BY id
+		LIMIT $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := rs.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []reprocessRecord
+	for rows.Next() {
+		var record reprocessRecord
+		if err := rows.Scan(&record.ID, &record.Text, &record.RelevanceScore, &record.Language); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// cleanupScopeWhere builds a WHERE clause for a cleanup scope
+func cleanupScopeWhere(scope database.CleanupScope) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if scope.Project != "" {
+		args = append(args, scope.Project)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if scope.Source != "" {
+		args = append(args, scope.Source)
+		conditions = append(conditions, fmt.Sprintf("source = $%d", len(args)))
+	}
+	if scope.StartDate != nil {
+		args = append(args, *scope.StartDate)
+		conditions = append(conditions, fmt.Sprintf("processed_at >= $%d", len(args)))
+	}
+	if scope.EndDate != nil {
+		args = append(args, *scope.EndDate)
+		conditions = append(conditions, fmt.Sprintf("processed_at < $%d", len(args)))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// appendJobError adds an error message unless the cap has been reached
+func appendJobError(errors []string, message string) []string {
+	if len(errors) >= maxJobErrors {
+		return errors
+	}
+	return append(errors, message)
+}
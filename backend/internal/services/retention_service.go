@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"covid19-kms/database"
+	"covid19-kms/internal/config"
+)
+
+// Retention modes
+const (
+	RetentionModeArchive = "archive"
+	RetentionModeDelete  = "delete"
+)
+
+var (
+	// ErrRetentionDisabled is returned when no retention age is configured
+	ErrRetentionDisabled = errors.New("raw data retention is disabled, set RETENTION_RAW_DATA_MAX_AGE")
+	// ErrRetentionRunning is returned while another retention run prunes
+	ErrRetentionRunning = errors.New("a retention run is already in progress")
+)
+
+// retentionMu keeps the scheduler, the API and the CLI from pruning at once
+var retentionMu sync.Mutex
+
+// RetentionService prunes raw_data rows older than the retention age,
+// archiving them to a snapshot file first in archive mode
+type RetentionService struct {
+	raw        database.RawDataRepo
+	maxAge     time.Duration
+	mode       string
+	archiveDir string
+	batchSize  int
+	dryRun     bool
+}
+
+// NewRetentionService creates a new retention service pruning the raw data
+// of raw
+func NewRetentionService(cfg config.RetentionConfig, raw database.RawDataRepo) *RetentionService {
+	return &RetentionService{
+		raw:        raw,
+		maxAge:     cfg.MaxAge,
+		mode:       cfg.Mode,
+		archiveDir: cfg.ArchiveDir,
+		batchSize:  cfg.BatchSize,
+		dryRun:     cfg.DryRun,
+	}
+}
+
+// Enabled reports whether a retention age is configured
+func (rs *RetentionService) Enabled() bool {
+	return rs.maxAge > 0
+}
+
+// StartRetention registers a retention run and prunes in the background
+func (rs *RetentionService) StartRetention(trigger string, dryRun bool) (*database.RetentionRun, error) {
+	run, err := rs.prepareRetention(trigger, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	go rs.runRetention(run)
+
+	return run, nil
+}
+
+// RunRetention prunes the expired raw data and waits for it to finish. A dry
+// run, or any run with RETENTION_DRY_RUN, only reports what would be pruned.
+func (rs *RetentionService) RunRetention(trigger string, dryRun bool) (*database.RetentionRun, error) {
+	run, err := rs.prepareRetention(trigger, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.runRetention(run)
+	if run.Status == "failed" {
+		return run, fmt.Errorf("retention run failed: %s", run.ErrorMessage)
+	}
+
+	return run, nil
+}
+
+// prepareRetention takes the retention lock and records the run as running.
+// The lock is released when the run finishes.
+func (rs *RetentionService) prepareRetention(trigger string, dryRun bool) (*database.RetentionRun, error) {
+	if !rs.Enabled() {
+		return nil, ErrRetentionDisabled
+	}
+	if rs.mode != RetentionModeArchive && rs.mode != RetentionModeDelete {
+		return nil, fmt.Errorf("unsupported retention mode %q (supported: archive, delete)", rs.mode)
+	}
+	if !retentionMu.TryLock() {
+		return nil, ErrRetentionRunning
+	}
+
+	run := &database.RetentionRun{
+		Mode:    rs.mode,
+		Trigger: trigger,
+		DryRun:  dryRun || rs.dryRun,
+		Status:  "running",
+		Cutoff:  time.Now().UTC().Add(-rs.maxAge),
+	}
+	if rs.mode == RetentionModeArchive && !run.DryRun {
+		if err := os.MkdirAll(rs.archiveDir, 0o755); err != nil {
+			retentionMu.Unlock()
+			return nil, fmt.Errorf("failed to create archive directory: %w", err)
+		}
+		filename := fmt.Sprintf("raw_data_%s.jsonl.gz", time.Now().UTC().Format("20060102T150405Z"))
+		run.ArchivePath = filepath.Join(rs.archiveDir, filename)
+	}
+	if err := database.CreateRetentionRun(run); err != nil {
+		retentionMu.Unlock()
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// runRetention prunes or counts the expired rows and records the outcome
+func (rs *RetentionService) runRetention(run *database.RetentionRun) {
+	defer retentionMu.Unlock()
+
+	if run.DryRun {
+		log.Printf("🧹 Starting retention dry run #%d for raw data extracted before %s", run.ID, run.Cutoff.Format(time.RFC3339))
+	} else {
+		log.Printf("🧹 Starting retention run #%d (%s) for raw data extracted before %s", run.ID, run.Mode, run.Cutoff.Format(time.RFC3339))
+	}
+
+	var err error
+	run.TableBytesBefore, err = rs.raw.RawDataTableSize()
+	if err == nil {
+		if run.DryRun {
+			run.RowsPruned, run.BytesReclaimed, err = rs.raw.CountExpiredRawData(run.Cutoff)
+		} else {
+			err = rs.prune(run)
+		}
+	}
+	if err == nil {
+		run.TableBytesAfter, err = rs.raw.RawDataTableSize()
+	}
+
+	completedAt := time.Now().UTC()
+	run.CompletedAt = &completedAt
+
+	if err != nil {
+		run.Status = "failed"
+		run.ErrorMessage = err.Error()
+		log.Printf("❌ Retention run #%d failed after %d rows: %v", run.ID, run.RowsPruned, err)
+	} else {
+		run.Status = "completed"
+		verb := "Pruned"
+		if run.DryRun {
+			verb = "Would prune"
+		}
+		log.Printf("✅ Retention run #%d: %s %d raw data rows (%d bytes)", run.ID, verb, run.RowsPruned, run.BytesReclaimed)
+	}
+
+	if err := database.UpdateRetentionRun(run); err != nil {
+		log.Printf("⚠️ Failed to record retention run #%d result: %v", run.ID, err)
+	}
+}
+
+// prune deletes the expired rows batch by batch. In archive mode each batch
+// is written to the archive file and flushed before it is deleted, so a
+// failed run never loses rows that are not archived. Archives use the
+// snapshot backup format.
+func (rs *RetentionService) prune(run *database.RetentionRun) error {
+	var archive func(rows []map[string]interface{}) error
+	var gz *gzip.Writer
+	if run.ArchivePath != "" {
+		file, err := os.Create(run.ArchivePath)
+		if err != nil {
+			return fmt.Errorf("failed to create archive file: %w", err)
+		}
+		defer file.Close()
+
+		gz = gzip.NewWriter(file)
+		writer := bufio.NewWriter(gz)
+		encoder := json.NewEncoder(writer)
+
+		archive = func(rows []map[string]interface{}) error {
+			for _, row := range rows {
+				if err := encoder.Encode(SnapshotLine{Table: "raw_data", Row: row}); err != nil {
+					return fmt.Errorf("failed to write archive file: %w", err)
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				return fmt.Errorf("failed to write archive file: %w", err)
+			}
+			if err := gz.Flush(); err != nil {
+				return fmt.Errorf("failed to write archive file: %w", err)
+			}
+			if err := file.Sync(); err != nil {
+				return fmt.Errorf("failed to sync archive file: %w", err)
+			}
+			return nil
+		}
+	}
+
+	for {
+		count, size, err := rs.raw.PruneRawData(run.Cutoff, rs.batchSize, archive)
+		if err != nil {
+			return err
+		}
+		run.RowsPruned += count
+		run.BytesReclaimed += size
+		if count < rs.batchSize {
+			break
+		}
+	}
+
+	if gz == nil {
+		return nil
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive file: %w", err)
+	}
+	// Nothing expired, so keep no empty archive around
+	if run.RowsPruned == 0 {
+		os.Remove(run.ArchivePath)
+		run.ArchivePath = ""
+	}
+
+	return nil
+}
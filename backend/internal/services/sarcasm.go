@@ -0,0 +1,110 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sarcasmThreshold is the cue weight at which a text is flagged as sarcastic
+const sarcasmThreshold = 1.0
+
+// sarcasmMarkers are phrases that on their own usually signal irony in
+// Indonesian and English social comments
+var sarcasmMarkers = []struct {
+	pattern *regexp.Regexp
+	weight  float64
+}{
+	{regexp.MustCompile(`(?i)(^|[\s,.!?])yh+a+($|[\s,.!?])`), 1.0},
+	{regexp.MustCompile(`(?i)\bya kali\b`), 1.0},
+	{regexp.MustCompile(`(?i)(^|\s)/s($|\s)`), 1.0},
+	{regexp.MustCompile(`(?i)\byeah,? right\b`), 1.0},
+	{regexp.MustCompile(`(?i)\boh,? great\b`), 0.7},
+	{regexp.MustCompile(`(?i)\bkatanya\b`), 0.5},
+	{regexp.MustCompile(`(?i)\bhade+h\b`), 0.5},
+	{regexp.MustCompile(`(?i)\bwkwk\w*`), 0.5},
+}
+
+// sarcasmEmoji weighs emoji that often accompany ironic praise
+var sarcasmEmoji = []struct {
+	emoji  string
+	weight float64
+}{
+	{"🙃", 1.0}, {"🤡", 1.0}, {"🙄", 0.7}, {"😏", 0.7},
+	{"😂", 0.3}, {"🤣", 0.3}, {"👏", 0.3},
+}
+
+// praiseWords are slang compliments that are often used ironically, on top
+// of the positive lexicon
+var praiseWords = map[string]bool{
+	"mantap": true, "mantul": true, "keren": true, "pinter": true, "top": true,
+}
+
+// intensifiers exaggerate praise ("mantap banget nih")
+var intensifiers = map[string]bool{
+	"banget": true, "bgt": true, "sekali": true, "bener": true, "nih": true, "so": true, "very": true,
+}
+
+// scareQuotes matches short quoted phrases, e.g. "hebat"
+var scareQuotes = regexp.MustCompile(`["“'‘]([^"”'’]{1,30})["”'’]`)
+
+// DetectSarcasm flags text that is likely sarcastic, based on ironic markers
+// ("yha", "ya kali"), eye-roll and clown emoji, praise in scare quotes and
+// exaggerated praise of the government ("mantap banget nih pemerintah").
+// It returns the cues found so the flag can be explained.
+func (sa *SentimentAnalyzer) DetectSarcasm(text string) (bool, []string) {
+	var weight float64
+	var cues []string
+
+	for _, marker := range sarcasmMarkers {
+		if match := marker.pattern.FindString(text); match != "" {
+			weight += marker.weight
+			cues = append(cues, strings.TrimSpace(strings.Trim(match, ",.!?")))
+		}
+	}
+
+	for _, emoji := range sarcasmEmoji {
+		if strings.Contains(text, emoji.emoji) {
+			weight += emoji.weight
+			cues = append(cues, emoji.emoji)
+		}
+	}
+
+	for _, quoted := range scareQuotes.FindAllStringSubmatch(text, -1) {
+		for _, word := range sa.tokenizeText(quoted[1]) {
+			if sa.isPraise(word) {
+				weight += 1.0
+				cues = append(cues, quoted[0])
+				break
+			}
+		}
+	}
+
+	// Exaggerated praise, especially of the government, is rarely meant
+	words := sa.tokenizeText(text)
+	praised, government := false, false
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		if !praised && sa.isPraise(lower) && i+1 < len(words) && intensifiers[strings.ToLower(words[i+1])] {
+			praised = true
+			cues = append(cues, lower+" "+strings.ToLower(words[i+1]))
+		}
+		if aspectLookup[lower] == "government" {
+			government = true
+		}
+	}
+	if praised {
+		weight += 0.5
+		if government {
+			weight += 0.5
+		}
+	}
+
+	return weight >= sarcasmThreshold, cues
+}
+
+// isPraise reports whether word is a compliment
+func (sa *SentimentAnalyzer) isPraise(word string) bool {
+	word = strings.ToLower(word)
+	_, positive := sa.positiveKeywords[word]
+	return positive || praiseWords[word]
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// maxMatchesPerSearch caps how many new records are reported per saved search and run
+const maxMatchesPerSearch = 50
+
+// SavedSearchMatcher checks newly loaded records against saved searches
+// and notifies subscribers when new matches appear
+type SavedSearchMatcher struct {
+	notifier *Notifier
+}
+
+// SavedSearchMatch describes the new matches found for one saved search
+type SavedSearchMatch struct {
+	SearchID   int      `json:"search_id"`
+	SearchName string   `json:"search_name"`
+	UserID     string   `json:"user_id"`
+	Matches    int      `json:"matches"`
+	Notified   []string `json:"notified,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// MatchResult represents the result of one matching pass
+type MatchResult struct {
+	SearchesChecked int                `json:"searches_checked"`
+	TotalMatches    int                `json:"total_matches"`
+	Searches        []SavedSearchMatch `json:"searches,omitempty"`
+	ProcessingTime  time.Duration      `json:"processing_time"`
+}
+
+// NewSavedSearchMatcher creates a new saved search matcher
+func NewSavedSearchMatcher(notifier *Notifier) *SavedSearchMatcher {
+	return &SavedSearchMatcher{
+		notifier: notifier,
+	}
+}
+
+// MatchNewRecords runs every saved search against records loaded since its last check
+func (m *SavedSearchMatcher) MatchNewRecords() (*MatchResult, error) {
+	startTime := time.Now()
+	result := &MatchResult{}
+
+	searches, err := database.GetSavedSearches("", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load saved searches: %w", err)
+	}
+
+	for _, search := range searches {
+		result.SearchesChecked++
+		match := m.matchSearch(search)
+		result.TotalMatches += match.Matches
+		if match.Matches > 0 || match.Error != "" {
+			result.Searches = append(result.Searches, match)
+		}
+	}
+
+	result.ProcessingTime = time.Since(startTime)
+	log.Printf("🔔 Saved search matching: %d searches checked, %d new matches", result.SearchesChecked, result.TotalMatches)
+	return result, nil
+}
+
+// notificationChannel is a delivery channel of a saved search. Each channel
+// keeps its own cursor, so a failed delivery is retried on the next run
+// without sending the matches again over the channels that delivered them.
+type notificationChannel struct {
+	name    string
+	afterID int
+	deliver func(records []database.ProcessedData) error
+}
+
+// channels returns the notification channels of a saved search. Email is
+// skipped while no SMTP server is configured.
+func (m *SavedSearchMatcher) channels(search database.SavedSearch) []notificationChannel {
+	var channels []notificationChannel
+	if search.WebhookURL != "" {
+		channels = append(channels, notificationChannel{
+			name:    "webhook",
+			afterID: search.WebhookMatchedID,
+			deliver: func(records []database.ProcessedData) error {
+				return m.notifier.PostWebhook(search.WebhookURL, buildWebhookPayload(search, records))
+			},
+		})
+	}
+	if search.NotifyEmail != "" && m.notifier.EmailEnabled() {
+		channels = append(channels, notificationChannel{
+			name:    "email",
+			afterID: search.EmailMatchedID,
+			deliver: func(records []database.ProcessedData) error {
+				subject := fmt.Sprintf("[COVID-19 KMS] %d new matches for \"%s\"", len(records), search.Name)
+				return m.notifier.SendEmail(search.NotifyEmail, subject, buildEmailBody(search, records))
+			},
+		})
+	}
+	return channels
+}
+
+// matchSearch finds new matches for a single saved search and notifies its subscribers
+func (m *SavedSearchMatcher) matchSearch(search database.SavedSearch) SavedSearchMatch {
+	match := SavedSearchMatch{
+		SearchID:   search.ID,
+		SearchName: search.Name,
+		UserID:     search.UserID,
+	}
+
+	records, err := database.SearchProcessedData(search.ProjectID, search.Query, search.Source, search.Sentiment, search.LastMatchedID, maxMatchesPerSearch)
+	if err != nil {
+		match.Error = err.Error()
+		return match
+	}
+	match.Matches = len(records)
+
+	// Records come back oldest first, each cursor moves to the last record it covered
+	cursors := make(map[string]int)
+	if len(records) > 0 {
+		cursors["matched"] = records[len(records)-1].ID
+	}
+
+	var errs []string
+	for _, channel := range m.channels(search) {
+		pending := records
+		if channel.afterID != search.LastMatchedID {
+			pending, err = database.SearchProcessedData(search.ProjectID, search.Query, search.Source, search.Sentiment, channel.afterID, maxMatchesPerSearch)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		if err := channel.deliver(pending); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		match.Notified = append(match.Notified, channel.name)
+		cursors[channel.name] = pending[len(pending)-1].ID
+	}
+	if len(errs) > 0 {
+		match.Error = strings.Join(errs, "; ")
+		log.Printf("⚠️ Saved search %d notification failed: %s", search.ID, match.Error)
+	}
+
+	if err := database.UpdateSavedSearchCursors(search.ID, cursors, len(match.Notified) > 0); err != nil {
+		log.Printf("⚠️ Failed to advance saved search %d cursors: %v", search.ID, err)
+	}
+
+	return match
+}
+
+// buildWebhookPayload creates the JSON body posted to a saved search webhook
+func buildWebhookPayload(search database.SavedSearch, records []database.ProcessedData) map[string]interface{} {
+	matches := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		matches = append(matches, map[string]interface{}{
+			"id":           record.ID,
+			"source":       record.Source,
+			"title":        record.Title,
+			"sentiment":    record.Sentiment,
+			"processed_at": record.ProcessedAt.Format(time.RFC3339),
+		})
+	}
+
+	return map[string]interface{}{
+		"event": "saved_search.matched",
+		"saved_search": map[string]interface{}{
+			"id":    search.ID,
+			"name":  search.Name,
+			"query": search.Query,
+		},
+		"matches":    matches,
+		"matched_at": time.Now().Format(time.RFC3339),
+	}
+}
+
+// buildEmailBody creates the plain-text email body for new matches
+func buildEmailBody(search database.SavedSearch, records []database.ProcessedData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your saved search \"%s\" (query: %s) has %d new matches:\n\n", search.Name, search.Query, len(records))
+	for _, record := range records {
+		fmt.Fprintf(&b, "- [%s] %s (sentiment: %s)\n", record.Source, record.Title, record.Sentiment)
+	}
+	return b.String()
+}
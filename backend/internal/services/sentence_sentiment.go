@@ -0,0 +1,58 @@
+package services
+
+import (
+	"math"
+)
+
+// polarizedSentenceScore is how far from zero a sentence must score to count
+// as clearly positive or negative when detecting polarizing texts
+const polarizedSentenceScore = 0.05
+
+// SentenceSentiment summarizes the sentiment of the individual sentences of
+// a text, so a text mixing strongly positive and negative sentences can be
+// told apart from a neutral one
+type SentenceSentiment struct {
+	Count       int     `json:"count"`        // sentences that contain words
+	MeanScore   float64 `json:"mean_score"`   // average sentence score
+	Variance    float64 `json:"variance"`     // population variance of the sentence scores
+	MaxNegative float64 `json:"max_negative"` // score of the most negative sentence
+	MaxPositive float64 `json:"max_positive"` // score of the most positive sentence
+	Polarized   bool    `json:"polarized"`    // both clearly positive and clearly negative sentences
+}
+
+// AnalyzeSentences scores every sentence of text on its own. It returns nil
+// for texts with fewer than two sentences, where the overall score says it all.
+func (sa *SentimentAnalyzer) AnalyzeSentences(text string) *SentenceSentiment {
+	var scores []float64
+	for _, sentence := range splitSentences(text) {
+		words := sa.tokenizeText(sentence)
+		if len(words) == 0 {
+			continue
+		}
+		scores = append(scores, sa.scoreWords(words).Score)
+	}
+
+	if len(scores) < 2 {
+		return nil
+	}
+
+	distribution := &SentenceSentiment{Count: len(scores)}
+	var sum float64
+	for _, score := range scores {
+		sum += score
+		distribution.MaxNegative = math.Min(distribution.MaxNegative, score)
+		distribution.MaxPositive = math.Max(distribution.MaxPositive, score)
+	}
+	distribution.MeanScore = sum / float64(len(scores))
+
+	for _, score := range scores {
+		diff := score - distribution.MeanScore
+		distribution.Variance += diff * diff
+	}
+	distribution.Variance /= float64(len(scores))
+
+	distribution.Polarized = distribution.MaxNegative <= -polarizedSentenceScore &&
+		distribution.MaxPositive >= polarizedSentenceScore
+
+	return distribution
+}
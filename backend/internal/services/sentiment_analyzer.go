@@ -3,14 +3,26 @@ package services
 import (
 	"strings"
 	"unicode"
+
+	"covid19-kms/internal/config"
 )
 
+// SentimentAnalyzerVersion identifies the current lexicon and scoring logic.
+// Bump it whenever either changes so records scored by older logic can be
+// segmented in analytics and re-scored by the sentiment cleanup.
+const SentimentAnalyzerVersion = 4
+
 // SentimentResult represents the result of sentiment analysis
 type SentimentResult struct {
 	Score      float64  `json:"score"`      // -1.0 to +1.0 (negative to positive)
 	Category   string   `json:"category"`   // "positive", "negative", "neutral"
 	Confidence float64  `json:"confidence"` // 0.0 to 1.0
 	Keywords   []string `json:"keywords"`   // Words that influenced the score
+
+	// Aspects holds per-aspect sentiment for the aspects the text mentions
+	Aspects AspectScores `json:"aspects,omitempty"`
+	// Sentences summarizes per-sentence scores of multi-sentence texts
+	Sentences *SentenceSentiment `json:"sentence_sentiment,omitempty"`
 }
 
 // SentimentAnalyzer analyzes text sentiment using keyword matching
@@ -18,11 +30,17 @@ type SentimentAnalyzer struct {
 	positiveKeywords map[string]float64
 	negativeKeywords map[string]float64
 	neutralKeywords  map[string]float64
+
+	// emojiKeywords weighs emoji and emoticons, matched case-sensitively
+	emojiKeywords map[string]float64
+	emoticons     [][]rune
 }
 
-// NewSentimentAnalyzer creates a new sentiment analyzer instance
+// NewSentimentAnalyzer creates a new sentiment analyzer instance with the
+// configured emoji weights
 func NewSentimentAnalyzer() *SentimentAnalyzer {
-	return &SentimentAnalyzer{
+	cfg, _ := config.LoadConfig()
+	sa := &SentimentAnalyzer{
 		positiveKeywords: map[string]float64{
 			// English - General Positive
 			"good": 0.7, "great": 0.8, "excellent": 0.9, "amazing": 0.9,
@@ -96,6 +114,10 @@ func NewSentimentAnalyzer() *SentimentAnalyzer {
 			"kasus": 0.0, "jumlah": 0.0, "hitung": 0.0,
 		},
 	}
+
+	sa.emojiKeywords = newEmojiLexicon(cfg.Sentiment)
+	sa.emoticons = lexiconEmoticons(sa.emojiKeywords)
+	return sa
 }
 
 // AnalyzeSentiment analyzes the sentiment of given text
@@ -110,28 +132,107 @@ func (sa *SentimentAnalyzer) AnalyzeSentiment(text string) *SentimentResult {
 	}
 
 	// Clean and tokenize text
-	words := sa.tokenizeText(text)
+	result := sa.scoreWords(sa.tokenizeText(text))
+	result.Aspects = sa.AnalyzeAspects(text)
+	result.Sentences = sa.AnalyzeSentences(text)
+
+	// Scoring per word dilutes long articles towards neutral; fall back to
+	// the sentence average when the sentences themselves lean one way
+	if result.Category == "neutral" && result.Sentences != nil {
+		if category := sentimentCategory(result.Sentences.MeanScore); category != "neutral" {
+			result.Score = result.Sentences.MeanScore
+			result.Category = category
+		}
+	}
+
+	return result
+}
+
+// Negators flip the sentiment of the next keyword within negationWindow
+// words ("tidak terlalu efektif", "not very effective"); the flipped score is
+// weakened by negationFactor, as "not good" is milder than "bad". The
+// tokenizer splits contractions, so "isn't" arrives as "isn".
+var Negators = map[string]bool{
+	"tidak": true, "tak": true, "bukan": true, "belum": true,
+	"gak": true, "nggak": true, "enggak": true, "ga": true,
+	"not": true, "no": true, "never": true,
+	"isn": true, "aren": true, "wasn": true, "don": true, "doesn": true, "didn": true,
+}
+
+// Intensifiers strengthen the next keyword within intensifierWindow words
+var Intensifiers = map[string]float64{
+	"sangat": 1.5, "amat": 1.5, "paling": 1.5, "terlalu": 1.3, "makin": 1.3, "semakin": 1.3,
+	"very": 1.5, "extremely": 1.8, "highly": 1.5, "really": 1.3, "too": 1.3,
+}
+
+// TrailingIntensifiers strengthen the keyword right before them ("baik sekali")
+var TrailingIntensifiers = map[string]float64{
+	"sekali": 1.5, "banget": 1.5,
+}
+
+const (
+	negationWindow    = 3
+	negationFactor    = -0.8
+	intensifierWindow = 2
+)
 
+// scoreWords scores already tokenized words against the lexicon, flipping
+// negated keywords and strengthening intensified ones
+func (sa *SentimentAnalyzer) scoreWords(words []string) *SentimentResult {
 	var totalScore float64
 	var foundKeywords []string
 	var positiveCount, negativeCount, neutralCount int
 
+	// Positions of the last negator, intensifier and keyword; -1 when none
+	negatedAt, intensifiedAt, lastKeywordAt := -1, -1, -1
+	var intensity, lastScore float64
+
 	// Analyze each word
-	for _, word := range words {
+	for i, word := range words {
 		wordLower := strings.ToLower(word)
 
-		// Check positive keywords
-		if score, exists := sa.positiveKeywords[wordLower]; exists {
-			totalScore += score
-			foundKeywords = append(foundKeywords, word)
-			positiveCount++
+		if Negators[wordLower] {
+			negatedAt = i
+			continue
+		}
+		if multiplier, exists := Intensifiers[wordLower]; exists {
+			intensifiedAt, intensity = i, multiplier
+			continue
+		}
+		if multiplier, exists := TrailingIntensifiers[wordLower]; exists && lastKeywordAt == i-1 {
+			totalScore += lastScore * (multiplier - 1)
+			continue
 		}
 
-		// Check negative keywords
-		if score, exists := sa.negativeKeywords[wordLower]; exists {
+		keyword := word
+		score, exists := sa.positiveKeywords[wordLower]
+		if !exists {
+			score, exists = sa.negativeKeywords[wordLower]
+		}
+		if exists {
+			if intensifiedAt >= 0 && i-intensifiedAt <= intensifierWindow {
+				score *= intensity
+				intensifiedAt = -1
+			}
+			if negatedAt >= 0 && i-negatedAt <= negationWindow {
+				score *= negationFactor
+				keyword = strings.Join(words[negatedAt:i+1], " ")
+				negatedAt = -1
+			}
+		} else {
+			// Emoji express the writer's mood, which negators do not flip
+			score, exists = sa.emojiKeywords[word]
+		}
+
+		if exists {
 			totalScore += score
-			foundKeywords = append(foundKeywords, word)
-			negativeCount++
+			foundKeywords = append(foundKeywords, keyword)
+			if score > 0 {
+				positiveCount++
+			} else if score < 0 {
+				negativeCount++
+			}
+			lastKeywordAt, lastScore = i, score
 		}
 
 		// Check neutral keywords
@@ -147,23 +248,44 @@ func (sa *SentimentAnalyzer) AnalyzeSentiment(text string) *SentimentResult {
 	return result
 }
 
-// tokenizeText splits text into words and cleans them
+// tokenizeText splits text into words at whitespace and punctuation. Emoji
+// and the emoticons of the lexicon become tokens of their own, also when
+// they are attached to a word ("mantap👍").
 func (sa *SentimentAnalyzer) tokenizeText(text string) []string {
-	// Split by whitespace and punctuation
-	words := strings.FieldsFunc(text, func(r rune) bool {
-		return unicode.IsSpace(r) || unicode.IsPunct(r)
-	})
-
-	// Clean words (remove empty strings, normalize)
-	var cleanedWords []string
-	for _, word := range words {
-		word = strings.TrimSpace(word)
-		if word != "" && len(word) > 1 { // Skip single characters
-			cleanedWords = append(cleanedWords, word)
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(string(word)) > 1 { // Skip single characters
+			tokens = append(tokens, string(word))
+		}
+		word = word[:0]
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if emoticon := sa.emoticonAt(runes, i); emoticon != "" {
+			flush()
+			tokens = append(tokens, emoticon)
+			i += len([]rune(emoticon)) - 1
+			continue
+		}
+
+		r := runes[i]
+		switch {
+		case isEmojiModifier(r):
+			flush()
+		case unicode.Is(unicode.So, r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			flush()
+		default:
+			word = append(word, r)
 		}
 	}
+	flush()
 
-	return cleanedWords
+	return tokens
 }
 
 // calculateFinalSentiment determines the final sentiment category and confidence
@@ -2,15 +2,21 @@ package services
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
+
+	"covid19-kms/database"
 )
 
 // SentimentCleanupService handles cleaning up sentiment data in the database
 type SentimentCleanupService struct {
 	db                *sql.DB
 	sentimentAnalyzer *SentimentAnalyzer
+	projectID         string
+	restart           bool
 }
 
 // CleanupResult represents the result of a sentiment cleanup operation
@@ -22,6 +28,9 @@ type CleanupResult struct {
 	ProcessingTime   time.Duration `json:"processing_time"`
 	Errors           []string      `json:"errors,omitempty"`
 	Status           string        `json:"status"`
+	ResumedFromID    int           `json:"resumed_from_id,omitempty"` // last record ID of the interrupted run that was resumed
+
+	updatedIDs []int // records rescored by a batch
 }
 
 // NewSentimentCleanupService creates a new sentiment cleanup service
@@ -29,97 +38,101 @@ func NewSentimentCleanupService(db *sql.DB) *SentimentCleanupService {
 	return &SentimentCleanupService{
 		db:                db,
 		sentimentAnalyzer: NewSentimentAnalyzer(),
+		projectID:         database.DefaultProject,
 	}
 }
 
+// ForProject restricts subsequent cleanups to records of the given project
+func (scs *SentimentCleanupService) ForProject(projectID string) *SentimentCleanupService {
+	scs.projectID = projectID
+	return scs
+}
+
+// Restart makes subsequent cleanups ignore and replace any saved checkpoint
+func (scs *SentimentCleanupService) Restart() *SentimentCleanupService {
+	scs.restart = true
+	return scs
+}
+
 // CleanAllSentiments cleans sentiment data for all records in the database
 func (scs *SentimentCleanupService) CleanAllSentiments() *CleanupResult {
 	log.Println("🧹 Starting sentiment cleanup for all records...")
+	return scs.runCleanup("all", "all records", nil, nil)
+}
+
+// CleanSentimentBySource cleans sentiment data for a specific source
+func (scs *SentimentCleanupService) CleanSentimentBySource(source string) *CleanupResult {
+	log.Printf("🧹 Starting sentiment cleanup for source: %s", source)
+	return scs.runCleanup("source:"+source, "source "+source, []string{"source = $1"}, []interface{}{source})
+}
+
+// CleanSentimentByDateRange cleans sentiment data for records within a date range
+func (scs *SentimentCleanupService) CleanSentimentByDateRange(startDate, endDate time.Time) *CleanupResult {
+	log.Printf("🧹 Starting sentiment cleanup for date range: %s to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	return scs.runCleanup(
+		"range:"+startDate.Format("2006-01-02")+":"+endDate.Format("2006-01-02"),
+		"date range",
+		[]string{"processed_at BETWEEN $1 AND $2"},
+		[]interface{}{startDate, endDate},
+	)
+}
 
+// runCleanup re-scores the records matching conditions in ID order. Batches
+// are fetched by keyset (id > last ID) and the last processed ID is persisted
+// after every batch, so an interrupted cleanup of the same scope resumes where
+// it stopped. The checkpoint is removed once the scope is fully processed.
+func (scs *SentimentCleanupService) runCleanup(scopeKey, label string, conditions []string, args []interface{}) *CleanupResult {
 	startTime := time.Now()
 	result := &CleanupResult{
 		Status: "processing",
 	}
 
-	// Get total count of records
-	totalCount, err := scs.getTotalRecordCount()
-	if err != nil {
-		result.Status = "error"
-		result.Errors = append(result.Errors, fmt.Sprintf("Failed to get record count: %v", err))
-		return result
-	}
-	result.TotalRecords = totalCount
-
-	// Process records in batches
-	batchSize := 100
-	offset := 0
+	args = append(args, scs.projectID)
+	conditions = append(conditions, "deleted_at IS NULL", fmt.Sprintf("project_id = $%d", len(args)))
+	where := strings.Join(conditions, " AND ")
 
-	for offset < totalCount {
-		// Get batch of records
-		records, err := scs.getRecordsBatch(offset, batchSize)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("Failed to get batch at offset %d: %v", offset, err))
-			offset += batchSize
-			continue
+	checkpointKey := scs.projectID + ":" + scopeKey
+	if scs.restart {
+		if err := database.DeleteCleanupCheckpoint(checkpointKey); err != nil {
+			result.Status = "error"
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to reset checkpoint: %v", err))
+			return result
 		}
-
-		// Process batch
-		batchResult := scs.processBatch(records)
-		result.ProcessedRecords += batchResult.ProcessedRecords
-		result.UpdatedRecords += batchResult.UpdatedRecords
-		result.ErrorRecords += batchResult.ErrorRecords
-		result.Errors = append(result.Errors, batchResult.Errors...)
-
-		// Log progress
-		log.Printf("📊 Processed batch: %d/%d records (%.1f%%)",
-			result.ProcessedRecords, totalCount,
-			float64(result.ProcessedRecords)/float64(totalCount)*100)
-
-		offset += batchSize
 	}
 
-	result.ProcessingTime = time.Since(startTime)
-
-	if len(result.Errors) == 0 {
-		result.Status = "completed"
-		log.Printf("✅ Sentiment cleanup completed successfully in %v", result.ProcessingTime)
-	} else {
-		result.Status = "completed_with_errors"
-		log.Printf("⚠️  Sentiment cleanup completed with %d errors in %v", len(result.Errors), result.ProcessingTime)
+	lastID, err := database.GetCleanupCheckpoint(checkpointKey)
+	if err != nil {
+		result.Status = "error"
+		result.Errors = append(result.Errors, fmt.Sprintf("Failed to load checkpoint: %v", err))
+		return result
 	}
-
-	return result
-}
-
-// CleanSentimentBySource cleans sentiment data for a specific source
-func (scs *SentimentCleanupService) CleanSentimentBySource(source string) *CleanupResult {
-	log.Printf("🧹 Starting sentiment cleanup for source: %s", source)
-
-	startTime := time.Now()
-	result := &CleanupResult{
-		Status: "processing",
+	if lastID > 0 {
+		result.ResumedFromID = lastID
+		log.Printf("⏩ Resuming sentiment cleanup for %s after record %d", label, lastID)
 	}
 
-	// Get total count of records for this source
-	totalCount, err := scs.getRecordCountBySource(source)
+	// Get count of records still to process
+	totalCount, err := scs.countRecordsAfter(where, args, lastID)
 	if err != nil {
 		result.Status = "error"
-		result.Errors = append(result.Errors, fmt.Sprintf("Failed to get record count for source %s: %v", source, err))
+		result.Errors = append(result.Errors, fmt.Sprintf("Failed to get record count for %s: %v", label, err))
 		return result
 	}
 	result.TotalRecords = totalCount
 
-	// Process records in batches
 	batchSize := 100
-	offset := 0
-
-	for offset < totalCount {
-		// Get batch of records for this source
-		records, err := scs.getRecordsBySourceBatch(source, offset, batchSize)
+	finished := false
+	days := make(map[string]time.Time)
+	for {
+		records, err := scs.getRecordsAfter(where, args, lastID, batchSize)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("Failed to get batch for source %s at offset %d: %v", source, offset, err))
-			offset += batchSize
-			continue
+			// Keep the checkpoint so the next run resumes from here
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to get batch for %s after record %d: %v", label, lastID, err))
+			break
+		}
+		if len(records) == 0 {
+			finished = true
+			break
 		}
 
 		// Process batch
@@ -128,82 +141,97 @@ func (scs *SentimentCleanupService) CleanSentimentBySource(source string) *Clean
 		result.UpdatedRecords += batchResult.UpdatedRecords
 		result.ErrorRecords += batchResult.ErrorRecords
 		result.Errors = append(result.Errors, batchResult.Errors...)
+		scs.addRescoredDays(days, batchResult.updatedIDs)
+
+		lastID = records[len(records)-1].ID
+		if err := database.SaveCleanupCheckpoint(checkpointKey, scs.projectID, lastID); err != nil {
+			log.Printf("⚠️ Failed to save cleanup checkpoint at record %d: %v", lastID, err)
+		}
 
 		// Log progress
 		log.Printf("📊 Processed batch for %s: %d/%d records (%.1f%%)",
-			source, result.ProcessedRecords, totalCount,
+			label, result.ProcessedRecords, totalCount,
 			float64(result.ProcessedRecords)/float64(totalCount)*100)
+	}
 
-		offset += batchSize
+	if finished {
+		if err := database.DeleteCleanupCheckpoint(checkpointKey); err != nil {
+			log.Printf("⚠️ Failed to remove cleanup checkpoint: %v", err)
+		}
 	}
+	scs.refreshRescoredDays(days)
 
 	result.ProcessingTime = time.Since(startTime)
 
 	if len(result.Errors) == 0 {
 		result.Status = "completed"
-		log.Printf("✅ Sentiment cleanup for %s completed successfully in %v", source, result.ProcessingTime)
+		log.Printf("✅ Sentiment cleanup for %s completed successfully in %v", label, result.ProcessingTime)
 	} else {
 		result.Status = "completed_with_errors"
-		log.Printf("⚠️  Sentiment cleanup for %s completed with %d errors in %v", source, len(result.Errors), result.ProcessingTime)
+		log.Printf("⚠️  Sentiment cleanup for %s completed with %d errors in %v", label, len(result.Errors), result.ProcessingTime)
 	}
 
 	return result
 }
 
-// CleanSentimentByDateRange cleans sentiment data for records within a date range
-func (scs *SentimentCleanupService) CleanSentimentByDateRange(startDate, endDate time.Time) *CleanupResult {
-	log.Printf("🧹 Starting sentiment cleanup for date range: %s to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+// CleanStaleSentiments re-scores records whose sentiment was produced by an
+// older analyzer version (or before versioning was introduced)
+func (scs *SentimentCleanupService) CleanStaleSentiments() *CleanupResult {
+	log.Printf("🧹 Starting sentiment cleanup for records older than analyzer version %d", SentimentAnalyzerVersion)
 
 	startTime := time.Now()
 	result := &CleanupResult{
 		Status: "processing",
 	}
 
-	// Get total count of records in date range
-	totalCount, err := scs.getRecordCountByDateRange(startDate, endDate)
+	// Get total count of stale records
+	totalCount, err := scs.getStaleRecordCount()
 	if err != nil {
 		result.Status = "error"
-		result.Errors = append(result.Errors, fmt.Sprintf("Failed to get record count for date range: %v", err))
+		result.Errors = append(result.Errors, fmt.Sprintf("Failed to get stale record count: %v", err))
 		return result
 	}
 	result.TotalRecords = totalCount
 
-	// Process records in batches
+	// Re-scored records leave the stale set, so always read from the start
 	batchSize := 100
-	offset := 0
-
-	for offset < totalCount {
-		// Get batch of records in date range
-		records, err := scs.getRecordsByDateRangeBatch(startDate, endDate, offset, batchSize)
+	days := make(map[string]time.Time)
+	for result.ProcessedRecords < totalCount {
+		records, err := scs.getStaleRecordsBatch(batchSize)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("Failed to get batch for date range at offset %d: %v", offset, err))
-			offset += batchSize
-			continue
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to get stale batch: %v", err))
+			break
+		}
+		if len(records) == 0 {
+			break
 		}
 
-		// Process batch
 		batchResult := scs.processBatch(records)
 		result.ProcessedRecords += batchResult.ProcessedRecords
 		result.UpdatedRecords += batchResult.UpdatedRecords
 		result.ErrorRecords += batchResult.ErrorRecords
 		result.Errors = append(result.Errors, batchResult.Errors...)
+		scs.addRescoredDays(days, batchResult.updatedIDs)
 
-		// Log progress
-		log.Printf("📊 Processed batch for date range: %d/%d records (%.1f%%)",
+		// Stop rather than loop forever if a whole batch failed to update
+		if batchResult.UpdatedRecords == 0 {
+			break
+		}
+
+		log.Printf("📊 Processed stale batch: %d/%d records (%.1f%%)",
 			result.ProcessedRecords, totalCount,
 			float64(result.ProcessedRecords)/float64(totalCount)*100)
-
-		offset += batchSize
 	}
+	scs.refreshRescoredDays(days)
 
 	result.ProcessingTime = time.Since(startTime)
 
 	if len(result.Errors) == 0 {
 		result.Status = "completed"
-		log.Printf("✅ Sentiment cleanup for date range completed successfully in %v", result.ProcessingTime)
+		log.Printf("✅ Stale sentiment cleanup completed successfully in %v", result.ProcessingTime)
 	} else {
 		result.Status = "completed_with_errors"
-		log.Printf("⚠️  Sentiment cleanup for date range completed with %d errors in %v", len(result.Errors), result.ProcessingTime)
+		log.Printf("⚠️  Stale sentiment cleanup completed with %d errors in %v", len(result.Errors), result.ProcessingTime)
 	}
 
 	return result
@@ -234,6 +262,7 @@ func (scs *SentimentCleanupService) processBatch(records []ProcessedDataRecord)
 			log.Printf("❌ Failed to update record %d: %v", record.ID, err)
 		} else {
 			result.UpdatedRecords++
+			result.updatedIDs = append(result.updatedIDs, record.ID)
 			log.Printf("✅ Successfully updated record %d", record.ID)
 		}
 	}
@@ -241,6 +270,34 @@ func (scs *SentimentCleanupService) processBatch(records []ProcessedDataRecord)
 	return result
 }
 
+// addRescoredDays adds the days the rescored records fall on to days
+func (scs *SentimentCleanupService) addRescoredDays(days map[string]time.Time, ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	recordDays, err := database.GetRecordDays(scs.projectID, ids)
+	if err != nil {
+		log.Printf("⚠️ Failed to find the rollup days of rescored records: %v", err)
+		return
+	}
+	for _, day := range recordDays {
+		days[day.Format("2006-01-02")] = day
+	}
+}
+
+// refreshRescoredDays rebuilds the rollups of the days records were rescored
+// on and drops the cached analytics of the project
+func (scs *SentimentCleanupService) refreshRescoredDays(days map[string]time.Time) {
+	if len(days) == 0 {
+		return
+	}
+	rescored := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		rescored = append(rescored, day)
+	}
+	RefreshAnalytics(scs.projectID, rescored)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -248,17 +305,30 @@ func min(a, b int) int {
 	return b
 }
 
-// updateRecordSentiment updates the sentiment fields for a single record
+// updateRecordSentiment updates the sentiment fields for a single record and
+// stamps rescored_at. processed_at is left alone: it keeps the record in its
+// month's partition and on its day in the trends.
 func (scs *SentimentCleanupService) updateRecordSentiment(recordID int, sentimentResult *SentimentResult) error {
 	query := `
 		UPDATE processed_data 
 		SET sentiment = $1, 
 		    sentiment_score = $2, 
 		    sentiment_confidence = $3,
-		    processed_at = $4
-		WHERE id = $5
+		    analyzer_version = $4,
+		    rescored_at = $5,
+		    processed_data = (processed_data - 'aspects' - 'sentence_sentiment') || $6::jsonb
+		WHERE id = $7
 	`
 
+	// Keep the aspect and sentence breakdowns in the stored JSON in step with the score
+	breakdown, err := json.Marshal(struct {
+		Aspects   AspectScores       `json:"aspects,omitempty"`
+		Sentences *SentenceSentiment `json:"sentence_sentiment,omitempty"`
+	}{sentimentResult.Aspects, sentimentResult.Sentences})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sentiment breakdown: %v", err)
+	}
+
 	log.Printf("🔧 Updating record %d: sentiment='%s', score=%.3f, confidence=%.3f",
 		recordID, sentimentResult.Category, sentimentResult.Score, sentimentResult.Confidence)
 
@@ -266,7 +336,9 @@ func (scs *SentimentCleanupService) updateRecordSentiment(recordID int, sentimen
 		sentimentResult.Category,
 		sentimentResult.Score,
 		sentimentResult.Confidence,
-		time.Now(),
+		SentimentAnalyzerVersion,
+		time.Now().UTC(),
+		string(breakdown),
 		recordID,
 	)
 
@@ -282,70 +354,29 @@ func (scs *SentimentCleanupService) updateRecordSentiment(recordID int, sentimen
 }
 
 // Database helper functions
-func (scs *SentimentCleanupService) getTotalRecordCount() (int, error) {
+func (scs *SentimentCleanupService) countRecordsAfter(where string, args []interface{}, afterID int) (int, error) {
 	var count int
-	err := scs.db.QueryRow("SELECT COUNT(*) FROM processed_data").Scan(&count)
+	query := fmt.Sprintf("SELECT COUNT(*) FROM processed_data WHERE %s AND id > $%d", where, len(args)+1)
+	err := scs.db.QueryRow(query, append(args, afterID)...).Scan(&count)
 	return count, err
 }
 
-func (scs *SentimentCleanupService) getRecordCountBySource(source string) (int, error) {
+func (scs *SentimentCleanupService) getStaleRecordCount() (int, error) {
 	var count int
-	err := scs.db.QueryRow("SELECT COUNT(*) FROM processed_data WHERE source = $1", source).Scan(&count)
+	err := scs.db.QueryRow("SELECT COUNT(*) FROM processed_data WHERE COALESCE(analyzer_version, 0) < $1 AND deleted_at IS NULL AND project_id = $2", SentimentAnalyzerVersion, scs.projectID).Scan(&count)
 	return count, err
 }
 
-func (scs *SentimentCleanupService) getRecordCountByDateRange(startDate, endDate time.Time) (int, error) {
-	var count int
-	err := scs.db.QueryRow("SELECT COUNT(*) FROM processed_data WHERE processed_at BETWEEN $1 AND $2", startDate, endDate).Scan(&count)
-	return count, err
-}
-
-func (scs *SentimentCleanupService) getRecordsBatch(offset, limit int) ([]ProcessedDataRecord, error) {
-	query := `
-		SELECT id, source, title, content, relevance_score, sentiment, processed_at, processed_data
-		FROM processed_data 
-		ORDER BY id 
-		LIMIT $1 OFFSET $2
-	`
-
-	rows, err := scs.db.Query(query, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var records []ProcessedDataRecord
-	for rows.Next() {
-		var record ProcessedDataRecord
-		err := rows.Scan(
-			&record.ID,
-			&record.Source,
-			&record.Title,
-			&record.Content,
-			&record.RelevanceScore,
-			&record.Sentiment,
-			&record.ProcessedAt,
-			&record.ProcessedData,
-		)
-		if err != nil {
-			return nil, err
-		}
-		records = append(records, record)
-	}
-
-	return records, nil
-}
-
-func (scs *SentimentCleanupService) getRecordsBySourceBatch(source string, offset, limit int) ([]ProcessedDataRecord, error) {
+func (scs *SentimentCleanupService) getStaleRecordsBatch(limit int) ([]ProcessedDataRecord, error) {
 	query := `
 		SELECT id, source, title, content, relevance_score, sentiment, processed_at, processed_data
-		FROM processed_data 
-		WHERE source = $1
-		ORDER BY id 
-		LIMIT $2 OFFSET $3
+		FROM processed_data
+		WHERE COALESCE(analyzer_version, 0) < $1 AND deleted_at IS NULL AND project_id = $3
+		ORDER BY id
+		LIMIT $2
 	`
 
-	rows, err := scs.db.Query(query, source, limit, offset)
+	rows, err := scs.db.Query(query, SentimentAnalyzerVersion, limit, scs.projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -373,16 +404,16 @@ func (scs *SentimentCleanupService) getRecordsBySourceBatch(source string, offse
 	return records, nil
 }
 
-func (scs *SentimentCleanupService) getRecordsByDateRangeBatch(startDate, endDate time.Time, offset, limit int) ([]ProcessedDataRecord, error) {
-	query := `
+func (scs *SentimentCleanupService) getRecordsAfter(where string, args []interface{}, afterID, limit int) ([]ProcessedDataRecord, error) {
+	query := fmt.Sprintf(`
 		SELECT id, source, title, content, relevance_score, sentiment, processed_at, processed_data
-		FROM processed_data 
-		WHERE processed_at BETWEEN $1 AND $2
-		ORDER BY id 
-		LIMIT $3 OFFSET $4
-	`
+		FROM processed_data
+		WHERE %s AND id > $%d
+		ORDER BY id
+		LIMIT $%d
+	`, where, len(args)+1, len(args)+2)
 
-	rows, err := scs.db.Query(query, startDate, endDate, limit, offset)
+	rows, err := scs.db.Query(query, append(args, afterID, limit)...)
 	if err != nil {
 		return nil, err
 	}
@@ -407,7 +438,7 @@ func (scs *SentimentCleanupService) getRecordsByDateRangeBatch(startDate, endDat
 		records = append(records, record)
 	}
 
-	return records, nil
+	return records, rows.Err()
 }
 
 // ProcessedDataRecord represents a record from the processed_data table
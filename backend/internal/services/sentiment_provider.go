@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"covid19-kms/internal/config"
+)
+
+// SentimentProvider scores the sentiment of texts in batches, returning one
+// result per text in order
+type SentimentProvider interface {
+	Name() string
+	AnalyzeBatch(texts []string) ([]*SentimentResult, error)
+}
+
+// Name identifies the keyword analyzer as a sentiment provider
+func (sa *SentimentAnalyzer) Name() string {
+	return "keyword"
+}
+
+// AnalyzeBatch scores texts with the keyword analyzer, which never fails
+func (sa *SentimentAnalyzer) AnalyzeBatch(texts []string) ([]*SentimentResult, error) {
+	return sa.AnalyzeSentimentBatch(texts), nil
+}
+
+// NewSentimentProvider creates the provider named in the configuration
+func NewSentimentProvider(cfg config.SentimentConfig) (SentimentProvider, error) {
+	switch cfg.Provider {
+	case "", "keyword":
+		return NewSentimentAnalyzer(), nil
+	case "http":
+		if cfg.APIURL == "" {
+			return nil, fmt.Errorf("SENTIMENT_API_URL is required for the http sentiment provider")
+		}
+		batchSize := cfg.BatchSize
+		if batchSize <= 0 {
+			batchSize = 32
+		}
+		return &httpSentimentProvider{
+			config:    cfg,
+			batchSize: batchSize,
+			client:    &http.Client{Timeout: cfg.Timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sentiment provider %q", cfg.Provider)
+	}
+}
+
+// NewConfiguredSentimentProvider creates the provider from the environment,
+// logging and falling back to the keyword analyzer when the configuration is
+// invalid
+func NewConfiguredSentimentProvider() SentimentProvider {
+	cfg, _ := config.LoadConfig()
+	provider, err := NewSentimentProvider(cfg.Sentiment)
+	if err != nil {
+		log.Printf("⚠️ Using the keyword sentiment analyzer: %v", err)
+		return NewSentimentAnalyzer()
+	}
+	return provider
+}
+
+// httpSentimentProvider calls a text classification model that accepts
+// {"inputs": ["...", ...]} and returns the label scores of each text, as the
+// Hugging Face inference API does: [[{"label": "positive", "score": 0.9},
+// ...], ...]. A single best label per text, [{"label": ..., "score": ...}],
+// is accepted too.
+type httpSentimentProvider struct {
+	config    config.SentimentConfig
+	batchSize int
+	client    *http.Client
+}
+
+// sentimentLabelScore is the probability a model assigns to one label
+type sentimentLabelScore struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+// Name identifies the remote model as a sentiment provider
+func (p *httpSentimentProvider) Name() string {
+	return "http"
+}
+
+// AnalyzeBatch scores texts in requests of at most batchSize texts. Any
+// failed request fails the whole call, so callers can fall back to the
+// keyword analyzer.
+func (p *httpSentimentProvider) AnalyzeBatch(texts []string) ([]*SentimentResult, error) {
+	results := make([]*SentimentResult, 0, len(texts))
+	for start := 0; start < len(texts); start += p.batchSize {
+		end := start + p.batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := p.analyze(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+// analyze requests the sentiment of one batch of texts
+func (p *httpSentimentProvider) analyze(texts []string) ([]*SentimentResult, error) {
+	body, err := json.Marshal(map[string][]string{"inputs": texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sentiment request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.config.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sentiment request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sentiment request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sentiment API returned status %d", resp.StatusCode)
+	}
+
+	var raw []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode sentiment response: %w", err)
+	}
+	if len(raw) != len(texts) {
+		return nil, fmt.Errorf("sentiment API returned %d results for %d texts", len(raw), len(texts))
+	}
+
+	results := make([]*SentimentResult, len(raw))
+	for i, item := range raw {
+		var labels []sentimentLabelScore
+		if err := json.Unmarshal(item, &labels); err != nil {
+			var label sentimentLabelScore
+			if err := json.Unmarshal(item, &label); err != nil {
+				return nil, fmt.Errorf("failed to decode sentiment result %d: %w", i, err)
+			}
+			labels = []sentimentLabelScore{label}
+		}
+		result, err := p.result(labels)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// result converts the label scores of one text: the category is the most
+// likely label, the confidence its probability, and the score the positive
+// probability minus the negative one
+func (p *httpSentimentProvider) result(labels []sentimentLabelScore) (*SentimentResult, error) {
+	result := &SentimentResult{Category: "neutral", Keywords: []string{}}
+	probabilities := make(map[string]float64)
+	for _, label := range labels {
+		category := p.category(label.Label)
+		if category == "" {
+			return nil, fmt.Errorf("sentiment API returned unknown label %q; map it with SENTIMENT_API_LABELS", label.Label)
+		}
+		probabilities[category] += label.Score
+		if label.Score > result.Confidence {
+			result.Category = category
+			result.Confidence = label.Score
+		}
+	}
+
+	result.Score = probabilities["positive"] - probabilities["negative"]
+	if len(labels) == 1 && result.Category == "negative" {
+		result.Score = -result.Confidence
+	}
+	return result, nil
+}
+
+// category maps a model label to a sentiment category, through the
+// configured labels first
+func (p *httpSentimentProvider) category(label string) string {
+	if category, ok := p.config.Labels[label]; ok {
+		return category
+	}
+	switch strings.ToLower(label) {
+	case "positive", "pos":
+		return "positive"
+	case "negative", "neg":
+		return "negative"
+	case "neutral", "neu":
+		return "neutral"
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSentimentNegationAndIntensifiers tests that negated keywords flip and
+// intensified keywords weigh more, in Indonesian and English
+func TestSentimentNegationAndIntensifiers(t *testing.T) {
+	analyzer := NewSentimentAnalyzer()
+
+	categories := map[string]string{
+		"Kebijakan ini efektif":               "positive",
+		"Kebijakan ini tidak efektif":         "negative",
+		"Kebijakan ini tidak terlalu efektif": "negative",
+		"Ini bukan masalah":                   "positive",
+		"Warga gak takut lagi":                "positive",
+		"This policy is effective":            "positive",
+		"This policy is not effective":        "negative",
+		"This policy is not very effective":   "negative",
+		"This policy isn't good":              "negative",
+		"There is no problem":                 "positive",
+	}
+	for text, expected := range categories {
+		if result := analyzer.AnalyzeSentiment(text); result.Category != expected {
+			t.Errorf("Expected %q to be %s, got %s (%.2f)", text, expected, result.Category, result.Score)
+		}
+	}
+
+	stronger := map[string]string{
+		"sangat efektif": "cukup efektif",
+		"bagus banget":   "bagus juga",
+		"very good":      "quite good",
+		"sangat buruk":   "cukup buruk",
+	}
+	for intensified, plain := range stronger {
+		intensifiedScore := analyzer.AnalyzeSentiment(intensified).Score
+		plainScore := analyzer.AnalyzeSentiment(plain).Score
+		if math.Abs(intensifiedScore) <= math.Abs(plainScore) {
+			t.Errorf("Expected %q (%.2f) to be stronger than %q (%.2f)", intensified, intensifiedScore, plain, plainScore)
+		}
+	}
+
+	if keywords := analyzer.AnalyzeSentiment("Kebijakan ini tidak efektif").Keywords; len(keywords) != 1 || keywords[0] != "tidak efektif" {
+		t.Errorf("Expected the negated phrase as keyword, got %v", keywords)
+	}
+}
+
+// TestSentimentEmoji tests that emoji and emoticons are scored, also when
+// attached to words, and that SENTIMENT_EMOJI_WEIGHT scales them
+func TestSentimentEmoji(t *testing.T) {
+	analyzer := NewSentimentAnalyzer()
+
+	categories := map[string]string{
+		"Terima kasih nakes ❤️❤️":        "positive",
+		"mantap👍":                        "positive",
+		"Semoga cepat sembuh ya :)":      "positive",
+		"Keluarga kami terpapar 😷😭":      "negative",
+		"Antre dari pagi belum dapat :(": "negative",
+		"Ket:Dinas kesehatan Jakarta":    "neutral",
+	}
+	for text, expected := range categories {
+		if result := analyzer.AnalyzeSentiment(text); result.Category != expected {
+			t.Errorf("Expected %q to be %s, got %s (%.2f, %v)", text, expected, result.Category, result.Score, result.Keywords)
+		}
+	}
+
+	if keywords := analyzer.AnalyzeSentiment("mantap👍").Keywords; len(keywords) != 1 || keywords[0] != "👍" {
+		t.Errorf("Expected the emoji as keyword, got %v", keywords)
+	}
+
+	t.Setenv("SENTIMENT_EMOJI_WEIGHT", "0")
+	if result := NewSentimentAnalyzer().AnalyzeSentiment("mantap👍"); result.Category != "neutral" {
+		t.Errorf("Expected emoji to be ignored with a weight of 0, got %s", result.Category)
+	}
+}
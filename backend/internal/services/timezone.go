@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Indonesia's zones keep no daylight saving time, so they are fixed offsets.
+// They are named after their IANA zones, which PostgreSQL also understands,
+// and do not depend on tzdata being installed.
+var (
+	// WIB (Waktu Indonesia Barat) is the zone of Jakarta and Sumatra
+	WIB = time.FixedZone("Asia/Jakarta", 7*3600)
+	// WITA (Waktu Indonesia Tengah) is the zone of Bali, Kalimantan and Sulawesi
+	WITA = time.FixedZone("Asia/Makassar", 8*3600)
+	// WIT (Waktu Indonesia Timur) is the zone of Maluku and Papua
+	WIT = time.FixedZone("Asia/Jayapura", 9*3600)
+)
+
+// indonesianZones maps the zone abbreviations and IANA names to the zones
+var indonesianZones = map[string]*time.Location{
+	"WIB":            WIB,
+	"WITA":           WITA,
+	"WIT":            WIT,
+	"ASIA/JAKARTA":   WIB,
+	"ASIA/PONTIANAK": WIB,
+	"ASIA/MAKASSAR":  WITA,
+	"ASIA/JAYAPURA":  WIT,
+}
+
+// LoadTimeZone resolves a time zone parameter: WIB, WITA or WIT, an IANA
+// name such as Asia/Jakarta, or UTC when empty
+func LoadTimeZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "UTC") {
+		return time.UTC, nil
+	}
+	if zone, ok := indonesianZones[strings.ToUpper(name)]; ok {
+		return zone, nil
+	}
+
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q; use WIB, WITA, WIT or an IANA name", name)
+	}
+	return zone, nil
+}
+
+// timestampFormats are the layouts ParseTimestamp accepts; layouts without
+// an offset are read in the zone given to it
+var timestampFormats = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 02 Jan 2006 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04",
+	"02/01/2006",
+}
+
+// ParseTimestamp parses a source timestamp. A trailing WIB, WITA or WIT, as
+// in "2021-07-01 10:00 WIB", gives the zone of a timestamp without offset;
+// timestamps with neither are in zone.
+func ParseTimestamp(value string, zone *time.Location) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if fields := strings.Fields(value); len(fields) > 1 {
+		last := strings.ToUpper(fields[len(fields)-1])
+		if last == "WIB" || last == "WITA" || last == "WIT" {
+			zone = indonesianZones[last]
+			value = strings.Join(fields[:len(fields)-1], " ")
+		}
+	}
+
+	for _, format := range timestampFormats {
+		if parsed, err := time.ParseInLocation(format, value, zone); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
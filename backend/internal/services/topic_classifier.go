@@ -0,0 +1,91 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TopicClassifierVersion identifies the topic keyword set. Bump it when
+// TopicKeywords changes so automatic tags can be told apart by version.
+const TopicClassifierVersion = 1
+
+// TopicKeywords lists the words (English and Indonesian) that mark a text as
+// being about a topic. Multi-word keywords match as phrases.
+var TopicKeywords = map[string][]string{
+	"vaccination": {
+		"vaccine", "vaccines", "vaccination", "vaccinated", "booster", "sinovac", "pfizer", "moderna", "astrazeneca",
+		"vaksin", "vaksinasi", "divaksin", "imunisasi", "dosis",
+	},
+	"restrictions": {
+		"lockdown", "curfew", "quarantine", "restrictions", "social distancing",
+		"ppkm", "psbb", "karantina", "isolasi", "pembatasan", "penyekatan", "mudik", "wfh",
+	},
+	"health_protocol": {
+		"mask", "masks", "handwashing", "sanitizer", "health protocol",
+		"masker", "prokes", "protokol kesehatan", "cuci tangan", "jaga jarak", "3m", "5m",
+	},
+	"testing": {
+		"test", "testing", "swab", "pcr", "antigen", "tracing", "positive cases",
+		"tes", "pengujian", "pelacakan", "kasus positif", "genose",
+	},
+	"healthcare": {
+		"hospital", "hospitals", "icu", "oxygen", "ventilator", "doctor", "doctors", "nurse", "nurses",
+		"rumah sakit", "oksigen", "dokter", "perawat", "nakes", "tenaga kesehatan", "puskesmas",
+	},
+	"variants": {
+		"variant", "variants", "delta", "omicron", "mutation",
+		"varian", "mutasi",
+	},
+	"economy": {
+		"economy", "economic", "business", "unemployment", "layoffs", "inflation",
+		"ekonomi", "bisnis", "umkm", "pengangguran", "phk", "bansos", "bantuan sosial",
+	},
+	"education": {
+		"school", "schools", "students", "online learning",
+		"sekolah", "siswa", "mahasiswa", "pjj", "belajar daring", "pembelajaran tatap muka", "ptm",
+	},
+	"misinformation": {
+		"hoax", "hoaxes", "misinformation", "conspiracy", "fake news",
+		"hoaks", "konspirasi", "berita palsu", "disinformasi",
+	},
+}
+
+// ClassifyTopics returns the topics a text is about, the most mentioned
+// first. Texts that match no topic keyword return nil.
+func ClassifyTopics(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	counts := make(map[string]int)
+	for _, word := range words {
+		counts[word]++
+	}
+	// Pad with spaces so phrases only match whole words
+	normalized := " " + strings.Join(words, " ") + " "
+
+	hits := make(map[string]int)
+	for topic, keywords := range TopicKeywords {
+		for _, keyword := range keywords {
+			if strings.Contains(keyword, " ") {
+				hits[topic] += strings.Count(normalized, " "+keyword+" ")
+			} else {
+				hits[topic] += counts[keyword]
+			}
+		}
+	}
+
+	var topics []string
+	for topic, count := range hits {
+		if count > 0 {
+			topics = append(topics, topic)
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if hits[topics[i]] != hits[topics[j]] {
+			return hits[topics[i]] > hits[topics[j]]
+		}
+		return topics[i] < topics[j]
+	})
+	return topics
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"covid19-kms/internal/config"
+)
+
+// ToxicityScorer rates how abusive a text is, from 0.0 (clean) to 1.0 (abusive).
+// Toxicity is separate from sentiment: "vaccine rollout is a disaster" is
+// negative but not abusive.
+type ToxicityScorer interface {
+	Score(text string) (float64, error)
+}
+
+// NewToxicityScorer creates the scorer named in the configuration. It returns
+// nil when toxicity scoring is disabled.
+func NewToxicityScorer(cfg config.ToxicityConfig) (ToxicityScorer, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "wordlist":
+		return newWordlistToxicityScorer(cfg.Wordlist), nil
+	case "http":
+		if cfg.APIURL == "" {
+			return nil, fmt.Errorf("TOXICITY_API_URL is required for the http toxicity provider")
+		}
+		return &httpToxicityScorer{
+			config: cfg,
+			client: &http.Client{Timeout: cfg.Timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown toxicity provider %q", cfg.Provider)
+	}
+}
+
+// NewConfiguredToxicityScorer creates the scorer from the environment, logging
+// and disabling scoring when the configuration is invalid
+func NewConfiguredToxicityScorer() ToxicityScorer {
+	cfg, _ := config.LoadConfig()
+	scorer, err := NewToxicityScorer(cfg.Toxicity)
+	if err != nil {
+		log.Printf("⚠️ Toxicity scoring disabled: %v", err)
+		return nil
+	}
+	return scorer
+}
+
+// defaultToxicTerms are insults and slurs common in Indonesian and English
+// comments, weighted by how reliably they signal abuse
+var defaultToxicTerms = map[string]float64{
+	// Indonesian
+	"anjing": 0.7, "anjir": 0.4, "bangsat": 0.8, "bajingan": 0.8, "brengsek": 0.7,
+	"goblok": 0.7, "goblog": 0.7, "tolol": 0.7, "bego": 0.5, "bodoh": 0.4,
+	"kampret": 0.6, "keparat": 0.7, "sialan": 0.5, "babi": 0.6, "monyet": 0.6,
+	"tai": 0.6, "taik": 0.6, "idiot": 0.6, "dungu": 0.6, "bacot": 0.6,
+	"laknat": 0.6, "biadab": 0.6, "kadrun": 0.7, "cebong": 0.7, "kampungan": 0.5,
+
+	// English
+	"stupid": 0.5, "moron": 0.7, "dumb": 0.4, "fuck": 0.8, "fucking": 0.7,
+	"shit": 0.5, "bastard": 0.8, "bitch": 0.8, "asshole": 0.8, "retard": 0.8,
+	"scum": 0.7, "sheeple": 0.6,
+}
+
+// customToxicTermWeight is the weight of terms added via TOXICITY_WORDLIST
+const customToxicTermWeight = 0.6
+
+// wordlistToxicityScorer scores texts by the abusive terms they contain
+type wordlistToxicityScorer struct {
+	terms map[string]float64
+}
+
+// newWordlistToxicityScorer creates a wordlist scorer with extra terms
+func newWordlistToxicityScorer(extra []string) *wordlistToxicityScorer {
+	terms := make(map[string]float64, len(defaultToxicTerms)+len(extra))
+	for term, weight := range defaultToxicTerms {
+		terms[term] = weight
+	}
+	for _, term := range extra {
+		terms[strings.ToLower(term)] = customToxicTermWeight
+	}
+	return &wordlistToxicityScorer{terms: terms}
+}
+
+// Score combines the weights of the terms found as independent signals, so
+// one strong insult scores high and several mild ones add up without
+// exceeding 1.0
+func (s *wordlistToxicityScorer) Score(text string) (float64, error) {
+	clean := 1.0
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isWordSeparator) {
+		if weight, ok := s.terms[word]; ok {
+			clean *= 1 - weight
+		}
+	}
+	return 1 - clean, nil
+}
+
+// isWordSeparator splits text into words on anything but letters and digits
+func isWordSeparator(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+}
+
+// httpToxicityScorer calls an external model that accepts {"text": "..."} and
+// returns {"score": 0.0-1.0}
+type httpToxicityScorer struct {
+	config config.ToxicityConfig
+	client *http.Client
+}
+
+// Score requests the toxicity of a single text
+func (s *httpToxicityScorer) Score(text string) (float64, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal toxicity request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.config.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create toxicity request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("toxicity request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("toxicity API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Score float64 `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode toxicity response: %w", err)
+	}
+	if result.Score < 0 || result.Score > 1 {
+		return 0, fmt.Errorf("toxicity API returned score %v outside 0-1", result.Score)
+	}
+	return result.Score, nil
+}
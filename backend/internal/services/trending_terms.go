@@ -0,0 +1,142 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"covid19-kms/database"
+)
+
+// trendingMinCount keeps terms mentioned by only a couple of records out of
+// the rising and falling lists
+const trendingMinCount = 3
+
+// TermTrend is a term mentioned by more or fewer records than its baseline
+// predicts
+type TermTrend struct {
+	Term          string  `json:"term"`
+	Count         int     `json:"count"`          // records mentioning the term in the window
+	Score         float64 `json:"score"`          // Count with each record decayed by its age
+	BaselineCount int     `json:"baseline_count"` // records mentioning the term in the baseline window
+	Expected      float64 `json:"expected"`       // Score the baseline rate predicts for the window
+	Growth        float64 `json:"growth"`         // smoothed ratio of Score to Expected
+}
+
+// TermTrends lists the rising and falling terms of a window
+type TermTrends struct {
+	From         string      `json:"from"` // RFC3339 start of the window
+	To           string      `json:"to"`
+	BaselineFrom string      `json:"baseline_from"`
+	HalfLife     string      `json:"half_life"`
+	Rising       []TermTrend `json:"rising"`
+	Falling      []TermTrend `json:"falling"`
+}
+
+// TrendingService compares the word frequencies of a recent window with
+// those of the window before it
+type TrendingService struct{}
+
+// NewTrendingService creates a new trending terms service
+func NewTrendingService() *TrendingService {
+	return &TrendingService{}
+}
+
+// TrendingTerms returns the terms of a project rising and falling most in the
+// window ending at to, against the baseline window before it. Records in the
+// window count less the older they are, halving every half of the window, so
+// terms that picked up at its end rank above those fading since its start.
+func (ts *TrendingService) TrendingTerms(projectID, source string, to time.Time, window, baseline time.Duration, limit int, includeDuplicates bool) (*TermTrends, error) {
+	from := to.Add(-window)
+	baselineFrom := from.Add(-baseline)
+
+	current, err := database.GetRecordWords(projectID, source, from, to, includeDuplicates)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := database.GetRecordWords(projectID, source, baselineFrom, from, includeDuplicates)
+	if err != nil {
+		return nil, err
+	}
+
+	halfLife := window / 2
+	rising, falling := RankTermTrends(current, previous, to, window, baseline, halfLife, limit)
+	return &TermTrends{
+		From:         from.Format(time.RFC3339),
+		To:           to.Format(time.RFC3339),
+		BaselineFrom: baselineFrom.Format(time.RFC3339),
+		HalfLife:     halfLife.String(),
+		Rising:       rising,
+		Falling:      falling,
+	}, nil
+}
+
+// RankTermTrends ranks the terms of the records of a window ending at to
+// against the records of the baseline window before it. A record in the
+// window weighs 0.5^(age/halfLife). The baseline count is scaled to the
+// window's length and to the weight a steady stream of records would have
+// there, so a term mentioned at its usual rate has a growth of about 1.
+func RankTermTrends(current, baseline []database.RecordWords, to time.Time, window, baselineWindow, halfLife time.Duration, limit int) (rising, falling []TermTrend) {
+	trends := make(map[string]*TermTrend)
+	trend := func(term string) *TermTrend {
+		if trends[term] == nil {
+			trends[term] = &TermTrend{Term: term}
+		}
+		return trends[term]
+	}
+
+	for _, record := range current {
+		age := to.Sub(record.At)
+		if age < 0 {
+			age = 0
+		}
+		weight := math.Pow(0.5, float64(age)/float64(halfLife))
+		for _, word := range record.Words {
+			t := trend(word)
+			t.Count++
+			t.Score += weight
+		}
+	}
+	for _, record := range baseline {
+		for _, word := range record.Words {
+			trend(word).BaselineCount++
+		}
+	}
+
+	// Average weight of records spread evenly over the window
+	ratio := float64(window) / float64(halfLife)
+	steadyWeight := (1 - math.Pow(0.5, ratio)) / (ratio * math.Ln2)
+	scale := float64(window) / float64(baselineWindow)
+
+	rising, falling = []TermTrend{}, []TermTrend{}
+	for _, t := range trends {
+		t.Expected = float64(t.BaselineCount) * scale * steadyWeight
+		t.Growth = (t.Score + 1) / (t.Expected + 1)
+		switch {
+		case t.Growth > 1 && t.Count >= trendingMinCount:
+			rising = append(rising, *t)
+		case t.Growth < 1 && float64(t.BaselineCount)*scale >= trendingMinCount:
+			falling = append(falling, *t)
+		}
+	}
+
+	sort.Slice(rising, func(i, j int) bool {
+		if rising[i].Growth != rising[j].Growth {
+			return rising[i].Growth > rising[j].Growth
+		}
+		return rising[i].Term < rising[j].Term
+	})
+	sort.Slice(falling, func(i, j int) bool {
+		if falling[i].Growth != falling[j].Growth {
+			return falling[i].Growth < falling[j].Growth
+		}
+		return falling[i].Term < falling[j].Term
+	})
+	if len(rising) > limit {
+		rising = rising[:limit]
+	}
+	if len(falling) > limit {
+		falling = falling[:limit]
+	}
+	return rising, falling
+}